@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+
+	"github.com/pachecoc/sqs-ui/internal/listener"
+	"github.com/pachecoc/sqs-ui/internal/plugin"
+	"github.com/pachecoc/sqs-ui/internal/queueref"
+	"github.com/pachecoc/sqs-ui/internal/secrets"
+	"github.com/pachecoc/sqs-ui/internal/service"
+	"github.com/pachecoc/sqs-ui/internal/settings"
+	"github.com/pachecoc/sqs-ui/internal/tracing"
+	"github.com/pachecoc/sqs-ui/internal/version"
+)
+
+const identityTimeout = 3 * time.Second
+
+// logStartup writes one record with everything a support request needs: build, effective
+// (redacted) settings, AWS identity and region, how the queue resolved and what is enabled.
+func logStartup(ctx context.Context, log *slog.Logger, cfg settings.AppConfig, awsCfg aws.Config, awsErr error, svc *service.SQSService, listen listener.Config) {
+	awsAttrs := []any{slog.String("region", awsCfg.Region)}
+	if cfg.SQSEndpoint != "" {
+		awsAttrs = append(awsAttrs, slog.String("sqs_endpoint", cfg.SQSEndpoint))
+	}
+	switch {
+	case cfg.DemoMode:
+		awsAttrs = []any{slog.String("region", svc.Region), slog.Bool("demo", true)}
+	case awsErr != nil:
+		awsAttrs = append(awsAttrs, slog.String("error", awsErr.Error()))
+	default:
+		idCtx, cancel := context.WithTimeout(ctx, identityTimeout)
+		id, err := sts.NewFromConfig(awsCfg).GetCallerIdentity(idCtx, &sts.GetCallerIdentityInput{})
+		cancel()
+		if err != nil {
+			awsAttrs = append(awsAttrs, slog.String("identity_error", err.Error()))
+		} else {
+			awsAttrs = append(awsAttrs, slog.String("account", aws.ToString(id.Account)), slog.String("arn", aws.ToString(id.Arn)))
+		}
+	}
+
+	queueAttrs := []any{slog.String("name", svc.QueueName), slog.String("url", svc.QueueURL)}
+	switch {
+	case svc.QueueName == "" && svc.QueueURL == "":
+		queueAttrs = append(queueAttrs, slog.String("status", "idle"))
+	case svc.QueueURL != "":
+		queueAttrs = append(queueAttrs, slog.String("status", "resolved"))
+	default:
+		if _, err := svc.FetchQueueURL(ctx); err != nil {
+			queueAttrs = append(queueAttrs, slog.String("status", "unresolved"), slog.String("error", err.Error()))
+		} else {
+			queueAttrs = append(queueAttrs, slog.String("status", "resolved"), slog.String("resolved_url", svc.QueueURL))
+		}
+	}
+
+	log.Info("startup",
+		slog.Group("build", "version", version.Version, "commit", version.Commit, "build_time", version.BuildTime),
+		slog.Group("aws", awsAttrs...),
+		slog.Group("queue", queueAttrs...),
+		slog.Any("features", enabledFeatures(cfg, svc, listen)),
+		slog.Any("config", settings.Effective()),
+	)
+}
+
+// enabledFeatures lists the optional features turned on by the settings.
+func enabledFeatures(cfg settings.AppConfig, svc *service.SQSService, listen listener.Config) []string {
+	features := []string{"store:" + cfg.StoreBackend, "receive_mode:" + string(svc.DefaultMode())}
+	for _, f := range []struct {
+		name string
+		on   bool
+	}{
+		{"demo_mode", cfg.DemoMode},
+		{"fault_injection", cfg.DemoMode && cfg.FaultInjection != ""},
+		{"access_log", cfg.AccessLog},
+		{"tls", listen.TLS()},
+		{"listener_file", cfg.ListenerFile != ""},
+		{"queue_reference", queueref.IsReference(cfg.QueueURL)},
+		{"secret_references", slices.ContainsFunc([]string{cfg.SlackSigningSecret, cfg.SMTPPassword, cfg.RedisURL, cfg.DigestWebhookURL, cfg.OIDCClientSecret, cfg.SessionSecret, cfg.APITokens}, secrets.IsReference)},
+		{"coordination", cfg.CoordinationEnabled},
+		{"store_encryption", cfg.StoreEncryptionKeys != "" || cfg.StoreKMSKeyID != ""},
+		{"store_retention", cfg.StoreRetention != ""},
+		{"oidc_sign_in", cfg.OIDCIssuer != ""},
+		{"api_tokens", cfg.APITokens != ""},
+		{"approvals", len(cfg.ApprovalQueues) > 0},
+		{"admin_endpoints", len(cfg.Admins) > 0},
+		{"rbac", cfg.RBACDefaultRole != ""},
+		{"cors", len(cfg.CORSAllowedOrigins) > 0},
+		{"share_links", cfg.ShareLinks},
+		{"base_path", cfg.BasePath != "" && cfg.BasePath != "/"},
+		{"maintenance_windows", cfg.MaintenanceWindows != ""},
+		{"profiles_file", cfg.ProfilesFile != ""},
+		{"exec_decoder", cfg.ExecDecoderCommand != ""},
+		{"email_notifications", cfg.SMTPHost != "" && cfg.EmailRules != ""},
+		{"digest", cfg.DigestWebhookURL != ""},
+		{"heartbeat", cfg.HeartbeatURL != ""},
+		{"slack_commands", cfg.SlackSigningSecret != ""},
+		{"depth_alerts", cfg.AlertDepthThreshold > 0},
+		{"depth_history", cfg.DepthHistoryInterval > 0 && cfg.DepthHistoryWindow > 0},
+		{"cloudwatch_metrics", cfg.CloudWatchMetrics && !cfg.DemoMode},
+		{"plugin_sinks", len(plugin.Sinks()) > 0},
+		{"update_check", cfg.UpdateCheck},
+		{"telemetry", cfg.TelemetryEnabled},
+		{"tracing", tracing.Enabled()},
+	} {
+		if f.on {
+			features = append(features, f.name)
+		}
+	}
+	return features
+}
@@ -0,0 +1,406 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+
+	"github.com/pachecoc/sqs-ui/internal/queueref"
+	"github.com/pachecoc/sqs-ui/internal/service"
+	"github.com/pachecoc/sqs-ui/internal/validate"
+)
+
+// Pipe subcommands: `sqs-ui send` reads NDJSON from stdin into SendMessageBatch, `sqs-ui
+// receive` writes received messages to stdout as NDJSON. Diagnostics go to stderr, so both
+// can sit in a shell pipeline.
+const (
+	cmdSend    = "send"
+	cmdReceive = "receive"
+)
+
+// maxPipeLine bounds one stdin line: a maximum-size body plus JSON escaping and attributes.
+const maxPipeLine = 4 * validate.MaxMessageBytes
+
+// Exit codes of the pipe subcommands. They are stable so scripts can branch on the cause.
+const (
+	exitOK           = 0
+	exitFailure      = 1 // any other failure, including messages that could not be sent
+	exitUsage        = 2
+	exitNotFound     = 3 // the queue does not exist
+	exitAccessDenied = 4 // IAM or KMS denied the call, or AWS rejected the credentials
+	exitTimeout      = 5 // -timeout or an AWS call deadline passed
+)
+
+// runCLI runs a pipe subcommand and returns the process exit code.
+func runCLI(name string, args []string) int {
+	fs := flag.NewFlagSet("sqs-ui "+name, flag.ContinueOnError)
+	queue := fs.String("queue", cmpEnv("QUEUE_URL", "QUEUE_NAME"), "queue name or URL (default $QUEUE_URL or $QUEUE_NAME)")
+	endpoint := fs.String("endpoint", os.Getenv("SQS_ENDPOINT"), "SQS endpoint replacing AWS (default $SQS_ENDPOINT)")
+	output := fs.String("output", "text", "format of errors and the summary on stderr: text or json")
+	timeout := fs.Duration("timeout", 0, "give up after this long and exit 5 (0 = no limit)")
+	raw := fs.Bool("raw", false, "send: every line is a message body instead of a JSON message")
+	linger := fs.Duration("linger", 200*time.Millisecond, "send: how long a partial batch waits for more lines")
+	limit := fs.Int("max", 0, "receive: stop after this many messages (0 = no limit)")
+	idle := fs.Duration("idle", 0, "receive: stop after no message arrived for this long (0 = keep waiting)")
+	keep := fs.Bool("keep", false, "receive: don't delete written messages; they reappear after -visibility")
+	visibility := fs.Int("visibility", 30, "receive: seconds a message stays hidden until it is written and deleted")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+	if *output != "text" && *output != "json" {
+		fmt.Fprintln(os.Stderr, "sqs-ui: -output must be text or json")
+		return exitUsage
+	}
+	if name == cmdReceive && (*visibility < 1 || *visibility > validate.MaxVisibilitySeconds) {
+		fmt.Fprintf(os.Stderr, "sqs-ui: -visibility must be 1-%d\n", validate.MaxVisibilitySeconds)
+		return exitUsage
+	}
+
+	rep := &reporter{json: *output == "json", w: os.Stderr, res: cliResult{Command: name, Queue: *queue}}
+	logOpts := &slog.HandlerOptions{Level: slog.LevelWarn}
+	log := slog.New(slog.NewTextHandler(os.Stderr, logOpts))
+	if rep.json {
+		log = slog.New(slog.NewJSONHandler(os.Stderr, logOpts))
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+
+	svc, err := openQueue(ctx, *queue, *endpoint, log)
+	if err != nil {
+		return rep.fail(err)
+	}
+	rep.res.Queue = svc.QueueName
+	if name == cmdSend {
+		return pipeSend(ctx, svc, os.Stdin, rep, *raw, *linger)
+	}
+	return pipeReceive(ctx, svc, os.Stdout, rep, *limit, *idle, *keep, int32(*visibility))
+}
+
+// cliResult is the summary of a pipe command, written as one JSON object with -output json.
+type cliResult struct {
+	Command   string        `json:"command"`
+	Queue     string        `json:"queue,omitempty"`
+	Messages  int           `json:"messages"` // sent or received
+	Failed    int           `json:"failed"`
+	Failures  []lineFailure `json:"failures,omitempty"`
+	Error     string        `json:"error,omitempty"`
+	ErrorCode string        `json:"error_code,omitempty"`
+	ExitCode  int           `json:"exit_code"`
+}
+
+type lineFailure struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+// reporter writes a pipe command's failures and summary to stderr: as text lines while it
+// runs, or with -output json as a single cliResult when it ends. stdout only ever carries
+// messages.
+type reporter struct {
+	json bool
+	w    io.Writer
+	res  cliResult
+}
+
+// lineFailed records a stdin line that was not sent.
+func (r *reporter) lineFailed(n int, err error) {
+	r.res.Failed++
+	if r.json {
+		r.res.Failures = append(r.res.Failures, lineFailure{Line: n, Error: err.Error()})
+		return
+	}
+	fmt.Fprintf(r.w, "sqs-ui: line %d: %v\n", n, err)
+}
+
+// record notes err as a cause of failure and returns it translated. The first error
+// recorded decides the exit code.
+func (r *reporter) record(err error) error {
+	err = service.TranslateAWSError(err)
+	if r.res.Error == "" {
+		r.res.Error, r.res.ExitCode = err.Error(), exitCode(err)
+		if coded, ok := err.(interface{ ErrorCode() string }); ok {
+			r.res.ErrorCode = coded.ErrorCode()
+		}
+	}
+	return err
+}
+
+// batchFailed records stdin lines lost to one failed SendMessageBatch call.
+func (r *reporter) batchFailed(lines []int, err error) {
+	err = r.record(err)
+	r.res.Failed += len(lines)
+	if r.json {
+		for _, n := range lines {
+			r.res.Failures = append(r.res.Failures, lineFailure{Line: n, Error: err.Error()})
+		}
+		return
+	}
+	fmt.Fprintf(r.w, "sqs-ui: lines %d-%d: %v\n", lines[0], lines[len(lines)-1], err)
+}
+
+// problem records err and, in text mode, prints it.
+func (r *reporter) problem(err error) {
+	err = r.record(err)
+	if !r.json {
+		fmt.Fprintln(r.w, "sqs-ui:", err)
+	}
+}
+
+// fail ends the command on err.
+func (r *reporter) fail(err error) int {
+	r.problem(err)
+	return r.finish("")
+}
+
+// finish writes the summary and returns the exit code: the recorded error's, else
+// exitFailure when some messages failed.
+func (r *reporter) finish(summary string) int {
+	if r.res.ExitCode == exitOK && r.res.Failed > 0 {
+		r.res.ExitCode = exitFailure
+	}
+	if r.json {
+		_ = json.NewEncoder(r.w).Encode(r.res)
+	} else if summary != "" {
+		fmt.Fprintln(r.w, "sqs-ui:", summary)
+	}
+	return r.res.ExitCode
+}
+
+// exitCode maps a (translated) error to the exit code scripts branch on.
+func exitCode(err error) int {
+	var awsErr *service.AWSError
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return exitTimeout
+	case errors.As(err, &awsErr):
+		switch awsErr.Kind {
+		case service.KindQueueNotFound:
+			return exitNotFound
+		case service.KindAccessDenied, service.KindKMSAccessDenied, service.KindCredentialsInvalid:
+			return exitAccessDenied
+		}
+	}
+	return exitFailure
+}
+
+// errTimeout ends a command whose -timeout passed.
+var errTimeout = fmt.Errorf("stopped by -timeout: %w", context.DeadlineExceeded)
+
+// openQueue builds the service for a queue name or URL and resolves its URL.
+func openQueue(ctx context.Context, queue, endpoint string, log *slog.Logger) (*service.SQSService, error) {
+	if queue == "" {
+		return nil, errors.New("no queue: pass -queue or set QUEUE_URL or QUEUE_NAME")
+	}
+	if endpoint != "" {
+		if err := service.ValidateEndpoint(endpoint); err != nil {
+			return nil, fmt.Errorf("invalid endpoint: %w", err)
+		}
+	}
+	awsCfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not load AWS config: %w", err)
+	}
+	if queueref.IsReference(queue) {
+		if queue, err = queueref.NewResolver(awsCfg).Resolve(ctx, queue); err != nil {
+			return nil, err
+		}
+	}
+	client := service.NewClient(awsCfg, endpoint)
+	name, url := queueref.Split(queue)
+	svc := service.NewSQSService(ctx, client, name, url, client.Options().Region, log)
+	if svc.QueueURL == "" {
+		if _, err := svc.FetchQueueURL(ctx); err != nil {
+			return nil, service.TranslateAWSError(err)
+		}
+	}
+	return svc, nil
+}
+
+// pipeLine is one stdin line with its 1-based number.
+type pipeLine struct {
+	n    int
+	text string
+}
+
+// pipeSend batches stdin lines into SendMessageBatch calls: a batch is sent when it is full
+// (count or size), when linger passed since its first line, and at the end of input.
+// Failed lines are reported by line number and make the exit code exitFailure, unless a
+// whole batch failed for a cause with its own code.
+func pipeSend(ctx context.Context, svc *service.SQSService, in io.Reader, rep *reporter, raw bool, linger time.Duration) int {
+	lines := make(chan pipeLine)
+	readErr := make(chan error, 1)
+	go func() {
+		defer close(lines)
+		sc := bufio.NewScanner(in)
+		sc.Buffer(make([]byte, 64*1024), maxPipeLine)
+		for n := 1; sc.Scan(); n++ {
+			select {
+			case lines <- pipeLine{n: n, text: sc.Text()}:
+			case <-ctx.Done():
+				return
+			}
+		}
+		readErr <- sc.Err()
+	}()
+
+	var (
+		batch   []service.PipeMessage
+		lineNos []int
+		size    int
+		timer   <-chan time.Time
+	)
+	flush := func(ctx context.Context) {
+		if len(batch) == 0 {
+			return
+		}
+		results, failures, err := svc.SendBatch(ctx, batch)
+		rep.res.Messages += len(results)
+		for _, f := range failures {
+			rep.lineFailed(lineNos[f.Index], errors.New(f.Error))
+		}
+		if err != nil {
+			var lost []int
+			for i, n := range lineNos {
+				if !slices.ContainsFunc(failures, func(f service.BatchFailure) bool { return f.Index == i }) {
+					lost = append(lost, n)
+				}
+			}
+			rep.batchFailed(lost, err)
+		}
+		batch, lineNos, size, timer = batch[:0], lineNos[:0], 0, nil
+	}
+
+loop:
+	for {
+		select {
+		case l, ok := <-lines:
+			if !ok {
+				break loop
+			}
+			if strings.TrimSpace(l.text) == "" {
+				continue
+			}
+			msg := service.PipeMessage{Body: l.text}
+			if !raw {
+				msg = service.PipeMessage{}
+				if err := json.Unmarshal([]byte(l.text), &msg); err != nil {
+					rep.lineFailed(l.n, fmt.Errorf("invalid JSON message: %w", err))
+					continue
+				}
+			}
+			n := service.BatchSize(msg)
+			if n > validate.MaxMessageBytes {
+				rep.lineFailed(l.n, fmt.Errorf("message is %d bytes, more than the %d allowed", n, validate.MaxMessageBytes))
+				continue
+			}
+			if len(batch) == validate.MaxBatchSize || size+n > validate.MaxMessageBytes {
+				flush(ctx)
+			}
+			batch, lineNos, size = append(batch, msg), append(lineNos, l.n), size+n
+			if timer == nil {
+				timer = time.After(linger)
+			}
+		case <-timer:
+			flush(ctx)
+		case <-ctx.Done():
+			break loop
+		}
+	}
+	// Lines already read are sent even when interrupted
+	flush(context.WithoutCancel(ctx))
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		rep.problem(errTimeout)
+	}
+
+	select {
+	case err := <-readErr:
+		if err != nil {
+			rep.problem(fmt.Errorf("reading stdin: %w", err))
+		}
+	default:
+	}
+	return rep.finish(fmt.Sprintf("sent %d message(s) to %s, %d failed", rep.res.Messages, svc.QueueName, rep.res.Failed))
+}
+
+// pipeReceive long-polls the queue and writes each message to out as one JSON line, then
+// deletes it (unless keep), so a message is only lost once it has been written. It runs
+// until ctx ends, limit messages were written, or nothing arrived for idle; reaching
+// -timeout first exits with exitTimeout.
+func pipeReceive(ctx context.Context, svc *service.SQSService, out io.Writer, rep *reporter, limit int, idle time.Duration, keep bool, visibility int32) int {
+	w := bufio.NewWriter(out)
+	enc := json.NewEncoder(w)
+	lastMessage := time.Now()
+
+	for limit == 0 || rep.res.Messages < limit {
+		msgs, err := svc.ReceiveBatch(ctx, visibility)
+		if ctx.Err() != nil {
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				rep.problem(errTimeout)
+			}
+			break
+		}
+		if err != nil {
+			return rep.fail(err)
+		}
+		if len(msgs) == 0 {
+			if idle > 0 && time.Since(lastMessage) >= idle {
+				break
+			}
+			continue
+		}
+		lastMessage = time.Now()
+		if limit > 0 {
+			// The rest become visible again after the visibility timeout
+			msgs = msgs[:min(len(msgs), limit-rep.res.Messages)]
+		}
+
+		handles := make([]string, 0, len(msgs))
+		for _, m := range msgs {
+			if err := enc.Encode(m); err != nil {
+				return rep.fail(fmt.Errorf("writing stdout: %w", err))
+			}
+			handles = append(handles, m.ReceiptHandle)
+		}
+		if err := w.Flush(); err != nil {
+			return rep.fail(fmt.Errorf("writing stdout: %w", err))
+		}
+		rep.res.Messages += len(msgs)
+		if keep {
+			continue
+		}
+		if _, err := svc.Delete(context.WithoutCancel(ctx), handles); err != nil {
+			return rep.fail(err)
+		}
+	}
+	return rep.finish(fmt.Sprintf("received %d message(s) from %s", rep.res.Messages, svc.QueueName))
+}
+
+// cmpEnv returns the first non-empty environment variable of keys.
+func cmpEnv(keys ...string) string {
+	for _, k := range keys {
+		if v := os.Getenv(k); v != "" {
+			return v
+		}
+	}
+	return ""
+}
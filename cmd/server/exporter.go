@@ -0,0 +1,164 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+
+	"github.com/pachecoc/sqs-ui/internal/exporter"
+	"github.com/pachecoc/sqs-ui/internal/logging"
+	"github.com/pachecoc/sqs-ui/internal/memsqs"
+	"github.com/pachecoc/sqs-ui/internal/service"
+)
+
+// cmdExporter runs sqs-ui as a Prometheus exporter only: `sqs-ui exporter` reads the counts
+// of many queues on an interval and serves them on /metrics, without the UI, API or store.
+const cmdExporter = "exporter"
+
+// runExporter runs the exporter subcommand until SIGINT/SIGTERM and returns the exit code.
+func runExporter(args []string) int {
+	demoDefault, _ := strconv.ParseBool(os.Getenv("DEMO_MODE"))
+	fs := flag.NewFlagSet("sqs-ui "+cmdExporter, flag.ContinueOnError)
+	listen := fs.String("listen", ":"+cmp.Or(os.Getenv("PORT"), "8080"), "address serving /metrics (default :$PORT or :8080)")
+	queues := fs.String("queues", os.Getenv("EXPORTER_QUEUES"), "comma-separated queue names or URLs; empty reads every queue in the account (default $EXPORTER_QUEUES)")
+	prefix := fs.String("prefix", os.Getenv("EXPORTER_QUEUE_PREFIX"), "without -queues, only read queues whose names start with this (default $EXPORTER_QUEUE_PREFIX)")
+	interval := fs.Duration("interval", time.Minute, "time between rounds of reads; SQS updates its counts about once a minute")
+	concurrency := fs.Int("concurrency", 8, "queues read at the same time")
+	endpoint := fs.String("endpoint", os.Getenv("SQS_ENDPOINT"), "SQS endpoint replacing AWS (default $SQS_ENDPOINT)")
+	demo := fs.Bool("demo", demoDefault, "read the in-memory demo queues instead of AWS (default $DEMO_MODE)")
+	statsdAddr := fs.String("statsd", defaultStatsDAddr(), "also push readings to this StatsD/DogStatsD host:port (default $STATSD_ADDRESS, or $DD_AGENT_HOST:8125)")
+	statsdPrefix := fs.String("statsd-prefix", cmp.Or(os.Getenv("STATSD_PREFIX"), "sqs."), "prefix of StatsD metric names (default $STATSD_PREFIX or sqs.)")
+	statsdFormat := fs.String("statsd-format", cmp.Or(os.Getenv("STATSD_FORMAT"), "dogstatsd"), "dogstatsd (queue and region as tags) or statsd (queue in the metric name) (default $STATSD_FORMAT)")
+	statsdTags := fs.String("statsd-tags", os.Getenv("STATSD_TAGS"), "comma-separated DogStatsD tags added to every metric, e.g. env:prod (default $STATSD_TAGS)")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+	if *interval < time.Second || *concurrency < 1 {
+		fmt.Fprintln(os.Stderr, "sqs-ui: exporter needs an -interval of at least 1s and a -concurrency of at least 1")
+		return exitUsage
+	}
+	if *endpoint != "" {
+		if err := service.ValidateEndpoint(*endpoint); err != nil {
+			fmt.Fprintln(os.Stderr, "sqs-ui: invalid endpoint:", err)
+			return exitUsage
+		}
+	}
+	var statsd *exporter.StatsD
+	if *statsdAddr != "" {
+		if *statsdFormat != "dogstatsd" && *statsdFormat != "statsd" {
+			fmt.Fprintln(os.Stderr, "sqs-ui: -statsd-format must be dogstatsd or statsd")
+			return exitUsage
+		}
+		statsd = &exporter.StatsD{Addr: *statsdAddr, Prefix: *statsdPrefix, Plain: *statsdFormat == "statsd", Tags: splitList(*statsdTags)}
+		if err := statsd.Validate(); err != nil {
+			fmt.Fprintln(os.Stderr, "sqs-ui:", err)
+			return exitUsage
+		}
+	}
+
+	log := logging.NewLogger(os.Getenv("LOG_LEVEL"))
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	var client service.SQSAPI
+	var region string
+	if *demo {
+		mem := memsqs.New()
+		if err := mem.SeedDataset(ctx, cmp.Or(os.Getenv("DEMO_DATASET"), "full"), 60); err != nil {
+			fmt.Fprintln(os.Stderr, "sqs-ui: could not seed demo queues:", err)
+			return exitFailure
+		}
+		client, region = mem, mem.Region
+		log.Warn("demo mode enabled: queues are in memory and nothing reaches AWS")
+	} else {
+		awsCfg, err := config.LoadDefaultConfig(ctx)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "sqs-ui: could not load AWS config:", err)
+			return exitFailure
+		}
+		c := service.NewClient(awsCfg, *endpoint)
+		client, region = c, c.Options().Region
+	}
+
+	exp := &exporter.Exporter{
+		Base:        service.NewSQSService(ctx, client, "", "", region, log),
+		Prefix:      *prefix,
+		Interval:    *interval,
+		Concurrency: *concurrency,
+		Queues:      splitList(*queues),
+		StatsD:      statsd,
+		Log:         log,
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", exp)
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("ok\n"))
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, _ *http.Request) {
+		if !exp.Ready() {
+			http.Error(w, "first round of reads not done yet", http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte("ok\n"))
+	})
+	server := &http.Server{
+		Addr:         *listen,
+		Handler:      mux,
+		ReadTimeout:  10 * time.Second,
+		WriteTimeout: 10 * time.Second,
+		IdleTimeout:  60 * time.Second,
+	}
+
+	go exp.Run(ctx)
+	errCh := make(chan error, 1)
+	go func() { errCh <- server.ListenAndServe() }()
+	log.Info("exporter serving /metrics", "listen", *listen, "region", region, "statsd", *statsdAddr)
+
+	select {
+	case err := <-errCh:
+		log.Error("exporter server failed", "error", err)
+		return exitFailure
+	case <-ctx.Done():
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Warn("exporter shutdown incomplete", "error", err)
+	}
+	return exitOK
+}
+
+// defaultStatsDAddr is STATSD_ADDRESS, or the Datadog agent the environment points at.
+func defaultStatsDAddr() string {
+	if addr := os.Getenv("STATSD_ADDRESS"); addr != "" {
+		return addr
+	}
+	if host := os.Getenv("DD_AGENT_HOST"); host != "" {
+		return net.JoinHostPort(host, cmp.Or(os.Getenv("DD_DOGSTATSD_PORT"), "8125"))
+	}
+	return ""
+}
+
+// splitList splits a comma-separated flag, dropping blanks.
+func splitList(s string) []string {
+	var out []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"cmp"
 	"context"
 	"fmt"
 	"log/slog"
@@ -9,19 +10,58 @@ import (
 	"os/signal"
 	"syscall"
 	"time"
+	_ "time/tzdata" // MAINTENANCE_TIMEZONE must resolve in images without /usr/share/zoneinfo
 
 	"github.com/aws/aws-sdk-go-v2/config"
-	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
 
+	"github.com/pachecoc/sqs-ui/internal/annotations"
+	"github.com/pachecoc/sqs-ui/internal/approvals"
+	"github.com/pachecoc/sqs-ui/internal/auth"
+	"github.com/pachecoc/sqs-ui/internal/coord"
+	"github.com/pachecoc/sqs-ui/internal/digest"
+	"github.com/pachecoc/sqs-ui/internal/erasure"
+	"github.com/pachecoc/sqs-ui/internal/events"
+	"github.com/pachecoc/sqs-ui/internal/faultsqs"
 	"github.com/pachecoc/sqs-ui/internal/handler"
+	"github.com/pachecoc/sqs-ui/internal/heartbeat"
+	"github.com/pachecoc/sqs-ui/internal/jobs"
+	"github.com/pachecoc/sqs-ui/internal/lifecycle"
+	"github.com/pachecoc/sqs-ui/internal/listener"
+	"github.com/pachecoc/sqs-ui/internal/locks"
 	"github.com/pachecoc/sqs-ui/internal/logging"
+	"github.com/pachecoc/sqs-ui/internal/maintenance"
+	"github.com/pachecoc/sqs-ui/internal/memsqs"
+	"github.com/pachecoc/sqs-ui/internal/metrics"
+	"github.com/pachecoc/sqs-ui/internal/notify"
+	"github.com/pachecoc/sqs-ui/internal/plugin"
+	"github.com/pachecoc/sqs-ui/internal/plugin/execdecoder"
+	"github.com/pachecoc/sqs-ui/internal/profiles"
+	"github.com/pachecoc/sqs-ui/internal/provision"
+	"github.com/pachecoc/sqs-ui/internal/queueref"
+	"github.com/pachecoc/sqs-ui/internal/rbac"
+	"github.com/pachecoc/sqs-ui/internal/retention"
+	"github.com/pachecoc/sqs-ui/internal/scratch"
+	"github.com/pachecoc/sqs-ui/internal/scripts"
+	"github.com/pachecoc/sqs-ui/internal/secrets"
 	"github.com/pachecoc/sqs-ui/internal/service"
 	"github.com/pachecoc/sqs-ui/internal/settings"
+	"github.com/pachecoc/sqs-ui/internal/shares"
+	"github.com/pachecoc/sqs-ui/internal/store"
+	"github.com/pachecoc/sqs-ui/internal/telemetry"
+	"github.com/pachecoc/sqs-ui/internal/tracing"
+	"github.com/pachecoc/sqs-ui/internal/triage"
+	"github.com/pachecoc/sqs-ui/internal/updates"
 	"github.com/pachecoc/sqs-ui/internal/version"
+	"github.com/pachecoc/sqs-ui/internal/watch"
 )
 
 func main() {
-	if handleVersionFlag() {
+	if handleFlags() {
 		return
 	}
 
@@ -38,8 +78,18 @@ func main() {
 	// Rebuild logger using configured level
 	log := logging.NewLogger(appCfg.LogLevel)
 
-	// Load AWS config (best effort)
-	awsCfg, awsErr := config.LoadDefaultConfig(ctx)
+	// OpenTelemetry tracing when OTEL_EXPORTER_OTLP_ENDPOINT is set (optional)
+	shutdownTracing, err := tracing.Setup(ctx)
+	if err != nil {
+		log.Error("could not set up tracing", "error", err)
+		os.Exit(1)
+	}
+	if tracing.Enabled() {
+		log.Info("tracing enabled", "otlp_endpoint", cmp.Or(os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"), os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")))
+	}
+
+	// Load AWS config (best effort); SDK calls are traced
+	awsCfg, awsErr := config.LoadDefaultConfig(ctx, tracing.WithAWS())
 	if awsErr != nil {
 		log.Warn("could not load AWS config", "error", awsErr)
 	} else if awsCfg.Region != "" {
@@ -48,59 +98,615 @@ func main() {
 		log.Info("aws region not set")
 	}
 
-	var sqsClient *sqs.Client
+	// Custom SQS endpoint (LocalStack, ElasticMQ) instead of AWS (optional)
+	if appCfg.SQSEndpoint != "" {
+		if err := service.ValidateEndpoint(appCfg.SQSEndpoint); err != nil {
+			log.Error("invalid SQS_ENDPOINT", "error", err)
+			os.Exit(1)
+		}
+		log.Info("using custom SQS endpoint", "endpoint", appCfg.SQSEndpoint)
+	}
+
+	// QUEUE_URL may reference an SSM parameter or CloudFormation export holding the queue
+	queueName, queueURL := appCfg.QueueName, appCfg.QueueURL
+	var queueRef *queueref.Refresher
+	if queueref.IsReference(appCfg.QueueURL) {
+		if awsErr != nil {
+			log.Error("resolving QUEUE_URL needs AWS config", "ref", appCfg.QueueURL, "error", awsErr)
+			os.Exit(1)
+		}
+		resolver := queueref.NewResolver(awsCfg)
+		value, err := resolver.Resolve(ctx, appCfg.QueueURL)
+		if err != nil {
+			log.Error("could not resolve QUEUE_URL", "ref", appCfg.QueueURL, "error", err)
+			os.Exit(1)
+		}
+		log.Info("queue reference resolved", "ref", appCfg.QueueURL, "value", value)
+		queueRef = &queueref.Refresher{Ref: appCfg.QueueURL, Resolver: resolver, Log: log, Interval: appCfg.QueueRefRefresh, Value: value}
+		queueName, queueURL = queueref.Split(value)
+	}
+
+	// A nil *sqs.Client must not end up in the interface, or the service's nil checks miss it
+	var sqsClient service.SQSAPI
+	region := awsCfg.Region
 	if awsErr == nil {
-		sqsClient = sqs.NewFromConfig(awsCfg)
+		client := service.NewClient(awsCfg, appCfg.SQSEndpoint)
+		sqsClient, region = client, client.Options().Region
+	}
+
+	// Demo mode: an in-memory SQS emulator with sample queues replaces AWS entirely
+	if appCfg.DemoMode {
+		demo := memsqs.New()
+		if err := demo.SeedDataset(ctx, appCfg.DemoDataset, appCfg.DemoDatasetSize); err != nil {
+			log.Error("could not seed demo queues", "error", err)
+			os.Exit(1)
+		}
+		sqsClient, region = demo, demo.Region
+		if queueName == "" && queueURL == "" {
+			queueName = memsqs.DemoQueue
+		}
+		log.Warn("demo mode enabled: queues are in memory and nothing reaches AWS", "queue_name", queueName, "dataset", appCfg.DemoDataset)
+
+		if appCfg.FaultInjection != "" {
+			faults, err := faultsqs.Parse(appCfg.FaultInjection)
+			if err != nil {
+				log.Error("invalid FAULT_INJECTION", "error", err)
+				os.Exit(1)
+			}
+			sqsClient = faultsqs.New(demo, uint64(appCfg.FaultInjectionSeed), faults...)
+			log.Warn("fault injection enabled: demo SQS calls fail and slow down on purpose", "rules", len(faults), "seed", appCfg.FaultInjectionSeed)
+		}
+	} else if appCfg.FaultInjection != "" {
+		log.Error("FAULT_INJECTION only applies to DEMO_MODE")
+		os.Exit(1)
 	}
 
 	// Build SQS service (idle mode if no queue config)
-	svc := buildSQSService(ctx, sqsClient, awsCfg.Region, appCfg.QueueName, appCfg.QueueURL, log)
+	svc := buildSQSService(ctx, sqsClient, region, queueName, queueURL, log)
+	mode, err := service.ParseReceiveMode(appCfg.ReceiveMode)
+	if err != nil {
+		log.Error("invalid RECEIVE_MODE", "error", err)
+		os.Exit(1)
+	}
+	svc.Mode = mode
+	if err := service.ValidateID("DEFAULT_MESSAGE_GROUP_ID", appCfg.DefaultMessageGroupID); err != nil {
+		log.Error("invalid DEFAULT_MESSAGE_GROUP_ID", "error", err)
+		os.Exit(1)
+	}
+	service.DefaultMessageGroupID = appCfg.DefaultMessageGroupID
+	service.AttributeCacheTTL = appCfg.AttributeCacheTTL
+
+	// External command decoder for specific queues (optional)
+	if appCfg.ExecDecoderCommand != "" {
+		dec, err := execdecoder.New(appCfg.ExecDecoderCommand, appCfg.ExecDecoderQueues, appCfg.ExecDecoderTimeout, appCfg.ExecDecoderRate)
+		if err != nil {
+			log.Error("invalid EXEC_DECODER_COMMAND", "error", err)
+			os.Exit(1)
+		}
+		plugin.PrependDecoder(dec)
+		log.Info("exec decoder enabled", "decoder", dec.Name(), "queues", appCfg.ExecDecoderQueues)
+	}
+
+	// Background subsystems run in one group: stopped together, and named when they fail
+	bg := lifecycle.New(ctx, log)
+
+	// Secret settings may reference Secrets Manager or SSM instead of holding the secret
+	secretLoader := secrets.NewLoader(awsCfg, log, appCfg.SecretsRefresh)
+	loadSecret := func(setting, raw string) *secrets.Value {
+		if secrets.IsReference(raw) && awsErr != nil {
+			log.Error("loading "+setting+" needs AWS config", "error", awsErr)
+			os.Exit(1)
+		}
+		v, err := secretLoader.Load(ctx, setting, raw)
+		if err != nil {
+			log.Error("could not load secret", "error", err)
+			os.Exit(1)
+		}
+		return v
+	}
+	slackSecret := loadSecret("SLACK_SIGNING_SECRET", appCfg.SlackSigningSecret)
+	smtpPassword := loadSecret("SMTP_PASSWORD", appCfg.SMTPPassword)
+	redisURL := loadSecret("REDIS_URL", appCfg.RedisURL).Get()
+	digestWebhookURL := loadSecret("DIGEST_WEBHOOK_URL", appCfg.DigestWebhookURL).Get()
+	heartbeatURL := loadSecret("HEARTBEAT_URL", appCfg.HeartbeatURL).Get()
+	heartbeatFailURL := loadSecret("HEARTBEAT_FAIL_URL", appCfg.HeartbeatFailURL).Get()
+	oidcClientSecret := loadSecret("OIDC_CLIENT_SECRET", appCfg.OIDCClientSecret)
+	sessionSecret := loadSecret("SESSION_SECRET", appCfg.SessionSecret)
+	apiTokens := loadSecret("API_TOKENS", appCfg.APITokens)
+	shareLinkSecret := loadSecret("SHARE_LINK_SECRET", appCfg.ShareLinkSecret)
+	if appCfg.SecretsRefresh > 0 {
+		bg.Go("secrets_refresh", secretLoader.Run)
+	}
+
+	// Local store for retained state (job results, artifacts)
+	storeCfg := store.Config{
+		Backend:       appCfg.StoreBackend,
+		DataDir:       appCfg.DataDir,
+		RedisURL:      redisURL,
+		RedisPrefix:   appCfg.RedisPrefix,
+		DynamoDBTable: appCfg.DynamoDBTable,
+	}
+	if appCfg.StoreBackend == store.BackendDynamoDB {
+		if awsErr != nil {
+			log.Error("STORE_BACKEND=dynamodb needs AWS config", "error", awsErr)
+			os.Exit(1)
+		}
+		storeCfg.DynamoDB = dynamodb.NewFromConfig(awsCfg)
+	}
+	st, err := store.Open(storeCfg, log)
+	if err != nil {
+		log.Error("could not open store", "error", err)
+		os.Exit(1)
+	}
+	defer st.Close()
+
+	// Store values are sealed at rest with a configured key or KMS data keys (optional)
+	switch {
+	case appCfg.StoreEncryptionKeys != "" && appCfg.StoreKMSKeyID != "":
+		log.Error("set either STORE_ENCRYPTION_KEYS or STORE_KMS_KEY_ID, not both")
+		os.Exit(1)
+	case appCfg.StoreEncryptionKeys != "":
+		keys, err := store.ParseKeys(loadSecret("STORE_ENCRYPTION_KEYS", appCfg.StoreEncryptionKeys).Get())
+		if err != nil {
+			log.Error("invalid STORE_ENCRYPTION_KEYS", "error", err)
+			os.Exit(1)
+		}
+		st = store.NewEncrypted(st, keys)
+		log.Info("store encryption enabled", "key_id", keys.CurrentID())
+	case appCfg.StoreKMSKeyID != "":
+		if awsErr != nil {
+			log.Error("STORE_KMS_KEY_ID needs AWS config", "error", awsErr)
+			os.Exit(1)
+		}
+		keys, err := store.KMSKeyring(ctx, st, kms.NewFromConfig(awsCfg), appCfg.StoreKMSKeyID, appCfg.StoreKeyRotation, log)
+		if err != nil {
+			log.Error("could not load store data keys", "kms_key_id", appCfg.StoreKMSKeyID, "error", err)
+			os.Exit(1)
+		}
+		st = store.NewEncrypted(st, keys)
+		log.Info("store encryption enabled", "kms_key_id", appCfg.StoreKMSKeyID, "key_id", keys.CurrentID())
+	}
+
+	// Multi-replica coordination through store leases (optional)
+	var elector *coord.Elector
+	var leaser store.Leaser
+	if appCfg.CoordinationEnabled {
+		l, ok := st.(store.Leaser)
+		if !ok {
+			log.Error("store backend does not support leases", "backend", appCfg.StoreBackend)
+			os.Exit(1)
+		}
+		leaser = l
+		elector = coord.NewElector(leaser, appCfg.LeaseTTL, log)
+		bg.Go("leader_election", elector.Run)
+	}
+
+	// Retention policies bound what the store keeps; the janitor also drops expired records
+	retentionPolicies, err := retention.ParsePolicies(appCfg.StoreRetention)
+	if err != nil {
+		log.Error("invalid STORE_RETENTION", "error", err)
+		os.Exit(1)
+	}
+	janitor := &retention.Janitor{
+		Store:    st,
+		Policies: retentionPolicies,
+		Log:      log,
+		Interval: appCfg.StoreJanitorInterval,
+		Leader:   elector.IsLeader,
+	}
+	if appCfg.StoreJanitorInterval > 0 {
+		bg.Go("store_janitor", janitor.Run)
+	}
+
+	// Per-queue profiles override global settings whenever their queue is selected
+	profileMgr := &profiles.Manager{Store: st}
+	if appCfg.ProfilesFile != "" {
+		if profileMgr.Defaults, err = profiles.LoadFile(appCfg.ProfilesFile); err != nil {
+			log.Error("could not load PROFILES_FILE", "error", err)
+			os.Exit(1)
+		}
+		log.Info("queue profiles loaded", "file", appCfg.ProfilesFile, "count", len(profileMgr.Defaults))
+	}
+	svc.Configure = profileMgr.Configure
+	profileMgr.Configure(svc)
 
 	// HTTP routing
 	mux := http.NewServeMux()
 	api := handler.NewAPIHandler(svc, log)
+	api.Profiles = profileMgr
+	api.InfoStreamInterval = appCfg.InfoStreamInterval
+	api.Events = events.NewHub(log)
+	api.Store = st
+	api.Retention = janitor
+	api.Annotations = &annotations.Manager{Store: st}
+	api.Triage = &triage.Manager{Store: st}
+	api.Locks = &locks.Manager{Store: st}
+	api.Scripts = &scripts.Manager{Store: st, Timeout: appCfg.ScriptTimeout}
+	api.Subsystems = bg
+	api.UserHeader = appCfg.UserHeader
+	if appCfg.OIDCIssuer != "" {
+		if appCfg.OIDCClientID == "" || appCfg.OIDCRedirectURL == "" {
+			log.Error("OIDC_ISSUER needs OIDC_CLIENT_ID and OIDC_REDIRECT_URL")
+			os.Exit(1)
+		}
+		oidcAuth, err := auth.New(ctx, auth.Config{
+			Issuer:        appCfg.OIDCIssuer,
+			ClientID:      appCfg.OIDCClientID,
+			ClientSecret:  oidcClientSecret,
+			RedirectURL:   appCfg.OIDCRedirectURL,
+			Scopes:        appCfg.OIDCScopes,
+			UserClaim:     appCfg.OIDCUserClaim,
+			SessionSecret: sessionSecret,
+			SessionTTL:    appCfg.SessionTTL,
+		}, log)
+		if err != nil {
+			log.Error("could not set up OIDC sign-in", "error", err)
+			os.Exit(1)
+		}
+		api.Auth = oidcAuth
+	}
+	if apiTokens.IsSet() {
+		api.Tokens = &auth.Tokens{Source: apiTokens}
+		if err := api.Tokens.Validate(); err != nil {
+			log.Error("invalid API_TOKENS", "error", err)
+			os.Exit(1)
+		}
+	}
+	api.RequestTimeout = appCfg.RequestTimeout
+	api.SQSEndpoint = appCfg.SQSEndpoint
+	if appCfg.DemoMode {
+		api.Client = sqsClient
+	}
+	api.ReconnectHint = appCfg.ReconnectHint
+	if len(appCfg.ApprovalQueues) > 0 {
+		api.Approvals = &approvals.Manager{
+			Store:     st,
+			TTL:       appCfg.ApprovalTTL,
+			Queues:    appCfg.ApprovalQueues,
+			Approvers: appCfg.Approvers,
+		}
+		log.Info("two-person approval enabled", "queues", appCfg.ApprovalQueues, "ttl_minutes", appCfg.ApprovalTTL.Minutes())
+	}
+	if appCfg.MaintenanceWindows != "" {
+		rules, err := maintenance.ParseRules(appCfg.MaintenanceWindows)
+		if err != nil {
+			log.Error("invalid MAINTENANCE_WINDOWS", "error", err)
+			os.Exit(1)
+		}
+		loc, err := time.LoadLocation(appCfg.MaintenanceTimezone)
+		if err != nil {
+			log.Error("invalid MAINTENANCE_TIMEZONE", "error", err)
+			os.Exit(1)
+		}
+		api.Maintenance = &maintenance.Policy{Rules: rules, Location: loc, AllowOverride: appCfg.MaintenanceOverride}
+		log.Info("maintenance windows enabled", "rules", len(rules), "timezone", loc.String(), "allow_override", appCfg.MaintenanceOverride)
+	}
+	namingRules, err := provision.ParseRules(appCfg.QueueNameRules)
+	if err != nil {
+		log.Error("invalid QUEUE_NAME_RULES", "error", err)
+		os.Exit(1)
+	}
+	api.Provisioner = &provision.Provisioner{NamingRules: namingRules}
+	if awsErr == nil && !appCfg.DemoMode {
+		api.Provisioner.Permissions = &provision.IAMChecker{IAM: iam.NewFromConfig(awsCfg), STS: sts.NewFromConfig(awsCfg)}
+	}
+	if appCfg.CloudWatchMetrics && !appCfg.DemoMode {
+		if awsErr != nil {
+			log.Error("CLOUDWATCH_METRICS_ENABLED needs AWS config", "error", awsErr)
+			os.Exit(1)
+		}
+		api.CloudWatch = &metrics.Fetcher{API: cloudwatch.NewFromConfig(awsCfg)}
+	}
+	if !scratch.PrefixPattern.MatchString(appCfg.ScratchPrefix) {
+		log.Error("invalid SCRATCH_QUEUE_PREFIX", "prefix", appCfg.ScratchPrefix)
+		os.Exit(1)
+	}
+	api.Scratch = &scratch.Manager{
+		Service:    api.CurrentService,
+		Log:        log,
+		Prefix:     appCfg.ScratchPrefix,
+		DefaultTTL: appCfg.ScratchTTL,
+		MaxTTL:     max(appCfg.ScratchMaxTTL, appCfg.ScratchTTL),
+		Interval:   appCfg.ScratchReapInterval,
+		Leader:     elector.IsLeader,
+	}
+	api.SlackSigningSecret = slackSecret
+	api.Jobs = jobs.NewManager(appCfg.JobWorkers, appCfg.JobQueueConcurrency, api.Events, log)
+	api.Jobs.Store = st
+	api.Jobs.ResultTTL = appCfg.JobResultTTL
+	jobs.RegisterDefaults(api.Jobs)
+	jobs.RegisterTransfers(api.Jobs, api.Scripts)
+	jobs.RegisterCleanup(api.Jobs)
+	if leaser != nil {
+		api.Jobs.Leaser = leaser
+		api.Jobs.Owner = elector.Owner
+		api.Jobs.LeaseTTL = appCfg.LeaseTTL
+	}
+	if appCfg.UpdateCheck {
+		if err := service.ValidateEndpoint(appCfg.UpdateCheckURL); err != nil {
+			log.Error("invalid UPDATE_CHECK_URL", "error", err)
+			os.Exit(1)
+		}
+		api.Updates = &updates.Checker{URL: appCfg.UpdateCheckURL, Current: version.Version, TTL: appCfg.UpdateCheckTTL}
+	}
+	api.PurgeConfirmTTL = appCfg.PurgeConfirmTTL
+	if appCfg.ShareLinks {
+		// Falls back to SESSION_SECRET, so one secret shared by the replicas covers both
+		shareSecret := shareLinkSecret
+		if !shareSecret.IsSet() {
+			shareSecret = sessionSecret
+		}
+		if !shareSecret.IsSet() {
+			log.Warn("SHARE_LINK_SECRET is not set; share links stop working on restart and aren't shared between replicas")
+		}
+		api.Shares = &shares.Manager{Store: st, Secret: shareSecret}
+		api.ShareMaxTTL = appCfg.ShareLinkMaxTTL
+		log.Info("share links enabled", "max_ttl", appCfg.ShareLinkMaxTTL)
+	}
+	api.Admins = appCfg.Admins
+	if appCfg.RBACDefaultRole != "" {
+		role, err := rbac.ParseRole(appCfg.RBACDefaultRole)
+		if err != nil {
+			log.Error("invalid RBAC_DEFAULT_ROLE", "error", err)
+			os.Exit(1)
+		}
+		api.RBAC = &rbac.Policy{
+			Viewers:   appCfg.RBACViewers,
+			Operators: appCfg.RBACOperators,
+			Admins:    appCfg.Admins,
+			Default:   role,
+			Header:    appCfg.RBACRoleHeader,
+		}
+		log.Info("role-based access control enabled", "default_role", role.String(), "viewers", len(appCfg.RBACViewers),
+			"operators", len(appCfg.RBACOperators), "admins", len(appCfg.Admins), "role_header", appCfg.RBACRoleHeader)
+	}
+	api.Eraser = &erasure.Eraser{Store: st, ForgetJob: api.Jobs.Forget}
+	bg.Go("jobs", api.Jobs.Run)
+
+	// Anonymous usage counts, only when explicitly enabled with an endpoint to send them to
+	if appCfg.TelemetryEnabled {
+		if err := service.ValidateEndpoint(appCfg.TelemetryEndpoint); err != nil {
+			log.Error("TELEMETRY_ENABLED needs a valid TELEMETRY_ENDPOINT", "error", err)
+			os.Exit(1)
+		}
+		api.Telemetry = &telemetry.Reporter{
+			Endpoint: appCfg.TelemetryEndpoint,
+			Interval: appCfg.TelemetryInterval,
+			Log:      log,
+		}
+	}
 	api.RegisterRoutes(mux)
-	mux.Handle("/", http.FileServer(http.Dir("./web")))
+	registerUI(mux, api, appCfg.WebDir, log)
 
-	server := &http.Server{
-		Addr:         ":" + appCfg.Port,
-		Handler:      mux,
+	var root http.Handler = mux
+	if api.Auth != nil || api.Tokens != nil {
+		root = api.Authenticate(root)
+	}
+	if len(appCfg.CORSAllowedOrigins) > 0 {
+		cors := handler.CORSConfig{
+			AllowedOrigins:   appCfg.CORSAllowedOrigins,
+			AllowedMethods:   appCfg.CORSAllowedMethods,
+			AllowedHeaders:   appCfg.CORSAllowedHeaders,
+			AllowCredentials: appCfg.CORSAllowCredentials,
+			MaxAge:           appCfg.CORSMaxAge,
+		}
+		if err := cors.Validate(); err != nil {
+			log.Error("invalid CORS settings", "error", err)
+			os.Exit(1)
+		}
+		root = handler.CORS(cors, root)
+		log.Info("CORS enabled", "origins", appCfg.CORSAllowedOrigins, "credentials", appCfg.CORSAllowCredentials)
+	}
+	if appCfg.AccessLog {
+		root = handler.AccessLog(log, root)
+	}
+	root = handler.RequestMeta(root)
+	if tracing.Enabled() {
+		root = tracing.Middleware(root)
+	}
+	basePath, err := handler.CleanBasePath(appCfg.BasePath)
+	if err != nil {
+		log.Error("invalid BASE_PATH", "error", err)
+		os.Exit(1)
+	}
+	if basePath != "" {
+		root = handler.BasePath(basePath, root)
+		log.Info("serving under a base path", "base_path", basePath)
+	}
+	server := &listener.Manager{
+		Handler:      root,
+		Log:          log,
 		ReadTimeout:  10 * time.Second,
-		WriteTimeout: 10 * time.Second,
+		WriteTimeout: max(10*time.Second, appCfg.RequestTimeout+2*time.Second),
 		IdleTimeout:  60 * time.Second,
+		DrainTimeout: appCfg.ShutdownTimeout,
 	}
 
-	// Start server
-	go func() {
-		log.Info("starting server", "port", appCfg.Port)
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Error("server error", "error", err)
+	// Background watcher feeding notifications into the events stream
+	watcher := &watch.Watcher{
+		Service:        api.CurrentService,
+		Events:         api.Events,
+		Log:            log,
+		Interval:       appCfg.WatchInterval,
+		DepthThreshold: appCfg.AlertDepthThreshold,
+		Leader:         elector.IsLeader,
+	}
+	bg.Go("watcher", watcher.Run)
+
+	api.InFlight = &watch.InFlightTracker{
+		Service:  api.CurrentService,
+		Log:      log,
+		Interval: appCfg.InFlightInterval,
+		Window:   appCfg.InFlightWindow,
+	}
+	bg.Go("inflight_sampler", api.InFlight.Run)
+
+	// Depth history for sparklines, so page loads don't each read queue attributes
+	if appCfg.DepthHistoryInterval > 0 && appCfg.DepthHistoryWindow > 0 {
+		api.Depth = &watch.DepthPoller{
+			Service:  api.CurrentService,
+			Log:      log,
+			Interval: appCfg.DepthHistoryInterval,
+			Window:   appCfg.DepthHistoryWindow,
+		}
+		bg.Go("depth_history", api.Depth.Run)
+	}
+
+	// A referenced queue is re-resolved, and followed while it is still the one selected
+	if queueRef != nil && appCfg.QueueRefRefresh > 0 {
+		queueRef.Current, queueRef.Switch = api.CurrentService, api.SwitchQueue
+		bg.Go("queue_reference", queueRef.Run)
+	}
+
+	// Scratch queues are deleted once their TTL tag has passed
+	bg.Go("scratch_reaper", api.Scratch.Run)
+
+	// Notifications: email rules and plugin sinks (optional)
+	dispatcher := &notify.Dispatcher{Events: api.Events, Sinks: plugin.Sinks(), Log: log}
+	if appCfg.SMTPHost != "" && appCfg.EmailRules != "" {
+		rules, err := notify.ParseEmailRules(appCfg.EmailRules)
+		if err != nil {
+			log.Error("invalid EMAIL_RULES", "error", err)
 			os.Exit(1)
 		}
-	}()
+		for eventType, to := range rules {
+			dispatcher.Rules = append(dispatcher.Rules, notify.Rule{
+				EventType: eventType,
+				Notifier: &notify.Email{
+					Host:     appCfg.SMTPHost,
+					Port:     appCfg.SMTPPort,
+					Username: appCfg.SMTPUsername,
+					Password: smtpPassword,
+					From:     appCfg.SMTPFrom,
+					To:       to,
+				},
+			})
+		}
+	}
+	if len(dispatcher.Rules) > 0 || len(dispatcher.Sinks) > 0 {
+		bg.Go("notifications", dispatcher.Run)
+	}
+
+	// Periodic queue health digest posted to Slack/Teams (optional)
+	if appCfg.DigestWebhookURL != "" {
+		api.Digest = &digest.Digester{
+			Service:    api.CurrentService,
+			Notifier:   &notify.Webhook{URL: digestWebhookURL},
+			Log:        log,
+			Interval:   appCfg.DigestInterval,
+			Queues:     appCfg.DigestQueues,
+			StaleAfter: appCfg.DigestStaleAfter,
+			Leader:     elector.IsLeader,
+		}
+		bg.Go("digest", api.Digest.Run)
+	}
+
+	// Dead-man switch ping while SQS and the subsystems are healthy (optional)
+	if appCfg.HeartbeatURL != "" {
+		hb := &heartbeat.Publisher{
+			URL:        heartbeatURL,
+			FailURL:    heartbeatFailURL,
+			Interval:   appCfg.HeartbeatInterval,
+			Service:    api.CurrentService,
+			Subsystems: bg,
+			Log:        log,
+		}
+		bg.Go(heartbeat.Name, hb.Run)
+	}
 
-	// Wait for termination
-	<-ctx.Done()
-	log.Info("shutting down", "timeout_seconds", 3)
+	// Start server; SIGHUP re-reads LISTENER_FILE and the TLS certificate
+	listenCfg := listener.Config{Port: appCfg.Port, TLSCertFile: appCfg.TLSCertFile, TLSKeyFile: appCfg.TLSKeyFile}
+	startCfg, err := listener.LoadFile(appCfg.ListenerFile, listenCfg)
+	if err != nil {
+		log.Error("could not load LISTENER_FILE", "error", err)
+		os.Exit(1)
+	}
+	logStartup(ctx, log, appCfg, awsCfg, awsErr, svc, startCfg)
+	if api.Telemetry != nil {
+		api.Telemetry.Features = enabledFeatures(appCfg, svc, startCfg)
+		bg.Go("telemetry", api.Telemetry.Run)
+	}
+	if err := server.Start(startCfg); err != nil {
+		log.Error("server error", "error", err)
+		os.Exit(1)
+	}
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
 
-	shutdownCtx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	// Wait for termination, or a failed subsystem
+wait:
+	for {
+		select {
+		case <-ctx.Done():
+			break wait
+		case <-bg.Failed():
+			log.Error("background subsystem failed, shutting down", "error", bg.Err())
+			break wait
+		case err := <-server.Err():
+			log.Error("server error", "error", err)
+			os.Exit(1)
+		case <-hup:
+			cfg, err := listener.LoadFile(appCfg.ListenerFile, listenCfg)
+			if err == nil {
+				err = server.Reload(cfg)
+			}
+			if err != nil {
+				log.Error("listener reload failed, keeping current settings", "error", err)
+			}
+		}
+	}
+	log.Info("shutting down", "timeout_seconds", appCfg.ShutdownTimeout.Seconds())
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), appCfg.ShutdownTimeout)
 	defer cancel()
 
+	// Tell stream clients to reconnect elsewhere, then stop accepting requests
+	api.DrainStreams()
 	if err := server.Shutdown(shutdownCtx); err != nil {
 		log.Error("graceful shutdown failed", "error", err)
 		os.Exit(1)
 	}
 
+	// Subsystems were canceled with ctx; give them the rest of the budget to finish (running
+	// jobs save what they have, telemetry sends its last report)
+	_ = bg.Stop(shutdownCtx)
+	if running := bg.Running(); len(running) > 0 {
+		log.Warn("background subsystems did not stop before the shutdown timeout", "running", running)
+	}
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		log.Warn("could not flush traces", "error", err)
+	}
+
+	if err := bg.Err(); err != nil {
+		log.Error("shutdown complete after a subsystem failure", "error", err)
+		os.Exit(1)
+	}
 	log.Info("shutdown complete")
 }
 
-func handleVersionFlag() bool {
+func handleFlags() bool {
 	if len(os.Args) > 1 {
 		switch os.Args[1] {
 		case "--version", "-v":
 			fmt.Printf("Version: %s\nCommit: %s\nBuilt: %s\n",
 				version.Version, version.Commit, version.BuildTime)
 			return true
+		case "--print-default-config":
+			if err := settings.WriteDefaults(os.Stdout); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			return true
+		case cmdSend, cmdReceive:
+			if code := runCLI(os.Args[1], os.Args[2:]); code != 0 {
+				os.Exit(code)
+			}
+			return true
+		case cmdSoak:
+			os.Exit(runSoak(os.Args[2:]))
+		case cmdExporter:
+			os.Exit(runExporter(os.Args[2:]))
 		}
 	}
 	return false
@@ -108,7 +714,7 @@ func handleVersionFlag() bool {
 
 func buildSQSService(
 	ctx context.Context,
-	client *sqs.Client,
+	client service.SQSAPI,
 	region string,
 	queueName string,
 	queueURL string,
@@ -0,0 +1,15 @@
+package main
+
+// Extensions are compiled in by importing their packages here. Proprietary extensions can
+// live in a separate file behind a build tag, e.g. plugins_acme.go:
+//
+//	//go:build acme
+//
+//	package main
+//
+//	import _ "example.com/acme/sqsui-decoders"
+//
+// and be built with `go build -tags acme ./cmd/server`.
+import (
+	_ "github.com/pachecoc/sqs-ui/internal/plugin/builtin"
+)
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+
+	"github.com/pachecoc/sqs-ui/internal/memsqs"
+	"github.com/pachecoc/sqs-ui/internal/service"
+)
+
+// cmdSoak is a maintainer-only subcommand, left out of the usage text: `sqs-ui soak` runs
+// send/receive/delete cycles against the in-memory backend and fails when goroutines or
+// the heap keep growing, to catch leaks in the receive loop before they reach production.
+const cmdSoak = "soak"
+
+// soakWarmup samples are dropped before leak detection: pools and caches fill up first.
+const soakWarmup = 2
+
+// soakHeapGrowth is how much the heap must grow, beyond never shrinking, to count as a
+// leak; bounded caches (poll history, resolved URLs) add a few objects as they fill.
+const soakHeapGrowth = 1.10
+
+// soakSample is one reading of the runtime, taken after a forced GC.
+type soakSample struct {
+	At          time.Duration
+	Goroutines  int
+	HeapObjects uint64
+	HeapInuse   uint64
+	Cycles      int64
+}
+
+// runSoak runs the soak subcommand and returns the process exit code.
+func runSoak(args []string) int {
+	fs := flag.NewFlagSet("sqs-ui "+cmdSoak, flag.ContinueOnError)
+	duration := fs.Duration("duration", 2*time.Minute, "how long to run")
+	interval := fs.Duration("sample", 5*time.Second, "how often goroutines and heap are sampled")
+	workers := fs.Int("workers", 4, "concurrent send/receive loops")
+	batch := fs.Int("batch", 10, "messages sent per cycle")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+	if *workers < 1 || *batch < 1 || *interval <= 0 || *duration < time.Duration(soakWarmup+3)**interval {
+		fmt.Fprintln(os.Stderr, "sqs-ui: soak needs -workers and -batch of at least 1 and a -duration of at least 5 -sample intervals")
+		return exitUsage
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	ctx, cancel := context.WithTimeout(ctx, *duration)
+	defer cancel()
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	client := memsqs.New()
+	out, err := client.CreateQueue(ctx, &sqs.CreateQueueInput{QueueName: aws.String("soak")})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "sqs-ui:", err)
+		return exitFailure
+	}
+	svc := service.NewSQSService(ctx, client, "soak", aws.ToString(out.QueueUrl), client.Region, log)
+	svc.WaitSeconds = 1
+
+	var cycles, failures atomic.Int64
+	var wg sync.WaitGroup
+	for range *workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				if err := soakCycle(ctx, svc, *batch); err != nil && ctx.Err() == nil {
+					failures.Add(1)
+					continue
+				}
+				cycles.Add(1)
+			}
+		}()
+	}
+
+	start := time.Now()
+	var samples []soakSample
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+	for done := false; !done; {
+		select {
+		case <-ctx.Done():
+			done = true
+		case <-ticker.C:
+			s := readSoakSample(time.Since(start), cycles.Load())
+			if ctx.Err() != nil {
+				done = true // workers are stopping; the reading would look like a drop
+				continue
+			}
+			samples = append(samples, s)
+			fmt.Fprintf(os.Stderr, "%6s goroutines=%d heap_objects=%d heap_inuse=%d cycles=%d\n",
+				s.At.Truncate(time.Second), s.Goroutines, s.HeapObjects, s.HeapInuse, s.Cycles)
+		}
+	}
+	wg.Wait()
+
+	if n := failures.Load(); n > 0 {
+		fmt.Fprintf(os.Stderr, "sqs-ui: %d of %d cycles failed\n", n, n+cycles.Load())
+		return exitFailure
+	}
+	if len(samples) <= soakWarmup+2 {
+		fmt.Fprintln(os.Stderr, "sqs-ui: soak stopped before enough samples were taken")
+		return exitFailure
+	}
+	samples = samples[soakWarmup:]
+	leaks := 0
+	if growing(samples, 1, func(s soakSample) uint64 { return uint64(s.Goroutines) }) {
+		fmt.Fprintf(os.Stderr, "LEAK goroutines grew from %d to %d\n", samples[0].Goroutines, samples[len(samples)-1].Goroutines)
+		leaks++
+	}
+	if growing(samples, soakHeapGrowth, func(s soakSample) uint64 { return s.HeapObjects }) {
+		fmt.Fprintf(os.Stderr, "LEAK heap objects grew from %d to %d\n", samples[0].HeapObjects, samples[len(samples)-1].HeapObjects)
+		leaks++
+	}
+	if leaks > 0 {
+		return exitFailure
+	}
+	fmt.Fprintf(os.Stderr, "OK %d cycles, no sustained goroutine or heap growth\n", cycles.Load())
+	return exitOK
+}
+
+// soakCycle sends batch messages, lists the queue as the UI does (observe), then consumes
+// and deletes what it finds.
+func soakCycle(ctx context.Context, svc *service.SQSService, batch int) error {
+	for i := range batch {
+		if _, err := svc.Send(ctx, fmt.Sprintf(`{"soak":%d}`, i), service.SendOptions{}); err != nil {
+			return err
+		}
+	}
+	if _, err := svc.Receive(ctx, service.ModeObserve); err != nil {
+		return err
+	}
+	msgs, err := svc.Receive(ctx, service.ModeConsume)
+	if err != nil {
+		return err
+	}
+	handles := make([]string, 0, len(msgs))
+	for _, m := range msgs {
+		if h, ok := m["receipt_handle"].(string); ok {
+			handles = append(handles, h)
+		}
+	}
+	if len(handles) == 0 {
+		return nil
+	}
+	_, err = svc.Delete(ctx, handles)
+	return err
+}
+
+func readSoakSample(at time.Duration, cycles int64) soakSample {
+	runtime.GC()
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	return soakSample{At: at, Goroutines: runtime.NumGoroutine(), HeapObjects: m.HeapObjects, HeapInuse: m.HeapInuse, Cycles: cycles}
+}
+
+// growing reports whether a value never dropped between samples and ended more than factor
+// times higher than it started. A steady workload without leaks plateaus or fluctuates.
+func growing(samples []soakSample, factor float64, value func(soakSample) uint64) bool {
+	for i := 1; i < len(samples); i++ {
+		if value(samples[i]) < value(samples[i-1]) {
+			return false
+		}
+	}
+	first, last := value(samples[0]), value(samples[len(samples)-1])
+	return last > first && float64(last) > float64(first)*factor
+}
@@ -0,0 +1,30 @@
+//go:build !noui
+
+package main
+
+import (
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"os"
+
+	"github.com/pachecoc/sqs-ui/internal/handler"
+	"github.com/pachecoc/sqs-ui/web"
+)
+
+// registerUI serves the server-rendered fallback under /ui/ (templates are embedded) and
+// the web UI, embedded in the binary. webDir, when set, serves the UI from disk instead so
+// edits show up without a rebuild; a missing webDir falls back to the embedded copy.
+func registerUI(mux *http.ServeMux, api *handler.APIHandler, webDir string, log *slog.Logger) {
+	handler.NewUIHandler(api, log).RegisterRoutes(mux)
+	var assets fs.FS = web.Assets
+	if webDir != "" {
+		if info, err := os.Stat(webDir); err != nil || !info.IsDir() {
+			log.Warn("WEB_DIR not found, serving the embedded web UI", "web_dir", webDir)
+		} else {
+			assets = os.DirFS(webDir)
+			log.Info("serving the web UI from disk", "web_dir", webDir)
+		}
+	}
+	mux.Handle("/", handler.NewStaticHandler(assets, log))
+}
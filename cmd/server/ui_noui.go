@@ -0,0 +1,15 @@
+//go:build noui
+
+package main
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/pachecoc/sqs-ui/internal/handler"
+)
+
+// registerUI is a no-op in headless builds (-tags noui): only the JSON API is served.
+func registerUI(_ *http.ServeMux, _ *handler.APIHandler, _ string, log *slog.Logger) {
+	log.Info("built without UI, serving the JSON API only")
+}
@@ -0,0 +1,43 @@
+// Command testenv starts LocalStack with the default queues, prints the variables that
+// point sqs-ui at it, and removes the container on Ctrl-C:
+//
+//	eval "$(go run ./cmd/testenv | grep ^export)"   # in another shell, once it is ready
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/pachecoc/sqs-ui/internal/testenv"
+)
+
+func main() {
+	log := slog.New(slog.NewTextHandler(os.Stderr, nil))
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	env, err := testenv.Start(ctx, testenv.Options{Image: os.Getenv("LOCALSTACK_IMAGE"), Log: log})
+	if err != nil {
+		log.Error("could not start the test environment", "error", err)
+		os.Exit(1)
+	}
+	for name, url := range env.QueueURLs {
+		log.Info("queue ready", "queue_name", name, "queue_url", url)
+	}
+	for _, kv := range env.Environ("orders") {
+		fmt.Println("export " + kv)
+	}
+
+	<-ctx.Done()
+	stopCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := env.Stop(stopCtx); err != nil {
+		log.Error("could not remove the LocalStack container", "error", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,155 @@
+// Package annotations keeps team notes and labels on messages, keyed by MessageId.
+package annotations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pachecoc/sqs-ui/internal/store"
+)
+
+// category is the store category holding annotations.
+const category = "annotations"
+
+// maxNoteLength keeps notes to a reasonable size.
+const maxNoteLength = 4096
+
+// ErrNotFound is returned when a message has no annotation.
+var ErrNotFound = errors.New("annotation not found")
+
+// Annotation is a local note attached to one message.
+type Annotation struct {
+	MessageID string    `json:"message_id"`
+	Labels    []string  `json:"labels,omitempty"`
+	Note      string    `json:"note,omitempty"`
+	Author    string    `json:"author,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Manager reads and writes annotations in the shared store.
+type Manager struct {
+	Store store.Store
+}
+
+// Get returns the annotation for a message.
+func (m *Manager) Get(ctx context.Context, messageID string) (Annotation, error) {
+	var a Annotation
+	if err := store.GetJSON(ctx, m.Store, category, messageID, &a); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return Annotation{}, ErrNotFound
+		}
+		return Annotation{}, err
+	}
+	return a, nil
+}
+
+// Put validates and saves an annotation, replacing any previous one.
+func (m *Manager) Put(ctx context.Context, a Annotation) (Annotation, error) {
+	a.MessageID = strings.TrimSpace(a.MessageID)
+	if a.MessageID == "" {
+		return Annotation{}, fmt.Errorf("message id is required")
+	}
+	if len(a.Note) > maxNoteLength {
+		return Annotation{}, fmt.Errorf("note exceeds %d characters", maxNoteLength)
+	}
+	a.Labels = normalizeLabels(a.Labels)
+	a.Note = strings.TrimSpace(a.Note)
+	a.Author = strings.TrimSpace(a.Author)
+	if len(a.Labels) == 0 && a.Note == "" {
+		return Annotation{}, fmt.Errorf("annotation needs a label or a note")
+	}
+	a.UpdatedAt = time.Now().UTC()
+
+	if err := store.PutJSON(ctx, m.Store, category, a.MessageID, a, 0); err != nil {
+		return Annotation{}, err
+	}
+	return a, nil
+}
+
+// Delete removes a message's annotation.
+func (m *Manager) Delete(ctx context.Context, messageID string) error {
+	return m.Store.Delete(ctx, category, messageID)
+}
+
+// Search returns annotations whose note, author or labels contain query (case-insensitive)
+// and that carry label, when given. Results are newest first.
+func (m *Manager) Search(ctx context.Context, query, label string) ([]Annotation, error) {
+	keys, err := m.Store.List(ctx, category)
+	if err != nil {
+		return nil, err
+	}
+
+	query = strings.ToLower(strings.TrimSpace(query))
+	label = strings.ToLower(strings.TrimSpace(label))
+	out := []Annotation{}
+	for _, k := range keys {
+		a, err := m.Get(ctx, k)
+		if err != nil {
+			continue
+		}
+		if label != "" && !a.HasLabel(label) {
+			continue
+		}
+		if query != "" && !a.matches(query) {
+			continue
+		}
+		out = append(out, a)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].UpdatedAt.After(out[j].UpdatedAt) })
+	return out, nil
+}
+
+// Attach adds an "annotation" field to each message that has one. Lookups that fail are skipped.
+func (m *Manager) Attach(ctx context.Context, msgs []map[string]any) {
+	for _, msg := range msgs {
+		id, _ := msg["message_id"].(string)
+		if id == "" {
+			continue
+		}
+		if a, err := m.Get(ctx, id); err == nil {
+			msg["annotation"] = a
+		}
+	}
+}
+
+// HasLabel reports whether the annotation carries label.
+func (a Annotation) HasLabel(label string) bool {
+	for _, l := range a.Labels {
+		if l == label {
+			return true
+		}
+	}
+	return false
+}
+
+func (a Annotation) matches(query string) bool {
+	if strings.Contains(strings.ToLower(a.Note), query) || strings.Contains(strings.ToLower(a.Author), query) {
+		return true
+	}
+	for _, l := range a.Labels {
+		if strings.Contains(l, query) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeLabels lowercases, trims and de-duplicates labels.
+func normalizeLabels(labels []string) []string {
+	seen := make(map[string]bool, len(labels))
+	out := make([]string, 0, len(labels))
+	for _, l := range labels {
+		l = strings.ToLower(strings.TrimSpace(l))
+		if l == "" || seen[l] {
+			continue
+		}
+		seen[l] = true
+		out = append(out, l)
+	}
+	sort.Strings(out)
+	return out
+}
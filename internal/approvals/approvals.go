@@ -0,0 +1,270 @@
+// Package approvals implements two-person approval for destructive actions: on protected queues
+// an action becomes a pending request that a second user must approve before it runs.
+package approvals
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pachecoc/sqs-ui/internal/store"
+)
+
+// category is the store category holding approval requests.
+const category = "approvals"
+
+// retention is how long decided requests are kept for the audit trail.
+const retention = 30 * 24 * time.Hour
+
+// decideLease bounds how long one replica holds a request while deciding it.
+const decideLease = time.Minute
+
+// Status is where a request is in the approval workflow.
+type Status string
+
+const (
+	StatusPending  Status = "pending"
+	StatusRejected Status = "rejected"
+	StatusExpired  Status = "expired"
+	StatusExecuted Status = "executed"
+	StatusFailed   Status = "failed"
+)
+
+var (
+	// ErrNotFound is returned for unknown request ids.
+	ErrNotFound = errors.New("approval request not found")
+	// ErrNotPending is returned when deciding a request that was already decided or expired.
+	ErrNotPending = errors.New("approval request is no longer pending")
+	// ErrForbidden is returned when the user may not decide the request.
+	ErrForbidden = errors.New("user may not approve this request")
+	// ErrBusy is returned while another replica is deciding the same request.
+	ErrBusy = errors.New("approval request is being decided")
+)
+
+// Request is one pending (or decided) destructive action. History is its audit trail.
+type Request struct {
+	ID          string         `json:"id"`
+	Action      string         `json:"action"`
+	QueueName   string         `json:"queue_name"`
+	Params      map[string]any `json:"params,omitempty"`
+	Status      Status         `json:"status"`
+	RequestedBy string         `json:"requested_by"`
+	RequestedAt time.Time      `json:"requested_at"`
+	ExpiresAt   time.Time      `json:"expires_at"`
+	DecidedBy   string         `json:"decided_by,omitempty"`
+	DecidedAt   *time.Time     `json:"decided_at,omitempty"`
+	Result      any            `json:"result,omitempty"`
+	Error       string         `json:"error,omitempty"`
+	History     []Entry        `json:"history"`
+}
+
+// Entry is one audit trail record.
+type Entry struct {
+	At     time.Time `json:"at"`
+	By     string    `json:"by,omitempty"`
+	Event  string    `json:"event"`
+	Detail string    `json:"detail,omitempty"`
+}
+
+// Manager stores requests and enforces the approval rules.
+type Manager struct {
+	Store store.Store
+	TTL   time.Duration
+
+	// Queues are path.Match patterns (e.g. "prod-*") of queues that need approval.
+	Queues []string
+	// Approvers may approve requests; empty means any user other than the requester.
+	Approvers []string
+
+	mu sync.Mutex
+}
+
+// Required reports whether actions on queue need a second approval.
+func (m *Manager) Required(queue string) bool {
+	if m == nil {
+		return false
+	}
+	for _, p := range m.Queues {
+		if ok, _ := path.Match(p, queue); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Create records a pending request for action on queue.
+func (m *Manager) Create(ctx context.Context, action, queue string, params map[string]any, by string) (Request, error) {
+	by = strings.TrimSpace(by)
+	if by == "" {
+		return Request{}, fmt.Errorf("a user is required to request %s on %s", action, queue)
+	}
+
+	now := time.Now().UTC()
+	req := Request{
+		ID:          newID(),
+		Action:      action,
+		QueueName:   queue,
+		Params:      params,
+		Status:      StatusPending,
+		RequestedBy: by,
+		RequestedAt: now,
+		ExpiresAt:   now.Add(m.TTL),
+		History:     []Entry{{At: now, By: by, Event: "requested"}},
+	}
+	if err := m.save(ctx, req); err != nil {
+		return Request{}, err
+	}
+	return req, nil
+}
+
+// Get returns a request, marking it expired once its TTL has passed.
+func (m *Manager) Get(ctx context.Context, id string) (Request, error) {
+	var req Request
+	if err := store.GetJSON(ctx, m.Store, category, id, &req); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return Request{}, ErrNotFound
+		}
+		return Request{}, err
+	}
+	if req.Status == StatusPending && time.Now().After(req.ExpiresAt) {
+		req.Status = StatusExpired
+		req.History = append(req.History, Entry{At: req.ExpiresAt, Event: "expired"})
+		if err := m.save(ctx, req); err != nil {
+			return Request{}, err
+		}
+	}
+	return req, nil
+}
+
+// List returns requests, optionally only those with status, newest first.
+func (m *Manager) List(ctx context.Context, status Status) ([]Request, error) {
+	keys, err := m.Store.List(ctx, category)
+	if err != nil {
+		return nil, err
+	}
+
+	out := []Request{}
+	for _, k := range keys {
+		req, err := m.Get(ctx, k)
+		if err != nil {
+			continue
+		}
+		if status != "" && req.Status != status {
+			continue
+		}
+		out = append(out, req)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].RequestedAt.After(out[j].RequestedAt) })
+	return out, nil
+}
+
+// Approve runs execute for a pending request approved by a second user and records the outcome.
+// The request stays claimed while execute runs so it cannot be executed twice.
+func (m *Manager) Approve(ctx context.Context, id, by string, execute func(Request) (any, error)) (Request, error) {
+	by = strings.TrimSpace(by)
+	req, release, err := m.claim(ctx, id, by)
+	if err != nil {
+		return req, err
+	}
+	defer release()
+
+	if by == req.RequestedBy {
+		return req, fmt.Errorf("%w: requester cannot approve their own request", ErrForbidden)
+	}
+	if len(m.Approvers) > 0 && !slices.Contains(m.Approvers, by) {
+		return req, fmt.Errorf("%w: %s is not an approver", ErrForbidden, by)
+	}
+
+	now := time.Now().UTC()
+	req.DecidedBy, req.DecidedAt = by, &now
+	req.History = append(req.History, Entry{At: now, By: by, Event: "approved"})
+
+	result, execErr := execute(req)
+	req.Result = result
+	req.Status = StatusExecuted
+	entry := Entry{At: time.Now().UTC(), Event: "executed"}
+	if execErr != nil {
+		req.Status, req.Error = StatusFailed, execErr.Error()
+		entry.Event, entry.Detail = "failed", execErr.Error()
+	}
+	req.History = append(req.History, entry)
+	return req, m.save(context.WithoutCancel(ctx), req)
+}
+
+// Reject closes a pending request without running it. The requester may withdraw their own.
+func (m *Manager) Reject(ctx context.Context, id, by, reason string) (Request, error) {
+	by = strings.TrimSpace(by)
+	req, release, err := m.claim(ctx, id, by)
+	if err != nil {
+		return req, err
+	}
+	defer release()
+
+	if by != req.RequestedBy && len(m.Approvers) > 0 && !slices.Contains(m.Approvers, by) {
+		return req, fmt.Errorf("%w: %s is not an approver", ErrForbidden, by)
+	}
+
+	now := time.Now().UTC()
+	req.Status = StatusRejected
+	req.DecidedBy, req.DecidedAt = by, &now
+	req.History = append(req.History, Entry{At: now, By: by, Event: "rejected", Detail: strings.TrimSpace(reason)})
+	return req, m.save(ctx, req)
+}
+
+// claim loads a pending request and holds it (locally and, when the store supports leases,
+// across replicas) until release is called.
+func (m *Manager) claim(ctx context.Context, id, by string) (Request, func(), error) {
+	if by == "" {
+		return Request{}, nil, fmt.Errorf("%w: no user", ErrForbidden)
+	}
+
+	m.mu.Lock()
+	release := m.mu.Unlock
+	if l, ok := m.Store.(store.Leaser); ok {
+		name := category + "-" + id
+		acquired, err := l.AcquireLease(ctx, name, by, decideLease)
+		if err != nil || !acquired {
+			m.mu.Unlock()
+			if err == nil {
+				err = ErrBusy
+			}
+			return Request{}, nil, err
+		}
+		release = func() {
+			_ = l.ReleaseLease(context.WithoutCancel(ctx), name, by)
+			m.mu.Unlock()
+		}
+	}
+
+	req, err := m.Get(ctx, id)
+	if err == nil && req.Status != StatusPending {
+		err = fmt.Errorf("%w (%s)", ErrNotPending, req.Status)
+	}
+	if err != nil {
+		release()
+		return req, nil, err
+	}
+	return req, release, nil
+}
+
+func (m *Manager) save(ctx context.Context, req Request) error {
+	ttl := retention
+	if req.Status == StatusPending {
+		ttl += time.Until(req.ExpiresAt)
+	}
+	return store.PutJSON(ctx, m.Store, category, req.ID, req, ttl)
+}
+
+func newID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
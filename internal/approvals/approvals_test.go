@@ -0,0 +1,160 @@
+package approvals
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pachecoc/sqs-ui/internal/store"
+)
+
+func TestApprove(t *testing.T) {
+	tests := []struct {
+		name      string
+		approvers []string
+		ttl       time.Duration
+		// before runs against the pending request ahead of the approval
+		before func(t *testing.T, m *Manager, id string)
+		by     string
+		want   error
+		status Status
+	}{
+		{name: "second user", by: "bob", status: StatusExecuted},
+		{name: "requester", by: "alice", want: ErrForbidden, status: StatusPending},
+		{name: "no user", by: " ", want: ErrForbidden, status: StatusPending},
+		{name: "listed approver", approvers: []string{"carol"}, by: "carol", status: StatusExecuted},
+		{name: "not an approver", approvers: []string{"carol"}, by: "bob", want: ErrForbidden, status: StatusPending},
+		{name: "requester listed as approver", approvers: []string{"alice"}, by: "alice", want: ErrForbidden, status: StatusPending},
+		{name: "expired", ttl: -time.Minute, by: "bob", want: ErrNotPending, status: StatusExpired},
+		{
+			name: "already approved",
+			before: func(t *testing.T, m *Manager, id string) {
+				if _, err := m.Approve(context.Background(), id, "carol", noop); err != nil {
+					t.Fatal(err)
+				}
+			},
+			by: "bob", want: ErrNotPending, status: StatusExecuted,
+		},
+		{
+			name: "rejected",
+			before: func(t *testing.T, m *Manager, id string) {
+				if _, err := m.Reject(context.Background(), id, "alice", "withdrawn"); err != nil {
+					t.Fatal(err)
+				}
+			},
+			by: "bob", want: ErrNotPending, status: StatusRejected,
+		},
+		{
+			name: "claimed by another replica",
+			before: func(t *testing.T, m *Manager, id string) {
+				l := m.Store.(store.Leaser)
+				if ok, err := l.AcquireLease(context.Background(), category+"-"+id, "replica-2", time.Minute); !ok || err != nil {
+					t.Fatalf("lease: %v, %v", ok, err)
+				}
+			},
+			by: "bob", want: ErrBusy, status: StatusPending,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			ttl := tt.ttl
+			if ttl == 0 {
+				ttl = time.Hour
+			}
+			m := &Manager{Store: store.NewMemory(), TTL: ttl, Approvers: tt.approvers}
+			req, err := m.Create(ctx, "purge", "orders", nil, "alice")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.before != nil {
+				tt.before(t, m, req.ID)
+			}
+
+			runs := 0
+			_, err = m.Approve(ctx, req.ID, tt.by, func(Request) (any, error) {
+				runs++
+				return nil, nil
+			})
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("Approve by %q: got %v, want %v", tt.by, err, tt.want)
+			}
+			wantRuns := 0
+			if tt.want == nil {
+				wantRuns = 1
+			}
+			if runs != wantRuns {
+				t.Errorf("execute ran %d times, want %d", runs, wantRuns)
+			}
+			got, err := m.Get(ctx, req.ID)
+			if err != nil || got.Status != tt.status {
+				t.Errorf("status %q (%v), want %q", got.Status, err, tt.status)
+			}
+		})
+	}
+}
+
+func TestApproveReleasesClaim(t *testing.T) {
+	ctx := context.Background()
+	m := &Manager{Store: store.NewMemory(), TTL: time.Hour}
+	req, err := m.Create(ctx, "drain", "orders", nil, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Approve(ctx, req.ID, "alice", noop); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("self-approval: %v", err)
+	}
+	// The refused attempt must not leave the request claimed
+	if _, err := m.Approve(ctx, req.ID, "bob", noop); err != nil {
+		t.Fatalf("approval after a refused one: %v", err)
+	}
+}
+
+func TestApproveRecordsFailure(t *testing.T) {
+	ctx := context.Background()
+	m := &Manager{Store: store.NewMemory(), TTL: time.Hour}
+	req, err := m.Create(ctx, "purge", "orders", nil, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := m.Approve(ctx, req.ID, "bob", func(Request) (any, error) { return nil, errors.New("access denied") })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != StatusFailed || got.Error != "access denied" || got.DecidedBy != "bob" {
+		t.Errorf("got %+v", got)
+	}
+	if events := len(got.History); events != 3 || got.History[2].Event != "failed" {
+		t.Errorf("history %+v", got.History)
+	}
+}
+
+func TestReject(t *testing.T) {
+	tests := []struct {
+		name      string
+		approvers []string
+		by        string
+		want      error
+	}{
+		{name: "requester withdraws", approvers: []string{"carol"}, by: "alice"},
+		{name: "approver", approvers: []string{"carol"}, by: "carol"},
+		{name: "anyone without approvers", by: "bob"},
+		{name: "not an approver", approvers: []string{"carol"}, by: "bob", want: ErrForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			m := &Manager{Store: store.NewMemory(), TTL: time.Hour, Approvers: tt.approvers}
+			req, err := m.Create(ctx, "purge", "orders", nil, "alice")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := m.Reject(ctx, req.ID, tt.by, "not now"); !errors.Is(err, tt.want) {
+				t.Fatalf("Reject by %q: got %v, want %v", tt.by, err, tt.want)
+			}
+		})
+	}
+}
+
+func noop(Request) (any, error) { return nil, nil }
@@ -0,0 +1,271 @@
+// Package auth signs users in with an OpenID Connect provider (authorization code flow with
+// PKCE) and keeps them signed in with a signed, stateless session cookie, so sessions work
+// across replicas that share the session secret.
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+
+	"github.com/pachecoc/sqs-ui/internal/secrets"
+)
+
+// Cookie names. The login cookie only lives for the round trip to the provider.
+const (
+	SessionCookie = "sqs_ui_session"
+	loginCookie   = "sqs_ui_login"
+)
+
+// loginTTL bounds how long a user may take to sign in at the provider.
+const loginTTL = 10 * time.Minute
+
+// Config configures New.
+type Config struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret *secrets.Value
+	// RedirectURL is this server's /auth/callback as registered with the provider.
+	RedirectURL string
+	Scopes      []string
+	// UserClaim is the ID token claim naming the user ("email" when empty).
+	UserClaim string
+	// SessionSecret signs cookies; when unset a random key is used, so sessions end on
+	// restart and aren't shared between replicas.
+	SessionSecret *secrets.Value
+	SessionTTL    time.Duration
+}
+
+// OIDC runs the sign-in flow against one provider.
+type OIDC struct {
+	cfg      Config
+	endpoint oauth2.Endpoint
+	verifier *oidc.IDTokenVerifier
+	secure   bool
+	// loginPath scopes the login cookie to the callback's directory, which carries BASE_PATH
+	loginPath string
+	key       []byte // used when no SessionSecret is set
+	log       *slog.Logger
+}
+
+// Session is a signed-in user.
+type Session struct {
+	User      string    `json:"user"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// New discovers the provider's endpoints and keys from its issuer URL.
+func New(ctx context.Context, cfg Config, log *slog.Logger) (*OIDC, error) {
+	if cfg.UserClaim == "" {
+		cfg.UserClaim = "email"
+	}
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = []string{oidc.ScopeOpenID, "email", "profile"}
+	}
+	provider, err := oidc.NewProvider(ctx, cfg.Issuer)
+	if err != nil {
+		return nil, fmt.Errorf("OIDC discovery failed for %s: %w", cfg.Issuer, err)
+	}
+	o := &OIDC{
+		cfg:      cfg,
+		endpoint: provider.Endpoint(),
+		verifier: provider.Verifier(&oidc.Config{ClientID: cfg.ClientID}),
+		secure:   strings.HasPrefix(cfg.RedirectURL, "https://"),
+		log:      log,
+	}
+	o.loginPath = "/auth/"
+	if u, err := url.Parse(cfg.RedirectURL); err == nil && strings.HasSuffix(u.Path, "/callback") {
+		o.loginPath = strings.TrimSuffix(u.Path, "callback")
+	}
+	if !cfg.SessionSecret.IsSet() {
+		o.key = make([]byte, 32)
+		_, _ = rand.Read(o.key)
+		log.Warn("SESSION_SECRET is not set; sessions end on restart and aren't shared between replicas")
+	}
+	return o, nil
+}
+
+// oauth2Config is built per use so a rotated client secret takes effect.
+func (o *OIDC) oauth2Config() *oauth2.Config {
+	return &oauth2.Config{
+		ClientID:     o.cfg.ClientID,
+		ClientSecret: o.cfg.ClientSecret.Get(),
+		Endpoint:     o.endpoint,
+		RedirectURL:  o.cfg.RedirectURL,
+		Scopes:       o.cfg.Scopes,
+	}
+}
+
+// login is what the login cookie carries across the round trip to the provider.
+type login struct {
+	State    string    `json:"state"`
+	Nonce    string    `json:"nonce"`
+	Verifier string    `json:"verifier"`
+	ReturnTo string    `json:"return_to"`
+	Expires  time.Time `json:"expires"`
+}
+
+// LoginURL starts a sign-in: it remembers the state, nonce, PKCE verifier and returnTo in
+// a short-lived cookie and returns the provider URL to redirect the browser to.
+func (o *OIDC) LoginURL(w http.ResponseWriter, returnTo string) string {
+	l := login{
+		State:    randomString(),
+		Nonce:    randomString(),
+		Verifier: oauth2.GenerateVerifier(),
+		ReturnTo: SafeReturnTo(returnTo),
+		Expires:  time.Now().Add(loginTTL),
+	}
+	o.setCookie(w, loginCookie, o.loginPath, l, l.Expires)
+	return o.oauth2Config().AuthCodeURL(l.State, oidc.Nonce(l.Nonce), oauth2.S256ChallengeOption(l.Verifier))
+}
+
+// Callback completes a sign-in from the provider's redirect: it checks the state, redeems
+// the code, verifies the ID token and its nonce, and sets the session cookie. It returns
+// the session and where the user was going.
+func (o *OIDC) Callback(w http.ResponseWriter, r *http.Request) (Session, string, error) {
+	q := r.URL.Query()
+	if e := q.Get("error"); e != "" {
+		return Session{}, "", fmt.Errorf("sign-in failed at the provider: %s %s", e, q.Get("error_description"))
+	}
+	var l login
+	if !o.readCookie(r, loginCookie, &l) || time.Now().After(l.Expires) {
+		return Session{}, "", errors.New("sign-in expired or was started elsewhere; try again")
+	}
+	o.clearCookie(w, loginCookie, o.loginPath)
+	if !hmac.Equal([]byte(q.Get("state")), []byte(l.State)) {
+		return Session{}, "", errors.New("sign-in state mismatch; try again")
+	}
+
+	token, err := o.oauth2Config().Exchange(r.Context(), q.Get("code"), oauth2.VerifierOption(l.Verifier))
+	if err != nil {
+		return Session{}, "", fmt.Errorf("could not redeem the authorization code: %w", err)
+	}
+	raw, _ := token.Extra("id_token").(string)
+	if raw == "" {
+		return Session{}, "", errors.New("the provider returned no ID token")
+	}
+	idToken, err := o.verifier.Verify(r.Context(), raw)
+	if err != nil {
+		return Session{}, "", fmt.Errorf("invalid ID token: %w", err)
+	}
+	if !hmac.Equal([]byte(idToken.Nonce), []byte(l.Nonce)) {
+		return Session{}, "", errors.New("ID token nonce mismatch")
+	}
+	var claims map[string]any
+	if err := idToken.Claims(&claims); err != nil {
+		return Session{}, "", fmt.Errorf("invalid ID token claims: %w", err)
+	}
+	user, _ := claims[o.cfg.UserClaim].(string)
+	if user == "" {
+		return Session{}, "", fmt.Errorf("the ID token has no %q claim", o.cfg.UserClaim)
+	}
+	if verified, ok := claims["email_verified"].(bool); o.cfg.UserClaim == "email" && ok && !verified {
+		return Session{}, "", fmt.Errorf("%s is not verified at the provider", user)
+	}
+
+	s := Session{User: user, ExpiresAt: time.Now().Add(o.cfg.SessionTTL).UTC().Truncate(time.Second)}
+	o.setCookie(w, SessionCookie, "/", s, s.ExpiresAt)
+	return s, l.ReturnTo, nil
+}
+
+// Session returns the request's session, if it carries a valid, unexpired one.
+func (o *OIDC) Session(r *http.Request) (Session, bool) {
+	var s Session
+	if !o.readCookie(r, SessionCookie, &s) || s.User == "" || time.Now().After(s.ExpiresAt) {
+		return Session{}, false
+	}
+	return s, true
+}
+
+// Logout clears the session cookie.
+func (o *OIDC) Logout(w http.ResponseWriter) {
+	o.clearCookie(w, SessionCookie, "/")
+}
+
+// SafeReturnTo keeps sign-in redirects on this server: anything but a local path becomes "/".
+func SafeReturnTo(s string) string {
+	if !strings.HasPrefix(s, "/") || strings.HasPrefix(s, "//") || strings.HasPrefix(s, "/\\") {
+		return "/"
+	}
+	return s
+}
+
+func (o *OIDC) signingKey() []byte {
+	if o.key != nil {
+		return o.key
+	}
+	sum := sha256.Sum256([]byte(o.cfg.SessionSecret.Get()))
+	return sum[:]
+}
+
+func (o *OIDC) sign(payload string) string {
+	mac := hmac.New(sha256.New, o.signingKey())
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// setCookie stores v as base64 JSON with an HMAC. SameSite=Lax keeps the cookie off
+// cross-site POSTs while still sending it when the provider redirects back.
+func (o *OIDC) setCookie(w http.ResponseWriter, name, path string, v any, expires time.Time) {
+	b, _ := json.Marshal(v)
+	payload := base64.RawURLEncoding.EncodeToString(b)
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Value:    payload + "." + o.sign(payload),
+		Path:     path,
+		Expires:  expires,
+		HttpOnly: true,
+		Secure:   o.secure,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+func (o *OIDC) readCookie(r *http.Request, name string, v any) bool {
+	c, err := r.Cookie(name)
+	if err != nil {
+		return false
+	}
+	payload, sig, ok := strings.Cut(c.Value, ".")
+	if !ok || !hmac.Equal([]byte(sig), []byte(o.sign(payload))) {
+		return false
+	}
+	b, err := base64.RawURLEncoding.DecodeString(payload)
+	return err == nil && json.Unmarshal(b, v) == nil
+}
+
+func (o *OIDC) clearCookie(w http.ResponseWriter, name, path string) {
+	http.SetCookie(w, &http.Cookie{Name: name, Path: path, MaxAge: -1, HttpOnly: true, Secure: o.secure, SameSite: http.SameSiteLaxMode})
+}
+
+func randomString() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+type userKey struct{}
+
+// WithUser returns ctx carrying the signed-in user.
+func WithUser(ctx context.Context, user string) context.Context {
+	return context.WithValue(ctx, userKey{}, user)
+}
+
+// User returns the signed-in user WithUser stored in ctx, if any.
+func User(ctx context.Context) string {
+	user, _ := ctx.Value(userKey{}).(string)
+	return user
+}
@@ -0,0 +1,151 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// newTestOIDC returns an OIDC signing with key, without provider discovery.
+func newTestOIDC(key string) *OIDC {
+	return &OIDC{
+		cfg: Config{
+			ClientID:    "sqs-ui",
+			RedirectURL: "https://sqs-ui.example.com/auth/callback",
+			SessionTTL:  time.Hour,
+		},
+		endpoint:  oauth2.Endpoint{AuthURL: "https://idp.example.com/authorize", TokenURL: "https://idp.example.com/token"},
+		secure:    true,
+		loginPath: "/auth/",
+		key:       []byte(key),
+	}
+}
+
+// withCookies returns a request to target carrying the cookies rec set.
+func withCookies(rec *httptest.ResponseRecorder, target string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	for _, c := range rec.Result().Cookies() {
+		req.AddCookie(c)
+	}
+	return req
+}
+
+func TestSessionCookie(t *testing.T) {
+	o := newTestOIDC("first-key")
+	rec := httptest.NewRecorder()
+	o.setCookie(rec, SessionCookie, "/", Session{User: "ada@example.com", ExpiresAt: time.Now().Add(time.Hour)}, time.Now().Add(time.Hour))
+
+	s, ok := o.Session(withCookies(rec, "/"))
+	if !ok || s.User != "ada@example.com" {
+		t.Fatalf("round trip: got %+v, %v", s, ok)
+	}
+	if _, ok := newTestOIDC("other-key").Session(withCookies(rec, "/")); ok {
+		t.Error("a cookie signed with another key was accepted")
+	}
+
+	// Swap in another user but keep the original signature
+	_, sig, _ := strings.Cut(rec.Result().Cookies()[0].Value, ".")
+	forged, _ := json.Marshal(Session{User: "mallory@example.com", ExpiresAt: time.Now().Add(time.Hour)})
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(&http.Cookie{Name: SessionCookie, Value: base64.RawURLEncoding.EncodeToString(forged) + "." + sig})
+	if s, ok := o.Session(req); ok {
+		t.Errorf("tampered cookie accepted as %q", s.User)
+	}
+
+	expired := httptest.NewRecorder()
+	o.setCookie(expired, SessionCookie, "/", Session{User: "ada@example.com", ExpiresAt: time.Now().Add(-time.Minute)}, time.Now().Add(time.Hour))
+	if _, ok := o.Session(withCookies(expired, "/")); ok {
+		t.Error("expired session accepted")
+	}
+}
+
+func TestLoginURLUsesPKCE(t *testing.T) {
+	o := newTestOIDC("key")
+	rec := httptest.NewRecorder()
+	u, err := url.Parse(o.LoginURL(rec, "/queues?x=1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var l login
+	if !o.readCookie(withCookies(rec, "/auth/callback"), loginCookie, &l) {
+		t.Fatal("LoginURL set no readable login cookie")
+	}
+	q := u.Query()
+	sum := sha256.Sum256([]byte(l.Verifier))
+	if got, want := q.Get("code_challenge"), base64.RawURLEncoding.EncodeToString(sum[:]); got != want || q.Get("code_challenge_method") != "S256" {
+		t.Errorf("challenge %q (%s), want %q (S256)", got, q.Get("code_challenge_method"), want)
+	}
+	if q.Get("code_verifier") != "" {
+		t.Error("the verifier was sent to the browser")
+	}
+	if q.Get("state") != l.State || q.Get("nonce") != l.Nonce || l.State == "" || l.Nonce == "" {
+		t.Errorf("state/nonce %q/%q, cookie has %q/%q", q.Get("state"), q.Get("nonce"), l.State, l.Nonce)
+	}
+	if l.ReturnTo != "/queues?x=1" {
+		t.Errorf("return_to %q", l.ReturnTo)
+	}
+	if c := rec.Result().Cookies()[0]; c.Path != "/auth/" || !c.HttpOnly || !c.Secure {
+		t.Errorf("login cookie path %q, HttpOnly %v, Secure %v", c.Path, c.HttpOnly, c.Secure)
+	}
+}
+
+func TestCallbackRejects(t *testing.T) {
+	o := newTestOIDC("key")
+	started := httptest.NewRecorder()
+	o.LoginURL(started, "/")
+
+	stale := httptest.NewRecorder()
+	o.setCookie(stale, loginCookie, "/auth/", login{State: "s", Expires: time.Now().Add(-time.Minute)}, time.Now().Add(time.Hour))
+
+	tests := []struct {
+		name  string
+		from  *httptest.ResponseRecorder
+		query string
+		want  string
+	}{
+		{"provider error", started, "error=access_denied&error_description=no", "access_denied"},
+		{"no login cookie", httptest.NewRecorder(), "state=s&code=c", "expired"},
+		{"expired login", stale, "state=s&code=c", "expired"},
+		{"state mismatch", started, "state=other&code=c", "state mismatch"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			_, _, err := o.Callback(rec, withCookies(tt.from, "/auth/callback?"+tt.query))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("got %v, want an error mentioning %q", err, tt.want)
+			}
+			for _, c := range rec.Result().Cookies() {
+				if c.Name == SessionCookie {
+					t.Error("a failed callback set a session cookie")
+				}
+			}
+		})
+	}
+}
+
+func TestSafeReturnTo(t *testing.T) {
+	tests := map[string]string{
+		"":                      "/",
+		"/queues":               "/queues",
+		"/q/eu-west-1/1/orders": "/q/eu-west-1/1/orders",
+		"https://evil.example":  "/",
+		"//evil.example":        "/",
+		"/\\evil.example":       "/",
+		"javascript:alert(1)":   "/",
+	}
+	for in, want := range tests {
+		if got := SafeReturnTo(in); got != want {
+			t.Errorf("SafeReturnTo(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/pachecoc/sqs-ui/internal/secrets"
+)
+
+// MinTokenLength keeps guessable tokens out of API_TOKENS.
+const MinTokenLength = 16
+
+// tokenUser names requests made with a token configured without a name.
+const tokenUser = "api-token"
+
+// Tokens authenticates automation with static bearer tokens. Source holds comma-separated
+// entries, each "name:token" or a bare token; it is re-read on every check, so a rotated
+// secret reference applies without a restart.
+type Tokens struct {
+	Source *secrets.Value
+}
+
+type token struct {
+	name string
+	hash [32]byte
+}
+
+// Validate checks the configured entries: there must be one, and every token must be at
+// least MinTokenLength long.
+func (t *Tokens) Validate() error {
+	n := 0
+	for i, entry := range strings.Split(t.Source.Get(), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		n++
+		if _, secret := splitToken(entry); len(secret) < MinTokenLength {
+			return fmt.Errorf("API_TOKENS entry %d: tokens must be at least %d characters", i+1, MinTokenLength)
+		}
+	}
+	if n == 0 {
+		return errors.New("API_TOKENS has no tokens")
+	}
+	return nil
+}
+
+// Check returns the name of the token presented, if it is one of the configured tokens.
+// Hashes are compared in constant time, so response times don't reveal token prefixes.
+func (t *Tokens) Check(presented string) (string, bool) {
+	if presented == "" {
+		return "", false
+	}
+	hash := sha256.Sum256([]byte(presented))
+	name, found := "", false
+	for _, tok := range parseTokens(t.Source.Get()) {
+		if subtle.ConstantTimeCompare(hash[:], tok.hash[:]) == 1 && !found {
+			name, found = tok.name, true
+		}
+	}
+	return name, found
+}
+
+func parseTokens(raw string) []token {
+	var list []token
+	for _, entry := range strings.Split(raw, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, secret := splitToken(entry)
+		list = append(list, token{name: name, hash: sha256.Sum256([]byte(secret))})
+	}
+	return list
+}
+
+// splitToken separates "name:token"; a bare token is named tokenUser.
+func splitToken(entry string) (name, secret string) {
+	if name, secret, ok := strings.Cut(entry, ":"); ok && name != "" {
+		return name, secret
+	}
+	return tokenUser, entry
+}
@@ -0,0 +1,48 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/pachecoc/sqs-ui/internal/secrets"
+)
+
+func TestTokensCheck(t *testing.T) {
+	tokens := &Tokens{Source: secrets.Static("ci:ci-token-0123456789, deploy-token-0123456789 ,,")}
+
+	tests := []struct {
+		presented, user string
+		ok              bool
+	}{
+		{"ci-token-0123456789", "ci", true},
+		{"deploy-token-0123456789", "api-token", true},
+		{"ci:ci-token-0123456789", "", false},
+		{"ci-token-012345678", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		user, ok := tokens.Check(tt.presented)
+		if user != tt.user || ok != tt.ok {
+			t.Errorf("Check(%q) = %q, %v; want %q, %v", tt.presented, user, ok, tt.user, tt.ok)
+		}
+	}
+}
+
+func TestTokensValidate(t *testing.T) {
+	tests := []struct {
+		source string
+		ok     bool
+	}{
+		{"ci:ci-token-0123456789", true},
+		{"ci-token-0123456789,ops:ops-token-0123456789", true},
+		{"ci:short", false},
+		{"ci-token-0123456789,short", false},
+		{"", false},
+		{" , ", false},
+	}
+	for _, tt := range tests {
+		err := (&Tokens{Source: secrets.Static(tt.source)}).Validate()
+		if (err == nil) != tt.ok {
+			t.Errorf("Validate(%q) = %v, want ok %v", tt.source, err, tt.ok)
+		}
+	}
+}
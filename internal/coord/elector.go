@@ -0,0 +1,88 @@
+package coord
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/pachecoc/sqs-ui/internal/lifecycle"
+	"github.com/pachecoc/sqs-ui/internal/store"
+)
+
+// leaderLease is the lease name contended for by all replicas.
+const leaderLease = "leader"
+
+// Elector keeps (or contends for) cluster leadership through a store lease. Only
+// the leader runs singleton work such as watchers and scheduled digests.
+type Elector struct {
+	Leaser store.Leaser
+	Owner  string
+	TTL    time.Duration
+	Log    *slog.Logger
+
+	leader atomic.Bool
+}
+
+// NewElector creates an Elector with a unique owner id for this process.
+func NewElector(leaser store.Leaser, ttl time.Duration, log *slog.Logger) *Elector {
+	return &Elector{Leaser: leaser, Owner: NewOwnerID(), TTL: ttl, Log: log}
+}
+
+// NewOwnerID identifies this replica in lease records (hostname-pid-random).
+func NewOwnerID() string {
+	host, _ := os.Hostname()
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+	return fmt.Sprintf("%s-%d-%s", host, os.Getpid(), hex.EncodeToString(b))
+}
+
+// IsLeader reports whether this replica currently holds the leader lease.
+func (e *Elector) IsLeader() bool {
+	if e == nil {
+		// No coordination configured: every replica acts alone
+		return true
+	}
+	return e.leader.Load()
+}
+
+// Run renews or contends for the lease every TTL/3 until ctx is canceled, then releases it.
+func (e *Elector) Run(ctx context.Context) {
+	e.Log.Info("leader election started", "owner", e.Owner, "ttl_seconds", e.TTL.Seconds())
+	ticker := time.NewTicker(e.TTL / 3)
+	defer ticker.Stop()
+
+	for {
+		e.tick(ctx)
+		select {
+		case <-ctx.Done():
+			if e.leader.Load() {
+				releaseCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+				if err := e.Leaser.ReleaseLease(releaseCtx, leaderLease, e.Owner); err != nil {
+					e.Log.Warn("failed to release leader lease", "error", err)
+				}
+				cancel()
+			}
+			e.Log.Info("leader election stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (e *Elector) tick(ctx context.Context) {
+	ok, err := e.Leaser.AcquireLease(ctx, leaderLease, e.Owner, e.TTL)
+	if err != nil {
+		// Step down on errors: a replica that can't renew may already have lost the lease
+		e.Log.Warn("leader lease renewal failed", "error", err)
+		ok = false
+	}
+	lifecycle.Report(ctx, err)
+	if was := e.leader.Swap(ok); was != ok {
+		e.Log.Info("leadership changed", "leader", ok, "owner", e.Owner)
+	}
+}
@@ -0,0 +1,158 @@
+// Package digest periodically posts queue health summaries to a chat webhook.
+package digest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/pachecoc/sqs-ui/internal/lifecycle"
+	"github.com/pachecoc/sqs-ui/internal/notify"
+	"github.com/pachecoc/sqs-ui/internal/service"
+)
+
+// QueueSummary is the health of one queue at digest time.
+type QueueSummary struct {
+	QueueName     string `json:"queue_name"`
+	Depth         string `json:"depth"`
+	InFlight      any    `json:"in_flight"`
+	DLQName       string `json:"dlq_name,omitempty"`
+	DLQDepth      string `json:"dlq_depth,omitempty"`
+	OldestSeconds int64  `json:"oldest_sampled_age_seconds"`
+	Stale         bool   `json:"stale"`
+	Error         string `json:"error,omitempty"`
+}
+
+// Digester builds and posts digests for registered queues.
+type Digester struct {
+	Service  func() *service.SQSService
+	Notifier notify.Notifier
+	Log      *slog.Logger
+	Interval time.Duration
+
+	// Queues to summarize; the active queue when empty.
+	Queues []string
+
+	// StaleAfter flags queues whose oldest sampled message is older than this.
+	StaleAfter time.Duration
+
+	// Leader, when set, limits posting to the elected replica.
+	Leader func() bool
+}
+
+// Run posts a digest every Interval until ctx is canceled. The first digest is sent after
+// one full interval so restarts don't repost.
+func (d *Digester) Run(ctx context.Context) {
+	d.Log.Info("digest scheduler started", "interval_hours", d.Interval.Hours(), "queues", d.Queues)
+	ticker := time.NewTicker(d.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			d.Log.Info("digest scheduler stopped")
+			return
+		case <-ticker.C:
+		}
+		if d.Leader != nil && !d.Leader() {
+			continue
+		}
+		err := d.Send(ctx)
+		if err != nil {
+			d.Log.Warn("failed to send digest", "error", err)
+		}
+		lifecycle.Report(ctx, err)
+	}
+}
+
+// Send builds a digest now and posts it.
+func (d *Digester) Send(ctx context.Context) error {
+	summaries, err := d.Build(ctx)
+	if err != nil {
+		return err
+	}
+	if err := d.Notifier.Notify(ctx, Format(summaries)); err != nil {
+		return err
+	}
+	d.Log.Info("digest sent", "queues", len(summaries))
+	return nil
+}
+
+// Build collects summaries for every registered queue. Per-queue failures are reported inline.
+func (d *Digester) Build(ctx context.Context) ([]QueueSummary, error) {
+	svc := d.Service()
+	if svc == nil {
+		return nil, errors.New("service unavailable")
+	}
+	queues := d.Queues
+	if len(queues) == 0 {
+		queues = []string{svc.QueueName}
+	}
+
+	out := make([]QueueSummary, 0, len(queues))
+	for _, name := range queues {
+		out = append(out, d.summarize(ctx, svc, name))
+	}
+	return out, nil
+}
+
+func (d *Digester) summarize(ctx context.Context, base *service.SQSService, name string) QueueSummary {
+	sum := QueueSummary{QueueName: name}
+	svc, err := base.ForQueue(ctx, name)
+	if err != nil {
+		sum.Error = err.Error()
+		return sum
+	}
+
+	info := svc.Info(ctx)
+	if info["status"] != "ok" {
+		sum.Error = fmt.Sprint(info["error"])
+		return sum
+	}
+	sum.Depth = fmt.Sprint(info["number_of_messages"])
+	sum.InFlight = info["approximate_number_of_messages_not_visible"]
+
+	if dlq, err := svc.DeadLetterQueue(ctx); err == nil {
+		sum.DLQName = dlq.QueueName
+		sum.DLQDepth = fmt.Sprint(dlq.Info(ctx)["number_of_messages"])
+	} else if !errors.Is(err, service.ErrNoDeadLetterQueue) {
+		d.Log.Debug("digest could not read DLQ", "queue_name", name, "error", err)
+	}
+
+	if age, err := svc.OldestMessageAge(ctx); err == nil {
+		sum.OldestSeconds = int64(age.Seconds())
+		sum.Stale = d.StaleAfter > 0 && age > d.StaleAfter
+	}
+	return sum
+}
+
+// Format renders summaries as a notification.
+func Format(summaries []QueueSummary) notify.Message {
+	var lines []string
+	var fields []notify.Field
+	for _, s := range summaries {
+		if s.Error != "" {
+			lines = append(lines, fmt.Sprintf("⚠️ %s: %s", s.QueueName, s.Error))
+			continue
+		}
+		value := fmt.Sprintf("depth %s, in flight %v", s.Depth, s.InFlight)
+		if s.DLQName != "" {
+			value += fmt.Sprintf(", DLQ %s", s.DLQDepth)
+		}
+		if s.OldestSeconds > 0 {
+			value += fmt.Sprintf(", oldest %s", (time.Duration(s.OldestSeconds) * time.Second).String())
+		}
+		if s.Stale {
+			value += " (stale)"
+		}
+		fields = append(fields, notify.Field{Name: s.QueueName, Value: value})
+	}
+	return notify.Message{
+		Title:  "SQS queue digest",
+		Text:   strings.Join(lines, "\n"),
+		Fields: fields,
+	}
+}
@@ -0,0 +1,247 @@
+// Package erasure deletes the copies of messages that sqs-ui keeps in its store (receive
+// snapshots, job results and artifacts, approvals, annotations, triage items), for
+// data-subject requests: find everything mentioning a customer id and remove it, with a
+// report of what was removed.
+package erasure
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/pachecoc/sqs-ui/internal/store"
+	"github.com/pachecoc/sqs-ui/internal/validate"
+)
+
+// Store categories holding message copies, as written by their owning packages.
+const (
+	categorySnapshots   = "snapshots"
+	categoryJobs        = "jobs"
+	categoryArtifacts   = "artifacts"
+	categoryApprovals   = "approvals"
+	categoryAnnotations = "annotations"
+	categoryTriage      = "triage"
+)
+
+// minContains keeps a short needle from matching most of the store.
+const minContains = 3
+
+// maxMessageIDs bounds one request.
+const maxMessageIDs = 1000
+
+// Filter selects what to erase: records containing Contains anywhere in their text
+// (bodies, attributes, notes, artifacts) and records of the listed message ids.
+type Filter struct {
+	Contains   string   `json:"contains"`
+	MessageIDs []string `json:"message_ids"`
+}
+
+// Validate checks that the filter selects something specific.
+func (f Filter) Validate() error {
+	var v validate.Validator
+	v.Check(f.Contains != "" || len(f.MessageIDs) > 0, "contains", "contains or message_ids is required")
+	v.Check(f.Contains == "" || len(f.Contains) >= minContains, "contains", fmt.Sprintf("must be at least %d characters", minContains))
+	v.Check(len(f.MessageIDs) <= maxMessageIDs, "message_ids", fmt.Sprintf("at most %d ids", maxMessageIDs))
+	for _, id := range f.MessageIDs {
+		v.Check(strings.TrimSpace(id) != "", "message_ids", "must not contain empty ids")
+	}
+	return v.Err()
+}
+
+// Report lists what Erase removed (or, on a dry run, would remove), by category and key.
+type Report struct {
+	DryRun     bool                `json:"dry_run"`
+	Records    int                 `json:"records"`
+	Removed    map[string][]string `json:"removed"`
+	MessageIDs []string            `json:"message_ids"` // messages found in snapshots or named in the filter
+	Errors     []string            `json:"errors,omitempty"`
+}
+
+func (r *Report) add(category, key string) {
+	r.Removed[category] = append(r.Removed[category], key)
+	r.Records++
+}
+
+// Eraser removes message copies from Store. ForgetJob removes a finished job with its
+// artifact (and from the job manager's memory); when nil, the records are deleted directly.
+type Eraser struct {
+	Store     store.Store
+	ForgetJob func(ctx context.Context, id string) error
+}
+
+// ErrActiveJob is reported for matching jobs that are still queued or running.
+var ErrActiveJob = errors.New("job is still active; cancel it or retry once it finishes")
+
+// Erase deletes every record matching f. Snapshots go first, as messages found in them add
+// their ids to the annotations and triage items to remove. Records that can't be read or
+// deleted are listed in Report.Errors and the rest still proceeds.
+func (e *Eraser) Erase(ctx context.Context, f Filter, dryRun bool) (Report, error) {
+	if err := f.Validate(); err != nil {
+		return Report{}, err
+	}
+	rep := Report{DryRun: dryRun, Removed: map[string][]string{}}
+	ids := map[string]bool{}
+	for _, id := range f.MessageIDs {
+		ids[strings.TrimSpace(id)] = true
+	}
+	needle := []byte(f.Contains)
+	textMatch := func(v any) bool { return f.Contains != "" && containsText(v, f.Contains) }
+
+	remove := func(category, key string) {
+		if !dryRun {
+			if err := e.Store.Delete(ctx, category, key); err != nil {
+				rep.Errors = append(rep.Errors, fmt.Sprintf("%s/%s: %v", category, key, err))
+				return
+			}
+		}
+		rep.add(category, key)
+	}
+
+	// Snapshots: a receive is dropped whole when any of its messages match
+	e.scan(ctx, &rep, categorySnapshots, func(key string, raw []byte) {
+		var snap struct {
+			Messages []map[string]any `json:"messages"`
+		}
+		if err := json.Unmarshal(raw, &snap); err != nil {
+			rep.Errors = append(rep.Errors, fmt.Sprintf("%s/%s: %v", categorySnapshots, key, err))
+			return
+		}
+		matched := false
+		for _, m := range snap.Messages {
+			id, _ := m["message_id"].(string)
+			if ids[id] || textMatch(m) {
+				ids[id] = true
+				matched = true
+			}
+		}
+		if matched {
+			remove(categorySnapshots, key)
+		}
+	})
+
+	// Jobs: parameters, results and artifacts (exports) are all searched
+	matchedJobs := map[string]bool{}
+	e.scan(ctx, &rep, categoryArtifacts, func(key string, raw []byte) {
+		var a struct {
+			Data []byte `json:"data"`
+		}
+		if json.Unmarshal(raw, &a) == nil && ((len(needle) > 0 && bytes.Contains(a.Data, needle)) || containsAnyID(a.Data, ids)) {
+			matchedJobs[key] = true
+		}
+	})
+	e.scan(ctx, &rep, categoryJobs, func(key string, raw []byte) {
+		var job map[string]any
+		if json.Unmarshal(raw, &job) != nil {
+			return
+		}
+		if !matchedJobs[key] && !textMatch(job) && !containsAnyID(raw, ids) {
+			return
+		}
+		delete(matchedJobs, key)
+		if status, _ := job["status"].(string); status == "queued" || status == "running" {
+			rep.Errors = append(rep.Errors, fmt.Sprintf("%s/%s: %v", categoryJobs, key, ErrActiveJob))
+			return
+		}
+		e.forgetJob(ctx, &rep, key, dryRun)
+	})
+	for key := range matchedJobs {
+		// An artifact whose job record already expired
+		remove(categoryArtifacts, key)
+	}
+
+	// Approvals carry the parameters of the action they gate
+	e.scan(ctx, &rep, categoryApprovals, func(key string, raw []byte) {
+		var req any
+		if json.Unmarshal(raw, &req) == nil && (textMatch(req) || containsAnyID(raw, ids)) {
+			remove(categoryApprovals, key)
+		}
+	})
+
+	// Annotations and triage items are keyed by message id
+	for _, category := range []string{categoryAnnotations, categoryTriage} {
+		e.scan(ctx, &rep, category, func(key string, raw []byte) {
+			var rec any
+			if ids[key] || (json.Unmarshal(raw, &rec) == nil && textMatch(rec)) {
+				remove(category, key)
+			}
+		})
+	}
+
+	for id := range ids {
+		rep.MessageIDs = append(rep.MessageIDs, id)
+	}
+	sort.Strings(rep.MessageIDs)
+	for _, keys := range rep.Removed {
+		sort.Strings(keys)
+	}
+	return rep, nil
+}
+
+// scan calls fn with every readable record of category.
+func (e *Eraser) scan(ctx context.Context, rep *Report, category string, fn func(key string, raw []byte)) {
+	keys, err := e.Store.List(ctx, category)
+	if err != nil {
+		rep.Errors = append(rep.Errors, fmt.Sprintf("%s: %v", category, err))
+		return
+	}
+	for _, key := range keys {
+		raw, err := e.Store.Get(ctx, category, key)
+		if errors.Is(err, store.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			rep.Errors = append(rep.Errors, fmt.Sprintf("%s/%s: %v", category, key, err))
+			continue
+		}
+		fn(key, raw)
+	}
+}
+
+func (e *Eraser) forgetJob(ctx context.Context, rep *Report, id string, dryRun bool) {
+	if !dryRun {
+		var err error
+		if e.ForgetJob != nil {
+			err = e.ForgetJob(ctx, id)
+		} else if err = e.Store.Delete(ctx, categoryArtifacts, id); err == nil {
+			err = e.Store.Delete(ctx, categoryJobs, id)
+		}
+		if err != nil {
+			rep.Errors = append(rep.Errors, fmt.Sprintf("%s/%s: %v", categoryJobs, id, err))
+			return
+		}
+	}
+	rep.add(categoryJobs, id)
+}
+
+// containsText reports whether any string in a decoded JSON value (keys included)
+// contains needle.
+func containsText(v any, needle string) bool {
+	switch t := v.(type) {
+	case string:
+		return strings.Contains(t, needle)
+	case map[string]any:
+		for k, x := range t {
+			if strings.Contains(k, needle) || containsText(x, needle) {
+				return true
+			}
+		}
+	case []any:
+		return slices.ContainsFunc(t, func(x any) bool { return containsText(x, needle) })
+	}
+	return false
+}
+
+// containsAnyID reports whether raw mentions one of ids.
+func containsAnyID(raw []byte, ids map[string]bool) bool {
+	for id := range ids {
+		if bytes.Contains(raw, []byte(id)) {
+			return true
+		}
+	}
+	return false
+}
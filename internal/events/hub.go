@@ -0,0 +1,123 @@
+package events
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Notification types carried on the events stream.
+const (
+	TypeJobCompleted           = "job_completed"
+	TypeAlertThreshold         = "alert_threshold"
+	TypeCredentialsExpiring    = "credentials_expiring"
+	TypeQueueReconnected       = "queue_reconnected"
+	TypeApprovalRequested      = "approval_requested"
+	TypeApprovalDecided        = "approval_decided"
+	TypeMaintenanceOverride    = "maintenance_override"
+	TypeQueueLockChanged       = "queue_lock_changed"
+	TypeQueueCreated           = "queue_created"
+	TypeQueueAttributesChanged = "queue_attributes_changed"
+	TypeDataErased             = "data_erased"
+)
+
+// Severity levels, used by the UI to style toasts.
+const (
+	LevelInfo  = "info"
+	LevelWarn  = "warn"
+	LevelError = "error"
+)
+
+const (
+	subscriberBuffer = 16
+	replaySize       = 50
+)
+
+// Event is a server notification delivered to every connected client.
+type Event struct {
+	ID      uint64         `json:"id"`
+	Type    string         `json:"type"`
+	Level   string         `json:"level"`
+	Message string         `json:"message"`
+	Data    map[string]any `json:"data,omitempty"`
+	Time    time.Time      `json:"time"`
+}
+
+// Hub fans out events to subscribers and keeps a short replay buffer for reconnects.
+type Hub struct {
+	Log *slog.Logger
+
+	mu     sync.Mutex
+	nextID uint64
+	subs   map[chan Event]struct{}
+	recent []Event
+}
+
+// NewHub creates an empty Hub.
+func NewHub(log *slog.Logger) *Hub {
+	return &Hub{Log: log, subs: map[chan Event]struct{}{}}
+}
+
+// Publish assigns an id and timestamp to e and delivers it. Slow subscribers miss
+// events rather than blocking the publisher.
+func (h *Hub) Publish(e Event) {
+	if h == nil {
+		return
+	}
+	if e.Level == "" {
+		e.Level = LevelInfo
+	}
+
+	h.mu.Lock()
+	h.nextID++
+	e.ID = h.nextID
+	e.Time = time.Now().UTC()
+	h.recent = append(h.recent, e)
+	if len(h.recent) > replaySize {
+		h.recent = h.recent[len(h.recent)-replaySize:]
+	}
+	for ch := range h.subs {
+		select {
+		case ch <- e:
+		default:
+			h.Log.Warn("dropping event for slow subscriber", "type", e.Type, "id", e.ID)
+		}
+	}
+	h.mu.Unlock()
+
+	h.Log.Debug("event published", "type", e.Type, "id", e.ID)
+}
+
+// Recent returns the events in the replay buffer, oldest first.
+func (h *Hub) Recent() []Event {
+	if h == nil {
+		return nil
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]Event(nil), h.recent...)
+}
+
+// Subscribe registers a subscriber. Events newer than lastID are replayed first;
+// the returned func must be called to unsubscribe.
+func (h *Hub) Subscribe(lastID uint64) (<-chan Event, func()) {
+	ch := make(chan Event, subscriberBuffer+replaySize)
+
+	h.mu.Lock()
+	for _, e := range h.recent {
+		if lastID > 0 && e.ID > lastID {
+			ch <- e
+		}
+	}
+	h.subs[ch] = struct{}{}
+	h.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			h.mu.Lock()
+			delete(h.subs, ch)
+			h.mu.Unlock()
+		})
+	}
+}
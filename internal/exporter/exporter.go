@@ -0,0 +1,232 @@
+// Package exporter turns sqs-ui into a queue-depth exporter for Prometheus: it reads the
+// approximate counts of many queues on an interval and serves the last readings as gauges in
+// the text exposition format. Scrapes are answered from memory, so Prometheus polling never
+// turns into SQS calls. The same readings can be pushed to StatsD or DogStatsD.
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pachecoc/sqs-ui/internal/queueref"
+	"github.com/pachecoc/sqs-ui/internal/service"
+)
+
+// listPageSize is the ListQueues page size when discovering queues.
+const listPageSize = 1000
+
+// reading is the last result for one queue.
+type reading struct {
+	counts service.QueueCounts
+	err    error
+}
+
+// Exporter reads queue counts on an interval and serves them on /metrics.
+type Exporter struct {
+	// Base supplies the AWS client and region; its own queue isn't read unless listed.
+	Base *service.SQSService
+	// Queues are the queue names or URLs to read; when empty, every queue ListQueues returns
+	// (names starting with Prefix) is read, and new queues are picked up on the next round.
+	Queues []string
+	Prefix string
+	// Interval between rounds; Concurrency bounds the GetQueueAttributes calls in flight.
+	Interval    time.Duration
+	Concurrency int
+	// StatsD, when set, also receives every round's readings (optional).
+	StatsD *StatsD
+	Log    *slog.Logger
+
+	mu       sync.RWMutex
+	readings map[string]reading
+	services map[string]*service.SQSService // only touched by Collect
+	lastAt   time.Time
+	duration time.Duration
+	errors   int64 // failed queue reads since start
+	listErr  error
+}
+
+// Run reads every queue now and then every Interval until ctx is canceled.
+func (e *Exporter) Run(ctx context.Context) {
+	e.Log.Info("exporter started", "interval_seconds", e.Interval.Seconds(), "queues", e.Queues, "prefix", e.Prefix)
+	ticker := time.NewTicker(e.Interval)
+	defer ticker.Stop()
+	for {
+		e.Collect(ctx)
+		select {
+		case <-ctx.Done():
+			e.Log.Info("exporter stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Collect reads every queue once and replaces the served readings.
+func (e *Exporter) Collect(ctx context.Context) {
+	start := time.Now()
+	targets, listErr := e.targets(ctx)
+	if listErr != nil {
+		e.Log.Warn("could not list queues, reading the last known set", "error", listErr)
+	}
+
+	readings := make(map[string]reading, len(targets))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, max(1, e.Concurrency))
+	for _, svc := range targets {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			var counts service.QueueCounts
+			var err error
+			if svc.QueueURL == "" {
+				// Listed by name and not resolved yet (or resolving failed last round)
+				_, err = svc.FetchQueueURL(ctx)
+				err = service.TranslateAWSError(err)
+			}
+			if err == nil {
+				counts, err = svc.Counts(ctx)
+			}
+			mu.Lock()
+			readings[svc.QueueName] = reading{counts: counts, err: err}
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	var failed int64
+	for name, r := range readings {
+		if r.err != nil {
+			failed++
+			e.Log.Warn("could not read queue counts", "queue_name", name, "error", r.err)
+		}
+	}
+	e.mu.Lock()
+	e.readings, e.lastAt, e.duration, e.listErr = readings, time.Now(), time.Since(start), listErr
+	e.errors += failed
+	e.mu.Unlock()
+	if e.StatsD != nil {
+		if err := e.StatsD.publish(e.Base.Region, readings, time.Since(start), failed); err != nil {
+			e.Log.Warn("could not publish to StatsD", "error", err)
+		}
+	}
+	e.Log.Debug("exporter round done", "queues", len(readings), "failed", failed, "duration_ms", time.Since(start).Milliseconds())
+}
+
+// targets returns a service per queue to read, keyed as listed. Services are kept
+// between rounds, so a queue listed by name is only resolved to its URL once. When listing
+// fails, the last known queues are read again.
+func (e *Exporter) targets(ctx context.Context) (map[string]*service.SQSService, error) {
+	out := map[string]*service.SQSService{}
+	var listErr error
+	if len(e.Queues) > 0 {
+		for _, q := range e.Queues {
+			svc, ok := e.services[q]
+			if !ok {
+				name, url := queueref.Split(q)
+				svc = service.NewSQSService(ctx, e.Base.Client, name, url, e.Base.Region, e.Log)
+			}
+			out[q] = svc
+		}
+	} else {
+		token := ""
+		for {
+			page, err := e.Base.ListQueues(ctx, e.Prefix, listPageSize, token)
+			if err != nil {
+				listErr = service.TranslateAWSError(err)
+				out = e.services
+				break
+			}
+			for _, ref := range page.Queues {
+				svc, ok := e.services[ref.Name]
+				if !ok || svc.QueueURL != ref.URL {
+					svc = service.NewSQSService(ctx, e.Base.Client, ref.Name, ref.URL, e.Base.Region, e.Log)
+				}
+				out[ref.Name] = svc
+			}
+			if token = page.NextToken; token == "" {
+				break
+			}
+		}
+	}
+	e.services = out
+	return out, listErr
+}
+
+// Ready reports whether a round has completed, for readiness probes.
+func (e *Exporter) Ready() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return !e.lastAt.IsZero()
+}
+
+// ServeHTTP writes the last readings in the Prometheus text exposition format.
+func (e *Exporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	e.write(w)
+}
+
+func (e *Exporter) write(w io.Writer) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	names := make([]string, 0, len(e.readings))
+	for name := range e.readings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	region := escapeLabel(e.Base.Region)
+
+	gauge := func(metric, help string, value func(service.QueueCounts) int64) {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n", metric, help, metric)
+		for _, name := range names {
+			if r := e.readings[name]; r.err == nil {
+				fmt.Fprintf(w, "%s{queue=\"%s\",region=\"%s\"} %d\n", metric, escapeLabel(name), region, value(r.counts))
+			}
+		}
+	}
+	gauge("sqs_queue_messages_visible", "Approximate number of messages available for retrieval.",
+		func(c service.QueueCounts) int64 { return c.Visible })
+	gauge("sqs_queue_messages_in_flight", "Approximate number of messages received but not yet deleted.",
+		func(c service.QueueCounts) int64 { return c.NotVisible })
+	gauge("sqs_queue_messages_delayed", "Approximate number of messages delayed and not yet available.",
+		func(c service.QueueCounts) int64 { return c.Delayed })
+
+	fmt.Fprint(w, "# HELP sqs_queue_up Whether the last read of the queue's attributes succeeded.\n# TYPE sqs_queue_up gauge\n")
+	for _, name := range names {
+		up := 1
+		if e.readings[name].err != nil {
+			up = 0
+		}
+		fmt.Fprintf(w, "sqs_queue_up{queue=\"%s\",region=\"%s\"} %d\n", escapeLabel(name), region, up)
+	}
+
+	listUp := 1
+	if e.listErr != nil {
+		listUp = 0
+	}
+	fmt.Fprintf(w, "# HELP sqs_exporter_queues Queues read in the last round.\n# TYPE sqs_exporter_queues gauge\nsqs_exporter_queues %d\n", len(names))
+	fmt.Fprintf(w, "# HELP sqs_exporter_list_up Whether the last ListQueues discovery succeeded.\n# TYPE sqs_exporter_list_up gauge\nsqs_exporter_list_up %d\n", listUp)
+	fmt.Fprintf(w, "# HELP sqs_exporter_read_errors_total Failed queue reads since start.\n# TYPE sqs_exporter_read_errors_total counter\nsqs_exporter_read_errors_total %d\n", e.errors)
+	if !e.lastAt.IsZero() {
+		fmt.Fprintf(w, "# HELP sqs_exporter_last_round_timestamp_seconds When the last round finished.\n# TYPE sqs_exporter_last_round_timestamp_seconds gauge\nsqs_exporter_last_round_timestamp_seconds %d\n", e.lastAt.Unix())
+		fmt.Fprintf(w, "# HELP sqs_exporter_round_duration_seconds How long the last round took.\n# TYPE sqs_exporter_round_duration_seconds gauge\nsqs_exporter_round_duration_seconds %g\n", e.duration.Seconds())
+	}
+}
+
+// escapeLabel escapes a label value as the exposition format requires.
+func escapeLabel(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
@@ -0,0 +1,120 @@
+package exporter
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxPacket keeps datagrams under a typical MTU; DogStatsD and StatsD both accept several
+// metrics per packet, one per line.
+const maxPacket = 1432
+
+// statsdUnsafe matches what plain StatsD metric names can't carry.
+var statsdUnsafe = regexp.MustCompile(`[^a-zA-Z0-9_.-]`)
+
+// StatsD pushes each round's readings to a StatsD or DogStatsD agent over UDP, for fleets
+// that don't scrape Prometheus. Gauges match the /metrics ones: sqs_queue_messages_visible
+// becomes <Prefix>queue.messages_visible.
+type StatsD struct {
+	// Addr is the agent's host:port.
+	Addr string
+	// Prefix starts every metric name ("sqs." when empty).
+	Prefix string
+	// Plain sends Etsy StatsD: no tags, so the queue goes into the metric name
+	// (<Prefix>queue.<name>.messages_visible). Otherwise DogStatsD tags carry queue and region.
+	Plain bool
+	// Tags are DogStatsD tags added to every metric (env:prod, team:payments).
+	Tags []string
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+// Validate checks Addr, so a typo fails at startup rather than on every round.
+func (s *StatsD) Validate() error {
+	if _, _, err := net.SplitHostPort(s.Addr); err != nil {
+		return fmt.Errorf("StatsD address %q must be host:port: %w", s.Addr, err)
+	}
+	return nil
+}
+
+// publish sends one round: the per-queue gauges, then the exporter's own metrics.
+func (s *StatsD) publish(region string, readings map[string]reading, duration time.Duration, failed int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		conn, err := net.Dial("udp", s.Addr)
+		if err != nil {
+			return fmt.Errorf("could not reach StatsD at %s: %w", s.Addr, err)
+		}
+		s.conn = conn
+	}
+	prefix := s.Prefix
+	if prefix == "" {
+		prefix = "sqs."
+	}
+
+	var pkt, line bytes.Buffer
+	var sendErr error
+	emit := func(name, value, kind string, tags ...string) {
+		line.Reset()
+		fmt.Fprintf(&line, "%s%s:%s|%s", prefix, name, value, kind)
+		if !s.Plain {
+			if tags = append(tags, s.Tags...); len(tags) > 0 {
+				line.WriteString("|#" + strings.Join(tags, ","))
+			}
+		}
+		if pkt.Len() > 0 && pkt.Len()+1+line.Len() > maxPacket {
+			if _, err := s.conn.Write(pkt.Bytes()); err != nil && sendErr == nil {
+				sendErr = err
+			}
+			pkt.Reset()
+		}
+		if pkt.Len() > 0 {
+			pkt.WriteByte('\n')
+		}
+		pkt.Write(line.Bytes())
+	}
+
+	for queue, r := range readings {
+		name := "queue."
+		var tags []string
+		if s.Plain {
+			name += statsdUnsafe.ReplaceAllString(strings.ReplaceAll(queue, ".", "_"), "_") + "."
+		} else {
+			tags = []string{"queue:" + queue, "region:" + region}
+		}
+		up := "1"
+		if r.err != nil {
+			up = "0"
+		}
+		emit(name+"up", up, "g", tags...)
+		if r.err != nil {
+			continue
+		}
+		emit(name+"messages_visible", fmt.Sprint(r.counts.Visible), "g", tags...)
+		emit(name+"messages_in_flight", fmt.Sprint(r.counts.NotVisible), "g", tags...)
+		emit(name+"messages_delayed", fmt.Sprint(r.counts.Delayed), "g", tags...)
+	}
+	emit("exporter.queues", fmt.Sprint(len(readings)), "g")
+	emit("exporter.read_errors", fmt.Sprint(failed), "c")
+	emit("exporter.round_duration", fmt.Sprint(duration.Milliseconds()), "ms")
+
+	if pkt.Len() > 0 {
+		if _, err := s.conn.Write(pkt.Bytes()); err != nil && sendErr == nil {
+			sendErr = err
+		}
+	}
+	if sendErr != nil {
+		// Redial next round: the agent may have moved (a restarted DaemonSet pod)
+		_ = s.conn.Close()
+		s.conn = nil
+		return fmt.Errorf("could not send to StatsD at %s: %w", s.Addr, sendErr)
+	}
+	return nil
+}
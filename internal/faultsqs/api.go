@@ -0,0 +1,180 @@
+package faultsqs
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+func (c *Client) GetQueueUrl(ctx context.Context, in *sqs.GetQueueUrlInput, optFns ...func(*sqs.Options)) (*sqs.GetQueueUrlOutput, error) {
+	if err := c.inject(ctx, "GetQueueUrl"); err != nil {
+		return nil, err
+	}
+	return c.API.GetQueueUrl(ctx, in, optFns...)
+}
+
+func (c *Client) GetQueueAttributes(ctx context.Context, in *sqs.GetQueueAttributesInput, optFns ...func(*sqs.Options)) (*sqs.GetQueueAttributesOutput, error) {
+	if err := c.inject(ctx, "GetQueueAttributes"); err != nil {
+		return nil, err
+	}
+	return c.API.GetQueueAttributes(ctx, in, optFns...)
+}
+
+func (c *Client) SetQueueAttributes(ctx context.Context, in *sqs.SetQueueAttributesInput, optFns ...func(*sqs.Options)) (*sqs.SetQueueAttributesOutput, error) {
+	if err := c.inject(ctx, "SetQueueAttributes"); err != nil {
+		return nil, err
+	}
+	return c.API.SetQueueAttributes(ctx, in, optFns...)
+}
+
+func (c *Client) ListQueues(ctx context.Context, in *sqs.ListQueuesInput, optFns ...func(*sqs.Options)) (*sqs.ListQueuesOutput, error) {
+	if err := c.inject(ctx, "ListQueues"); err != nil {
+		return nil, err
+	}
+	return c.API.ListQueues(ctx, in, optFns...)
+}
+
+func (c *Client) ListDeadLetterSourceQueues(ctx context.Context, in *sqs.ListDeadLetterSourceQueuesInput, optFns ...func(*sqs.Options)) (*sqs.ListDeadLetterSourceQueuesOutput, error) {
+	if err := c.inject(ctx, "ListDeadLetterSourceQueues"); err != nil {
+		return nil, err
+	}
+	return c.API.ListDeadLetterSourceQueues(ctx, in, optFns...)
+}
+
+func (c *Client) CreateQueue(ctx context.Context, in *sqs.CreateQueueInput, optFns ...func(*sqs.Options)) (*sqs.CreateQueueOutput, error) {
+	if err := c.inject(ctx, "CreateQueue"); err != nil {
+		return nil, err
+	}
+	return c.API.CreateQueue(ctx, in, optFns...)
+}
+
+func (c *Client) DeleteQueue(ctx context.Context, in *sqs.DeleteQueueInput, optFns ...func(*sqs.Options)) (*sqs.DeleteQueueOutput, error) {
+	if err := c.inject(ctx, "DeleteQueue"); err != nil {
+		return nil, err
+	}
+	return c.API.DeleteQueue(ctx, in, optFns...)
+}
+
+func (c *Client) PurgeQueue(ctx context.Context, in *sqs.PurgeQueueInput, optFns ...func(*sqs.Options)) (*sqs.PurgeQueueOutput, error) {
+	if err := c.inject(ctx, "PurgeQueue"); err != nil {
+		return nil, err
+	}
+	return c.API.PurgeQueue(ctx, in, optFns...)
+}
+
+func (c *Client) ListQueueTags(ctx context.Context, in *sqs.ListQueueTagsInput, optFns ...func(*sqs.Options)) (*sqs.ListQueueTagsOutput, error) {
+	if err := c.inject(ctx, "ListQueueTags"); err != nil {
+		return nil, err
+	}
+	return c.API.ListQueueTags(ctx, in, optFns...)
+}
+
+func (c *Client) TagQueue(ctx context.Context, in *sqs.TagQueueInput, optFns ...func(*sqs.Options)) (*sqs.TagQueueOutput, error) {
+	if err := c.inject(ctx, "TagQueue"); err != nil {
+		return nil, err
+	}
+	return c.API.TagQueue(ctx, in, optFns...)
+}
+
+func (c *Client) UntagQueue(ctx context.Context, in *sqs.UntagQueueInput, optFns ...func(*sqs.Options)) (*sqs.UntagQueueOutput, error) {
+	if err := c.inject(ctx, "UntagQueue"); err != nil {
+		return nil, err
+	}
+	return c.API.UntagQueue(ctx, in, optFns...)
+}
+
+func (c *Client) SendMessage(ctx context.Context, in *sqs.SendMessageInput, optFns ...func(*sqs.Options)) (*sqs.SendMessageOutput, error) {
+	if err := c.inject(ctx, "SendMessage"); err != nil {
+		return nil, err
+	}
+	return c.API.SendMessage(ctx, in, optFns...)
+}
+
+func (c *Client) ReceiveMessage(ctx context.Context, in *sqs.ReceiveMessageInput, optFns ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error) {
+	if err := c.inject(ctx, "ReceiveMessage"); err != nil {
+		return nil, err
+	}
+	return c.API.ReceiveMessage(ctx, in, optFns...)
+}
+
+// The batch calls only pass the entries that survive PartialRate to API and report the
+// others as failed, as SQS does when some entries of a batch fail.
+
+func (c *Client) SendMessageBatch(ctx context.Context, in *sqs.SendMessageBatchInput, optFns ...func(*sqs.Options)) (*sqs.SendMessageBatchOutput, error) {
+	const op = "SendMessageBatch"
+	if err := c.inject(ctx, op); err != nil {
+		return nil, err
+	}
+	var failed []types.BatchResultErrorEntry
+	pass := *in
+	pass.Entries = nil
+	for i, drop := range c.dropEntries(op, len(in.Entries)) {
+		if drop {
+			failed = append(failed, failedEntry(in.Entries[i].Id, op))
+		} else {
+			pass.Entries = append(pass.Entries, in.Entries[i])
+		}
+	}
+	out := &sqs.SendMessageBatchOutput{}
+	if len(pass.Entries) > 0 {
+		var err error
+		if out, err = c.API.SendMessageBatch(ctx, &pass, optFns...); err != nil {
+			return nil, err
+		}
+	}
+	out.Failed = append(out.Failed, failed...)
+	return out, nil
+}
+
+func (c *Client) DeleteMessageBatch(ctx context.Context, in *sqs.DeleteMessageBatchInput, optFns ...func(*sqs.Options)) (*sqs.DeleteMessageBatchOutput, error) {
+	const op = "DeleteMessageBatch"
+	if err := c.inject(ctx, op); err != nil {
+		return nil, err
+	}
+	var failed []types.BatchResultErrorEntry
+	pass := *in
+	pass.Entries = nil
+	for i, drop := range c.dropEntries(op, len(in.Entries)) {
+		if drop {
+			failed = append(failed, failedEntry(in.Entries[i].Id, op))
+		} else {
+			pass.Entries = append(pass.Entries, in.Entries[i])
+		}
+	}
+	out := &sqs.DeleteMessageBatchOutput{}
+	if len(pass.Entries) > 0 {
+		var err error
+		if out, err = c.API.DeleteMessageBatch(ctx, &pass, optFns...); err != nil {
+			return nil, err
+		}
+	}
+	out.Failed = append(out.Failed, failed...)
+	return out, nil
+}
+
+func (c *Client) ChangeMessageVisibilityBatch(ctx context.Context, in *sqs.ChangeMessageVisibilityBatchInput, optFns ...func(*sqs.Options)) (*sqs.ChangeMessageVisibilityBatchOutput, error) {
+	const op = "ChangeMessageVisibilityBatch"
+	if err := c.inject(ctx, op); err != nil {
+		return nil, err
+	}
+	var failed []types.BatchResultErrorEntry
+	pass := *in
+	pass.Entries = nil
+	for i, drop := range c.dropEntries(op, len(in.Entries)) {
+		if drop {
+			failed = append(failed, failedEntry(in.Entries[i].Id, op))
+		} else {
+			pass.Entries = append(pass.Entries, in.Entries[i])
+		}
+	}
+	out := &sqs.ChangeMessageVisibilityBatchOutput{}
+	if len(pass.Entries) > 0 {
+		var err error
+		if out, err = c.API.ChangeMessageVisibilityBatch(ctx, &pass, optFns...); err != nil {
+			return nil, err
+		}
+	}
+	out.Failed = append(out.Failed, failed...)
+	return out, nil
+}
@@ -0,0 +1,195 @@
+// Package faultsqs wraps a service.SQSAPI and injects errors, latency and partial batch
+// failures, so retries, timeouts and partial results can be exercised deterministically in
+// tests and demo mode. Faults are drawn from a seeded source: the same seed and the same
+// sequence of calls fail the same way.
+package faultsqs
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/aws/smithy-go"
+
+	"github.com/pachecoc/sqs-ui/internal/service"
+)
+
+// DefaultCode is the error code of injected failures: a server fault callers may retry.
+const DefaultCode = "ServiceUnavailable"
+
+// Fault is one injection rule.
+type Fault struct {
+	// Ops are the operations it applies to (e.g. "ReceiveMessage"); empty means all.
+	Ops []string
+	// ErrorRate is the probability, 0 to 1, that a call fails with Code.
+	ErrorRate float64
+	Code      string
+	// Latency delays each call, plus up to Jitter more; a context ending first cancels it.
+	Latency time.Duration
+	Jitter  time.Duration
+	// PartialRate is the probability that each entry of a batch call (send, delete, change
+	// visibility) fails on its own while the others go through.
+	PartialRate float64
+}
+
+func (f Fault) applies(op string) bool {
+	return len(f.Ops) == 0 || slices.Contains(f.Ops, op)
+}
+
+// Client is a service.SQSAPI that runs Faults before delegating to API.
+type Client struct {
+	API    service.SQSAPI
+	Faults []Fault
+
+	mu  sync.Mutex
+	rng *rand.Rand
+}
+
+var _ service.SQSAPI = (*Client)(nil)
+
+// New wraps api with faults drawn from seed.
+func New(api service.SQSAPI, seed uint64, faults ...Fault) *Client {
+	return &Client{API: api, Faults: faults, rng: rand.New(rand.NewPCG(seed, seed))}
+}
+
+func (c *Client) float() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.rng.Float64()
+}
+
+func (c *Client) jitter(upTo time.Duration) time.Duration {
+	if upTo <= 0 {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return time.Duration(c.rng.Int64N(int64(upTo)))
+}
+
+// inject applies op's latency, then fails it at the faults' error rates.
+func (c *Client) inject(ctx context.Context, op string) error {
+	var delay time.Duration
+	for _, f := range c.Faults {
+		if f.applies(op) {
+			delay += f.Latency + c.jitter(f.Jitter)
+		}
+	}
+	if delay > 0 {
+		t := time.NewTimer(delay)
+		defer t.Stop()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+	for _, f := range c.Faults {
+		if f.applies(op) && f.ErrorRate > 0 && c.float() < f.ErrorRate {
+			return &smithy.GenericAPIError{
+				Code:    cmpOr(f.Code, DefaultCode),
+				Message: "injected fault on " + op,
+				Fault:   smithy.FaultServer,
+			}
+		}
+	}
+	return nil
+}
+
+// dropEntries decides which of n batch entries fail on their own.
+func (c *Client) dropEntries(op string, n int) []bool {
+	var rate float64
+	for _, f := range c.Faults {
+		if f.applies(op) {
+			rate = max(rate, f.PartialRate)
+		}
+	}
+	drop := make([]bool, n)
+	if rate <= 0 {
+		return drop
+	}
+	for i := range drop {
+		drop[i] = c.float() < rate
+	}
+	return drop
+}
+
+func failedEntry(id *string, op string) types.BatchResultErrorEntry {
+	return types.BatchResultErrorEntry{
+		Id:          id,
+		Code:        aws.String("InternalError"),
+		Message:     aws.String("injected partial failure on " + op),
+		SenderFault: false,
+	}
+}
+
+func cmpOr(s, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
+
+// Parse reads faults from a spec of ";"-separated rules, each an optional "Op|Op:" list
+// ("*" or nothing for all operations) followed by comma-separated settings:
+//
+//	ReceiveMessage:error=0.2,latency=300ms,jitter=200ms;DeleteMessageBatch:partial=0.3;*:latency=50ms
+//
+// Settings are error (rate), code, latency, jitter and partial (rate).
+func Parse(spec string) ([]Fault, error) {
+	var faults []Fault
+	for _, rule := range strings.Split(spec, ";") {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+		var f Fault
+		settings := rule
+		if ops, rest, ok := strings.Cut(rule, ":"); ok {
+			settings = rest
+			if ops = strings.TrimSpace(ops); ops != "*" && ops != "" {
+				for _, op := range strings.Split(ops, "|") {
+					f.Ops = append(f.Ops, strings.TrimSpace(op))
+				}
+			}
+		}
+		for _, kv := range strings.Split(settings, ",") {
+			key, value, _ := strings.Cut(strings.TrimSpace(kv), "=")
+			var err error
+			switch key {
+			case "error":
+				f.ErrorRate, err = parseRate(value)
+			case "partial":
+				f.PartialRate, err = parseRate(value)
+			case "latency":
+				f.Latency, err = time.ParseDuration(value)
+			case "jitter":
+				f.Jitter, err = time.ParseDuration(value)
+			case "code":
+				f.Code = value
+			default:
+				err = fmt.Errorf("unknown setting %q (want error, code, latency, jitter or partial)", key)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("fault %q: %w", rule, err)
+			}
+		}
+		faults = append(faults, f)
+	}
+	return faults, nil
+}
+
+func parseRate(s string) (float64, error) {
+	r, err := strconv.ParseFloat(s, 64)
+	if err != nil || r < 0 || r > 1 {
+		return 0, fmt.Errorf("rate %q must be between 0 and 1", s)
+	}
+	return r, nil
+}
@@ -0,0 +1,138 @@
+package faultsqs_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/aws/smithy-go"
+
+	"github.com/pachecoc/sqs-ui/internal/faultsqs"
+	"github.com/pachecoc/sqs-ui/internal/memsqs"
+)
+
+func newQueue(t *testing.T) (*memsqs.Client, *string) {
+	t.Helper()
+	mem := memsqs.New()
+	out, err := mem.CreateQueue(context.Background(), &sqs.CreateQueueInput{QueueName: aws.String("orders")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return mem, out.QueueUrl
+}
+
+func TestParse(t *testing.T) {
+	faults, err := faultsqs.Parse("ReceiveMessage:error=0.2,latency=300ms,jitter=200ms; DeleteMessageBatch|SendMessageBatch:partial=0.3;*:latency=50ms,code=ThrottlingException")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []faultsqs.Fault{
+		{Ops: []string{"ReceiveMessage"}, ErrorRate: 0.2, Latency: 300 * time.Millisecond, Jitter: 200 * time.Millisecond},
+		{Ops: []string{"DeleteMessageBatch", "SendMessageBatch"}, PartialRate: 0.3},
+		{Latency: 50 * time.Millisecond, Code: "ThrottlingException"},
+	}
+	if !reflect.DeepEqual(faults, want) {
+		t.Errorf("parsed %+v\nwant %+v", faults, want)
+	}
+
+	for _, spec := range []string{"error=2", "ReceiveMessage:partial=-0.1", "latency=soon", "*:retries=3"} {
+		if _, err := faultsqs.Parse(spec); err == nil {
+			t.Errorf("%q parsed", spec)
+		}
+	}
+}
+
+// failures calls GetQueueAttributes n times through a fresh client and reports which failed.
+func failures(t *testing.T, seed uint64, n int) []bool {
+	t.Helper()
+	mem, url := newQueue(t)
+	c := faultsqs.New(mem, seed, faultsqs.Fault{Ops: []string{"GetQueueAttributes"}, ErrorRate: 0.5})
+	failed := make([]bool, n)
+	for i := range failed {
+		_, err := c.GetQueueAttributes(context.Background(), &sqs.GetQueueAttributesInput{QueueUrl: url})
+		if err != nil {
+			var apiErr smithy.APIError
+			if !errors.As(err, &apiErr) || apiErr.ErrorCode() != faultsqs.DefaultCode || apiErr.ErrorFault() != smithy.FaultServer {
+				t.Fatalf("injected error %v, want a %s server fault", err, faultsqs.DefaultCode)
+			}
+			failed[i] = true
+		}
+	}
+	return failed
+}
+
+func TestSameSeedSameFaults(t *testing.T) {
+	first, second := failures(t, 7, 32), failures(t, 7, 32)
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("seed 7 failed differently: %v, then %v", first, second)
+	}
+	var n int
+	for _, f := range first {
+		if f {
+			n++
+		}
+	}
+	if n == 0 || n == len(first) {
+		t.Errorf("%d of %d calls failed at rate 0.5", n, len(first))
+	}
+}
+
+func TestFaultsApplyToTheirOps(t *testing.T) {
+	ctx := context.Background()
+	mem, url := newQueue(t)
+	c := faultsqs.New(mem, 1, faultsqs.Fault{Ops: []string{"ReceiveMessage"}, ErrorRate: 1})
+
+	if _, err := c.SendMessage(ctx, &sqs.SendMessageInput{QueueUrl: url, MessageBody: aws.String("hello")}); err != nil {
+		t.Errorf("send: %v", err)
+	}
+	if _, err := c.ReceiveMessage(ctx, &sqs.ReceiveMessageInput{QueueUrl: url}); err == nil {
+		t.Error("receive went through an error rate of 1")
+	}
+}
+
+func TestPartialBatch(t *testing.T) {
+	ctx := context.Background()
+	mem, url := newQueue(t)
+	c := faultsqs.New(mem, 1, faultsqs.Fault{Ops: []string{"SendMessageBatch"}, PartialRate: 1})
+
+	entries := make([]types.SendMessageBatchRequestEntry, 3)
+	for i := range entries {
+		entries[i] = types.SendMessageBatchRequestEntry{Id: aws.String(strconv.Itoa(i)), MessageBody: aws.String("batch")}
+	}
+	out, err := c.SendMessageBatch(ctx, &sqs.SendMessageBatchInput{QueueUrl: url, Entries: entries})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out.Successful) != 0 || len(out.Failed) != 3 {
+		t.Errorf("%d sent, %d failed, want every entry failed", len(out.Successful), len(out.Failed))
+	}
+	recv, err := mem.ReceiveMessage(ctx, &sqs.ReceiveMessageInput{QueueUrl: url, MaxNumberOfMessages: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recv.Messages) != 0 {
+		t.Errorf("%d failed entries reached the queue", len(recv.Messages))
+	}
+}
+
+func TestLatencyEndsWithContext(t *testing.T) {
+	mem, url := newQueue(t)
+	c := faultsqs.New(mem, 1, faultsqs.Fault{Latency: time.Hour})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := c.GetQueueAttributes(ctx, &sqs.GetQueueAttributesInput{QueueUrl: url})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("delayed call: %v, want the context's deadline", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("delayed call returned after %v", elapsed)
+	}
+}
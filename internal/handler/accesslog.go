@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// accessWriter records what AccessLog reports about a response.
+type accessWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+	code   string
+}
+
+// Unwrap lets http.ResponseController and metaFor reach the underlying writer.
+func (aw *accessWriter) Unwrap() http.ResponseWriter { return aw.ResponseWriter }
+
+func (aw *accessWriter) WriteHeader(status int) {
+	if aw.status == 0 {
+		aw.status = status
+	}
+	aw.ResponseWriter.WriteHeader(status)
+}
+
+func (aw *accessWriter) Write(b []byte) (int, error) {
+	if aw.status == 0 {
+		aw.status = http.StatusOK
+	}
+	n, err := aw.ResponseWriter.Write(b)
+	aw.bytes += int64(n)
+	return n, err
+}
+
+// AccessLog logs one "request" record per request once it completes, with its method, path
+// (never the query, which may carry message content), status, error code, size and
+// duration. Wrapped by RequestMeta, records carry the request_id. Health probes that
+// succeed are logged at debug level so they don't drown everything else.
+func AccessLog(log *slog.Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		aw := &accessWriter{ResponseWriter: w}
+		next.ServeHTTP(aw, r)
+		if aw.status == 0 {
+			aw.status = http.StatusOK
+		}
+
+		level := slog.LevelInfo
+		switch {
+		case aw.status >= 500:
+			level = slog.LevelWarn
+		case (r.URL.Path == "/healthz" || r.URL.Path == "/readyz") && aw.status < 400:
+			level = slog.LevelDebug
+		}
+		attrs := []slog.Attr{
+			slog.String("method", r.Method),
+			slog.String("path", logPath(r.URL.Path)),
+			slog.Int("status", aw.status),
+			slog.Int64("bytes", aw.bytes),
+			slog.Int64("duration_ms", time.Since(start).Milliseconds()),
+			slog.String("remote_addr", r.RemoteAddr),
+		}
+		if aw.code != "" {
+			attrs = append(attrs, slog.String("error_code", aw.code))
+		}
+		if ua := r.UserAgent(); ua != "" {
+			attrs = append(attrs, slog.String("user_agent", ua))
+		}
+		log.LogAttrs(r.Context(), level, "request", attrs...)
+	})
+}
+
+// logPath is the path as logged: share link tokens are credentials, so they're left out.
+func logPath(p string) string {
+	for _, prefix := range []string{"/api/shared/", "/shared/"} {
+		if strings.HasPrefix(p, prefix) {
+			return prefix + "[redacted]"
+		}
+	}
+	return p
+}
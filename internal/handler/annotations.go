@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/pachecoc/sqs-ui/internal/annotations"
+)
+
+// handleAnnotations searches annotations: GET ?q=<text>&label=<label>.
+func (h *APIHandler) handleAnnotations(w http.ResponseWriter, r *http.Request) {
+	if !enforceMethod(w, r, http.MethodGet) {
+		return
+	}
+	if h.Annotations == nil {
+		respondError(w, http.StatusServiceUnavailable, errors.New("annotations are not enabled"))
+		return
+	}
+
+	query := r.URL.Query()
+	found, err := h.Annotations.Search(r.Context(), query.Get("q"), query.Get("label"))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]any{"annotations": found})
+}
+
+// handleAnnotation reads (GET), sets (PUT { "labels": [...], "note": "...", "author": "..." })
+// or removes (DELETE) the annotation of one message.
+func (h *APIHandler) handleAnnotation(w http.ResponseWriter, r *http.Request) {
+	if h.Annotations == nil {
+		respondError(w, http.StatusServiceUnavailable, errors.New("annotations are not enabled"))
+		return
+	}
+	id := r.PathValue("id")
+
+	switch r.Method {
+	case http.MethodGet:
+		a, err := h.Annotations.Get(r.Context(), id)
+		if errors.Is(err, annotations.ErrNotFound) {
+			respondError(w, http.StatusNotFound, err)
+			return
+		}
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, err)
+			return
+		}
+		respondJSON(w, http.StatusOK, a)
+	case http.MethodPut:
+		var a annotations.Annotation
+		if err := json.NewDecoder(r.Body).Decode(&a); err != nil {
+			respondError(w, http.StatusBadRequest, err)
+			return
+		}
+		a.MessageID = id
+		saved, err := h.Annotations.Put(r.Context(), a)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, err)
+			return
+		}
+		h.Log.InfoContext(r.Context(), "message annotated", "message_id", id, "author", saved.Author, "labels", saved.Labels)
+		respondJSON(w, http.StatusOK, saved)
+	case http.MethodDelete:
+		if err := h.Annotations.Delete(r.Context(), id); err != nil {
+			respondError(w, http.StatusInternalServerError, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case http.MethodOptions:
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.Header().Set("Allow", "GET, PUT, DELETE")
+		respondError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+	}
+}
@@ -4,61 +4,319 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/config"
-	"github.com/aws/aws-sdk-go-v2/service/sqs"
 
+	"github.com/pachecoc/sqs-ui/internal/annotations"
+	"github.com/pachecoc/sqs-ui/internal/approvals"
+	"github.com/pachecoc/sqs-ui/internal/auth"
+	"github.com/pachecoc/sqs-ui/internal/digest"
+	"github.com/pachecoc/sqs-ui/internal/erasure"
+	"github.com/pachecoc/sqs-ui/internal/events"
+	"github.com/pachecoc/sqs-ui/internal/jobs"
+	"github.com/pachecoc/sqs-ui/internal/lifecycle"
+	"github.com/pachecoc/sqs-ui/internal/locks"
+	"github.com/pachecoc/sqs-ui/internal/maintenance"
+	"github.com/pachecoc/sqs-ui/internal/metrics"
+	"github.com/pachecoc/sqs-ui/internal/plugin"
+	"github.com/pachecoc/sqs-ui/internal/profiles"
+	"github.com/pachecoc/sqs-ui/internal/provision"
+	"github.com/pachecoc/sqs-ui/internal/rbac"
+	"github.com/pachecoc/sqs-ui/internal/retention"
+	"github.com/pachecoc/sqs-ui/internal/scratch"
+	"github.com/pachecoc/sqs-ui/internal/scripts"
+	"github.com/pachecoc/sqs-ui/internal/secrets"
 	"github.com/pachecoc/sqs-ui/internal/service"
+	"github.com/pachecoc/sqs-ui/internal/shares"
+	"github.com/pachecoc/sqs-ui/internal/store"
+	"github.com/pachecoc/sqs-ui/internal/telemetry"
+	"github.com/pachecoc/sqs-ui/internal/tracing"
+	"github.com/pachecoc/sqs-ui/internal/triage"
+	"github.com/pachecoc/sqs-ui/internal/updates"
+	"github.com/pachecoc/sqs-ui/internal/validate"
 	"github.com/pachecoc/sqs-ui/internal/version"
+	"github.com/pachecoc/sqs-ui/internal/watch"
 )
 
+// defaultInfoStreamInterval is used when InfoStreamInterval is not set.
+const defaultInfoStreamInterval = 5 * time.Second
+
 // APIHandler provides HTTP endpoints for interacting with SQS.
 type APIHandler struct {
 	SQS *service.SQSService
 	Log *slog.Logger
 	mu  sync.RWMutex // switched to RWMutex: reads dominate, queue change is rare
+
+	// InfoStreamInterval is how often /api/info/stream pushes queue attributes.
+	InfoStreamInterval time.Duration
+
+	// Events receives server notifications streamed on /api/events (optional).
+	Events *events.Hub
+
+	// Jobs runs background operations exposed under /api/jobs (optional).
+	Jobs *jobs.Manager
+
+	// Annotations holds team labels and notes shown in listings (optional).
+	Annotations *annotations.Manager
+
+	// Triage tracks assignment and resolution of DLQ messages (optional).
+	Triage *triage.Manager
+
+	// Scripts holds user filter/transform scripts applied to listings (optional).
+	Scripts *scripts.Manager
+
+	// Digest posts periodic queue health summaries; exposed on /api/digest (optional).
+	Digest *digest.Digester
+
+	// SlackSigningSecret enables /api/slack/commands when set.
+	SlackSigningSecret *secrets.Value
+
+	// Approvals gates destructive actions on protected queues behind a second user (optional).
+	Approvals *approvals.Manager
+
+	// Profiles holds per-queue settings applied whenever a queue is selected (optional).
+	Profiles *profiles.Manager
+
+	// InFlight records in-flight history for the stuck-message report on /api/queue/health (optional).
+	InFlight *watch.InFlightTracker
+
+	// Depth keeps sampled queue depth for /api/metrics/history (optional).
+	Depth *watch.DepthPoller
+
+	// CloudWatch serves historical queue metrics on /api/metrics/cloudwatch (optional).
+	CloudWatch *metrics.Fetcher
+
+	// Maintenance limits destructive actions to configured time windows (optional).
+	Maintenance *maintenance.Policy
+
+	// Locks holds per-queue observe-only locks; nil never locks (optional).
+	Locks *locks.Manager
+
+	// Provisioner checks and creates queues for POST /api/queues (optional).
+	Provisioner *provision.Provisioner
+
+	// Scratch creates temporary queues for POST /api/queues/scratch (optional).
+	Scratch *scratch.Manager
+
+	// SQSEndpoint, when set, replaces the AWS SQS endpoint for clients built on a queue
+	// switch (LocalStack, ElasticMQ).
+	SQSEndpoint string
+
+	// Client, when set, serves every queue switch instead of a client built from the AWS
+	// config (DEMO_MODE's in-memory emulator).
+	Client service.SQSAPI
+
+	// UserHeader names the request header carrying the user, set by an authenticating proxy.
+	UserHeader string
+
+	// Auth signs users in with OIDC; Authenticate gates every route on its sessions (optional).
+	Auth *auth.OIDC
+
+	// Tokens authenticate automation on /api/ routes with a bearer token (optional).
+	Tokens *auth.Tokens
+
+	// RequestTimeout is the budget of one API request; service calls derive their deadlines
+	// from it. Zero leaves requests unbounded.
+	RequestTimeout time.Duration
+
+	// ReconnectHint is how long stream clients are told to wait before reconnecting when
+	// the server shuts down.
+	ReconnectHint time.Duration
+
+	// Store keeps receive snapshots so paginated /api/messages cursors work across replicas.
+	// When nil, snapshots are kept in memory.
+	Store store.Store
+
+	// PurgeConfirmTTL is how long the confirm token of a purge stays valid (2 minutes when
+	// zero).
+	PurgeConfirmTTL time.Duration
+
+	// Admins may call /api/admin endpoints; empty disables them.
+	Admins []string
+
+	// RBAC limits what each user may do by role (see writeRoles); nil lets everyone do
+	// everything (optional).
+	RBAC *rbac.Policy
+
+	// Eraser deletes stored message copies for /api/admin/erase.
+	Eraser *erasure.Eraser
+
+	// Updates compares the build with the latest release for /api/version/check (optional).
+	Updates *updates.Checker
+
+	// Telemetry counts route usage and error codes for opt-in usage reports (optional).
+	Telemetry *telemetry.Reporter
+
+	// Retention reports store usage on /api/storage and enforces retention policies.
+	Retention *retention.Janitor
+
+	// Shares issues signed read-only links for people without an account (optional).
+	Shares *shares.Manager
+
+	// ShareMaxTTL caps how long a share link lasts (24h when zero).
+	ShareMaxTTL time.Duration
+
+	// Subsystems runs the background subsystems whose state /readyz reports (optional).
+	Subsystems *lifecycle.Group
+
+	localStore     store.Store
+	localStoreOnce sync.Once
+
+	drainCh   chan struct{}
+	drainOnce sync.Once
+
+	queuesMu sync.Mutex
+	queues   map[string]cachedQueue
 }
 
 // NewAPIHandler creates a new APIHandler.
 func NewAPIHandler(sqs *service.SQSService, log *slog.Logger) *APIHandler {
-	return &APIHandler{SQS: sqs, Log: log}
+	return &APIHandler{SQS: sqs, Log: log, InfoStreamInterval: defaultInfoStreamInterval}
+}
+
+// DrainStreams sends open event streams a shutdown event with the reconnect hint and ends
+// them, so http.Server.Shutdown isn't left waiting on connections that never go idle.
+func (h *APIHandler) DrainStreams() {
+	close(h.draining())
 }
 
-// requireQueue ensures a queue name or URL is configured before executing the handler.
-func (h *APIHandler) requireQueue(next http.HandlerFunc) http.HandlerFunc {
+func (h *APIHandler) draining() chan struct{} {
+	h.drainOnce.Do(func() { h.drainCh = make(chan struct{}) })
+	return h.drainCh
+}
+
+// endStream sends the shutdown notice on a draining stream.
+func (h *APIHandler) endStream(stream *sseStream) {
+	if err := stream.Shutdown(h.ReconnectHint); err != nil {
+		h.Log.Debug("failed to send shutdown event", "error", err)
+	}
+}
+
+// withBudget bounds the request context by RequestTimeout.
+func (h *APIHandler) withBudget(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		svc := h.getService()
-		if svc == nil {
-			respondError(w, http.StatusServiceUnavailable, errors.New("service unavailable"))
-			return
-		}
-		if err := svc.EnsureQueueConfigured(); err != nil {
-			respondError(w, http.StatusBadRequest, err)
+		if h.RequestTimeout <= 0 {
+			next(w, r)
 			return
 		}
+		ctx, cancel := context.WithTimeout(r.Context(), h.RequestTimeout)
+		defer cancel()
+		next(w, r.WithContext(ctx))
+	}
+}
+
+// withRoute names the request's trace span after the route pattern it matched.
+func withRoute(pattern string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tracing.SetRoute(r, pattern)
 		next(w, r)
 	}
 }
 
-// RegisterRoutes wires all HTTP endpoints.
-func (h *APIHandler) RegisterRoutes(mux *http.ServeMux) {
-	mux.HandleFunc("/api/send", h.requireQueue(h.handleSend))
-	mux.HandleFunc("/api/messages", h.requireQueue(h.handleMessages))
-	mux.HandleFunc("/api/purge", h.requireQueue(h.handlePurge))
+// Router is what RegisterRoutes adds routes to; *http.ServeMux is one.
+type Router interface {
+	HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request))
+}
+
+// RegisterRoutes wires all HTTP endpoints. openapi.yaml documents each of them.
+func (h *APIHandler) RegisterRoutes(mux Router) {
+	// Streams run until the client leaves; everything else gets the request budget
+	handle := func(pattern string, fn http.HandlerFunc) {
+		mux.HandleFunc(pattern, withRoute(pattern, h.counted(pattern, h.withBudget(h.authorize(pattern, fn)))))
+	}
+	stream := func(pattern string, fn http.HandlerFunc) {
+		mux.HandleFunc(pattern, withRoute(pattern, h.counted(pattern, h.authorize(pattern, fn))))
+	}
+
+	handle("/api/send", h.requireQueue(h.handleSend))
+	handle("/api/messages", h.requireQueue(h.handleMessages))
+	handle("/api/messages/delete", h.requireQueue(h.handleDeleteMessages))
+	handle("/api/purge", h.requireQueue(h.handlePurge))
+	handle("/api/queue/health", h.requireQueue(h.handleQueueHealth))
+	handle("/api/queue/attributes", h.requireQueue(h.handleQueueAttributes))
+	handle("/api/queue/tags", h.requireQueue(h.handleQueueTags))
+	handle("/api/queue/redrive", h.requireQueue(h.handleQueueRedrive))
+	handle("/api/queue/advisor", h.requireQueue(h.handleQueueAdvisor))
+	handle("/api/queue/report", h.requireQueue(h.handleQueueReport))
+	handle("/api/metrics/history", h.requireQueue(h.handleDepthHistory))
+	handle("/api/metrics/cloudwatch", h.requireQueue(h.handleCloudWatchMetrics))
+	handle("/api/dlq/sources", h.requireQueue(h.handleDLQSources))
+
+	// Background jobs (export, drain, ...)
+	handle("/api/jobs", h.withQueue(h.handleJobs))
+	handle("/api/jobs/{id}", h.handleJob)
+	handle("/api/jobs/{id}/artifact", h.handleJobArtifact)
+
+	// Message annotations (labels, notes)
+	handle("/api/annotations", h.handleAnnotations)
+	handle("/api/annotations/{id}", h.handleAnnotation)
+
+	// User filter/transform scripts
+	handle("/api/scripts", h.handleScripts)
+	handle("/api/scripts/{name}", h.handleScript)
+	handle("/api/scripts/{name}/test", h.handleScriptTest)
+	handle("/api/pipeline/preview", h.withQueue(h.handlePipelinePreview))
+
+	// DLQ triage workflow
+	handle("/api/triage", h.handleTriage)
+	handle("/api/triage/summary", h.handleTriageSummary)
+	handle("/api/triage/{id}", h.handleTriageItem)
+
+	// Two-person approval of destructive actions
+	handle("/api/approvals", h.handleApprovals)
+	handle("/api/approvals/{id}", h.handleApproval)
+	handle("/api/approvals/{id}/{decision}", h.handleApprovalDecision)
+
+	// ChatOps: Slack slash commands and queue digests
+	handle("/api/slack/commands", h.handleSlackCommand)
+	handle("/api/digest", h.handleDigest)
 
 	// Queue can be (re)configured at runtime
-	mux.HandleFunc("/api/config/queue", h.handleChangeQueue)
+	handle("/api/queues", h.handleQueues)
+	handle("/api/queues/scratch", h.handleScratchQueue)
+	handle("/api/config/queue", h.handleChangeQueue)
+	handle("/api/q/{region}/{account}/{name}", h.handleQueueLink)
+	handle("/api/profiles", h.handleProfiles)
+	handle("/api/profiles/{queue}", h.handleProfile)
+	handle("/api/locks", h.handleLocks)
+	handle("/api/locks/{queue}", h.handleLock)
+	handle("/api/storage", h.handleStorage)
+	handle("/api/admin/erase", h.handleErase)
+	handle("/api/shares", h.handleShares)
+	handle("/api/shares/{id}", h.handleShare)
+	handle("/api/shared/{token}", h.handleSharedView)
+
+	// OIDC sign-in (see Authenticate)
+	handle("/auth/login", h.handleLogin)
+	handle("/auth/callback", h.handleAuthCallback)
+	handle("/auth/logout", h.handleLogout)
+	handle("/auth/session", h.handleSession)
 
 	// Informational endpoints
-	mux.HandleFunc("/info", h.handleInfo)
-	mux.HandleFunc("/healthz", h.handleHealth)
+	handle("/info", h.withQueue(h.handleInfo))
+	stream("/api/info/stream", h.withQueue(h.handleInfoStream))
+	stream("/api/events", h.handleEvents)
+	stream("/api/messages/stream", h.requireQueue(h.handleMessageStream))
+	handle("/healthz", h.handleHealth)
+	handle("/readyz", h.handleReady)
+	handle("/api/version", h.handleVersion)
+	handle("/api/version/check", h.handleVersionCheck)
+	handle("/api/plugins", h.handlePlugins)
+	handle("/api/telemetry", h.handleTelemetry)
+	handle("/api/openapi.yaml", h.handleOpenAPI)
 }
 
-// handleSend accepts JSON { "message": "<text>" } and forwards to SQS.
+// handleSend accepts JSON { "message": "<text>", "attributes": { "<name>": { "type", "value" } } }
+// and forwards to SQS.
 func (h *APIHandler) handleSend(w http.ResponseWriter, r *http.Request) {
 	if !enforceMethod(w, r, http.MethodPost) {
 		return
@@ -69,71 +327,450 @@ func (h *APIHandler) handleSend(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req struct {
-		Message string `json:"message"`
+		Message        string                              `json:"message"`
+		Attributes     map[string]service.MessageAttribute `json:"attributes"`
+		DelaySeconds   int                                 `json:"delay_seconds"`
+		MessageGroupID string                              `json:"message_group_id"`
+		DedupID        string                              `json:"dedup_id"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		respondError(w, http.StatusBadRequest, err)
 		return
 	}
-	if req.Message == "" {
-		respondError(w, http.StatusBadRequest, errors.New("message cannot be empty"))
+	var v validate.Validator
+	if v.Required("message", req.Message) {
+		v.MaxBytes("message", req.Message, validate.MaxMessageBytes)
+	}
+	v.Merge("attributes", service.ValidateAttributes(req.Attributes))
+	v.Range("delay_seconds", req.DelaySeconds, 0, validate.MaxDelaySeconds)
+	v.Merge("message_group_id", service.ValidateID("message_group_id", req.MessageGroupID))
+	v.Merge("dedup_id", service.ValidateID("dedup_id", req.DedupID))
+	if err := v.Err(); err != nil {
+		respondError(w, http.StatusBadRequest, err)
 		return
 	}
 
-	svc := h.getService()
+	svc := h.queueService(r.Context())
 	if svc == nil {
 		respondError(w, http.StatusServiceUnavailable, errors.New("service unavailable"))
 		return
 	}
 
-	if err := svc.Send(r.Context(), req.Message); err != nil {
-		h.Log.Error("failed to send message", "error", err)
-		respondError(w, http.StatusInternalServerError, err)
+	if err := plugin.Validate(r.Context(), plugin.Message{QueueName: svc.QueueName, Body: req.Message}); err != nil {
+		respondError(w, http.StatusUnprocessableEntity, err)
 		return
 	}
-
-	respondJSON(w, http.StatusOK, map[string]any{
-		"status":  "ok",
-		"message": "message sent successfully",
+	res, err := svc.Send(r.Context(), req.Message, service.SendOptions{
+		Attributes:      req.Attributes,
+		DelaySeconds:    int32(req.DelaySeconds),
+		MessageGroupID:  req.MessageGroupID,
+		DeduplicationID: req.DedupID,
+		Provenance:      &service.Provenance{SentBy: h.requestUser(r), RequestID: requestID(r.Context())},
 	})
+	if err != nil {
+		h.Log.ErrorContext(r.Context(), "failed to send message", "error", err)
+		respondError(w, serviceErrorStatus(err), err)
+		return
+	}
+
+	out := map[string]any{
+		"status":      "ok",
+		"message":     "message sent successfully",
+		"message_id":  res.MessageID,
+		"md5_of_body": res.MD5OfBody,
+	}
+	if res.SequenceNumber != "" {
+		out["sequence_number"] = res.SequenceNumber
+	}
+	respondJSON(w, http.StatusOK, out)
 }
 
-// handleMessages fetches available messages (non-destructive peek).
+// handleMessages lists available messages. ?mode=observe (default) leaves them visible,
+// ?mode=consume keeps them in flight and returns receipt handles for /api/messages/delete.
+// ?include_dlq=true merges in the dead-letter queue, labeling each message's origin, and
+// ?label= keeps only messages annotated with that label, and ?filter= / ?transform= run stored scripts.
+// With ?limit= or ?cursor= the receive is kept as a snapshot and returned one page at a time.
+// Observe listings carry a depth-based ETag; If-None-Match with it gets 304 without a receive.
 func (h *APIHandler) handleMessages(w http.ResponseWriter, r *http.Request) {
 	if !enforceMethod(w, r, http.MethodGet) {
 		return
 	}
 
-	svc := h.getService()
+	svc := h.queueService(r.Context())
 	if svc == nil {
 		respondError(w, http.StatusServiceUnavailable, errors.New("service unavailable"))
 		return
 	}
 
-	msgs, err := svc.Fetch(r.Context(), 0)
+	query := r.URL.Query()
+	var v validate.Validator
+	mode, err := service.ParseReceiveMode(query.Get("mode"))
+	if err != nil {
+		v.Add("mode", "must be observe or consume")
+	}
+	if mode == "" {
+		mode = svc.DefaultMode()
+		// Consume hides messages from other consumers; viewers only ever observe
+		if mode == service.ModeConsume && h.requestRole(r) < rbac.Operator {
+			mode = service.ModeObserve
+		}
+	}
+	includeDLQ, _ := strconv.ParseBool(query.Get("include_dlq"))
+	v.Check(!includeDLQ || mode == service.ModeObserve, "include_dlq", "requires mode=observe")
+	if err := v.Err(); err != nil {
+		respondError(w, http.StatusBadRequest, err)
+		return
+	}
+	if mode == service.ModeConsume && !h.checkRole(w, r, rbac.Operator, "consume mode") {
+		return
+	}
+	if mode, err = h.receiveMode(r.Context(), svc, mode); err != nil {
+		respondError(w, serviceErrorStatus(err), err)
+		return
+	}
+
+	// Observe listings can be skipped when the depth hasn't changed (If-None-Match)
+	if mode != service.ModeConsume && !includeDLQ && !query.Has("cursor") && h.notModified(w, r, svc) {
+		return
+	}
+
+	if query.Has("limit") || query.Has("cursor") {
+		h.handleMessagePage(w, r, svc, mode, includeDLQ)
+		return
+	}
+
+	msgs, err := h.receive(r.Context(), svc, mode, includeDLQ)
+	setPollHint(w, svc)
+	partial := errors.Is(err, service.ErrPartial)
+	if err != nil && !partial {
+		h.Log.ErrorContext(r.Context(), "failed to receive messages", "error", err)
+		respondError(w, serviceErrorStatus(err), err)
+		return
+	}
+	if partial {
+		// Decorating and scripts are local work: finish them for what did arrive
+		r = r.WithContext(context.WithoutCancel(r.Context()))
+	}
+	msgs = h.decorate(r.Context(), msgs, query.Get("label"))
+	if msgs, err = h.runScripts(r, msgs); err != nil {
+		respondError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	w.Header().Set("X-Receive-Mode", string(mode))
+	text := func() string { return formatBodies(msgs) }
+	if partial {
+		h.Log.WarnContext(r.Context(), "request budget expired during receive", "count", len(msgs))
+		respondPartial(w, r, msgs, service.ErrPartial, text)
+		return
+	}
+	respondNegotiated(w, r, http.StatusOK, msgs, text)
+}
+
+// handleMessagePage serves one page of a receive snapshot, receiving a new one when no cursor is given.
+func (h *APIHandler) handleMessagePage(w http.ResponseWriter, r *http.Request, svc *service.SQSService, mode service.ReceiveMode, includeDLQ bool) {
+	limit, err := pageSize(r)
 	if err != nil {
-		h.Log.Error("failed to receive messages", "error", err)
-		respondError(w, http.StatusInternalServerError, err)
+		respondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	var (
+		snap    receiveSnapshot
+		offset  int
+		partial bool
+	)
+	if cursor := r.URL.Query().Get("cursor"); cursor != "" {
+		if snap, offset, err = h.loadSnapshot(r.Context(), cursor); err != nil {
+			respondError(w, http.StatusGone, err)
+			return
+		}
+	} else {
+		msgs, err := h.receive(r.Context(), svc, mode, includeDLQ)
+		setPollHint(w, svc)
+		partial = errors.Is(err, service.ErrPartial)
+		if err != nil && !partial {
+			h.Log.ErrorContext(r.Context(), "failed to receive messages", "error", err)
+			respondError(w, serviceErrorStatus(err), err)
+			return
+		}
+		// A partial receive is still worth paging through, so save it past the spent budget
+		if snap, err = h.saveSnapshot(context.WithoutCancel(r.Context()), svc, mode, msgs); err != nil {
+			h.Log.ErrorContext(r.Context(), "failed to save receive snapshot", "error", err)
+			respondError(w, http.StatusInternalServerError, err)
+			return
+		}
+		h.Log.DebugContext(r.Context(), "receive snapshot saved", "snapshot_id", snap.ID, "count", len(msgs))
+	}
+
+	if partial {
+		r = r.WithContext(context.WithoutCancel(r.Context()))
+	}
+	page := snap.page(offset, limit)
+	page.Messages = h.decorate(r.Context(), page.Messages, r.URL.Query().Get("label"))
+	if page.Messages, err = h.runScripts(r, page.Messages); err != nil {
+		respondError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	w.Header().Set("X-Receive-Mode", string(page.Mode))
+	text := func() string { return formatBodies(page.Messages) }
+	if partial {
+		h.Log.WarnContext(r.Context(), "request budget expired during receive", "count", page.Total)
+		respondPartial(w, r, page, service.ErrPartial, text)
 		return
 	}
-	respondJSON(w, http.StatusOK, msgs)
+	respondNegotiated(w, r, http.StatusOK, page, text)
 }
 
-// handlePurge deletes all messages presently in the queue.
+// receive lists the queue, merged with its DLQ when requested.
+func (h *APIHandler) receive(ctx context.Context, svc *service.SQSService, mode service.ReceiveMode, includeDLQ bool) ([]map[string]any, error) {
+	if includeDLQ {
+		return svc.ReceiveWithDLQ(ctx, mode)
+	}
+	return svc.Receive(ctx, mode)
+}
+
+// decorate enriches listed messages with plugin-decoded payloads, triage state and annotations
+// and, when label is set, keeps only messages annotated with it.
+func (h *APIHandler) decorate(ctx context.Context, msgs []map[string]any, label string) []map[string]any {
+	h.decode(ctx, msgs)
+	if h.Triage != nil {
+		h.Triage.Attach(ctx, msgs)
+	}
+	if h.Annotations == nil {
+		return msgs
+	}
+	h.Annotations.Attach(ctx, msgs)
+	if label == "" {
+		return msgs
+	}
+
+	label = strings.ToLower(strings.TrimSpace(label))
+	filtered := []map[string]any{}
+	for _, m := range msgs {
+		if a, ok := m["annotation"].(annotations.Annotation); ok && a.HasLabel(label) {
+			filtered = append(filtered, m)
+		}
+	}
+	return filtered
+}
+
+// runScripts applies the ?filter= and ?transform= scripts named in the request.
+func (h *APIHandler) runScripts(r *http.Request, msgs []map[string]any) ([]map[string]any, error) {
+	filter, transform := r.URL.Query().Get("filter"), r.URL.Query().Get("transform")
+	if filter == "" && transform == "" {
+		return msgs, nil
+	}
+	if h.Scripts == nil {
+		return nil, errors.New("scripts are not enabled")
+	}
+	return h.Scripts.Apply(r.Context(), filter, transform, msgs)
+}
+
+// decode adds "decoded" (and the decoder's name) to messages a registered decoder understands,
+// then masks the fields the queue's profile hides.
+func (h *APIHandler) decode(ctx context.Context, msgs []map[string]any) {
+	svc := h.queueService(ctx)
+	seen := map[string]profiles.Profile{}
+	for _, m := range msgs {
+		pm := plugin.Message{MessageID: fmt.Sprint(m["message_id"]), Body: fmt.Sprint(m["body"])}
+		if q, ok := m["queue_name"].(string); ok {
+			pm.QueueName = q
+		} else if svc != nil {
+			pm.QueueName = svc.QueueName
+		}
+		profile := h.profileFor(ctx, pm.QueueName, seen)
+
+		decoded, decoder, err := plugin.DecodeWith(ctx, pm, profile.Decoders)
+		switch {
+		case err != nil:
+			m["decode_error"] = fmt.Sprintf("%s: %v", decoder, err)
+		case decoder != "":
+			m["decoded"] = decoded
+			m["decoder"] = decoder
+		}
+		profile.MaskMessage(m)
+	}
+}
+
+// setPollHint advertises how long the client should wait before polling the queue again
+// (X-Poll-Interval, in seconds); JSON responses repeat it as meta.poll_interval_seconds.
+func setPollHint(w http.ResponseWriter, svc *service.SQSService) {
+	w.Header().Set("X-Poll-Interval", strconv.Itoa(int(svc.PollHint().Seconds())))
+}
+
+// dryRun reports whether a destructive request only asks what would happen (?dry_run=true).
+func dryRun(r *http.Request) bool {
+	v, _ := strconv.ParseBool(r.URL.Query().Get("dry_run"))
+	return v
+}
+
+// serviceErrorStatus maps an SQS service error to an HTTP status.
+func serviceErrorStatus(err error) int {
+	var awsErr *service.AWSError
+	if errors.As(service.TranslateAWSError(err), &awsErr) {
+		switch awsErr.Kind {
+		case service.KindQueueNotFound:
+			return http.StatusNotFound
+		case service.KindAccessDenied, service.KindKMSAccessDenied:
+			return http.StatusForbidden
+		case service.KindPurgeInProgress, service.KindThrottled:
+			return http.StatusTooManyRequests
+		case service.KindCredentialsInvalid:
+			return http.StatusBadGateway
+		}
+	}
+	switch {
+	case errors.As(err, new(validate.Errors)):
+		return http.StatusBadRequest
+	case errors.As(err, new(*service.PurgeCooldownError)):
+		return http.StatusTooManyRequests
+	case errors.As(err, new(*locks.LockedError)):
+		return http.StatusLocked
+	case errors.Is(err, service.ErrNoDeadLetterQueue), errors.As(err, new(*service.TailRedriveError)):
+		return http.StatusConflict
+	case errors.Is(err, service.ErrReadOnly):
+		return http.StatusForbidden
+	case errors.Is(err, service.ErrAWSNotConfigured):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// handleDeleteMessages acknowledges consumed messages: JSON { "receipt_handles": ["..."] }.
+// With ?dry_run=true it only reports what would be deleted.
+func (h *APIHandler) handleDeleteMessages(w http.ResponseWriter, r *http.Request) {
+	if !enforceMethod(w, r, http.MethodPost) {
+		return
+	}
+
+	var req struct {
+		ReceiptHandles []string `json:"receipt_handles"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, err)
+		return
+	}
+	var v validate.Validator
+	if v.NotEmpty("receipt_handles", len(req.ReceiptHandles)) {
+		for i, rh := range req.ReceiptHandles {
+			v.Required(fmt.Sprintf("receipt_handles[%d]", i), rh)
+		}
+	}
+	if err := v.Err(); err != nil {
+		respondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	svc := h.queueService(r.Context())
+	if svc == nil {
+		respondError(w, http.StatusServiceUnavailable, errors.New("service unavailable"))
+		return
+	}
+
+	if dryRun(r) {
+		h.Log.InfoContext(r.Context(), "dry run", "action", "delete", "queue_name", svc.QueueName, "count", len(req.ReceiptHandles))
+		respondJSON(w, http.StatusOK, service.Plan{
+			DryRun:    true,
+			Action:    "delete",
+			QueueName: svc.QueueName,
+			QueueURL:  svc.QueueURL,
+			Count:     int64(len(req.ReceiptHandles)),
+		})
+		return
+	}
+	if !h.checkLock(w, r, "delete", svc.QueueName) {
+		return
+	}
+	if !h.checkMaintenance(w, r, "delete", svc.QueueName) {
+		return
+	}
+
+	deleted, err := svc.Delete(r.Context(), req.ReceiptHandles)
+	if err != nil {
+		h.Log.ErrorContext(r.Context(), "failed to delete messages", "error", err)
+		respondError(w, serviceErrorStatus(err), err)
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]any{
+		"deleted": deleted,
+		"failed":  len(req.ReceiptHandles) - deleted,
+	})
+}
+
+// formatBodies is the plain-text form of a message list: bodies only, one per line.
+func formatBodies(msgs []map[string]any) string {
+	var b strings.Builder
+	for _, m := range msgs {
+		fmt.Fprintln(&b, m["body"])
+	}
+	return b.String()
+}
+
+// handlePurge deletes all messages presently in the queue, in two calls. The first (or any
+// ?dry_run=true call) returns the approximate count, a sample and a short-lived confirm
+// token; only a second call with JSON { "confirm": "<token>" } purges.
 func (h *APIHandler) handlePurge(w http.ResponseWriter, r *http.Request) {
 	if !enforceMethod(w, r, http.MethodPost) {
 		return
 	}
 
-	svc := h.getService()
+	svc := h.queueService(r.Context())
 	if svc == nil {
 		respondError(w, http.StatusServiceUnavailable, errors.New("service unavailable"))
 		return
 	}
 
+	var body struct {
+		Confirm string `json:"confirm"`
+	}
+	// Chunked bodies have no length; an empty one is the same as none
+	if r.Body != http.NoBody {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil && !errors.Is(err, io.EOF) {
+			respondError(w, http.StatusBadRequest, fmt.Errorf("invalid JSON body: %w", err))
+			return
+		}
+	}
+
+	// Phase one: show what would be purged and hand out the token that confirms it
+	if dryRun(r) || body.Confirm == "" {
+		plan, err := svc.PlanQueue(r.Context(), "purge", 0, true)
+		if err != nil {
+			respondError(w, serviceErrorStatus(err), err)
+			return
+		}
+		confirm, err := h.issuePurgeConfirm(r, plan)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, err)
+			return
+		}
+		respondJSON(w, http.StatusOK, confirm)
+		return
+	}
+	if svc.ReadOnly {
+		respondError(w, http.StatusForbidden, service.ErrReadOnly)
+		return
+	}
+	if !h.checkLock(w, r, actionPurge, svc.QueueName) {
+		return
+	}
+	if !h.checkMaintenance(w, r, actionPurge, svc.QueueName) {
+		return
+	}
+	if status, err := h.redeemPurgeConfirm(r.Context(), r, svc, body.Confirm); err != nil {
+		respondError(w, status, err)
+		return
+	}
+	if h.Approvals.Required(svc.QueueName) {
+		h.requestApproval(w, r, actionPurge, svc.QueueName, nil)
+		return
+	}
+
 	if err := svc.Purge(r.Context()); err != nil {
-		h.Log.Error("failed to purge queue", "error", err)
-		respondError(w, http.StatusInternalServerError, err)
+		h.Log.ErrorContext(r.Context(), "failed to purge queue", "error", err)
+		respondError(w, serviceErrorStatus(err), err)
 		return
 	}
 	respondJSON(w, http.StatusOK, map[string]string{
@@ -147,21 +784,174 @@ func (h *APIHandler) handleInfo(w http.ResponseWriter, r *http.Request) {
 	if !enforceMethod(w, r, http.MethodGet) {
 		return
 	}
-	svc := h.getService()
+	svc := h.queueService(r.Context())
 	// Even if nil, return a not_connected semantics
 	if svc == nil {
-		respondJSON(w, http.StatusOK, map[string]any{
+		respondNegotiated(w, r, http.StatusOK, map[string]any{
 			"status":  "not_connected",
 			"error":   "service unavailable",
 			"message": "no SQS service configured",
-		})
+		}, nil)
 		return
 	}
 	info := svc.Info(r.Context())
-	respondJSON(w, http.StatusOK, info)
+	if lock, err := h.Locks.Get(r.Context(), svc.QueueName); err == nil && lock.Locked {
+		info["observe_only_lock"] = map[string]any{"reason": lock.Reason, "by": lock.By, "since": lock.Since}
+	}
+	setPollHint(w, svc)
+	respondNegotiated(w, r, http.StatusOK, info, func() string {
+		return formatKeyValues(info)
+	})
+}
+
+// handleInfoStream pushes queue info as server-sent "info" events until the client disconnects.
+func (h *APIHandler) handleInfoStream(w http.ResponseWriter, r *http.Request) {
+	if !enforceMethod(w, r, http.MethodGet) {
+		return
+	}
+
+	stream, err := startSSE(w)
+	if err != nil {
+		h.Log.WarnContext(r.Context(), "failed to start info stream", "error", err)
+		return
+	}
+
+	interval := h.InfoStreamInterval
+	if interval <= 0 {
+		interval = defaultInfoStreamInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	h.Log.DebugContext(r.Context(), "info stream opened", "interval_seconds", interval.Seconds())
+	for {
+		// Resolve per tick so a runtime queue change is picked up by open streams (?queue= pins one)
+		var payload map[string]interface{}
+		if svc := h.queueService(r.Context()); svc != nil {
+			payload = svc.Info(r.Context())
+		} else {
+			payload = map[string]interface{}{
+				"status":  "not_connected",
+				"error":   "service unavailable",
+				"message": "no SQS service configured",
+			}
+		}
+		if err := stream.Send("info", payload); err != nil {
+			h.Log.DebugContext(r.Context(), "info stream closed", "error", err)
+			return
+		}
+
+		select {
+		case <-r.Context().Done():
+			h.Log.DebugContext(r.Context(), "info stream closed by client")
+			return
+		case <-h.draining():
+			h.endStream(stream)
+			return
+		case <-ticker.C:
+		}
+	}
 }
 
-// handleChangeQueue updates the SQS queue at runtime.
+// handleEvents streams server notifications as "notification" events. Clients resuming
+// with Last-Event-ID receive the notifications they missed (bounded replay).
+func (h *APIHandler) handleEvents(w http.ResponseWriter, r *http.Request) {
+	if !enforceMethod(w, r, http.MethodGet) {
+		return
+	}
+	if h.Events == nil {
+		respondError(w, http.StatusServiceUnavailable, errors.New("events are not enabled"))
+		return
+	}
+
+	lastID, _ := strconv.ParseUint(r.Header.Get("Last-Event-ID"), 10, 64)
+	ch, unsubscribe := h.Events.Subscribe(lastID)
+	defer unsubscribe()
+
+	stream, err := startSSE(w)
+	if err != nil {
+		h.Log.WarnContext(r.Context(), "failed to start events stream", "error", err)
+		return
+	}
+
+	keepAlive := time.NewTicker(25 * time.Second)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-h.draining():
+			h.endStream(stream)
+			return
+		case e := <-ch:
+			if err := stream.SendWithID(strconv.FormatUint(e.ID, 10), "notification", e); err != nil {
+				return
+			}
+		case <-keepAlive.C:
+			if err := stream.Comment("keep-alive"); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// switchService builds the service for a queue switch: on h.Client when set, else on a
+// client from the reloaded AWS config.
+func (h *APIHandler) switchService(ctx context.Context, queueName, queueURL string) (*service.SQSService, error) {
+	if h.Client != nil {
+		return service.NewSQSService(ctx, h.Client, queueName, queueURL, h.CurrentService().Region, h.Log), nil
+	}
+	awsCfg, err := config.LoadDefaultConfig(ctx, tracing.WithAWS())
+	if err != nil {
+		return nil, err
+	}
+	client := service.NewClient(awsCfg, h.SQSEndpoint)
+	return service.NewSQSService(ctx, client, queueName, queueURL, client.Options().Region, h.Log), nil
+}
+
+// SwitchQueue makes a queue the default for every client, as POST /api/config/queue does,
+// keeping the current receive mode.
+func (h *APIHandler) SwitchQueue(ctx context.Context, queueName, queueURL string) error {
+	newSvc, err := h.switchService(ctx, queueName, queueURL)
+	if err != nil {
+		return err
+	}
+	h.install(newSvc, "")
+	return nil
+}
+
+// install makes newSvc the default service and announces the switch. mode, when set,
+// overrides the receive mode.
+func (h *APIHandler) install(newSvc *service.SQSService, mode service.ReceiveMode) {
+	h.mu.Lock()
+	// Receive mode: the request's, else the queue profile's, else the previous queue's
+	if h.SQS != nil {
+		newSvc.Mode = h.SQS.Mode
+	}
+	if h.Profiles != nil {
+		newSvc.Configure = h.Profiles.Configure
+		h.Profiles.Configure(newSvc)
+	}
+	if mode != "" {
+		newSvc.Mode = mode
+	}
+	h.SQS = newSvc
+	h.mu.Unlock()
+
+	h.Log.Info("SQS queue updated", "queue_name", newSvc.QueueName, "queue_url", newSvc.QueueURL)
+	h.Events.Publish(events.Event{
+		Type:    events.TypeQueueReconnected,
+		Message: "queue switched to " + newSvc.QueueName,
+		Data: map[string]any{
+			"queue_name": newSvc.QueueName,
+			"queue_url":  newSvc.QueueURL,
+		},
+	})
+}
+
+// handleChangeQueue switches the default queue at runtime, for every client. Requests that
+// only need another queue pass ?queue= instead.
 func (h *APIHandler) handleChangeQueue(w http.ResponseWriter, r *http.Request) {
 	if !enforceMethod(w, r, http.MethodPost) {
 		return
@@ -172,46 +962,54 @@ func (h *APIHandler) handleChangeQueue(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var body struct {
-		QueueName string `json:"queue_name"`
-		QueueURL  string `json:"queue_url"`
+		QueueName   string `json:"queue_name"`
+		QueueURL    string `json:"queue_url"`
+		ReceiveMode string `json:"receive_mode"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
 		respondError(w, http.StatusBadRequest, err)
 		return
 	}
-	if body.QueueName == "" && body.QueueURL == "" {
-		respondError(w, http.StatusBadRequest, errors.New("queue_name or queue_url must be provided"))
+	var v validate.Validator
+	v.Check(body.QueueName != "" || body.QueueURL != "", "queue_name", "queue_name or queue_url must be provided")
+	mode, err := service.ParseReceiveMode(body.ReceiveMode)
+	if err != nil {
+		v.Add("receive_mode", "must be observe or consume")
+	}
+	if err := v.Err(); err != nil {
+		respondError(w, http.StatusBadRequest, err)
 		return
 	}
 
-	// Short timeout to avoid long hangs on AWS metadata/STS
-	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
-	defer cancel()
+	ctx := r.Context()
 
-	awsCfg, err := config.LoadDefaultConfig(ctx)
+	newSvc, err := h.switchService(ctx, body.QueueName, body.QueueURL)
 	if err != nil {
-		h.Log.Warn("failed to reload AWS config", "error", err)
+		h.Log.WarnContext(r.Context(), "failed to reload AWS config", "error", err)
 		respondError(w, http.StatusServiceUnavailable, errors.New("could not reload AWS config"))
 		return
 	}
 
-	client := sqs.NewFromConfig(awsCfg)
-	newSvc := service.NewSQSService(ctx, client, body.QueueName, body.QueueURL, awsCfg.Region, h.Log)
-
-	h.mu.Lock()
-	h.SQS = newSvc
-	h.mu.Unlock()
-
-	h.Log.Info("SQS queue updated", "queue_name", newSvc.QueueName, "queue_url", newSvc.QueueURL)
+	h.install(newSvc, mode)
 
 	respondJSON(w, http.StatusOK, map[string]any{
-		"status":      "ok",
-		"queue_name":  newSvc.QueueName,
-		"queue_url":   newSvc.QueueURL,
-		"reconnected": newSvc.QueueURL != "",
+		"status":       "ok",
+		"queue_name":   newSvc.QueueName,
+		"queue_url":    newSvc.QueueURL,
+		"reconnected":  newSvc.QueueURL != "",
+		"receive_mode": newSvc.DefaultMode(),
+		"read_only":    newSvc.ReadOnly,
 	})
 }
 
+// handlePlugins lists the compiled-in extensions.
+func (h *APIHandler) handlePlugins(w http.ResponseWriter, r *http.Request) {
+	if !enforceMethod(w, r, http.MethodGet) {
+		return
+	}
+	respondJSON(w, http.StatusOK, plugin.Names())
+}
+
 // handleHealth returns a simple liveness probe and version info.
 func (h *APIHandler) handleHealth(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusOK, map[string]string{
@@ -222,10 +1020,76 @@ func (h *APIHandler) handleHealth(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// handleReady is the readiness probe. It reports each background subsystem's state and
+// answers 503 once the server is shutting down or a subsystem has failed, so load
+// balancers stop routing here. Subsystems whose latest iteration failed (say, the depth
+// sampler getting AccessDenied) make it "degraded" but still ready: the UI works without them.
+func (h *APIHandler) handleReady(w http.ResponseWriter, r *http.Request) {
+	if !enforceMethod(w, r, http.MethodGet) {
+		return
+	}
+	subsystems := h.Subsystems.Status()
+	status, code := "ok", http.StatusOK
+	for _, s := range subsystems {
+		if s.Failed {
+			status, code = "failed", http.StatusServiceUnavailable
+			break
+		}
+		if !s.Healthy() {
+			status = "degraded"
+		}
+	}
+	select {
+	case <-h.draining():
+		status, code = "shutting_down", http.StatusServiceUnavailable
+	default:
+	}
+	respondJSON(w, code, map[string]any{
+		"status":     status,
+		"subsystems": subsystems,
+	})
+}
+
+// handleVersion returns build metadata including the asset hash used for cache busting.
+func (h *APIHandler) handleVersion(w http.ResponseWriter, r *http.Request) {
+	if !enforceMethod(w, r, http.MethodGet) {
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]string{
+		"version":    version.Version,
+		"commit":     version.Commit,
+		"build_time": version.BuildTime,
+		"asset_hash": version.AssetHash,
+	})
+}
+
+// handleVersionCheck reports whether a newer release than the running build is published.
+func (h *APIHandler) handleVersionCheck(w http.ResponseWriter, r *http.Request) {
+	if !enforceMethod(w, r, http.MethodGet) {
+		return
+	}
+	if h.Updates == nil {
+		respondError(w, http.StatusServiceUnavailable, errors.New("version check is disabled"))
+		return
+	}
+	res, err := h.Updates.Check(r.Context())
+	if err != nil {
+		h.Log.WarnContext(r.Context(), "version check failed", "error", err)
+		respondError(w, http.StatusBadGateway, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, res)
+}
+
 /*
 Helper functions
 */
 
+// CurrentService returns the active SQS service (nil when none is configured).
+func (h *APIHandler) CurrentService() *service.SQSService {
+	return h.getService()
+}
+
 func (h *APIHandler) getService() *service.SQSService {
 	h.mu.RLock()
 	defer h.mu.RUnlock()
@@ -246,19 +1110,21 @@ func enforceMethod(w http.ResponseWriter, r *http.Request, allowed string) bool
 	return true
 }
 
-func respondJSON(w http.ResponseWriter, status int, v any) {
-	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Cache-Control", "no-store")
-	w.WriteHeader(status)
-	_ = json.NewEncoder(w).Encode(v)
-}
-
-func respondError(w http.ResponseWriter, status int, err error) {
-	payload := map[string]string{
-		"error": http.StatusText(status),
+// formatKeyValues renders a flat map as sorted "key: value" lines.
+func formatKeyValues(m map[string]interface{}) string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
 	}
-	if err != nil {
-		payload["detail"] = err.Error()
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, k := range keys {
+		v := m[k]
+		if v == nil {
+			v = "-"
+		}
+		fmt.Fprintf(&b, "%s: %v\n", k, v)
 	}
-	respondJSON(w, status, payload)
+	return b.String()
 }
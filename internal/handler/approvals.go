@@ -0,0 +1,199 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/pachecoc/sqs-ui/internal/approvals"
+	"github.com/pachecoc/sqs-ui/internal/auth"
+	"github.com/pachecoc/sqs-ui/internal/events"
+	"github.com/pachecoc/sqs-ui/internal/jobs"
+	"github.com/pachecoc/sqs-ui/internal/rbac"
+)
+
+// actionPurge is the approval action for /api/purge; job kinds are used as actions for jobs.
+const actionPurge = "purge"
+
+// requestUser is the signed-in user, else the user named by the configured proxy header
+// (empty when unauthenticated).
+func (h *APIHandler) requestUser(r *http.Request) string {
+	if user := auth.User(r.Context()); user != "" {
+		return user
+	}
+	if h.UserHeader == "" {
+		return ""
+	}
+	return strings.TrimSpace(r.Header.Get(h.UserHeader))
+}
+
+// requestApproval answers a destructive request on a protected queue with a pending approval.
+func (h *APIHandler) requestApproval(w http.ResponseWriter, r *http.Request, action, queue string, params map[string]any) {
+	req, err := h.Approvals.Create(r.Context(), action, queue, params, h.requestUser(r))
+	if err != nil {
+		respondError(w, http.StatusForbidden, err)
+		return
+	}
+
+	h.Log.InfoContext(r.Context(), "approval requested", "id", req.ID, "action", action, "queue_name", queue, "user", req.RequestedBy)
+	h.Events.Publish(events.Event{
+		Type:    events.TypeApprovalRequested,
+		Level:   events.LevelWarn,
+		Message: fmt.Sprintf("%s requested %s on %s; a second user must approve", req.RequestedBy, action, queue),
+		Data:    map[string]any{"approval_id": req.ID, "action": action, "queue_name": queue, "expires_at": req.ExpiresAt},
+	})
+	respondJSON(w, http.StatusAccepted, map[string]any{"approval": req})
+}
+
+// handleApprovals lists approval requests (?status= filters).
+func (h *APIHandler) handleApprovals(w http.ResponseWriter, r *http.Request) {
+	if !enforceMethod(w, r, http.MethodGet) {
+		return
+	}
+	if h.Approvals == nil {
+		respondError(w, http.StatusServiceUnavailable, errors.New("approvals are not enabled"))
+		return
+	}
+
+	list, err := h.Approvals.List(r.Context(), approvals.Status(r.URL.Query().Get("status")))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]any{"approvals": list})
+}
+
+// handleApproval returns one request with its audit trail.
+func (h *APIHandler) handleApproval(w http.ResponseWriter, r *http.Request) {
+	if !enforceMethod(w, r, http.MethodGet) {
+		return
+	}
+	if h.Approvals == nil {
+		respondError(w, http.StatusServiceUnavailable, errors.New("approvals are not enabled"))
+		return
+	}
+
+	req, err := h.Approvals.Get(r.Context(), r.PathValue("id"))
+	if err != nil {
+		respondError(w, approvalErrorStatus(err), err)
+		return
+	}
+	respondJSON(w, http.StatusOK, req)
+}
+
+// handleApprovalDecision approves (runs the action) or rejects a pending request:
+// POST /api/approvals/{id}/approve or /reject (optional JSON { "reason": "..." }).
+func (h *APIHandler) handleApprovalDecision(w http.ResponseWriter, r *http.Request) {
+	if !enforceMethod(w, r, http.MethodPost) {
+		return
+	}
+	if h.Approvals == nil {
+		respondError(w, http.StatusServiceUnavailable, errors.New("approvals are not enabled"))
+		return
+	}
+	id, user := r.PathValue("id"), h.requestUser(r)
+
+	var (
+		req approvals.Request
+		err error
+	)
+	switch r.PathValue("decision") {
+	case "approve":
+		var pending approvals.Request
+		if pending, err = h.Approvals.Get(r.Context(), id); err != nil {
+			respondError(w, approvalErrorStatus(err), err)
+			return
+		}
+		// Approving runs the action, so it needs the role that submitting it directly would
+		destructive := pending.Action == actionPurge || jobs.Destructive(pending.Action) || jobs.AccountWide(pending.Action)
+		if destructive && !h.checkRole(w, r, rbac.Admin, "approving a "+pending.Action) {
+			return
+		}
+		if !h.checkMaintenance(w, r, pending.Action, pending.QueueName) {
+			return
+		}
+		req, err = h.Approvals.Approve(r.Context(), id, user, func(req approvals.Request) (any, error) {
+			return h.executeApproved(r.Context(), req)
+		})
+	case "reject":
+		var body struct {
+			Reason string `json:"reason"`
+		}
+		if r.Body != http.NoBody {
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil && !errors.Is(err, io.EOF) {
+				respondError(w, http.StatusBadRequest, err)
+				return
+			}
+		}
+		req, err = h.Approvals.Reject(r.Context(), id, user, body.Reason)
+	default:
+		respondError(w, http.StatusNotFound, errors.New("decision must be approve or reject"))
+		return
+	}
+	if err != nil {
+		respondError(w, approvalErrorStatus(err), err)
+		return
+	}
+
+	h.Log.InfoContext(r.Context(), "approval decided", "id", req.ID, "action", req.Action, "queue_name", req.QueueName,
+		"status", req.Status, "requested_by", req.RequestedBy, "decided_by", req.DecidedBy)
+	level := events.LevelInfo
+	if req.Status == approvals.StatusFailed {
+		level = events.LevelError
+	}
+	h.Events.Publish(events.Event{
+		Type:    events.TypeApprovalDecided,
+		Level:   level,
+		Message: fmt.Sprintf("%s on %s %s by %s", req.Action, req.QueueName, req.Status, req.DecidedBy),
+		Data:    map[string]any{"approval_id": req.ID, "action": req.Action, "queue_name": req.QueueName, "status": req.Status},
+	})
+	respondJSON(w, http.StatusOK, req)
+}
+
+// executeApproved runs an approved action against its queue: purges run inline, jobs are submitted.
+func (h *APIHandler) executeApproved(ctx context.Context, req approvals.Request) (any, error) {
+	svc := h.getService()
+	if svc == nil {
+		return nil, errors.New("service unavailable")
+	}
+	svc, err := svc.ForQueue(ctx, req.QueueName)
+	if err != nil {
+		return nil, err
+	}
+	if err := h.Locks.Check(ctx, svc.QueueName, req.Action); err != nil {
+		return nil, err
+	}
+
+	if req.Action == actionPurge {
+		if err := svc.Purge(ctx); err != nil {
+			return nil, err
+		}
+		return map[string]any{"message": "queue purged successfully"}, nil
+	}
+	if h.Jobs == nil {
+		return nil, errors.New("jobs are not enabled")
+	}
+	job, err := h.Jobs.Submit(req.Action, svc, req.Params)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{"job_id": job.ID}, nil
+}
+
+// approvalErrorStatus maps an approvals error to an HTTP status.
+func approvalErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, approvals.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, approvals.ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, approvals.ErrNotPending), errors.Is(err, approvals.ErrBusy):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
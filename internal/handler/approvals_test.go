@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/pachecoc/sqs-ui/internal/approvals"
+	"github.com/pachecoc/sqs-ui/internal/store"
+)
+
+func TestRejectReason(t *testing.T) {
+	mux, h, _ := newTestAPI(t, nil)
+	h.Approvals = &approvals.Manager{Store: store.NewMemory(), TTL: time.Hour, Queues: []string{testQueue}}
+
+	tests := []struct {
+		name, body, reason string
+		chunked            bool
+	}{
+		{"no body", "", "", false},
+		{"reason", `{"reason":"wrong queue"}`, "wrong queue", false},
+		{"empty chunked body", "", "", true},
+		{"chunked reason", `{"reason":"wrong queue"}`, "wrong queue", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := do(mux, "ada", http.MethodPost, "/api/jobs", `{"type":"drain"}`)
+			var env struct {
+				Data struct {
+					Approval approvals.Request `json:"approval"`
+				} `json:"data"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &env); err != nil || rec.Code != http.StatusAccepted {
+				t.Fatalf("request: got %d (%s)", rec.Code, rec.Body)
+			}
+			target := "/api/approvals/" + env.Data.Approval.ID + "/reject"
+
+			if tt.chunked {
+				rec = chunked(mux, "bob", http.MethodPost, target, tt.body)
+			} else {
+				rec = do(mux, "bob", http.MethodPost, target, tt.body)
+			}
+			if rec.Code != http.StatusOK {
+				t.Fatalf("reject: got %d (%s)", rec.Code, rec.Body)
+			}
+			req, err := h.Approvals.Get(context.Background(), env.Data.Approval.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if last := req.History[len(req.History)-1]; req.Status != approvals.StatusRejected || last.Detail != tt.reason {
+				t.Errorf("status %s, reason %q; want rejected with %q", req.Status, last.Detail, tt.reason)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/pachecoc/sqs-ui/internal/auth"
+)
+
+// errAuthDisabled answers the sign-in endpoints when OIDC is not configured.
+var errAuthDisabled = errors.New("sign-in is not enabled; set OIDC_ISSUER")
+
+// publicPath reports whether path is served without a session: health probes, the sign-in
+// flow itself, Slack commands and share links, which carry their own signature.
+func publicPath(path string) bool {
+	return path == "/healthz" || path == "/readyz" || strings.HasPrefix(path, "/auth/") || path == "/api/slack/commands" ||
+		strings.HasPrefix(path, "/api/shared/") || strings.HasPrefix(path, "/shared/")
+}
+
+// Authenticate gates requests on the configured sign-in methods. A bearer token from
+// API_TOKENS authenticates API calls by itself, and a wrong one is refused. Without a token,
+// OIDC (when enabled) requires a session on every other route: browsers opening a page are
+// sent to the provider, API calls get 401. With tokens but no OIDC, API calls without a
+// token get 401. The token's name or the session's user becomes
+// the request user, replacing USER_HEADER.
+func (h *APIHandler) Authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok && h.Tokens != nil {
+			name, valid := h.Tokens.Check(strings.TrimSpace(bearer))
+			switch {
+			case !valid:
+				respondError(w, http.StatusUnauthorized, errors.New("invalid API token"))
+			case !isAPIPath(r.URL.Path):
+				respondError(w, http.StatusForbidden, errors.New("API tokens are only accepted on /api/ routes"))
+			default:
+				next.ServeHTTP(w, r.WithContext(auth.WithUser(r.Context(), name)))
+			}
+			return
+		}
+		if publicPath(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if h.Auth == nil {
+			// Tokens alone guard the API; pages stay open but can't read anything without one
+			if h.Tokens != nil && isAPIPath(r.URL.Path) {
+				respondError(w, http.StatusUnauthorized, errors.New("an API token is required"))
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+		s, ok := h.Auth.Session(r)
+		if !ok {
+			if r.Method == http.MethodGet && strings.Contains(r.Header.Get("Accept"), "text/html") && !isAPIPath(r.URL.Path) {
+				http.Redirect(w, r, h.Auth.LoginURL(w, r.URL.RequestURI()), http.StatusFound)
+				return
+			}
+			respondError(w, http.StatusUnauthorized, errors.New("sign in at /auth/login"))
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(auth.WithUser(r.Context(), s.User)))
+	})
+}
+
+func isAPIPath(path string) bool {
+	return strings.HasPrefix(path, "/api/") || path == "/info"
+}
+
+// handleLogin redirects to the provider; ?return_to= is where to go once signed in.
+func (h *APIHandler) handleLogin(w http.ResponseWriter, r *http.Request) {
+	if !enforceMethod(w, r, http.MethodGet) {
+		return
+	}
+	if h.Auth == nil {
+		respondError(w, http.StatusServiceUnavailable, errAuthDisabled)
+		return
+	}
+	http.Redirect(w, r, h.Auth.LoginURL(w, r.URL.Query().Get("return_to")), http.StatusFound)
+}
+
+// handleAuthCallback completes the sign-in the provider redirected back from.
+func (h *APIHandler) handleAuthCallback(w http.ResponseWriter, r *http.Request) {
+	if !enforceMethod(w, r, http.MethodGet) {
+		return
+	}
+	if h.Auth == nil {
+		respondError(w, http.StatusServiceUnavailable, errAuthDisabled)
+		return
+	}
+	s, returnTo, err := h.Auth.Callback(w, r)
+	if err != nil {
+		h.Log.WarnContext(r.Context(), "sign-in failed", "error", err)
+		respondError(w, http.StatusUnauthorized, err)
+		return
+	}
+	h.Log.InfoContext(r.Context(), "user signed in", "user", s.User, "expires_at", s.ExpiresAt)
+	http.Redirect(w, r, returnTo, http.StatusFound)
+}
+
+// handleLogout ends the session.
+func (h *APIHandler) handleLogout(w http.ResponseWriter, r *http.Request) {
+	if !enforceMethod(w, r, http.MethodPost) {
+		return
+	}
+	if h.Auth == nil {
+		respondError(w, http.StatusServiceUnavailable, errAuthDisabled)
+		return
+	}
+	if s, ok := h.Auth.Session(r); ok {
+		h.Log.InfoContext(r.Context(), "user signed out", "user", s.User)
+	}
+	h.Auth.Logout(w)
+	respondJSON(w, http.StatusOK, map[string]string{"status": "signed out"})
+}
+
+// handleSession returns the signed-in user and when the session ends (401 without one).
+func (h *APIHandler) handleSession(w http.ResponseWriter, r *http.Request) {
+	if !enforceMethod(w, r, http.MethodGet) {
+		return
+	}
+	if h.Auth == nil {
+		respondError(w, http.StatusServiceUnavailable, errAuthDisabled)
+		return
+	}
+	s, ok := h.Auth.Session(r)
+	if !ok {
+		respondError(w, http.StatusUnauthorized, errors.New("not signed in"))
+		return
+	}
+	respondJSON(w, http.StatusOK, s)
+}
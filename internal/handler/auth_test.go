@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pachecoc/sqs-ui/internal/auth"
+	"github.com/pachecoc/sqs-ui/internal/secrets"
+)
+
+const testToken = "0123456789abcdef0123"
+
+func TestTokensWithoutOIDC(t *testing.T) {
+	mux, h, _ := newTestAPI(t, nil)
+	h.Tokens = &auth.Tokens{Source: secrets.Static("ci:" + testToken)}
+	root := h.Authenticate(mux)
+
+	tests := []struct {
+		name, target, token string
+		want                int
+	}{
+		{"no token", "/api/queue/attributes", "", http.StatusUnauthorized},
+		{"no token on /info", "/info", "", http.StatusUnauthorized},
+		{"invalid token", "/api/queue/attributes", "not-" + testToken, http.StatusUnauthorized},
+		{"valid token", "/api/queue/attributes", testToken, http.StatusOK},
+		{"valid token on a page", "/", testToken, http.StatusForbidden},
+		{"health probe", "/healthz", "", http.StatusOK},
+		{"share link", "/api/shared/forged", "", http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			rec := httptest.NewRecorder()
+			root.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("GET %s: got %d, want %d (%s)", tt.target, rec.Code, tt.want, rec.Body)
+			}
+		})
+	}
+}
+
+func TestNoAuthConfigured(t *testing.T) {
+	mux, h, _ := newTestAPI(t, nil)
+	root := h.Authenticate(mux)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/queue/attributes", nil)
+	rec := httptest.NewRecorder()
+	root.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("without OIDC or tokens: got %d, want 200 (%s)", rec.Code, rec.Body)
+	}
+}
@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// basePathKey is the context key under which BasePath stores the prefix it stripped.
+type basePathKey struct{}
+
+// basePathFrom returns the prefix the request was served under ("" without BASE_PATH). Paths
+// handed back to clients (share links, redirects) are prefixed with it.
+func basePathFrom(ctx context.Context) string {
+	base, _ := ctx.Value(basePathKey{}).(string)
+	return base
+}
+
+// CleanBasePath normalizes a BASE_PATH: "sqs-ui/" and "/sqs-ui" both become "/sqs-ui", and
+// "" or "/" mean no prefix.
+func CleanBasePath(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "/" {
+		return "", nil
+	}
+	if strings.ContainsAny(s, "?#%\\ ") {
+		return "", fmt.Errorf("BASE_PATH %q must be a plain path like /sqs-ui", s)
+	}
+	cleaned := path.Clean("/" + s)
+	if strings.Contains(s, "..") || cleaned == "/" {
+		return "", fmt.Errorf("BASE_PATH %q must be a plain path like /sqs-ui", s)
+	}
+	return cleaned, nil
+}
+
+// baseWriter puts the prefix back on redirects, which handlers write as paths on this server.
+type baseWriter struct {
+	http.ResponseWriter
+	base string
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer (SSE flushes).
+func (bw *baseWriter) Unwrap() http.ResponseWriter { return bw.ResponseWriter }
+
+func (bw *baseWriter) WriteHeader(status int) {
+	h := bw.ResponseWriter.Header()
+	if loc := h.Get("Location"); strings.HasPrefix(loc, "/") && !strings.HasPrefix(loc, "//") && !strings.HasPrefix(loc, bw.base+"/") {
+		h.Set("Location", bw.base+loc)
+	}
+	bw.ResponseWriter.WriteHeader(status)
+}
+
+// BasePath serves next under prefix (cleaned by CleanBasePath) for reverse proxies that route
+// a path to sqs-ui without rewriting it: prefix/api/info reaches /api/info, and the bare prefix
+// redirects to prefix/. Health probes also answer at /healthz and /readyz, where kubelets
+// call them; anything else outside the prefix is 404.
+func BasePath(prefix string, next http.Handler) http.Handler {
+	stripped := http.StripPrefix(prefix, next)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == prefix:
+			target := prefix + "/"
+			if r.URL.RawQuery != "" {
+				target += "?" + r.URL.RawQuery
+			}
+			http.Redirect(w, r, target, http.StatusMovedPermanently)
+		case strings.HasPrefix(r.URL.Path, prefix+"/"):
+			r = r.WithContext(context.WithValue(r.Context(), basePathKey{}, prefix))
+			stripped.ServeHTTP(&baseWriter{ResponseWriter: w, base: prefix}, r)
+		case r.URL.Path == "/healthz" || r.URL.Path == "/readyz":
+			next.ServeHTTP(w, r)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
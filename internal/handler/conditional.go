@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"github.com/pachecoc/sqs-ui/internal/service"
+)
+
+// notModified tags an observe listing with an ETag derived from the queue depth and the
+// query, and answers 304 when If-None-Match already carries it, so the receive is skipped.
+// The depth comes from the attribute cache; ?refresh=true reads it from SQS.
+func (h *APIHandler) notModified(w http.ResponseWriter, r *http.Request, svc *service.SQSService) bool {
+	depth, err := svc.DepthTag(r.Context())
+	if err != nil {
+		h.Log.DebugContext(r.Context(), "no depth tag for conditional receive", "error", err)
+		return false
+	}
+	query := r.URL.Query()
+	query.Del("refresh") // how fresh the depth is doesn't change the listing
+	sum := sha256.Sum256([]byte(depth + "?" + query.Encode()))
+	etag := `W/"` + hex.EncodeToString(sum[:8]) + `"`
+	w.Header().Set("ETag", etag)
+	if !etagMatches(r.Header.Get("If-None-Match"), etag) {
+		return false
+	}
+	setPollHint(w, svc)
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// etagMatches applies the weak comparison of If-None-Match: any listed tag, or "*".
+func etagMatches(header, etag string) bool {
+	for _, t := range strings.Split(header, ",") {
+		t = strings.TrimSpace(t)
+		if t == "*" || strings.TrimPrefix(t, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
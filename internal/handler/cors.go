@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSConfig configures CORS. An empty AllowedOrigins disables it.
+type CORSConfig struct {
+	// AllowedOrigins are exact origins ("https://ui.example.com"), subdomain wildcards
+	// ("https://*.example.com") or "*" for any origin.
+	AllowedOrigins []string
+	AllowedMethods []string
+	AllowedHeaders []string
+	// AllowCredentials lets browsers send cookies and read responses with them; it can't be
+	// combined with "*".
+	AllowCredentials bool
+	MaxAge           time.Duration
+}
+
+// corsExposed are the response headers cross-origin scripts may read.
+const corsExposed = "X-Request-ID, Retry-After, ETag"
+
+// Validate checks that origins are "*", scheme://host[:port] or scheme://*.domain, and that
+// credentials aren't allowed for any origin.
+func (c CORSConfig) Validate() error {
+	for _, o := range c.AllowedOrigins {
+		if o == "*" {
+			if c.AllowCredentials {
+				return errors.New("CORS_ALLOW_CREDENTIALS can't be combined with CORS_ALLOWED_ORIGINS=*; list the origins")
+			}
+			continue
+		}
+		u, err := url.Parse(strings.Replace(o, "://*.", "://", 1))
+		if err != nil || u.Scheme == "" || u.Host == "" || u.Path != "" || u.RawQuery != "" {
+			return fmt.Errorf("CORS_ALLOWED_ORIGINS entry %q must be scheme://host[:port], scheme://*.domain or *", o)
+		}
+	}
+	return nil
+}
+
+// allows reports whether origin is one of AllowedOrigins.
+func (c CORSConfig) allows(origin string) bool {
+	for _, o := range c.AllowedOrigins {
+		if o == "*" || strings.EqualFold(o, origin) {
+			return true
+		}
+		scheme, host, ok := strings.Cut(o, "://*.")
+		if ok && strings.HasPrefix(origin, scheme+"://") && strings.HasSuffix(strings.ToLower(origin), "."+strings.ToLower(host)) {
+			return true
+		}
+	}
+	return false
+}
+
+// CORS answers cross-origin requests to the API (/api/ routes and /info) from the allowed
+// origins. Preflights are answered here, before authentication, since browsers send them
+// without credentials; other requests pass through with the CORS headers added. Requests
+// from other origins get no CORS headers, so browsers keep refusing them.
+func CORS(cfg CORSConfig, next http.Handler) http.Handler {
+	methods := strings.Join(cfg.AllowedMethods, ", ")
+	headers := strings.Join(cfg.AllowedHeaders, ", ")
+	anyOrigin := slices.Contains(cfg.AllowedOrigins, "*") && !cfg.AllowCredentials
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" || !isAPIPath(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		h := w.Header()
+		h.Add("Vary", "Origin")
+		if !cfg.allows(origin) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if anyOrigin {
+			h.Set("Access-Control-Allow-Origin", "*")
+		} else {
+			h.Set("Access-Control-Allow-Origin", origin)
+		}
+		if cfg.AllowCredentials {
+			h.Set("Access-Control-Allow-Credentials", "true")
+		}
+
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			h.Add("Vary", "Access-Control-Request-Method")
+			h.Add("Vary", "Access-Control-Request-Headers")
+			h.Set("Access-Control-Allow-Methods", methods)
+			h.Set("Access-Control-Allow-Headers", headers)
+			if cfg.MaxAge > 0 {
+				h.Set("Access-Control-Max-Age", strconv.Itoa(int(cfg.MaxAge.Seconds())))
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		h.Set("Access-Control-Expose-Headers", corsExposed)
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,36 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+)
+
+// handleDigest previews the queue health digest (GET) or posts it right away (POST).
+func (h *APIHandler) handleDigest(w http.ResponseWriter, r *http.Request) {
+	if h.Digest == nil {
+		respondError(w, http.StatusServiceUnavailable, errors.New("digest is not configured"))
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		summaries, err := h.Digest.Build(r.Context())
+		if err != nil {
+			respondError(w, http.StatusServiceUnavailable, err)
+			return
+		}
+		respondJSON(w, http.StatusOK, map[string]any{"queues": summaries})
+	case http.MethodPost:
+		if err := h.Digest.Send(r.Context()); err != nil {
+			h.Log.WarnContext(r.Context(), "failed to send digest", "error", err)
+			respondError(w, http.StatusBadGateway, err)
+			return
+		}
+		respondJSON(w, http.StatusOK, map[string]string{"status": "sent"})
+	case http.MethodOptions:
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		respondError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+	}
+}
@@ -0,0 +1,210 @@
+package handler
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pachecoc/sqs-ui/internal/logging"
+	"github.com/pachecoc/sqs-ui/internal/service"
+	"github.com/pachecoc/sqs-ui/internal/validate"
+)
+
+// envelope is the shape of every JSON API response: exactly one of Data or Error is set,
+// except for partial results where Data holds what was gathered before the error.
+type envelope struct {
+	Data  any          `json:"data"`
+	Error *envelopeErr `json:"error"`
+	Meta  envelopeMeta `json:"meta"`
+}
+
+type envelopeErr struct {
+	Code    string                `json:"code"`
+	Message string                `json:"message"`
+	Fields  []validate.FieldError `json:"fields,omitempty"`
+	Hint    string                `json:"hint,omitempty"`
+	// RetryAfterSeconds mirrors the Retry-After header for errors that clear by themselves.
+	RetryAfterSeconds int `json:"retry_after_seconds,omitempty"`
+}
+
+type envelopeMeta struct {
+	RequestID  string      `json:"request_id,omitempty"`
+	DurationMS int64       `json:"duration_ms"`
+	Pagination *pagination `json:"pagination,omitempty"`
+	Partial    bool        `json:"partial,omitempty"`
+	// PollIntervalSeconds mirrors the X-Poll-Interval header on queue listings and info.
+	PollIntervalSeconds int `json:"poll_interval_seconds,omitempty"`
+}
+
+// pagination describes where a page sits in a larger result. Total is nil when the
+// source can't tell (token-based listings).
+type pagination struct {
+	Total      *int   `json:"total,omitempty"`
+	Offset     int    `json:"offset"`
+	Count      int    `json:"count"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// paginated is implemented by responses that are one page of a larger result.
+type paginated interface {
+	pagination() *pagination
+}
+
+type requestMetaKey struct{}
+
+// requestMeta is what RequestMeta records for each request.
+type requestMeta struct {
+	ID    string
+	Start time.Time
+}
+
+// metaWriter carries the request meta to respondJSON, which only sees the ResponseWriter.
+type metaWriter struct {
+	http.ResponseWriter
+	meta requestMeta
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer (flush, deadlines).
+func (mw *metaWriter) Unwrap() http.ResponseWriter { return mw.ResponseWriter }
+
+// RequestMeta assigns each request an ID (reusing a sane incoming X-Request-ID), echoes it
+// in the response headers and records the start time for the envelope's duration_ms. The
+// ID is also logged as request_id by records logged with the request's context.
+func RequestMeta(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		meta := requestMeta{ID: r.Header.Get("X-Request-ID"), Start: time.Now()}
+		if !validRequestID(meta.ID) {
+			meta.ID = newRequestID()
+		}
+		w.Header().Set("X-Request-ID", meta.ID)
+		ctx := context.WithValue(logging.WithRequestID(r.Context(), meta.ID), requestMetaKey{}, meta)
+		next.ServeHTTP(&metaWriter{ResponseWriter: w, meta: meta}, r.WithContext(ctx))
+	})
+}
+
+// requestID returns the ID RequestMeta assigned to the request, if any.
+func requestID(ctx context.Context) string {
+	meta, _ := ctx.Value(requestMetaKey{}).(requestMeta)
+	return meta.ID
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	return !strings.ContainsFunc(id, func(r rune) bool { return r < 0x21 || r > 0x7e })
+}
+
+func newRequestID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// respondJSON writes v as the data of a response envelope.
+func respondJSON(w http.ResponseWriter, status int, v any) {
+	env := envelope{Data: v, Meta: metaFor(w)}
+	if p, ok := v.(paginated); ok {
+		env.Meta.Pagination = p.pagination()
+	}
+	writeJSON(w, status, env)
+}
+
+// respondError writes an error envelope. The code is the snake_case status text
+// (e.g. "not_found") unless err carries its own, the message the error itself or the
+// status text when err is nil. Validation errors also list the invalid fields; known
+// AWS failures are reworded and carry a remediation hint.
+func respondError(w http.ResponseWriter, status int, err error) {
+	e := describeError(status, err)
+	noteError(w, e.Code)
+	if e.RetryAfterSeconds > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(e.RetryAfterSeconds))
+	}
+	writeJSON(w, status, envelope{Error: e, Meta: metaFor(w)})
+}
+
+// describeError builds the error body respondError writes, also sent on event streams.
+func describeError(status int, err error) *envelopeErr {
+	e := &envelopeErr{Code: errorCode(status), Message: http.StatusText(status)}
+	if err == nil {
+		return e
+	}
+	err = service.TranslateAWSError(err)
+	e.Message = err.Error()
+	var coded interface{ ErrorCode() string }
+	if errors.As(err, &coded) {
+		e.Code = coded.ErrorCode()
+	}
+	var fields validate.Errors
+	if errors.As(err, &fields) {
+		e.Fields = fields
+	}
+	var hinted interface{ Remediation() string }
+	if errors.As(err, &hinted) {
+		e.Hint = hinted.Remediation()
+	}
+	var retry interface{ RetryAfter() time.Duration }
+	if errors.As(err, &retry) {
+		e.RetryAfterSeconds = int(math.Ceil(retry.RetryAfter().Seconds()))
+	}
+	return e
+}
+
+// respondPartial writes the results gathered before the request budget ran out: 504 with
+// both data and error set and meta.partial, so clients can still show what arrived.
+// Non-JSON formats get the same status and an X-Partial-Results header.
+func respondPartial(w http.ResponseWriter, r *http.Request, v any, err error, text func() string) {
+	w.Header().Set("X-Partial-Results", "true")
+	noteError(w, errorCode(http.StatusGatewayTimeout))
+	if negotiateFormat(r) != formatJSON {
+		respondNegotiated(w, r, http.StatusGatewayTimeout, v, text)
+		return
+	}
+	env := envelope{
+		Data:  v,
+		Error: &envelopeErr{Code: errorCode(http.StatusGatewayTimeout), Message: err.Error()},
+		Meta:  metaFor(w),
+	}
+	env.Meta.Partial = true
+	if p, ok := v.(paginated); ok {
+		env.Meta.Pagination = p.pagination()
+	}
+	writeJSON(w, http.StatusGatewayTimeout, env)
+}
+
+// writeJSON writes v as-is, for callers with a fixed response contract (Slack).
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func metaFor(w http.ResponseWriter) envelopeMeta {
+	poll, _ := strconv.Atoi(w.Header().Get("X-Poll-Interval"))
+	for {
+		switch v := w.(type) {
+		case *metaWriter:
+			return envelopeMeta{RequestID: v.meta.ID, DurationMS: time.Since(v.meta.Start).Milliseconds(), PollIntervalSeconds: poll}
+		case interface{ Unwrap() http.ResponseWriter }:
+			w = v.Unwrap()
+		default:
+			return envelopeMeta{PollIntervalSeconds: poll}
+		}
+	}
+}
+
+func errorCode(status int) string {
+	text := http.StatusText(status)
+	if text == "" {
+		return "error"
+	}
+	return strings.ToLower(strings.NewReplacer(" ", "_", "-", "_", "'", "").Replace(text))
+}
@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/pachecoc/sqs-ui/internal/erasure"
+	"github.com/pachecoc/sqs-ui/internal/events"
+)
+
+// requireAdmin refuses the request (403) unless the user is one of Admins. Admin endpoints
+// are off while no admins are configured.
+func (h *APIHandler) requireAdmin(w http.ResponseWriter, r *http.Request) (string, bool) {
+	if len(h.Admins) == 0 {
+		respondError(w, http.StatusForbidden, errors.New("admin endpoints are disabled; set ADMINS"))
+		return "", false
+	}
+	user := h.requestUser(r)
+	if user == "" {
+		respondError(w, http.StatusForbidden, fmt.Errorf("admin endpoints need a user (%s header)", h.UserHeader))
+		return "", false
+	}
+	if !slices.Contains(h.Admins, user) {
+		respondError(w, http.StatusForbidden, fmt.Errorf("%s is not an admin", user))
+		return "", false
+	}
+	return user, true
+}
+
+// handleErase deletes every stored copy of matching messages (JSON { "contains": "...",
+// "message_ids": [...] }) and reports what was removed. ?dry_run=true only reports.
+func (h *APIHandler) handleErase(w http.ResponseWriter, r *http.Request) {
+	if !enforceMethod(w, r, http.MethodPost) {
+		return
+	}
+	user, ok := h.requireAdmin(w, r)
+	if !ok {
+		return
+	}
+	if h.Eraser == nil {
+		respondError(w, http.StatusServiceUnavailable, errors.New("erasure is not enabled"))
+		return
+	}
+
+	var f erasure.Filter
+	if err := json.NewDecoder(io.LimitReader(r.Body, 1<<20)).Decode(&f); err != nil {
+		respondError(w, http.StatusBadRequest, fmt.Errorf("invalid JSON body: %w", err))
+		return
+	}
+	if err := f.Validate(); err != nil {
+		respondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	dry := dryRun(r)
+	report, err := h.Eraser.Erase(r.Context(), f, dry)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	// The filter itself may be personal data, so only its shape is logged
+	h.Log.InfoContext(r.Context(), "stored message copies erased", "user", user, "dry_run", dry, "records", report.Records,
+		"message_ids", len(report.MessageIDs), "errors", len(report.Errors))
+	if !dry && report.Records > 0 {
+		h.Events.Publish(events.Event{
+			Type:    events.TypeDataErased,
+			Level:   events.LevelWarn,
+			Message: fmt.Sprintf("%s erased %d stored records (%s)", user, report.Records, erasedSummary(report)),
+			Data:    map[string]any{"user": user, "records": report.Records},
+		})
+	}
+	respondJSON(w, http.StatusOK, report)
+}
+
+// erasedSummary counts removed records per category, e.g. "snapshots 2, annotations 1".
+func erasedSummary(rep erasure.Report) string {
+	parts := make([]string, 0, len(rep.Removed))
+	for category, keys := range rep.Removed {
+		parts = append(parts, fmt.Sprintf("%s %d", category, len(keys)))
+	}
+	slices.Sort(parts)
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/pachecoc/sqs-ui/internal/faultsqs"
+	"github.com/pachecoc/sqs-ui/internal/service"
+)
+
+// injectFaults swaps the selected queue's client for one running faults.
+func injectFaults(h *APIHandler, faults ...faultsqs.Fault) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	svc := service.NewSQSService(context.Background(), faultsqs.New(h.SQS.Client, 1, faults...), h.SQS.QueueName, h.SQS.QueueURL, h.SQS.Region, discardLogger())
+	svc.WaitSeconds = h.SQS.WaitSeconds
+	h.SQS = svc
+}
+
+func TestInjectedFaultStatuses(t *testing.T) {
+	mux, h, _ := newTestAPI(t, nil)
+	injectFaults(h,
+		faultsqs.Fault{Ops: []string{"SendMessage"}, ErrorRate: 1, Code: "ThrottlingException"},
+		faultsqs.Fault{Ops: []string{"ReceiveMessage"}, ErrorRate: 1},
+	)
+
+	rec := do(mux, "", http.MethodPost, "/api/send", `{"message":"hello"}`)
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("throttled send: got %d, want 429 (%s)", rec.Code, rec.Body)
+	}
+	var env struct {
+		Error struct{ Code, Hint string }
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &env); err != nil || env.Error.Code != service.KindThrottled || env.Error.Hint == "" {
+		t.Errorf("throttled send body %s", rec.Body)
+	}
+
+	if rec := do(mux, "", http.MethodGet, "/api/messages", ""); rec.Code != http.StatusInternalServerError {
+		t.Errorf("failing receive: got %d, want 500 (%s)", rec.Code, rec.Body)
+	}
+}
+
+func TestPartialListing(t *testing.T) {
+	mux, h, _ := newTestAPI(t, nil)
+	if rec := do(mux, "", http.MethodPost, "/api/send", `{"message":"first"}`); rec.Code != http.StatusOK {
+		t.Fatalf("send: got %d (%s)", rec.Code, rec.Body)
+	}
+	// The first receive answers at 300ms; the request budget runs out during the second
+	injectFaults(h, faultsqs.Fault{Ops: []string{"ReceiveMessage"}, Latency: 300 * time.Millisecond})
+	h.RequestTimeout = 500 * time.Millisecond
+
+	rec := do(mux, "", http.MethodGet, "/api/messages", "")
+	if rec.Code != http.StatusGatewayTimeout || rec.Header().Get("X-Partial-Results") != "true" {
+		t.Fatalf("listing past the budget: got %d, partial %q, want 504 with partial results (%s)",
+			rec.Code, rec.Header().Get("X-Partial-Results"), rec.Body)
+	}
+	var env struct {
+		Data []map[string]any
+		Meta struct{ Partial bool }
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &env); err != nil || !env.Meta.Partial || len(env.Data) != 1 || env.Data[0]["body"] != "first" {
+		t.Errorf("partial listing body %s", rec.Body)
+	}
+}
@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/pachecoc/sqs-ui/internal/metrics"
+)
+
+// handleQueueHealth reports in-flight health of the active queue: an estimate of messages stuck in
+// flight (and since when) from recorded NotVisible history, plus a redelivery sample.
+func (h *APIHandler) handleQueueHealth(w http.ResponseWriter, r *http.Request) {
+	if !enforceMethod(w, r, http.MethodGet) {
+		return
+	}
+	if h.InFlight == nil {
+		respondError(w, http.StatusServiceUnavailable, errors.New("in-flight tracking is not enabled"))
+		return
+	}
+
+	svc := h.queueService(r.Context())
+	report, err := h.InFlight.Report(r.Context(), svc)
+	if err != nil {
+		respondError(w, serviceErrorStatus(err), err)
+		return
+	}
+	respondJSON(w, http.StatusOK, report)
+}
+
+// handleQueueAdvisor returns receive-tuning suggestions (wait time, batch size) for the active queue,
+// based on the receive calls this replica made and the queue's attributes.
+func (h *APIHandler) handleQueueAdvisor(w http.ResponseWriter, r *http.Request) {
+	if !enforceMethod(w, r, http.MethodGet) {
+		return
+	}
+
+	advice, err := h.queueService(r.Context()).Advise(r.Context())
+	if err != nil {
+		respondError(w, serviceErrorStatus(err), err)
+		return
+	}
+	respondJSON(w, http.StatusOK, advice)
+}
+
+// handleDepthHistory returns the depth samples recorded for the queue over the history
+// window (oldest first), read from memory without calling SQS.
+func (h *APIHandler) handleDepthHistory(w http.ResponseWriter, r *http.Request) {
+	if !enforceMethod(w, r, http.MethodGet) {
+		return
+	}
+	if h.Depth == nil {
+		respondError(w, http.StatusServiceUnavailable, errors.New("depth history is not enabled"))
+		return
+	}
+	svc := h.queueService(r.Context())
+	respondJSON(w, http.StatusOK, h.Depth.History(svc.QueueName))
+}
+
+// handleCloudWatchMetrics returns the queue's sends, receives, deletes and oldest message
+// age from CloudWatch over ?range= (or ?start=&end=), one point per period.
+func (h *APIHandler) handleCloudWatchMetrics(w http.ResponseWriter, r *http.Request) {
+	if !enforceMethod(w, r, http.MethodGet) {
+		return
+	}
+	if h.CloudWatch == nil {
+		respondError(w, http.StatusServiceUnavailable, errors.New("CloudWatch metrics are not enabled"))
+		return
+	}
+	q, err := metrics.ParseQuery(r.URL.Query(), time.Now())
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err)
+		return
+	}
+	svc := h.queueService(r.Context())
+	history, err := h.CloudWatch.Fetch(r.Context(), svc.QueueName, svc.Region, q)
+	if err != nil {
+		h.Log.ErrorContext(r.Context(), "failed to fetch CloudWatch metrics", "queue_name", svc.QueueName, "error", err)
+		respondError(w, serviceErrorStatus(err), err)
+		return
+	}
+	respondJSON(w, http.StatusOK, history)
+}
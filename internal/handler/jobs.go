@@ -0,0 +1,183 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/http"
+
+	"github.com/pachecoc/sqs-ui/internal/jobs"
+	"github.com/pachecoc/sqs-ui/internal/rbac"
+	"github.com/pachecoc/sqs-ui/internal/service"
+	"github.com/pachecoc/sqs-ui/internal/validate"
+)
+
+// handleJobs lists jobs (GET) or submits a new one (POST { "type": "...", "params": {...} }).
+func (h *APIHandler) handleJobs(w http.ResponseWriter, r *http.Request) {
+	if h.Jobs == nil {
+		respondError(w, http.StatusServiceUnavailable, errors.New("jobs are not enabled"))
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		respondJSON(w, http.StatusOK, map[string]any{
+			"jobs":  h.Jobs.List(r.Context()),
+			"types": h.Jobs.Types(),
+		})
+	case http.MethodPost:
+		h.submitJob(w, r)
+	case http.MethodOptions:
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		respondError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+	}
+}
+
+func (h *APIHandler) submitJob(w http.ResponseWriter, r *http.Request) {
+	if ct := r.Header.Get("Content-Type"); ct != "" && ct != "application/json" {
+		respondError(w, http.StatusUnsupportedMediaType, errors.New("Content-Type must be application/json"))
+		return
+	}
+
+	var req struct {
+		Type   string         `json:"type"`
+		Params map[string]any `json:"params"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, err)
+		return
+	}
+	var v validate.Validator
+	v.Required("type", req.Type)
+	if limit, ok := req.Params["limit"]; ok {
+		n, isNum := limit.(float64)
+		v.Check(isNum && n >= 0 && n == float64(int(n)), "params.limit", "must be a non-negative integer")
+	}
+	if err := v.Err(); err != nil {
+		respondError(w, http.StatusBadRequest, err)
+		return
+	}
+	// Jobs that delete messages or queues are purges under another name; they need the same role
+	if (jobs.Destructive(req.Type) || jobs.AccountWide(req.Type)) && !h.checkRole(w, r, rbac.Admin, req.Type+" job") {
+		return
+	}
+
+	svc := h.queueService(r.Context())
+	if svc == nil {
+		respondError(w, http.StatusServiceUnavailable, errors.New("service unavailable"))
+		return
+	}
+	// ?dry_run=true is shorthand for params.dry_run on destructive job kinds
+	if dryRun(r) {
+		if req.Params == nil {
+			req.Params = make(map[string]any)
+		}
+		req.Params["dry_run"] = true
+	}
+
+	if jobs.AccountWide(req.Type) {
+		// Queue cleanups don't touch the active queue; they confirm their own dry run instead
+		dry, _ := req.Params["dry_run"].(bool)
+		confirm, _ := req.Params["confirm"].(string)
+		if !dry && confirm == "" {
+			v.Add("params.confirm", "is required: submit a dry run first and pass its confirm token")
+			respondError(w, http.StatusBadRequest, v.Err())
+			return
+		}
+	} else {
+		if err := svc.EnsureQueueConfigured(); err != nil {
+			respondError(w, http.StatusBadRequest, err)
+			return
+		}
+		// Every other job kind receives from the queue, which an observe-only lock rules out
+		if !h.checkLock(w, r, req.Type+" job", svc.QueueName) {
+			return
+		}
+	}
+
+	if dry, _ := req.Params["dry_run"].(bool); !dry && jobs.Destructive(req.Type) {
+		if svc.ReadOnly {
+			respondError(w, http.StatusForbidden, service.ErrReadOnly)
+			return
+		}
+		if !h.checkMaintenance(w, r, req.Type, svc.QueueName) {
+			return
+		}
+		if h.Approvals.Required(svc.QueueName) {
+			h.requestApproval(w, r, req.Type, svc.QueueName, req.Params)
+			return
+		}
+	}
+
+	job, err := h.Jobs.Submit(req.Type, svc, req.Params)
+	if err != nil {
+		if errors.Is(err, jobs.ErrUnknownType) {
+			respondError(w, http.StatusBadRequest, err)
+			return
+		}
+		respondError(w, http.StatusInternalServerError, err)
+		return
+	}
+	respondJSON(w, http.StatusAccepted, job)
+}
+
+// handleJob returns (GET) or cancels (DELETE) a single job.
+func (h *APIHandler) handleJob(w http.ResponseWriter, r *http.Request) {
+	if h.Jobs == nil {
+		respondError(w, http.StatusServiceUnavailable, errors.New("jobs are not enabled"))
+		return
+	}
+
+	var (
+		job jobs.Job
+		err error
+	)
+	switch r.Method {
+	case http.MethodGet:
+		job, err = h.Jobs.Get(r.Context(), r.PathValue("id"))
+	case http.MethodDelete:
+		job, err = h.Jobs.Cancel(r.PathValue("id"))
+	case http.MethodOptions:
+		w.WriteHeader(http.StatusNoContent)
+		return
+	default:
+		w.Header().Set("Allow", "GET, DELETE")
+		respondError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	if errors.Is(err, jobs.ErrNotFound) {
+		respondError(w, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, job)
+}
+
+// handleJobArtifact downloads the retained artifact of a finished job.
+func (h *APIHandler) handleJobArtifact(w http.ResponseWriter, r *http.Request) {
+	if !enforceMethod(w, r, http.MethodGet) {
+		return
+	}
+	if h.Jobs == nil {
+		respondError(w, http.StatusServiceUnavailable, errors.New("jobs are not enabled"))
+		return
+	}
+
+	artifact, err := h.Jobs.Artifact(r.Context(), r.PathValue("id"))
+	if errors.Is(err, jobs.ErrNotFound) {
+		respondError(w, http.StatusNotFound, errors.New("artifact not found or expired"))
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": artifact.Filename}))
+	respondRaw(w, http.StatusOK, artifact.ContentType, artifact.Data)
+}
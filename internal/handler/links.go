@@ -0,0 +1,133 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/pachecoc/sqs-ui/internal/rbac"
+	"github.com/pachecoc/sqs-ui/internal/scripts"
+	"github.com/pachecoc/sqs-ui/internal/service"
+	"github.com/pachecoc/sqs-ui/internal/validate"
+)
+
+var (
+	regionPattern  = regexp.MustCompile(`^[a-z]{2}(-[a-z]+)+-\d$`)
+	accountPattern = regexp.MustCompile(`^\d{12}$`)
+)
+
+// linkParams are the listing settings a share link may carry; anything else in its query is
+// dropped.
+var linkParams = []string{"filter", "transform", "label", "mode"}
+
+// queueLink is a resolved share link: the queue it names and the state the UI opens it with.
+type queueLink struct {
+	QueueName string `json:"queue_name"`
+	QueueURL  string `json:"queue_url"`
+	Region    string `json:"region"`
+	Account   string `json:"account"`
+	// Link is the canonical form of the link, for copying.
+	Link      string `json:"link"`
+	Filter    string `json:"filter,omitempty"`
+	Transform string `json:"transform,omitempty"`
+	Label     string `json:"label,omitempty"`
+	Mode      string `json:"mode,omitempty"`
+}
+
+// queueAccount returns the account ID in a queue URL (https://sqs.<region>.amazonaws.com/<account>/<name>).
+func queueAccount(queueURL string) string {
+	u, err := url.Parse(queueURL)
+	if err != nil {
+		return ""
+	}
+	account, _, _ := strings.Cut(strings.TrimPrefix(u.Path, "/"), "/")
+	return account
+}
+
+// handleQueueLink resolves a share link, /q/{region}/{account}/{name}?filter=..., for the UI
+// that opens it: the queue must be reachable from this server with the caller's access, and
+// the scripts and mode it asks for must be usable by them. The UI then works on that queue
+// with ?queue=, leaving everyone else's default queue alone.
+func (h *APIHandler) handleQueueLink(w http.ResponseWriter, r *http.Request) {
+	if !enforceMethod(w, r, http.MethodGet) {
+		return
+	}
+	region, account, name := r.PathValue("region"), r.PathValue("account"), r.PathValue("name")
+	q := r.URL.Query()
+	link := queueLink{
+		Region:    region,
+		Account:   account,
+		Filter:    q.Get("filter"),
+		Transform: q.Get("transform"),
+		Label:     q.Get("label"),
+		Mode:      q.Get("mode"),
+	}
+	var v validate.Validator
+	v.Check(regionPattern.MatchString(region), "region", "must be an AWS region (e.g. us-east-1)")
+	v.Check(accountPattern.MatchString(account), "account", "must be a 12-digit AWS account ID")
+	v.Check(queueNamePattern.MatchString(name), "name", "must be a queue name (letters, digits, - and _, optionally ending in .fifo)")
+	mode, err := service.ParseReceiveMode(link.Mode)
+	if err != nil {
+		v.Add("mode", "must be observe or consume")
+	}
+	if err := v.Err(); err != nil {
+		respondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	base := h.getService()
+	if base == nil {
+		respondError(w, http.StatusServiceUnavailable, errors.New("service unavailable"))
+		return
+	}
+	// The link names a queue this server can't reach; say so rather than open another one
+	if region != base.Region {
+		respondError(w, http.StatusNotFound, fmt.Errorf("the link is for %s, but this server works in %s", region, base.Region))
+		return
+	}
+	svc, err := h.queueFor(r.Context(), name)
+	if err != nil {
+		respondError(w, serviceErrorStatus(err), err)
+		return
+	}
+	if got := queueAccount(svc.QueueURL); got != account {
+		respondError(w, http.StatusNotFound, fmt.Errorf("queue %s was not found in account %s", name, account))
+		return
+	}
+	if mode == service.ModeConsume && !h.checkRole(w, r, rbac.Operator, "consume mode") {
+		return
+	}
+	for _, script := range []string{link.Filter, link.Transform} {
+		if script == "" {
+			continue
+		}
+		if h.Scripts == nil {
+			respondError(w, http.StatusServiceUnavailable, errors.New("scripts are not enabled"))
+			return
+		}
+		if _, err := h.Scripts.Get(r.Context(), script); err != nil {
+			status := http.StatusInternalServerError
+			if errors.Is(err, scripts.ErrNotFound) {
+				status = http.StatusNotFound
+			}
+			respondError(w, status, fmt.Errorf("the link's script %s could not be loaded: %w", script, err))
+			return
+		}
+	}
+
+	link.QueueName, link.QueueURL = svc.QueueName, svc.QueueURL
+	kept := url.Values{}
+	for _, p := range linkParams {
+		if value := q.Get(p); value != "" {
+			kept.Set(p, value)
+		}
+	}
+	link.Link = basePathFrom(r.Context()) + "/q/" + region + "/" + account + "/" + name
+	if len(kept) > 0 {
+		link.Link += "?" + kept.Encode()
+	}
+	respondJSON(w, http.StatusOK, link)
+}
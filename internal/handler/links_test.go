@@ -0,0 +1,23 @@
+package handler
+
+import (
+	"strings"
+	"testing"
+)
+
+func FuzzQueueAccount(f *testing.F) {
+	f.Add("https://sqs.us-east-1.amazonaws.com/123456789012/orders")
+	f.Add("http://localhost:4566/000000000000/orders.fifo")
+	f.Add("https://sqs.us-east-1.amazonaws.com/")
+	f.Add("https://sqs.us-east-1.amazonaws.com")
+	f.Add("orders")
+	f.Add("https://sqs.us-east-1.amazonaws.com/1234%2F5678/orders")
+	f.Add("%zz")
+	f.Add("")
+	f.Fuzz(func(t *testing.T, queueURL string) {
+		account := queueAccount(queueURL)
+		if strings.Contains(account, "/") {
+			t.Fatalf("%q: account %q spans path segments", queueURL, account)
+		}
+	})
+}
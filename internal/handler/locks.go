@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/pachecoc/sqs-ui/internal/events"
+	"github.com/pachecoc/sqs-ui/internal/service"
+	"github.com/pachecoc/sqs-ui/internal/validate"
+)
+
+// checkLock refuses action on queue while it has an observe-only lock (423), or when the
+// lock can't be read.
+func (h *APIHandler) checkLock(w http.ResponseWriter, r *http.Request, action, queue string) bool {
+	if err := h.Locks.Check(r.Context(), queue, action); err != nil {
+		respondError(w, serviceErrorStatus(err), err)
+		return false
+	}
+	return true
+}
+
+// receiveMode applies the queue's observe-only lock to a listing: observe becomes peek
+// (visibility 0) and consume is refused.
+func (h *APIHandler) receiveMode(ctx context.Context, svc *service.SQSService, mode service.ReceiveMode) (service.ReceiveMode, error) {
+	if mode == service.ModeConsume {
+		return mode, h.Locks.Check(ctx, svc.QueueName, "consume mode")
+	}
+	lock, err := h.Locks.Get(ctx, svc.QueueName)
+	if err != nil {
+		return mode, err
+	}
+	if lock.Locked {
+		return service.ModePeek, nil
+	}
+	return mode, nil
+}
+
+// handleLocks lists queues with their observe-only lock state and audit trail.
+func (h *APIHandler) handleLocks(w http.ResponseWriter, r *http.Request) {
+	if !enforceMethod(w, r, http.MethodGet) {
+		return
+	}
+	if h.Locks == nil {
+		respondError(w, http.StatusServiceUnavailable, errors.New("locks are not enabled"))
+		return
+	}
+	list, err := h.Locks.List(r.Context())
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]any{"locks": list})
+}
+
+// handleLock reads (GET), sets (PUT, JSON { "reason": "..." }) or lifts (DELETE, optional
+// JSON reason) the observe-only lock of a queue.
+func (h *APIHandler) handleLock(w http.ResponseWriter, r *http.Request) {
+	if h.Locks == nil {
+		respondError(w, http.StatusServiceUnavailable, errors.New("locks are not enabled"))
+		return
+	}
+	queue := r.PathValue("queue")
+
+	switch r.Method {
+	case http.MethodGet:
+		lock, err := h.Locks.Get(r.Context(), queue)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, err)
+			return
+		}
+		respondJSON(w, http.StatusOK, lock)
+	case http.MethodPut, http.MethodDelete:
+		var body struct {
+			Reason string `json:"reason"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil && !errors.Is(err, io.EOF) {
+			respondError(w, http.StatusBadRequest, err)
+			return
+		}
+		locked := r.Method == http.MethodPut
+		var v validate.Validator
+		v.Check(queueNamePattern.MatchString(queue), "queue", "must be a queue name")
+		if locked {
+			v.Required("reason", body.Reason)
+		}
+		v.MaxBytes("reason", body.Reason, 500)
+		if err := v.Err(); err != nil {
+			respondError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		user := h.requestUser(r)
+		lock, err := h.Locks.Set(r.Context(), queue, locked, user, body.Reason)
+		if err != nil {
+			respondError(w, http.StatusForbidden, err)
+			return
+		}
+		event := "unlocked"
+		if locked {
+			event = "locked"
+		}
+		msg := fmt.Sprintf("%s %s %s (observe-only)", user, event, queue)
+		if body.Reason != "" {
+			msg += ": " + body.Reason
+		}
+		h.Log.WarnContext(r.Context(), "queue lock changed", "queue_name", queue, "event", event, "user", user, "reason", body.Reason)
+		h.Events.Publish(events.Event{
+			Type:    events.TypeQueueLockChanged,
+			Level:   events.LevelWarn,
+			Message: msg,
+			Data:    map[string]any{"queue_name": queue, "locked": locked, "user": user, "reason": body.Reason},
+		})
+		respondJSON(w, http.StatusOK, lock)
+	case http.MethodOptions:
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.Header().Set("Allow", "GET, PUT, DELETE")
+		respondError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+	}
+}
@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/pachecoc/sqs-ui/internal/locks"
+	"github.com/pachecoc/sqs-ui/internal/store"
+)
+
+func TestLockedQueueRefusesDestructiveActions(t *testing.T) {
+	mux, h, _ := newTestAPI(t, nil)
+	h.Locks = &locks.Manager{Store: store.NewMemory()}
+
+	if rec := do(mux, "ada", http.MethodPut, "/api/locks/"+testQueue, `{"reason":"consumers catching up"}`); rec.Code != http.StatusOK {
+		t.Fatalf("lock: got %d (%s)", rec.Code, rec.Body)
+	}
+
+	tests := []struct {
+		name, method, target, body string
+	}{
+		{"delete", http.MethodPost, "/api/messages/delete", `{"receipt_handles":["rh-1"]}`},
+		{"purge", http.MethodPost, "/api/purge", `{"confirm":"token"}`},
+		{"drain job", http.MethodPost, "/api/jobs", `{"type":"drain"}`},
+		{"export job", http.MethodPost, "/api/jobs", `{"type":"export"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := do(mux, "ada", tt.method, tt.target, tt.body)
+			if rec.Code != http.StatusLocked || !strings.Contains(rec.Body.String(), "queue_locked") {
+				t.Errorf("got %d, want 423 queue_locked (%s)", rec.Code, rec.Body)
+			}
+		})
+	}
+
+	if rec := do(mux, "ada", http.MethodDelete, "/api/locks/"+testQueue, ""); rec.Code != http.StatusOK {
+		t.Fatalf("unlock: got %d (%s)", rec.Code, rec.Body)
+	}
+	if rec := do(mux, "ada", http.MethodPost, "/api/jobs", `{"type":"export"}`); rec.Code != http.StatusAccepted {
+		t.Errorf("export job after unlocking: got %d, want 202 (%s)", rec.Code, rec.Body)
+	}
+}
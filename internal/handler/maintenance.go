@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pachecoc/sqs-ui/internal/events"
+)
+
+// checkMaintenance enforces maintenance windows for a destructive action on queue. Outside the
+// queue's windows it responds 403 and returns false, unless overrides are allowed and the request
+// gives a reason with ?override=<reason>.
+func (h *APIHandler) checkMaintenance(w http.ResponseWriter, r *http.Request, action, queue string) bool {
+	err := h.Maintenance.Check(queue, time.Now())
+	if err == nil {
+		return true
+	}
+
+	reason := strings.TrimSpace(r.URL.Query().Get("override"))
+	if !h.Maintenance.AllowOverride || reason == "" {
+		if h.Maintenance.AllowOverride {
+			err = fmt.Errorf("%w; pass ?override=<reason> to proceed anyway", err)
+		}
+		respondError(w, http.StatusForbidden, err)
+		return false
+	}
+
+	user := h.requestUser(r)
+	if user == "" {
+		respondError(w, http.StatusForbidden, errors.New("a user is required to override a maintenance window"))
+		return false
+	}
+	h.Log.WarnContext(r.Context(), "maintenance window overridden", "action", action, "queue_name", queue, "user", user, "reason", reason)
+	h.Events.Publish(events.Event{
+		Type:    events.TypeMaintenanceOverride,
+		Level:   events.LevelWarn,
+		Message: fmt.Sprintf("%s overrode the maintenance window to %s %s: %s", user, action, queue, reason),
+		Data:    map[string]any{"action": action, "queue_name": queue, "user": user, "reason": reason},
+	})
+	return true
+}
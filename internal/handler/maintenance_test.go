@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/pachecoc/sqs-ui/internal/maintenance"
+)
+
+func TestMaintenanceWindows(t *testing.T) {
+	mux, h, _ := newTestAPI(t, nil)
+	// A window on no day: every change to testQueue is outside it
+	h.Maintenance = &maintenance.Policy{Rules: []maintenance.Rule{{Queue: testQueue, Windows: []maintenance.Window{{}}}}}
+
+	tests := []struct {
+		method, target, body string
+		dryRun               bool
+	}{
+		{http.MethodPost, "/api/messages/delete", `{"receipt_handles":["rh-1"]}`, true},
+		{http.MethodPut, "/api/queue/attributes", `{"attributes":{"VisibilityTimeout":60}}`, true},
+		{http.MethodPut, "/api/queue/tags", `{"tags":{"team":"payments"}}`, false},
+		{http.MethodDelete, "/api/queue/tags", `{"keys":["team"]}`, false},
+		{http.MethodPut, "/api/queue/redrive", `{"dead_letter_queue":"orders-dlq","max_receive_count":5}`, true},
+		{http.MethodDelete, "/api/queue/redrive", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.target, func(t *testing.T) {
+			h.Maintenance.AllowOverride = false
+			if rec := do(mux, "ada", tt.method, tt.target, tt.body); rec.Code != http.StatusForbidden {
+				t.Fatalf("outside the window: got %d, want 403 (%s)", rec.Code, rec.Body)
+			}
+			if rec := do(mux, "ada", tt.method, tt.target+"?override=incident", tt.body); rec.Code != http.StatusForbidden {
+				t.Errorf("override while overrides are off: got %d, want 403 (%s)", rec.Code, rec.Body)
+			}
+			if tt.dryRun {
+				if rec := do(mux, "ada", tt.method, tt.target+"?dry_run=true", tt.body); rec.Code == http.StatusForbidden {
+					t.Errorf("dry run refused: %s", rec.Body)
+				}
+			}
+
+			h.Maintenance.AllowOverride = true
+			rec := do(mux, "ada", tt.method, tt.target, tt.body)
+			if rec.Code != http.StatusForbidden || !strings.Contains(rec.Body.String(), "override") {
+				t.Errorf("without a reason: got %d, want 403 naming ?override= (%s)", rec.Code, rec.Body)
+			}
+			if rec := do(mux, "", tt.method, tt.target+"?override=incident", tt.body); rec.Code != http.StatusForbidden {
+				t.Errorf("anonymous override: got %d, want 403 (%s)", rec.Code, rec.Body)
+			}
+			if rec := do(mux, "ada", tt.method, tt.target+"?override=incident", tt.body); rec.Code == http.StatusForbidden {
+				t.Errorf("override with a reason refused: %s", rec.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+
+	"github.com/pachecoc/sqs-ui/internal/service"
+)
+
+// listMessages decodes the data of a GET /api/messages response.
+func listMessages(t *testing.T, rec *httptest.ResponseRecorder) []map[string]any {
+	t.Helper()
+	if rec.Code != http.StatusOK {
+		t.Fatalf("list: got %d (%s)", rec.Code, rec.Body)
+	}
+	var env struct {
+		Data []map[string]any
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &env); err != nil {
+		t.Fatalf("list: %v (%s)", err, rec.Body)
+	}
+	return env.Data
+}
+
+func wantQueueCounts(t *testing.T, h *APIHandler, want service.QueueCounts) {
+	t.Helper()
+	got, err := h.getService().Counts(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("queue counts %+v, want %+v", got, want)
+	}
+}
+
+func TestMessageLifecycle(t *testing.T) {
+	mux, h, _ := newTestAPI(t, nil)
+
+	for _, body := range []string{"alpha", "beta"} {
+		if rec := do(mux, "", http.MethodPost, "/api/send", `{"message":"`+body+`"}`); rec.Code != http.StatusOK {
+			t.Fatalf("send %s: got %d (%s)", body, rec.Code, rec.Body)
+		}
+	}
+	wantQueueCounts(t, h, service.QueueCounts{Visible: 2})
+
+	// Observing hands every message back
+	rec := do(mux, "", http.MethodGet, "/api/messages", "")
+	if mode := rec.Header().Get("X-Receive-Mode"); mode != string(service.ModeObserve) {
+		t.Errorf("X-Receive-Mode %q, want observe", mode)
+	}
+	observed := listMessages(t, rec)
+	if len(observed) != 2 {
+		t.Fatalf("observed %d messages, want 2", len(observed))
+	}
+	for _, m := range observed {
+		if m["message_id"] == "" || (m["body"] != "alpha" && m["body"] != "beta") || m["receipt_handle"] != nil {
+			t.Errorf("observed message %v", m)
+		}
+	}
+	wantQueueCounts(t, h, service.QueueCounts{Visible: 2})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/messages", nil)
+	req.Header.Set("Accept", "text/plain")
+	text := httptest.NewRecorder()
+	mux.ServeHTTP(text, req)
+	if lines := strings.Fields(text.Body.String()); len(lines) != 2 {
+		t.Errorf("plain-text listing %q, want the two bodies", text.Body)
+	}
+
+	// Consuming keeps them in flight until they are deleted
+	consumed := listMessages(t, do(mux, "", http.MethodGet, "/api/messages?mode=consume", ""))
+	if len(consumed) != 2 {
+		t.Fatalf("consumed %d messages, want 2", len(consumed))
+	}
+	wantQueueCounts(t, h, service.QueueCounts{NotVisible: 2})
+
+	handles := make([]string, 0, len(consumed))
+	for _, m := range consumed {
+		handle, _ := m["receipt_handle"].(string)
+		handles = append(handles, handle)
+	}
+	body, _ := json.Marshal(map[string]any{"receipt_handles": handles})
+	if rec := do(mux, "", http.MethodPost, "/api/messages/delete", string(body)); rec.Code != http.StatusOK {
+		t.Fatalf("delete: got %d (%s)", rec.Code, rec.Body)
+	}
+	wantQueueCounts(t, h, service.QueueCounts{})
+}
+
+func TestMessagesIncludeDLQ(t *testing.T) {
+	mux, h, mem := newTestAPI(t, nil)
+	ctx := context.Background()
+	dlq, err := mem.CreateQueue(ctx, &sqs.CreateQueueInput{QueueName: aws.String("orders-dlq")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	arn := "arn:aws:sqs:" + mem.Region + ":" + mem.Account + ":orders-dlq"
+	_, err = mem.SetQueueAttributes(ctx, &sqs.SetQueueAttributesInput{
+		QueueUrl:   aws.String(h.getService().QueueURL),
+		Attributes: map[string]string{"RedrivePolicy": service.RedrivePolicyJSON(arn, service.TailMinReceiveCount)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := mem.SendMessage(ctx, &sqs.SendMessageInput{QueueUrl: dlq.QueueUrl, MessageBody: aws.String("poison")}); err != nil {
+		t.Fatal(err)
+	}
+	if rec := do(mux, "", http.MethodPost, "/api/send", `{"message":"fresh"}`); rec.Code != http.StatusOK {
+		t.Fatalf("send: got %d (%s)", rec.Code, rec.Body)
+	}
+
+	msgs := listMessages(t, do(mux, "", http.MethodGet, "/api/messages?include_dlq=true", ""))
+	origins := map[any]map[string]any{}
+	for _, m := range msgs {
+		origins[m["body"]] = m
+	}
+	if m := origins["fresh"]; m == nil || m["origin"] != service.OriginQueue || m["queue_name"] != nil {
+		t.Errorf("queue message %v", m)
+	}
+	if m := origins["poison"]; m == nil || m["origin"] != service.OriginDLQ || m["queue_name"] != "orders-dlq" {
+		t.Errorf("dead-letter message %v", m)
+	}
+
+	if rec := do(mux, "", http.MethodGet, "/api/messages?include_dlq=true&mode=consume", ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("include_dlq in consume mode: got %d, want 400", rec.Code)
+	}
+}
@@ -0,0 +1,169 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Output formats supported by content negotiation.
+const (
+	formatJSON = "json"
+	formatYAML = "yaml"
+	formatText = "text"
+)
+
+// negotiateFormat picks the response format from the Accept header (highest q wins, JSON by default).
+func negotiateFormat(r *http.Request) string {
+	accept := r.Header.Get("Accept")
+	if accept == "" {
+		return formatJSON
+	}
+
+	best, bestQ := formatJSON, -1.0
+	for _, part := range strings.Split(accept, ",") {
+		fields := strings.Split(part, ";")
+		mediaType := strings.ToLower(strings.TrimSpace(fields[0]))
+		q := 1.0
+		for _, p := range fields[1:] {
+			if v, ok := strings.CutPrefix(strings.TrimSpace(p), "q="); ok {
+				if n, err := strconv.ParseFloat(v, 64); err == nil {
+					q = n
+				}
+			}
+		}
+
+		var format string
+		switch mediaType {
+		case "application/json", "*/*", "application/*":
+			format = formatJSON
+		case "application/yaml", "application/x-yaml", "text/yaml", "text/x-yaml":
+			format = formatYAML
+		case "text/plain":
+			format = formatText
+		default:
+			continue
+		}
+		if q > bestQ {
+			best, bestQ = format, q
+		}
+	}
+	return best
+}
+
+// respondNegotiated writes v as JSON, YAML or plain text depending on the Accept header.
+// text renders the plain-text variant; when nil, the YAML form is used for text/plain too.
+func respondNegotiated(w http.ResponseWriter, r *http.Request, status int, v any, text func() string) {
+	switch negotiateFormat(r) {
+	case formatYAML:
+		body, err := encodeYAML(v)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, err)
+			return
+		}
+		respondRaw(w, status, "application/yaml", body)
+	case formatText:
+		if text == nil {
+			body, err := encodeYAML(v)
+			if err != nil {
+				respondError(w, http.StatusInternalServerError, err)
+				return
+			}
+			respondRaw(w, status, "text/plain; charset=utf-8", body)
+			return
+		}
+		respondRaw(w, status, "text/plain; charset=utf-8", []byte(text()))
+	default:
+		respondJSON(w, status, v)
+	}
+}
+
+func respondRaw(w http.ResponseWriter, status int, contentType string, body []byte) {
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_, _ = w.Write(body)
+}
+
+// encodeYAML renders v as a YAML document. Values are normalized through JSON first,
+// so field names and omitempty rules match the JSON representation exactly.
+func encodeYAML(v any) ([]byte, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var generic any
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	if err := dec.Decode(&generic); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(yamlNode(generic)); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// yamlNode converts a decoded JSON value to a YAML node. Numbers keep their JSON text, so
+// large integers aren't rewritten in exponent form.
+func yamlNode(v any) *yaml.Node {
+	switch t := v.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		n := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		for _, k := range keys {
+			n.Content = append(n.Content, yamlNode(k), yamlNode(t[k]))
+		}
+		return n
+	case []any:
+		n := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		for _, item := range t {
+			n.Content = append(n.Content, yamlNode(item))
+		}
+		return n
+	case string:
+		n := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: t}
+		if strings.Contains(t, "\n") && !literalRoundTrips(t) {
+			n.Style = yaml.DoubleQuotedStyle
+		}
+		return n
+	case json.Number:
+		tag := "!!int"
+		if strings.ContainsAny(t.String(), ".eE") {
+			tag = "!!float"
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: t.String()}
+	case bool:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: strconv.FormatBool(t)}
+	default:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}
+	}
+}
+
+// literalRoundTrips reports whether yaml.v3 reads s back unchanged from the literal block it
+// writes multi-line strings as. Some (leading tabs, lone line breaks) don't survive; those
+// are double-quoted instead.
+func literalRoundTrips(s string) bool {
+	out, err := yaml.Marshal(&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: s, Style: yaml.LiteralStyle})
+	if err != nil {
+		return false
+	}
+	var back string
+	return yaml.Unmarshal(out, &back) == nil && back == s
+}
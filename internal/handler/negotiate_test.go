@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+func FuzzNegotiateFormat(f *testing.F) {
+	f.Add("")
+	f.Add("application/json")
+	f.Add("text/yaml;q=0.9, text/plain")
+	f.Add("application/x-yaml, application/json;q=0.5")
+	f.Add("*/*;q=0")
+	f.Add("application/yaml;q=abc")
+	f.Add("text/plain;q=NaN, application/yaml;q=Inf")
+	f.Add("text/html")
+	f.Add(";;,,;q=")
+	f.Fuzz(func(t *testing.T, accept string) {
+		req := httptest.NewRequest("GET", "/api/messages", nil)
+		req.Header.Set("Accept", accept)
+		switch got := negotiateFormat(req); got {
+		case formatJSON, formatYAML, formatText:
+		default:
+			t.Fatalf("Accept %q: negotiated unknown format %q", accept, got)
+		}
+	})
+}
+
+// TestYAMLMultiLineStrings parses encodeYAML output back and checks multi-line strings
+// survive: leading spaces, trailing line breaks and carriage returns.
+func TestYAMLMultiLineStrings(t *testing.T) {
+	tests := []struct {
+		value, header string
+	}{
+		{"first\nsecond", "|-"},
+		{"first\nsecond\n", "|\n"},
+		{"first\nsecond\n\n\n", "|+"},
+		{"  indented\nsecond", "|2-"},
+		{"\n  after a blank line\n", `"\n  after a blank line\n"`},
+		{"  \nblank-looking first line", `"  \nblank-looking first line"`},
+		{"first\n\n  indented\n\tTabbed", "|-"},
+		{"windows\r\nline endings", `"windows\r\nline endings"`},
+		{"\n\n", `"\n\n"`},
+		{"bell\a\nline", `"bell\a\nline"`},
+	}
+	for _, tt := range tests {
+		assertYAMLString(t, tt.value)
+		out, err := encodeYAML(map[string]any{"body": tt.value})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(string(out), "body: "+tt.header) {
+			t.Errorf("%q: encoded as\n%s\nwant it to start with %q", tt.value, out, "body: "+tt.header)
+		}
+	}
+}
+
+func FuzzYAMLString(f *testing.F) {
+	f.Add("first\nsecond\n")
+	f.Add("  indented\n\n\n")
+	f.Add("\n \n")
+	f.Add("a\r\nb")
+	f.Add("\t\n")
+	f.Add("key: value\n- item\n# comment")
+	f.Fuzz(assertYAMLString)
+}
+
+// assertYAMLString encodes s as a top-level value, in a nested mapping and in a sequence,
+// and checks yaml.v3 reads the same values back.
+func assertYAMLString(t *testing.T, s string) {
+	t.Helper()
+	if !utf8.ValidString(s) {
+		t.Skip("JSON replaces invalid UTF-8 before encoding")
+	}
+	want := map[string]any{
+		"top":    s,
+		"nested": map[string]any{"body": s},
+		"list":   []any{s, map[string]any{"body": s}},
+	}
+	out, err := encodeYAML(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]any
+	if err := yaml.Unmarshal(out, &got); err != nil {
+		t.Fatalf("%q: invalid YAML: %v\n%s", s, err, out)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("%q: read back %#v from\n%s", s, got, out)
+	}
+}
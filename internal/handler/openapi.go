@@ -0,0 +1,25 @@
+package handler
+
+import (
+	_ "embed"
+	"net/http"
+	"strings"
+)
+
+// openAPISpec documents every route RegisterRoutes serves; the contract tests fail when the
+// two drift apart.
+//
+//go:embed openapi.yaml
+var openAPISpec []byte
+
+// handleOpenAPI serves the OpenAPI document, with its server URL set to BASE_PATH.
+func (h *APIHandler) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	if !enforceMethod(w, r, http.MethodGet) {
+		return
+	}
+	body := openAPISpec
+	if base := basePathFrom(r.Context()); base != "" {
+		body = []byte(strings.Replace(string(body), "\nservers:\n  - url: /\n", "\nservers:\n  - url: "+base+"\n", 1))
+	}
+	respondRaw(w, http.StatusOK, "application/yaml", body)
+}
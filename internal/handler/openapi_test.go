@@ -0,0 +1,254 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/legacy"
+
+	"github.com/pachecoc/sqs-ui/internal/locks"
+	"github.com/pachecoc/sqs-ui/internal/store"
+)
+
+func discardLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+// loadSpec parses and validates openapi.yaml.
+func loadSpec(t *testing.T) *openapi3.T {
+	t.Helper()
+	doc, err := openapi3.NewLoader().LoadFromData(openAPISpec)
+	if err != nil {
+		t.Fatalf("parse openapi.yaml: %v", err)
+	}
+	if err := doc.Validate(context.Background()); err != nil {
+		t.Fatalf("openapi.yaml is not a valid OpenAPI document: %v", err)
+	}
+	return doc
+}
+
+// patternRecorder notes the patterns RegisterRoutes adds.
+type patternRecorder struct {
+	*http.ServeMux
+	patterns []string
+}
+
+func (p *patternRecorder) HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request)) {
+	p.patterns = append(p.patterns, pattern)
+	p.ServeMux.HandleFunc(pattern, handler)
+}
+
+func TestSpecDocumentsEveryRoute(t *testing.T) {
+	doc := loadSpec(t)
+	rec := &patternRecorder{ServeMux: http.NewServeMux()}
+	NewAPIHandler(nil, discardLogger()).RegisterRoutes(rec)
+
+	for _, pattern := range rec.patterns {
+		if doc.Paths.Value(pattern) == nil {
+			t.Errorf("route %s is served but not in openapi.yaml", pattern)
+		}
+	}
+	for _, path := range doc.Paths.InMatchingOrder() {
+		if !slices.Contains(rec.patterns, path) {
+			t.Errorf("openapi.yaml documents %s, which isn't served", path)
+		}
+	}
+}
+
+// contract serves requests through the API and fails the test when a request or response
+// doesn't match openapi.yaml.
+type contract struct {
+	t      *testing.T
+	mux    http.Handler
+	router routers.Router
+}
+
+func newContract(t *testing.T, mux http.Handler) *contract {
+	router, err := legacy.NewRouter(loadSpec(t))
+	if err != nil {
+		t.Fatalf("openapi router: %v", err)
+	}
+	return &contract{t: t, mux: mux, router: router}
+}
+
+// call checks one request against the spec, serves it as user ada, checks the response and
+// returns its decoded envelope. Requests meant to be refused (invalid) skip the request check.
+func (c *contract) call(method, target, body string, wantStatus int, invalid bool) map[string]any {
+	c.t.Helper()
+	newRequest := func() *http.Request {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		if body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("X-User", "ada")
+		return req
+	}
+
+	req := newRequest()
+	route, params, err := c.router.FindRoute(req)
+	if err != nil {
+		if !invalid {
+			c.t.Fatalf("%s %s: not in openapi.yaml: %v", method, target, err)
+		}
+		// An undocumented method: only the refusal can be checked
+		rec := httptest.NewRecorder()
+		c.mux.ServeHTTP(rec, newRequest())
+		if rec.Code != wantStatus {
+			c.t.Errorf("%s %s: got %d, want %d: %s", method, target, rec.Code, wantStatus, rec.Body)
+		}
+		return nil
+	}
+	opts := &openapi3filter.Options{IncludeResponseStatus: true, MultiError: true}
+	input := &openapi3filter.RequestValidationInput{Request: req, PathParams: params, Route: route, Options: opts}
+	if !invalid {
+		if err := openapi3filter.ValidateRequest(context.Background(), input); err != nil {
+			c.t.Errorf("%s %s: request doesn't match openapi.yaml: %v", method, target, err)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	c.mux.ServeHTTP(rec, newRequest())
+	if rec.Code != wantStatus {
+		c.t.Fatalf("%s %s: got %d, want %d: %s", method, target, rec.Code, wantStatus, rec.Body)
+	}
+	err = openapi3filter.ValidateResponse(context.Background(), &openapi3filter.ResponseValidationInput{
+		RequestValidationInput: input,
+		Status:                 rec.Code,
+		Header:                 rec.Header(),
+		Body:                   io.NopCloser(bytes.NewReader(rec.Body.Bytes())),
+		Options:                opts,
+	})
+	if err != nil {
+		c.t.Errorf("%s %s: response doesn't match openapi.yaml: %v\n%s", method, target, err, rec.Body)
+	}
+
+	var env map[string]any
+	if strings.HasPrefix(rec.Header().Get("Content-Type"), "application/json") {
+		if err := json.Unmarshal(rec.Body.Bytes(), &env); err != nil {
+			c.t.Fatalf("%s %s: decode response: %v", method, target, err)
+		}
+	}
+	return env
+}
+
+// dataField returns env.data[key] as a string.
+func dataField(env map[string]any, key string) string {
+	data, _ := env["data"].(map[string]any)
+	s, _ := data[key].(string)
+	return s
+}
+
+func TestContract(t *testing.T) {
+	mux, h, mem := newTestAPI(t, nil)
+	h.Locks = &locks.Manager{Store: store.NewMemory()}
+	ctx := context.Background()
+	if _, err := mem.CreateQueue(ctx, &sqs.CreateQueueInput{QueueName: aws.String("orders-dlq")}); err != nil {
+		t.Fatal(err)
+	}
+	c := newContract(t, mux)
+
+	t.Run("status", func(t *testing.T) {
+		c.t = t
+		c.call(http.MethodGet, "/healthz", "", http.StatusOK, false)
+		c.call(http.MethodGet, "/readyz", "", http.StatusOK, false)
+		c.call(http.MethodGet, "/api/version", "", http.StatusOK, false)
+		c.call(http.MethodGet, "/api/plugins", "", http.StatusOK, false)
+		c.call(http.MethodGet, "/api/openapi.yaml", "", http.StatusOK, false)
+		c.call(http.MethodGet, "/info", "", http.StatusOK, false)
+		c.call(http.MethodGet, "/info?queue=orders-dlq", "", http.StatusOK, false)
+	})
+
+	t.Run("messages", func(t *testing.T) {
+		c.t = t
+		c.call(http.MethodPost, "/api/send", `{"message":"hello","attributes":{"kind":{"type":"String","value":"greeting"}}}`, http.StatusOK, false)
+		c.call(http.MethodPost, "/api/send", `{"message":"again","delay_seconds":0}`, http.StatusOK, false)
+		c.call(http.MethodPost, "/api/send", `{}`, http.StatusBadRequest, true)
+		c.call(http.MethodDelete, "/api/send", "", http.StatusMethodNotAllowed, true)
+
+		c.call(http.MethodGet, "/api/messages", "", http.StatusOK, false)
+		page := c.call(http.MethodGet, "/api/messages?limit=1", "", http.StatusOK, false)
+		if next := dataField(page, "next_cursor"); next != "" {
+			c.call(http.MethodGet, "/api/messages?cursor="+next, "", http.StatusOK, false)
+		} else {
+			t.Error("first page of two messages has no next_cursor")
+		}
+		c.call(http.MethodGet, "/api/messages?cursor=gone.0", "", http.StatusGone, false)
+
+		consumed := c.call(http.MethodGet, "/api/messages?mode=consume", "", http.StatusOK, false)
+		msgs, _ := consumed["data"].([]any)
+		if len(msgs) == 0 {
+			t.Fatal("consume listing is empty")
+		}
+		handle, _ := msgs[0].(map[string]any)["receipt_handle"].(string)
+		c.call(http.MethodPost, "/api/messages/delete?dry_run=true", `{"receipt_handles":["`+handle+`"]}`, http.StatusOK, false)
+		c.call(http.MethodPost, "/api/messages/delete", `{"receipt_handles":["`+handle+`"]}`, http.StatusOK, false)
+		c.call(http.MethodPost, "/api/messages/delete", `{"receipt_handles":[]}`, http.StatusBadRequest, true)
+	})
+
+	t.Run("purge", func(t *testing.T) {
+		c.t = t
+		plan := c.call(http.MethodPost, "/api/purge", "", http.StatusOK, false)
+		confirm := dataField(plan, "confirm")
+		if confirm == "" {
+			t.Fatal("purge plan has no confirm token")
+		}
+		c.call(http.MethodPost, "/api/purge", `{"confirm":"`+confirm+`"}`, http.StatusOK, false)
+		c.call(http.MethodPost, "/api/purge", `{"confirm":"`+confirm+`"}`, http.StatusConflict, false)
+	})
+
+	t.Run("queue", func(t *testing.T) {
+		c.t = t
+		c.call(http.MethodGet, "/api/queues", "", http.StatusOK, false)
+		c.call(http.MethodGet, "/api/queues?prefix=orders&limit=1", "", http.StatusOK, false)
+		c.call(http.MethodGet, "/api/queues?limit=0", "", http.StatusBadRequest, true)
+		c.call(http.MethodGet, "/api/dlq/sources", "", http.StatusOK, false)
+		c.call(http.MethodGet, "/api/queue/attributes", "", http.StatusOK, false)
+		c.call(http.MethodPut, "/api/queue/attributes?dry_run=true", `{"attributes":{"VisibilityTimeout":60}}`, http.StatusOK, false)
+		c.call(http.MethodPut, "/api/queue/tags", `{"tags":{"team":"payments"}}`, http.StatusOK, false)
+		c.call(http.MethodGet, "/api/queue/tags", "", http.StatusOK, false)
+		c.call(http.MethodDelete, "/api/queue/tags", `{"keys":["team"]}`, http.StatusOK, false)
+		c.call(http.MethodGet, "/api/queue/redrive", "", http.StatusOK, false)
+		c.call(http.MethodPut, "/api/queue/redrive", `{"dead_letter_queue":"orders-dlq","max_receive_count":5}`, http.StatusOK, false)
+		c.call(http.MethodGet, "/api/queue/redrive", "", http.StatusOK, false)
+		c.call(http.MethodDelete, "/api/queue/redrive?dry_run=true", "", http.StatusOK, false)
+		c.call(http.MethodGet, "/api/queue/attributes?queue=missing", "", http.StatusNotFound, false)
+	})
+
+	t.Run("jobs", func(t *testing.T) {
+		c.t = t
+		c.call(http.MethodGet, "/api/jobs", "", http.StatusOK, false)
+		job := c.call(http.MethodPost, "/api/jobs", `{"type":"export"}`, http.StatusAccepted, false)
+		id := dataField(job, "id")
+		c.call(http.MethodGet, "/api/jobs/"+id, "", http.StatusOK, false)
+		c.call(http.MethodGet, "/api/jobs/missing", "", http.StatusNotFound, false)
+		c.call(http.MethodPost, "/api/jobs", `{"type":"nope"}`, http.StatusBadRequest, false)
+	})
+
+	t.Run("locks", func(t *testing.T) {
+		c.t = t
+		c.call(http.MethodPut, "/api/locks/orders", `{"reason":"incident"}`, http.StatusOK, false)
+		c.call(http.MethodGet, "/api/locks/orders", "", http.StatusOK, false)
+		c.call(http.MethodGet, "/api/locks", "", http.StatusOK, false)
+		c.call(http.MethodDelete, "/api/locks/orders", "", http.StatusOK, false)
+	})
+
+	t.Run("config", func(t *testing.T) {
+		c.t = t
+		c.call(http.MethodPost, "/api/config/queue", `{"queue_name":"orders-dlq","receive_mode":"observe"}`, http.StatusOK, false)
+		c.call(http.MethodPost, "/api/config/queue", `{}`, http.StatusBadRequest, true)
+	})
+}
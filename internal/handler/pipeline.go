@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/pachecoc/sqs-ui/internal/pipeline"
+	"github.com/pachecoc/sqs-ui/internal/service"
+	"github.com/pachecoc/sqs-ui/internal/validate"
+)
+
+// defaultPreviewSample is how many queue messages a pipeline preview uses by default.
+const defaultPreviewSample = 5
+
+// pipelineRequest is the body of POST /api/pipeline/preview. When Messages is empty, Sample
+// messages are read from the active queue in observe mode.
+type pipelineRequest struct {
+	Pipeline pipeline.Spec      `json:"pipeline"`
+	Messages []pipeline.Message `json:"messages"`
+	Sample   int                `json:"sample"`
+}
+
+// handlePipelinePreview shows each message before and after the pipeline without sending anything.
+func (h *APIHandler) handlePipelinePreview(w http.ResponseWriter, r *http.Request) {
+	if !enforceMethod(w, r, http.MethodPost) {
+		return
+	}
+
+	var req pipelineRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, err)
+		return
+	}
+	var v validate.Validator
+	v.Range("sample", req.Sample, 0, maxPageSize)
+	if err := v.Err(); err != nil {
+		respondError(w, http.StatusBadRequest, err)
+		return
+	}
+	p, err := pipeline.Compile(r.Context(), req.Pipeline, h.Scripts)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	samples := req.Messages
+	if len(samples) == 0 {
+		svc := h.queueService(r.Context())
+		if svc == nil {
+			respondError(w, http.StatusServiceUnavailable, errors.New("service unavailable"))
+			return
+		}
+		if err := svc.EnsureQueueConfigured(); err != nil {
+			respondError(w, http.StatusBadRequest, err)
+			return
+		}
+		n := req.Sample
+		if n <= 0 {
+			n = defaultPreviewSample
+		}
+		mode, err := h.receiveMode(r.Context(), svc, service.ModeObserve)
+		if err != nil {
+			respondError(w, serviceErrorStatus(err), err)
+			return
+		}
+		msgs, err := svc.Receive(r.Context(), mode)
+		if err != nil && !errors.Is(err, service.ErrPartial) {
+			respondError(w, serviceErrorStatus(err), err)
+			return
+		}
+		if err != nil {
+			// Enough samples may have arrived; preview them past the spent budget
+			r = r.WithContext(context.WithoutCancel(r.Context()))
+		}
+		for _, m := range msgs[:min(n, len(msgs))] {
+			samples = append(samples, pipeline.Message{MessageID: fmt.Sprint(m["message_id"]), Body: fmt.Sprint(m["body"])})
+		}
+	}
+
+	respondJSON(w, http.StatusOK, map[string]any{"previews": p.PreviewAll(r.Context(), samples)})
+}
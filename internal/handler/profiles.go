@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/pachecoc/sqs-ui/internal/profiles"
+)
+
+// handleProfiles lists queue profiles (GET) or saves one (POST, see profiles.Profile).
+func (h *APIHandler) handleProfiles(w http.ResponseWriter, r *http.Request) {
+	if h.Profiles == nil {
+		respondError(w, http.StatusServiceUnavailable, errors.New("profiles are not enabled"))
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		list, err := h.Profiles.List(r.Context())
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, err)
+			return
+		}
+		respondJSON(w, http.StatusOK, map[string]any{"profiles": list})
+	case http.MethodPost:
+		var p profiles.Profile
+		if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+			respondError(w, http.StatusBadRequest, err)
+			return
+		}
+		saved, err := h.Profiles.Save(r.Context(), p)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, err)
+			return
+		}
+		h.Log.InfoContext(r.Context(), "queue profile saved", "queue", saved.Queue, "read_only", saved.ReadOnly)
+		h.reapplyProfile()
+		respondJSON(w, http.StatusOK, saved)
+	case http.MethodOptions:
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		respondError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+	}
+}
+
+// handleProfile reads (GET) or deletes (DELETE) the stored profile for a queue name or pattern.
+func (h *APIHandler) handleProfile(w http.ResponseWriter, r *http.Request) {
+	if h.Profiles == nil {
+		respondError(w, http.StatusServiceUnavailable, errors.New("profiles are not enabled"))
+		return
+	}
+	key := r.PathValue("queue")
+
+	switch r.Method {
+	case http.MethodGet:
+		p, err := h.Profiles.Get(r.Context(), key)
+		if errors.Is(err, profiles.ErrNotFound) {
+			respondError(w, http.StatusNotFound, err)
+			return
+		}
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, err)
+			return
+		}
+		respondJSON(w, http.StatusOK, p)
+	case http.MethodDelete:
+		if err := h.Profiles.Delete(r.Context(), key); err != nil {
+			respondError(w, http.StatusInternalServerError, err)
+			return
+		}
+		h.reapplyProfile()
+		w.WriteHeader(http.StatusNoContent)
+	case http.MethodOptions:
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.Header().Set("Allow", "GET, DELETE")
+		respondError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+	}
+}
+
+// reapplyProfile refreshes the active queue's settings after a profile change. The service is
+// copied and swapped so in-flight requests keep a consistent view.
+func (h *APIHandler) reapplyProfile() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.SQS == nil {
+		return
+	}
+	svc := *h.SQS
+	h.Profiles.Configure(&svc)
+	h.SQS = &svc
+}
+
+// profileFor returns the profile of queue, caching lookups in seen for the length of one listing.
+func (h *APIHandler) profileFor(ctx context.Context, queue string, seen map[string]profiles.Profile) profiles.Profile {
+	if p, ok := seen[queue]; ok {
+		return p
+	}
+	p, _ := h.Profiles.Lookup(ctx, queue)
+	seen[queue] = p
+	return p
+}
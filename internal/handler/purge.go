@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"cmp"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/pachecoc/sqs-ui/internal/service"
+	"github.com/pachecoc/sqs-ui/internal/store"
+)
+
+const (
+	// categoryPurgeConfirms holds pending purge confirmations in the store.
+	categoryPurgeConfirms = "purge_confirms"
+
+	// defaultPurgeConfirmTTL is how long a purge confirmation can be used when
+	// PurgeConfirmTTL is unset.
+	defaultPurgeConfirmTTL = 2 * time.Minute
+)
+
+// purgeConfirm is what a purge confirmation token stands for: purging this queue for this
+// user while it holds about Count messages.
+type purgeConfirm struct {
+	QueueURL  string    `json:"queue_url"`
+	QueueName string    `json:"queue_name"`
+	Count     int64     `json:"count"`
+	User      string    `json:"user,omitempty"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// purgePlan is the first phase of a purge: what it would delete and the token that runs it.
+type purgePlan struct {
+	service.Plan
+	Confirm          string    `json:"confirm"`
+	ConfirmExpiresAt time.Time `json:"confirm_expires_at"`
+}
+
+// purgeGrowthAllowed is how many more messages than the confirmed count a queue may hold
+// when the purge runs: a busy queue moves between the two calls, but a confirmation for
+// 100 messages shouldn't purge 10,000.
+func purgeGrowthAllowed(confirmed int64) int64 {
+	return max(10, confirmed/10)
+}
+
+// issuePurgeConfirm stores a confirmation for plan and returns it with its token.
+func (h *APIHandler) issuePurgeConfirm(r *http.Request, plan service.Plan) (purgePlan, error) {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	ttl := cmp.Or(h.PurgeConfirmTTL, defaultPurgeConfirmTTL)
+	c := purgeConfirm{
+		QueueURL:  plan.QueueURL,
+		QueueName: plan.QueueName,
+		Count:     plan.Count,
+		User:      h.requestUser(r),
+		ExpiresAt: time.Now().Add(ttl).UTC().Truncate(time.Second),
+	}
+	token := hex.EncodeToString(b)
+	if err := store.PutJSON(r.Context(), h.snapshotStore(), categoryPurgeConfirms, token, c, ttl); err != nil {
+		return purgePlan{}, fmt.Errorf("could not save the purge confirmation: %w", err)
+	}
+	return purgePlan{Plan: plan, Confirm: token, ConfirmExpiresAt: c.ExpiresAt}, nil
+}
+
+// redeemPurgeConfirm checks token against the queue about to be purged and uses it up. The
+// returned status goes with the error.
+func (h *APIHandler) redeemPurgeConfirm(ctx context.Context, r *http.Request, svc *service.SQSService, token string) (int, error) {
+	var c purgeConfirm
+	st := h.snapshotStore()
+	if err := store.GetJSON(ctx, st, categoryPurgeConfirms, token, &c); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return http.StatusConflict, errors.New("the purge confirmation expired or was already used; start the purge again")
+		}
+		return http.StatusInternalServerError, err
+	}
+	// Single use, whatever happens next
+	if err := st.Delete(ctx, categoryPurgeConfirms, token); err != nil && !errors.Is(err, store.ErrNotFound) {
+		return http.StatusInternalServerError, err
+	}
+	if time.Now().After(c.ExpiresAt) {
+		return http.StatusConflict, errors.New("the purge confirmation expired; start the purge again")
+	}
+	if c.QueueURL != svc.QueueURL {
+		return http.StatusConflict, fmt.Errorf("the purge confirmation is for %s, not %s", c.QueueName, svc.QueueName)
+	}
+	if c.User != "" && c.User != h.requestUser(r) {
+		return http.StatusForbidden, fmt.Errorf("the purge confirmation was issued to %s", c.User)
+	}
+	counts, err := svc.Counts(ctx)
+	if err != nil {
+		return serviceErrorStatus(err), err
+	}
+	if now := counts.Visible + counts.NotVisible; now > c.Count+purgeGrowthAllowed(c.Count) {
+		return http.StatusConflict, fmt.Errorf("%s grew from %d to %d messages since the purge was confirmed; start the purge again", c.QueueName, c.Count, now)
+	}
+	return http.StatusOK, nil
+}
@@ -0,0 +1,54 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+)
+
+// chunked is do for a body without a Content-Length, the way chunked uploads arrive.
+func chunked(mux http.Handler, user, method, target, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.ContentLength = -1
+	req.Header.Set("Content-Type", "application/json")
+	if user != "" {
+		req.Header.Set("X-User", user)
+	}
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestPurgeChunkedBody(t *testing.T) {
+	mux, _, mem := newTestAPI(t, nil)
+	// Purges start a per-queue cooldown for the whole process; use a queue no other test purges
+	if _, err := mem.CreateQueue(context.Background(), &sqs.CreateQueueInput{QueueName: aws.String("purge-chunked")}); err != nil {
+		t.Fatal(err)
+	}
+	if rec := do(mux, "", http.MethodPost, "/api/config/queue", `{"queue_name":"purge-chunked"}`); rec.Code != http.StatusOK {
+		t.Fatalf("select queue: got %d (%s)", rec.Code, rec.Body)
+	}
+
+	// An empty chunked body asks for the plan, like no body at all
+	rec := chunked(mux, "", http.MethodPost, "/api/purge", "")
+	var plan struct {
+		Data purgePlan `json:"data"`
+	}
+	if rec.Code != http.StatusOK || json.Unmarshal(rec.Body.Bytes(), &plan) != nil || plan.Data.Confirm == "" {
+		t.Fatalf("plan: got %d (%s)", rec.Code, rec.Body)
+	}
+
+	if rec := chunked(mux, "", http.MethodPost, "/api/purge", `{"confirm":`); rec.Code != http.StatusBadRequest {
+		t.Errorf("truncated body: got %d, want 400 (%s)", rec.Code, rec.Body)
+	}
+	rec = chunked(mux, "", http.MethodPost, "/api/purge", `{"confirm":"`+plan.Data.Confirm+`"}`)
+	if rec.Code != http.StatusOK || strings.Contains(rec.Body.String(), `"confirm"`) {
+		t.Errorf("chunked confirm: got %d, want the purge to run (%s)", rec.Code, rec.Body)
+	}
+}
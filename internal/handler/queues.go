@@ -0,0 +1,394 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/pachecoc/sqs-ui/internal/events"
+	"github.com/pachecoc/sqs-ui/internal/provision"
+	"github.com/pachecoc/sqs-ui/internal/scratch"
+	"github.com/pachecoc/sqs-ui/internal/service"
+	"github.com/pachecoc/sqs-ui/internal/validate"
+)
+
+const (
+	defaultQueueListSize = 100
+	maxQueueListSize     = 1000 // ListQueues MaxResults limit
+)
+
+var queuePrefixPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{0,80}$`)
+
+// queuePage is one page of /api/queues or /api/dlq/sources.
+type queuePage struct {
+	service.QueueList
+	Prefix string `json:"prefix,omitempty"`
+}
+
+func (p queuePage) pagination() *pagination {
+	return &pagination{Count: len(p.Queues), NextCursor: p.NextToken}
+}
+
+// handleQueues lists the account's queues for a queue picker:
+// ?prefix=orders- filters by name prefix, ?limit=N (1-1000, default 100) sizes the page and
+// ?cursor=<next_token> continues from the previous page. POST creates a queue.
+func (h *APIHandler) handleQueues(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodPost {
+		h.handleCreateQueue(w, r)
+		return
+	}
+	if !enforceMethod(w, r, http.MethodGet) {
+		return
+	}
+	q := r.URL.Query()
+	prefix := q.Get("prefix")
+
+	var v validate.Validator
+	v.Check(queuePrefixPattern.MatchString(prefix), "prefix", "must be up to 80 letters, digits, '_' or '-'")
+	limit := queueListLimit(&v, q.Get("limit"))
+	if err := v.Err(); err != nil {
+		respondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	svc := h.getService()
+	if svc == nil {
+		respondError(w, http.StatusServiceUnavailable, errors.New("service unavailable"))
+		return
+	}
+	list, err := svc.ListQueues(r.Context(), prefix, int32(limit), q.Get("cursor"))
+	if err != nil {
+		h.Log.ErrorContext(r.Context(), "failed to list queues", "prefix", prefix, "error", err)
+		respondError(w, serviceErrorStatus(err), err)
+		return
+	}
+	respondJSON(w, http.StatusOK, queuePage{QueueList: list, Prefix: prefix})
+}
+
+// handleCreateQueue creates a queue, JSON { "name": "...", "attributes": {...},
+// "dead_letter_queue": true, "max_receive_count": 5 }, after pre-flight checks (naming
+// rules, existing queues, sqs:CreateQueue). ?dry_run=true only returns the pre-flight
+// report; a failed check answers 422 with the report and creates nothing.
+func (h *APIHandler) handleCreateQueue(w http.ResponseWriter, r *http.Request) {
+	if h.Provisioner == nil {
+		respondError(w, http.StatusServiceUnavailable, errors.New("queue creation is not enabled"))
+		return
+	}
+	if ct := r.Header.Get("Content-Type"); ct != "" && ct != "application/json" {
+		respondError(w, http.StatusUnsupportedMediaType, errors.New("Content-Type must be application/json"))
+		return
+	}
+	var req provision.Request
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		respondError(w, http.StatusBadRequest, err)
+		return
+	}
+	svc := h.getService()
+	if svc == nil {
+		respondError(w, http.StatusServiceUnavailable, errors.New("service unavailable"))
+		return
+	}
+
+	if dryRun(r) {
+		rep := h.Provisioner.Preflight(r.Context(), svc, req)
+		respondJSON(w, http.StatusOK, rep)
+		return
+	}
+
+	user := h.requestUser(r)
+	rep, err := h.Provisioner.Create(r.Context(), svc, req)
+	switch {
+	case err != nil:
+		h.Log.ErrorContext(r.Context(), "failed to create queue", "queue_name", req.Name, "user", user, "rolled_back", rep.RolledBack, "error", err)
+		respondError(w, serviceErrorStatus(err), err)
+	case !rep.OK:
+		h.Log.InfoContext(r.Context(), "queue creation refused by pre-flight checks", "queue_name", req.Name, "user", user)
+		respondJSON(w, http.StatusUnprocessableEntity, rep)
+	default:
+		h.Log.InfoContext(r.Context(), "queue created", "queue_name", req.Name, "queue_url", rep.QueueURL, "dead_letter_queue", rep.DeadLetterQueue, "user", user)
+		h.Events.Publish(events.Event{
+			Type:    events.TypeQueueCreated,
+			Message: "queue " + req.Name + " created",
+			Data:    map[string]any{"queue_name": req.Name, "queue_url": rep.QueueURL, "dead_letter_queue_url": rep.DeadLetterQueueURL, "user": user},
+		})
+		respondJSON(w, http.StatusCreated, rep)
+	}
+}
+
+// handleScratchQueue creates a temporary queue, JSON { "ttl_minutes": 30, "fifo": false }
+// (both optional), that the reaper deletes once its TTL has passed.
+func (h *APIHandler) handleScratchQueue(w http.ResponseWriter, r *http.Request) {
+	if !enforceMethod(w, r, http.MethodPost) {
+		return
+	}
+	if h.Scratch == nil {
+		respondError(w, http.StatusServiceUnavailable, errors.New("scratch queues are not enabled"))
+		return
+	}
+	var req scratch.Request
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+	if err := h.Scratch.Validate(req); err != nil {
+		respondError(w, http.StatusBadRequest, err)
+		return
+	}
+	svc := h.getService()
+	if svc == nil {
+		respondError(w, http.StatusServiceUnavailable, errors.New("service unavailable"))
+		return
+	}
+
+	user := h.requestUser(r)
+	q, err := h.Scratch.Create(r.Context(), svc, req, user)
+	if err != nil {
+		h.Log.ErrorContext(r.Context(), "failed to create scratch queue", "user", user, "error", err)
+		respondError(w, serviceErrorStatus(err), err)
+		return
+	}
+	h.Log.InfoContext(r.Context(), "scratch queue created", "queue_name", q.Name, "user", user, "expires_at", q.ExpiresAt)
+	h.Events.Publish(events.Event{
+		Type:    events.TypeQueueCreated,
+		Message: fmt.Sprintf("scratch queue %s created, expires at %s", q.Name, q.ExpiresAt.Format(time.RFC3339)),
+		Data:    map[string]any{"queue_name": q.Name, "queue_url": q.URL, "expires_at": q.ExpiresAt, "user": user, "scratch": true},
+	})
+	respondJSON(w, http.StatusCreated, q)
+}
+
+// handleDLQSources lists the queues whose redrive policy targets the queue, i.e. whose failed
+// messages end up in it. ?limit= and ?cursor= page as on /api/queues.
+func (h *APIHandler) handleDLQSources(w http.ResponseWriter, r *http.Request) {
+	if !enforceMethod(w, r, http.MethodGet) {
+		return
+	}
+	q := r.URL.Query()
+	var v validate.Validator
+	limit := queueListLimit(&v, q.Get("limit"))
+	if err := v.Err(); err != nil {
+		respondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	svc := h.queueService(r.Context())
+	list, err := svc.DeadLetterSources(r.Context(), int32(limit), q.Get("cursor"))
+	if err != nil {
+		h.Log.ErrorContext(r.Context(), "failed to list dead-letter source queues", "queue_name", svc.QueueName, "error", err)
+		respondError(w, serviceErrorStatus(err), err)
+		return
+	}
+	respondJSON(w, http.StatusOK, queuePage{QueueList: list})
+}
+
+// handleQueueAttributes returns every attribute of the queue (configuration as well as
+// counts); ?refresh=true reads them from SQS instead of the attribute cache. PUT changes
+// mutable settings, JSON { "attributes": { "VisibilityTimeout": 60, ... } }; with
+// ?dry_run=true it only reports what would change.
+func (h *APIHandler) handleQueueAttributes(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodPut {
+		h.handleSetQueueAttributes(w, r)
+		return
+	}
+	if !enforceMethod(w, r, http.MethodGet) {
+		return
+	}
+	svc := h.queueService(r.Context())
+	attrs, err := svc.Attributes(r.Context())
+	if err != nil {
+		h.Log.ErrorContext(r.Context(), "failed to get queue attributes", "queue_name", svc.QueueName, "error", err)
+		respondError(w, serviceErrorStatus(err), err)
+		return
+	}
+	respondJSON(w, http.StatusOK, attrs)
+}
+
+func (h *APIHandler) handleSetQueueAttributes(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Attributes map[string]json.Number `json:"attributes"`
+	}
+	dec := json.NewDecoder(r.Body)
+	dec.UseNumber()
+	if err := dec.Decode(&body); err != nil {
+		respondError(w, http.StatusBadRequest, err)
+		return
+	}
+	attrs := make(map[string]string, len(body.Attributes))
+	for name, n := range body.Attributes {
+		attrs[name] = n.String()
+	}
+
+	svc := h.queueService(r.Context())
+	dry := dryRun(r)
+	if !dry && !h.checkMaintenance(w, r, "set_attributes", svc.QueueName) {
+		return
+	}
+	changes, err := svc.SetAttributes(r.Context(), attrs, dry)
+	if err != nil {
+		h.Log.ErrorContext(r.Context(), "failed to set queue attributes", "queue_name", svc.QueueName, "error", err)
+		respondError(w, serviceErrorStatus(err), err)
+		return
+	}
+	if !dry && len(changes) > 0 {
+		user := h.requestUser(r)
+		h.Log.InfoContext(r.Context(), "queue attributes updated", "queue_name", svc.QueueName, "user", user, "changes", changes)
+		h.Events.Publish(events.Event{
+			Type:    events.TypeQueueAttributesChanged,
+			Message: fmt.Sprintf("%d attribute(s) of %s changed", len(changes), svc.QueueName),
+			Data:    map[string]any{"queue_name": svc.QueueName, "changes": changes, "user": user},
+		})
+	}
+	respondJSON(w, http.StatusOK, map[string]any{"queue_name": svc.QueueName, "dry_run": dry, "changes": changes})
+}
+
+// handleQueueTags reads (GET), adds or overwrites (PUT, JSON { "tags": { "team": "payments" } })
+// or removes (DELETE, JSON { "keys": ["team"] }) the queue's tags.
+func (h *APIHandler) handleQueueTags(w http.ResponseWriter, r *http.Request) {
+	svc := h.queueService(r.Context())
+
+	switch r.Method {
+	case http.MethodGet:
+		tags, err := svc.Tags(r.Context())
+		if err != nil {
+			h.Log.ErrorContext(r.Context(), "failed to list queue tags", "queue_name", svc.QueueName, "error", err)
+			respondError(w, serviceErrorStatus(err), err)
+			return
+		}
+		respondJSON(w, http.StatusOK, map[string]any{"queue_name": svc.QueueName, "tags": tags})
+		return
+	case http.MethodPut, http.MethodDelete:
+	case http.MethodOptions:
+		w.WriteHeader(http.StatusNoContent)
+		return
+	default:
+		w.Header().Set("Allow", "GET, PUT, DELETE")
+		respondError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+
+	var body struct {
+		Tags map[string]string `json:"tags"`
+		Keys []string          `json:"keys"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		respondError(w, http.StatusBadRequest, err)
+		return
+	}
+	action := "tag"
+	if r.Method == http.MethodDelete {
+		action = "untag"
+	}
+	if !h.checkMaintenance(w, r, action, svc.QueueName) {
+		return
+	}
+	var err error
+	if r.Method == http.MethodPut {
+		err = svc.TagQueue(r.Context(), body.Tags)
+	} else {
+		err = svc.UntagQueue(r.Context(), body.Keys)
+	}
+	if err != nil {
+		h.Log.ErrorContext(r.Context(), "failed to change queue tags", "queue_name", svc.QueueName, "error", err)
+		respondError(w, serviceErrorStatus(err), err)
+		return
+	}
+	h.Log.InfoContext(r.Context(), "queue tags changed", "queue_name", svc.QueueName, "user", h.requestUser(r), "set", body.Tags, "removed", body.Keys)
+
+	tags, err := svc.Tags(r.Context())
+	if err != nil {
+		respondError(w, serviceErrorStatus(err), err)
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]any{"queue_name": svc.QueueName, "tags": tags})
+}
+
+// handleQueueRedrive reads (GET), sets (PUT, JSON { "dead_letter_queue": "orders-dlq",
+// "max_receive_count": 5 }) or removes (DELETE) the queue's redrive policy. The DLQ may be a
+// queue name or ARN; with ?dry_run=true PUT and DELETE only report the change.
+func (h *APIHandler) handleQueueRedrive(w http.ResponseWriter, r *http.Request) {
+	svc := h.queueService(r.Context())
+
+	switch r.Method {
+	case http.MethodGet:
+		policy, err := svc.RedrivePolicy(r.Context())
+		if err != nil {
+			h.Log.ErrorContext(r.Context(), "failed to read redrive policy", "queue_name", svc.QueueName, "error", err)
+			respondError(w, serviceErrorStatus(err), err)
+			return
+		}
+		respondJSON(w, http.StatusOK, map[string]any{"queue_name": svc.QueueName, "redrive_policy": policy})
+		return
+	case http.MethodPut, http.MethodDelete:
+	case http.MethodOptions:
+		w.WriteHeader(http.StatusNoContent)
+		return
+	default:
+		w.Header().Set("Allow", "GET, PUT, DELETE")
+		respondError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+
+	dry := dryRun(r)
+	action := "set_redrive_policy"
+	if r.Method == http.MethodDelete {
+		action = "remove_redrive_policy"
+	}
+	if !dry && !h.checkMaintenance(w, r, action, svc.QueueName) {
+		return
+	}
+	var change service.RedriveChange
+	var err error
+	if r.Method == http.MethodPut {
+		var body struct {
+			DeadLetterQueue string `json:"dead_letter_queue"`
+			MaxReceiveCount int    `json:"max_receive_count"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			respondError(w, http.StatusBadRequest, err)
+			return
+		}
+		change, err = svc.SetRedrivePolicy(r.Context(), body.DeadLetterQueue, body.MaxReceiveCount, dry)
+	} else {
+		change, err = svc.RemoveRedrivePolicy(r.Context(), dry)
+	}
+	if err != nil {
+		h.Log.ErrorContext(r.Context(), "failed to change redrive policy", "queue_name", svc.QueueName, "error", err)
+		respondError(w, serviceErrorStatus(err), err)
+		return
+	}
+	if !dry {
+		user := h.requestUser(r)
+		h.Log.InfoContext(r.Context(), "redrive policy changed", "queue_name", svc.QueueName, "user", user, "from", change.From, "to", change.To)
+		message := fmt.Sprintf("redrive policy of %s removed", svc.QueueName)
+		if change.To != nil {
+			message = fmt.Sprintf("%s now redrives to %s after %d receives", svc.QueueName, change.To.DeadLetterQueue, change.To.MaxReceiveCount)
+		}
+		h.Events.Publish(events.Event{
+			Type:    events.TypeQueueAttributesChanged,
+			Message: message,
+			Data:    map[string]any{"queue_name": svc.QueueName, "redrive_policy": change, "user": user},
+		})
+	}
+	respondJSON(w, http.StatusOK, map[string]any{"queue_name": svc.QueueName, "dry_run": dry, "from": change.From, "to": change.To})
+}
+
+// queueListLimit parses ?limit= for queue listings (1-1000, default 100).
+func queueListLimit(v *validate.Validator, raw string) int {
+	if raw == "" {
+		return defaultQueueListSize
+	}
+	n, err := strconv.Atoi(raw)
+	if v.Check(err == nil, "limit", "must be an integer") {
+		v.Range("limit", n, 1, maxQueueListSize)
+	}
+	return n
+}
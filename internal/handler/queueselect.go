@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"regexp"
+	"strconv"
+
+	"github.com/pachecoc/sqs-ui/internal/service"
+	"github.com/pachecoc/sqs-ui/internal/validate"
+)
+
+// queueCacheSize bounds how many ?queue= services are kept resolved at once.
+const queueCacheSize = 64
+
+// queueNamePattern matches an SQS queue name (optionally with the .fifo suffix).
+var queueNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,80}(\.fifo)?$`)
+
+type queueServiceKey struct{}
+
+// cachedQueue is a resolved service for a ?queue= name, derived from the default service base.
+// A different default (queue switch, profile change) makes the entry stale.
+type cachedQueue struct {
+	base *service.SQSService
+	svc  *service.SQSService
+}
+
+// withQueue resolves an optional ?queue=<name> to a service sharing the default's AWS client,
+// so requests can target another queue without switching it for everyone. Without the
+// parameter the configured default is used. ?refresh=true reads queue attributes from SQS
+// instead of the attribute cache.
+func (h *APIHandler) withQueue(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if refresh, _ := strconv.ParseBool(r.URL.Query().Get("refresh")); refresh {
+			r = r.WithContext(service.WithRefresh(r.Context()))
+		}
+		name := r.URL.Query().Get("queue")
+		if name == "" {
+			next(w, r)
+			return
+		}
+		var v validate.Validator
+		v.Check(queueNamePattern.MatchString(name), "queue", "must be a queue name (letters, digits, - and _, optionally ending in .fifo)")
+		if err := v.Err(); err != nil {
+			respondError(w, http.StatusBadRequest, err)
+			return
+		}
+		svc, err := h.queueFor(r.Context(), name)
+		if err != nil {
+			respondError(w, serviceErrorStatus(err), err)
+			return
+		}
+		next(w, r.WithContext(context.WithValue(r.Context(), queueServiceKey{}, svc)))
+	}
+}
+
+// requireQueue resolves ?queue= and ensures a queue name or URL is configured before executing the handler.
+func (h *APIHandler) requireQueue(next http.HandlerFunc) http.HandlerFunc {
+	return h.withQueue(func(w http.ResponseWriter, r *http.Request) {
+		svc := h.queueService(r.Context())
+		if svc == nil {
+			respondError(w, http.StatusServiceUnavailable, errors.New("service unavailable"))
+			return
+		}
+		if err := svc.EnsureQueueConfigured(); err != nil {
+			respondError(w, http.StatusBadRequest, err)
+			return
+		}
+		next(w, r)
+	})
+}
+
+// queueService returns the service the request targets: the ?queue= one resolved by
+// withQueue, else the configured default (nil when none is configured).
+func (h *APIHandler) queueService(ctx context.Context) *service.SQSService {
+	if svc, ok := ctx.Value(queueServiceKey{}).(*service.SQSService); ok {
+		return svc
+	}
+	return h.resolvedService(ctx)
+}
+
+// resolvedService returns the default service, first swapping in a copy with the queue URL
+// resolved when it was configured by name and couldn't be resolved yet. The installed
+// service is shared by concurrent requests, so it is replaced rather than written.
+func (h *APIHandler) resolvedService(ctx context.Context) *service.SQSService {
+	svc := h.getService()
+	if svc == nil || svc.QueueURL != "" || svc.QueueName == "" || svc.Client == nil {
+		return svc
+	}
+	resolved, err := svc.Resolve(ctx)
+	if err != nil {
+		return svc
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.SQS != svc {
+		// Switched or resolved by another request meanwhile
+		return h.SQS
+	}
+	h.SQS = resolved
+	return resolved
+}
+
+// queueFor returns the cached service for name, resolving its URL on first use.
+func (h *APIHandler) queueFor(ctx context.Context, name string) (*service.SQSService, error) {
+	base := h.getService()
+	if base == nil {
+		return nil, errors.New("service unavailable")
+	}
+	if name == base.QueueName {
+		return base, nil
+	}
+
+	h.queuesMu.Lock()
+	entry, ok := h.queues[name]
+	h.queuesMu.Unlock()
+	if ok && entry.base == base {
+		return entry.svc, nil
+	}
+
+	svc, err := base.ForQueue(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	h.queuesMu.Lock()
+	defer h.queuesMu.Unlock()
+	if h.queues == nil || len(h.queues) >= queueCacheSize {
+		h.queues = make(map[string]cachedQueue)
+	}
+	h.queues[name] = cachedQueue{base: base, svc: svc}
+	h.Log.DebugContext(ctx, "queue resolved for request", "queue_name", name, "queue_url", svc.QueueURL)
+	return svc, nil
+}
@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/pachecoc/sqs-ui/internal/service"
+)
+
+func TestDefaultQueueResolvedByName(t *testing.T) {
+	mux, h, mem := newTestAPI(t, nil)
+	named := service.NewSQSService(context.Background(), mem, testQueue, "", mem.Region, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	h.mu.Lock()
+	h.SQS = named
+	h.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if rec := do(mux, "", http.MethodGet, "/info", ""); rec.Code != http.StatusOK {
+				t.Errorf("info: %d %s", rec.Code, rec.Body)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if named.QueueURL != "" {
+		t.Errorf("the installed service was written: QueueURL %s", named.QueueURL)
+	}
+	svc := h.getService()
+	if svc == named || svc.QueueURL == "" || svc.QueueName != testQueue {
+		t.Errorf("default service after a request: %s %s, want a resolved copy", svc.QueueName, svc.QueueURL)
+	}
+	if rec := do(mux, "", http.MethodPost, "/api/send", `{"message":"hello"}`); rec.Code != http.StatusOK {
+		t.Errorf("send on the resolved default: %d %s", rec.Code, rec.Body)
+	}
+}
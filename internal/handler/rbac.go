@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/pachecoc/sqs-ui/internal/rbac"
+)
+
+// writeRoles is the role each route needs for methods that change something (anything but
+// GET, HEAD and OPTIONS); unlisted routes need rbac.Operator. Reads need rbac.Viewer.
+var writeRoles = map[string]rbac.Role{
+	"/api/purge":            rbac.Admin,
+	"/api/queue/attributes": rbac.Admin,
+	"/api/queue/tags":       rbac.Admin,
+	"/api/queue/redrive":    rbac.Admin,
+	"/api/queues":           rbac.Admin,
+	"/api/config/queue":     rbac.Admin,
+	"/api/profiles":         rbac.Admin,
+	"/api/profiles/{queue}": rbac.Admin,
+	"/api/locks/{queue}":    rbac.Admin,
+	"/api/admin/erase":      rbac.Admin,
+}
+
+// authorize refuses requests whose role is below what the route and method need (403).
+// Public routes (health probes, sign-in, Slack commands) aren't checked.
+func (h *APIHandler) authorize(pattern string, next http.HandlerFunc) http.HandlerFunc {
+	if publicPath(pattern) {
+		return next
+	}
+	need, ok := writeRoles[pattern]
+	if !ok {
+		need = rbac.Operator
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			if !h.checkRole(w, r, rbac.Viewer, "reading") {
+				return
+			}
+		default:
+			if !h.checkRole(w, r, need, r.Method+" "+pattern) {
+				return
+			}
+		}
+		next(w, r)
+	}
+}
+
+// requestRole returns the role of the request's user under RBAC.
+func (h *APIHandler) requestRole(r *http.Request) rbac.Role {
+	var header string
+	if h.RBAC != nil && h.RBAC.Header != "" {
+		header = r.Header.Get(h.RBAC.Header)
+	}
+	return h.RBAC.Role(h.requestUser(r), header)
+}
+
+// checkRole refuses action (403) unless the request's role is at least need.
+func (h *APIHandler) checkRole(w http.ResponseWriter, r *http.Request, need rbac.Role, action string) bool {
+	role := h.requestRole(r)
+	if err := rbac.Check(role, need, action); err != nil {
+		h.Log.InfoContext(r.Context(), "request refused by role", "user", h.requestUser(r), "role", role.String(), "need", need.String(), "action", action)
+		respondError(w, http.StatusForbidden, err)
+		return false
+	}
+	return true
+}
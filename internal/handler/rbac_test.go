@@ -0,0 +1,149 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+
+	"github.com/pachecoc/sqs-ui/internal/approvals"
+	"github.com/pachecoc/sqs-ui/internal/jobs"
+	"github.com/pachecoc/sqs-ui/internal/memsqs"
+	"github.com/pachecoc/sqs-ui/internal/rbac"
+	"github.com/pachecoc/sqs-ui/internal/service"
+	"github.com/pachecoc/sqs-ui/internal/store"
+)
+
+// testQueue is the queue newTestAPI creates and selects.
+const testQueue = "orders"
+
+// newTestAPI returns a mux serving an APIHandler on a fresh in-memory emulator with
+// testQueue selected, the built-in job kinds registered and RBAC set to policy. Requests
+// name their user in X-User.
+func newTestAPI(t *testing.T, policy *rbac.Policy) (*http.ServeMux, *APIHandler, *memsqs.Client) {
+	t.Helper()
+	ctx := context.Background()
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	mem := memsqs.New()
+	out, err := mem.CreateQueue(ctx, &sqs.CreateQueueInput{QueueName: aws.String(testQueue)})
+	if err != nil {
+		t.Fatalf("create queue: %v", err)
+	}
+
+	m := jobs.NewManager(1, 1, nil, log)
+	jobs.RegisterDefaults(m)
+	jobs.RegisterCleanup(m)
+
+	svc := service.NewSQSService(ctx, mem, "", aws.ToString(out.QueueUrl), mem.Region, log)
+	// Listings poll until the queue answers empty; keep each empty poll short
+	svc.WaitSeconds = 1
+	h := NewAPIHandler(svc, log)
+	h.Client = mem
+	h.Jobs = m
+	h.RBAC = policy
+	h.UserHeader = "X-User"
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+	return mux, h, mem
+}
+
+// do serves one request as user and returns the recorded response.
+func do(mux http.Handler, user, method, target, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if user != "" {
+		req.Header.Set("X-User", user)
+	}
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestOperatorCannotRunDestructiveJobs(t *testing.T) {
+	mux, _, _ := newTestAPI(t, &rbac.Policy{Operators: []string{"olive"}, Admins: []string{"ada"}, Default: rbac.Viewer})
+
+	for _, kind := range []string{jobs.TypeDrain, jobs.TypeDrainGroups, jobs.TypeMove, jobs.TypeReplay, jobs.TypeCleanup} {
+		t.Run(kind, func(t *testing.T) {
+			body := `{"type":"` + kind + `","params":{"prefix":"pr-","target_queue":"other"}}`
+			if rec := do(mux, "olive", http.MethodPost, "/api/jobs", body); rec.Code != http.StatusForbidden {
+				t.Errorf("operator %s job: got %d, want 403 (%s)", kind, rec.Code, rec.Body)
+			}
+			// A dry run still needs the role: its confirm token is the admin's to use
+			if rec := do(mux, "olive", http.MethodPost, "/api/jobs?dry_run=true", body); rec.Code != http.StatusForbidden {
+				t.Errorf("operator %s dry run: got %d, want 403 (%s)", kind, rec.Code, rec.Body)
+			}
+		})
+	}
+}
+
+func TestOperatorCanRunExportJob(t *testing.T) {
+	mux, _, _ := newTestAPI(t, &rbac.Policy{Operators: []string{"olive"}, Default: rbac.Viewer})
+
+	rec := do(mux, "olive", http.MethodPost, "/api/jobs", `{"type":"export"}`)
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("operator export job: got %d, want 202 (%s)", rec.Code, rec.Body)
+	}
+	if rec := do(mux, "victor", http.MethodPost, "/api/jobs", `{"type":"export"}`); rec.Code != http.StatusForbidden {
+		t.Errorf("viewer export job: got %d, want 403", rec.Code)
+	}
+}
+
+func TestAdminCanRunDestructiveJobs(t *testing.T) {
+	mux, _, _ := newTestAPI(t, &rbac.Policy{Admins: []string{"ada"}, Default: rbac.Viewer})
+
+	rec := do(mux, "ada", http.MethodPost, "/api/jobs", `{"type":"drain"}`)
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("admin drain job: got %d, want 202 (%s)", rec.Code, rec.Body)
+	}
+}
+
+func TestOperatorCannotChangeQueueConfig(t *testing.T) {
+	mux, h, _ := newTestAPI(t, &rbac.Policy{Operators: []string{"olive"}, Admins: []string{"ada"}, Default: rbac.Viewer})
+
+	rec := do(mux, "olive", http.MethodPost, "/api/config/queue", `{"queue_name":"other"}`)
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("operator queue change: got %d, want 403 (%s)", rec.Code, rec.Body)
+	}
+	if got := h.getService().QueueName; got != testQueue {
+		t.Errorf("active queue changed to %q by a refused request", got)
+	}
+}
+
+func TestOperatorCannotApproveDestructiveJobs(t *testing.T) {
+	mux, h, _ := newTestAPI(t, &rbac.Policy{Operators: []string{"olive"}, Admins: []string{"ada", "abe"}, Default: rbac.Viewer})
+	h.Approvals = &approvals.Manager{Store: store.NewMemory(), TTL: time.Hour, Queues: []string{testQueue}}
+
+	rec := do(mux, "ada", http.MethodPost, "/api/jobs", `{"type":"drain"}`)
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("admin drain job: got %d, want 202 (%s)", rec.Code, rec.Body)
+	}
+	var env struct {
+		Data struct {
+			Approval approvals.Request `json:"approval"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &env); err != nil || env.Data.Approval.Status != approvals.StatusPending {
+		t.Fatalf("drain job on a protected queue: want a pending approval, got %s", rec.Body)
+	}
+	id := env.Data.Approval.ID
+
+	if rec := do(mux, "olive", http.MethodPost, "/api/approvals/"+id+"/approve", ""); rec.Code != http.StatusForbidden {
+		t.Fatalf("operator approving a drain: got %d, want 403 (%s)", rec.Code, rec.Body)
+	}
+	if req, err := h.Approvals.Get(context.Background(), id); err != nil || req.Status != approvals.StatusPending {
+		t.Fatalf("refused approval changed the request: %+v, %v", req, err)
+	}
+	if rec := do(mux, "abe", http.MethodPost, "/api/approvals/"+id+"/approve", ""); rec.Code != http.StatusOK {
+		t.Errorf("second admin approving a drain: got %d, want 200 (%s)", rec.Code, rec.Body)
+	}
+}
@@ -0,0 +1,307 @@
+package handler
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pachecoc/sqs-ui/internal/events"
+	"github.com/pachecoc/sqs-ui/internal/locks"
+	"github.com/pachecoc/sqs-ui/internal/service"
+	"github.com/pachecoc/sqs-ui/internal/validate"
+	"github.com/pachecoc/sqs-ui/internal/watch"
+)
+
+const (
+	defaultReportSamples = 5
+	maxReportSamples     = 50
+
+	// maxReportActions bounds the audited actions listed, newest first.
+	maxReportActions = 100
+)
+
+// reportTemplateSource is the printable form of a queueReport. It isn't one of the no-JS UI's
+// templates/*.tmpl, so headless builds (-tags noui) keep it.
+//
+//go:embed templates/report.html
+var reportTemplateSource string
+
+var reportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"sparkline": depthSparkline,
+}).Parse(reportTemplateSource))
+
+// queueReport is an incident report on one queue, built from what sqs-ui knows about it now.
+// Sections that couldn't be read are left out and explained in Errors: a report is still
+// produced while SQS or a subsystem misbehaves, which is when it's wanted.
+type queueReport struct {
+	QueueName   string              `json:"queue_name"`
+	QueueURL    string              `json:"queue_url"`
+	Region      string              `json:"region"`
+	GeneratedAt time.Time           `json:"generated_at"`
+	GeneratedBy string              `json:"generated_by,omitempty"`
+	Attributes  map[string]string   `json:"attributes,omitempty"`
+	Lock        *locks.Lock         `json:"observe_only_lock,omitempty"`
+	Depth       *watch.DepthHistory `json:"depth_history,omitempty"`
+	DLQ         *reportDLQ          `json:"dead_letter_queue,omitempty"`
+	Actions     []reportAction      `json:"actions"`
+	Errors      map[string]string   `json:"errors,omitempty"`
+}
+
+// reportDLQ summarizes the queue's dead-letter queue with a sample of what landed there.
+type reportDLQ struct {
+	QueueName       string              `json:"queue_name"`
+	QueueURL        string              `json:"queue_url"`
+	MaxReceiveCount int                 `json:"max_receive_count"`
+	Counts          service.QueueCounts `json:"counts"`
+	Samples         []map[string]any    `json:"samples"`
+}
+
+// reportAction is one audited action on the queue: a lock change, an approval step, a job or
+// a notification.
+type reportAction struct {
+	At     time.Time `json:"at"`
+	Source string    `json:"source"`
+	Action string    `json:"action"`
+	By     string    `json:"by,omitempty"`
+	Detail string    `json:"detail,omitempty"`
+}
+
+// handleQueueReport compiles an incident report on the queue: its attributes, depth history,
+// dead-letter queue with ?samples= (default 5) of its messages, and the recent audited actions
+// on it. JSON by default; ?format=html (or a browser's Accept) gives a printable page, and
+// ?download=true saves either as a file to attach to a ticket.
+func (h *APIHandler) handleQueueReport(w http.ResponseWriter, r *http.Request) {
+	if !enforceMethod(w, r, http.MethodGet) {
+		return
+	}
+	q := r.URL.Query()
+	samples := defaultReportSamples
+	var v validate.Validator
+	if raw := q.Get("samples"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if v.Check(err == nil, "samples", "must be an integer") {
+			v.Range("samples", n, 0, maxReportSamples)
+			samples = n
+		}
+	}
+	format := q.Get("format")
+	if format == "" && strings.Contains(r.Header.Get("Accept"), "text/html") {
+		format = "html"
+	}
+	v.OneOf("format", cmp.Or(format, "json"), "json", "html")
+	if err := v.Err(); err != nil {
+		respondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	svc := h.queueService(r.Context())
+	report := h.buildReport(r.Context(), svc, samples)
+	report.GeneratedBy = h.requestUser(r)
+	h.Log.InfoContext(r.Context(), "queue report generated", "queue_name", svc.QueueName, "user", report.GeneratedBy, "sections_failed", len(report.Errors))
+
+	if download, _ := strconv.ParseBool(q.Get("download")); download {
+		ext := "json"
+		if format == "html" {
+			ext = "html"
+		}
+		name := fmt.Sprintf("sqs-report-%s-%s.%s", svc.QueueName, report.GeneratedAt.Format("20060102-150405"), ext)
+		w.Header().Set("Content-Disposition", `attachment; filename="`+name+`"`)
+	}
+	if format != "html" {
+		respondJSON(w, http.StatusOK, report)
+		return
+	}
+	var buf bytes.Buffer
+	if err := reportTemplate.Execute(&buf, report); err != nil {
+		respondError(w, http.StatusInternalServerError, err)
+		return
+	}
+	respondRaw(w, http.StatusOK, "text/html; charset=utf-8", buf.Bytes())
+}
+
+// buildReport gathers each section of the report, noting the ones that fail.
+func (h *APIHandler) buildReport(ctx context.Context, svc *service.SQSService, samples int) queueReport {
+	report := queueReport{
+		QueueName:   svc.QueueName,
+		QueueURL:    svc.QueueURL,
+		Region:      svc.Region,
+		GeneratedAt: time.Now().UTC().Truncate(time.Second),
+		Actions:     []reportAction{},
+		Errors:      map[string]string{},
+	}
+	fail := func(section string, err error) {
+		h.Log.WarnContext(ctx, "queue report section failed", "queue_name", svc.QueueName, "section", section, "error", err)
+		report.Errors[section] = err.Error()
+	}
+
+	if attrs, err := svc.Attributes(ctx); err != nil {
+		fail("attributes", err)
+	} else {
+		report.Attributes = attrs.Attributes
+	}
+	if h.Depth != nil {
+		history := h.Depth.History(svc.QueueName)
+		report.Depth = &history
+	}
+	if dlq, err := h.reportDLQ(ctx, svc, samples); err != nil {
+		if !errors.Is(err, service.ErrNoDeadLetterQueue) {
+			fail("dead_letter_queue", err)
+		}
+	} else {
+		report.DLQ = dlq
+	}
+	if lock, err := h.Locks.Get(ctx, svc.QueueName); err != nil {
+		fail("lock", err)
+	} else {
+		if lock.Locked {
+			report.Lock = &lock
+		}
+		for _, e := range lock.History {
+			report.Actions = append(report.Actions, reportAction{At: e.At, Source: "lock", Action: e.Event, By: e.By, Detail: e.Reason})
+		}
+	}
+	if actions, err := h.reportApprovals(ctx, svc.QueueName); err != nil {
+		fail("approvals", err)
+	} else {
+		report.Actions = append(report.Actions, actions...)
+	}
+	report.Actions = append(report.Actions, h.reportJobs(ctx, svc.QueueName)...)
+	report.Actions = append(report.Actions, h.reportEvents(svc.QueueName)...)
+
+	sort.SliceStable(report.Actions, func(i, j int) bool { return report.Actions[i].At.After(report.Actions[j].At) })
+	if len(report.Actions) > maxReportActions {
+		report.Actions = report.Actions[:maxReportActions]
+	}
+	return report
+}
+
+// reportDLQ reads the dead-letter queue's counts and samples its messages in observe mode,
+// so they're released straight away.
+func (h *APIHandler) reportDLQ(ctx context.Context, svc *service.SQSService, samples int) (*reportDLQ, error) {
+	policy, err := svc.RedrivePolicy(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if policy == nil {
+		return nil, service.ErrNoDeadLetterQueue
+	}
+	dlqSvc, err := svc.DeadLetterQueue(ctx)
+	if err != nil {
+		return nil, err
+	}
+	counts, err := dlqSvc.Counts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	dlq := &reportDLQ{
+		QueueName:       dlqSvc.QueueName,
+		QueueURL:        dlqSvc.QueueURL,
+		MaxReceiveCount: policy.MaxReceiveCount,
+		Counts:          counts,
+		Samples:         []map[string]any{},
+	}
+	if samples == 0 || counts.Visible == 0 {
+		return dlq, nil
+	}
+	mode, err := h.receiveMode(ctx, dlqSvc, service.ModeObserve)
+	if err != nil {
+		return nil, err
+	}
+	msgs, err := dlqSvc.Receive(ctx, mode)
+	if err != nil && !errors.Is(err, service.ErrPartial) {
+		return nil, err
+	}
+	msgs = msgs[:min(samples, len(msgs))]
+	// Decoders and masking follow the DLQ's profile
+	h.decode(context.WithValue(ctx, queueServiceKey{}, dlqSvc), msgs)
+	dlq.Samples = append(dlq.Samples, msgs...)
+	return dlq, nil
+}
+
+// reportApprovals lists the approval trail of requests on queue.
+func (h *APIHandler) reportApprovals(ctx context.Context, queue string) ([]reportAction, error) {
+	if h.Approvals == nil {
+		return nil, nil
+	}
+	list, err := h.Approvals.List(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	var out []reportAction
+	for _, req := range list {
+		if req.QueueName != queue {
+			continue
+		}
+		for _, e := range req.History {
+			out = append(out, reportAction{At: e.At, Source: "approval", Action: req.Action + " " + e.Event, By: e.By, Detail: e.Detail})
+		}
+	}
+	return out, nil
+}
+
+// reportJobs lists the jobs run on queue that are still on record.
+func (h *APIHandler) reportJobs(ctx context.Context, queue string) []reportAction {
+	if h.Jobs == nil {
+		return nil
+	}
+	var out []reportAction
+	for _, j := range h.Jobs.List(ctx) {
+		if j.QueueName != queue {
+			continue
+		}
+		a := reportAction{At: j.CreatedAt, Source: "job", Action: j.Type + " " + j.Status, Detail: j.Error}
+		if j.FinishedAt != nil {
+			a.At = *j.FinishedAt
+		}
+		out = append(out, a)
+	}
+	return out
+}
+
+// reportEvents lists this replica's recent notifications about queue, leaving out the kinds
+// already covered by the lock, approval and job trails.
+func (h *APIHandler) reportEvents(queue string) []reportAction {
+	var out []reportAction
+	for _, e := range h.Events.Recent() {
+		switch e.Type {
+		case events.TypeQueueLockChanged, events.TypeApprovalRequested, events.TypeApprovalDecided, events.TypeJobCompleted:
+			continue
+		}
+		if q, _ := e.Data["queue_name"].(string); q != queue {
+			continue
+		}
+		by, _ := e.Data["user"].(string)
+		out = append(out, reportAction{At: e.Time, Source: "notification", Action: e.Type, By: by, Detail: e.Message})
+	}
+	return out
+}
+
+// depthSparkline draws the total depth (visible and in flight) of a history as an inline SVG.
+func depthSparkline(history *watch.DepthHistory) template.HTML {
+	const width, height = 600.0, 80.0
+	if history == nil || len(history.Samples) < 2 {
+		return ""
+	}
+	var peak int64 = 1
+	for _, s := range history.Samples {
+		peak = max(peak, s.Visible+s.NotVisible)
+	}
+	points := make([]string, len(history.Samples))
+	step := width / float64(len(history.Samples)-1)
+	for i, s := range history.Samples {
+		y := height - float64(s.Visible+s.NotVisible)/float64(peak)*(height-4) - 2
+		points[i] = fmt.Sprintf("%.1f,%.1f", float64(i)*step, y)
+	}
+	return template.HTML(fmt.Sprintf(
+		`<svg viewBox="0 0 %.0f %.0f" width="100%%" height="%.0f" role="img" aria-label="Queue depth, peak %d"><polyline fill="none" stroke="#2563eb" stroke-width="2" points="%s"/></svg>`,
+		width, height, height, peak, strings.Join(points, " ")))
+}
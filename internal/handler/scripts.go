@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/pachecoc/sqs-ui/internal/scripts"
+)
+
+// handleScripts lists scripts (GET) or creates/replaces one (POST { "name", "kind", "source" }).
+func (h *APIHandler) handleScripts(w http.ResponseWriter, r *http.Request) {
+	if h.Scripts == nil {
+		respondError(w, http.StatusServiceUnavailable, errors.New("scripts are not enabled"))
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		list, err := h.Scripts.List(r.Context())
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, err)
+			return
+		}
+		respondJSON(w, http.StatusOK, map[string]any{"scripts": list})
+	case http.MethodPost:
+		var s scripts.Script
+		if err := json.NewDecoder(r.Body).Decode(&s); err != nil {
+			respondError(w, http.StatusBadRequest, err)
+			return
+		}
+		saved, err := h.Scripts.Save(r.Context(), s)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, err)
+			return
+		}
+		h.Log.InfoContext(r.Context(), "script saved", "name", saved.Name, "kind", saved.Kind)
+		respondJSON(w, http.StatusOK, saved)
+	case http.MethodOptions:
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		respondError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+	}
+}
+
+// handleScript reads (GET) or deletes (DELETE) one script.
+func (h *APIHandler) handleScript(w http.ResponseWriter, r *http.Request) {
+	if h.Scripts == nil {
+		respondError(w, http.StatusServiceUnavailable, errors.New("scripts are not enabled"))
+		return
+	}
+	name := r.PathValue("name")
+
+	switch r.Method {
+	case http.MethodGet:
+		s, err := h.Scripts.Get(r.Context(), name)
+		if errors.Is(err, scripts.ErrNotFound) {
+			respondError(w, http.StatusNotFound, err)
+			return
+		}
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, err)
+			return
+		}
+		respondJSON(w, http.StatusOK, s)
+	case http.MethodDelete:
+		if err := h.Scripts.Delete(r.Context(), name); err != nil {
+			respondError(w, http.StatusInternalServerError, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case http.MethodOptions:
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.Header().Set("Allow", "GET, DELETE")
+		respondError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+	}
+}
+
+// handleScriptTest runs a stored script against a sample message: POST { "message_id": "...", "body": "..." }.
+func (h *APIHandler) handleScriptTest(w http.ResponseWriter, r *http.Request) {
+	if !enforceMethod(w, r, http.MethodPost) {
+		return
+	}
+	if h.Scripts == nil {
+		respondError(w, http.StatusServiceUnavailable, errors.New("scripts are not enabled"))
+		return
+	}
+
+	s, err := h.Scripts.Get(r.Context(), r.PathValue("name"))
+	if errors.Is(err, scripts.ErrNotFound) {
+		respondError(w, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	var msg map[string]any
+	if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+		respondError(w, http.StatusBadRequest, err)
+		return
+	}
+	result, err := h.Scripts.Run(r.Context(), s, msg)
+	if err != nil {
+		respondError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]any{"kind": s.Kind, "result": result})
+}
@@ -0,0 +1,244 @@
+package handler
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/pachecoc/sqs-ui/internal/service"
+	"github.com/pachecoc/sqs-ui/internal/shares"
+	"github.com/pachecoc/sqs-ui/internal/store"
+	"github.com/pachecoc/sqs-ui/internal/validate"
+)
+
+// errSharesDisabled answers the share link endpoints when SHARE_LINKS is off.
+var errSharesDisabled = errors.New("share links are not enabled; set SHARE_LINKS=true")
+
+// defaultShareMaxTTL caps share links when ShareMaxTTL is unset.
+const defaultShareMaxTTL = 24 * time.Hour
+
+// sharedView is what a share link shows: the queue's counts and a peek at it, or the pinned
+// snapshot's messages.
+type sharedView struct {
+	QueueName  string               `json:"queue_name"`
+	SnapshotID string               `json:"snapshot_id,omitempty"`
+	Note       string               `json:"note,omitempty"`
+	SharedBy   string               `json:"shared_by,omitempty"`
+	ExpiresAt  time.Time            `json:"expires_at"`
+	Counts     *service.QueueCounts `json:"counts,omitempty"`
+	ReceivedAt time.Time            `json:"received_at"`
+	Messages   []map[string]any     `json:"messages"`
+}
+
+// handleShares lists live share links (GET) or creates one (POST, JSON { "queue": "...",
+// "snapshot_id": "...", "ttl_seconds": 3600, "note": "..." }). Without snapshot_id the link
+// peeks at the queue (the default queue when unset) each time it's opened; with it, the link
+// shows that receive snapshot, which is kept as long as the link.
+func (h *APIHandler) handleShares(w http.ResponseWriter, r *http.Request) {
+	if h.Shares == nil {
+		respondError(w, http.StatusServiceUnavailable, errSharesDisabled)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		list, err := h.Shares.List(r.Context())
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, err)
+			return
+		}
+		respondJSON(w, http.StatusOK, map[string]any{"shares": list})
+	case http.MethodPost:
+		h.createShare(w, r)
+	case http.MethodOptions:
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		respondError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+	}
+}
+
+func (h *APIHandler) createShare(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Queue      string `json:"queue"`
+		SnapshotID string `json:"snapshot_id"`
+		TTLSeconds int    `json:"ttl_seconds"`
+		Note       string `json:"note"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, err)
+		return
+	}
+	maxTTL := cmp.Or(h.ShareMaxTTL, defaultShareMaxTTL)
+	ttl := shares.DefaultTTL
+	if req.TTLSeconds != 0 {
+		ttl = time.Duration(req.TTLSeconds) * time.Second
+	}
+	ttl = min(ttl, maxTTL)
+	var v validate.Validator
+	if req.Queue != "" {
+		v.Check(queueNamePattern.MatchString(req.Queue), "queue", "must be a queue name (letters, digits, - and _, optionally ending in .fifo)")
+	}
+	if req.TTLSeconds != 0 {
+		v.Range("ttl_seconds", req.TTLSeconds, 60, int(maxTTL.Seconds()))
+	}
+	v.MaxBytes("note", req.Note, 500)
+	if err := v.Err(); err != nil {
+		respondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	ctx := r.Context()
+	s := shares.Share{Note: req.Note, CreatedBy: h.requestUser(r)}
+	if req.SnapshotID != "" {
+		// Pin the snapshot for as long as the link lasts
+		var snap receiveSnapshot
+		if err := store.GetJSON(ctx, h.snapshotStore(), categorySnapshots, req.SnapshotID, &snap); err != nil {
+			status := http.StatusInternalServerError
+			if errors.Is(err, store.ErrNotFound) {
+				status, err = http.StatusNotFound, errors.New("the snapshot expired; fetch messages again and share the new one")
+			}
+			respondError(w, status, err)
+			return
+		}
+		if req.Queue != "" && req.Queue != snap.QueueName {
+			respondError(w, http.StatusBadRequest, fmt.Errorf("snapshot %s is of %s, not %s", snap.ID, snap.QueueName, req.Queue))
+			return
+		}
+		if err := store.PutJSON(ctx, h.snapshotStore(), categorySnapshots, snap.ID, snap, max(ttl, snapshotTTL)); err != nil {
+			respondError(w, http.StatusInternalServerError, err)
+			return
+		}
+		s.QueueName, s.SnapshotID = snap.QueueName, snap.ID
+	} else {
+		svc := h.queueService(ctx)
+		if req.Queue != "" {
+			var err error
+			if svc, err = h.queueFor(ctx, req.Queue); err != nil {
+				respondError(w, serviceErrorStatus(err), err)
+				return
+			}
+		}
+		if svc == nil {
+			respondError(w, http.StatusServiceUnavailable, errors.New("service unavailable"))
+			return
+		}
+		if err := svc.EnsureQueueConfigured(); err != nil {
+			respondError(w, http.StatusBadRequest, err)
+			return
+		}
+		s.QueueName = svc.QueueName
+	}
+
+	saved, token, err := h.Shares.Create(ctx, s, ttl)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, fmt.Errorf("could not save the share link: %w", err))
+		return
+	}
+	h.Log.InfoContext(ctx, "share link created", "share_id", saved.ID, "user", saved.CreatedBy, "queue_name", saved.QueueName, "snapshot_id", saved.SnapshotID, "expires_at", saved.ExpiresAt)
+	base := basePathFrom(ctx)
+	respondJSON(w, http.StatusCreated, map[string]any{
+		"share":   saved,
+		"url":     base + "/shared/" + token,
+		"api_url": base + "/api/shared/" + token,
+	})
+}
+
+// handleShare revokes a share link before it expires.
+func (h *APIHandler) handleShare(w http.ResponseWriter, r *http.Request) {
+	if !enforceMethod(w, r, http.MethodDelete) {
+		return
+	}
+	if h.Shares == nil {
+		respondError(w, http.StatusServiceUnavailable, errSharesDisabled)
+		return
+	}
+	id := r.PathValue("id")
+	if err := h.Shares.Revoke(r.Context(), id); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, store.ErrNotFound) {
+			status = http.StatusNotFound
+		}
+		respondError(w, status, err)
+		return
+	}
+	h.Log.InfoContext(r.Context(), "share link revoked", "share_id", id, "user", h.requestUser(r))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleSharedView shows what a share link grants. It needs no sign-in: the token is the
+// credential, and it only ever reads.
+func (h *APIHandler) handleSharedView(w http.ResponseWriter, r *http.Request) {
+	if !enforceMethod(w, r, http.MethodGet) {
+		return
+	}
+	view, status, err := h.openShare(r.Context(), r.PathValue("token"))
+	if err != nil {
+		respondError(w, status, err)
+		return
+	}
+	respondNegotiated(w, r, http.StatusOK, view, func() string { return formatBodies(view.Messages) })
+}
+
+// openShare resolves a share token into its view. The returned status goes with the error.
+func (h *APIHandler) openShare(ctx context.Context, token string) (sharedView, int, error) {
+	if h.Shares == nil {
+		return sharedView{}, http.StatusServiceUnavailable, errSharesDisabled
+	}
+	s, err := h.Shares.Open(ctx, token)
+	switch {
+	case errors.Is(err, shares.ErrInvalid):
+		return sharedView{}, http.StatusNotFound, err
+	case errors.Is(err, shares.ErrExpired), errors.Is(err, shares.ErrRevoked):
+		return sharedView{}, http.StatusGone, err
+	case err != nil:
+		return sharedView{}, http.StatusInternalServerError, err
+	}
+	view := sharedView{QueueName: s.QueueName, SnapshotID: s.SnapshotID, Note: s.Note, SharedBy: s.CreatedBy, ExpiresAt: s.ExpiresAt}
+	svc, err := h.queueFor(ctx, s.QueueName)
+	if err != nil {
+		return sharedView{}, serviceErrorStatus(err), err
+	}
+	// Decoders and masking follow the shared queue's profile
+	ctx = context.WithValue(ctx, queueServiceKey{}, svc)
+
+	if s.SnapshotID != "" {
+		var snap receiveSnapshot
+		if err := store.GetJSON(ctx, h.snapshotStore(), categorySnapshots, s.SnapshotID, &snap); err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				return sharedView{}, http.StatusGone, errors.New("the shared messages are no longer available")
+			}
+			return sharedView{}, http.StatusInternalServerError, err
+		}
+		view.ReceivedAt, view.Messages = snap.ReceivedAt, snap.Messages
+	} else {
+		counts, err := svc.Counts(ctx)
+		if err != nil {
+			return sharedView{}, serviceErrorStatus(err), err
+		}
+		// Observe mode releases what it lists (or peeks, under a lock): the link's reader
+		// never holds messages back from consumers
+		mode, err := h.receiveMode(ctx, svc, service.ModeObserve)
+		if err != nil {
+			return sharedView{}, serviceErrorStatus(err), err
+		}
+		msgs, err := svc.Receive(ctx, mode)
+		if err != nil {
+			return sharedView{}, serviceErrorStatus(err), err
+		}
+		view.Counts, view.ReceivedAt, view.Messages = &counts, time.Now().UTC(), msgs
+	}
+	// Profile masking applies as it does in the UI; receipt handles would let the reader
+	// delete messages through someone else's credentials
+	h.decode(ctx, view.Messages)
+	for _, m := range view.Messages {
+		delete(m, "receipt_handle")
+	}
+	if view.Messages == nil {
+		view.Messages = []map[string]any{}
+	}
+	return view, http.StatusOK, nil
+}
@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/pachecoc/sqs-ui/internal/shares"
+	"github.com/pachecoc/sqs-ui/internal/store"
+)
+
+func TestShareLink(t *testing.T) {
+	mux, h, _ := newTestAPI(t, nil)
+	h.Shares = &shares.Manager{Store: store.NewMemory()}
+
+	if rec := do(mux, "", http.MethodPost, "/api/send", `{"message":"order 42"}`); rec.Code != http.StatusOK {
+		t.Fatalf("send: got %d (%s)", rec.Code, rec.Body)
+	}
+	rec := do(mux, "ada", http.MethodPost, "/api/shares", `{"note":"incident 7"}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create: got %d (%s)", rec.Code, rec.Body)
+	}
+	var created struct {
+		Data struct {
+			Share  shares.Share `json:"share"`
+			APIURL string       `json:"api_url"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil || created.Data.APIURL == "" {
+		t.Fatalf("create: %v (%s)", err, rec.Body)
+	}
+
+	// Anyone holding the link reads the queue, without a user and without receipt handles
+	rec = do(mux, "", http.MethodGet, created.Data.APIURL, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("open: got %d (%s)", rec.Code, rec.Body)
+	}
+	var view struct {
+		Data sharedView `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &view); err != nil {
+		t.Fatal(err)
+	}
+	if view.Data.QueueName != testQueue || view.Data.Note != "incident 7" || view.Data.SharedBy != "ada" || len(view.Data.Messages) != 1 {
+		t.Fatalf("shared view %+v", view.Data)
+	}
+	if _, ok := view.Data.Messages[0]["receipt_handle"]; ok || strings.Contains(rec.Body.String(), "receipt_handle") {
+		t.Errorf("shared view leaks receipt handles: %s", rec.Body)
+	}
+	if view.Data.Messages[0]["body"] != "order 42" {
+		t.Errorf("shared message %v", view.Data.Messages[0])
+	}
+
+	if rec := do(mux, "", http.MethodGet, created.Data.APIURL+"x", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("forged link: got %d, want 404 (%s)", rec.Code, rec.Body)
+	}
+
+	if rec := do(mux, "ada", http.MethodDelete, "/api/shares/"+created.Data.Share.ID, ""); rec.Code != http.StatusNoContent {
+		t.Fatalf("revoke: got %d (%s)", rec.Code, rec.Body)
+	}
+	if rec := do(mux, "", http.MethodGet, created.Data.APIURL, ""); rec.Code != http.StatusGone {
+		t.Errorf("revoked link: got %d, want 410 (%s)", rec.Code, rec.Body)
+	}
+	if rec := do(mux, "ada", http.MethodDelete, "/api/shares/"+created.Data.Share.ID, ""); rec.Code != http.StatusNotFound {
+		t.Errorf("second revoke: got %d, want 404 (%s)", rec.Code, rec.Body)
+	}
+}
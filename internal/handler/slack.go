@@ -0,0 +1,212 @@
+package handler
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pachecoc/sqs-ui/internal/notify"
+	"github.com/pachecoc/sqs-ui/internal/service"
+)
+
+const (
+	// slackMaxSkew rejects replayed requests older than Slack's recommended five minutes.
+	slackMaxSkew = 5 * time.Minute
+
+	// slackCommandTimeout bounds the work done for one command.
+	slackCommandTimeout = 30 * time.Second
+
+	slackMaxPeek = 10
+)
+
+// slackMessage is a slash-command response (https://api.slack.com/interactivity/slash-commands).
+type slackMessage struct {
+	ResponseType string       `json:"response_type"`
+	Text         string       `json:"text"`
+	Blocks       []slackBlock `json:"blocks,omitempty"`
+}
+
+type slackBlock struct {
+	Type   string       `json:"type"`
+	Text   *slackText   `json:"text,omitempty"`
+	Fields []*slackText `json:"fields,omitempty"`
+}
+
+type slackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+func slackSection(text string, fields ...string) slackBlock {
+	b := slackBlock{Type: "section", Text: &slackText{Type: "mrkdwn", Text: text}}
+	for _, f := range fields {
+		b.Fields = append(b.Fields, &slackText{Type: "mrkdwn", Text: f})
+	}
+	return b
+}
+
+// handleSlackCommand implements the /sqs slash command:
+//
+//	/sqs info [queue]
+//	/sqs peek [queue] [count]
+//
+// Requests are verified with the app's signing secret. The command is acknowledged at once
+// and the result is posted to the response_url, since Slack only waits three seconds.
+func (h *APIHandler) handleSlackCommand(w http.ResponseWriter, r *http.Request) {
+	if !enforceMethod(w, r, http.MethodPost) {
+		return
+	}
+	if !h.SlackSigningSecret.IsSet() {
+		respondError(w, http.StatusNotFound, errors.New("slack integration is not configured"))
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, 64<<10))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := verifySlackSignature(h.SlackSigningSecret.Get(), r.Header, body, time.Now()); err != nil {
+		h.Log.WarnContext(r.Context(), "rejected slack command", "error", err)
+		respondError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err)
+		return
+	}
+	args := strings.Fields(form.Get("text"))
+	responseURL := form.Get("response_url")
+	h.Log.InfoContext(r.Context(), "slack command", "user", form.Get("user_name"), "text", form.Get("text"))
+
+	if len(args) == 0 || args[0] == "help" || !strings.HasPrefix(responseURL, "https://hooks.slack.com/") {
+		writeJSON(w, http.StatusOK, slackHelp())
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), slackCommandTimeout)
+		defer cancel()
+		if err := notify.PostJSON(ctx, responseURL, h.runSlackCommand(ctx, args)); err != nil {
+			h.Log.WarnContext(r.Context(), "failed to post slack response", "error", err)
+		}
+	}()
+	writeJSON(w, http.StatusOK, slackMessage{ResponseType: "ephemeral", Text: "Working on `" + strings.Join(args, " ") + "`…"})
+}
+
+// runSlackCommand executes a parsed command and renders the reply.
+func (h *APIHandler) runSlackCommand(ctx context.Context, args []string) slackMessage {
+	var queue string
+	if len(args) > 1 {
+		queue = args[1]
+	}
+	svc, err := h.serviceFor(ctx, queue)
+	if err != nil {
+		return slackError(err)
+	}
+
+	switch args[0] {
+	case "info":
+		info := svc.Info(ctx)
+		if info["status"] != "ok" {
+			return slackError(fmt.Errorf("%v", info["error"]))
+		}
+		return slackMessage{
+			ResponseType: "in_channel",
+			Text:         "Queue " + svc.QueueName,
+			Blocks: []slackBlock{
+				slackSection("*Queue* `" + svc.QueueName + "`"),
+				slackSection("",
+					fmt.Sprintf("*Total*\n%v", info["number_of_messages"]),
+					fmt.Sprintf("*Visible*\n%v", info["approximate_number_of_messages"]),
+					fmt.Sprintf("*In flight*\n%v", info["approximate_number_of_messages_not_visible"]),
+					fmt.Sprintf("*Delayed*\n%v", info["approximate_number_of_messages_delayed"]),
+				),
+			},
+		}
+	case "peek":
+		count := 5
+		if len(args) > 2 {
+			if count, err = strconv.Atoi(args[2]); err != nil || count <= 0 {
+				return slackError(errors.New("count must be a positive number"))
+			}
+		}
+		// A peek only needs a few messages, so a listing cut short by the deadline is fine
+		mode, err := h.receiveMode(ctx, svc, service.ModeObserve)
+		if err != nil {
+			return slackError(err)
+		}
+		msgs, err := svc.Receive(ctx, mode)
+		if err != nil && !errors.Is(err, service.ErrPartial) {
+			return slackError(err)
+		}
+		msgs = msgs[:min(count, slackMaxPeek, len(msgs))]
+
+		blocks := []slackBlock{slackSection(fmt.Sprintf("*%d message(s)* from `%s`", len(msgs), svc.QueueName))}
+		for _, m := range msgs {
+			blocks = append(blocks, slackSection(fmt.Sprintf("`%v`\n```%s```", m["message_id"], truncate(fmt.Sprint(m["body"]), 500))))
+		}
+		return slackMessage{ResponseType: "ephemeral", Text: "Messages from " + svc.QueueName, Blocks: blocks}
+	default:
+		return slackHelp()
+	}
+}
+
+// serviceFor returns the active service, or one for another queue sharing its AWS client.
+func (h *APIHandler) serviceFor(ctx context.Context, queueName string) (*service.SQSService, error) {
+	svc := h.getService()
+	if svc == nil {
+		return nil, errors.New("service unavailable")
+	}
+	return svc.ForQueue(ctx, queueName)
+}
+
+func slackHelp() slackMessage {
+	return slackMessage{
+		ResponseType: "ephemeral",
+		Text:         "Usage: `/sqs info [queue]` or `/sqs peek [queue] [count]`",
+	}
+}
+
+func slackError(err error) slackMessage {
+	return slackMessage{ResponseType: "ephemeral", Text: ":warning: " + err.Error()}
+}
+
+// verifySlackSignature checks X-Slack-Signature (v0 HMAC-SHA256 over "v0:<timestamp>:<body>").
+func verifySlackSignature(secret string, header http.Header, body []byte, now time.Time) error {
+	ts := header.Get("X-Slack-Request-Timestamp")
+	sec, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return errors.New("missing or invalid request timestamp")
+	}
+	if skew := now.Sub(time.Unix(sec, 0)); skew > slackMaxSkew || skew < -slackMaxSkew {
+		return errors.New("request timestamp too old")
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "v0:%s:", ts)
+	mac.Write(body)
+	expected := "v0=" + hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(header.Get("X-Slack-Signature"))) {
+		return errors.New("invalid signature")
+	}
+	return nil
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "…"
+}
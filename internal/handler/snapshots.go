@@ -0,0 +1,139 @@
+package handler
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pachecoc/sqs-ui/internal/service"
+	"github.com/pachecoc/sqs-ui/internal/store"
+	"github.com/pachecoc/sqs-ui/internal/validate"
+)
+
+const (
+	// categorySnapshots holds receive snapshots in the store.
+	categorySnapshots = "snapshots"
+
+	// snapshotTTL bounds how long a cursor can be paged after the receive.
+	snapshotTTL = 10 * time.Minute
+
+	defaultPageSize = 20
+	maxPageSize     = 500
+)
+
+// receiveSnapshot is one receive of the queue, kept in the store so any replica
+// can serve the following pages of the same cursor.
+type receiveSnapshot struct {
+	ID            string              `json:"id"`
+	QueueName     string              `json:"queue_name"`
+	Mode          service.ReceiveMode `json:"mode"`
+	ReceivedAt    time.Time           `json:"received_at"`
+	InFlightUntil *time.Time          `json:"in_flight_until,omitempty"`
+	Messages      []map[string]any    `json:"messages"`
+}
+
+// messagePage is the paginated /api/messages response.
+type messagePage struct {
+	Messages      []map[string]any    `json:"messages"`
+	Total         int                 `json:"total"`
+	Offset        int                 `json:"offset"`
+	NextCursor    string              `json:"next_cursor,omitempty"`
+	SnapshotID    string              `json:"snapshot_id"`
+	Mode          service.ReceiveMode `json:"mode"`
+	ReceivedAt    time.Time           `json:"received_at"`
+	InFlightUntil *time.Time          `json:"in_flight_until,omitempty"`
+}
+
+// saveSnapshot stores a fresh receive and returns it.
+func (h *APIHandler) saveSnapshot(ctx context.Context, svc *service.SQSService, mode service.ReceiveMode, msgs []map[string]any) (receiveSnapshot, error) {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return receiveSnapshot{}, err
+	}
+	now := time.Now().UTC()
+	snap := receiveSnapshot{
+		ID:         hex.EncodeToString(id),
+		QueueName:  svc.QueueName,
+		Mode:       mode,
+		ReceivedAt: now,
+		Messages:   msgs,
+	}
+	if v := svc.VisibilityFor(mode); v > 0 {
+		until := now.Add(v)
+		snap.InFlightUntil = &until
+	}
+	if err := store.PutJSON(ctx, h.snapshotStore(), categorySnapshots, snap.ID, snap, snapshotTTL); err != nil {
+		return receiveSnapshot{}, err
+	}
+	return snap, nil
+}
+
+// loadSnapshot resolves a cursor ("<snapshot id>.<offset>") into its snapshot and offset.
+func (h *APIHandler) loadSnapshot(ctx context.Context, cursor string) (receiveSnapshot, int, error) {
+	id, off, ok := strings.Cut(cursor, ".")
+	offset, err := strconv.Atoi(off)
+	if !ok || err != nil || offset < 0 {
+		return receiveSnapshot{}, 0, errors.New("invalid cursor")
+	}
+	var snap receiveSnapshot
+	if err := store.GetJSON(ctx, h.snapshotStore(), categorySnapshots, id, &snap); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return receiveSnapshot{}, 0, errors.New("cursor expired, fetch messages again")
+		}
+		return receiveSnapshot{}, 0, err
+	}
+	return snap, offset, nil
+}
+
+// page slices a snapshot, returning a cursor for the next page when one exists.
+func (snap receiveSnapshot) page(offset, limit int) messagePage {
+	total := len(snap.Messages)
+	start := min(offset, total)
+	end := min(start+limit, total)
+
+	p := messagePage{
+		Messages:      snap.Messages[start:end],
+		Total:         total,
+		Offset:        start,
+		SnapshotID:    snap.ID,
+		Mode:          snap.Mode,
+		ReceivedAt:    snap.ReceivedAt,
+		InFlightUntil: snap.InFlightUntil,
+	}
+	if end < total {
+		p.NextCursor = fmt.Sprintf("%s.%d", snap.ID, end)
+	}
+	return p
+}
+
+// snapshotStore falls back to a process-local store when none is configured.
+func (h *APIHandler) snapshotStore() store.Store {
+	if h.Store != nil {
+		return h.Store
+	}
+	h.localStoreOnce.Do(func() { h.localStore = store.NewMemory() })
+	return h.localStore
+}
+
+// pageSize reads ?limit=, clamped to maxPageSize.
+func pageSize(r *http.Request) (int, error) {
+	raw := r.URL.Query().Get("limit")
+	if raw == "" {
+		return defaultPageSize, nil
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n <= 0 {
+		return 0, validate.Errors{{Field: "limit", Message: "must be a positive integer"}}
+	}
+	return min(n, maxPageSize), nil
+}
+
+func (p messagePage) pagination() *pagination {
+	return &pagination{Total: &p.Total, Offset: p.Offset, Count: len(p.Messages), NextCursor: p.NextCursor}
+}
@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pachecoc/sqs-ui/internal/service"
+	"github.com/pachecoc/sqs-ui/internal/store"
+)
+
+func FuzzLoadSnapshot(f *testing.F) {
+	const id = "0123456789abcdef"
+	f.Add(id + ".0")
+	f.Add(id + ".2")
+	f.Add(id + ".3")
+	f.Add(id + ".99999999999999999999")
+	f.Add(id + ".-1")
+	f.Add(id + ".+1")
+	f.Add(id)
+	f.Add("gone.0")
+	f.Add("../" + id + ".0")
+	f.Add(".")
+	f.Add("")
+
+	ctx := context.Background()
+	h := NewAPIHandler(nil, discardLogger())
+	snap := receiveSnapshot{
+		ID:         id,
+		QueueName:  testQueue,
+		Mode:       service.ModeObserve,
+		ReceivedAt: time.Now(),
+		Messages:   []map[string]any{{"message_id": "1"}, {"message_id": "2"}, {"message_id": "3"}},
+	}
+	if err := store.PutJSON(ctx, h.snapshotStore(), categorySnapshots, id, snap, snapshotTTL); err != nil {
+		f.Fatal(err)
+	}
+
+	f.Fuzz(func(t *testing.T, cursor string) {
+		got, offset, err := h.loadSnapshot(ctx, cursor)
+		if err != nil {
+			return
+		}
+		if got.ID != id || offset < 0 {
+			t.Fatalf("cursor %q: loaded snapshot %q at offset %d", cursor, got.ID, offset)
+		}
+		for _, limit := range []int{1, defaultPageSize, maxPageSize} {
+			p := got.page(offset, limit)
+			if p.Total != len(snap.Messages) || p.Offset > p.Total || len(p.Messages) > limit {
+				t.Fatalf("cursor %q, limit %d: bad page %+v", cursor, limit, p)
+			}
+			if (p.NextCursor != "") != (p.Offset+len(p.Messages) < p.Total) {
+				t.Fatalf("cursor %q, limit %d: next cursor %q on page %+v", cursor, limit, p.NextCursor, p)
+			}
+		}
+	})
+}
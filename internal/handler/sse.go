@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// sseStream writes server-sent events to a single client.
+type sseStream struct {
+	w  http.ResponseWriter
+	rc *http.ResponseController
+}
+
+// startSSE switches the response into event-stream mode. Streams outlive the server's
+// WriteTimeout, so the per-connection write deadline is cleared.
+func startSSE(w http.ResponseWriter) (*sseStream, error) {
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return nil, err
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	s := &sseStream{w: w, rc: rc}
+	return s, rc.Flush()
+}
+
+// Send writes one named event with a JSON payload and flushes it.
+func (s *sseStream) Send(event string, v any) error {
+	return s.SendWithID("", event, v)
+}
+
+// SendWithID is Send with an event id, letting EventSource resume via Last-Event-ID.
+func (s *sseStream) SendWithID(id, event string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if id != "" {
+		if _, err := fmt.Fprintf(s.w, "id: %s\n", id); err != nil {
+			return err
+		}
+	}
+	if _, err := fmt.Fprintf(s.w, "event: %s\ndata: %s\n\n", event, data); err != nil {
+		return err
+	}
+	return s.rc.Flush()
+}
+
+// Shutdown tells the client the server is going away and, through the SSE retry field,
+// how long to wait before EventSource reconnects (to another replica or the restarted one).
+func (s *sseStream) Shutdown(reconnect time.Duration) error {
+	if _, err := fmt.Fprintf(s.w, "retry: %d\n", reconnect.Milliseconds()); err != nil {
+		return err
+	}
+	return s.Send("shutdown", map[string]any{
+		"reason":             "server shutting down",
+		"reconnect_after_ms": reconnect.Milliseconds(),
+	})
+}
+
+// Comment writes a keep-alive comment line so proxies don't close idle streams.
+func (s *sseStream) Comment(text string) error {
+	if _, err := fmt.Fprintf(s.w, ": %s\n\n", text); err != nil {
+		return err
+	}
+	return s.rc.Flush()
+}
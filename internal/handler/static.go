@@ -0,0 +1,183 @@
+//go:build !noui
+
+package handler
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/fs"
+	"log/slog"
+	"mime"
+	"net/http"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/pachecoc/sqs-ui/internal/version"
+)
+
+// extraMIMETypes covers extensions the platform MIME table often lacks or gets wrong.
+var extraMIMETypes = map[string]string{
+	".mjs":         "text/javascript; charset=utf-8",
+	".js":          "text/javascript; charset=utf-8",
+	".wasm":        "application/wasm",
+	".svg":         "image/svg+xml",
+	".webmanifest": "application/manifest+json",
+}
+
+// apiPrefixes are never answered with the SPA shell; unknown API paths must 404. Each
+// matches the path without its trailing slash and everything below it, so client-side
+// routes such as /information still reach the shell.
+var apiPrefixes = []string{"/api/", "/ui/", "/info/", "/healthz/", "/readyz/"}
+
+// assetRefPattern matches relative asset references in index.html that get a ?v= suffix.
+var assetRefPattern = regexp.MustCompile(`((?:src|href)=")((?:js|css|assets)/[^"?#]+)(")`)
+
+// StaticHandler serves the web UI without directory listings, hiding dotfiles and
+// falling back to index.html for unknown client-side routes.
+type StaticHandler struct {
+	FS  fs.FS
+	Log *slog.Logger
+}
+
+// NewStaticHandler creates a StaticHandler rooted at fsys. If no asset hash was
+// injected at build time, one is derived from the asset contents.
+func NewStaticHandler(fsys fs.FS, log *slog.Logger) *StaticHandler {
+	if version.AssetHash == "" {
+		hash, err := hashAssets(fsys)
+		if err != nil {
+			log.Warn("could not hash web assets, falling back to commit", "error", err)
+			hash = version.Commit
+		}
+		version.AssetHash = hash
+	}
+	log.Debug("web assets ready", "asset_hash", version.AssetHash)
+	return &StaticHandler{FS: fsys, Log: log}
+}
+
+func (s *StaticHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	urlPath := path.Clean("/" + r.URL.Path)
+	for _, prefix := range apiPrefixes {
+		if strings.HasPrefix(urlPath, prefix) || urlPath+"/" == prefix {
+			http.NotFound(w, r)
+			return
+		}
+	}
+
+	name := strings.TrimPrefix(urlPath, "/")
+	if name == "" {
+		name = "index.html"
+	}
+	if hasDotSegment(name) {
+		http.NotFound(w, r)
+		return
+	}
+
+	if s.serveFile(w, r, name) || s.serveFile(w, r, path.Join(name, "index.html")) {
+		return
+	}
+
+	// Paths that look like assets must not be masked by the SPA shell; share links
+	// (/q/.../orders.fifo) only look like one.
+	if path.Ext(name) != "" && !strings.HasPrefix(name, "q/") {
+		http.NotFound(w, r)
+		return
+	}
+	if !s.serveFile(w, r, "index.html") {
+		http.NotFound(w, r)
+	}
+}
+
+// serveFile writes the named regular file and reports whether it existed.
+func (s *StaticHandler) serveFile(w http.ResponseWriter, r *http.Request, name string) bool {
+	f, err := s.FS.Open(name)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			s.Log.WarnContext(r.Context(), "failed to open static file", "name", name, "error", err)
+		}
+		return false
+	}
+	defer f.Close()
+
+	stat, err := f.Stat()
+	if err != nil || stat.IsDir() {
+		return false
+	}
+
+	content, ok := f.(io.ReadSeeker)
+	if !ok {
+		s.Log.WarnContext(r.Context(), "static file is not seekable", "name", name)
+		return false
+	}
+
+	ext := strings.ToLower(path.Ext(name))
+	if ct, ok := extraMIMETypes[ext]; ok {
+		w.Header().Set("Content-Type", ct)
+	} else if ct := mime.TypeByExtension(ext); ct != "" {
+		w.Header().Set("Content-Type", ct)
+	}
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+
+	// The HTML shell is always revalidated; assets requested with the current
+	// version are immutable because any deploy changes their URL.
+	if ext == ".html" {
+		w.Header().Set("Cache-Control", "no-cache")
+		if raw, err := io.ReadAll(content); err == nil {
+			versioned := assetRefPattern.ReplaceAll(raw, []byte("${1}${2}?v="+version.AssetHash+"${3}"))
+			// Relative asset and API URLs resolve against <base>, which follows BASE_PATH
+			if base := basePathFrom(r.Context()); base != "" {
+				versioned = bytes.Replace(versioned, []byte(`<base href="/"`), []byte(`<base href="`+base+`/"`), 1)
+			}
+			http.ServeContent(w, r, name, stat.ModTime(), bytes.NewReader(versioned))
+			return true
+		}
+		_, _ = content.Seek(0, io.SeekStart)
+	} else if r.URL.Query().Get("v") == version.AssetHash {
+		w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	} else {
+		w.Header().Set("Cache-Control", "no-cache")
+	}
+
+	http.ServeContent(w, r, name, stat.ModTime(), content)
+	return true
+}
+
+// hashAssets returns a short content hash over every regular file in fsys.
+func hashAssets(fsys fs.FS) (string, error) {
+	h := sha256.New()
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return err
+		}
+		h.Write([]byte(p))
+		h.Write(data)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil))[:12], nil
+}
+
+// hasDotSegment reports whether any path segment is hidden (e.g. .git, .env).
+func hasDotSegment(name string) bool {
+	for _, seg := range strings.Split(name, "/") {
+		if strings.HasPrefix(seg, ".") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,59 @@
+//go:build !noui
+
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestStaticFallback(t *testing.T) {
+	s := &StaticHandler{
+		FS: fstest.MapFS{
+			"index.html":  {Data: []byte(`<html><base href="/"><script src="js/app.js"></script></html>`)},
+			"js/app.js":   {Data: []byte("main()")},
+			".env":        {Data: []byte("SECRET=1")},
+			"css/app.css": {Data: []byte("body{}")},
+		},
+		Log: discardLogger(),
+	}
+
+	tests := []struct {
+		path  string
+		code  int
+		shell bool
+	}{
+		{"/", http.StatusOK, true},
+		{"/queues/orders", http.StatusOK, true},
+		{"/infobox", http.StatusOK, true},
+		{"/information/settings", http.StatusOK, true},
+		{"/healthzone", http.StatusOK, true},
+		{"/q/abc/orders.fifo", http.StatusOK, true},
+		{"/js/app.js", http.StatusOK, false},
+		{"/js/missing.js", http.StatusNotFound, false},
+		{"/.env", http.StatusNotFound, false},
+		{"/css/", http.StatusOK, true},
+		{"/info", http.StatusNotFound, false},
+		{"/info/", http.StatusNotFound, false},
+		{"/info/extra", http.StatusNotFound, false},
+		{"/healthz", http.StatusNotFound, false},
+		{"/readyz/live", http.StatusNotFound, false},
+		{"/api", http.StatusNotFound, false},
+		{"/api/unknown", http.StatusNotFound, false},
+		{"/ui/messages", http.StatusNotFound, false},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if rec.Code != tt.code {
+			t.Errorf("%s: got %d, want %d", tt.path, rec.Code, tt.code)
+			continue
+		}
+		if shell := strings.Contains(rec.Body.String(), "<html>"); shell != tt.shell {
+			t.Errorf("%s: served the SPA shell %v, want %v", tt.path, shell, tt.shell)
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+)
+
+// handleStorage reports what the store holds per category (records, bytes, oldest record)
+// with each category's retention limits and the janitor's last sweep.
+func (h *APIHandler) handleStorage(w http.ResponseWriter, r *http.Request) {
+	if !enforceMethod(w, r, http.MethodGet) {
+		return
+	}
+	if h.Retention == nil {
+		respondError(w, http.StatusServiceUnavailable, errors.New("storage usage is not enabled"))
+		return
+	}
+	usage, err := h.Retention.Usage(r.Context())
+	if err != nil {
+		h.Log.ErrorContext(r.Context(), "failed to read storage usage", "error", err)
+		respondError(w, http.StatusInternalServerError, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, usage)
+}
@@ -0,0 +1,141 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pachecoc/sqs-ui/internal/rbac"
+	"github.com/pachecoc/sqs-ui/internal/service"
+)
+
+// tailKeepAlive is how often an idle message stream sends a keep-alive comment.
+const tailKeepAlive = 25 * time.Second
+
+// handleMessageStream pushes messages arriving on the queue as server-sent "message" events
+// until the client disconnects. A background goroutine long-polls the queue the stream was
+// opened on, peeking so consumers are barely delayed. Event ids are the newest SentTimestamp
+// (milliseconds) of each polled batch, so a client resuming with Last-Event-ID picks up from
+// the last batch it saw (possibly repeating messages sent in that same millisecond).
+// ?label=, ?filter= and ?transform= apply as they do to /api/messages. Receive failures are
+// sent as "error" events and retried. A queue that dead-letters after fewer than
+// service.TailMinReceiveCount receives is refused with 409 unless an operator passes
+// ?allow_redrive=true, since every poll counts as a receive.
+func (h *APIHandler) handleMessageStream(w http.ResponseWriter, r *http.Request) {
+	if !enforceMethod(w, r, http.MethodGet) {
+		return
+	}
+	svc := h.queueService(r.Context())
+
+	if err := svc.CheckTail(r.Context()); err != nil {
+		var risk *service.TailRedriveError
+		if !errors.As(err, &risk) || r.URL.Query().Get("allow_redrive") != "true" {
+			respondError(w, serviceErrorStatus(err), err)
+			return
+		}
+		// Dead-lettering messages is a change to the queue; viewers can't accept that risk
+		if !h.checkRole(w, r, rbac.Operator, "tailing a queue that dead-letters after few receives") {
+			return
+		}
+		h.Log.WarnContext(r.Context(), "tailing a queue with a low maxReceiveCount", "queue_name", svc.QueueName,
+			"max_receive_count", risk.Policy.MaxReceiveCount, "user", h.requestUser(r))
+	}
+
+	since := time.Now()
+	if ms, err := strconv.ParseInt(r.Header.Get("Last-Event-ID"), 10, 64); err == nil {
+		since = time.UnixMilli(ms)
+	}
+
+	stream, err := startSSE(w)
+	if err != nil {
+		h.Log.WarnContext(r.Context(), "failed to start message stream", "error", err)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	batches := make(chan []service.PipeMessage)
+	failures := make(chan error)
+	go func() {
+		_ = svc.Tail(ctx, since, func(msgs []service.PipeMessage) {
+			select {
+			case batches <- msgs:
+			case <-ctx.Done():
+			}
+		}, func(err error) {
+			select {
+			case failures <- err:
+			case <-ctx.Done():
+			}
+		})
+	}()
+
+	keepAlive := time.NewTicker(tailKeepAlive)
+	defer keepAlive.Stop()
+
+	h.Log.DebugContext(r.Context(), "message stream opened", "queue_name", svc.QueueName)
+	for {
+		select {
+		case <-r.Context().Done():
+			h.Log.DebugContext(r.Context(), "message stream closed by client", "queue_name", svc.QueueName)
+			return
+		case <-h.draining():
+			h.endStream(stream)
+			return
+		case err := <-failures:
+			h.Log.WarnContext(r.Context(), "message stream receive failed", "queue_name", svc.QueueName, "error", err)
+			if err := stream.Send("error", describeError(serviceErrorStatus(err), err)); err != nil {
+				return
+			}
+		case batch := <-batches:
+			if err := h.sendTailBatch(r, stream, batch); err != nil {
+				h.Log.DebugContext(r.Context(), "message stream closed", "error", err)
+				return
+			}
+		case <-keepAlive.C:
+			if err := stream.Comment("keep-alive"); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// sendTailBatch decorates a tailed batch like a listing and sends each message as an event.
+func (h *APIHandler) sendTailBatch(r *http.Request, stream *sseStream, batch []service.PipeMessage) error {
+	var newest int64
+	msgs := make([]map[string]any, 0, len(batch))
+	for _, m := range batch {
+		if ms, err := strconv.ParseInt(m.SentTimestamp, 10, 64); err == nil && ms > newest {
+			newest = ms
+		}
+		msg := map[string]any{
+			"message_id":     m.MessageID,
+			"body":           m.Body,
+			"sent_timestamp": m.SentTimestamp,
+		}
+		if len(m.Attributes) > 0 {
+			msg["message_attributes"] = m.Attributes
+		}
+		if m.MessageGroupID != "" {
+			msg["message_group_id"] = m.MessageGroupID
+		}
+		msgs = append(msgs, msg)
+	}
+	msgs = h.decorate(r.Context(), msgs, r.URL.Query().Get("label"))
+	msgs, err := h.runScripts(r, msgs)
+	if err != nil {
+		return stream.Send("error", describeError(http.StatusUnprocessableEntity, err))
+	}
+	id := ""
+	if newest > 0 {
+		id = strconv.FormatInt(newest, 10)
+	}
+	for _, m := range msgs {
+		if err := stream.SendWithID(id, "message", m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
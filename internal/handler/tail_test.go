@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+
+	"github.com/pachecoc/sqs-ui/internal/rbac"
+	"github.com/pachecoc/sqs-ui/internal/service"
+)
+
+func TestMessageStreamRefusesLowMaxReceiveCount(t *testing.T) {
+	mux, h, mem := newTestAPI(t, &rbac.Policy{Operators: []string{"olive"}, Default: rbac.Viewer})
+	ctx := context.Background()
+	if _, err := mem.CreateQueue(ctx, &sqs.CreateQueueInput{QueueName: aws.String("orders-dlq")}); err != nil {
+		t.Fatal(err)
+	}
+	arn := "arn:aws:sqs:" + mem.Region + ":" + mem.Account + ":orders-dlq"
+	_, err := mem.SetQueueAttributes(ctx, &sqs.SetQueueAttributesInput{
+		QueueUrl:   aws.String(h.getService().QueueURL),
+		Attributes: map[string]string{"RedrivePolicy": service.RedrivePolicyJSON(arn, 3)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec := do(mux, "olive", http.MethodGet, "/api/messages/stream", "")
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("stream on maxReceiveCount 3: got %d, want 409 (%s)", rec.Code, rec.Body)
+	}
+	var env struct {
+		Error struct{ Code, Hint string }
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &env); err != nil || env.Error.Code != "tail_redrive_risk" || env.Error.Hint == "" {
+		t.Errorf("refusal body %s", rec.Body)
+	}
+
+	if rec := do(mux, "victor", http.MethodGet, "/api/messages/stream?allow_redrive=true", ""); rec.Code != http.StatusForbidden {
+		t.Errorf("viewer overriding the refusal: got %d, want 403", rec.Code)
+	}
+
+	// An operator may tail anyway; the stream runs until the client goes away
+	streamCtx, cancel := context.WithTimeout(ctx, 200*time.Millisecond)
+	defer cancel()
+	req := httptest.NewRequest(http.MethodGet, "/api/messages/stream?allow_redrive=true", nil).WithContext(streamCtx)
+	req.Header.Set("X-User", "olive")
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/event-stream") {
+		t.Errorf("operator override: got %d %q, want an event stream (%s)", rec.Code, rec.Header().Get("Content-Type"), rec.Body)
+	}
+}
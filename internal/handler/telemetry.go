@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/pachecoc/sqs-ui/internal/telemetry"
+)
+
+// telemetryWriter lets respondError report the error code of a counted request.
+type telemetryWriter struct {
+	http.ResponseWriter
+	code string
+}
+
+// Unwrap lets http.ResponseController and metaFor reach the underlying writer.
+func (tw *telemetryWriter) Unwrap() http.ResponseWriter { return tw.ResponseWriter }
+
+// counted records each call to pattern, and the error code it answered with, for usage
+// telemetry. Only the registered pattern is kept, never the path or query.
+func (h *APIHandler) counted(pattern string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if h.Telemetry == nil {
+			next(w, r)
+			return
+		}
+		tw := &telemetryWriter{ResponseWriter: w}
+		next(tw, r)
+		h.Telemetry.CountRequest(r.Method, pattern)
+		h.Telemetry.CountError(tw.code)
+	}
+}
+
+// noteError hands the code of an error response to the request's telemetryWriter and
+// accessWriter, if any.
+func noteError(w http.ResponseWriter, code string) {
+	for {
+		switch v := w.(type) {
+		case *telemetryWriter:
+			v.code = code
+			w = v.Unwrap()
+		case *accessWriter:
+			v.code = code
+			return
+		case interface{ Unwrap() http.ResponseWriter }:
+			w = v.Unwrap()
+		default:
+			return
+		}
+	}
+}
+
+// telemetryStatus is the body of GET /api/telemetry.
+type telemetryStatus struct {
+	Enabled         bool              `json:"enabled"`
+	Endpoint        string            `json:"endpoint,omitempty"`
+	IntervalSeconds int               `json:"interval_seconds,omitempty"`
+	Next            *telemetry.Report `json:"next,omitempty"`
+}
+
+// handleTelemetry shows whether usage telemetry is enabled and, when it is, exactly what
+// the next report will contain.
+func (h *APIHandler) handleTelemetry(w http.ResponseWriter, r *http.Request) {
+	if !enforceMethod(w, r, http.MethodGet) {
+		return
+	}
+	if h.Telemetry == nil {
+		respondJSON(w, http.StatusOK, telemetryStatus{})
+		return
+	}
+	next := h.Telemetry.Preview()
+	respondJSON(w, http.StatusOK, telemetryStatus{
+		Enabled:         true,
+		Endpoint:        h.Telemetry.Endpoint,
+		IntervalSeconds: int(h.Telemetry.Interval.Seconds()),
+		Next:            &next,
+	})
+}
@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/pachecoc/sqs-ui/internal/triage"
+)
+
+// handleTriage lists triage items: GET ?queue=&status=&assignee=.
+func (h *APIHandler) handleTriage(w http.ResponseWriter, r *http.Request) {
+	if !enforceMethod(w, r, http.MethodGet) {
+		return
+	}
+	f, ok := h.triageFilter(w, r)
+	if !ok {
+		return
+	}
+
+	items, err := h.Triage.List(r.Context(), f)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]any{"items": items})
+}
+
+// handleTriageSummary counts the backlog by status and open items by assignee.
+func (h *APIHandler) handleTriageSummary(w http.ResponseWriter, r *http.Request) {
+	if !enforceMethod(w, r, http.MethodGet) {
+		return
+	}
+	f, ok := h.triageFilter(w, r)
+	if !ok {
+		return
+	}
+
+	sum, err := h.Triage.Summarize(r.Context(), f)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, sum)
+}
+
+// handleTriageItem reads (GET) or updates (PATCH { "assignee", "status", "by", "note", "queue_name" })
+// the triage state of one message.
+func (h *APIHandler) handleTriageItem(w http.ResponseWriter, r *http.Request) {
+	if h.Triage == nil {
+		respondError(w, http.StatusServiceUnavailable, errors.New("triage is not enabled"))
+		return
+	}
+	id := r.PathValue("id")
+
+	switch r.Method {
+	case http.MethodGet:
+		it, err := h.Triage.Get(r.Context(), id)
+		if errors.Is(err, triage.ErrNotFound) {
+			respondError(w, http.StatusNotFound, err)
+			return
+		}
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, err)
+			return
+		}
+		respondJSON(w, http.StatusOK, it)
+	case http.MethodPatch:
+		var u triage.Update
+		if err := json.NewDecoder(r.Body).Decode(&u); err != nil {
+			respondError(w, http.StatusBadRequest, err)
+			return
+		}
+		if u.QueueName == "" {
+			if svc := h.getService(); svc != nil {
+				u.QueueName = svc.QueueName
+			}
+		}
+		it, err := h.Triage.Apply(r.Context(), id, u)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, err)
+			return
+		}
+		h.Log.InfoContext(r.Context(), "triage updated", "message_id", id, "status", it.Status, "assignee", it.Assignee, "by", it.UpdatedBy)
+		respondJSON(w, http.StatusOK, it)
+	case http.MethodOptions:
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.Header().Set("Allow", "GET, PATCH")
+		respondError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+	}
+}
+
+// triageFilter parses list filters, writing an error response when they are invalid.
+func (h *APIHandler) triageFilter(w http.ResponseWriter, r *http.Request) (triage.Filter, bool) {
+	if h.Triage == nil {
+		respondError(w, http.StatusServiceUnavailable, errors.New("triage is not enabled"))
+		return triage.Filter{}, false
+	}
+
+	query := r.URL.Query()
+	f := triage.Filter{QueueName: query.Get("queue"), Assignee: query.Get("assignee")}
+	if s := query.Get("status"); s != "" {
+		status, err := triage.ParseStatus(s)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, err)
+			return triage.Filter{}, false
+		}
+		f.Status = status
+	}
+	return f, true
+}
@@ -0,0 +1,243 @@
+//go:build !noui
+
+package handler
+
+import (
+	"cmp"
+	"embed"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/pachecoc/sqs-ui/internal/annotations"
+	"github.com/pachecoc/sqs-ui/internal/plugin"
+	"github.com/pachecoc/sqs-ui/internal/service"
+	"github.com/pachecoc/sqs-ui/internal/validate"
+)
+
+//go:embed templates/*.tmpl
+var templatesFS embed.FS
+
+// UIHandler serves a server-rendered HTML fallback for environments where the JS UI cannot run.
+type UIHandler struct {
+	API   *APIHandler
+	Log   *slog.Logger
+	pages map[string]*template.Template
+}
+
+// uiPage is the data shared by all server-rendered pages.
+type uiPage struct {
+	Title    string
+	Error    string
+	Notice   string
+	Rows     []uiRow
+	Messages []uiMessage
+	Message  string
+	// Shared pages are opened without an account, so they don't link to the rest of the UI.
+	Shared bool
+}
+
+type uiRow struct {
+	Label string
+	Value any
+}
+
+type uiMessage struct {
+	MessageId string
+	Body      string
+	Labels    []string
+	Note      string
+}
+
+// NewUIHandler parses the embedded templates and creates a UIHandler sharing the API's queue state.
+func NewUIHandler(api *APIHandler, log *slog.Logger) *UIHandler {
+	pages := map[string]*template.Template{}
+	for _, name := range []string{"info", "messages", "send", "shared"} {
+		pages[name] = template.Must(template.ParseFS(templatesFS, "templates/layout.tmpl", "templates/"+name+".tmpl"))
+	}
+	return &UIHandler{API: api, Log: log, pages: pages}
+}
+
+// RegisterRoutes wires the HTML views under /ui/.
+func (u *UIHandler) RegisterRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/ui/", u.handleInfo)
+	mux.HandleFunc("/ui/messages", u.handleMessages)
+	mux.HandleFunc("/ui/send", u.handleSend)
+	mux.HandleFunc("/shared/{token}", u.handleShared)
+}
+
+// handleInfo renders queue attributes as a table.
+func (u *UIHandler) handleInfo(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/ui/" {
+		http.NotFound(w, r)
+		return
+	}
+	page := uiPage{Title: "Queue Info"}
+
+	svc := u.API.getService()
+	if svc == nil {
+		page.Error = "no SQS service configured"
+		u.render(w, "info", page)
+		return
+	}
+
+	info := svc.Info(r.Context())
+	if e, ok := info["error"].(string); ok && e != "" {
+		page.Error = e
+	}
+	for _, f := range []struct{ label, key string }{
+		{"Current Region", "current_region"},
+		{"Queue Name", "queue_name"},
+		{"Queue URL", "queue_url"},
+		{"Total Messages", "number_of_messages"},
+		{"Visible", "approximate_number_of_messages"},
+		{"In Flight", "approximate_number_of_messages_not_visible"},
+		{"Delayed", "approximate_number_of_messages_delayed"},
+		{"Status", "status"},
+	} {
+		v := info[f.key]
+		if v == nil || v == "" {
+			v = "-"
+		}
+		page.Rows = append(page.Rows, uiRow{Label: f.label, Value: v})
+	}
+	u.render(w, "info", page)
+}
+
+// handleMessages renders a receive (peek) of the queue.
+func (u *UIHandler) handleMessages(w http.ResponseWriter, r *http.Request) {
+	page := uiPage{Title: "Messages"}
+
+	svc := u.API.getService()
+	if svc == nil {
+		page.Error = "no SQS service configured"
+		u.render(w, "messages", page)
+		return
+	}
+	if err := svc.EnsureQueueConfigured(); err != nil {
+		page.Error = err.Error()
+		u.render(w, "messages", page)
+		return
+	}
+
+	mode, err := u.API.receiveMode(r.Context(), svc, svc.DefaultMode())
+	var msgs []map[string]any
+	if err == nil {
+		msgs, err = svc.Receive(r.Context(), mode)
+	}
+	if err != nil {
+		u.Log.ErrorContext(r.Context(), "failed to receive messages", "error", err)
+		page.Error = err.Error()
+	}
+	msgs = u.API.decorate(r.Context(), msgs, r.URL.Query().Get("label"))
+	for _, m := range msgs {
+		um := uiMessage{
+			MessageId: fmt.Sprint(m["message_id"]),
+			Body:      fmt.Sprint(m["body"]),
+		}
+		if a, ok := m["annotation"].(annotations.Annotation); ok {
+			um.Labels, um.Note = a.Labels, a.Note
+		}
+		page.Messages = append(page.Messages, um)
+	}
+	u.render(w, "messages", page)
+}
+
+// handleShared renders what a share link grants, for people without an account.
+func (u *UIHandler) handleShared(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	page := uiPage{Title: "Shared Messages", Shared: true}
+	view, status, err := u.API.openShare(r.Context(), r.PathValue("token"))
+	if err != nil {
+		page.Error = err.Error()
+		u.renderStatus(w, status, "shared", page)
+		return
+	}
+	page.Rows = []uiRow{
+		{Label: "Queue Name", Value: view.QueueName},
+		{Label: "Received At", Value: view.ReceivedAt.Format(time.RFC3339)},
+		{Label: "Shared By", Value: cmp.Or(view.SharedBy, "-")},
+		{Label: "Link Expires", Value: view.ExpiresAt.Format(time.RFC3339)},
+	}
+	if view.Counts != nil {
+		page.Rows = append(page.Rows,
+			uiRow{Label: "Visible", Value: view.Counts.Visible},
+			uiRow{Label: "In Flight", Value: view.Counts.NotVisible},
+			uiRow{Label: "Delayed", Value: view.Counts.Delayed})
+	}
+	if view.Note != "" {
+		page.Rows = append(page.Rows, uiRow{Label: "Note", Value: view.Note})
+	}
+	for _, m := range view.Messages {
+		page.Messages = append(page.Messages, uiMessage{MessageId: fmt.Sprint(m["message_id"]), Body: fmt.Sprint(m["body"])})
+	}
+	u.render(w, "shared", page)
+}
+
+// handleSend shows the send form (GET) and publishes the submitted message (POST).
+func (u *UIHandler) handleSend(w http.ResponseWriter, r *http.Request) {
+	page := uiPage{Title: "Send"}
+
+	switch r.Method {
+	case http.MethodGet:
+		u.render(w, "send", page)
+		return
+	case http.MethodPost:
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	msg := r.PostFormValue("message")
+	svc := u.API.getService()
+	var v validate.Validator
+	if v.Required("message", msg) {
+		v.MaxBytes("message", msg, validate.MaxMessageBytes)
+	}
+	switch {
+	case v.Err() != nil:
+		page.Error = v.Err().Error()
+	case svc == nil:
+		page.Error = "no SQS service configured"
+	default:
+		if err := svc.EnsureQueueConfigured(); err != nil {
+			page.Error = err.Error()
+		} else if err := plugin.Validate(r.Context(), plugin.Message{QueueName: svc.QueueName, Body: msg}); err != nil {
+			page.Error = err.Error()
+		} else if res, err := svc.Send(r.Context(), msg, service.SendOptions{
+			Provenance: &service.Provenance{SentBy: u.API.requestUser(r), RequestID: requestID(r.Context())},
+		}); err != nil {
+			u.Log.ErrorContext(r.Context(), "failed to send message", "error", err)
+			page.Error = err.Error()
+		} else {
+			page.Notice = "message sent successfully (MessageId " + res.MessageID + ")"
+		}
+	}
+	status := http.StatusOK
+	if page.Error != "" {
+		// Keep the draft so the user can retry
+		page.Message = msg
+		status = http.StatusBadRequest
+	}
+	u.renderStatus(w, status, "send", page)
+}
+
+func (u *UIHandler) render(w http.ResponseWriter, name string, page uiPage) {
+	u.renderStatus(w, http.StatusOK, name, page)
+}
+
+func (u *UIHandler) renderStatus(w http.ResponseWriter, status int, name string, page uiPage) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	if err := u.pages[name].ExecuteTemplate(w, "layout", page); err != nil {
+		u.Log.Error("failed to render page", "page", name, "error", err)
+	}
+}
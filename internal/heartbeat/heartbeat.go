@@ -0,0 +1,143 @@
+// Package heartbeat pings an external dead-man switch (healthchecks.io, Dead Man's Snitch,
+// Cronitor, an Uptime Kuma push monitor) while the server is healthy. When SQS can't be
+// reached or a background subsystem is failing, the ping is withheld, so the monitor alerts
+// once it stops hearing from sqs-ui, including when the process or its host is gone.
+package heartbeat
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pachecoc/sqs-ui/internal/lifecycle"
+	"github.com/pachecoc/sqs-ui/internal/service"
+	"github.com/pachecoc/sqs-ui/internal/version"
+)
+
+// Name is the subsystem the heartbeat runs as. Its own failed pings don't count against
+// the health it reports, or one outage of the monitor would keep it failing.
+const Name = "heartbeat"
+
+// pingTimeout bounds one ping, including the health check before it.
+const pingTimeout = 10 * time.Second
+
+// Publisher pings URL every Interval while the server is healthy.
+type Publisher struct {
+	URL string
+	// FailURL, when set, is sent the reason instead when the server is unhealthy (e.g.
+	// healthchecks.io's <url>/fail), so the monitor alerts at once rather than after its
+	// grace period.
+	FailURL  string
+	Interval time.Duration
+	// Service returns the active queue's service; reaching SQS through it is the
+	// connectivity check.
+	Service func() *service.SQSService
+	// Subsystems are the background subsystems that must be healthy (optional).
+	Subsystems *lifecycle.Group
+	Client     *http.Client
+	Log        *slog.Logger
+}
+
+// Run pings now and then every Interval until ctx is canceled.
+func (p *Publisher) Run(ctx context.Context) {
+	p.Log.Info("heartbeat started", "interval_seconds", p.Interval.Seconds(), "report_failures", p.FailURL != "")
+	ticker := time.NewTicker(p.Interval)
+	defer ticker.Stop()
+	for {
+		lifecycle.Report(ctx, p.Beat(ctx))
+		select {
+		case <-ctx.Done():
+			p.Log.Info("heartbeat stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Beat checks health and pings URL (or FailURL with the reason). It returns an error only
+// when the ping itself could not be delivered.
+func (p *Publisher) Beat(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, pingTimeout)
+	defer cancel()
+	if err := p.Check(ctx); err != nil {
+		p.Log.Warn("server unhealthy, heartbeat withheld", "error", err, "report_failure", p.FailURL != "")
+		if p.FailURL == "" {
+			return nil
+		}
+		return p.ping(ctx, p.FailURL, err.Error())
+	}
+	return p.ping(ctx, p.URL, "ok")
+}
+
+// Check reports why the server is unhealthy: SQS unreachable with the configured
+// credentials, or a background subsystem failed or failing.
+func (p *Publisher) Check(ctx context.Context) error {
+	var problems []string
+	for _, s := range p.Subsystems.Status() {
+		if s.Name == Name || s.Healthy() {
+			continue
+		}
+		if s.Failed {
+			problems = append(problems, fmt.Sprintf("subsystem %s failed", s.Name))
+		} else {
+			problems = append(problems, fmt.Sprintf("subsystem %s failing: %s", s.Name, s.LastError))
+		}
+	}
+	if err := p.checkSQS(ctx); err != nil {
+		problems = append(problems, "SQS unreachable: "+err.Error())
+	}
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// checkSQS reads the active queue's counts, or lists one queue when none is configured, so
+// it needs no more than the UI's own permissions.
+func (p *Publisher) checkSQS(ctx context.Context) error {
+	svc := p.Service()
+	if svc == nil {
+		return errors.New("service unavailable")
+	}
+	var err error
+	if svc.QueueURL != "" {
+		_, err = svc.Counts(ctx)
+	} else {
+		_, err = svc.ListQueues(ctx, "", 1, "")
+	}
+	return service.TranslateAWSError(err)
+}
+
+func (p *Publisher) ping(ctx context.Context, target, body string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, strings.NewReader(body))
+	if err != nil {
+		return errors.New("heartbeat URL is not a valid URL")
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	req.Header.Set("User-Agent", "sqs-ui/"+version.Version)
+	client := p.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		// The URL carries the check's secret; keep it out of logs
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("heartbeat ping failed: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("heartbeat ping answered %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,219 @@
+package jobs
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pachecoc/sqs-ui/internal/service"
+)
+
+// TypeCleanup deletes whole queues (not messages) matching params.prefix, optional
+// params.tags and params.older_than_days, e.g. per-PR test queues left behind by CI.
+const TypeCleanup = "cleanup"
+
+// minCleanupPrefix keeps a cleanup from sweeping most of an account by accident.
+const minCleanupPrefix = 3
+
+// AccountWide reports whether a job kind works on the account's queues rather than the
+// active queue, so it needs no active queue and the active queue's lock doesn't apply.
+func AccountWide(kind string) bool {
+	return kind == TypeCleanup
+}
+
+// RegisterCleanup registers the cleanup job kind.
+func RegisterCleanup(m *Manager) {
+	m.Register(TypeCleanup, cleanupJob)
+}
+
+// cleanupCandidate is one queue matched by a cleanup.
+type cleanupCandidate struct {
+	Name      string            `json:"name"`
+	URL       string            `json:"url"`
+	CreatedAt time.Time         `json:"created_at"`
+	Tags      map[string]string `json:"tags,omitempty"`
+	Deleted   bool              `json:"deleted,omitempty"`
+	Error     string            `json:"error,omitempty"`
+}
+
+// cleanupJob lists the queues named params.prefix* that carry every params.tags tag and were
+// created more than params.older_than_days ago; the active queue is never included.
+// params.limit caps how many are deleted. With params.dry_run it only lists them along with
+// a confirmation token; a real run needs that token as params.confirm and refuses to run
+// when the matching queues changed since.
+func cleanupJob(ctx context.Context, svc *service.SQSService, params map[string]any) (Result, error) {
+	prefix, _ := params["prefix"].(string)
+	if len(prefix) < minCleanupPrefix {
+		return Result{}, fmt.Errorf("prefix must be at least %d characters", minCleanupPrefix)
+	}
+	days, err := intParam(params, "older_than_days")
+	if err != nil {
+		return Result{}, err
+	}
+	if days < 1 {
+		return Result{}, errors.New("older_than_days must be at least 1")
+	}
+	tags, err := tagsParam(params, "tags")
+	if err != nil {
+		return Result{}, err
+	}
+	limit, err := intParam(params, "limit")
+	if err != nil {
+		return Result{}, err
+	}
+	dryRun, _ := params["dry_run"].(bool)
+	confirm, _ := params["confirm"].(string)
+	if !dryRun && confirm == "" {
+		return Result{}, errors.New("confirm is required: run a dry run first and pass its confirm token")
+	}
+
+	started := time.Now().UTC()
+	cutoff := started.AddDate(0, 0, -days)
+	matched, err := cleanupCandidates(ctx, svc, prefix, tags, cutoff)
+	if err != nil {
+		return Result{}, err
+	}
+	if limit > 0 && len(matched) > limit {
+		matched = matched[:limit]
+	}
+	token := cleanupToken(matched)
+
+	report := map[string]any{
+		"prefix":          prefix,
+		"tags":            tags,
+		"older_than_days": days,
+		"created_before":  cutoff,
+		"limit":           limit,
+		"dry_run":         dryRun,
+		"confirm":         token,
+		"queues":          matched,
+		"started_at":      started,
+	}
+	summary := map[string]any{"matched": len(matched), "dry_run": dryRun}
+	if dryRun {
+		summary["confirm"] = token
+	} else {
+		if confirm != token {
+			return Result{}, fmt.Errorf("the matching queues changed since the dry run (%d now match); run a new dry run and confirm again", len(matched))
+		}
+		deleted := 0
+		for i := range matched {
+			if err := ctx.Err(); err != nil {
+				break
+			}
+			if err := svc.DeleteQueue(ctx, matched[i].URL); err != nil {
+				matched[i].Error = service.TranslateAWSError(err).Error()
+				continue
+			}
+			matched[i].Deleted = true
+			deleted++
+		}
+		summary["deleted"] = deleted
+		summary["failed"] = len(matched) - deleted
+		err = ctx.Err()
+	}
+	report["finished_at"] = time.Now().UTC()
+	if err != nil {
+		report["error"] = err.Error()
+	}
+
+	artifact, _ := json.MarshalIndent(report, "", "  ")
+	kind := "cleanup-report"
+	if dryRun {
+		kind = "cleanup-plan"
+	}
+	return Result{
+		Summary:     summary,
+		Artifact:    artifact,
+		ContentType: "application/json",
+		Filename:    artifactName(prefix, kind, "json"),
+	}, err
+}
+
+// cleanupCandidates pages through the queues named prefix* and keeps those created before
+// cutoff that carry all tags, oldest first.
+func cleanupCandidates(ctx context.Context, svc *service.SQSService, prefix string, tags map[string]string, cutoff time.Time) ([]cleanupCandidate, error) {
+	out := []cleanupCandidate{}
+	token := ""
+	for {
+		page, err := svc.ListQueues(ctx, prefix, 1000, token)
+		if err != nil {
+			return nil, err
+		}
+		for _, q := range page.Queues {
+			if q.URL == svc.QueueURL || (svc.QueueURL == "" && q.Name == svc.QueueName) {
+				continue
+			}
+			created, err := svc.QueueCreatedAt(ctx, q.URL)
+			if err != nil {
+				return nil, err
+			}
+			if !created.Before(cutoff) {
+				continue
+			}
+			c := cleanupCandidate{Name: q.Name, URL: q.URL, CreatedAt: created}
+			if len(tags) > 0 {
+				if c.Tags, err = svc.QueueTags(ctx, q.URL); err != nil {
+					return nil, err
+				}
+				if !hasTags(c.Tags, tags) {
+					continue
+				}
+			}
+			out = append(out, c)
+		}
+		if token = page.NextToken; token == "" {
+			break
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out, nil
+}
+
+func hasTags(have, want map[string]string) bool {
+	for k, v := range want {
+		if got, ok := have[k]; !ok || (v != "" && got != v) {
+			return false
+		}
+	}
+	return true
+}
+
+// cleanupToken identifies a set of queues, so a confirmed run deletes exactly what its dry
+// run listed.
+func cleanupToken(queues []cleanupCandidate) string {
+	urls := make([]string, len(queues))
+	for i, q := range queues {
+		urls[i] = q.URL
+	}
+	sort.Strings(urls)
+	sum := sha256.Sum256([]byte(strings.Join(urls, "\n")))
+	return hex.EncodeToString(sum[:8])
+}
+
+// tagsParam reads an optional object of string tag values ("" matches any value).
+func tagsParam(params map[string]any, key string) (map[string]string, error) {
+	raw, ok := params[key]
+	if !ok || raw == nil {
+		return nil, nil
+	}
+	obj, ok := raw.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%s must be an object of strings", key)
+	}
+	out := make(map[string]string, len(obj))
+	for k, v := range obj {
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s must be an object of strings", key)
+		}
+		out[k] = s
+	}
+	return out, nil
+}
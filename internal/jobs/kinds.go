@@ -0,0 +1,206 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/pachecoc/sqs-ui/internal/service"
+)
+
+// Built-in job kinds.
+const (
+	TypeExport      = "export"
+	TypeDrain       = "drain"
+	TypeReconcile   = "reconcile"
+	TypeDrainGroups = "drain_groups"
+)
+
+// Destructive reports whether a job kind deletes messages from a queue.
+func Destructive(kind string) bool {
+	switch kind {
+	case TypeDrain, TypeDrainGroups, TypeMove, TypeReplay:
+		return true
+	default:
+		return false
+	}
+}
+
+// RegisterDefaults registers the built-in job kinds on m.
+func RegisterDefaults(m *Manager) {
+	m.Register(TypeExport, exportJob)
+	m.Register(TypeDrain, drainJob)
+	m.Register(TypeReconcile, reconcileJob)
+	m.Register(TypeDrainGroups, drainGroupsJob)
+}
+
+// exportJob snapshots the currently receivable messages into an NDJSON artifact without deleting them.
+// When canceled mid-listing (e.g. on shutdown) the messages read so far are still exported.
+func exportJob(ctx context.Context, svc *service.SQSService, _ map[string]any) (Result, error) {
+	msgs, err := svc.Receive(ctx, service.ModeObserve)
+	partial := errors.Is(err, service.ErrPartial)
+	if err != nil && !partial {
+		return Result{}, err
+	}
+
+	var artifact []byte
+	for _, m := range msgs {
+		line, err := json.Marshal(m)
+		if err != nil {
+			return Result{}, err
+		}
+		artifact = append(append(artifact, line...), '\n')
+	}
+	summary := map[string]any{"count": len(msgs)}
+	if partial {
+		summary["partial"] = true
+	}
+	return Result{
+		Summary:     summary,
+		Artifact:    artifact,
+		ContentType: "application/x-ndjson",
+		Filename:    artifactName(svc.QueueName, "export", "ndjson"),
+	}, err
+}
+
+// drainJob deletes messages one batch at a time; params.limit caps the total.
+// The artifact is a JSON drain report, or the plan when params.dry_run is set.
+func drainJob(ctx context.Context, svc *service.SQSService, params map[string]any) (Result, error) {
+	limit, err := intParam(params, "limit")
+	if err != nil {
+		return Result{}, err
+	}
+	if dryRun, _ := params["dry_run"].(bool); dryRun {
+		plan, err := svc.PlanQueue(ctx, TypeDrain, limit, false)
+		if err != nil {
+			return Result{}, err
+		}
+		artifact, _ := json.MarshalIndent(plan, "", "  ")
+		return Result{
+			Summary:     map[string]any{"dry_run": true, "count": plan.Count},
+			Artifact:    artifact,
+			ContentType: "application/json",
+			Filename:    artifactName(svc.QueueName, "drain-plan", "json"),
+		}, nil
+	}
+	started := time.Now().UTC()
+	deleted, err := svc.Drain(ctx, limit)
+	summary := map[string]any{"deleted": deleted}
+
+	report := map[string]any{
+		"queue_name":  svc.QueueName,
+		"queue_url":   svc.QueueURL,
+		"limit":       limit,
+		"deleted":     deleted,
+		"started_at":  started,
+		"finished_at": time.Now().UTC(),
+	}
+	if err != nil {
+		report["error"] = err.Error()
+	}
+	artifact, _ := json.MarshalIndent(report, "", "  ")
+	return Result{
+		Summary:     summary,
+		Artifact:    artifact,
+		ContentType: "application/json",
+		Filename:    artifactName(svc.QueueName, "drain-report", "json"),
+	}, err
+}
+
+// drainGroupsJob deletes only the messages of params.groups (MessageGroupIds) from a FIFO queue;
+// params.limit caps the total. With params.dry_run matches are counted and sampled, not deleted.
+func drainGroupsJob(ctx context.Context, svc *service.SQSService, params map[string]any) (Result, error) {
+	limit, err := intParam(params, "limit")
+	if err != nil {
+		return Result{}, err
+	}
+	groups, err := stringsParam(params, "groups")
+	if err != nil {
+		return Result{}, err
+	}
+	dryRun, _ := params["dry_run"].(bool)
+
+	started := time.Now().UTC()
+	res, err := svc.DrainGroups(ctx, groups, limit, dryRun)
+	report := map[string]any{
+		"queue_name":  svc.QueueName,
+		"groups":      groups,
+		"limit":       limit,
+		"dry_run":     dryRun,
+		"result":      res,
+		"started_at":  started,
+		"finished_at": time.Now().UTC(),
+	}
+	if err != nil {
+		report["error"] = err.Error()
+	}
+	artifact, _ := json.MarshalIndent(report, "", "  ")
+	return Result{
+		Summary:     map[string]any{"deleted": res.Deleted, "matched": res.Matched, "other": res.Other, "dry_run": dryRun},
+		Artifact:    artifact,
+		ContentType: "application/json",
+		Filename:    artifactName(svc.QueueName, "group-drain-report", "json"),
+	}, err
+}
+
+// reconcileJob compares reported counts with a full non-destructive peek; params.limit caps
+// how many messages are peeked. The artifact is the JSON reconciliation report.
+func reconcileJob(ctx context.Context, svc *service.SQSService, params map[string]any) (Result, error) {
+	limit, err := intParam(params, "limit")
+	if err != nil {
+		return Result{}, err
+	}
+	rec, err := svc.Reconcile(ctx, limit)
+	if err != nil {
+		return Result{}, err
+	}
+
+	artifact, _ := json.MarshalIndent(rec, "", "  ")
+	return Result{
+		Summary: map[string]any{
+			"reported_visible": rec.Before.Visible,
+			"receivable":       rec.Receivable,
+			"not_visible":      rec.Before.NotVisible,
+			"truncated":        rec.Truncated,
+		},
+		Artifact:    artifact,
+		ContentType: "application/json",
+		Filename:    artifactName(svc.QueueName, "reconcile-report", "json"),
+	}, nil
+}
+
+func artifactName(queue, kind, ext string) string {
+	return fmt.Sprintf("%s-%s-%s.%s", queue, kind, time.Now().UTC().Format("20060102T150405Z"), ext)
+}
+
+// stringsParam reads a list of non-empty strings.
+func stringsParam(params map[string]any, key string) ([]string, error) {
+	raw, ok := params[key].([]any)
+	if !ok {
+		return nil, fmt.Errorf("%s must be a list of strings", key)
+	}
+	out := make([]string, 0, len(raw))
+	for _, v := range raw {
+		s, ok := v.(string)
+		if !ok || s == "" {
+			return nil, fmt.Errorf("%s must be a list of strings", key)
+		}
+		out = append(out, s)
+	}
+	return out, nil
+}
+
+// intParam reads a non-negative integer param (JSON numbers decode as float64).
+func intParam(params map[string]any, key string) (int, error) {
+	v, ok := params[key]
+	if !ok || v == nil {
+		return 0, nil
+	}
+	f, ok := v.(float64)
+	if !ok || f < 0 || f != float64(int(f)) {
+		return 0, fmt.Errorf("%s must be a non-negative integer", key)
+	}
+	return int(f), nil
+}
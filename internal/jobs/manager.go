@@ -0,0 +1,565 @@
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pachecoc/sqs-ui/internal/events"
+	"github.com/pachecoc/sqs-ui/internal/service"
+	"github.com/pachecoc/sqs-ui/internal/store"
+)
+
+// Store categories used for retained job state.
+const (
+	categoryJobs      = "jobs"
+	categoryArtifacts = "artifacts"
+)
+
+// Job states.
+const (
+	StatusQueued    = "queued"
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+	StatusCanceled  = "canceled"
+)
+
+// ErrUnknownType is returned when submitting a job kind that was never registered.
+var ErrUnknownType = errors.New("unknown job type")
+
+// ErrNotFound is returned for unknown job ids.
+var ErrNotFound = errors.New("job not found")
+
+// ErrActive is returned when forgetting a job that is still queued or running.
+var ErrActive = errors.New("job is still active")
+
+// Result is what a job produces: a small summary shown in listings plus an optional
+// downloadable artifact (export file, report, ...).
+type Result struct {
+	Summary     map[string]any
+	Artifact    []byte
+	ContentType string
+	Filename    string
+}
+
+// Func executes one job against the queue captured at submit time.
+type Func func(ctx context.Context, svc *service.SQSService, params map[string]any) (Result, error)
+
+// Artifact is a retained job output ready for download.
+type Artifact struct {
+	ContentType string `json:"content_type"`
+	Filename    string `json:"filename"`
+	Data        []byte `json:"data"`
+}
+
+// Job is the externally visible state of a background job.
+type Job struct {
+	ID            string         `json:"id"`
+	Type          string         `json:"type"`
+	QueueName     string         `json:"queue_name"`
+	Status        string         `json:"status"`
+	Params        map[string]any `json:"params,omitempty"`
+	Result        map[string]any `json:"result,omitempty"`
+	Error         string         `json:"error,omitempty"`
+	QueuePosition int            `json:"queue_position,omitempty"`
+	HasArtifact   bool           `json:"has_artifact"`
+	CreatedAt     time.Time      `json:"created_at"`
+	StartedAt     *time.Time     `json:"started_at,omitempty"`
+	FinishedAt    *time.Time     `json:"finished_at,omitempty"`
+	ExpiresAt     *time.Time     `json:"expires_at,omitempty"`
+}
+
+// entry is the manager's private bookkeeping for a job.
+type entry struct {
+	job    Job
+	svc    *service.SQSService
+	ctx    context.Context
+	cancel context.CancelFunc
+	slot   string // cross-replica lease held while running
+}
+
+// Manager runs jobs on a fixed worker pool, limiting how many jobs touch the same
+// queue at once. Jobs over either limit wait in FIFO order.
+type Manager struct {
+	Workers  int
+	PerQueue int
+	Events   *events.Hub
+	Log      *slog.Logger
+
+	// Store retains finished jobs and artifacts for ResultTTL (optional).
+	Store     store.Store
+	ResultTTL time.Duration
+
+	// Leaser, when set, enforces PerQueue across replicas sharing the store:
+	// a job only starts once it holds one of the queue's slot leases.
+	Leaser   store.Leaser
+	Owner    string
+	LeaseTTL time.Duration
+
+	mu      sync.Mutex
+	cond    *sync.Cond
+	funcs   map[string]Func
+	jobs    map[string]*entry
+	pending []*entry
+	running map[string]int
+	closed  bool
+	done    <-chan struct{} // Run's ctx.Done, to tell shutdown from a user cancel
+}
+
+// NewManager creates a Manager; call Run to start the workers.
+func NewManager(workers, perQueue int, hub *events.Hub, log *slog.Logger) *Manager {
+	if workers <= 0 {
+		workers = 1
+	}
+	if perQueue <= 0 {
+		perQueue = 1
+	}
+	m := &Manager{
+		Workers:  workers,
+		PerQueue: perQueue,
+		Events:   hub,
+		Log:      log,
+		funcs:    map[string]Func{},
+		jobs:     map[string]*entry{},
+		running:  map[string]int{},
+	}
+	m.cond = sync.NewCond(&m.mu)
+	return m
+}
+
+// Register makes a job kind available for submission.
+func (m *Manager) Register(kind string, fn Func) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.funcs[kind] = fn
+}
+
+// Types lists the registered job kinds.
+func (m *Manager) Types() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	kinds := make([]string, 0, len(m.funcs))
+	for k := range m.funcs {
+		kinds = append(kinds, k)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+// Submit queues a job for svc's queue.
+func (m *Manager) Submit(kind string, svc *service.SQSService, params map[string]any) (Job, error) {
+	m.mu.Lock()
+	_, ok := m.funcs[kind]
+	m.mu.Unlock()
+	if !ok {
+		return Job{}, fmt.Errorf("%w: %s", ErrUnknownType, kind)
+	}
+
+	e := &entry{
+		job: Job{
+			ID:        newID(),
+			Type:      kind,
+			QueueName: svc.QueueName,
+			Status:    StatusQueued,
+			Params:    params,
+			CreatedAt: time.Now().UTC(),
+		},
+		svc: svc,
+	}
+	// Persist before a worker can pick it up so the queued record never overwrites a later state
+	m.persist(e.job)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.jobs[e.job.ID] = e
+	m.pending = append(m.pending, e)
+	m.cond.Signal()
+
+	m.Log.Info("job queued", "job_id", e.job.ID, "type", kind, "queue_name", svc.QueueName, "pending", len(m.pending))
+	return m.snapshot(e), nil
+}
+
+// Get returns a job by id, falling back to retained jobs from earlier runs.
+func (m *Manager) Get(ctx context.Context, id string) (Job, error) {
+	m.mu.Lock()
+	e, ok := m.jobs[id]
+	if ok {
+		job := m.snapshot(e)
+		m.mu.Unlock()
+		return job, nil
+	}
+	m.mu.Unlock()
+
+	if m.Store == nil {
+		return Job{}, ErrNotFound
+	}
+	var job Job
+	if err := store.GetJSON(ctx, m.Store, categoryJobs, id, &job); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return Job{}, ErrNotFound
+		}
+		return Job{}, err
+	}
+	return job, nil
+}
+
+// List returns active and retained jobs, newest first. Finished jobs past their
+// retention are dropped from memory here.
+func (m *Manager) List(ctx context.Context) []Job {
+	m.mu.Lock()
+	now := time.Now()
+	seen := map[string]bool{}
+	out := make([]Job, 0, len(m.jobs))
+	for id, e := range m.jobs {
+		if e.job.ExpiresAt != nil && now.After(*e.job.ExpiresAt) {
+			delete(m.jobs, id)
+			continue
+		}
+		seen[id] = true
+		out = append(out, m.snapshot(e))
+	}
+	m.mu.Unlock()
+
+	if m.Store != nil {
+		ids, err := m.Store.List(ctx, categoryJobs)
+		if err != nil {
+			m.Log.Warn("failed to list retained jobs", "error", err)
+		}
+		for _, id := range ids {
+			if seen[id] {
+				continue
+			}
+			var job Job
+			if err := store.GetJSON(ctx, m.Store, categoryJobs, id, &job); err == nil {
+				out = append(out, job)
+			}
+		}
+	}
+
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	return out
+}
+
+// Artifact loads the retained artifact of a finished job.
+func (m *Manager) Artifact(ctx context.Context, id string) (Artifact, error) {
+	var a Artifact
+	if m.Store == nil {
+		return a, ErrNotFound
+	}
+	if err := store.GetJSON(ctx, m.Store, categoryArtifacts, id, &a); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return a, ErrNotFound
+		}
+		return a, err
+	}
+	return a, nil
+}
+
+// Forget drops a finished job from memory and deletes its retained record and artifact.
+func (m *Manager) Forget(ctx context.Context, id string) error {
+	m.mu.Lock()
+	if e, ok := m.jobs[id]; ok {
+		if e.job.Status == StatusQueued || e.job.Status == StatusRunning {
+			m.mu.Unlock()
+			return ErrActive
+		}
+		delete(m.jobs, id)
+	}
+	m.mu.Unlock()
+
+	if m.Store == nil {
+		return nil
+	}
+	if err := m.Store.Delete(ctx, categoryArtifacts, id); err != nil {
+		return err
+	}
+	return m.Store.Delete(ctx, categoryJobs, id)
+}
+
+// Cancel stops a queued or running job.
+func (m *Manager) Cancel(id string) (Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.jobs[id]
+	if !ok {
+		return Job{}, ErrNotFound
+	}
+	switch e.job.Status {
+	case StatusQueued:
+		m.removePending(e)
+		now := time.Now().UTC()
+		e.job.Status = StatusCanceled
+		e.job.FinishedAt = &now
+	case StatusRunning:
+		// The worker records the final status once the job returns
+		e.cancel()
+	}
+	return m.snapshot(e), nil
+}
+
+// Run starts the worker pool and blocks until ctx is canceled and workers exit. Running jobs
+// see ctx canceled too; Run returns once they have wrapped up and their results are retained.
+func (m *Manager) Run(ctx context.Context) {
+	m.Log.Info("job workers started", "workers", m.Workers, "per_queue", m.PerQueue)
+	m.mu.Lock()
+	m.done = ctx.Done()
+	m.mu.Unlock()
+
+	go func() {
+		// Slots held by other replicas free up without a local signal, so re-check periodically
+		var recheck <-chan time.Time
+		if m.Leaser != nil {
+			t := time.NewTicker(2 * time.Second)
+			defer t.Stop()
+			recheck = t.C
+		}
+		for {
+			select {
+			case <-ctx.Done():
+				m.mu.Lock()
+				m.closed = true
+				m.cond.Broadcast()
+				m.mu.Unlock()
+				return
+			case <-recheck:
+				m.cond.Broadcast()
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < m.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			m.worker(ctx)
+		}()
+	}
+	wg.Wait()
+	m.Log.Info("job workers stopped")
+}
+
+func (m *Manager) worker(ctx context.Context) {
+	for {
+		e, fn, ok := m.next(ctx)
+		if !ok {
+			return
+		}
+		m.execute(e, fn)
+	}
+}
+
+// next blocks until a pending job is eligible under the per-queue limit.
+func (m *Manager) next(ctx context.Context) (*entry, Func, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for {
+		if m.closed {
+			return nil, nil, false
+		}
+		for _, e := range m.pending {
+			if m.running[e.job.QueueName] >= m.PerQueue {
+				continue
+			}
+			if m.Leaser != nil {
+				if e.slot = m.acquireSlot(ctx, e.job.QueueName); e.slot == "" {
+					continue
+				}
+			}
+			m.removePending(e)
+			m.running[e.job.QueueName]++
+
+			e.ctx, e.cancel = context.WithCancel(ctx)
+			now := time.Now().UTC()
+			e.job.Status = StatusRunning
+			e.job.StartedAt = &now
+			return e, m.funcs[e.job.Type], true
+		}
+		m.cond.Wait()
+	}
+}
+
+func (m *Manager) execute(e *entry, fn Func) {
+	m.Log.Info("job started", "job_id", e.job.ID, "type", e.job.Type, "queue_name", e.job.QueueName)
+	m.mu.Lock()
+	started := m.snapshot(e)
+	m.mu.Unlock()
+	m.persist(started)
+	if e.slot != "" {
+		stopRenew := m.renewSlot(e)
+		defer stopRenew()
+	}
+	result, err := fn(e.ctx, e.svc, e.job.Params)
+
+	m.mu.Lock()
+	e.cancel()
+	m.running[e.job.QueueName]--
+	now := time.Now().UTC()
+	e.job.FinishedAt = &now
+	e.job.Result = result.Summary
+	e.job.HasArtifact = len(result.Artifact) > 0 && m.Store != nil
+	if m.ResultTTL > 0 {
+		expires := now.Add(m.ResultTTL)
+		e.job.ExpiresAt = &expires
+	}
+	switch {
+	case err != nil && errors.Is(err, context.Canceled) && m.stopping():
+		// Jobs return what they got through on cancellation; keep it as the checkpoint
+		e.job.Status = StatusCanceled
+		e.job.Error = "interrupted by server shutdown: " + err.Error()
+	case err != nil && errors.Is(err, context.Canceled):
+		e.job.Status = StatusCanceled
+		e.job.Error = err.Error()
+	case err != nil:
+		e.job.Status = StatusFailed
+		e.job.Error = err.Error()
+	default:
+		e.job.Status = StatusSucceeded
+	}
+	job := m.snapshot(e)
+	m.cond.Broadcast()
+	m.mu.Unlock()
+
+	m.Log.Info("job finished", "job_id", job.ID, "type", job.Type, "status", job.Status, "error", job.Error)
+	m.retain(job, result)
+
+	level := events.LevelInfo
+	if job.Status == StatusFailed {
+		level = events.LevelError
+	}
+	m.Events.Publish(events.Event{
+		Type:    events.TypeJobCompleted,
+		Level:   level,
+		Message: fmt.Sprintf("%s job on %s %s", job.Type, job.QueueName, job.Status),
+		Data: map[string]any{
+			"job_id":     job.ID,
+			"job_type":   job.Type,
+			"queue_name": job.QueueName,
+			"status":     job.Status,
+		},
+	})
+}
+
+// stopping reports whether Run's context is done. Callers hold m.mu.
+func (m *Manager) stopping() bool {
+	select {
+	case <-m.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// acquireSlot tries each of the queue's PerQueue slot leases and returns the one taken ("" if all busy).
+func (m *Manager) acquireSlot(ctx context.Context, queue string) string {
+	for i := 0; i < m.PerQueue; i++ {
+		name := fmt.Sprintf("job-slot:%s:%d", queue, i)
+		ok, err := m.Leaser.AcquireLease(ctx, name, m.Owner, m.LeaseTTL)
+		if err != nil {
+			m.Log.Warn("failed to acquire job slot", "slot", name, "error", err)
+			return ""
+		}
+		if ok {
+			return name
+		}
+	}
+	return ""
+}
+
+// renewSlot keeps the job's slot lease alive until the returned func is called, which also releases it.
+func (m *Manager) renewSlot(e *entry) func() {
+	done := make(chan struct{})
+	go func() {
+		t := time.NewTicker(m.LeaseTTL / 3)
+		defer t.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-t.C:
+				if _, err := m.Leaser.AcquireLease(context.Background(), e.slot, m.Owner, m.LeaseTTL); err != nil {
+					m.Log.Warn("failed to renew job slot", "slot", e.slot, "error", err)
+				}
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		if err := m.Leaser.ReleaseLease(ctx, e.slot, m.Owner); err != nil {
+			m.Log.Warn("failed to release job slot", "slot", e.slot, "error", err)
+		}
+	}
+}
+
+// retain persists a finished job and its artifact so they survive refreshes and restarts.
+func (m *Manager) retain(job Job, result Result) {
+	if m.Store == nil {
+		return
+	}
+	if job.HasArtifact {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		artifact := Artifact{ContentType: result.ContentType, Filename: result.Filename, Data: result.Artifact}
+		if err := store.PutJSON(ctx, m.Store, categoryArtifacts, job.ID, artifact, m.ResultTTL); err != nil {
+			m.Log.Warn("failed to retain job artifact", "job_id", job.ID, "error", err)
+		}
+	}
+	m.persist(job)
+}
+
+// persist writes the job record so replicas sharing the store can list it. Active
+// jobs are written without a TTL; finished ones expire after ResultTTL.
+func (m *Manager) persist(job Job) {
+	if m.Store == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	ttl := time.Duration(0)
+	if job.FinishedAt != nil {
+		ttl = m.ResultTTL
+	}
+	if err := store.PutJSON(ctx, m.Store, categoryJobs, job.ID, job, ttl); err != nil {
+		m.Log.Warn("failed to persist job", "job_id", job.ID, "error", err)
+	}
+}
+
+// snapshot copies a job, adding its 1-based position among pending jobs. Caller holds mu.
+func (m *Manager) snapshot(e *entry) Job {
+	job := e.job
+	if job.Status == StatusQueued {
+		for i, p := range m.pending {
+			if p == e {
+				job.QueuePosition = i + 1
+				break
+			}
+		}
+	}
+	return job
+}
+
+// removePending drops e from the pending list. Caller holds mu.
+func (m *Manager) removePending(e *entry) {
+	for i, p := range m.pending {
+		if p == e {
+			m.pending = append(m.pending[:i], m.pending[i+1:]...)
+			return
+		}
+	}
+}
+
+func newID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,132 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/pachecoc/sqs-ui/internal/pipeline"
+	"github.com/pachecoc/sqs-ui/internal/scripts"
+	"github.com/pachecoc/sqs-ui/internal/service"
+)
+
+// Transfer job kinds. Each accepts an optional params.pipeline spec applied to every message.
+const (
+	TypeMove    = "move"    // active queue -> params.target_queue, deleting from the source
+	TypeForward = "forward" // active queue -> params.target_queue, keeping the source
+	TypeReplay  = "replay"  // active queue's DLQ -> active queue, deleting from the DLQ
+)
+
+// RegisterTransfers registers move, forward and replay. sm resolves script pipeline steps
+// and may be nil.
+func RegisterTransfers(m *Manager, sm *scripts.Manager) {
+	m.Register(TypeMove, transferJob(TypeMove, sm))
+	m.Register(TypeForward, transferJob(TypeForward, sm))
+	m.Register(TypeReplay, transferJob(TypeReplay, sm))
+}
+
+// transferJob builds the job func for one transfer kind. With params.dry_run it only previews
+// the pipeline on a sample of the source queue; nothing is sent or deleted.
+func transferJob(kind string, sm *scripts.Manager) Func {
+	return func(ctx context.Context, svc *service.SQSService, params map[string]any) (Result, error) {
+		limit, err := intParam(params, "limit")
+		if err != nil {
+			return Result{}, err
+		}
+		spec, err := pipeline.ParseSpec(params["pipeline"])
+		if err != nil {
+			return Result{}, err
+		}
+		p, err := pipeline.Compile(ctx, spec, sm)
+		if err != nil {
+			return Result{}, err
+		}
+		src, dst, err := transferQueues(ctx, kind, svc, params)
+		if err != nil {
+			return Result{}, err
+		}
+
+		if dryRun, _ := params["dry_run"].(bool); dryRun {
+			return previewTransfer(ctx, kind, src, dst, p, limit)
+		}
+
+		var transform service.TransformFunc
+		if !p.Empty() {
+			transform = func(ctx context.Context, id, body string) (string, error) {
+				out, err := p.Apply(ctx, pipeline.Message{MessageID: id, Body: body})
+				return out.Body, err
+			}
+		}
+
+		started := time.Now().UTC()
+		res, err := src.Transfer(ctx, dst, limit, transform, kind == TypeForward)
+		report := map[string]any{
+			"kind":         kind,
+			"source_queue": src.QueueName,
+			"target_queue": dst.QueueName,
+			"limit":        limit,
+			"pipeline":     spec,
+			"result":       res,
+			"started_at":   started,
+			"finished_at":  time.Now().UTC(),
+		}
+		if err != nil {
+			report["error"] = err.Error()
+		}
+		artifact, _ := json.MarshalIndent(report, "", "  ")
+		return Result{
+			Summary:     map[string]any{"sent": res.Sent, "deleted": res.Deleted, "failed": res.Failed},
+			Artifact:    artifact,
+			ContentType: "application/json",
+			Filename:    artifactName(src.QueueName, kind+"-report", "json"),
+		}, err
+	}
+}
+
+// transferQueues resolves the source and target services for kind.
+func transferQueues(ctx context.Context, kind string, svc *service.SQSService, params map[string]any) (src, dst *service.SQSService, err error) {
+	if kind == TypeReplay {
+		src, err = svc.DeadLetterQueue(ctx)
+		return src, svc, err
+	}
+	target, _ := params["target_queue"].(string)
+	if target == "" {
+		return nil, nil, fmt.Errorf("target_queue is required")
+	}
+	dst, err = svc.ForQueue(ctx, target)
+	return svc, dst, err
+}
+
+// previewTransfer returns the dry-run plan for a transfer with the pipeline applied to its sample.
+func previewTransfer(ctx context.Context, kind string, src, dst *service.SQSService, p *pipeline.Pipeline, limit int) (Result, error) {
+	plan, err := src.PlanQueue(ctx, kind, limit, false)
+	if err != nil {
+		return Result{}, err
+	}
+	plan.TargetQueue = dst.QueueName
+
+	samples := make([]pipeline.Message, 0, len(plan.Sample))
+	for _, m := range plan.Sample {
+		samples = append(samples, pipeline.Message{MessageID: fmt.Sprint(m["message_id"]), Body: fmt.Sprint(m["body"])})
+	}
+	previews := p.PreviewAll(ctx, samples)
+
+	failed := 0
+	for _, pv := range previews {
+		if pv.Error != "" {
+			failed++
+		}
+	}
+	report := map[string]any{
+		"plan":     plan,
+		"previews": previews,
+	}
+	artifact, _ := json.MarshalIndent(report, "", "  ")
+	return Result{
+		Summary:     map[string]any{"dry_run": true, "count": plan.Count, "sampled": len(previews), "failed": failed},
+		Artifact:    artifact,
+		ContentType: "application/json",
+		Filename:    artifactName(src.QueueName, kind+"-preview", "json"),
+	}, nil
+}
@@ -0,0 +1,179 @@
+// Package lifecycle owns the server's background subsystems (pollers, schedulers, watchers,
+// job workers). Each runs under a name on a shared context; they stop together, and the
+// server can tell which one failed or is still running when the shutdown budget runs out.
+// Subsystems Report how each iteration went, which /readyz shows per subsystem.
+package lifecycle
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// Group runs named subsystems until its context ends or one of them fails.
+type Group struct {
+	log    *slog.Logger
+	eg     *errgroup.Group
+	ctx    context.Context
+	cancel context.CancelFunc
+	failed chan struct{}
+
+	mu      sync.Mutex
+	running map[string]int
+	status  map[string]*Status
+	err     error
+}
+
+// Status is what a subsystem last reported.
+type Status struct {
+	Name    string `json:"name"`
+	Running bool   `json:"running"`
+	// Failed is set once the subsystem panicked; the group then stops.
+	Failed      bool       `json:"failed,omitempty"`
+	LastSuccess *time.Time `json:"last_success,omitempty"`
+	LastError   string     `json:"last_error,omitempty"`
+	LastErrorAt *time.Time `json:"last_error_at,omitempty"`
+	// Errors counts failed iterations since the last success.
+	Errors int `json:"consecutive_errors,omitempty"`
+}
+
+// Healthy reports whether the subsystem is running and its latest iteration succeeded.
+func (s Status) Healthy() bool {
+	return !s.Failed && s.Errors == 0
+}
+
+// New returns a group whose subsystems stop when ctx ends, when Stop is called, or when
+// one of them fails.
+func New(ctx context.Context, log *slog.Logger) *Group {
+	ctx, cancel := context.WithCancel(ctx)
+	eg, ctx := errgroup.WithContext(ctx)
+	return &Group{log: log, eg: eg, ctx: ctx, cancel: cancel, failed: make(chan struct{}), running: map[string]int{}, status: map[string]*Status{}}
+}
+
+// Go starts run as the subsystem name. Subsystems return once their context ends; returning
+// earlier (nothing to do) is fine, while a panic fails the group and stops every other one.
+func (g *Group) Go(name string, run func(ctx context.Context)) {
+	g.mu.Lock()
+	g.running[name]++
+	if g.status[name] == nil {
+		g.status[name] = &Status{Name: name}
+	}
+	g.status[name].Running = true
+	g.mu.Unlock()
+	g.log.Debug("subsystem started", "subsystem", name)
+
+	g.eg.Go(func() (err error) {
+		defer func() {
+			if p := recover(); p != nil {
+				err = fmt.Errorf("subsystem %s panicked: %v", name, p)
+				g.log.Error("subsystem failed", "subsystem", name, "error", err, "stack", string(debug.Stack()))
+			}
+			g.mu.Lock()
+			if g.running[name]--; g.running[name] == 0 {
+				delete(g.running, name)
+				g.status[name].Running = false
+			}
+			if err != nil {
+				g.status[name].Failed = true
+				g.record(name, err)
+			}
+			if err != nil && g.err == nil {
+				g.err = err
+				close(g.failed)
+			}
+			g.mu.Unlock()
+			g.log.Debug("subsystem stopped", "subsystem", name)
+		}()
+		run(context.WithValue(g.ctx, reporterKey{}, reporter{g, name}))
+		return nil
+	})
+}
+
+type reporterKey struct{}
+
+type reporter struct {
+	g    *Group
+	name string
+}
+
+// Report records how one iteration of the subsystem running on ctx went: nil is a success.
+// It does nothing for contexts that don't come from Group.Go.
+func Report(ctx context.Context, err error) {
+	r, ok := ctx.Value(reporterKey{}).(reporter)
+	if !ok {
+		return
+	}
+	r.g.mu.Lock()
+	defer r.g.mu.Unlock()
+	r.g.record(r.name, err)
+}
+
+// record notes an outcome for name; callers hold mu.
+func (g *Group) record(name string, err error) {
+	st := g.status[name]
+	now := time.Now().UTC()
+	if err == nil {
+		st.LastSuccess, st.Errors = &now, 0
+		return
+	}
+	st.LastError, st.LastErrorAt = err.Error(), &now
+	st.Errors++
+}
+
+// Status returns every subsystem's latest report, sorted by name. It is empty for a nil Group.
+func (g *Group) Status() []Status {
+	if g == nil {
+		return nil
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	list := make([]Status, 0, len(g.status))
+	for _, st := range g.status {
+		list = append(list, *st)
+	}
+	slices.SortFunc(list, func(a, b Status) int { return strings.Compare(a.Name, b.Name) })
+	return list
+}
+
+// Failed is closed when a subsystem fails; Err then says which.
+func (g *Group) Failed() <-chan struct{} { return g.failed }
+
+// Err returns the first subsystem failure, if any.
+func (g *Group) Err() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.err
+}
+
+// Running lists the subsystems that haven't returned yet, sorted.
+func (g *Group) Running() []string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	names := make([]string, 0, len(g.running))
+	for name := range g.running {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Stop cancels every subsystem and waits for them until ctx ends. It returns the first
+// failure, or an error naming the subsystems still running when ctx ended.
+func (g *Group) Stop(ctx context.Context) error {
+	g.cancel()
+	done := make(chan error, 1)
+	go func() { done <- g.eg.Wait() }()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("still running after the shutdown timeout: %s", strings.Join(g.Running(), ", "))
+	}
+}
@@ -0,0 +1,237 @@
+// Package listener serves HTTP on an address that can change at runtime. Reload binds the
+// new port before draining the old server, so a settings tweak doesn't need a restart.
+package listener
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Config is the part of the settings that decides how the server listens.
+type Config struct {
+	Port        string `json:"port"`
+	TLSCertFile string `json:"tls_cert_file"`
+	TLSKeyFile  string `json:"tls_key_file"`
+}
+
+// TLS reports whether the config serves HTTPS.
+func (c Config) TLS() bool { return c.TLSCertFile != "" }
+
+func (c Config) validate() error {
+	if c.Port == "" {
+		return errors.New("port is required")
+	}
+	if (c.TLSCertFile == "") != (c.TLSKeyFile == "") {
+		return errors.New("tls_cert_file and tls_key_file must be set together")
+	}
+	return nil
+}
+
+// LoadFile reads a JSON object from name; fields it sets override base. An empty name
+// returns base unchanged.
+func LoadFile(name string, base Config) (Config, error) {
+	if name == "" {
+		return base, nil
+	}
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return Config{}, err
+	}
+	var over Config
+	if err := json.Unmarshal(data, &over); err != nil {
+		return Config{}, fmt.Errorf("invalid listener file %s: %w", name, err)
+	}
+	if over.Port != "" {
+		base.Port = over.Port
+	}
+	if over.TLSCertFile != "" || over.TLSKeyFile != "" {
+		base.TLSCertFile, base.TLSKeyFile = over.TLSCertFile, over.TLSKeyFile
+	}
+	return base, nil
+}
+
+// Manager owns the running http.Server. Configure the exported fields before Start.
+type Manager struct {
+	Handler      http.Handler
+	Log          *slog.Logger
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	IdleTimeout  time.Duration
+	// DrainTimeout bounds how long a replaced server may finish in-flight requests
+	// before its remaining connections (event streams) are closed.
+	DrainTimeout time.Duration
+
+	mu   sync.Mutex
+	cfg  Config
+	srv  *http.Server
+	cert atomic.Pointer[tls.Certificate]
+	errs chan error
+}
+
+// Start binds cfg and serves in the background. Bind errors are returned directly;
+// later serve errors arrive on Err.
+func (m *Manager) Start(cfg Config) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := cfg.validate(); err != nil {
+		return err
+	}
+	m.errs = make(chan error, 1)
+	if err := m.loadCert(cfg); err != nil {
+		return err
+	}
+	srv, err := m.serve(cfg)
+	if err != nil {
+		return err
+	}
+	m.cfg, m.srv = cfg, srv
+	m.Log.Info("starting server", "port", cfg.Port, "tls", cfg.TLS())
+	return nil
+}
+
+// Err reports a server that stopped serving unexpectedly.
+func (m *Manager) Err() <-chan error { return m.errs }
+
+// Reload applies cfg. A new port is bound before the old server drains, so clients
+// never see a refused connection; on the same port only the certificate is swapped.
+// Switching between HTTP and HTTPS on the same port has to close the old listener
+// first and leaves a short gap. If the new settings can't be applied the previous
+// ones stay in effect.
+func (m *Manager) Reload(cfg Config) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := cfg.validate(); err != nil {
+		return err
+	}
+	old := m.cfg
+	if cfg.Port == old.Port && cfg.TLS() == old.TLS() {
+		if err := m.loadCert(cfg); err != nil {
+			return err
+		}
+		m.cfg = cfg
+		m.Log.Info("listener reloaded", "port", cfg.Port, "tls", cfg.TLS(), "rebound", false)
+		return nil
+	}
+
+	prevCert := m.cert.Load()
+	if err := m.loadCert(cfg); err != nil {
+		return err
+	}
+	if cfg.Port == old.Port {
+		// Same port, different protocol: the old listener has to go first. Shutdown closes
+		// it right away and keeps draining connections in the background.
+		go m.drain(m.srv)
+		srv, err := m.serveRetry(cfg)
+		if err != nil {
+			// Fall back to the previous settings so the server stays reachable
+			m.cert.Store(prevCert)
+			if m.srv, _ = m.serveRetry(old); m.srv == nil {
+				m.fail(fmt.Errorf("could not restore listener on port %s: %w", old.Port, err))
+			}
+			return err
+		}
+		m.srv, m.cfg = srv, cfg
+	} else {
+		srv, err := m.serve(cfg)
+		if err != nil {
+			m.cert.Store(prevCert)
+			return err
+		}
+		prev := m.srv
+		m.srv, m.cfg = srv, cfg
+		go m.drain(prev)
+	}
+	m.Log.Info("listener reloaded", "port", cfg.Port, "tls", cfg.TLS(), "previous_port", old.Port, "rebound", true)
+	return nil
+}
+
+// Shutdown stops accepting connections and waits for in-flight requests until ctx ends.
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.mu.Lock()
+	srv := m.srv
+	m.mu.Unlock()
+	if srv == nil {
+		return nil
+	}
+	return srv.Shutdown(ctx)
+}
+
+// serve binds cfg.Port and starts a server on it.
+func (m *Manager) serve(cfg Config) (*http.Server, error) {
+	ln, err := net.Listen("tcp", ":"+cfg.Port)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.TLS() {
+		ln = tls.NewListener(ln, &tls.Config{
+			MinVersion:     tls.VersionTLS12,
+			NextProtos:     []string{"h2", "http/1.1"},
+			GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) { return m.cert.Load(), nil },
+		})
+	}
+	srv := &http.Server{
+		Handler:      m.Handler,
+		ReadTimeout:  m.ReadTimeout,
+		WriteTimeout: m.WriteTimeout,
+		IdleTimeout:  m.IdleTimeout,
+	}
+	go func() {
+		if err := srv.Serve(ln); err != nil && err != http.ErrServerClosed {
+			m.fail(err)
+		}
+	}()
+	return srv, nil
+}
+
+// serveRetry retries serve briefly while a draining server releases the port.
+func (m *Manager) serveRetry(cfg Config) (*http.Server, error) {
+	var err error
+	for range 20 {
+		var srv *http.Server
+		if srv, err = m.serve(cfg); err == nil {
+			return srv, nil
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	return nil, err
+}
+
+func (m *Manager) fail(err error) {
+	select {
+	case m.errs <- err:
+	default:
+	}
+}
+
+// drain gives srv DrainTimeout to finish its requests, then closes what is left.
+func (m *Manager) drain(srv *http.Server) {
+	ctx, cancel := context.WithTimeout(context.Background(), m.DrainTimeout)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		m.Log.Warn("previous listener did not drain in time, closing remaining connections", "error", err)
+		_ = srv.Close()
+	}
+}
+
+func (m *Manager) loadCert(cfg Config) error {
+	if !cfg.TLS() {
+		m.cert.Store(nil)
+		return nil
+	}
+	cert, err := tls.LoadX509KeyPair(cfg.TLSCertFile, cfg.TLSKeyFile)
+	if err != nil {
+		return fmt.Errorf("load TLS certificate: %w", err)
+	}
+	m.cert.Store(&cert)
+	return nil
+}
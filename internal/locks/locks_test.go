@@ -0,0 +1,64 @@
+package locks
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/pachecoc/sqs-ui/internal/store"
+)
+
+func TestLockBlocksDestructiveActions(t *testing.T) {
+	ctx := context.Background()
+	m := &Manager{Store: store.NewMemory()}
+	if _, err := m.Set(ctx, "orders", true, "ada", "consumers catching up"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, action := range []string{"delete", "purge", "drain job", "move job", "consume mode"} {
+		err := m.Check(ctx, "orders", action)
+		var locked *LockedError
+		if !errors.As(err, &locked) {
+			t.Fatalf("%s on a locked queue: got %v, want a *LockedError", action, err)
+		}
+		if locked.ErrorCode() != "queue_locked" || !strings.Contains(err.Error(), action) || !strings.Contains(err.Error(), "consumers catching up") {
+			t.Errorf("%s: %q (%s)", action, err, locked.ErrorCode())
+		}
+	}
+	if err := m.Check(ctx, "payments", "purge"); err != nil {
+		t.Errorf("purge on another queue: %v", err)
+	}
+
+	lock, err := m.Set(ctx, "orders", false, "ada", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Check(ctx, "orders", "purge"); err != nil {
+		t.Errorf("purge after unlocking: %v", err)
+	}
+	if lock.Locked || lock.By != "" || lock.Since != nil || len(lock.History) != 2 || lock.History[1].Event != "unlocked" {
+		t.Errorf("after unlocking: %+v", lock)
+	}
+}
+
+func TestSetRequiresUserAndReason(t *testing.T) {
+	ctx := context.Background()
+	m := &Manager{Store: store.NewMemory()}
+	if _, err := m.Set(ctx, "orders", true, "ada", "  "); err == nil {
+		t.Error("locked without a reason")
+	}
+	if _, err := m.Set(ctx, "orders", true, " ", "incident"); err == nil {
+		t.Error("locked without a user")
+	}
+	if err := m.Check(ctx, "orders", "purge"); err != nil {
+		t.Errorf("refused Set left a lock: %v", err)
+	}
+}
+
+func TestNilManagerNeverLocks(t *testing.T) {
+	var m *Manager
+	if err := m.Check(context.Background(), "orders", "purge"); err != nil {
+		t.Errorf("nil Manager: %v", err)
+	}
+}
@@ -6,7 +6,8 @@ import (
 	"strings"
 )
 
-// NewLogger builds a slog JSON logger honoring LOG_LEVEL (debug|info|warn|error).
+// NewLogger builds a slog JSON logger honoring LOG_LEVEL (debug|info|warn|error). Records
+// logged with a request's context carry its request_id.
 func NewLogger(levelStr string) *slog.Logger {
 	level := slog.LevelInfo
 	switch strings.ToLower(strings.TrimSpace(levelStr)) {
@@ -18,5 +19,5 @@ func NewLogger(levelStr string) *slog.Logger {
 		level = slog.LevelError
 	}
 	// Using JSON handler for structured output.
-	return slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: level}))
+	return slog.New(contextHandler{slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: level})})
 }
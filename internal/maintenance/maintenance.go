@@ -0,0 +1,184 @@
+// Package maintenance restricts destructive actions on matching queues to configured time windows.
+package maintenance
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrOutsideWindow is returned when a destructive action is attempted outside every window of its queue.
+var ErrOutsideWindow = errors.New("outside the maintenance window")
+
+var dayNames = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// Window is a weekly time range, e.g. "Mon-Fri 22:00-06:00". A range ending before it starts
+// runs past midnight and belongs to the day it starts on.
+type Window struct {
+	Days  [7]bool
+	Start int // minutes after midnight
+	End   int // minutes after midnight, exclusive; 1440 = end of day
+	text  string
+}
+
+// Rule restricts queues matching Queue (a path.Match pattern) to Windows.
+type Rule struct {
+	Queue   string
+	Windows []Window
+}
+
+// Policy is the set of rules. Queues that match no rule are never restricted.
+type Policy struct {
+	Rules    []Rule
+	Location *time.Location
+
+	// AllowOverride lets a user run an action outside its windows by giving a reason.
+	AllowOverride bool
+}
+
+// Check returns ErrOutsideWindow (wrapped with the allowed windows) when queue is restricted
+// and now falls outside all of its windows.
+func (p *Policy) Check(queue string, now time.Time) error {
+	if p == nil {
+		return nil
+	}
+	if p.Location != nil {
+		now = now.In(p.Location)
+	}
+	for _, r := range p.Rules {
+		if ok, _ := path.Match(r.Queue, queue); !ok {
+			continue
+		}
+		for _, w := range r.Windows {
+			if w.Contains(now) {
+				return nil
+			}
+		}
+		return fmt.Errorf("%w for %s (allowed: %s)", ErrOutsideWindow, queue, r.describe())
+	}
+	return nil
+}
+
+// Contains reports whether t falls inside the window.
+func (w Window) Contains(t time.Time) bool {
+	minute := t.Hour()*60 + t.Minute()
+	day := t.Weekday()
+	if w.Start < w.End {
+		return w.Days[day] && minute >= w.Start && minute < w.End
+	}
+	// Overnight: the late part belongs to today, the early part to yesterday's window
+	yesterday := (day + 6) % 7
+	return (w.Days[day] && minute >= w.Start) || (w.Days[yesterday] && minute < w.End)
+}
+
+func (r Rule) describe() string {
+	parts := make([]string, len(r.Windows))
+	for i, w := range r.Windows {
+		parts[i] = w.text
+	}
+	return strings.Join(parts, ", ")
+}
+
+// ParseRules parses "pattern=window|window;pattern=window", e.g.
+// "prod-*=Sat-Sun 00:00-24:00|Mon-Fri 22:00-06:00".
+func ParseRules(spec string) ([]Rule, error) {
+	var rules []Rule
+	for _, part := range strings.Split(spec, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		queue, windows, ok := strings.Cut(part, "=")
+		queue = strings.TrimSpace(queue)
+		if !ok || queue == "" {
+			return nil, fmt.Errorf("invalid maintenance rule %q (expected pattern=windows)", part)
+		}
+		if _, err := path.Match(queue, ""); err != nil {
+			return nil, fmt.Errorf("invalid queue pattern %q: %w", queue, err)
+		}
+
+		rule := Rule{Queue: queue}
+		for _, ws := range strings.Split(windows, "|") {
+			w, err := ParseWindow(ws)
+			if err != nil {
+				return nil, err
+			}
+			rule.Windows = append(rule.Windows, w)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// ParseWindow parses "<days> HH:MM-HH:MM" where days is "*", a range ("Mon-Fri") or a list ("Sat,Sun").
+func ParseWindow(s string) (Window, error) {
+	s = strings.TrimSpace(s)
+	w := Window{text: s}
+	days, hours, ok := strings.Cut(s, " ")
+	if !ok {
+		return w, fmt.Errorf("invalid maintenance window %q (expected e.g. \"Mon-Fri 22:00-06:00\")", s)
+	}
+	if err := w.parseDays(days); err != nil {
+		return w, fmt.Errorf("invalid maintenance window %q: %w", s, err)
+	}
+
+	from, to, ok := strings.Cut(strings.TrimSpace(hours), "-")
+	var err error
+	if !ok {
+		return w, fmt.Errorf("invalid maintenance window %q: missing time range", s)
+	}
+	if w.Start, err = parseClock(from); err != nil {
+		return w, fmt.Errorf("invalid maintenance window %q: %w", s, err)
+	}
+	if w.End, err = parseClock(to); err != nil {
+		return w, fmt.Errorf("invalid maintenance window %q: %w", s, err)
+	}
+	if w.Start == w.End {
+		return w, fmt.Errorf("invalid maintenance window %q: empty time range", s)
+	}
+	return w, nil
+}
+
+func (w *Window) parseDays(s string) error {
+	if s == "*" {
+		w.Days = [7]bool{true, true, true, true, true, true, true}
+		return nil
+	}
+	for _, item := range strings.Split(s, ",") {
+		from, to, isRange := strings.Cut(strings.ToLower(strings.TrimSpace(item)), "-")
+		start, ok := dayNames[from]
+		if !ok {
+			return fmt.Errorf("unknown day %q", from)
+		}
+		end := start
+		if isRange {
+			if end, ok = dayNames[to]; !ok {
+				return fmt.Errorf("unknown day %q", to)
+			}
+		}
+		for d := start; ; d = (d + 1) % 7 {
+			w.Days[d] = true
+			if d == end {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// parseClock parses HH:MM; "24:00" is the end of the day.
+func parseClock(s string) (int, error) {
+	h, m, ok := strings.Cut(strings.TrimSpace(s), ":")
+	hour, err1 := strconv.Atoi(h)
+	minute, err2 := strconv.Atoi(m)
+	if !ok || err1 != nil || err2 != nil || hour < 0 || minute < 0 || minute > 59 || hour > 24 || (hour == 24 && minute != 0) {
+		return 0, fmt.Errorf("invalid time %q", s)
+	}
+	return hour*60 + minute, nil
+}
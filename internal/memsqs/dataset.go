@@ -0,0 +1,277 @@
+package memsqs
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+// DemoNotificationsQueue receives SNS envelopes in the full dataset.
+const DemoNotificationsQueue = "demo-notifications"
+
+// Datasets Seed can create.
+const (
+	DatasetBasic = "basic" // a handful of messages per queue
+	DatasetFull  = "full"  // realistic orders, SNS envelopes, malformed payloads and DLQ poison messages
+)
+
+// DefaultDatasetSize is how many orders the full dataset sends.
+const DefaultDatasetSize = 60
+
+// SeedDataset creates the demo queues (see Seed) and fills them with dataset: DatasetBasic
+// is Seed; DatasetFull adds size synthetic orders (a few malformed), a queue of SNS
+// envelopes, more FIFO groups and DLQ poison messages. The data is generated from a fixed
+// seed, so every run has the same messages (with timestamps relative to startup), which
+// keeps screenshots and UI work stable.
+func (c *Client) SeedDataset(ctx context.Context, dataset string, size int) error {
+	switch dataset {
+	case DatasetBasic, "":
+		return c.Seed(ctx)
+	case DatasetFull:
+	default:
+		return fmt.Errorf("unknown demo dataset %q (want %s or %s)", dataset, DatasetBasic, DatasetFull)
+	}
+	if err := c.Seed(ctx); err != nil {
+		return err
+	}
+	if size <= 0 {
+		size = DefaultDatasetSize
+	}
+	g := &generator{rng: rand.New(rand.NewPCG(2034, 1)), now: time.Now().UTC()}
+
+	orders, err := c.queueURL(ctx, DemoQueue)
+	if err != nil {
+		return err
+	}
+	var entries []types.SendMessageBatchRequestEntry
+	for i := range size {
+		entries = append(entries, g.order(1100+i))
+	}
+	if err := c.sendAll(ctx, orders, entries); err != nil {
+		return err
+	}
+
+	notifications, err := c.CreateQueue(ctx, &sqs.CreateQueueInput{
+		QueueName: aws.String(DemoNotificationsQueue),
+		Tags:      map[string]string{"env": "demo", "source": "sns"},
+	})
+	if err != nil {
+		return err
+	}
+	entries = nil
+	for i := range max(size/3, 5) {
+		entries = append(entries, g.snsEnvelope(i))
+	}
+	if err := c.sendAll(ctx, notifications.QueueUrl, entries); err != nil {
+		return err
+	}
+
+	dlq, err := c.queueURL(ctx, DemoDLQ)
+	if err != nil {
+		return err
+	}
+	if err := c.sendAll(ctx, dlq, g.poison()); err != nil {
+		return err
+	}
+
+	fifo, err := c.queueURL(ctx, DemoFIFOQueue)
+	if err != nil {
+		return err
+	}
+	for i := range max(size/4, 6) {
+		user := fmt.Sprintf("user-%d", 40+i%4)
+		event := pick(g, "user.logged_in", "user.updated_profile", "user.added_card", "user.logged_out")
+		if _, err := c.SendMessage(ctx, &sqs.SendMessageInput{
+			QueueUrl:       fifo,
+			MessageBody:    aws.String(fmt.Sprintf(`{"event":%q,"user_id":%q,"seq":%d}`, event, user, i+3)),
+			MessageGroupId: aws.String(user),
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Client) queueURL(ctx context.Context, name string) (*string, error) {
+	out, err := c.GetQueueUrl(ctx, &sqs.GetQueueUrlInput{QueueName: aws.String(name)})
+	if err != nil {
+		return nil, err
+	}
+	return out.QueueUrl, nil
+}
+
+// sendAll sends entries in batches of ten, numbering their ids.
+func (c *Client) sendAll(ctx context.Context, queueURL *string, entries []types.SendMessageBatchRequestEntry) error {
+	for start := 0; start < len(entries); start += 10 {
+		batch := entries[start:min(start+10, len(entries))]
+		for i := range batch {
+			batch[i].Id = aws.String(fmt.Sprint(start + i))
+		}
+		if _, err := c.SendMessageBatch(ctx, &sqs.SendMessageBatchInput{QueueUrl: queueURL, Entries: batch}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type generator struct {
+	rng *rand.Rand
+	now time.Time
+}
+
+func pick[T any](g *generator, options ...T) T {
+	return options[g.rng.IntN(len(options))]
+}
+
+var (
+	firstNames = []string{"maria", "joao", "ana", "li", "amara", "noah", "fatima", "lucas", "sofia", "kenji", "olga", "diego"}
+	lastNames  = []string{"silva", "santos", "chen", "okafor", "muller", "haddad", "costa", "tanaka", "novak", "garcia"}
+	countries  = []string{"PT", "BR", "DE", "US", "JP", "NG", "ES", "FR"}
+	products   = []struct {
+		SKU   string
+		Price float64
+	}{
+		{"SKU-TSHIRT-M", 19.99}, {"SKU-HOODIE-L", 49.90}, {"SKU-MUG-01", 12.50}, {"SKU-STICKER-PACK", 4.99},
+		{"SKU-CAP-BLK", 24.00}, {"SKU-BOTTLE-750", 17.80}, {"SKU-NOTEBOOK-A5", 8.40},
+	}
+	statuses = []string{"created", "created", "paid", "paid", "paid", "packed", "shipped", "shipped", "delivered", "cancelled", "refunded"}
+	tenants  = []string{"acme", "globex", "initech"}
+)
+
+func stringAttr(v string) types.MessageAttributeValue {
+	return types.MessageAttributeValue{DataType: aws.String("String"), StringValue: aws.String(v)}
+}
+
+func numberAttr(v int) types.MessageAttributeValue {
+	return types.MessageAttributeValue{DataType: aws.String("Number"), StringValue: aws.String(fmt.Sprint(v))}
+}
+
+// order is an order event; about one in twenty is malformed the way producers get it wrong.
+func (g *generator) order(n int) types.SendMessageBatchRequestEntry {
+	id := fmt.Sprintf("ord-%04d", n)
+	status := pick(g, statuses...)
+	attrs := map[string]types.MessageAttributeValue{
+		"event_type":     stringAttr("order." + status),
+		"tenant":         stringAttr(pick(g, tenants...)),
+		"schema_version": numberAttr(2),
+		"traceparent":    stringAttr(fmt.Sprintf("00-%016x%016x-%016x-01", g.rng.Uint64(), g.rng.Uint64(), g.rng.Uint64())),
+	}
+
+	var body string
+	switch g.rng.IntN(20) {
+	case 0: // truncated by a producer that cut the payload at a buffer size
+		body = fmt.Sprintf(`{"order_id":%q,"status":%q,"items":[{"sku":"SKU-MUG-01","qty":`, id, status)
+	case 1: // an older producer still on schema v1
+		attrs["schema_version"] = numberAttr(1)
+		body = fmt.Sprintf(`{"orderId":%d,"state":%q,"amount":"%d.%02d"}`, n, strings.ToUpper(status), 10+g.rng.IntN(90), g.rng.IntN(100))
+	case 2: // base64-wrapped JSON, shown decoded by the base64-json decoder
+		body = base64.StdEncoding.EncodeToString(g.orderJSON(id, status))
+		attrs["content_encoding"] = stringAttr("base64")
+	default:
+		body = string(g.orderJSON(id, status))
+	}
+	return types.SendMessageBatchRequestEntry{MessageBody: aws.String(body), MessageAttributes: attrs}
+}
+
+func (g *generator) orderJSON(id, status string) []byte {
+	first, last := pick(g, firstNames...), pick(g, lastNames...)
+	type item struct {
+		SKU       string  `json:"sku"`
+		Qty       int     `json:"qty"`
+		UnitPrice float64 `json:"unit_price"`
+	}
+	var items []item
+	total := 0.0
+	for range 1 + g.rng.IntN(3) {
+		p := pick(g, products...)
+		it := item{SKU: p.SKU, Qty: 1 + g.rng.IntN(3), UnitPrice: p.Price}
+		items = append(items, it)
+		total += float64(it.Qty) * it.UnitPrice
+	}
+	b, _ := json.Marshal(map[string]any{
+		"order_id": id,
+		"status":   status,
+		"customer": map[string]any{
+			"id":      fmt.Sprintf("cus-%04d", g.rng.IntN(500)),
+			"email":   first + "." + last + "@example.com",
+			"country": pick(g, countries...),
+		},
+		"items":      items,
+		"total":      float64(int(total*100+0.5)) / 100,
+		"currency":   pick(g, "EUR", "EUR", "USD", "BRL"),
+		"created_at": g.now.Add(-time.Duration(g.rng.IntN(72*60)) * time.Minute).Format(time.RFC3339),
+	})
+	return b
+}
+
+// snsEnvelope is an SNS notification delivered to SQS without raw message delivery.
+func (g *generator) snsEnvelope(i int) types.SendMessageBatchRequestEntry {
+	topic := pick(g, "shipments", "payments", "inventory")
+	var inner string
+	switch topic {
+	case "shipments":
+		inner = fmt.Sprintf(`{"shipment_id":"shp-%05d","order_id":"ord-%04d","carrier":%q,"status":%q}`,
+			g.rng.IntN(100000), 1100+g.rng.IntN(60), pick(g, "DHL", "UPS", "CTT"), pick(g, "label_created", "in_transit", "delivered"))
+	case "payments":
+		inner = fmt.Sprintf(`{"payment_id":"pay-%05d","order_id":"ord-%04d","outcome":%q,"amount":%d.%02d}`,
+			g.rng.IntN(100000), 1100+g.rng.IntN(60), pick(g, "captured", "captured", "declined"), 10+g.rng.IntN(200), g.rng.IntN(100))
+	default:
+		inner = fmt.Sprintf(`{"sku":%q,"warehouse":%q,"on_hand":%d}`, pick(g, products...).SKU, pick(g, "LIS-1", "FRA-2"), g.rng.IntN(40))
+	}
+	b, _ := json.Marshal(map[string]any{
+		"Type":             "Notification",
+		"MessageId":        g.uuid(),
+		"TopicArn":         fmt.Sprintf("arn:aws:sns:%s:%s:demo-%s", DefaultRegion, DefaultAccount, topic),
+		"Subject":          topic + " update",
+		"Message":          inner,
+		"Timestamp":        g.now.Add(-time.Duration(i) * time.Minute).Format("2006-01-02T15:04:05.000Z"),
+		"SignatureVersion": "1",
+		"Signature":        base64.StdEncoding.EncodeToString([]byte(g.uuid())),
+		"SigningCertURL":   "https://sns.us-east-1.amazonaws.com/SimpleNotificationService-demo.pem",
+		"UnsubscribeURL":   "https://sns.us-east-1.amazonaws.com/?Action=Unsubscribe&SubscriptionArn=demo",
+		"MessageAttributes": map[string]any{
+			"event_source": map[string]string{"Type": "String", "Value": topic + "-service"},
+		},
+	})
+	return types.SendMessageBatchRequestEntry{MessageBody: aws.String(string(b))}
+}
+
+// poison are messages a consumer gave up on, each with the reason it failed.
+func (g *generator) poison() []types.SendMessageBatchRequestEntry {
+	bad := []struct{ reason, body string }{
+		{"json: unexpected end of input", `{"order_id":"ord-1201","status":"paid","items":[{"sku":"SKU-CAP-BLK"`},
+		{"json: invalid character 'O' looking for beginning of value", `ORDER ord-1202 PAID 24.00 EUR`},
+		{"unsupported content type application/xml", `<order><id>ord-1203</id><status>paid</status></order>`},
+		{"validation: total must be a number", `{"order_id":"ord-1204","status":"paid","total":"twelve euros"}`},
+		{"validation: unknown status \"teleported\"", `{"order_id":"ord-1205","status":"teleported","total":12.5}`},
+		{"customer cus-0000 not found", `{"order_id":"ord-1206","status":"paid","customer":{"id":"cus-0000"},"total":8.4}`},
+		{"json: cannot unmarshal string into Go value of type events.Order", `"ord-1207"`},
+		{"downstream timeout after 30s (payments-api)", string(g.orderJSON("ord-1208", "paid"))},
+		{"message exceeds the consumer's 64 KiB limit", fmt.Sprintf(`{"order_id":"ord-1209","status":"paid","notes":%q}`, strings.Repeat("gift wrap please. ", 4500))},
+	}
+	entries := make([]types.SendMessageBatchRequestEntry, 0, len(bad))
+	for _, p := range bad {
+		entries = append(entries, types.SendMessageBatchRequestEntry{
+			MessageBody: aws.String(p.body),
+			MessageAttributes: map[string]types.MessageAttributeValue{
+				"failure_reason": stringAttr(p.reason),
+				"source_queue":   stringAttr(DemoQueue),
+				"attempts":       numberAttr(3 + g.rng.IntN(3)),
+			},
+		})
+	}
+	return entries
+}
+
+func (g *generator) uuid() string {
+	a, b := g.rng.Uint64(), g.rng.Uint64()
+	return fmt.Sprintf("%08x-%04x-4%03x-a%03x-%012x", a>>32, a>>16&0xffff, a&0xfff, b>>48&0xfff, b&0xffffffffffff)
+}
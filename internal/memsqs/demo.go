@@ -0,0 +1,71 @@
+package memsqs
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+
+	"github.com/pachecoc/sqs-ui/internal/service"
+)
+
+// Demo queues created by Seed. DemoQueue is the one DEMO_MODE opens first.
+const (
+	DemoQueue     = "demo-orders"
+	DemoDLQ       = "demo-orders-dlq"
+	DemoFIFOQueue = "demo-events.fifo"
+)
+
+// Seed creates the demo queues with a few messages: DemoQueue redriving to DemoDLQ after
+// three receives, and DemoFIFOQueue with content-based deduplication.
+func (c *Client) Seed(ctx context.Context) error {
+	dlq, err := c.CreateQueue(ctx, &sqs.CreateQueueInput{QueueName: aws.String(DemoDLQ)})
+	if err != nil {
+		return err
+	}
+	dlqARN := fmt.Sprintf("arn:aws:sqs:%s:%s:%s", c.Region, c.Account, DemoDLQ)
+	orders, err := c.CreateQueue(ctx, &sqs.CreateQueueInput{
+		QueueName:  aws.String(DemoQueue),
+		Attributes: map[string]string{"RedrivePolicy": service.RedrivePolicyJSON(dlqARN, 3)},
+		Tags:       map[string]string{"env": "demo"},
+	})
+	if err != nil {
+		return err
+	}
+	fifo, err := c.CreateQueue(ctx, &sqs.CreateQueueInput{
+		QueueName:  aws.String(DemoFIFOQueue),
+		Attributes: map[string]string{"FifoQueue": "true", "ContentBasedDeduplication": "true"},
+	})
+	if err != nil {
+		return err
+	}
+
+	var entries []types.SendMessageBatchRequestEntry
+	for i, status := range []string{"created", "paid", "shipped"} {
+		entries = append(entries, types.SendMessageBatchRequestEntry{
+			Id:          aws.String(fmt.Sprint(i)),
+			MessageBody: aws.String(fmt.Sprintf(`{"order_id":"ord-%04d","status":%q,"total":%d.99}`, 1001+i, status, 20+i*15)),
+			MessageAttributes: map[string]types.MessageAttributeValue{
+				"event_type": {DataType: aws.String("String"), StringValue: aws.String("order." + status)},
+			},
+		})
+	}
+	if _, err := c.SendMessageBatch(ctx, &sqs.SendMessageBatchInput{QueueUrl: orders.QueueUrl, Entries: entries}); err != nil {
+		return err
+	}
+	if _, err := c.SendMessage(ctx, &sqs.SendMessageInput{QueueUrl: dlq.QueueUrl, MessageBody: aws.String(`{"order_id":"ord-0999","status":"unknown"}`)}); err != nil {
+		return err
+	}
+	for i := range 2 {
+		if _, err := c.SendMessage(ctx, &sqs.SendMessageInput{
+			QueueUrl:       fifo.QueueUrl,
+			MessageBody:    aws.String(fmt.Sprintf(`{"event":"user.signed_up","seq":%d}`, i+1)),
+			MessageGroupId: aws.String("user-42"),
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
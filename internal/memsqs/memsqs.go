@@ -0,0 +1,408 @@
+// Package memsqs is an in-memory SQS emulator implementing service.SQSAPI, used by
+// DEMO_MODE so the UI can be evaluated and end-to-end tested without AWS credentials.
+// It covers what sqs-ui uses: queues with attributes and tags, standard and FIFO
+// messages with delays, visibility timeouts, long polling, purges and redrive to a
+// dead-letter queue. State lives in the process and is lost on restart.
+package memsqs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/aws/smithy-go"
+
+	"github.com/pachecoc/sqs-ui/internal/service"
+)
+
+// Defaults for the emulated account.
+const (
+	DefaultRegion  = "us-east-1"
+	DefaultAccount = "000000000000"
+)
+
+// purgeInterval is how often SQS allows a queue to be purged.
+const purgeInterval = 60 * time.Second
+
+// defaultAttributes are the attributes of a new queue, before CreateQueue's own.
+var defaultAttributes = map[string]string{
+	"VisibilityTimeout":             "30",
+	"DelaySeconds":                  "0",
+	"MaximumMessageSize":            "262144",
+	"MessageRetentionPeriod":        "345600",
+	"ReceiveMessageWaitTimeSeconds": "0",
+	"SqsManagedSseEnabled":          "true",
+}
+
+// Client is the emulator. The zero value is not usable; call New.
+type Client struct {
+	Region  string
+	Account string
+
+	mu      sync.Mutex
+	queues  map[string]*queue // by name
+	changed chan struct{}     // closed and replaced when messages arrive, waking long polls
+}
+
+var _ service.SQSAPI = (*Client)(nil)
+
+// New returns an empty emulator for DefaultRegion and DefaultAccount.
+func New() *Client {
+	return &Client{Region: DefaultRegion, Account: DefaultAccount, queues: make(map[string]*queue), changed: make(chan struct{})}
+}
+
+type queue struct {
+	name       string
+	url        string
+	arn        string
+	attrs      map[string]string
+	tags       map[string]string
+	created    time.Time
+	modified   time.Time
+	lastPurge  time.Time
+	messages   []*message
+	dedup      map[string]dedupEntry // FIFO deduplication IDs seen in the last 5 minutes
+	sequence   int64
+	fifo       bool
+	contentDup bool
+}
+
+type dedupEntry struct {
+	messageID string
+	sequence  string
+	at        time.Time
+}
+
+// fifoDedupWindow is how long FIFO queues remember deduplication IDs.
+const fifoDedupWindow = 5 * time.Minute
+
+func apiError(code, format string, args ...any) error {
+	return &smithy.GenericAPIError{Code: code, Message: fmt.Sprintf(format, args...), Fault: smithy.FaultClient}
+}
+
+func errNoQueue() error {
+	return apiError("AWS.SimpleQueueService.NonExistentQueue", "The specified queue does not exist.")
+}
+
+// queueByURL returns the queue a QueueUrl names; callers hold c.mu.
+func (c *Client) queueByURL(url *string) (*queue, error) {
+	u := aws.ToString(url)
+	q, ok := c.queues[u[strings.LastIndex(u, "/")+1:]]
+	if !ok || q.url != u {
+		return nil, errNoQueue()
+	}
+	return q, nil
+}
+
+// notify wakes long polls; callers hold c.mu.
+func (c *Client) notify() {
+	close(c.changed)
+	c.changed = make(chan struct{})
+}
+
+func (c *Client) GetQueueUrl(_ context.Context, in *sqs.GetQueueUrlInput, _ ...func(*sqs.Options)) (*sqs.GetQueueUrlOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	q, ok := c.queues[aws.ToString(in.QueueName)]
+	if !ok || (in.QueueOwnerAWSAccountId != nil && *in.QueueOwnerAWSAccountId != c.Account) {
+		return nil, errNoQueue()
+	}
+	return &sqs.GetQueueUrlOutput{QueueUrl: aws.String(q.url)}, nil
+}
+
+func (c *Client) CreateQueue(_ context.Context, in *sqs.CreateQueueInput, _ ...func(*sqs.Options)) (*sqs.CreateQueueOutput, error) {
+	name := aws.ToString(in.QueueName)
+	fifo := strings.HasSuffix(name, ".fifo")
+	if base := strings.TrimSuffix(name, ".fifo"); base == "" || len(name) > 80 || strings.Trim(base, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_") != "" {
+		return nil, apiError("InvalidParameterValue", "Can only include alphanumeric characters, hyphens, or underscores. 1 to 80 in length")
+	}
+	if v, ok := in.Attributes["FifoQueue"]; ok && (v == "true") != fifo {
+		return nil, apiError("InvalidParameterValue", "The name of a FIFO queue can only include alphanumeric characters, hyphens, or underscores, must end with .fifo suffix")
+	}
+	if fifo && in.Attributes["FifoQueue"] != "true" {
+		return nil, apiError("InvalidParameterValue", "FifoQueue must be true for a queue named %s", name)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if q, ok := c.queues[name]; ok {
+		for k, v := range in.Attributes {
+			if q.attrs[k] != v {
+				return nil, apiError("QueueAlreadyExists", "A queue already exists with the same name and a different value for attribute %s", k)
+			}
+		}
+		return &sqs.CreateQueueOutput{QueueUrl: aws.String(q.url)}, nil
+	}
+
+	now := time.Now()
+	q := &queue{
+		name:     name,
+		url:      fmt.Sprintf("https://sqs.%s.amazonaws.com/%s/%s", c.Region, c.Account, name),
+		arn:      fmt.Sprintf("arn:aws:sqs:%s:%s:%s", c.Region, c.Account, name),
+		attrs:    maps.Clone(defaultAttributes),
+		tags:     maps.Clone(in.Tags),
+		created:  now,
+		modified: now,
+		dedup:    make(map[string]dedupEntry),
+		fifo:     fifo,
+	}
+	if q.tags == nil {
+		q.tags = make(map[string]string)
+	}
+	if fifo {
+		q.attrs["ContentBasedDeduplication"] = "false"
+	}
+	if err := q.setAttributes(in.Attributes); err != nil {
+		return nil, err
+	}
+	c.queues[name] = q
+	return &sqs.CreateQueueOutput{QueueUrl: aws.String(q.url)}, nil
+}
+
+func (c *Client) DeleteQueue(_ context.Context, in *sqs.DeleteQueueInput, _ ...func(*sqs.Options)) (*sqs.DeleteQueueOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	q, err := c.queueByURL(in.QueueUrl)
+	if err != nil {
+		return nil, err
+	}
+	delete(c.queues, q.name)
+	return &sqs.DeleteQueueOutput{}, nil
+}
+
+func (c *Client) ListQueues(_ context.Context, in *sqs.ListQueuesInput, _ ...func(*sqs.Options)) (*sqs.ListQueuesOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var urls []string
+	for _, name := range slices.Sorted(maps.Keys(c.queues)) {
+		if strings.HasPrefix(name, aws.ToString(in.QueueNamePrefix)) {
+			urls = append(urls, c.queues[name].url)
+		}
+	}
+	out := &sqs.ListQueuesOutput{}
+	out.QueueUrls, out.NextToken = paginate(urls, in.MaxResults, in.NextToken)
+	return out, nil
+}
+
+func (c *Client) ListDeadLetterSourceQueues(_ context.Context, in *sqs.ListDeadLetterSourceQueuesInput, _ ...func(*sqs.Options)) (*sqs.ListDeadLetterSourceQueuesOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	dlq, err := c.queueByURL(in.QueueUrl)
+	if err != nil {
+		return nil, err
+	}
+	var urls []string
+	for _, name := range slices.Sorted(maps.Keys(c.queues)) {
+		if target, _ := c.queues[name].redrive(); target == dlq.arn {
+			urls = append(urls, c.queues[name].url)
+		}
+	}
+	out := &sqs.ListDeadLetterSourceQueuesOutput{}
+	out.QueueUrls, out.NextToken = paginate(urls, in.MaxResults, in.NextToken)
+	return out, nil
+}
+
+// paginate returns the page of urls starting at the numeric token (up to 1000 without a
+// limit) and the token of the next page, if any.
+func paginate(urls []string, limit *int32, token *string) ([]string, *string) {
+	start, _ := strconv.Atoi(aws.ToString(token))
+	start = min(max(start, 0), len(urls))
+	n := int(aws.ToInt32(limit))
+	if n <= 0 {
+		n = 1000
+	}
+	end := min(start+n, len(urls))
+	if end < len(urls) {
+		return urls[start:end], aws.String(strconv.Itoa(end))
+	}
+	return urls[start:end], nil
+}
+
+func (c *Client) GetQueueAttributes(_ context.Context, in *sqs.GetQueueAttributesInput, _ ...func(*sqs.Options)) (*sqs.GetQueueAttributesOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	q, err := c.queueByURL(in.QueueUrl)
+	if err != nil {
+		return nil, err
+	}
+	q.expire(time.Now())
+	all := q.attributes(time.Now())
+	if len(in.AttributeNames) == 0 {
+		return &sqs.GetQueueAttributesOutput{}, nil
+	}
+	out := make(map[string]string)
+	for _, name := range in.AttributeNames {
+		if name == types.QueueAttributeNameAll {
+			return &sqs.GetQueueAttributesOutput{Attributes: all}, nil
+		}
+		if v, ok := all[string(name)]; ok {
+			out[string(name)] = v
+		}
+	}
+	return &sqs.GetQueueAttributesOutput{Attributes: out}, nil
+}
+
+func (c *Client) SetQueueAttributes(_ context.Context, in *sqs.SetQueueAttributesInput, _ ...func(*sqs.Options)) (*sqs.SetQueueAttributesOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	q, err := c.queueByURL(in.QueueUrl)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := in.Attributes["FifoQueue"]; ok {
+		return nil, apiError("InvalidAttributeName", "FifoQueue can only be set when the queue is created")
+	}
+	if err := q.setAttributes(in.Attributes); err != nil {
+		return nil, err
+	}
+	q.modified = time.Now()
+	c.notify()
+	return &sqs.SetQueueAttributesOutput{}, nil
+}
+
+func (c *Client) PurgeQueue(_ context.Context, in *sqs.PurgeQueueInput, _ ...func(*sqs.Options)) (*sqs.PurgeQueueOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	q, err := c.queueByURL(in.QueueUrl)
+	if err != nil {
+		return nil, err
+	}
+	if time.Since(q.lastPurge) < purgeInterval {
+		return nil, apiError("AWS.SimpleQueueService.PurgeQueueInProgress", "Only one PurgeQueue operation on %s is allowed every 60 seconds.", q.name)
+	}
+	q.lastPurge = time.Now()
+	q.messages = nil
+	return &sqs.PurgeQueueOutput{}, nil
+}
+
+func (c *Client) ListQueueTags(_ context.Context, in *sqs.ListQueueTagsInput, _ ...func(*sqs.Options)) (*sqs.ListQueueTagsOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	q, err := c.queueByURL(in.QueueUrl)
+	if err != nil {
+		return nil, err
+	}
+	return &sqs.ListQueueTagsOutput{Tags: maps.Clone(q.tags)}, nil
+}
+
+func (c *Client) TagQueue(_ context.Context, in *sqs.TagQueueInput, _ ...func(*sqs.Options)) (*sqs.TagQueueOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	q, err := c.queueByURL(in.QueueUrl)
+	if err != nil {
+		return nil, err
+	}
+	maps.Copy(q.tags, in.Tags)
+	return &sqs.TagQueueOutput{}, nil
+}
+
+func (c *Client) UntagQueue(_ context.Context, in *sqs.UntagQueueInput, _ ...func(*sqs.Options)) (*sqs.UntagQueueOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	q, err := c.queueByURL(in.QueueUrl)
+	if err != nil {
+		return nil, err
+	}
+	for _, k := range in.TagKeys {
+		delete(q.tags, k)
+	}
+	return &sqs.UntagQueueOutput{}, nil
+}
+
+// setAttributes validates and applies settable attributes.
+func (q *queue) setAttributes(attrs map[string]string) error {
+	for name, v := range attrs {
+		switch name {
+		case "VisibilityTimeout", "DelaySeconds", "MaximumMessageSize", "MessageRetentionPeriod", "ReceiveMessageWaitTimeSeconds":
+			if _, err := strconv.Atoi(v); err != nil {
+				return apiError("InvalidAttributeValue", "Invalid value for the parameter %s.", name)
+			}
+		case "RedrivePolicy":
+			if v == "" {
+				delete(q.attrs, name)
+				continue
+			}
+			var p struct {
+				DeadLetterTargetArn string `json:"deadLetterTargetArn"`
+			}
+			if err := json.Unmarshal([]byte(v), &p); err != nil || p.DeadLetterTargetArn == "" {
+				return apiError("InvalidAttributeValue", "Invalid value for the parameter RedrivePolicy.")
+			}
+		case "ContentBasedDeduplication":
+			if !q.fifo {
+				return apiError("InvalidAttributeName", "ContentBasedDeduplication is only supported on FIFO queues")
+			}
+			q.contentDup = v == "true"
+		case "FifoQueue", "KmsMasterKeyId", "KmsDataKeyReusePeriodSeconds", "SqsManagedSseEnabled", "Policy", "RedriveAllowPolicy",
+			"DeduplicationScope", "FifoThroughputLimit":
+		default:
+			return apiError("InvalidAttributeName", "Unknown Attribute %s.", name)
+		}
+		q.attrs[name] = v
+	}
+	return nil
+}
+
+// attributes returns the stored attributes plus the computed ones.
+func (q *queue) attributes(now time.Time) map[string]string {
+	out := maps.Clone(q.attrs)
+	var visible, inFlight, delayed int
+	for _, m := range q.messages {
+		switch {
+		case !now.Before(m.visibleAt):
+			visible++
+		case m.receives > 0:
+			inFlight++
+		default:
+			delayed++
+		}
+	}
+	out["ApproximateNumberOfMessages"] = strconv.Itoa(visible)
+	out["ApproximateNumberOfMessagesNotVisible"] = strconv.Itoa(inFlight)
+	out["ApproximateNumberOfMessagesDelayed"] = strconv.Itoa(delayed)
+	out["QueueArn"] = q.arn
+	out["CreatedTimestamp"] = strconv.FormatInt(q.created.Unix(), 10)
+	out["LastModifiedTimestamp"] = strconv.FormatInt(q.modified.Unix(), 10)
+	return out
+}
+
+// intAttr reads a numeric attribute.
+func (q *queue) intAttr(name string) int {
+	n, _ := strconv.Atoi(q.attrs[name])
+	return n
+}
+
+// redrive returns the dead-letter target ARN and maxReceiveCount, if the queue has one.
+func (q *queue) redrive() (string, int) {
+	raw := q.attrs["RedrivePolicy"]
+	if raw == "" {
+		return "", 0
+	}
+	var p struct {
+		DeadLetterTargetArn string          `json:"deadLetterTargetArn"`
+		MaxReceiveCount     json.RawMessage `json:"maxReceiveCount"`
+	}
+	_ = json.Unmarshal([]byte(raw), &p)
+	n, _ := strconv.Atoi(strings.Trim(string(p.MaxReceiveCount), `"`))
+	return p.DeadLetterTargetArn, n
+}
+
+// expire drops messages older than the retention period and stale deduplication IDs.
+func (q *queue) expire(now time.Time) {
+	retention := time.Duration(q.intAttr("MessageRetentionPeriod")) * time.Second
+	q.messages = slices.DeleteFunc(q.messages, func(m *message) bool { return now.Sub(m.sent) > retention })
+	for id, e := range q.dedup {
+		if now.Sub(e.at) > fifoDedupWindow {
+			delete(q.dedup, id)
+		}
+	}
+}
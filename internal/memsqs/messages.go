@@ -0,0 +1,375 @@
+package memsqs
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+// pollTick is how often a long poll looks again for delayed or released messages.
+const pollTick = 250 * time.Millisecond
+
+type message struct {
+	id           string
+	body         string
+	md5          string
+	attrs        map[string]types.MessageAttributeValue
+	group        string
+	dedupID      string
+	sequence     string
+	sent         time.Time
+	visibleAt    time.Time
+	firstReceive time.Time
+	receives     int
+	handle       string
+}
+
+// sendEntry is one SendMessage or SendMessageBatch entry.
+type sendEntry struct {
+	body    string
+	attrs   map[string]types.MessageAttributeValue
+	delay   int32
+	group   string
+	dedupID string
+}
+
+func newID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	h := hex.EncodeToString(b)
+	return h[:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:]
+}
+
+func md5Hex(s string) string {
+	sum := md5.Sum([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+// enqueue validates e and appends it to the queue, returning the stored message. A FIFO
+// duplicate within the deduplication window returns the original message without storing.
+func (q *queue) enqueue(e sendEntry, now time.Time) (*message, error) {
+	if e.body == "" {
+		return nil, apiError("MissingParameter", "The request must contain the parameter MessageBody.")
+	}
+	size := len(e.body)
+	for name, a := range e.attrs {
+		size += len(name) + len(aws.ToString(a.DataType)) + len(aws.ToString(a.StringValue)) + len(a.BinaryValue)
+	}
+	if limit := q.intAttr("MaximumMessageSize"); size > limit {
+		return nil, apiError("InvalidParameterValue", "One or more parameters are invalid. Reason: Message must be shorter than %d bytes.", limit)
+	}
+	if e.delay < 0 || e.delay > 900 {
+		return nil, apiError("InvalidParameterValue", "Value %d for parameter DelaySeconds is invalid. Reason: must be between 0 and 900.", e.delay)
+	}
+
+	m := &message{id: newID(), body: e.body, md5: md5Hex(e.body), attrs: e.attrs, group: e.group, sent: now}
+	delay := time.Duration(q.intAttr("DelaySeconds")) * time.Second
+	if q.fifo {
+		if e.group == "" {
+			return nil, apiError("MissingParameter", "The request must contain the parameter MessageGroupId.")
+		}
+		if e.delay > 0 {
+			return nil, apiError("InvalidParameterValue", "Value %d for parameter DelaySeconds is invalid. Reason: The request include parameter that is not valid for this queue type.", e.delay)
+		}
+		m.dedupID = e.dedupID
+		if m.dedupID == "" {
+			if !q.contentDup {
+				return nil, apiError("InvalidParameterValue", "The queue should either have ContentBasedDeduplication enabled or MessageDeduplicationId provided explicitly")
+			}
+			sum := sha256.Sum256([]byte(e.body))
+			m.dedupID = hex.EncodeToString(sum[:])
+		}
+		if d, ok := q.dedup[m.dedupID]; ok && now.Sub(d.at) < fifoDedupWindow {
+			return &message{id: d.messageID, md5: m.md5, sequence: d.sequence}, nil
+		}
+		q.sequence++
+		m.sequence = fmt.Sprintf("%020d", q.sequence)
+		q.dedup[m.dedupID] = dedupEntry{messageID: m.id, sequence: m.sequence, at: now}
+	} else if e.delay > 0 {
+		delay = time.Duration(e.delay) * time.Second
+	}
+	m.visibleAt = now.Add(delay)
+	q.messages = append(q.messages, m)
+	return m, nil
+}
+
+func (c *Client) SendMessage(_ context.Context, in *sqs.SendMessageInput, _ ...func(*sqs.Options)) (*sqs.SendMessageOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	q, err := c.queueByURL(in.QueueUrl)
+	if err != nil {
+		return nil, err
+	}
+	m, err := q.enqueue(sendEntry{
+		body:    aws.ToString(in.MessageBody),
+		attrs:   in.MessageAttributes,
+		delay:   in.DelaySeconds,
+		group:   aws.ToString(in.MessageGroupId),
+		dedupID: aws.ToString(in.MessageDeduplicationId),
+	}, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	c.notify()
+	out := &sqs.SendMessageOutput{MessageId: aws.String(m.id), MD5OfMessageBody: aws.String(m.md5)}
+	if m.sequence != "" {
+		out.SequenceNumber = aws.String(m.sequence)
+	}
+	return out, nil
+}
+
+func (c *Client) SendMessageBatch(_ context.Context, in *sqs.SendMessageBatchInput, _ ...func(*sqs.Options)) (*sqs.SendMessageBatchOutput, error) {
+	if err := checkBatch(len(in.Entries)); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	q, err := c.queueByURL(in.QueueUrl)
+	if err != nil {
+		return nil, err
+	}
+	out := &sqs.SendMessageBatchOutput{}
+	now := time.Now()
+	for _, e := range in.Entries {
+		m, err := q.enqueue(sendEntry{
+			body:    aws.ToString(e.MessageBody),
+			attrs:   e.MessageAttributes,
+			delay:   e.DelaySeconds,
+			group:   aws.ToString(e.MessageGroupId),
+			dedupID: aws.ToString(e.MessageDeduplicationId),
+		}, now)
+		if err != nil {
+			out.Failed = append(out.Failed, failure(e.Id, err))
+			continue
+		}
+		ok := types.SendMessageBatchResultEntry{Id: e.Id, MessageId: aws.String(m.id), MD5OfMessageBody: aws.String(m.md5)}
+		if m.sequence != "" {
+			ok.SequenceNumber = aws.String(m.sequence)
+		}
+		out.Successful = append(out.Successful, ok)
+	}
+	c.notify()
+	return out, nil
+}
+
+// ReceiveMessage returns visible messages, waiting up to WaitTimeSeconds (or the queue's
+// ReceiveMessageWaitTimeSeconds) for some to arrive.
+func (c *Client) ReceiveMessage(ctx context.Context, in *sqs.ReceiveMessageInput, _ ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error) {
+	c.mu.Lock()
+	q, err := c.queueByURL(in.QueueUrl)
+	if err != nil {
+		c.mu.Unlock()
+		return nil, err
+	}
+	wait := time.Duration(in.WaitTimeSeconds) * time.Second
+	if in.WaitTimeSeconds == 0 {
+		wait = time.Duration(q.intAttr("ReceiveMessageWaitTimeSeconds")) * time.Second
+	}
+	c.mu.Unlock()
+	deadline := time.Now().Add(wait)
+
+	for {
+		c.mu.Lock()
+		q, err := c.queueByURL(in.QueueUrl)
+		if err != nil {
+			c.mu.Unlock()
+			return nil, err
+		}
+		msgs := c.collect(q, in, time.Now())
+		changed := c.changed
+		c.mu.Unlock()
+
+		remaining := time.Until(deadline)
+		if len(msgs) > 0 || remaining <= 0 {
+			return &sqs.ReceiveMessageOutput{Messages: msgs}, nil
+		}
+		timer := time.NewTimer(min(pollTick, remaining))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-changed:
+		case <-timer.C:
+		}
+		timer.Stop()
+	}
+}
+
+// collect hands out up to MaxNumberOfMessages visible messages, moving those received more
+// than the redrive policy's maxReceiveCount to the dead-letter queue. FIFO groups with a
+// message in flight are skipped, so each group is delivered in order. Callers hold c.mu.
+func (c *Client) collect(q *queue, in *sqs.ReceiveMessageInput, now time.Time) []types.Message {
+	q.expire(now)
+	limit := int(min(max(in.MaxNumberOfMessages, 1), 10))
+	visibility := time.Duration(q.intAttr("VisibilityTimeout")) * time.Second
+	if in.VisibilityTimeout > 0 {
+		visibility = time.Duration(in.VisibilityTimeout) * time.Second
+	}
+	var dlq *queue
+	target, maxReceives := q.redrive()
+	if target != "" {
+		dlq = c.queues[target[strings.LastIndex(target, ":")+1:]]
+	}
+	wantSystem := len(in.AttributeNames) > 0 || len(in.MessageSystemAttributeNames) > 0
+
+	var out []types.Message
+	blocked := make(map[string]bool)
+	kept := q.messages[:0]
+	for _, m := range q.messages {
+		switch {
+		case len(out) == limit:
+		case now.Before(m.visibleAt):
+			if q.fifo && m.receives > 0 {
+				blocked[m.group] = true
+			}
+		case q.fifo && blocked[m.group]:
+		case dlq != nil && maxReceives > 0 && m.receives >= maxReceives:
+			m.visibleAt, m.handle = now, ""
+			dlq.messages = append(dlq.messages, m)
+			continue
+		default:
+			m.receives++
+			if m.firstReceive.IsZero() {
+				m.firstReceive = now
+			}
+			m.visibleAt = now.Add(visibility)
+			m.handle = m.id + ":" + newID()
+			out = append(out, m.toSQS(in.MessageAttributeNames, wantSystem))
+			if q.fifo {
+				blocked[m.group] = false
+			}
+		}
+		kept = append(kept, m)
+	}
+	clear(q.messages[len(kept):])
+	q.messages = kept
+	return out
+}
+
+func (m *message) toSQS(attributeNames []string, system bool) types.Message {
+	out := types.Message{
+		MessageId:     aws.String(m.id),
+		ReceiptHandle: aws.String(m.handle),
+		Body:          aws.String(m.body),
+		MD5OfBody:     aws.String(m.md5),
+	}
+	if system {
+		out.Attributes = map[string]string{
+			"SenderId":                         "AIDADEMODEMODEMODEMO",
+			"SentTimestamp":                    strconv.FormatInt(m.sent.UnixMilli(), 10),
+			"ApproximateReceiveCount":          strconv.Itoa(m.receives),
+			"ApproximateFirstReceiveTimestamp": strconv.FormatInt(m.firstReceive.UnixMilli(), 10),
+		}
+		if m.group != "" {
+			out.Attributes["MessageGroupId"] = m.group
+		}
+		if m.sequence != "" {
+			out.Attributes["MessageDeduplicationId"] = m.dedupID
+			out.Attributes["SequenceNumber"] = m.sequence
+		}
+	}
+	for name, a := range m.attrs {
+		for _, want := range attributeNames {
+			prefix, all := strings.CutSuffix(want, ".*")
+			if want == "All" || want == name || (all && strings.HasPrefix(name, prefix)) {
+				if out.MessageAttributes == nil {
+					out.MessageAttributes = make(map[string]types.MessageAttributeValue)
+				}
+				out.MessageAttributes[name] = a
+				break
+			}
+		}
+	}
+	return out
+}
+
+func (c *Client) DeleteMessageBatch(_ context.Context, in *sqs.DeleteMessageBatchInput, _ ...func(*sqs.Options)) (*sqs.DeleteMessageBatchOutput, error) {
+	if err := checkBatch(len(in.Entries)); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	q, err := c.queueByURL(in.QueueUrl)
+	if err != nil {
+		return nil, err
+	}
+	out := &sqs.DeleteMessageBatchOutput{}
+	for _, e := range in.Entries {
+		id, _, ok := strings.Cut(aws.ToString(e.ReceiptHandle), ":")
+		if !ok {
+			out.Failed = append(out.Failed, failure(e.Id, apiError("ReceiptHandleIsInvalid", "The input receipt handle is invalid.")))
+			continue
+		}
+		// Deleting a message that is already gone succeeds, as in SQS
+		for i, m := range q.messages {
+			if m.id == id {
+				q.messages = append(q.messages[:i], q.messages[i+1:]...)
+				break
+			}
+		}
+		out.Successful = append(out.Successful, types.DeleteMessageBatchResultEntry{Id: e.Id})
+	}
+	return out, nil
+}
+
+func (c *Client) ChangeMessageVisibilityBatch(_ context.Context, in *sqs.ChangeMessageVisibilityBatchInput, _ ...func(*sqs.Options)) (*sqs.ChangeMessageVisibilityBatchOutput, error) {
+	if err := checkBatch(len(in.Entries)); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	q, err := c.queueByURL(in.QueueUrl)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	out := &sqs.ChangeMessageVisibilityBatchOutput{}
+	for _, e := range in.Entries {
+		var found *message
+		for _, m := range q.messages {
+			if m.handle != "" && m.handle == aws.ToString(e.ReceiptHandle) {
+				found = m
+				break
+			}
+		}
+		if found == nil || !now.Before(found.visibleAt) {
+			out.Failed = append(out.Failed, failure(e.Id, apiError("AWS.SimpleQueueService.MessageNotInflight", "Message does not exist or is not available for visibility timeout change.")))
+			continue
+		}
+		found.visibleAt = now.Add(time.Duration(e.VisibilityTimeout) * time.Second)
+		out.Successful = append(out.Successful, types.ChangeMessageVisibilityBatchResultEntry{Id: e.Id})
+	}
+	c.notify()
+	return out, nil
+}
+
+func checkBatch(n int) error {
+	switch {
+	case n == 0:
+		return apiError("AWS.SimpleQueueService.EmptyBatchRequest", "There should be at least one entry in the request.")
+	case n > 10:
+		return apiError("AWS.SimpleQueueService.TooManyEntriesInBatchRequest", "Maximum number of entries per request are 10. You have sent %d.", n)
+	}
+	return nil
+}
+
+func failure(id *string, err error) types.BatchResultErrorEntry {
+	code, msg := "InternalError", err.Error()
+	if e, ok := err.(interface{ ErrorCode() string }); ok {
+		code = e.ErrorCode()
+	}
+	if e, ok := err.(interface{ ErrorMessage() string }); ok {
+		msg = e.ErrorMessage()
+	}
+	return types.BatchResultErrorEntry{Id: id, Code: aws.String(code), Message: aws.String(msg), SenderFault: true}
+}
@@ -0,0 +1,211 @@
+// Package metrics reads historical queue statistics from CloudWatch. GetQueueAttributes
+// only reports approximate current counts; CloudWatch keeps per-period sends, receives,
+// deletes and message age for weeks, which is what trends need.
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+
+	"github.com/pachecoc/sqs-ui/internal/validate"
+)
+
+// CloudWatchAPI is the subset of the CloudWatch client used here.
+type CloudWatchAPI interface {
+	GetMetricData(ctx context.Context, in *cloudwatch.GetMetricDataInput, optFns ...func(*cloudwatch.Options)) (*cloudwatch.GetMetricDataOutput, error)
+}
+
+// Metrics served, with the statistic that makes sense for each: counts are summed per
+// period, the oldest message's age is its maximum.
+var stats = map[string]types.Statistic{
+	"NumberOfMessagesSent":          types.StatisticSum,
+	"NumberOfMessagesReceived":      types.StatisticSum,
+	"NumberOfMessagesDeleted":       types.StatisticSum,
+	"ApproximateAgeOfOldestMessage": types.StatisticMaximum,
+}
+
+// Names lists the metrics served, in response order.
+var Names = []string{"NumberOfMessagesSent", "NumberOfMessagesReceived", "NumberOfMessagesDeleted", "ApproximateAgeOfOldestMessage"}
+
+// Ranges are the named time ranges accepted by ParseQuery.
+var Ranges = map[string]time.Duration{
+	"1h":  time.Hour,
+	"3h":  3 * time.Hour,
+	"12h": 12 * time.Hour,
+	"24h": 24 * time.Hour,
+	"3d":  3 * 24 * time.Hour,
+	"7d":  7 * 24 * time.Hour,
+	"14d": 14 * 24 * time.Hour,
+}
+
+const (
+	defaultRange = "3h"
+	// maxSpan is how long CloudWatch keeps 5-minute data.
+	maxSpan = 63 * 24 * time.Hour
+	// targetPoints is roughly how many points a series has when the period is picked.
+	targetPoints = 120
+)
+
+// Query selects the metrics and the time range to fetch.
+type Query struct {
+	Metrics []string
+	Start   time.Time
+	End     time.Time
+	Period  time.Duration
+}
+
+// ParseQuery reads ?range= (one of Ranges) or ?start=&end= (RFC 3339), an optional
+// ?period= in seconds and an optional comma-separated ?metrics=. The period defaults to
+// one giving about targetPoints points; ranges starting more than 15 days ago need
+// 5-minute multiples, as CloudWatch only keeps 1-minute data that long.
+func ParseQuery(q url.Values, now time.Time) (Query, error) {
+	var v validate.Validator
+	query := Query{Metrics: Names, End: now}
+
+	if raw := q.Get("metrics"); raw != "" {
+		query.Metrics = nil
+		for _, m := range strings.Split(raw, ",") {
+			m = strings.TrimSpace(m)
+			if v.Check(stats[m] != "", "metrics", fmt.Sprintf("unknown metric %q (one of %s)", m, strings.Join(Names, ", "))) {
+				query.Metrics = append(query.Metrics, m)
+			}
+		}
+	}
+
+	start, end := q.Get("start"), q.Get("end")
+	switch {
+	case start != "" || end != "":
+		v.Check(q.Get("range") == "", "range", "cannot be combined with start and end")
+		var err error
+		if query.Start, err = time.Parse(time.RFC3339, start); err != nil {
+			v.Add("start", "must be an RFC 3339 time")
+		}
+		if end != "" {
+			if query.End, err = time.Parse(time.RFC3339, end); err != nil {
+				v.Add("end", "must be an RFC 3339 time")
+			}
+		}
+	default:
+		name := q.Get("range")
+		if name == "" {
+			name = defaultRange
+		}
+		span, ok := Ranges[name]
+		v.Check(ok, "range", "must be one of 1h, 3h, 12h, 24h, 3d, 7d, 14d")
+		query.Start = now.Add(-span)
+	}
+	if err := v.Err(); err != nil {
+		return Query{}, err
+	}
+	v.Check(query.Start.Before(query.End), "start", "must be before end")
+	v.Check(now.Sub(query.Start) <= maxSpan, "start", "must be within the last 63 days")
+
+	step := time.Minute
+	if now.Sub(query.Start) > 15*24*time.Hour {
+		step = 5 * time.Minute
+	}
+	if raw := q.Get("period"); raw != "" {
+		secs, err := strconv.Atoi(raw)
+		query.Period = time.Duration(secs) * time.Second
+		v.Check(err == nil && secs > 0 && query.Period%step == 0, "period", fmt.Sprintf("must be a positive multiple of %d seconds for this range", int(step.Seconds())))
+	} else {
+		query.Period = max(step, (query.End.Sub(query.Start) / targetPoints).Truncate(step))
+	}
+	return query, v.Err()
+}
+
+// Point is one period's value.
+type Point struct {
+	At    time.Time `json:"at"`
+	Value float64   `json:"value"`
+}
+
+// Series is one metric over the range, oldest point first. Periods without data are
+// absent rather than zero.
+type Series struct {
+	Metric string  `json:"metric"`
+	Stat   string  `json:"stat"`
+	Points []Point `json:"points"`
+}
+
+// History is the response of Fetch.
+type History struct {
+	QueueName     string    `json:"queue_name"`
+	Start         time.Time `json:"start"`
+	End           time.Time `json:"end"`
+	PeriodSeconds int       `json:"period_seconds"`
+	Series        []Series  `json:"series"`
+}
+
+// Fetcher reads queue metrics with API.
+type Fetcher struct {
+	API CloudWatchAPI
+}
+
+// Fetch returns the queried metrics of queue. region, when set, overrides the client's
+// region so queues selected in another region read their own metrics.
+func (f *Fetcher) Fetch(ctx context.Context, queue, region string, q Query) (History, error) {
+	in := &cloudwatch.GetMetricDataInput{
+		StartTime: aws.Time(q.Start),
+		EndTime:   aws.Time(q.End),
+		ScanBy:    types.ScanByTimestampAscending,
+	}
+	for i, name := range q.Metrics {
+		in.MetricDataQueries = append(in.MetricDataQueries, types.MetricDataQuery{
+			Id:    aws.String(fmt.Sprintf("m%d", i)),
+			Label: aws.String(name),
+			MetricStat: &types.MetricStat{
+				Metric: &types.Metric{
+					Namespace:  aws.String("AWS/SQS"),
+					MetricName: aws.String(name),
+					Dimensions: []types.Dimension{{Name: aws.String("QueueName"), Value: aws.String(queue)}},
+				},
+				Period: aws.Int32(int32(q.Period.Seconds())),
+				Stat:   aws.String(string(stats[name])),
+			},
+		})
+	}
+	var opts []func(*cloudwatch.Options)
+	if region != "" {
+		opts = append(opts, func(o *cloudwatch.Options) { o.Region = region })
+	}
+
+	h := History{
+		QueueName:     queue,
+		Start:         q.Start.UTC(),
+		End:           q.End.UTC(),
+		PeriodSeconds: int(q.Period.Seconds()),
+		Series:        make([]Series, len(q.Metrics)),
+	}
+	for i, name := range q.Metrics {
+		h.Series[i] = Series{Metric: name, Stat: string(stats[name]), Points: []Point{}}
+	}
+	for {
+		out, err := f.API.GetMetricData(ctx, in, opts...)
+		if err != nil {
+			return History{}, err
+		}
+		for _, res := range out.MetricDataResults {
+			i := slices.IndexFunc(in.MetricDataQueries, func(mq types.MetricDataQuery) bool { return aws.ToString(mq.Id) == aws.ToString(res.Id) })
+			if i < 0 {
+				continue
+			}
+			for j := range min(len(res.Timestamps), len(res.Values)) {
+				h.Series[i].Points = append(h.Series[i].Points, Point{At: res.Timestamps[j].UTC(), Value: res.Values[j]})
+			}
+		}
+		if out.NextToken == nil {
+			return h, nil
+		}
+		in.NextToken = out.NextToken
+	}
+}
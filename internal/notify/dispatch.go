@@ -0,0 +1,105 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pachecoc/sqs-ui/internal/events"
+	"github.com/pachecoc/sqs-ui/internal/plugin"
+)
+
+// Rule sends events of one type ("*" for all) to a notifier.
+type Rule struct {
+	EventType string
+	Notifier  Notifier
+}
+
+// Dispatcher forwards hub events to notifiers according to rules, and to every plugin sink.
+type Dispatcher struct {
+	Events *events.Hub
+	Rules  []Rule
+	Sinks  []plugin.Sink
+	Log    *slog.Logger
+}
+
+// Run blocks until ctx is canceled.
+func (d *Dispatcher) Run(ctx context.Context) {
+	ch, cancel := d.Events.Subscribe(0)
+	defer cancel()
+
+	d.Log.Info("notification dispatcher started", "rules", len(d.Rules), "sinks", len(d.Sinks))
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case e, ok := <-ch:
+			if !ok {
+				return
+			}
+			d.dispatch(ctx, e)
+		}
+	}
+}
+
+func (d *Dispatcher) dispatch(ctx context.Context, e events.Event) {
+	for _, r := range d.Rules {
+		if r.EventType != "*" && r.EventType != e.Type {
+			continue
+		}
+		sendCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		if err := r.Notifier.Notify(sendCtx, FromEvent(e)); err != nil {
+			d.Log.Warn("failed to deliver notification", "event_type", e.Type, "error", err)
+		}
+		cancel()
+	}
+	for _, s := range d.Sinks {
+		sendCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		if err := s.Handle(sendCtx, e); err != nil {
+			d.Log.Warn("notification sink failed", "sink", s.Name(), "event_type", e.Type, "error", err)
+		}
+		cancel()
+	}
+}
+
+// FromEvent renders a hub event as a Message.
+func FromEvent(e events.Event) Message {
+	m := Message{
+		Title: fmt.Sprintf("%s: %s", strings.ToUpper(e.Level), e.Message),
+		Text:  fmt.Sprintf("%s at %s", e.Type, e.Time.Format(time.RFC3339)),
+	}
+	keys := make([]string, 0, len(e.Data))
+	for k := range e.Data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		m.Fields = append(m.Fields, Field{Name: k, Value: fmt.Sprint(e.Data[k])})
+	}
+	return m
+}
+
+// ParseEmailRules reads "type=addr,addr;type=addr" (type "*" matches every event)
+// into per-type recipient lists.
+func ParseEmailRules(spec string) (map[string][]string, error) {
+	rules := map[string][]string{}
+	for _, part := range strings.Split(spec, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		kind, addrs, ok := strings.Cut(part, "=")
+		if !ok || strings.TrimSpace(kind) == "" {
+			return nil, fmt.Errorf("invalid email rule %q (expected type=addr,addr)", part)
+		}
+		for _, a := range strings.Split(addrs, ",") {
+			if a = strings.TrimSpace(a); a != "" {
+				rules[strings.TrimSpace(kind)] = append(rules[strings.TrimSpace(kind)], a)
+			}
+		}
+	}
+	return rules, nil
+}
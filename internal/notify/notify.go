@@ -0,0 +1,124 @@
+// Package notify delivers human-readable notices (digests, alerts, job results) to chat webhooks.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Webhook formats.
+const (
+	FormatSlack = "slack"
+	FormatTeams = "teams"
+)
+
+var httpClient = &http.Client{Timeout: 10 * time.Second}
+
+// Message is a channel-agnostic notice: a title, free text and name/value facts.
+type Message struct {
+	Title  string
+	Text   string
+	Fields []Field
+}
+
+// Field is one name/value fact in a Message.
+type Field struct {
+	Name  string
+	Value string
+}
+
+// Notifier delivers messages to one destination.
+type Notifier interface {
+	Notify(ctx context.Context, m Message) error
+}
+
+// Webhook posts messages to a Slack incoming webhook or a Microsoft Teams connector.
+type Webhook struct {
+	URL    string
+	Format string // FormatSlack or FormatTeams; detected from the URL when empty
+}
+
+// Notify implements Notifier.
+func (w *Webhook) Notify(ctx context.Context, m Message) error {
+	format := w.Format
+	if format == "" {
+		format = DetectFormat(w.URL)
+	}
+	if format == FormatTeams {
+		return PostJSON(ctx, w.URL, teamsCard(m))
+	}
+	return PostJSON(ctx, w.URL, slackPayload(m))
+}
+
+// DetectFormat guesses the webhook flavor from its host.
+func DetectFormat(url string) string {
+	if strings.Contains(url, ".office.com") || strings.Contains(url, ".logic.azure.com") {
+		return FormatTeams
+	}
+	return FormatSlack
+}
+
+func slackPayload(m Message) map[string]any {
+	blocks := []map[string]any{{
+		"type": "header",
+		"text": map[string]any{"type": "plain_text", "text": m.Title},
+	}}
+	if m.Text != "" {
+		blocks = append(blocks, map[string]any{
+			"type": "section",
+			"text": map[string]any{"type": "mrkdwn", "text": m.Text},
+		})
+	}
+	// Slack allows at most 10 fields per section
+	for i := 0; i < len(m.Fields); i += 10 {
+		var fields []map[string]any
+		for _, f := range m.Fields[i:min(i+10, len(m.Fields))] {
+			fields = append(fields, map[string]any{"type": "mrkdwn", "text": fmt.Sprintf("*%s*\n%s", f.Name, f.Value)})
+		}
+		blocks = append(blocks, map[string]any{"type": "section", "fields": fields})
+	}
+	return map[string]any{"text": m.Title, "blocks": blocks}
+}
+
+func teamsCard(m Message) map[string]any {
+	facts := make([]map[string]string, 0, len(m.Fields))
+	for _, f := range m.Fields {
+		facts = append(facts, map[string]string{"name": f.Name, "value": f.Value})
+	}
+	return map[string]any{
+		"@type":    "MessageCard",
+		"@context": "http://schema.org/extensions",
+		"summary":  m.Title,
+		"title":    m.Title,
+		"text":     m.Text,
+		"sections": []map[string]any{{"facts": facts}},
+	}
+}
+
+// PostJSON sends v as JSON to a webhook URL.
+func PostJSON(ctx context.Context, target string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,203 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// PatchOp is one RFC 6902 JSON Patch operation.
+type PatchOp struct {
+	Op    string `json:"op"`
+	Path  string `json:"path"`
+	From  string `json:"from,omitempty"`
+	Value any    `json:"value,omitempty"`
+}
+
+func (op PatchOp) validate() error {
+	switch op.Op {
+	case "add", "replace", "test":
+	case "remove":
+	case "move", "copy":
+		if op.From == "" {
+			return fmt.Errorf("%s requires from", op.Op)
+		}
+	default:
+		return fmt.Errorf("unsupported patch op %q", op.Op)
+	}
+	if op.Path != "" && !strings.HasPrefix(op.Path, "/") {
+		return fmt.Errorf("invalid path %q", op.Path)
+	}
+	return nil
+}
+
+// applyPatch applies ops to a JSON document.
+func applyPatch(doc []byte, ops []PatchOp) ([]byte, error) {
+	var root any
+	if err := json.Unmarshal(doc, &root); err != nil {
+		return nil, fmt.Errorf("body is not JSON: %w", err)
+	}
+
+	for _, op := range ops {
+		var err error
+		switch op.Op {
+		case "add":
+			root, err = setPointer(root, op.Path, op.Value, true)
+		case "replace":
+			if _, err = getPointer(root, op.Path); err == nil {
+				root, err = setPointer(root, op.Path, op.Value, false)
+			}
+		case "remove":
+			root, err = removePointer(root, op.Path)
+		case "move", "copy":
+			var v any
+			if v, err = getPointer(root, op.From); err == nil {
+				if op.Op == "move" {
+					root, err = removePointer(root, op.From)
+				}
+				if err == nil {
+					root, err = setPointer(root, op.Path, v, true)
+				}
+			}
+		case "test":
+			var v any
+			if v, err = getPointer(root, op.Path); err == nil && !jsonEqual(v, op.Value) {
+				err = fmt.Errorf("test failed at %s", op.Path)
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s %s: %w", op.Op, op.Path, err)
+		}
+	}
+	return json.Marshal(root)
+}
+
+func splitPointer(path string) []string {
+	if path == "" {
+		return nil
+	}
+	parts := strings.Split(path[1:], "/")
+	for i, p := range parts {
+		parts[i] = strings.ReplaceAll(strings.ReplaceAll(p, "~1", "/"), "~0", "~")
+	}
+	return parts
+}
+
+func getPointer(root any, path string) (any, error) {
+	cur := root
+	for _, tok := range splitPointer(path) {
+		switch node := cur.(type) {
+		case map[string]any:
+			v, ok := node[tok]
+			if !ok {
+				return nil, fmt.Errorf("path not found")
+			}
+			cur = v
+		case []any:
+			i, err := strconv.Atoi(tok)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil, fmt.Errorf("index %q out of range", tok)
+			}
+			cur = node[i]
+		default:
+			return nil, fmt.Errorf("path not found")
+		}
+	}
+	return cur, nil
+}
+
+// setPointer sets (insert=true: adds, inserting into arrays) the value at path and returns the new root.
+func setPointer(root any, path string, value any, insert bool) (any, error) {
+	toks := splitPointer(path)
+	if len(toks) == 0 {
+		return value, nil
+	}
+	parent, err := getPointer(root, "/"+strings.Join(escapeTokens(toks[:len(toks)-1]), "/"))
+	if len(toks) == 1 {
+		parent, err = root, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	last := toks[len(toks)-1]
+	switch node := parent.(type) {
+	case map[string]any:
+		node[last] = value
+		return root, nil
+	case []any:
+		var i int
+		if last == "-" && insert {
+			i = len(node)
+		} else if i, err = strconv.Atoi(last); err != nil || i < 0 || i > len(node) || (!insert && i == len(node)) {
+			return nil, fmt.Errorf("index %q out of range", last)
+		}
+		if insert {
+			node = append(node[:i], append([]any{value}, node[i:]...)...)
+		} else {
+			node[i] = value
+		}
+		return replaceParent(root, toks[:len(toks)-1], node)
+	default:
+		return nil, fmt.Errorf("parent is not an object or array")
+	}
+}
+
+func removePointer(root any, path string) (any, error) {
+	toks := splitPointer(path)
+	if len(toks) == 0 {
+		return nil, fmt.Errorf("cannot remove the whole document")
+	}
+	parent, err := root, error(nil)
+	if len(toks) > 1 {
+		parent, err = getPointer(root, "/"+strings.Join(escapeTokens(toks[:len(toks)-1]), "/"))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	last := toks[len(toks)-1]
+	switch node := parent.(type) {
+	case map[string]any:
+		if _, ok := node[last]; !ok {
+			return nil, fmt.Errorf("path not found")
+		}
+		delete(node, last)
+		return root, nil
+	case []any:
+		i, err := strconv.Atoi(last)
+		if err != nil || i < 0 || i >= len(node) {
+			return nil, fmt.Errorf("index %q out of range", last)
+		}
+		return replaceParent(root, toks[:len(toks)-1], append(node[:i], node[i+1:]...))
+	default:
+		return nil, fmt.Errorf("parent is not an object or array")
+	}
+}
+
+// replaceParent stores a resized array back into its parent (slices can't be updated in place).
+func replaceParent(root any, toks []string, arr []any) (any, error) {
+	if len(toks) == 0 {
+		return arr, nil
+	}
+	return setPointer(root, "/"+strings.Join(escapeTokens(toks), "/"), arr, false)
+}
+
+func escapeTokens(toks []string) []string {
+	out := make([]string, len(toks))
+	for i, t := range toks {
+		out[i] = strings.ReplaceAll(strings.ReplaceAll(t, "~", "~0"), "/", "~1")
+	}
+	return out
+}
+
+func jsonEqual(a, b any) bool {
+	ja, _ := json.Marshal(a)
+	jb, _ := json.Marshal(b)
+	var va, vb any
+	json.Unmarshal(ja, &va)
+	json.Unmarshal(jb, &vb)
+	return reflect.DeepEqual(va, vb)
+}
@@ -0,0 +1,193 @@
+// Package pipeline applies an ordered list of message transformations (JSON patch, template
+// rendering, scripts). A pipeline spec is attached to move/replay/forward jobs and can be
+// previewed on sample messages before anything is sent.
+package pipeline
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/pachecoc/sqs-ui/internal/scripts"
+)
+
+// Step types.
+const (
+	StepJSONPatch = "json_patch"
+	StepTemplate  = "template"
+	StepScript    = "script"
+)
+
+// Spec is the serializable form of a pipeline.
+type Spec struct {
+	Steps []Step `json:"steps"`
+}
+
+// Step is one transformation. Only the field matching Type is used.
+type Step struct {
+	Type     string    `json:"type"`
+	Patch    []PatchOp `json:"patch,omitempty"`    // json_patch: RFC 6902 operations on the JSON body
+	Template string    `json:"template,omitempty"` // template: Go text/template producing the new body
+	Script   string    `json:"script,omitempty"`   // script: name of a stored transform script
+}
+
+// Message is what flows through a pipeline.
+type Message struct {
+	MessageID string `json:"message_id"`
+	Body      string `json:"body"`
+}
+
+// Pipeline is a compiled Spec.
+type Pipeline struct {
+	steps   []func(ctx context.Context, m Message) (Message, error)
+	scripts *scripts.Manager
+}
+
+// Preview is the before/after of one sample message.
+type Preview struct {
+	MessageID string `json:"message_id"`
+	Before    string `json:"before"`
+	After     string `json:"after,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Compile validates spec. sm resolves script steps and may be nil when none are used.
+func Compile(ctx context.Context, spec Spec, sm *scripts.Manager) (*Pipeline, error) {
+	p := &Pipeline{scripts: sm}
+	for i, st := range spec.Steps {
+		fn, err := p.compileStep(ctx, st)
+		if err != nil {
+			return nil, fmt.Errorf("step %d (%s): %w", i+1, st.Type, err)
+		}
+		p.steps = append(p.steps, fn)
+	}
+	return p, nil
+}
+
+// Apply runs every step in order.
+func (p *Pipeline) Apply(ctx context.Context, m Message) (Message, error) {
+	for i, fn := range p.steps {
+		var err error
+		if m, err = fn(ctx, m); err != nil {
+			return Message{}, fmt.Errorf("step %d: %w", i+1, err)
+		}
+	}
+	return m, nil
+}
+
+// Empty reports whether the pipeline has no steps.
+func (p *Pipeline) Empty() bool { return p == nil || len(p.steps) == 0 }
+
+// PreviewAll runs the pipeline over samples without side effects.
+func (p *Pipeline) PreviewAll(ctx context.Context, samples []Message) []Preview {
+	out := make([]Preview, 0, len(samples))
+	for _, m := range samples {
+		pv := Preview{MessageID: m.MessageID, Before: m.Body}
+		if after, err := p.Apply(ctx, m); err != nil {
+			pv.Error = err.Error()
+		} else {
+			pv.After = after.Body
+		}
+		out = append(out, pv)
+	}
+	return out
+}
+
+// ParseSpec decodes a spec from a job param (already JSON-decoded into generic values).
+func ParseSpec(v any) (Spec, error) {
+	var spec Spec
+	if v == nil {
+		return spec, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return spec, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&spec); err != nil {
+		return spec, fmt.Errorf("invalid pipeline: %w", err)
+	}
+	return spec, nil
+}
+
+func (p *Pipeline) compileStep(ctx context.Context, st Step) (func(context.Context, Message) (Message, error), error) {
+	switch st.Type {
+	case StepJSONPatch:
+		if len(st.Patch) == 0 {
+			return nil, errors.New("patch is empty")
+		}
+		for _, op := range st.Patch {
+			if err := op.validate(); err != nil {
+				return nil, err
+			}
+		}
+		return func(_ context.Context, m Message) (Message, error) {
+			body, err := applyPatch([]byte(m.Body), st.Patch)
+			if err != nil {
+				return Message{}, err
+			}
+			m.Body = string(body)
+			return m, nil
+		}, nil
+
+	case StepTemplate:
+		tmpl, err := template.New("step").Option("missingkey=error").Funcs(templateFuncs).Parse(st.Template)
+		if err != nil {
+			return nil, err
+		}
+		return func(_ context.Context, m Message) (Message, error) {
+			data := map[string]any{"MessageId": m.MessageID, "Body": m.Body}
+			var parsed any
+			if json.Unmarshal([]byte(m.Body), &parsed) == nil {
+				data["JSON"] = parsed
+			}
+			var b strings.Builder
+			if err := tmpl.Execute(&b, data); err != nil {
+				return Message{}, err
+			}
+			m.Body = b.String()
+			return m, nil
+		}, nil
+
+	case StepScript:
+		if p.scripts == nil {
+			return nil, errors.New("scripts are not enabled")
+		}
+		s, err := p.scripts.Get(ctx, st.Script)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", err, st.Script)
+		}
+		if s.Kind != scripts.KindTransform {
+			return nil, fmt.Errorf("script %s is not a transform", s.Name)
+		}
+		return func(ctx context.Context, m Message) (Message, error) {
+			out, err := p.scripts.Run(ctx, s, map[string]any{"message_id": m.MessageID, "body": m.Body})
+			if err != nil {
+				return Message{}, err
+			}
+			body, ok := out.(map[string]any)["body"].(string)
+			if !ok {
+				return Message{}, errors.New("script result has no string body")
+			}
+			m.Body = body
+			return m, nil
+		}, nil
+
+	default:
+		return nil, fmt.Errorf("unknown step type %q", st.Type)
+	}
+}
+
+var templateFuncs = template.FuncMap{
+	"json": func(v any) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+}
@@ -0,0 +1,115 @@
+// Package builtin registers the extensions shipped with sqs-ui.
+package builtin
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"strings"
+
+	"github.com/pachecoc/sqs-ui/internal/plugin"
+)
+
+func init() {
+	plugin.RegisterDecoder(base64JSON{})
+	plugin.RegisterDecoder(snsEnvelope{})
+}
+
+// maxDecodedSize caps decompressed payloads.
+const maxDecodedSize = 1 << 20
+
+// base64JSON decodes bodies that are base64 (optionally gzipped) JSON, a common way to
+// squeeze binary-safe or compressed payloads through SQS.
+type base64JSON struct{}
+
+func (base64JSON) Name() string { return "base64-json" }
+
+func (base64JSON) Decode(_ context.Context, m plugin.Message) (any, bool, error) {
+	body := strings.TrimSpace(m.Body)
+	if body == "" || strings.HasPrefix(body, "{") || strings.HasPrefix(body, "[") {
+		return nil, false, nil
+	}
+	raw, err := base64.StdEncoding.DecodeString(body)
+	if err != nil {
+		return nil, false, nil
+	}
+
+	// gzip magic number
+	if len(raw) > 2 && raw[0] == 0x1f && raw[1] == 0x8b {
+		zr, err := gzip.NewReader(bytes.NewReader(raw))
+		if err != nil {
+			return nil, false, nil
+		}
+		if raw, err = io.ReadAll(io.LimitReader(zr, maxDecodedSize)); err != nil {
+			return nil, false, nil
+		}
+	}
+
+	var v any
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return nil, false, nil
+	}
+	return v, true, nil
+}
+
+// snsEnvelope unwraps SNS notifications delivered to SQS without raw message delivery: the
+// body is SNS's JSON envelope and the payload is the string in its Message field.
+type snsEnvelope struct{}
+
+func (snsEnvelope) Name() string { return "sns" }
+
+func (snsEnvelope) Decode(ctx context.Context, m plugin.Message) (any, bool, error) {
+	body := strings.TrimSpace(m.Body)
+	if !strings.HasPrefix(body, "{") {
+		return nil, false, nil
+	}
+	var env struct {
+		Type              string
+		MessageID         string `json:"MessageId"`
+		TopicArn          string
+		Subject           string
+		Message           *string
+		Timestamp         string
+		MessageAttributes map[string]struct{ Type, Value string }
+	}
+	if err := json.Unmarshal([]byte(body), &env); err != nil {
+		return nil, false, nil
+	}
+	if env.Type != "Notification" || env.TopicArn == "" || env.Message == nil {
+		return nil, false, nil
+	}
+
+	out := map[string]any{
+		"topic_arn":  env.TopicArn,
+		"message_id": env.MessageID,
+		"timestamp":  env.Timestamp,
+		"message":    snsPayload(ctx, *env.Message),
+	}
+	if env.Subject != "" {
+		out["subject"] = env.Subject
+	}
+	if len(env.MessageAttributes) > 0 {
+		attrs := make(map[string]string, len(env.MessageAttributes))
+		for name, a := range env.MessageAttributes {
+			attrs[name] = a.Value
+		}
+		out["message_attributes"] = attrs
+	}
+	return out, true, nil
+}
+
+// snsPayload decodes the published message when it is JSON, plain or base64/gzip, and
+// returns it as the string it was otherwise.
+func snsPayload(ctx context.Context, msg string) any {
+	var v any
+	if err := json.Unmarshal([]byte(msg), &v); err == nil {
+		return v
+	}
+	if v, ok, _ := (base64JSON{}).Decode(ctx, plugin.Message{Body: msg}); ok {
+		return v
+	}
+	return msg
+}
@@ -0,0 +1,138 @@
+package builtin
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/pachecoc/sqs-ui/internal/plugin"
+)
+
+// gzipBase64 returns s gzipped and base64-encoded, the way producers squeeze payloads.
+func gzipBase64(s string) string {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	_, _ = zw.Write([]byte(s))
+	_ = zw.Close()
+	return base64.StdEncoding.EncodeToString(buf.Bytes())
+}
+
+func FuzzBase64JSON(f *testing.F) {
+	f.Add(`{"order_id":42}`)
+	f.Add(base64.StdEncoding.EncodeToString([]byte(`{"order_id":42}`)))
+	f.Add(base64.StdEncoding.EncodeToString([]byte(`[1,2,3]`)))
+	f.Add(gzipBase64(`{"order_id":42,"items":["a","b"]}`))
+	f.Add(gzipBase64(`not json`))
+	f.Add(gzipBase64(`{"order_id":42}`)[:12])
+	f.Add("H4sI")
+	f.Add("not base64 at all")
+	f.Add("   ")
+	f.Fuzz(func(t *testing.T, body string) {
+		v, ok, err := base64JSON{}.Decode(context.Background(), plugin.Message{Body: body})
+		if err != nil {
+			t.Fatalf("body %q: decoders report unknown formats with ok=false, got error %v", body, err)
+		}
+		if !ok {
+			if v != nil {
+				t.Fatalf("body %q: ok=false with a value %v", body, v)
+			}
+			return
+		}
+		if trimmed := strings.TrimSpace(body); strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[") {
+			t.Fatalf("body %q: plain JSON is left to the default rendering", body)
+		}
+		if _, err := json.Marshal(v); err != nil {
+			t.Fatalf("body %q: decoded value %v doesn't encode back to JSON: %v", body, v, err)
+		}
+	})
+}
+
+// notification returns an SNS envelope carrying message, as SQS receives it from a topic
+// subscription without raw message delivery.
+func notification(message string) string {
+	env, _ := json.Marshal(map[string]any{
+		"Type":      "Notification",
+		"MessageId": "5e1c6c4c-0f6e-5a4f-9a8e-1f2a3b4c5d6e",
+		"TopicArn":  "arn:aws:sns:us-east-1:123456789012:orders",
+		"Subject":   "order placed",
+		"Message":   message,
+		"Timestamp": "2024-05-01T12:00:00.000Z",
+		"MessageAttributes": map[string]any{
+			"kind": map[string]string{"Type": "String", "Value": "created"},
+		},
+	})
+	return string(env)
+}
+
+func TestSNSEnvelope(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		ok      bool
+		message any
+	}{
+		{"json message", notification(`{"order_id":42}`), true, map[string]any{"order_id": float64(42)}},
+		{"base64 message", notification(gzipBase64(`{"order_id":42}`)), true, map[string]any{"order_id": float64(42)}},
+		{"text message", notification("order 42 placed"), true, "order 42 placed"},
+		{"subscription confirmation", `{"Type":"SubscriptionConfirmation","TopicArn":"arn:aws:sns:us-east-1:123456789012:orders","Message":"confirm"}`, false, nil},
+		{"no message", `{"Type":"Notification","TopicArn":"arn:aws:sns:us-east-1:123456789012:orders"}`, false, nil},
+		{"plain json", `{"order_id":42}`, false, nil},
+		{"not json", "order 42 placed", false, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, ok, err := snsEnvelope{}.Decode(context.Background(), plugin.Message{Body: tt.body})
+			if err != nil || ok != tt.ok {
+				t.Fatalf("got ok=%v err=%v, want ok=%v", ok, err, tt.ok)
+			}
+			if !ok {
+				return
+			}
+			got := v.(map[string]any)
+			if got["topic_arn"] != "arn:aws:sns:us-east-1:123456789012:orders" || got["subject"] != "order placed" {
+				t.Errorf("envelope fields: %v", got)
+			}
+			if attrs, _ := got["message_attributes"].(map[string]string); attrs["kind"] != "created" {
+				t.Errorf("message_attributes: %v", got["message_attributes"])
+			}
+			want, _ := json.Marshal(tt.message)
+			if have, _ := json.Marshal(got["message"]); string(have) != string(want) {
+				t.Errorf("message: got %s, want %s", have, want)
+			}
+		})
+	}
+}
+
+func FuzzSNSEnvelope(f *testing.F) {
+	f.Add(notification(`{"order_id":42}`))
+	f.Add(notification(gzipBase64(`{"order_id":42}`)))
+	f.Add(notification("order 42 placed"))
+	f.Add(notification(""))
+	f.Add(`{"Type":"Notification","TopicArn":"arn","Message":null}`)
+	f.Add(`{"Type":"Notification","TopicArn":"arn","Message":"H4sI","MessageAttributes":{"a":null}}`)
+	f.Add(`{"Type":"Notification","TopicArn":"arn","Message":"x","MessageAttributes":[]}`)
+	f.Add(`{"Type":"Notification"`)
+	f.Add("not json")
+	f.Fuzz(func(t *testing.T, body string) {
+		v, ok, err := snsEnvelope{}.Decode(context.Background(), plugin.Message{Body: body})
+		if err != nil {
+			t.Fatalf("body %q: decoders report unknown formats with ok=false, got error %v", body, err)
+		}
+		if !ok {
+			if v != nil {
+				t.Fatalf("body %q: ok=false with a value %v", body, v)
+			}
+			return
+		}
+		if got, _ := v.(map[string]any); got == nil || got["topic_arn"] == "" {
+			t.Fatalf("body %q: decoded without a topic ARN: %v", body, v)
+		}
+		if _, err := json.Marshal(v); err != nil {
+			t.Fatalf("body %q: decoded value %v doesn't encode back to JSON: %v", body, v, err)
+		}
+	})
+}
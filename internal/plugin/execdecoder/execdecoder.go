@@ -0,0 +1,148 @@
+// Package execdecoder runs an external command as a payload decoder: the message body is
+// written to its stdin and its stdout must be JSON.
+package execdecoder
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pachecoc/sqs-ui/internal/plugin"
+)
+
+const (
+	maxOutput     = 1 << 20
+	maxConcurrent = 2
+)
+
+// ErrRateLimited is returned when decodes exceed the configured rate.
+var ErrRateLimited = errors.New("decoder rate limit exceeded")
+
+// Decoder invokes Command for messages of the configured queues.
+type Decoder struct {
+	Command []string      // program and arguments; no shell is involved
+	Queues  []string      // queues this decoder applies to (all when empty)
+	Timeout time.Duration // per invocation; the process is killed when exceeded
+	Rate    int           // max invocations per second
+
+	sem    chan struct{}
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// New validates the command and returns a decoder.
+func New(command string, queues []string, timeout time.Duration, rate int) (*Decoder, error) {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return nil, errors.New("decoder command is empty")
+	}
+	path, err := exec.LookPath(args[0])
+	if err != nil {
+		return nil, fmt.Errorf("decoder command: %w", err)
+	}
+	args[0] = path
+
+	return &Decoder{
+		Command: args,
+		Queues:  queues,
+		Timeout: timeout,
+		Rate:    max(rate, 1),
+		sem:     make(chan struct{}, maxConcurrent),
+		tokens:  float64(max(rate, 1)),
+		last:    time.Now(),
+	}, nil
+}
+
+func (d *Decoder) Name() string { return "exec:" + d.Command[0] }
+
+// Decode implements plugin.Decoder.
+func (d *Decoder) Decode(ctx context.Context, m plugin.Message) (any, bool, error) {
+	if len(d.Queues) > 0 && !slices.Contains(d.Queues, m.QueueName) {
+		return nil, false, nil
+	}
+	if !d.allow() {
+		return nil, false, ErrRateLimited
+	}
+
+	select {
+	case d.sem <- struct{}{}:
+	case <-ctx.Done():
+		// The request went away while every slot was busy
+		return nil, false, ctx.Err()
+	}
+	defer func() { <-d.sem }()
+
+	ctx, cancel := context.WithTimeout(ctx, d.Timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, d.Command[0], d.Command[1:]...)
+	// Minimal environment and a scratch working directory: the decoder sees only the body
+	cmd.Env = []string{"PATH=/usr/local/bin:/usr/bin:/bin", "SQS_QUEUE_NAME=" + m.QueueName, "SQS_MESSAGE_ID=" + m.MessageID}
+	cmd.Dir = os.TempDir()
+	cmd.Stdin = strings.NewReader(m.Body)
+	cmd.WaitDelay = time.Second
+
+	var stdout, stderr limitedBuffer
+	stdout.limit, stderr.limit = maxOutput, 4096
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return nil, false, fmt.Errorf("timed out after %s", d.Timeout)
+		}
+		return nil, false, fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	if stdout.truncated {
+		return nil, false, fmt.Errorf("output exceeds %d bytes", maxOutput)
+	}
+
+	var v any
+	if err := json.Unmarshal(stdout.Bytes(), &v); err != nil {
+		return nil, false, fmt.Errorf("output is not JSON: %w", err)
+	}
+	return v, true, nil
+}
+
+// allow is a token bucket refilled at Rate per second.
+func (d *Decoder) allow() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := time.Now()
+	d.tokens = min(float64(d.Rate), d.tokens+now.Sub(d.last).Seconds()*float64(d.Rate))
+	d.last = now
+	if d.tokens < 1 {
+		return false
+	}
+	d.tokens--
+	return true
+}
+
+// limitedBuffer keeps at most limit bytes and remembers whether more were written. The
+// buffer isn't embedded: io.Copy would use its ReadFrom and bypass the limit.
+type limitedBuffer struct {
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - b.buf.Len(); room < len(p) {
+		b.truncated = true
+		b.buf.Write(p[:max(room, 0)])
+		return len(p), nil
+	}
+	return b.buf.Write(p)
+}
+
+func (b *limitedBuffer) Bytes() []byte  { return b.buf.Bytes() }
+func (b *limitedBuffer) String() string { return b.buf.String() }
@@ -0,0 +1,118 @@
+package execdecoder
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pachecoc/sqs-ui/internal/plugin"
+)
+
+func newDecoder(t *testing.T, command string, timeout time.Duration, rate int) *Decoder {
+	t.Helper()
+	d, err := New(command, nil, timeout, rate)
+	if err != nil {
+		t.Skipf("%s: %v", command, err)
+	}
+	return d
+}
+
+func TestDecodeJSON(t *testing.T) {
+	d := newDecoder(t, "cat", time.Second, 10)
+	v, ok, err := d.Decode(context.Background(), plugin.Message{QueueName: "orders", Body: `{"id":42}`})
+	if err != nil || !ok {
+		t.Fatalf("got %v, %v", ok, err)
+	}
+	if m, _ := v.(map[string]any); m["id"] != float64(42) {
+		t.Errorf("decoded %#v", v)
+	}
+
+	if _, _, err := d.Decode(context.Background(), plugin.Message{Body: "not json"}); err == nil || !strings.Contains(err.Error(), "not JSON") {
+		t.Errorf("non-JSON output: %v", err)
+	}
+}
+
+func TestDecodeSkipsOtherQueues(t *testing.T) {
+	d := newDecoder(t, "false", time.Second, 10)
+	d.Queues = []string{"orders"}
+	if v, ok, err := d.Decode(context.Background(), plugin.Message{QueueName: "payments", Body: "{}"}); v != nil || ok || err != nil {
+		t.Errorf("got %v, %v, %v; want the decoder to pass", v, ok, err)
+	}
+}
+
+func TestDecodeKillsOnTimeout(t *testing.T) {
+	d := newDecoder(t, "sleep 10", 200*time.Millisecond, 10)
+	start := time.Now()
+	_, _, err := d.Decode(context.Background(), plugin.Message{Body: "{}"})
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Fatalf("got %v, want a timeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("returned after %s; the process wasn't killed", elapsed)
+	}
+}
+
+func TestDecodeCapsOutput(t *testing.T) {
+	d := newDecoder(t, "head -c 2000000 /dev/zero", 5*time.Second, 10)
+	if _, _, err := d.Decode(context.Background(), plugin.Message{Body: "{}"}); err == nil || !strings.Contains(err.Error(), "output exceeds") {
+		t.Errorf("got %v, want the output cap", err)
+	}
+}
+
+func TestDecodeRateLimit(t *testing.T) {
+	d := newDecoder(t, "cat", time.Second, 1)
+	ctx := context.Background()
+	if _, _, err := d.Decode(ctx, plugin.Message{Body: "{}"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := d.Decode(ctx, plugin.Message{Body: "{}"}); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("second decode within a second: got %v, want ErrRateLimited", err)
+	}
+}
+
+func TestDecodeStripsEnvironment(t *testing.T) {
+	script := filepath.Join(t.TempDir(), "env.sh")
+	source := "#!/bin/sh\nprintf '{\"secret\":\"%s\",\"aws\":\"%s\",\"queue\":\"%s\",\"id\":\"%s\"}' \"$DECODER_TEST_SECRET\" \"$AWS_SECRET_ACCESS_KEY\" \"$SQS_QUEUE_NAME\" \"$SQS_MESSAGE_ID\"\n"
+	if err := os.WriteFile(script, []byte(source), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("DECODER_TEST_SECRET", "hunter2")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "wJalrXUtnFEMI")
+
+	d := newDecoder(t, script, 5*time.Second, 10)
+	v, _, err := d.Decode(context.Background(), plugin.Message{QueueName: "orders", MessageID: "m-1", Body: "{}"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := v.(map[string]any)
+	if got["secret"] != "" || got["aws"] != "" || got["queue"] != "orders" || got["id"] != "m-1" {
+		t.Errorf("decoder environment %v", got)
+	}
+}
+
+func TestDecodeWaitsForSlotUntilCancelled(t *testing.T) {
+	d := newDecoder(t, "cat", time.Second, 10)
+	for range maxConcurrent {
+		d.sem <- struct{}{}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		_, _, err := d.Decode(ctx, plugin.Message{Body: "{}"})
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("got %v, want the context's error", err)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("Decode kept waiting for a slot after its context ended")
+	}
+}
@@ -0,0 +1,152 @@
+// Package plugin is the extension point for organization-specific message handling.
+// Extensions register decoders, validators and notification sinks from an init function;
+// the server picks them up by importing the extension package (see cmd/server/plugins.go).
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/pachecoc/sqs-ui/internal/events"
+)
+
+// Message is what decoders and validators see of an SQS message.
+type Message struct {
+	QueueName  string
+	MessageID  string
+	Body       string
+	Attributes map[string]string
+}
+
+// Decoder turns an opaque payload into a structured value for display.
+// Decode returns ok=false when the message isn't in a format it understands.
+type Decoder interface {
+	Name() string
+	Decode(ctx context.Context, m Message) (decoded any, ok bool, err error)
+}
+
+// Validator rejects outgoing messages before they are sent.
+type Validator interface {
+	Name() string
+	Validate(ctx context.Context, m Message) error
+}
+
+// Sink receives server notifications (alerts, job completion, ...).
+type Sink interface {
+	Name() string
+	Handle(ctx context.Context, e events.Event) error
+}
+
+var (
+	mu         sync.RWMutex
+	decoders   []Decoder
+	validators []Validator
+	sinks      []Sink
+)
+
+// RegisterDecoder adds a decoder. Decoders are tried in registration order.
+func RegisterDecoder(d Decoder) {
+	mu.Lock()
+	defer mu.Unlock()
+	decoders = append(decoders, d)
+}
+
+// PrependDecoder adds a decoder ahead of those already registered. Used for queue-specific
+// decoders configured at startup, which should win over generic ones.
+func PrependDecoder(d Decoder) {
+	mu.Lock()
+	defer mu.Unlock()
+	decoders = append([]Decoder{d}, decoders...)
+}
+
+// RegisterValidator adds a validator run before every send.
+func RegisterValidator(v Validator) {
+	mu.Lock()
+	defer mu.Unlock()
+	validators = append(validators, v)
+}
+
+// RegisterSink adds a notification sink.
+func RegisterSink(s Sink) {
+	mu.Lock()
+	defer mu.Unlock()
+	sinks = append(sinks, s)
+}
+
+// Decode runs the decoders in order and returns the first result, with the decoder's name.
+func Decode(ctx context.Context, m Message) (decoded any, decoder string, err error) {
+	return DecodeWith(ctx, m, nil)
+}
+
+// DecodeWith is Decode restricted to the named decoders (all of them when names is empty).
+// A decoder that panics fails like one returning an error.
+func DecodeWith(ctx context.Context, m Message, names []string) (decoded any, decoder string, err error) {
+	mu.RLock()
+	ds := decoders
+	mu.RUnlock()
+
+	for _, d := range ds {
+		if len(names) > 0 && !slices.Contains(names, d.Name()) {
+			continue
+		}
+		v, ok, err := safeDecode(ctx, d, m)
+		if err != nil {
+			return nil, d.Name(), err
+		}
+		if ok {
+			return v, d.Name(), nil
+		}
+	}
+	return nil, "", nil
+}
+
+// safeDecode runs d, turning a panic into its error: message bodies are untrusted, and a
+// decoder tripping over one must not take the request (or a background job) down with it.
+func safeDecode(ctx context.Context, d Decoder, m Message) (v any, ok bool, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			v, ok, err = nil, false, fmt.Errorf("decoder panicked: %v", p)
+		}
+	}()
+	return d.Decode(ctx, m)
+}
+
+// Validate runs every validator and returns the first failure.
+func Validate(ctx context.Context, m Message) error {
+	mu.RLock()
+	vs := validators
+	mu.RUnlock()
+
+	for _, v := range vs {
+		if err := v.Validate(ctx, m); err != nil {
+			return fmt.Errorf("%s: %w", v.Name(), err)
+		}
+	}
+	return nil
+}
+
+// Sinks returns the registered notification sinks.
+func Sinks() []Sink {
+	mu.RLock()
+	defer mu.RUnlock()
+	return append([]Sink(nil), sinks...)
+}
+
+// Names lists registered extensions by kind, for diagnostics.
+func Names() map[string][]string {
+	mu.RLock()
+	defer mu.RUnlock()
+	out := map[string][]string{"decoders": {}, "validators": {}, "sinks": {}}
+	for _, d := range decoders {
+		out["decoders"] = append(out["decoders"], d.Name())
+	}
+	for _, v := range validators {
+		out["validators"] = append(out["validators"], v.Name())
+	}
+	for _, s := range sinks {
+		out["sinks"] = append(out["sinks"], s.Name())
+	}
+	return out
+}
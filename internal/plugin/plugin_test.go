@@ -0,0 +1,42 @@
+package plugin
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// indexDecoder returns the body byte its first byte points at, standing in for a decoder
+// with an unchecked bound: bodies that point past their own end make it panic.
+type indexDecoder struct{}
+
+func (indexDecoder) Name() string { return "index" }
+
+func (indexDecoder) Decode(_ context.Context, m Message) (any, bool, error) {
+	if m.Body == "" {
+		return nil, false, nil
+	}
+	return m.Body[m.Body[0]], true, nil
+}
+
+func FuzzSafeDecode(f *testing.F) {
+	f.Add("")
+	f.Add("\x00")
+	f.Add("\x02ab")
+	f.Add("\x03ab")
+	f.Add("hello")
+	f.Fuzz(func(t *testing.T, body string) {
+		v, ok, err := safeDecode(context.Background(), indexDecoder{}, Message{Body: body})
+		panics := body != "" && int(body[0]) >= len(body)
+		switch {
+		case panics && (err == nil || !strings.HasPrefix(err.Error(), "decoder panicked")):
+			t.Fatalf("body %q: panic not turned into an error (got %v, %v, %v)", body, v, ok, err)
+		case panics && (ok || v != nil):
+			t.Fatalf("body %q: a panicking decoder reported a result %v", body, v)
+		case !panics && err != nil:
+			t.Fatalf("body %q: unexpected error %v", body, err)
+		case !panics && ok != (body != ""):
+			t.Fatalf("body %q: ok = %v", body, ok)
+		}
+	})
+}
@@ -0,0 +1,239 @@
+// Package profiles holds per-queue settings (receive defaults, FIFO group, decoders, masking,
+// read-only) applied automatically whenever that queue is selected.
+package profiles
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pachecoc/sqs-ui/internal/plugin"
+	"github.com/pachecoc/sqs-ui/internal/service"
+	"github.com/pachecoc/sqs-ui/internal/store"
+	"github.com/pachecoc/sqs-ui/internal/validate"
+)
+
+// category is the store category holding profiles.
+const category = "profiles"
+
+// lookupTimeout bounds store lookups made while configuring a service.
+const lookupTimeout = 2 * time.Second
+
+// maskedValue replaces masked field values.
+const maskedValue = "***"
+
+// ErrNotFound is returned when no profile is stored under a key.
+var ErrNotFound = errors.New("profile not found")
+
+// Profile overrides global settings for the queues matching Queue (a name or path.Match pattern).
+// Zero values keep the global defaults.
+type Profile struct {
+	Queue             string     `json:"queue"`
+	ReceiveMode       string     `json:"receive_mode,omitempty"`
+	VisibilitySeconds int        `json:"visibility_seconds,omitempty"` // consume-mode visibility
+	WaitSeconds       int        `json:"wait_seconds,omitempty"`
+	MessageGroupID    string     `json:"message_group_id,omitempty"`
+	Decoders          []string   `json:"decoders,omitempty"` // only these decoders run on listings
+	Mask              []string   `json:"mask,omitempty"`     // JSON field names hidden in listings
+	ReadOnly          bool       `json:"read_only,omitempty"`
+	NoProvenance      bool       `json:"no_provenance,omitempty"`
+	UpdatedAt         *time.Time `json:"updated_at,omitempty"` // unset for file defaults
+}
+
+// Manager resolves profiles from the store, falling back to Defaults (e.g. loaded from a file).
+// Exact queue names win over patterns; stored profiles win over defaults.
+type Manager struct {
+	Store    store.Store
+	Defaults []Profile
+}
+
+// LoadFile reads a JSON array of profiles.
+func LoadFile(name string) ([]Profile, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	var list []Profile
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("invalid profiles file %s: %w", name, err)
+	}
+	for i := range list {
+		if err := list[i].validate(); err != nil {
+			return nil, fmt.Errorf("invalid profiles file %s: %w", name, err)
+		}
+	}
+	return list, nil
+}
+
+// Get returns the stored profile saved under key (a queue name or pattern).
+func (m *Manager) Get(ctx context.Context, key string) (Profile, error) {
+	var p Profile
+	if err := store.GetJSON(ctx, m.Store, category, key, &p); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return Profile{}, ErrNotFound
+		}
+		return Profile{}, err
+	}
+	return p, nil
+}
+
+// Save validates and stores p under p.Queue.
+func (m *Manager) Save(ctx context.Context, p Profile) (Profile, error) {
+	p.Queue = strings.TrimSpace(p.Queue)
+	if err := p.validate(); err != nil {
+		return Profile{}, err
+	}
+	now := time.Now().UTC()
+	p.UpdatedAt = &now
+	if err := store.PutJSON(ctx, m.Store, category, p.Queue, p, 0); err != nil {
+		return Profile{}, err
+	}
+	return p, nil
+}
+
+// Delete removes a stored profile.
+func (m *Manager) Delete(ctx context.Context, key string) error {
+	return m.Store.Delete(ctx, category, key)
+}
+
+// List returns the stored profiles followed by the defaults.
+func (m *Manager) List(ctx context.Context) ([]Profile, error) {
+	out, err := m.stored(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return append(out, m.Defaults...), nil
+}
+
+func (m *Manager) stored(ctx context.Context) ([]Profile, error) {
+	keys, err := m.Store.List(ctx, category)
+	if err != nil {
+		return nil, err
+	}
+	out := []Profile{}
+	for _, k := range keys {
+		if p, err := m.Get(ctx, k); err == nil {
+			out = append(out, p)
+		}
+	}
+	return out, nil
+}
+
+// Lookup returns the profile that applies to queue, if any.
+func (m *Manager) Lookup(ctx context.Context, queue string) (Profile, bool) {
+	if m == nil || queue == "" {
+		return Profile{}, false
+	}
+	if p, err := m.Get(ctx, queue); err == nil {
+		return p, true
+	}
+	for _, p := range m.Defaults {
+		if p.Queue == queue {
+			return p, true
+		}
+	}
+
+	// Patterns: stored first (most specific, i.e. longest, first), then defaults in file order
+	stored, _ := m.stored(ctx)
+	sort.SliceStable(stored, func(i, j int) bool { return len(stored[i].Queue) > len(stored[j].Queue) })
+	for _, p := range append(stored, m.Defaults...) {
+		if ok, _ := path.Match(p.Queue, queue); ok {
+			return p, true
+		}
+	}
+	return Profile{}, false
+}
+
+// Configure applies the profile of svc's queue to it, resetting the overrides when none applies.
+// It has the signature of service.SQSService.Configure so services for other queues pick up
+// their profiles too.
+func (m *Manager) Configure(svc *service.SQSService) {
+	ctx, cancel := context.WithTimeout(context.Background(), lookupTimeout)
+	defer cancel()
+	p, _ := m.Lookup(ctx, svc.QueueName)
+	p.Apply(svc)
+}
+
+// Apply sets the profile's service-level overrides on svc.
+func (p Profile) Apply(svc *service.SQSService) {
+	if mode, err := service.ParseReceiveMode(p.ReceiveMode); err == nil && mode != "" {
+		svc.Mode = mode
+	}
+	svc.ConsumeTimeout = time.Duration(p.VisibilitySeconds) * time.Second
+	svc.WaitSeconds = int32(p.WaitSeconds)
+	svc.MessageGroupID = p.MessageGroupID
+	svc.ReadOnly = p.ReadOnly
+	svc.NoProvenance = p.NoProvenance
+}
+
+func (p Profile) validate() error {
+	var v validate.Validator
+	if v.Required("queue", p.Queue) {
+		if _, err := path.Match(p.Queue, ""); err != nil {
+			v.Add("queue", "invalid pattern: %v", err)
+		}
+	}
+	if _, err := service.ParseReceiveMode(p.ReceiveMode); err != nil {
+		v.Add("receive_mode", "must be observe or consume")
+	}
+	v.Range("visibility_seconds", p.VisibilitySeconds, 0, validate.MaxVisibilitySeconds)
+	v.Range("wait_seconds", p.WaitSeconds, 0, validate.MaxWaitSeconds)
+	known := plugin.Names()["decoders"]
+	for i, d := range p.Decoders {
+		v.Check(slices.Contains(known, d), fmt.Sprintf("decoders[%d]", i), fmt.Sprintf("unknown decoder %q", d))
+	}
+	return v.Err()
+}
+
+// MaskMessage hides the values of the profile's Mask fields in a listed message's JSON body
+// and decoded payload. Non-JSON bodies are left as they are.
+func (p Profile) MaskMessage(m map[string]any) {
+	if len(p.Mask) == 0 {
+		return
+	}
+	if body, ok := m["body"].(string); ok {
+		var v any
+		if json.Unmarshal([]byte(body), &v) == nil {
+			if masked, err := json.Marshal(p.mask(v)); err == nil {
+				m["body"] = string(masked)
+			}
+		}
+	}
+	if d, ok := m["decoded"]; ok {
+		m["decoded"] = p.mask(d)
+	}
+}
+
+func (p Profile) mask(v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		for k, child := range t {
+			if containsFold(p.Mask, k) {
+				t[k] = maskedValue
+			} else {
+				t[k] = p.mask(child)
+			}
+		}
+	case []any:
+		for i := range t {
+			t[i] = p.mask(t[i])
+		}
+	}
+	return v
+}
+
+func containsFold(list []string, v string) bool {
+	for _, s := range list {
+		if strings.EqualFold(s, v) {
+			return true
+		}
+	}
+	return false
+}
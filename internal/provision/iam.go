@@ -0,0 +1,90 @@
+package provision
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/aws/aws-sdk-go-v2/service/iam/types"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+// IAMChecker is a PermissionChecker that simulates the caller's policies with
+// iam:SimulatePrincipalPolicy, which the caller needs on itself.
+type IAMChecker struct {
+	IAM *iam.Client
+	STS *sts.Client
+
+	mu        sync.Mutex
+	account   string
+	principal string
+}
+
+// identity returns the caller's account and the IAM principal its policies are attached
+// to (the role behind an assumed-role session).
+func (c *IAMChecker) identity(ctx context.Context) (account, principal string, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.principal != "" {
+		return c.account, c.principal, nil
+	}
+	id, err := c.STS.GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get caller identity: %w", err)
+	}
+	c.account, c.principal = aws.ToString(id.Account), principalARN(aws.ToString(id.Arn))
+	return c.account, c.principal, nil
+}
+
+// QueueARN returns the ARN a queue named name would have in region, in the caller's account.
+func (c *IAMChecker) QueueARN(ctx context.Context, region, name string) (string, error) {
+	account, principal, err := c.identity(ctx)
+	if err != nil {
+		return "", err
+	}
+	if region == "" {
+		return "", fmt.Errorf("no AWS region configured")
+	}
+	partition := "aws"
+	if parts := strings.SplitN(principal, ":", 3); len(parts) == 3 {
+		partition = parts[1]
+	}
+	return fmt.Sprintf("arn:%s:sqs:%s:%s:%s", partition, region, account, name), nil
+}
+
+// Allowed simulates action on resource for the caller's principal.
+func (c *IAMChecker) Allowed(ctx context.Context, action, resource string) (bool, error) {
+	_, principal, err := c.identity(ctx)
+	if err != nil {
+		return false, err
+	}
+	out, err := c.IAM.SimulatePrincipalPolicy(ctx, &iam.SimulatePrincipalPolicyInput{
+		PolicySourceArn: aws.String(principal),
+		ActionNames:     []string{action},
+		ResourceArns:    []string{resource},
+	})
+	if err != nil {
+		return false, err
+	}
+	for _, r := range out.EvaluationResults {
+		if r.EvalDecision != types.PolicyEvaluationDecisionTypeAllowed {
+			return false, nil
+		}
+	}
+	return len(out.EvaluationResults) > 0, nil
+}
+
+// principalARN maps an assumed-role session ARN (arn:aws:sts::<account>:assumed-role/<role>/<session>)
+// to its role ARN; other ARNs are returned unchanged. Roles with a path can't be recovered
+// from the session ARN, so simulating them fails and the check is reported as unknown.
+func principalARN(arn string) string {
+	parts := strings.SplitN(arn, ":", 6)
+	if len(parts) != 6 || parts[2] != "sts" || !strings.HasPrefix(parts[5], "assumed-role/") {
+		return arn
+	}
+	role, _, _ := strings.Cut(strings.TrimPrefix(parts[5], "assumed-role/"), "/")
+	return fmt.Sprintf("arn:%s:iam::%s:role/%s", parts[1], parts[4], role)
+}
@@ -0,0 +1,254 @@
+// Package provision creates queues, optionally paired with a dead-letter queue, after a
+// pre-flight check of the name, the organisation's naming rules, existing queues and the
+// caller's IAM permissions, so a failed creation doesn't leave half of a pair behind.
+package provision
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pachecoc/sqs-ui/internal/service"
+	"github.com/pachecoc/sqs-ui/internal/validate"
+)
+
+// Check statuses. Only a failed check blocks creation; "unknown" means it couldn't be
+// evaluated (e.g. no permission to simulate policies) and is reported as a warning.
+const (
+	StatusPass    = "pass"
+	StatusFail    = "fail"
+	StatusUnknown = "unknown"
+)
+
+// defaultMaxReceiveCount is the redrive maxReceiveCount when the request sets none.
+const defaultMaxReceiveCount = 5
+
+// dlqSuffix names the dead-letter queue created alongside a queue (before any .fifo).
+const dlqSuffix = "-dlq"
+
+var namePattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,80}$`)
+
+// Request describes the queue to create. Attributes are passed to CreateQueue as-is
+// (FifoQueue is set for .fifo names); with DeadLetterQueue a "<name>-dlq" queue is
+// created first and the queue's RedrivePolicy points at it.
+type Request struct {
+	Name            string            `json:"name"`
+	Attributes      map[string]string `json:"attributes,omitempty"`
+	DeadLetterQueue bool              `json:"dead_letter_queue,omitempty"`
+	MaxReceiveCount int               `json:"max_receive_count,omitempty"` // default 5
+}
+
+// Check is one pre-flight result.
+type Check struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// Report is the pre-flight result, and after creation the created queues.
+type Report struct {
+	Queue              string  `json:"queue"`
+	DeadLetterQueue    string  `json:"dead_letter_queue,omitempty"`
+	Checks             []Check `json:"checks"`
+	OK                 bool    `json:"ok"`
+	Created            bool    `json:"created"`
+	QueueURL           string  `json:"queue_url,omitempty"`
+	DeadLetterQueueURL string  `json:"dead_letter_queue_url,omitempty"`
+	RolledBack         bool    `json:"rolled_back,omitempty"` // the DLQ was deleted after the queue failed
+}
+
+func (r *Report) add(name, status, detail string, args ...any) {
+	if len(args) > 0 {
+		detail = fmt.Sprintf(detail, args...)
+	}
+	r.Checks = append(r.Checks, Check{Name: name, Status: status, Detail: detail})
+	if status == StatusFail {
+		r.OK = false
+	}
+}
+
+// PermissionChecker reports whether the caller may perform an IAM action on a resource ARN.
+type PermissionChecker interface {
+	Allowed(ctx context.Context, action, resource string) (bool, error)
+	// QueueARN returns the ARN a queue named name would have in region.
+	QueueARN(ctx context.Context, region, name string) (string, error)
+}
+
+// Provisioner checks and creates queues.
+type Provisioner struct {
+	// NamingRules are regular expressions every new queue name (without .fifo) must match.
+	NamingRules []*regexp.Regexp
+
+	// Permissions verifies sqs:CreateQueue before creating (optional; skipped when nil).
+	Permissions PermissionChecker
+}
+
+// ParseRules parses NamingRules from a ";"-separated list of regular expressions.
+func ParseRules(s string) ([]*regexp.Regexp, error) {
+	var rules []*regexp.Regexp
+	for _, expr := range strings.Split(s, ";") {
+		if expr = strings.TrimSpace(expr); expr == "" {
+			continue
+		}
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid naming rule %q: %w", expr, err)
+		}
+		rules = append(rules, re)
+	}
+	return rules, nil
+}
+
+// Validate checks the request's fields.
+func (req Request) Validate() error {
+	var v validate.Validator
+	if v.Required("name", req.Name) {
+		base, _ := splitFIFO(req.Name)
+		v.Check(namePattern.MatchString(base), "name", "must be 1-80 letters, digits, '-' or '_', optionally ending in .fifo")
+		if req.DeadLetterQueue {
+			v.Check(len(base)+len(dlqSuffix) <= 80, "name", "leaves no room for the -dlq suffix (80 characters at most)")
+		}
+	}
+	if req.MaxReceiveCount != 0 {
+		v.Range("max_receive_count", req.MaxReceiveCount, 1, service.MaxReceiveCountLimit)
+	}
+	if _, ok := req.Attributes["RedrivePolicy"]; ok && req.DeadLetterQueue {
+		v.Add("attributes.RedrivePolicy", "can't be combined with dead_letter_queue")
+	}
+	return v.Err()
+}
+
+// Preflight checks req without creating anything.
+func (p *Provisioner) Preflight(ctx context.Context, svc *service.SQSService, req Request) Report {
+	names := []string{req.Name}
+	rep := Report{Queue: req.Name, OK: true}
+	if req.DeadLetterQueue {
+		rep.DeadLetterQueue = dlqName(req.Name)
+		names = append(names, rep.DeadLetterQueue)
+	}
+
+	if v, ok := req.Attributes["FifoQueue"]; ok {
+		if want, _ := strconv.ParseBool(v); want != strings.HasSuffix(req.Name, ".fifo") {
+			rep.add("fifo", StatusFail, "FifoQueue=%s doesn't match the name %s (FIFO queue names end in .fifo)", v, req.Name)
+		}
+	}
+
+	for _, name := range names {
+		base, _ := splitFIFO(name)
+		for _, re := range p.NamingRules {
+			if re.MatchString(base) {
+				rep.add("naming_rule", StatusPass, "%s matches %s", name, re)
+			} else {
+				rep.add("naming_rule", StatusFail, "%s does not match %s", name, re)
+			}
+		}
+
+		switch exists, err := svc.QueueExists(ctx, name); {
+		case err != nil:
+			rep.add("exists", StatusUnknown, "could not look up %s: %v", name, service.TranslateAWSError(err))
+		case exists:
+			rep.add("exists", StatusFail, "%s already exists", name)
+		default:
+			rep.add("exists", StatusPass, "%s does not exist yet", name)
+		}
+
+		if p.Permissions == nil {
+			rep.add("permission", StatusUnknown, "sqs:CreateQueue on %s was not checked (no permission checker)", name)
+			continue
+		}
+		arn, err := p.Permissions.QueueARN(ctx, svc.Region, name)
+		if err != nil {
+			rep.add("permission", StatusUnknown, "could not build the ARN of %s: %v", name, err)
+			continue
+		}
+		switch ok, err := p.Permissions.Allowed(ctx, "sqs:CreateQueue", arn); {
+		case err != nil:
+			rep.add("permission", StatusUnknown, "could not simulate sqs:CreateQueue on %s: %v", arn, err)
+		case ok:
+			rep.add("permission", StatusPass, "sqs:CreateQueue allowed on %s", arn)
+		default:
+			rep.add("permission", StatusFail, "sqs:CreateQueue denied on %s", arn)
+		}
+	}
+	return rep
+}
+
+// Create runs the pre-flight checks and, when none fails, creates the dead-letter queue (if
+// requested) and then the queue. If the queue can't be created the new DLQ is deleted again.
+// The report is returned even when err is set.
+func (p *Provisioner) Create(ctx context.Context, svc *service.SQSService, req Request) (Report, error) {
+	rep := p.Preflight(ctx, svc, req)
+	if !rep.OK {
+		return rep, nil
+	}
+
+	attrs := maps.Clone(req.Attributes)
+	if attrs == nil {
+		attrs = make(map[string]string)
+	}
+	_, fifo := splitFIFO(req.Name)
+	if fifo {
+		attrs["FifoQueue"] = "true"
+	}
+
+	if req.DeadLetterQueue {
+		dlqAttrs := map[string]string{}
+		if fifo {
+			dlqAttrs["FifoQueue"] = "true"
+		}
+		url, err := svc.CreateQueue(ctx, rep.DeadLetterQueue, dlqAttrs, nil)
+		if err != nil {
+			return rep, err
+		}
+		rep.DeadLetterQueueURL = url
+		arn, err := svc.QueueARN(ctx, url)
+		if err != nil {
+			p.rollback(ctx, svc, &rep)
+			return rep, err
+		}
+		maxReceives := req.MaxReceiveCount
+		if maxReceives == 0 {
+			maxReceives = defaultMaxReceiveCount
+		}
+		attrs["RedrivePolicy"] = service.RedrivePolicyJSON(arn, maxReceives)
+	}
+
+	url, err := svc.CreateQueue(ctx, req.Name, attrs, nil)
+	if err != nil {
+		if rep.DeadLetterQueueURL != "" {
+			p.rollback(ctx, svc, &rep)
+		}
+		return rep, err
+	}
+	rep.QueueURL = url
+	rep.Created = true
+	return rep, nil
+}
+
+// rollback deletes the dead-letter queue created for a queue that then failed.
+func (p *Provisioner) rollback(ctx context.Context, svc *service.SQSService, rep *Report) {
+	url := rep.DeadLetterQueueURL
+	if err := svc.DeleteQueue(context.WithoutCancel(ctx), url); err != nil {
+		svc.Log.Error("failed to roll back dead-letter queue", "queue_url", url, "error", err)
+		return
+	}
+	rep.RolledBack = true
+	rep.DeadLetterQueueURL = ""
+}
+
+// dlqName returns the dead-letter queue name for name, keeping the .fifo suffix last.
+func dlqName(name string) string {
+	base, fifo := splitFIFO(name)
+	if fifo {
+		return base + dlqSuffix + ".fifo"
+	}
+	return base + dlqSuffix
+}
+
+func splitFIFO(name string) (base string, fifo bool) {
+	base, fifo = strings.CutSuffix(name, ".fifo")
+	return base, fifo
+}
@@ -0,0 +1,127 @@
+package queueref
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+)
+
+// Exports looks up CloudFormation exports with the ListExports query API, signed with the
+// configured credentials. It is one read-only call, so it is made directly instead of
+// through the CloudFormation SDK module.
+type Exports struct {
+	Config aws.Config
+
+	// Endpoint overrides https://cloudformation.<region>.amazonaws.com (optional); the
+	// config's BaseEndpoint (AWS_ENDPOINT_URL) is used when set.
+	Endpoint string
+
+	// HTTP is the client for the calls; http.DefaultClient when nil.
+	HTTP *http.Client
+}
+
+type listExportsResponse struct {
+	Exports []struct {
+		Name  string `xml:"Name"`
+		Value string `xml:"Value"`
+	} `xml:"ListExportsResult>Exports>member"`
+	NextToken string `xml:"ListExportsResult>NextToken"`
+}
+
+type queryError struct {
+	Code    string `xml:"Error>Code"`
+	Message string `xml:"Error>Message"`
+}
+
+// Lookup returns the value of the export named name in the configured region.
+func (e *Exports) Lookup(ctx context.Context, name string) (string, error) {
+	token := ""
+	for {
+		page, err := e.listExports(ctx, token)
+		if err != nil {
+			return "", err
+		}
+		for _, exp := range page.Exports {
+			if exp.Name == name {
+				return exp.Value, nil
+			}
+		}
+		if token = page.NextToken; token == "" {
+			return "", fmt.Errorf("CloudFormation export %s does not exist in %s", name, e.Config.Region)
+		}
+	}
+}
+
+func (e *Exports) listExports(ctx context.Context, token string) (*listExportsResponse, error) {
+	if e.Config.Region == "" {
+		return nil, fmt.Errorf("reading CloudFormation exports needs an AWS region")
+	}
+	if e.Config.Credentials == nil {
+		return nil, fmt.Errorf("reading CloudFormation exports needs AWS credentials")
+	}
+	creds, err := e.Config.Credentials.Retrieve(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve AWS credentials: %w", err)
+	}
+
+	form := url.Values{"Action": {"ListExports"}, "Version": {"2010-05-15"}}
+	if token != "" {
+		form.Set("NextToken", token)
+	}
+	body := form.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.endpoint(), strings.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	sum := sha256.Sum256([]byte(body))
+	if err := v4.NewSigner().SignHTTP(ctx, creds, req, hex.EncodeToString(sum[:]), "cloudformation", e.Config.Region, time.Now()); err != nil {
+		return nil, fmt.Errorf("failed to sign CloudFormation request: %w", err)
+	}
+
+	client := e.HTTP
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list CloudFormation exports: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 10<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CloudFormation response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		var qe queryError
+		if xml.Unmarshal(data, &qe) == nil && qe.Code != "" {
+			return nil, fmt.Errorf("failed to list CloudFormation exports: %s: %s", qe.Code, qe.Message)
+		}
+		return nil, fmt.Errorf("failed to list CloudFormation exports: HTTP %d", resp.StatusCode)
+	}
+	var page listExportsResponse
+	if err := xml.Unmarshal(data, &page); err != nil {
+		return nil, fmt.Errorf("failed to decode CloudFormation response: %w", err)
+	}
+	return &page, nil
+}
+
+func (e *Exports) endpoint() string {
+	switch {
+	case e.Endpoint != "":
+		return e.Endpoint
+	case e.Config.BaseEndpoint != nil:
+		return aws.ToString(e.Config.BaseEndpoint)
+	}
+	return "https://cloudformation." + e.Config.Region + ".amazonaws.com/"
+}
@@ -0,0 +1,170 @@
+// Package queueref resolves QUEUE_URL references to where the queue URL is actually kept:
+// an SSM parameter (ssm:/path/to/param) or a CloudFormation export (cfn:ExportName). The
+// Refresher re-reads the reference so a queue replaced by infrastructure code is picked up
+// without a restart.
+package queueref
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	ssmtypes "github.com/aws/aws-sdk-go-v2/service/ssm/types"
+
+	"github.com/pachecoc/sqs-ui/internal/lifecycle"
+	"github.com/pachecoc/sqs-ui/internal/service"
+)
+
+// Reference prefixes accepted in QUEUE_URL.
+const (
+	PrefixSSM = "ssm:"
+	PrefixCFN = "cfn:"
+)
+
+// resolveTimeout bounds one lookup, including CloudFormation pagination.
+const resolveTimeout = 10 * time.Second
+
+// IsReference reports whether s is a queue reference rather than a queue URL.
+func IsReference(s string) bool {
+	return strings.HasPrefix(s, PrefixSSM) || strings.HasPrefix(s, PrefixCFN)
+}
+
+// Split turns a resolved value into a queue name or URL: values that look like URLs are
+// URLs, anything else is a queue name.
+func Split(value string) (name, url string) {
+	if strings.Contains(value, "://") {
+		return "", value
+	}
+	return value, ""
+}
+
+// SSMAPI is the part of the SSM client the resolver uses.
+type SSMAPI interface {
+	GetParameter(ctx context.Context, in *ssm.GetParameterInput, optFns ...func(*ssm.Options)) (*ssm.GetParameterOutput, error)
+}
+
+// Resolver reads references from SSM Parameter Store and CloudFormation exports.
+type Resolver struct {
+	SSM     SSMAPI
+	Exports *Exports
+}
+
+// NewResolver returns a resolver using cfg's credentials and region.
+func NewResolver(cfg aws.Config) *Resolver {
+	return &Resolver{SSM: ssm.NewFromConfig(cfg), Exports: &Exports{Config: cfg}}
+}
+
+// Resolve returns the value ref points to: the (decrypted) SSM parameter, or the value of
+// the CloudFormation export.
+func (r *Resolver) Resolve(ctx context.Context, ref string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, resolveTimeout)
+	defer cancel()
+
+	var value string
+	switch {
+	case strings.HasPrefix(ref, PrefixSSM):
+		name := strings.TrimPrefix(ref, PrefixSSM)
+		out, err := r.SSM.GetParameter(ctx, &ssm.GetParameterInput{Name: aws.String(name), WithDecryption: aws.Bool(true)})
+		var notFound *ssmtypes.ParameterNotFound
+		if errors.As(err, &notFound) {
+			return "", fmt.Errorf("SSM parameter %s does not exist", name)
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to read SSM parameter %s: %w", name, err)
+		}
+		value = aws.ToString(out.Parameter.Value)
+	case strings.HasPrefix(ref, PrefixCFN):
+		name := strings.TrimPrefix(ref, PrefixCFN)
+		v, err := r.Exports.Lookup(ctx, name)
+		if err != nil {
+			return "", err
+		}
+		value = v
+	default:
+		return "", fmt.Errorf("%q is not a queue reference (%s or %s)", ref, PrefixSSM, PrefixCFN)
+	}
+
+	if value = strings.TrimSpace(value); value == "" {
+		return "", fmt.Errorf("%s is empty", ref)
+	}
+	return value, nil
+}
+
+// Refresher re-resolves Ref every Interval and switches to the new queue when the value
+// changed, as long as the referenced queue is still the one in use: a queue picked in the
+// UI since is left alone.
+type Refresher struct {
+	Ref      string
+	Resolver *Resolver
+	Log      *slog.Logger
+	Interval time.Duration
+
+	// Value is the last resolved value; set it to the startup resolution.
+	Value string
+
+	// Current returns the service in use; Switch replaces it with one for name or url.
+	Current func() *service.SQSService
+	Switch  func(ctx context.Context, name, url string) error
+}
+
+// Run refreshes until ctx is canceled.
+func (r *Refresher) Run(ctx context.Context) {
+	r.Log.Info("queue reference refresher started", "ref", r.Ref, "interval_seconds", r.Interval.Seconds())
+	ticker := time.NewTicker(r.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			r.Log.Info("queue reference refresher stopped")
+			return
+		case <-ticker.C:
+		}
+		err := r.Refresh(ctx)
+		if err != nil {
+			r.Log.Warn("queue reference refresh failed", "ref", r.Ref, "error", err)
+		}
+		lifecycle.Report(ctx, err)
+	}
+}
+
+// Refresh resolves Ref once and switches queues if its value changed.
+func (r *Refresher) Refresh(ctx context.Context) error {
+	value, err := r.Resolver.Resolve(ctx, r.Ref)
+	if err != nil {
+		return err
+	}
+	if value == r.Value {
+		return nil
+	}
+	if !r.inUse() {
+		r.Log.Info("queue reference changed, but another queue is selected", "ref", r.Ref, "value", value)
+		r.Value = value
+		return nil
+	}
+	name, url := Split(value)
+	if err := r.Switch(ctx, name, url); err != nil {
+		return err
+	}
+	r.Log.Info("queue reference changed, queue switched", "ref", r.Ref, "previous", r.Value, "value", value)
+	r.Value = value
+	return nil
+}
+
+// inUse reports whether the current service is still the queue of the last resolution.
+func (r *Refresher) inUse() bool {
+	svc := r.Current()
+	if svc == nil {
+		return false
+	}
+	name, url := Split(r.Value)
+	if url != "" {
+		return svc.QueueURL == url
+	}
+	return svc.QueueName == name
+}
@@ -0,0 +1,88 @@
+// Package rbac maps users to roles (viewer, operator, admin) and checks whether a role may
+// perform an action. Viewers read queues; operators also send, consume and delete messages;
+// admins also purge and change queue configuration.
+package rbac
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Role is a level of access; each role can do everything the lower ones can.
+type Role int
+
+// Roles, lowest first. None can't use the API at all.
+const (
+	None Role = iota
+	Viewer
+	Operator
+	Admin
+)
+
+// ErrForbidden is returned when a role is below what an action needs.
+var ErrForbidden = errors.New("forbidden")
+
+var roleNames = []string{None: "none", Viewer: "viewer", Operator: "operator", Admin: "admin"}
+
+func (r Role) String() string {
+	if r < None || int(r) >= len(roleNames) {
+		return fmt.Sprintf("Role(%d)", int(r))
+	}
+	return roleNames[r]
+}
+
+// ParseRole parses a role name, case-insensitively.
+func ParseRole(s string) (Role, error) {
+	if i := slices.Index(roleNames, strings.ToLower(strings.TrimSpace(s))); i >= 0 {
+		return Role(i), nil
+	}
+	return None, fmt.Errorf("unknown role %q (want none, viewer, operator or admin)", s)
+}
+
+// Policy assigns roles. A nil Policy disables RBAC: everyone is an admin.
+type Policy struct {
+	Viewers   []string
+	Operators []string
+	Admins    []string
+
+	// Default is the role of users in no list, and of anonymous requests.
+	Default Role
+
+	// Header names a request header carrying the role, set by an authenticating proxy. It
+	// applies to users in no list; empty ignores it.
+	Header string
+}
+
+// Role returns user's role. A user in several lists gets the highest; otherwise the role
+// named by headerRole (the value of Header) applies when it is valid, and Default when not.
+func (p *Policy) Role(user, headerRole string) Role {
+	if p == nil {
+		return Admin
+	}
+	if user != "" {
+		switch {
+		case slices.Contains(p.Admins, user):
+			return Admin
+		case slices.Contains(p.Operators, user):
+			return Operator
+		case slices.Contains(p.Viewers, user):
+			return Viewer
+		}
+	}
+	if p.Header != "" && headerRole != "" {
+		if role, err := ParseRole(headerRole); err == nil {
+			return role
+		}
+	}
+	return p.Default
+}
+
+// Check returns ErrForbidden (wrapped with what was refused) when role is below need.
+func Check(role, need Role, action string) error {
+	if role >= need {
+		return nil
+	}
+	return fmt.Errorf("%w: %s needs the %s role (you are %s)", ErrForbidden, action, need, role)
+}
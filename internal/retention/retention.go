@@ -0,0 +1,301 @@
+// Package retention bounds what the store keeps. Policies give store categories a maximum
+// record age and a total size cap, and a background janitor deletes what falls outside
+// them, so long-lived pods don't accumulate snapshots, artifacts or history forever.
+package retention
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pachecoc/sqs-ui/internal/lifecycle"
+	"github.com/pachecoc/sqs-ui/internal/store"
+)
+
+// protected categories hold coordination state and encryption keys; deleting their
+// records would break leases or make sealed records unreadable.
+var protected = []string{"leases", "store-keys"}
+
+// Policy limits one store category. Zero fields don't limit.
+type Policy struct {
+	Category string        `json:"category"`
+	MaxAge   time.Duration `json:"-"`
+	MaxBytes int64         `json:"max_bytes,omitempty"`
+}
+
+// ParsePolicies reads "category=age:size;category=age" rules, e.g.
+// "snapshots=6h;artifacts=7d:512MB;annotations=:10MB". Ages are Go durations or whole days
+// ("30d"); sizes are bytes or KB/MB/GB (powers of 1024).
+func ParsePolicies(spec string) ([]Policy, error) {
+	var policies []Policy
+	for _, part := range strings.Split(spec, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		category, limits, ok := strings.Cut(part, "=")
+		category = strings.TrimSpace(category)
+		if !ok || category == "" {
+			return nil, fmt.Errorf("invalid retention rule %q (expected category=age:size)", part)
+		}
+		if slices.Contains(protected, category) {
+			return nil, fmt.Errorf("retention rule %q: category %s can't be limited", part, category)
+		}
+		if slices.ContainsFunc(policies, func(p Policy) bool { return p.Category == category }) {
+			return nil, fmt.Errorf("retention rule %q: category %s is already limited", part, category)
+		}
+
+		age, size, _ := strings.Cut(limits, ":")
+		p := Policy{Category: category}
+		var err error
+		if p.MaxAge, err = parseAge(strings.TrimSpace(age)); err != nil {
+			return nil, fmt.Errorf("retention rule %q: %w", part, err)
+		}
+		if p.MaxBytes, err = parseSize(strings.TrimSpace(size)); err != nil {
+			return nil, fmt.Errorf("retention rule %q: %w", part, err)
+		}
+		if p.MaxAge == 0 && p.MaxBytes == 0 {
+			return nil, fmt.Errorf("retention rule %q sets no limit", part)
+		}
+		policies = append(policies, p)
+	}
+	return policies, nil
+}
+
+func parseAge(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("invalid age %q", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid age %q", s)
+	}
+	return d, nil
+}
+
+func parseSize(s string) (int64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	mult := int64(1)
+	upper := strings.ToUpper(s)
+	for _, u := range []struct {
+		suffix string
+		mult   int64
+	}{{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10}, {"B", 1}} {
+		if n, ok := strings.CutSuffix(upper, u.suffix); ok {
+			upper, mult = strings.TrimSpace(n), u.mult
+			break
+		}
+	}
+	n, err := strconv.ParseInt(upper, 10, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return n * mult, nil
+}
+
+// Sweep is the outcome of one janitor pass.
+type Sweep struct {
+	At         time.Time `json:"at"`
+	Deleted    int       `json:"deleted"`
+	FreedBytes int64     `json:"freed_bytes"`
+	Errors     []string  `json:"errors,omitempty"`
+}
+
+// CategoryUsage is what one category holds, with its policy when it has one.
+type CategoryUsage struct {
+	Category      string     `json:"category"`
+	Records       int        `json:"records"`
+	Bytes         int64      `json:"bytes"`
+	Oldest        *time.Time `json:"oldest,omitempty"`
+	MaxAgeSeconds int64      `json:"max_age_seconds,omitempty"`
+	MaxBytes      int64      `json:"max_bytes,omitempty"`
+}
+
+// Usage reports the store's contents per category.
+type Usage struct {
+	Categories []CategoryUsage `json:"categories"`
+	Records    int             `json:"records"`
+	Bytes      int64           `json:"bytes"`
+	LastSweep  *Sweep          `json:"last_sweep,omitempty"`
+}
+
+// Janitor enforces Policies on Store every Interval. Each pass also walks every category,
+// which makes file and memory stores drop records whose TTL passed but were never read.
+type Janitor struct {
+	Store    store.Store
+	Policies []Policy
+	Log      *slog.Logger
+	Interval time.Duration
+	// Leader, when set, limits sweeping to the elected replica.
+	Leader func() bool
+
+	mu   sync.Mutex
+	last *Sweep
+}
+
+// Run sweeps every Interval until ctx is done.
+func (j *Janitor) Run(ctx context.Context) {
+	j.Log.Info("store janitor started", "interval_seconds", j.Interval.Seconds(), "policies", len(j.Policies))
+	ticker := time.NewTicker(j.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			j.Log.Info("store janitor stopped")
+			return
+		case <-ticker.C:
+		}
+		if j.Leader != nil && !j.Leader() {
+			continue
+		}
+		res := j.Sweep(ctx)
+		if len(res.Errors) > 0 {
+			j.Log.Warn("store sweep incomplete", "deleted", res.Deleted, "freed_bytes", res.FreedBytes, "errors", res.Errors)
+			lifecycle.Report(ctx, fmt.Errorf("store sweep incomplete: %s", strings.Join(res.Errors, "; ")))
+			continue
+		}
+		if res.Deleted > 0 {
+			j.Log.Info("store swept", "deleted", res.Deleted, "freed_bytes", res.FreedBytes)
+		}
+		lifecycle.Report(ctx, nil)
+	}
+}
+
+// Sweep deletes records older than their category's MaxAge, then the oldest records of
+// categories still over MaxBytes until they fit. Records with no known write time are
+// never aged out and go first when trimming to size. Failures are collected, not fatal.
+func (j *Janitor) Sweep(ctx context.Context) Sweep {
+	res := Sweep{At: time.Now().UTC()}
+	categories, err := store.Categories(ctx, j.Store)
+	if err != nil {
+		res.Errors = append(res.Errors, err.Error())
+	}
+	for _, c := range categories {
+		if j.policy(c) == nil {
+			if _, err := store.Inspect(ctx, j.Store, c); err != nil {
+				res.Errors = append(res.Errors, fmt.Sprintf("%s: %v", c, err))
+			}
+		}
+	}
+	for _, p := range j.Policies {
+		if err := j.enforce(ctx, p, &res); err != nil {
+			res.Errors = append(res.Errors, fmt.Sprintf("%s: %v", p.Category, err))
+		}
+	}
+
+	j.mu.Lock()
+	j.last = &res
+	j.mu.Unlock()
+	return res
+}
+
+func (j *Janitor) enforce(ctx context.Context, p Policy, res *Sweep) error {
+	infos, err := store.Inspect(ctx, j.Store, p.Category)
+	if err != nil {
+		return err
+	}
+	remove := func(info store.RecordInfo) error {
+		if err := j.Store.Delete(ctx, p.Category, info.Key); err != nil {
+			return err
+		}
+		res.Deleted++
+		res.FreedBytes += info.Size
+		return nil
+	}
+
+	var kept []store.RecordInfo
+	var total int64
+	for _, info := range infos {
+		if p.MaxAge > 0 && !info.Modified.IsZero() && time.Since(info.Modified) > p.MaxAge {
+			if err := remove(info); err != nil {
+				return err
+			}
+			continue
+		}
+		kept = append(kept, info)
+		total += info.Size
+	}
+	if p.MaxBytes == 0 || total <= p.MaxBytes {
+		return nil
+	}
+
+	sort.SliceStable(kept, func(a, b int) bool { return kept[a].Modified.Before(kept[b].Modified) })
+	for _, info := range kept {
+		if total <= p.MaxBytes {
+			break
+		}
+		if err := remove(info); err != nil {
+			return err
+		}
+		total -= info.Size
+	}
+	return nil
+}
+
+func (j *Janitor) policy(category string) *Policy {
+	for i := range j.Policies {
+		if j.Policies[i].Category == category {
+			return &j.Policies[i]
+		}
+	}
+	return nil
+}
+
+// Usage reports record counts and sizes for every category in the store and every
+// category with a policy, along with the last sweep.
+func (j *Janitor) Usage(ctx context.Context) (Usage, error) {
+	categories, err := store.Categories(ctx, j.Store)
+	if err != nil {
+		return Usage{}, err
+	}
+	for _, p := range j.Policies {
+		if !slices.Contains(categories, p.Category) {
+			categories = append(categories, p.Category)
+		}
+	}
+	sort.Strings(categories)
+
+	u := Usage{Categories: []CategoryUsage{}}
+	for _, c := range categories {
+		infos, err := store.Inspect(ctx, j.Store, c)
+		if err != nil {
+			return Usage{}, fmt.Errorf("%s: %w", c, err)
+		}
+		cu := CategoryUsage{Category: c, Records: len(infos)}
+		for _, info := range infos {
+			cu.Bytes += info.Size
+			if !info.Modified.IsZero() && (cu.Oldest == nil || info.Modified.Before(*cu.Oldest)) {
+				oldest := info.Modified.UTC()
+				cu.Oldest = &oldest
+			}
+		}
+		if p := j.policy(c); p != nil {
+			cu.MaxAgeSeconds = int64(p.MaxAge / time.Second)
+			cu.MaxBytes = p.MaxBytes
+		}
+		u.Categories = append(u.Categories, cu)
+		u.Records += cu.Records
+		u.Bytes += cu.Bytes
+	}
+
+	j.mu.Lock()
+	u.LastSweep = j.last
+	j.mu.Unlock()
+	return u, nil
+}
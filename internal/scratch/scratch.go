@@ -0,0 +1,176 @@
+// Package scratch creates short-lived queues for quick experiments. Each one is tagged with
+// its expiry, and a background reaper deletes it once that has passed, so experiments
+// don't leave queues behind in the account.
+package scratch
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"regexp"
+	"time"
+
+	"github.com/pachecoc/sqs-ui/internal/lifecycle"
+	"github.com/pachecoc/sqs-ui/internal/service"
+	"github.com/pachecoc/sqs-ui/internal/validate"
+)
+
+// Tags set on every scratch queue. The reaper only deletes queues carrying TagScratch.
+const (
+	TagScratch   = "sqs-ui:scratch"
+	TagExpiresAt = "sqs-ui:expires-at"
+	TagCreatedBy = "sqs-ui:created-by"
+)
+
+// PrefixPattern is what SCRATCH_QUEUE_PREFIX may contain; the random suffix and .fifo
+// still have to fit in the 80-character queue name.
+var PrefixPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,60}$`)
+
+// Request describes a scratch queue. TTLMinutes defaults to Manager.DefaultTTL.
+type Request struct {
+	TTLMinutes int  `json:"ttl_minutes,omitempty"`
+	FIFO       bool `json:"fifo,omitempty"`
+}
+
+// Queue is a created scratch queue.
+type Queue struct {
+	Name      string    `json:"queue_name"`
+	URL       string    `json:"queue_url"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedBy string    `json:"created_by,omitempty"`
+}
+
+// Manager creates scratch queues and reaps expired ones.
+type Manager struct {
+	Service func() *service.SQSService
+	Log     *slog.Logger
+
+	// Prefix starts every scratch queue name; the reaper only looks at queues named Prefix*.
+	Prefix     string
+	DefaultTTL time.Duration
+	MaxTTL     time.Duration
+
+	// Interval between reaper passes.
+	Interval time.Duration
+
+	// Leader, when set, limits reaping to the elected replica.
+	Leader func() bool
+}
+
+// Validate checks req against the manager's TTL bounds.
+func (m *Manager) Validate(req Request) error {
+	var v validate.Validator
+	if req.TTLMinutes != 0 {
+		v.Range("ttl_minutes", req.TTLMinutes, 1, int(m.MaxTTL/time.Minute))
+	}
+	return v.Err()
+}
+
+// Create creates a uniquely named scratch queue on svc's account that expires after the
+// requested TTL. user is recorded in a tag when set.
+func (m *Manager) Create(ctx context.Context, svc *service.SQSService, req Request, user string) (Queue, error) {
+	if err := m.Validate(req); err != nil {
+		return Queue{}, err
+	}
+	ttl := m.DefaultTTL
+	if req.TTLMinutes > 0 {
+		ttl = time.Duration(req.TTLMinutes) * time.Minute
+	}
+
+	q := Queue{Name: m.Prefix + newSuffix(), ExpiresAt: time.Now().UTC().Add(ttl).Truncate(time.Second), CreatedBy: user}
+	attrs := map[string]string{}
+	if req.FIFO {
+		q.Name += ".fifo"
+		attrs["FifoQueue"] = "true"
+	}
+	tags := map[string]string{
+		TagScratch:   "true",
+		TagExpiresAt: q.ExpiresAt.Format(time.RFC3339),
+	}
+	if user != "" {
+		tags[TagCreatedBy] = user
+	}
+
+	url, err := svc.CreateQueue(ctx, q.Name, attrs, tags)
+	if err != nil {
+		return Queue{}, err
+	}
+	q.URL = url
+	return q, nil
+}
+
+// Run reaps expired scratch queues every Interval until ctx is canceled.
+func (m *Manager) Run(ctx context.Context) {
+	m.Log.Info("scratch queue reaper started", "interval_seconds", m.Interval.Seconds(), "prefix", m.Prefix)
+	ticker := time.NewTicker(m.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			m.Log.Info("scratch queue reaper stopped")
+			return
+		case <-ticker.C:
+		}
+		if m.Leader != nil && !m.Leader() {
+			continue
+		}
+		_, err := m.Reap(ctx)
+		if err != nil {
+			m.Log.Warn("scratch queue reaping failed", "error", err)
+		}
+		lifecycle.Report(ctx, err)
+	}
+}
+
+// Reap deletes the scratch queues whose expiry has passed and returns how many it deleted.
+// Queues named Prefix* without the scratch tag are left alone.
+func (m *Manager) Reap(ctx context.Context) (int, error) {
+	svc := m.Service()
+	if svc == nil || svc.Client == nil {
+		return 0, nil
+	}
+	now := time.Now()
+	deleted := 0
+	token := ""
+	for {
+		page, err := svc.ListQueues(ctx, m.Prefix, 1000, token)
+		if err != nil {
+			return deleted, err
+		}
+		for _, q := range page.Queues {
+			tags, err := svc.QueueTags(ctx, q.URL)
+			if err != nil {
+				m.Log.Warn("failed to read scratch queue tags", "queue_url", q.URL, "error", err)
+				continue
+			}
+			if tags[TagScratch] != "true" {
+				continue
+			}
+			expires, err := time.Parse(time.RFC3339, tags[TagExpiresAt])
+			if err != nil {
+				m.Log.Warn("scratch queue has no valid expiry tag", "queue_url", q.URL, "value", tags[TagExpiresAt])
+				continue
+			}
+			if now.Before(expires) {
+				continue
+			}
+			if err := svc.DeleteQueue(ctx, q.URL); err != nil {
+				m.Log.Warn("failed to delete expired scratch queue", "queue_url", q.URL, "error", err)
+				continue
+			}
+			m.Log.Info("expired scratch queue deleted", "queue_name", q.Name, "expired_at", expires, "created_by", tags[TagCreatedBy])
+			deleted++
+		}
+		if token = page.NextToken; token == "" {
+			return deleted, nil
+		}
+	}
+}
+
+func newSuffix() string {
+	b := make([]byte, 6)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
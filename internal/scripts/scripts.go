@@ -0,0 +1,264 @@
+// Package scripts runs user-defined JavaScript filters and transforms over messages.
+//
+// A script defines a function main(msg) where msg is the message object
+// ({message_id, body, ...}). Filters return a truthy value to keep the message;
+// transforms return a replacement message object or a new body string.
+package scripts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dop251/goja"
+
+	"github.com/pachecoc/sqs-ui/internal/store"
+	"github.com/pachecoc/sqs-ui/internal/validate"
+)
+
+// category is the store category holding scripts.
+const category = "scripts"
+
+// Sandbox limits.
+const (
+	DefaultTimeout  = 200 * time.Millisecond
+	maxSourceLength = 64 << 10
+	maxCallStack    = 256
+)
+
+// Kind is what a script does with messages.
+type Kind string
+
+const (
+	KindFilter    Kind = "filter"
+	KindTransform Kind = "transform"
+)
+
+// ErrNotFound is returned for unknown script names.
+var ErrNotFound = errors.New("script not found")
+
+var namePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+// Script is a stored user script.
+type Script struct {
+	Name      string    `json:"name"`
+	Kind      Kind      `json:"kind"`
+	Source    string    `json:"source"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Manager stores scripts and runs them in fresh, time-limited runtimes.
+type Manager struct {
+	Store   store.Store
+	Timeout time.Duration // per message; DefaultTimeout when zero
+
+	mu       sync.Mutex
+	compiled map[string]compiled
+}
+
+type compiled struct {
+	updated time.Time
+	prog    *goja.Program
+}
+
+// Save validates, compiles and stores a script.
+func (m *Manager) Save(ctx context.Context, s Script) (Script, error) {
+	var v validate.Validator
+	v.Check(namePattern.MatchString(s.Name), "name", "must match "+namePattern.String())
+	if v.Required("kind", string(s.Kind)) {
+		v.OneOf("kind", string(s.Kind), string(KindFilter), string(KindTransform))
+	}
+	if v.Required("source", s.Source) && v.MaxBytes("source", s.Source, maxSourceLength) {
+		if _, err := compile(s); err != nil {
+			v.Add("source", "%v", err)
+		}
+	}
+	if err := v.Err(); err != nil {
+		return Script{}, err
+	}
+	s.UpdatedAt = time.Now().UTC()
+
+	if err := store.PutJSON(ctx, m.Store, category, s.Name, s, 0); err != nil {
+		return Script{}, err
+	}
+	return s, nil
+}
+
+// Get loads a script by name.
+func (m *Manager) Get(ctx context.Context, name string) (Script, error) {
+	var s Script
+	if err := store.GetJSON(ctx, m.Store, category, name, &s); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return Script{}, ErrNotFound
+		}
+		return Script{}, err
+	}
+	return s, nil
+}
+
+// Delete removes a script.
+func (m *Manager) Delete(ctx context.Context, name string) error {
+	return m.Store.Delete(ctx, category, name)
+}
+
+// List returns all scripts sorted by name.
+func (m *Manager) List(ctx context.Context) ([]Script, error) {
+	keys, err := m.Store.List(ctx, category)
+	if err != nil {
+		return nil, err
+	}
+	out := []Script{}
+	for _, k := range keys {
+		if s, err := m.Get(ctx, k); err == nil {
+			out = append(out, s)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out, nil
+}
+
+// Apply runs the named filter and then the named transform (either may be empty) over msgs.
+func (m *Manager) Apply(ctx context.Context, filter, transform string, msgs []map[string]any) ([]map[string]any, error) {
+	if filter != "" {
+		s, err := m.load(ctx, filter, KindFilter)
+		if err != nil {
+			return nil, err
+		}
+		kept := []map[string]any{}
+		for _, msg := range msgs {
+			v, err := m.Run(ctx, s, msg)
+			if err != nil {
+				return nil, fmt.Errorf("filter %s on %v: %w", s.Name, msg["message_id"], err)
+			}
+			if v.(bool) {
+				kept = append(kept, msg)
+			}
+		}
+		msgs = kept
+	}
+
+	if transform != "" {
+		s, err := m.load(ctx, transform, KindTransform)
+		if err != nil {
+			return nil, err
+		}
+		for i, msg := range msgs {
+			v, err := m.Run(ctx, s, msg)
+			if err != nil {
+				return nil, fmt.Errorf("transform %s on %v: %w", s.Name, msg["message_id"], err)
+			}
+			msgs[i] = v.(map[string]any)
+		}
+	}
+	return msgs, nil
+}
+
+// Run executes a script for one message: filters return a bool, transforms the new message.
+func (m *Manager) Run(ctx context.Context, s Script, msg map[string]any) (any, error) {
+	prog, err := m.program(s)
+	if err != nil {
+		return nil, err
+	}
+
+	vm := goja.New()
+	vm.SetMaxCallStackSize(maxCallStack)
+
+	timeout := m.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	stop := context.AfterFunc(ctx, func() { vm.Interrupt("script timed out") })
+	defer stop()
+
+	if _, err := vm.RunProgram(prog); err != nil {
+		return nil, err
+	}
+	main, ok := goja.AssertFunction(vm.Get("main"))
+	if !ok {
+		return nil, errors.New("script must define function main(msg)")
+	}
+
+	// The script gets a copy: it can't mutate the listing in place
+	in := make(map[string]any, len(msg))
+	for k, v := range msg {
+		in[k] = v
+	}
+	res, err := main(goja.Undefined(), vm.ToValue(in))
+	if err != nil {
+		return nil, err
+	}
+
+	if s.Kind == KindFilter {
+		return res.ToBoolean(), nil
+	}
+	return transformed(msg, res.Export())
+}
+
+// transformed builds the output message from a transform's return value.
+func transformed(orig map[string]any, v any) (map[string]any, error) {
+	switch out := v.(type) {
+	case string:
+		msg := make(map[string]any, len(orig))
+		for k, val := range orig {
+			msg[k] = val
+		}
+		msg["body"] = out
+		return msg, nil
+	case map[string]any:
+		// Identity fields can't be rewritten
+		if id, ok := orig["message_id"]; ok {
+			out["message_id"] = id
+		}
+		return out, nil
+	default:
+		return nil, fmt.Errorf("transform must return an object or a string, got %T", v)
+	}
+}
+
+func (m *Manager) load(ctx context.Context, name string, kind Kind) (Script, error) {
+	s, err := m.Get(ctx, name)
+	if err != nil {
+		return Script{}, fmt.Errorf("%w: %s", err, name)
+	}
+	if s.Kind != kind {
+		return Script{}, fmt.Errorf("script %s is a %s, not a %s", name, s.Kind, kind)
+	}
+	return s, nil
+}
+
+// program returns the compiled script, caching by name and version.
+func (m *Manager) program(s Script) (*goja.Program, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if c, ok := m.compiled[s.Name]; ok && c.updated.Equal(s.UpdatedAt) {
+		return c.prog, nil
+	}
+	prog, err := compile(s)
+	if err != nil {
+		return nil, err
+	}
+	if m.compiled == nil {
+		m.compiled = map[string]compiled{}
+	}
+	m.compiled[s.Name] = compiled{updated: s.UpdatedAt, prog: prog}
+	return prog, nil
+}
+
+func compile(s Script) (*goja.Program, error) {
+	if !strings.Contains(s.Source, "main") {
+		return nil, errors.New("script must define function main(msg)")
+	}
+	prog, err := goja.Compile(s.Name+".js", s.Source, true)
+	if err != nil {
+		return nil, fmt.Errorf("compile error: %w", err)
+	}
+	return prog, nil
+}
@@ -0,0 +1,98 @@
+package scripts
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dop251/goja"
+
+	"github.com/pachecoc/sqs-ui/internal/store"
+)
+
+func TestRun(t *testing.T) {
+	m := &Manager{Store: store.NewMemory()}
+	msg := map[string]any{"message_id": "m-1", "body": `{"total":120}`}
+
+	tests := []struct {
+		name   string
+		script Script
+		want   any
+	}{
+		{"filter keeps", Script{Name: "big", Kind: KindFilter, Source: `function main(m) { return JSON.parse(m.body).total > 100 }`}, true},
+		{"filter drops", Script{Name: "huge", Kind: KindFilter, Source: `function main(m) { return JSON.parse(m.body).total > 1000 }`}, false},
+		{"transform body", Script{Name: "upper", Kind: KindTransform, Source: `function main(m) { return m.body.toUpperCase() }`},
+			map[string]any{"message_id": "m-1", "body": `{"TOTAL":120}`}},
+		{"transform keeps the id", Script{Name: "rewrite", Kind: KindTransform, Source: `function main(m) { return {message_id: "forged", body: "x"} }`},
+			map[string]any{"message_id": "m-1", "body": "x"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := m.Run(context.Background(), tt.script, msg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			switch want := tt.want.(type) {
+			case map[string]any:
+				out, _ := got.(map[string]any)
+				if len(out) != len(want) || out["message_id"] != want["message_id"] || out["body"] != want["body"] {
+					t.Errorf("got %v, want %v", got, want)
+				}
+			default:
+				if got != want {
+					t.Errorf("got %v, want %v", got, want)
+				}
+			}
+		})
+	}
+	if msg["body"] != `{"total":120}` {
+		t.Errorf("a transform changed the caller's message: %v", msg)
+	}
+}
+
+func TestRunInterruptsOnTimeout(t *testing.T) {
+	m := &Manager{Store: store.NewMemory(), Timeout: 50 * time.Millisecond}
+	tests := map[string]string{
+		"loop in main":      `function main(m) { while (true) {} }`,
+		"loop at top level": `while (true) {} function main(m) { return true }`,
+	}
+	for name, source := range tests {
+		t.Run(name, func(t *testing.T) {
+			start := time.Now()
+			_, err := m.Run(context.Background(), Script{Name: "spin", Kind: KindFilter, Source: source}, map[string]any{})
+			if err == nil || !strings.Contains(err.Error(), "timed out") {
+				t.Fatalf("got %v, want the script interrupted", err)
+			}
+			if elapsed := time.Since(start); elapsed > 2*time.Second {
+				t.Errorf("interrupted after %s", elapsed)
+			}
+		})
+	}
+}
+
+func TestRunLimitsCallStack(t *testing.T) {
+	m := &Manager{Store: store.NewMemory()}
+	s := Script{Name: "recurse", Kind: KindFilter, Source: `function f(n) { return f(n + 1) } function main(m) { return f(0) }`}
+	_, err := m.Run(context.Background(), s, map[string]any{})
+	if !errors.As(err, new(*goja.StackOverflowError)) {
+		t.Errorf("got %v, want a *goja.StackOverflowError", err)
+	}
+}
+
+func TestSaveValidates(t *testing.T) {
+	m := &Manager{Store: store.NewMemory()}
+	tests := map[string]Script{
+		"bad name":     {Name: "Bad Name", Kind: KindFilter, Source: `function main(m) { return true }`},
+		"unknown kind": {Name: "ok", Kind: "map", Source: `function main(m) { return true }`},
+		"no main":      {Name: "ok", Kind: KindFilter, Source: `function keep(m) { return true }`},
+		"syntax error": {Name: "ok", Kind: KindFilter, Source: `function main(m) { return ( }`},
+		"too long":     {Name: "ok", Kind: KindFilter, Source: `function main(m) { return true } //` + strings.Repeat("x", maxSourceLength)},
+	}
+	for name, s := range tests {
+		if _, err := m.Save(context.Background(), s); err == nil {
+			t.Errorf("%s: saved", name)
+		}
+	}
+}
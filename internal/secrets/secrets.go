@@ -0,0 +1,217 @@
+// Package secrets loads credentials from AWS Secrets Manager or SSM Parameter Store
+// instead of plain environment variables, and keeps them current while the server runs.
+//
+// A secret setting holds either the secret itself or a reference to it:
+//
+//	secretsmanager:<secret-id>            the secret's string value
+//	secretsmanager:<secret-id>#<key>      one key of a JSON secret
+//	ssm:/path/to/parameter                a (SecureString) parameter
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	smtypes "github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	ssmtypes "github.com/aws/aws-sdk-go-v2/service/ssm/types"
+
+	"github.com/pachecoc/sqs-ui/internal/lifecycle"
+)
+
+// Reference prefixes accepted in secret settings.
+const (
+	PrefixSecretsManager = "secretsmanager:"
+	PrefixSSM            = "ssm:"
+)
+
+// readTimeout bounds reading one secret.
+const readTimeout = 10 * time.Second
+
+// IsReference reports whether s points to a secret rather than being one.
+func IsReference(s string) bool {
+	return strings.HasPrefix(s, PrefixSecretsManager) || strings.HasPrefix(s, PrefixSSM)
+}
+
+// Value is a secret that may change while the server runs. A nil Value is empty. It has no
+// String method, so it doesn't end up in logs by accident.
+type Value struct {
+	mu sync.RWMutex
+	v  string
+}
+
+// Static returns a Value that never changes.
+func Static(s string) *Value {
+	return &Value{v: s}
+}
+
+// Get returns the current secret.
+func (v *Value) Get() string {
+	if v == nil {
+		return ""
+	}
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.v
+}
+
+// IsSet reports whether the secret is non-empty.
+func (v *Value) IsSet() bool {
+	return v.Get() != ""
+}
+
+func (v *Value) set(s string) {
+	v.mu.Lock()
+	v.v = s
+	v.mu.Unlock()
+}
+
+// SecretsManagerAPI is the part of the Secrets Manager client the loader uses.
+type SecretsManagerAPI interface {
+	GetSecretValue(ctx context.Context, in *secretsmanager.GetSecretValueInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error)
+}
+
+// SSMAPI is the part of the SSM client the loader uses.
+type SSMAPI interface {
+	GetParameter(ctx context.Context, in *ssm.GetParameterInput, optFns ...func(*ssm.Options)) (*ssm.GetParameterOutput, error)
+}
+
+// Loader reads referenced secrets and refreshes them every Interval.
+type Loader struct {
+	SecretsManager SecretsManagerAPI
+	SSM            SSMAPI
+	Log            *slog.Logger
+
+	// Interval between refreshes of the loaded references.
+	Interval time.Duration
+
+	mu     sync.Mutex
+	loaded []loaded
+}
+
+type loaded struct {
+	setting string
+	ref     string
+	value   *Value
+}
+
+// NewLoader returns a loader using cfg's credentials and region.
+func NewLoader(cfg aws.Config, log *slog.Logger, interval time.Duration) *Loader {
+	return &Loader{
+		SecretsManager: secretsmanager.NewFromConfig(cfg),
+		SSM:            ssm.NewFromConfig(cfg),
+		Log:            log,
+		Interval:       interval,
+	}
+}
+
+// Load returns the secret for setting, whose configured value is raw: a literal is returned
+// as is, a reference is read now and then kept current by Run.
+func (l *Loader) Load(ctx context.Context, setting, raw string) (*Value, error) {
+	if !IsReference(raw) {
+		return Static(raw), nil
+	}
+	s, err := l.read(ctx, raw)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", setting, err)
+	}
+	v := Static(s)
+	l.mu.Lock()
+	l.loaded = append(l.loaded, loaded{setting: setting, ref: raw, value: v})
+	l.mu.Unlock()
+	l.Log.Info("secret loaded", "setting", setting, "ref", raw)
+	return v, nil
+}
+
+// Run refreshes the loaded references until ctx is canceled. A failed read keeps the
+// previous value.
+func (l *Loader) Run(ctx context.Context) {
+	l.mu.Lock()
+	n := len(l.loaded)
+	l.mu.Unlock()
+	if n == 0 {
+		return
+	}
+	l.Log.Info("secret refresher started", "secrets", n, "interval_seconds", l.Interval.Seconds())
+	ticker := time.NewTicker(l.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		l.mu.Lock()
+		list := append([]loaded(nil), l.loaded...)
+		l.mu.Unlock()
+		var failed []error
+		for _, s := range list {
+			v, err := l.read(ctx, s.ref)
+			if err != nil {
+				l.Log.Warn("secret refresh failed, keeping the previous value", "setting", s.setting, "ref", s.ref, "error", err)
+				failed = append(failed, fmt.Errorf("%s: %w", s.setting, err))
+				continue
+			}
+			if v != s.value.Get() {
+				s.value.set(v)
+				l.Log.Info("secret rotated", "setting", s.setting, "ref", s.ref)
+			}
+		}
+		lifecycle.Report(ctx, errors.Join(failed...))
+	}
+}
+
+// read returns the secret ref points to.
+func (l *Loader) read(ctx context.Context, ref string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, readTimeout)
+	defer cancel()
+
+	if name, ok := strings.CutPrefix(ref, PrefixSSM); ok {
+		out, err := l.SSM.GetParameter(ctx, &ssm.GetParameterInput{Name: aws.String(name), WithDecryption: aws.Bool(true)})
+		var notFound *ssmtypes.ParameterNotFound
+		if errors.As(err, &notFound) {
+			return "", fmt.Errorf("SSM parameter %s does not exist", name)
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to read SSM parameter %s: %w", name, err)
+		}
+		return aws.ToString(out.Parameter.Value), nil
+	}
+
+	id, key, _ := strings.Cut(strings.TrimPrefix(ref, PrefixSecretsManager), "#")
+	out, err := l.SecretsManager.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{SecretId: aws.String(id)})
+	var notFound *smtypes.ResourceNotFoundException
+	if errors.As(err, &notFound) {
+		return "", fmt.Errorf("secret %s does not exist", id)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret %s: %w", id, err)
+	}
+	if out.SecretString == nil {
+		return "", fmt.Errorf("secret %s has no string value", id)
+	}
+	if key == "" {
+		return *out.SecretString, nil
+	}
+	var fields map[string]any
+	if err := json.Unmarshal([]byte(*out.SecretString), &fields); err != nil {
+		return "", fmt.Errorf("secret %s is not a JSON object, so it has no key %q", id, key)
+	}
+	v, ok := fields[key]
+	if !ok {
+		return "", fmt.Errorf("secret %s has no key %q", id, key)
+	}
+	if s, ok := v.(string); ok {
+		return s, nil
+	}
+	return fmt.Sprint(v), nil
+}
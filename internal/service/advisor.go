@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+// minAdvisorCalls is how many recorded receive calls the advisor wants before judging ratios.
+const minAdvisorCalls = 20
+
+// Suggestion is one tuning recommendation.
+type Suggestion struct {
+	Setting   string `json:"setting"`
+	Current   string `json:"current,omitempty"`
+	Suggested string `json:"suggested"`
+	Reason    string `json:"reason"`
+}
+
+// Advice is the poll advisor's view of a queue.
+type Advice struct {
+	QueueName          string       `json:"queue_name"`
+	QueueWaitSeconds   int          `json:"queue_receive_wait_seconds"` // queue default for WaitTimeSeconds
+	Counts             QueueCounts  `json:"counts"`
+	Stats              PollStats    `json:"stats"`
+	Suggestions        []Suggestion `json:"suggestions"`
+	InsufficientSample bool         `json:"insufficient_sample,omitempty"`
+}
+
+// Advise combines recent receive statistics with queue attributes into tuning suggestions for
+// WaitTimeSeconds and MaxNumberOfMessages, for sqs-ui and for the queue's real consumers.
+func (s *SQSService) Advise(ctx context.Context) (Advice, error) {
+	adv := Advice{QueueName: s.QueueName, Stats: s.PollStats(), Suggestions: []Suggestion{}}
+	counts, err := s.Counts(ctx)
+	if err != nil {
+		return adv, err
+	}
+	adv.Counts = counts
+
+	attrs, _, err := s.queueAttributes(ctx)
+	if err != nil {
+		return adv, fmt.Errorf("failed to get queue attributes: %w", err)
+	}
+	adv.QueueWaitSeconds, _ = strconv.Atoi(attrs[string(types.QueueAttributeNameReceiveMessageWaitTimeSeconds)])
+
+	if adv.QueueWaitSeconds == 0 {
+		adv.Suggestions = append(adv.Suggestions, Suggestion{
+			Setting:   "ReceiveMessageWaitTimeSeconds (queue)",
+			Current:   "0",
+			Suggested: "20",
+			Reason:    "short polling is the queue default: consumers that don't set WaitTimeSeconds get empty responses and pay for them",
+		})
+	}
+
+	st := adv.Stats
+	if st.Calls < minAdvisorCalls {
+		adv.InsufficientSample = true
+		return adv, nil
+	}
+
+	emptyRatio := float64(st.Empty) / float64(st.Calls)
+	switch {
+	case emptyRatio > 0.5 && st.AvgWaitSeconds < 20:
+		adv.Suggestions = append(adv.Suggestions, Suggestion{
+			Setting:   "WaitTimeSeconds",
+			Current:   fmt.Sprintf("%.1f", st.AvgWaitSeconds),
+			Suggested: "20",
+			Reason:    fmt.Sprintf("%.0f%% of receives came back empty; long polling waits for messages instead of returning empty", emptyRatio*100),
+		})
+	case emptyRatio < 0.05 && st.AvgFullLatencyMs > 1000 && st.AvgWaitSeconds > 1:
+		adv.Suggestions = append(adv.Suggestions, Suggestion{
+			Setting:   "WaitTimeSeconds",
+			Current:   fmt.Sprintf("%.1f", st.AvgWaitSeconds),
+			Suggested: "1-5",
+			Reason:    "receives are almost never empty but take over a second; a shorter wait returns partial batches sooner",
+		})
+	}
+
+	if st.ShortPolls > 0 && st.Empty > 0 {
+		adv.Suggestions = append(adv.Suggestions, Suggestion{
+			Setting:   "WaitTimeSeconds",
+			Current:   "0",
+			Suggested: ">= 1",
+			Reason:    fmt.Sprintf("%d short polls were made; short polls sample a subset of servers and can miss messages", st.ShortPolls),
+		})
+	}
+
+	if st.AvgRequested < 10 && st.AvgBatchFill > 0.9 {
+		adv.Suggestions = append(adv.Suggestions, Suggestion{
+			Setting:   "MaxNumberOfMessages",
+			Current:   fmt.Sprintf("%.0f", st.AvgRequested),
+			Suggested: "10",
+			Reason:    "batches come back full; asking for 10 per call cuts the number of requests",
+		})
+	}
+	if st.AvgBatchFill > 0.9 && counts.Visible > 100 {
+		adv.Suggestions = append(adv.Suggestions, Suggestion{
+			Setting:   "consumer concurrency",
+			Suggested: "increase",
+			Reason:    fmt.Sprintf("batches are full and %d messages are waiting; consumers are not keeping up", counts.Visible),
+		})
+	}
+	return adv, nil
+}
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+// AttributeCacheTTL is how long queue attributes read from SQS are reused. Zero disables
+// the cache.
+var AttributeCacheTTL = 15 * time.Second
+
+// attrCache holds the last GetQueueAttributes result per queue URL, shared by every
+// service of this process so many UI clients polling /info cost one call per TTL.
+var attrCache = struct {
+	sync.Mutex
+	at map[string]cachedAttributes
+}{at: make(map[string]cachedAttributes)}
+
+type cachedAttributes struct {
+	attrs   map[string]string
+	fetched time.Time
+}
+
+type refreshKey struct{}
+
+// WithRefresh returns a context whose queue attribute reads skip the cache (and refill it).
+func WithRefresh(ctx context.Context) context.Context {
+	return context.WithValue(ctx, refreshKey{}, true)
+}
+
+// queueAttributes returns all attributes of the queue and when they were read from SQS.
+// The returned map is shared and must not be modified.
+func (s *SQSService) queueAttributes(ctx context.Context) (map[string]string, time.Time, error) {
+	if refresh, _ := ctx.Value(refreshKey{}).(bool); !refresh && AttributeCacheTTL > 0 {
+		attrCache.Lock()
+		c, ok := attrCache.at[s.QueueURL]
+		attrCache.Unlock()
+		if ok && time.Since(c.fetched) < AttributeCacheTTL {
+			return c.attrs, c.fetched, nil
+		}
+	}
+
+	ctx, cancel := budget(ctx, queueAttrTimeout)
+	defer cancel()
+	out, err := s.Client.GetQueueAttributes(ctx, &sqs.GetQueueAttributesInput{
+		QueueUrl:       &s.QueueURL,
+		AttributeNames: []types.QueueAttributeName{types.QueueAttributeNameAll},
+	})
+	if err != nil {
+		s.noteThrottle(err)
+		return nil, time.Time{}, err
+	}
+	c := cachedAttributes{attrs: out.Attributes, fetched: time.Now()}
+	attrCache.Lock()
+	attrCache.at[s.QueueURL] = c
+	attrCache.Unlock()
+	return c.attrs, c.fetched, nil
+}
+
+// invalidateAttributes drops the cached attributes after this process changed the queue.
+func (s *SQSService) invalidateAttributes() {
+	attrCache.Lock()
+	delete(attrCache.at, s.QueueURL)
+	attrCache.Unlock()
+}
+
+// DepthTag identifies the queue's approximate depth (visible, in flight and delayed counts)
+// as last read through the attribute cache; it changes whenever one of the counts does.
+func (s *SQSService) DepthTag(ctx context.Context) (string, error) {
+	if s.Client == nil {
+		return "", ErrAWSNotConfigured
+	}
+	if s.QueueURL == "" {
+		return "", fmt.Errorf("no active queue configured, try to fetch queue info first")
+	}
+	attrs, _, err := s.queueAttributes(ctx)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s|%s|%s|%s", s.QueueURL,
+		attrs[string(types.QueueAttributeNameApproximateNumberOfMessages)],
+		attrs[string(types.QueueAttributeNameApproximateNumberOfMessagesNotVisible)],
+		attrs[string(types.QueueAttributeNameApproximateNumberOfMessagesDelayed)]), nil
+}
+
+// QueueAttributes is the full attribute set of a queue as returned by GetQueueAttributes
+// (VisibilityTimeout, MessageRetentionPeriod, RedrivePolicy, KmsMasterKeyId, FifoQueue, ...).
+type QueueAttributes struct {
+	QueueName  string            `json:"queue_name"`
+	QueueURL   string            `json:"queue_url"`
+	Attributes map[string]string `json:"attributes"`
+	FetchedAt  time.Time         `json:"fetched_at"`
+	AgeSeconds int               `json:"age_seconds"`
+}
+
+// Attributes returns every attribute of the queue, read through the attribute cache.
+func (s *SQSService) Attributes(ctx context.Context) (QueueAttributes, error) {
+	if s.Client == nil {
+		return QueueAttributes{}, ErrAWSNotConfigured
+	}
+	if s.QueueURL == "" {
+		return QueueAttributes{}, fmt.Errorf("no active queue configured, try to fetch queue info first")
+	}
+	attrs, fetched, err := s.queueAttributes(ctx)
+	if err != nil {
+		s.forgetQueueURL(err)
+		return QueueAttributes{}, err
+	}
+	out := QueueAttributes{
+		QueueName:  s.QueueName,
+		QueueURL:   s.QueueURL,
+		Attributes: make(map[string]string, len(attrs)),
+		FetchedAt:  fetched.UTC(),
+		AgeSeconds: int(time.Since(fetched).Seconds()),
+	}
+	for k, v := range attrs {
+		out.Attributes[k] = v
+	}
+	return out, nil
+}
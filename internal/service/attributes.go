@@ -0,0 +1,105 @@
+package service
+
+import (
+	"encoding/base64"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+
+	"github.com/pachecoc/sqs-ui/internal/validate"
+)
+
+// MessageAttribute is one SQS message attribute as accepted by the API. Type is String,
+// Number or Binary, optionally with a custom suffix ("Number.int"); Binary values are base64.
+type MessageAttribute struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+var attributeNamePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,256}$`)
+
+// groupIDPattern is what SQS accepts for message group and deduplication IDs.
+var groupIDPattern = regexp.MustCompile(`^[!-~]{1,128}$`) // printable ASCII without spaces
+
+// ValidateAttributes checks attributes against SQS rules, reporting each problem under
+// "attributes.<name>" in a validate.Errors.
+func ValidateAttributes(attrs map[string]MessageAttribute) error {
+	var v validate.Validator
+	v.Check(len(attrs) <= validate.MaxMessageAttributes, "attributes",
+		fmt.Sprintf("at most %d attributes are allowed", validate.MaxMessageAttributes))
+	for _, name := range slices.Sorted(maps.Keys(attrs)) {
+		a, field := attrs[name], "attributes."+name
+		lower := strings.ToLower(name)
+		switch {
+		case !attributeNamePattern.MatchString(name):
+			v.Add(field, "name must be 1-256 letters, digits, '_', '-' or '.'")
+		case strings.HasPrefix(lower, "aws.") || strings.HasPrefix(lower, "amazon."):
+			v.Add(field, "names starting with AWS. or Amazon. are reserved")
+		case strings.HasPrefix(name, ".") || strings.HasSuffix(name, ".") || strings.Contains(name, ".."):
+			v.Add(field, "name can't start or end with '.' or contain '..'")
+		}
+		if !v.Required(field+".value", a.Value) {
+			continue
+		}
+		switch base, _, _ := strings.Cut(a.Type, "."); base {
+		case "String":
+		case "Number":
+			_, err := strconv.ParseFloat(a.Value, 64)
+			v.Check(err == nil, field+".value", "must be a number")
+		case "Binary":
+			_, err := base64.StdEncoding.DecodeString(a.Value)
+			v.Check(err == nil, field+".value", "must be base64")
+		default:
+			v.Add(field+".type", "must be String, Number or Binary (optionally with a .suffix)")
+		}
+	}
+	return v.Err()
+}
+
+// attributeValues converts validated attributes for SendMessage.
+func attributeValues(attrs map[string]MessageAttribute) map[string]types.MessageAttributeValue {
+	if len(attrs) == 0 {
+		return nil
+	}
+	out := make(map[string]types.MessageAttributeValue, len(attrs))
+	for name, a := range attrs {
+		v := types.MessageAttributeValue{DataType: aws.String(a.Type)}
+		if strings.HasPrefix(a.Type, "Binary") {
+			v.BinaryValue, _ = base64.StdEncoding.DecodeString(a.Value)
+		} else {
+			v.StringValue = aws.String(a.Value)
+		}
+		out[name] = v
+	}
+	return out
+}
+
+// validateOptions checks the per-message options against the queue type. FIFO queues only
+// support a queue-level delay; deduplication IDs only exist on FIFO queues.
+func (s *SQSService) validateOptions(opts SendOptions) error {
+	var v validate.Validator
+	if v.Range("delay_seconds", int(opts.DelaySeconds), 0, validate.MaxDelaySeconds) && opts.DelaySeconds > 0 {
+		v.Check(!isFIFO(s.QueueURL), "delay_seconds", "is not supported on FIFO queues (set DelaySeconds on the queue instead)")
+	}
+	v.Merge("message_group_id", ValidateID("message_group_id", opts.MessageGroupID))
+	v.Merge("dedup_id", ValidateID("dedup_id", opts.DeduplicationID))
+	if opts.DeduplicationID != "" {
+		v.Check(isFIFO(s.QueueURL), "dedup_id", "is only supported on FIFO queues")
+	}
+	return v.Err()
+}
+
+// ValidateID checks an optional message group or deduplication ID reported under field.
+func ValidateID(field, id string) error {
+	var v validate.Validator
+	if id != "" {
+		v.Check(groupIDPattern.MatchString(id), field, "must be 1-128 letters, digits or punctuation")
+	}
+	return v.Err()
+}
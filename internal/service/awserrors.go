@@ -0,0 +1,114 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/aws/smithy-go"
+)
+
+// AWS failure kinds reported as API error codes.
+const (
+	KindQueueNotFound      = "queue_not_found"
+	KindAccessDenied       = "access_denied"
+	KindKMSAccessDenied    = "kms_access_denied"
+	KindPurgeInProgress    = "purge_in_progress"
+	KindThrottled          = "aws_throttled"
+	KindCredentialsInvalid = "aws_credentials_invalid"
+)
+
+// AWSError is a common SQS failure rewritten as an actionable message. The SDK error
+// stays reachable through Unwrap for logging.
+type AWSError struct {
+	Kind    string
+	Message string
+	Hint    string
+	Err     error
+}
+
+func (e *AWSError) Error() string     { return e.Message }
+func (e *AWSError) ErrorCode() string { return e.Kind }
+func (e *AWSError) Unwrap() error     { return e.Err }
+
+// Remediation returns what the operator can do about it.
+func (e *AWSError) Remediation() string { return e.Hint }
+
+// deniedActionPattern finds the action in "... is not authorized to perform: sqs:PurgeQueue on resource ...".
+var deniedActionPattern = regexp.MustCompile(`not authorized to perform: ([\w-]+:\w+)`)
+
+// TranslateAWSError returns err as an *AWSError when it wraps a known SQS or KMS failure,
+// and err unchanged otherwise. Both the JSON protocol codes and the legacy query codes
+// (AWS.SimpleQueueService.*) are recognised.
+func TranslateAWSError(err error) error {
+	var translated *AWSError
+	if err == nil || errors.As(err, &translated) {
+		return err
+	}
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) {
+		return err
+	}
+	switch apiErr.ErrorCode() {
+	case "AWS.SimpleQueueService.NonExistentQueue", "QueueDoesNotExist":
+		return &AWSError{
+			Kind:    KindQueueNotFound,
+			Message: "the queue does not exist in this account and region",
+			Hint:    "Check the queue name or URL, the AWS region (AWS_REGION) and that the credentials belong to the queue's account.",
+			Err:     err,
+		}
+	case "AccessDenied", "AccessDeniedException":
+		action := "the required SQS action"
+		if m := deniedActionPattern.FindStringSubmatch(apiErr.ErrorMessage()); m != nil {
+			action = m[1]
+		}
+		return &AWSError{
+			Kind:    KindAccessDenied,
+			Message: fmt.Sprintf("access denied: the AWS identity is not allowed to perform %s", action),
+			Hint:    fmt.Sprintf("Allow %s on the queue in the IAM policy of the role sqs-ui runs as, and check the queue policy does not deny it.", action),
+			Err:     err,
+		}
+	case "KMS.AccessDeniedException", "KmsAccessDenied", "KMS.DisabledException", "KmsDisabled":
+		return &AWSError{
+			Kind:    KindKMSAccessDenied,
+			Message: "the queue is encrypted with a KMS key this identity cannot use",
+			Hint:    "Allow kms:GenerateDataKey (send) and kms:Decrypt (receive) on the queue's KMS key, and make sure the key is enabled.",
+			Err:     err,
+		}
+	case "AWS.SimpleQueueService.PurgeQueueInProgress", "PurgeQueueInProgress":
+		return &AWSError{
+			Kind:    KindPurgeInProgress,
+			Message: "a purge of this queue is already in progress",
+			Hint:    "SQS allows one purge per queue every 60 seconds; wait and try again.",
+			Err:     err,
+		}
+	case "ThrottlingException", "RequestThrottled", "AWS.SimpleQueueService.RequestThrottled":
+		return &AWSError{
+			Kind:    KindThrottled,
+			Message: "SQS is throttling requests",
+			Hint:    "Retry in a few seconds; reduce job concurrency or polling frequency if it persists.",
+			Err:     err,
+		}
+	case "InvalidClientTokenId", "SignatureDoesNotMatch", "ExpiredToken", "UnrecognizedClientException":
+		return &AWSError{
+			Kind:    KindCredentialsInvalid,
+			Message: "AWS rejected the credentials (invalid or expired)",
+			Hint:    "Refresh the credentials (e.g. aws sso login) or check AWS_PROFILE / AWS_ACCESS_KEY_ID.",
+			Err:     err,
+		}
+	}
+	return err
+}
+
+// setInfoError reports err in an Info map: the (translated) message, and its code and
+// remediation hint when known.
+func setInfoError(info map[string]any, err error) {
+	err = TranslateAWSError(err)
+	info["error"] = err.Error()
+	if coded, ok := err.(interface{ ErrorCode() string }); ok {
+		info["error_code"] = coded.ErrorCode()
+	}
+	if awsErr, ok := err.(*AWSError); ok {
+		info["hint"] = awsErr.Hint
+	}
+}
@@ -0,0 +1,20 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrPartial is returned alongside the results gathered before the caller's deadline
+// expired in the middle of a multi-call operation (listings, DLQ merges).
+var ErrPartial = errors.New("deadline reached before all results were gathered")
+
+// budget bounds an operation by the caller's deadline when it has one (an HTTP request's
+// budget), otherwise by the fallback d (background jobs, watchers).
+func budget(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, d)
+}
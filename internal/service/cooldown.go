@@ -0,0 +1,67 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// PurgeCooldown is how long SQS refuses another purge of the same queue.
+const PurgeCooldown = 60 * time.Second
+
+// lastPurges remembers when each queue (by URL) was last purged by this process, or
+// when SQS last reported a purge in progress.
+var lastPurges = struct {
+	sync.Mutex
+	at map[string]time.Time
+}{at: make(map[string]time.Time)}
+
+// PurgeCooldownError is returned when a purge is attempted within PurgeCooldown of the last one.
+type PurgeCooldownError struct {
+	Remaining time.Duration
+}
+
+func (e *PurgeCooldownError) Error() string {
+	return fmt.Sprintf("the queue was purged less than %d seconds ago; retry in %d seconds",
+		int(PurgeCooldown.Seconds()), retrySeconds(e.Remaining))
+}
+func (e *PurgeCooldownError) ErrorCode() string         { return "purge_cooldown" }
+func (e *PurgeCooldownError) RetryAfter() time.Duration { return e.Remaining }
+
+// PurgeCooldownRemaining is how long until the queue can be purged again (0 = now).
+func (s *SQSService) PurgeCooldownRemaining() time.Duration {
+	lastPurges.Lock()
+	defer lastPurges.Unlock()
+	return max(0, time.Until(lastPurges.at[s.QueueURL].Add(PurgeCooldown)))
+}
+
+// checkPurgeCooldown fails while a recent purge blocks another one.
+func (s *SQSService) checkPurgeCooldown() error {
+	if remaining := s.PurgeCooldownRemaining(); remaining > 0 {
+		return &PurgeCooldownError{Remaining: remaining}
+	}
+	return nil
+}
+
+// recordPurge starts the cooldown after a purge, or after SQS refused one because another
+// (e.g. from the console) is in progress; the exact start is unknown then, so the full
+// cooldown applies.
+func (s *SQSService) recordPurge(err error) error {
+	var awsErr *AWSError
+	if err != nil && !(errors.As(TranslateAWSError(err), &awsErr) && awsErr.Kind == KindPurgeInProgress) {
+		return fmt.Errorf("failed to purge queue: %w", err)
+	}
+	lastPurges.Lock()
+	lastPurges.at[s.QueueURL] = time.Now()
+	lastPurges.Unlock()
+	if err != nil {
+		return &PurgeCooldownError{Remaining: PurgeCooldown}
+	}
+	return nil
+}
+
+func retrySeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+// QueueExists reports whether a queue named name exists in the service's account and region.
+// It needs only a client, not a configured queue.
+func (s *SQSService) QueueExists(ctx context.Context, name string) (bool, error) {
+	if s.Client == nil {
+		return false, ErrAWSNotConfigured
+	}
+	ctx, cancel := budget(ctx, queueAttrTimeout)
+	defer cancel()
+
+	_, err := s.Client.GetQueueUrl(ctx, &sqs.GetQueueUrlInput{QueueName: aws.String(name)})
+	var awsErr *AWSError
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.As(TranslateAWSError(err), &awsErr) && awsErr.Kind == KindQueueNotFound:
+		return false, nil
+	default:
+		return false, err
+	}
+}
+
+// CreateQueue creates a queue with attrs and tags (either may be nil) and returns its URL.
+func (s *SQSService) CreateQueue(ctx context.Context, name string, attrs, tags map[string]string) (string, error) {
+	if s.Client == nil {
+		return "", ErrAWSNotConfigured
+	}
+	ctx, cancel := budget(ctx, queueAttrTimeout)
+	defer cancel()
+
+	out, err := s.Client.CreateQueue(ctx, &sqs.CreateQueueInput{QueueName: aws.String(name), Attributes: attrs, Tags: tags})
+	if err != nil {
+		return "", fmt.Errorf("failed to create queue %s: %w", name, err)
+	}
+	s.Log.InfoContext(ctx, "queue created", "queue_name", name, "queue_url", aws.ToString(out.QueueUrl))
+	return aws.ToString(out.QueueUrl), nil
+}
+
+// QueueARN reads the ARN of the queue at url.
+func (s *SQSService) QueueARN(ctx context.Context, url string) (string, error) {
+	if s.Client == nil {
+		return "", ErrAWSNotConfigured
+	}
+	ctx, cancel := budget(ctx, queueAttrTimeout)
+	defer cancel()
+
+	out, err := s.Client.GetQueueAttributes(ctx, &sqs.GetQueueAttributesInput{
+		QueueUrl:       aws.String(url),
+		AttributeNames: []types.QueueAttributeName{types.QueueAttributeNameQueueArn},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to read the ARN of %s: %w", url, err)
+	}
+	return out.Attributes[string(types.QueueAttributeNameQueueArn)], nil
+}
+
+// QueueCreatedAt reads when the queue at url was created.
+func (s *SQSService) QueueCreatedAt(ctx context.Context, url string) (time.Time, error) {
+	if s.Client == nil {
+		return time.Time{}, ErrAWSNotConfigured
+	}
+	ctx, cancel := budget(ctx, queueAttrTimeout)
+	defer cancel()
+
+	out, err := s.Client.GetQueueAttributes(ctx, &sqs.GetQueueAttributesInput{
+		QueueUrl:       aws.String(url),
+		AttributeNames: []types.QueueAttributeName{types.QueueAttributeNameCreatedTimestamp},
+	})
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to read the creation time of %s: %w", url, err)
+	}
+	secs, err := strconv.ParseInt(out.Attributes[string(types.QueueAttributeNameCreatedTimestamp)], 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid CreatedTimestamp of %s: %w", url, err)
+	}
+	return time.Unix(secs, 0).UTC(), nil
+}
+
+// QueueTags returns the tags of the queue at url.
+func (s *SQSService) QueueTags(ctx context.Context, url string) (map[string]string, error) {
+	if s.Client == nil {
+		return nil, ErrAWSNotConfigured
+	}
+	ctx, cancel := budget(ctx, queueAttrTimeout)
+	defer cancel()
+
+	out, err := s.Client.ListQueueTags(ctx, &sqs.ListQueueTagsInput{QueueUrl: aws.String(url)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags of %s: %w", url, err)
+	}
+	if out.Tags == nil {
+		return map[string]string{}, nil
+	}
+	return out.Tags, nil
+}
+
+// DeleteQueue deletes the queue at url: a DLQ rolled back after a failed creation, or an
+// ephemeral queue removed by a cleanup job or the scratch queue reaper.
+func (s *SQSService) DeleteQueue(ctx context.Context, url string) error {
+	if s.Client == nil {
+		return ErrAWSNotConfigured
+	}
+	ctx, cancel := budget(ctx, queueAttrTimeout)
+	defer cancel()
+
+	if _, err := s.Client.DeleteQueue(ctx, &sqs.DeleteQueueInput{QueueUrl: aws.String(url)}); err != nil {
+		return fmt.Errorf("failed to delete queue %s: %w", url, err)
+	}
+	s.Log.WarnContext(ctx, "queue deleted", "queue_url", url)
+	return nil
+}
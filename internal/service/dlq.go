@@ -0,0 +1,177 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+// Message origins used when listings from several queues are merged.
+const (
+	OriginQueue = "queue"
+	OriginDLQ   = "dlq"
+)
+
+// ErrNoDeadLetterQueue is returned when the queue has no redrive policy.
+var ErrNoDeadLetterQueue = errors.New("queue has no dead-letter queue configured")
+
+// DeadLetterQueue returns a service for the queue's DLQ, resolved from its RedrivePolicy.
+func (s *SQSService) DeadLetterQueue(ctx context.Context) (*SQSService, error) {
+	if s.Client == nil {
+		return nil, ErrAWSNotConfigured
+	}
+	if s.QueueURL == "" {
+		return nil, fmt.Errorf("no active queue configured, try to fetch queue info first")
+	}
+
+	attrs, _, err := s.queueAttributes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read redrive policy: %w", err)
+	}
+	raw := attrs[string(types.QueueAttributeNameRedrivePolicy)]
+	if raw == "" {
+		return nil, ErrNoDeadLetterQueue
+	}
+	policy, err := parseRedrivePolicy(raw)
+	if err != nil {
+		return nil, err
+	}
+	region, account, name, err := queueARNParts(policy.DeadLetterTargetARN)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.Client.GetQueueUrl(ctx, &sqs.GetQueueUrlInput{
+		QueueName:              aws.String(name),
+		QueueOwnerAWSAccountId: aws.String(account),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve dead-letter queue %s: %w", name, err)
+	}
+
+	dlq := &SQSService{
+		Client:    s.Client,
+		QueueName: name,
+		QueueURL:  aws.ToString(resp.QueueUrl),
+		Region:    region,
+		Log:       s.Log,
+		Mode:      s.Mode,
+		Configure: s.Configure,
+	}
+	if s.Configure != nil {
+		s.Configure(dlq)
+	}
+	return dlq, nil
+}
+
+// DeadLetterSources returns up to max queues whose redrive policy targets this queue,
+// continuing after token when set. No queues means the queue is not a dead-letter queue.
+func (s *SQSService) DeadLetterSources(ctx context.Context, max int32, token string) (QueueList, error) {
+	list := QueueList{Queues: []QueueRef{}}
+	if s.Client == nil {
+		return list, ErrAWSNotConfigured
+	}
+	if s.QueueURL == "" {
+		return list, fmt.Errorf("no active queue configured, try to fetch queue info first")
+	}
+
+	ctx, cancel := budget(ctx, queueAttrTimeout)
+	defer cancel()
+
+	input := &sqs.ListDeadLetterSourceQueuesInput{QueueUrl: &s.QueueURL, MaxResults: aws.Int32(max)}
+	if token != "" {
+		input.NextToken = aws.String(token)
+	}
+	out, err := s.Client.ListDeadLetterSourceQueues(ctx, input)
+	if err != nil {
+		return list, fmt.Errorf("failed to list dead-letter source queues: %w", err)
+	}
+	for _, u := range out.QueueUrls {
+		list.Queues = append(list.Queues, QueueRef{Name: u[strings.LastIndex(u, "/")+1:], URL: u})
+	}
+	list.NextToken = aws.ToString(out.NextToken)
+	return list, nil
+}
+
+// maxInfoSources caps the source queues /info lists; /api/dlq/sources pages through all.
+const maxInfoSources = 100
+
+// dlqSources caches the source queue names shown in Info per queue URL, for AttributeCacheTTL.
+var dlqSources = struct {
+	sync.Mutex
+	at map[string]cachedSources
+}{at: make(map[string]cachedSources)}
+
+type cachedSources struct {
+	names   []string
+	fetched time.Time
+}
+
+// deadLetterSourceNames returns the names of (up to maxInfoSources) queues that use this
+// queue as their DLQ.
+func (s *SQSService) deadLetterSourceNames(ctx context.Context) ([]string, error) {
+	if refresh, _ := ctx.Value(refreshKey{}).(bool); !refresh && AttributeCacheTTL > 0 {
+		dlqSources.Lock()
+		c, ok := dlqSources.at[s.QueueURL]
+		dlqSources.Unlock()
+		if ok && time.Since(c.fetched) < AttributeCacheTTL {
+			return c.names, nil
+		}
+	}
+	list, err := s.DeadLetterSources(ctx, maxInfoSources, "")
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(list.Queues))
+	for _, q := range list.Queues {
+		names = append(names, q.Name)
+	}
+	dlqSources.Lock()
+	dlqSources.at[s.QueueURL] = cachedSources{names: names, fetched: time.Now()}
+	dlqSources.Unlock()
+	return names, nil
+}
+
+// ReceiveWithDLQ lists the queue and its DLQ in one view, labeling each message's origin.
+// Only observe (or peek) mode is supported: receipt handles from two queues can't share one delete call.
+func (s *SQSService) ReceiveWithDLQ(ctx context.Context, mode ReceiveMode) ([]map[string]interface{}, error) {
+	if mode != ModeObserve && mode != ModePeek {
+		return nil, fmt.Errorf("include_dlq requires observe mode")
+	}
+	dlq, err := s.DeadLetterQueue(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	msgs, err := s.Receive(ctx, mode)
+	for _, m := range msgs {
+		m["origin"] = OriginQueue
+	}
+	if err != nil {
+		return msgs, err
+	}
+	if ctx.Err() != nil {
+		// Budget spent on the queue itself; report what we have rather than nothing
+		return msgs, fmt.Errorf("%w: %w", ErrPartial, ctx.Err())
+	}
+
+	dlqMsgs, err := dlq.Receive(ctx, mode)
+	for _, m := range dlqMsgs {
+		m["origin"] = OriginDLQ
+		m["queue_name"] = dlq.QueueName
+	}
+	msgs = append(msgs, dlqMsgs...)
+	if errors.Is(err, ErrPartial) || (err != nil && ctx.Err() != nil) {
+		return msgs, fmt.Errorf("%w: dead-letter queue %s: %w", ErrPartial, dlq.QueueName, ctx.Err())
+	}
+	if err != nil {
+		return nil, fmt.Errorf("dead-letter queue %s: %w", dlq.QueueName, err)
+	}
+	return msgs, nil
+}
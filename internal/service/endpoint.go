@@ -0,0 +1,37 @@
+package service
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+)
+
+// DefaultLocalRegion signs requests to a custom endpoint when no region is configured;
+// LocalStack and ElasticMQ accept any region, but the SDK needs one.
+const DefaultLocalRegion = "us-east-1"
+
+// NewClient builds an SQS client from cfg. A non-empty endpoint (LocalStack, ElasticMQ, ...)
+// replaces the AWS one; without it AWS_ENDPOINT_URL_SQS and AWS_ENDPOINT_URL, read into cfg
+// by the SDK, still apply.
+func NewClient(cfg aws.Config, endpoint string) *sqs.Client {
+	return sqs.NewFromConfig(cfg, func(o *sqs.Options) {
+		if endpoint == "" {
+			return
+		}
+		o.BaseEndpoint = aws.String(endpoint)
+		if o.Region == "" {
+			o.Region = DefaultLocalRegion
+		}
+	})
+}
+
+// ValidateEndpoint checks that endpoint is an absolute http(s) URL.
+func ValidateEndpoint(endpoint string) error {
+	u, err := url.Parse(endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%q must be an http:// or https:// URL", endpoint)
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pachecoc/sqs-ui/internal/faultsqs"
+	"github.com/pachecoc/sqs-ui/internal/memsqs"
+	"github.com/pachecoc/sqs-ui/internal/service"
+)
+
+func TestTailRetriesFailedReceives(t *testing.T) {
+	mem := memsqs.New()
+	// Seed 1 fails the first receive and lets the second through
+	faulty := faultsqs.New(mem, 1, faultsqs.Fault{Ops: []string{"ReceiveMessage"}, ErrorRate: 0.5})
+	svc := newQueue(t, mem, faulty, "orders", 0)
+	if _, err := svc.Send(context.Background(), "late", service.SendOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var emitted, failed int
+	err := svc.Tail(ctx, time.Now().Add(-time.Minute), func(msgs []service.PipeMessage) {
+		emitted += len(msgs)
+		cancel()
+	}, func(err error) {
+		failed++
+		var apiErr interface{ ErrorCode() string }
+		if !errors.As(err, &apiErr) || apiErr.ErrorCode() != faultsqs.DefaultCode {
+			t.Errorf("reported %v, want the injected fault", err)
+		}
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Tail returned %v, want it stopped after the emit", err)
+	}
+	if failed != 1 || emitted != 1 {
+		t.Errorf("%d failures reported and %d messages emitted, want 1 and 1", failed, emitted)
+	}
+}
+
+func TestReceivePartialOnDeadline(t *testing.T) {
+	mem := memsqs.New()
+	faulty := faultsqs.New(mem, 1, faultsqs.Fault{Ops: []string{"ReceiveMessage"}, Latency: 300 * time.Millisecond})
+	svc := newQueue(t, mem, faulty, "orders", 0)
+	if _, err := svc.Send(context.Background(), "first", service.SendOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The first receive returns the message at 300ms; the next is still delayed at the deadline
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	msgs, err := svc.Receive(ctx, service.ModeObserve)
+	if !errors.Is(err, service.ErrPartial) {
+		t.Fatalf("receive past the deadline: %v, want ErrPartial", err)
+	}
+	if len(msgs) != 1 || msgs[0]["body"] != "first" {
+		t.Errorf("partial listing %v, want the message that arrived", msgs)
+	}
+	wantCounts(t, svc, service.QueueCounts{Visible: 1})
+
+	// Without any message to show, the deadline is an error
+	empty := newQueue(t, mem, faulty, "empty", 0)
+	short, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if msgs, err := empty.Receive(short, service.ModeObserve); !errors.Is(err, context.DeadlineExceeded) || errors.Is(err, service.ErrPartial) {
+		t.Errorf("receive of nothing past the deadline: %v, %v", msgs, err)
+	}
+}
+
+func TestDeleteCountsPartialFailures(t *testing.T) {
+	ctx := context.Background()
+	mem := memsqs.New()
+	faulty := faultsqs.New(mem, 1, faultsqs.Fault{Ops: []string{"DeleteMessageBatch"}, PartialRate: 1})
+	svc := newQueue(t, mem, faulty, "orders", 0)
+	for range 2 {
+		if _, err := svc.Send(ctx, "stuck", service.SendOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	msgs, err := svc.Receive(ctx, service.ModeConsume)
+	if err != nil || len(msgs) != 2 {
+		t.Fatalf("consume: %d messages, %v", len(msgs), err)
+	}
+	handles := []string{msgs[0]["receipt_handle"].(string), msgs[1]["receipt_handle"].(string)}
+
+	if n, err := svc.Delete(ctx, handles); err != nil || n != 0 {
+		t.Errorf("delete with every entry failing: %d, %v, want 0 and no error", n, err)
+	}
+	wantCounts(t, svc, service.QueueCounts{NotVisible: 2})
+}
+
+func TestInjectedErrorsAreTranslated(t *testing.T) {
+	mem := memsqs.New()
+	faulty := faultsqs.New(mem, 1, faultsqs.Fault{Ops: []string{"SendMessage"}, ErrorRate: 1, Code: "ThrottlingException"})
+	svc := newQueue(t, mem, faulty, "orders", 0)
+
+	_, err := svc.Send(context.Background(), "hello", service.SendOptions{})
+	var awsErr *service.AWSError
+	if !errors.As(service.TranslateAWSError(err), &awsErr) || awsErr.Kind != service.KindThrottled || awsErr.Hint == "" {
+		t.Errorf("throttled send: %v, want an aws_throttled error with a hint", err)
+	}
+}
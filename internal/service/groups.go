@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+// GroupDrainResult reports a DrainGroups run.
+type GroupDrainResult struct {
+	Matched map[string]int   `json:"matched"` // by MessageGroupId
+	Deleted int              `json:"deleted"`
+	Other   int              `json:"other"` // messages of other groups, released untouched
+	Sample  []map[string]any `json:"sample,omitempty"`
+}
+
+// DrainGroups deletes the messages of the given MessageGroupIds from a FIFO queue until no more
+// are receivable, limit matches are reached (0 = no limit) or ctx ends. Messages of other groups
+// are held in flight while the drain runs, so SQS moves on to the next groups, and released at the
+// end. With dryRun nothing is deleted and the result counts and samples the matches.
+func (s *SQSService) DrainGroups(ctx context.Context, groups []string, limit int, dryRun bool) (GroupDrainResult, error) {
+	s.Log.DebugContext(ctx, "draining message groups", "queue_name", s.QueueName, "groups", groups, "limit", limit, "dry_run", dryRun)
+
+	res := GroupDrainResult{Matched: make(map[string]int)}
+	if s.Client == nil {
+		return res, ErrAWSNotConfigured
+	}
+	if s.QueueURL == "" {
+		return res, fmt.Errorf("no active queue configured, try to fetch queue info first")
+	}
+	if s.ReadOnly && !dryRun {
+		return res, ErrReadOnly
+	}
+	if !isFIFO(s.QueueURL) {
+		return res, fmt.Errorf("queue %s is not a FIFO queue", s.QueueName)
+	}
+	if len(groups) == 0 {
+		return res, fmt.Errorf("at least one message group is required")
+	}
+
+	var held []string
+	defer func() { s.release(context.WithoutCancel(ctx), held) }()
+
+	matched, empty := 0, 0
+	for empty < 2 && (limit == 0 || matched < limit) && ctx.Err() == nil {
+		resp, err := s.receiveMessage(ctx, &sqs.ReceiveMessageInput{
+			QueueUrl:                    &s.QueueURL,
+			MaxNumberOfMessages:         10,
+			VisibilityTimeout:           drainVisibility,
+			WaitTimeSeconds:             1,
+			MessageSystemAttributeNames: []types.MessageSystemAttributeName{types.MessageSystemAttributeNameMessageGroupId},
+		})
+		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			return res, fmt.Errorf("failed to receive messages: %w", err)
+		}
+		if len(resp.Messages) == 0 {
+			empty++
+			continue
+		}
+		empty = 0
+
+		var entries []types.DeleteMessageBatchRequestEntry
+		for _, m := range resp.Messages {
+			group := m.Attributes[string(types.MessageSystemAttributeNameMessageGroupId)]
+			wanted := slices.Contains(groups, group)
+			if !wanted || (limit > 0 && matched >= limit) {
+				if !wanted {
+					res.Other++
+				}
+				held = append(held, aws.ToString(m.ReceiptHandle))
+				continue
+			}
+
+			matched++
+			res.Matched[group]++
+			if len(res.Sample) < planSampleSize {
+				res.Sample = append(res.Sample, map[string]any{
+					"message_id":       aws.ToString(m.MessageId),
+					"message_group_id": group,
+					"body":             aws.ToString(m.Body),
+				})
+			}
+			if dryRun {
+				held = append(held, aws.ToString(m.ReceiptHandle))
+				continue
+			}
+			entries = append(entries, types.DeleteMessageBatchRequestEntry{
+				Id:            aws.String(strconv.Itoa(len(entries))),
+				ReceiptHandle: m.ReceiptHandle,
+			})
+		}
+
+		if len(entries) > 0 {
+			out, err := s.Client.DeleteMessageBatch(ctx, &sqs.DeleteMessageBatchInput{
+				QueueUrl: &s.QueueURL,
+				Entries:  entries,
+			})
+			if err != nil {
+				return res, fmt.Errorf("failed to delete messages: %w", err)
+			}
+			res.Deleted += len(out.Successful)
+			if len(out.Failed) > 0 {
+				s.Log.WarnContext(ctx, "some deletes failed during group drain", "failed", len(out.Failed))
+			}
+		}
+	}
+
+	s.Log.InfoContext(ctx, "message groups drained", "queue_name", s.QueueName, "groups", groups,
+		"deleted", res.Deleted, "other", res.Other, "dry_run", dryRun)
+	return res, nil
+}
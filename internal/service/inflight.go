@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+// ReceiveStats summarizes delivery attributes of one sample of receivable messages.
+type ReceiveStats struct {
+	Sampled         int        `json:"sampled"`
+	Redelivered     int        `json:"redelivered"` // received more than once before
+	MaxReceiveCount int        `json:"max_receive_count"`
+	OldestSentAt    *time.Time `json:"oldest_sent_at,omitempty"`
+}
+
+// VisibilityTimeout reads the queue's default visibility timeout.
+func (s *SQSService) VisibilityTimeout(ctx context.Context) (time.Duration, error) {
+	if s.Client == nil {
+		return 0, ErrAWSNotConfigured
+	}
+	if s.QueueURL == "" {
+		return 0, fmt.Errorf("no active queue configured, try to fetch queue info first")
+	}
+
+	attrs, _, err := s.queueAttributes(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get queue attributes: %w", err)
+	}
+	secs, _ := strconv.Atoi(attrs[string(types.QueueAttributeNameVisibilityTimeout)])
+	return time.Duration(secs) * time.Second, nil
+}
+
+// SampleReceiveStats receives one batch, reads its receive counts and timestamps and releases it.
+func (s *SQSService) SampleReceiveStats(ctx context.Context) (ReceiveStats, error) {
+	var stats ReceiveStats
+	if s.Client == nil {
+		return stats, ErrAWSNotConfigured
+	}
+	if s.QueueURL == "" {
+		return stats, fmt.Errorf("no active queue configured, try to fetch queue info first")
+	}
+
+	ctx, cancel := budget(ctx, receiveTimeout)
+	defer cancel()
+	resp, err := s.receiveMessage(ctx, &sqs.ReceiveMessageInput{
+		QueueUrl:            &s.QueueURL,
+		MaxNumberOfMessages: 10,
+		VisibilityTimeout:   receiveVisibility,
+		WaitTimeSeconds:     1,
+		MessageSystemAttributeNames: []types.MessageSystemAttributeName{
+			types.MessageSystemAttributeNameApproximateReceiveCount,
+			types.MessageSystemAttributeNameSentTimestamp,
+		},
+	})
+	if err != nil {
+		return stats, fmt.Errorf("failed to sample messages: %w", err)
+	}
+
+	handles := make([]string, 0, len(resp.Messages))
+	for _, m := range resp.Messages {
+		handles = append(handles, aws.ToString(m.ReceiptHandle))
+		stats.Sampled++
+		// The count includes this sample's own receive
+		count, _ := strconv.Atoi(m.Attributes[string(types.MessageSystemAttributeNameApproximateReceiveCount)])
+		if count > 1 {
+			stats.Redelivered++
+		}
+		stats.MaxReceiveCount = max(stats.MaxReceiveCount, count-1)
+		if ms, err := strconv.ParseInt(m.Attributes[string(types.MessageSystemAttributeNameSentTimestamp)], 10, 64); err == nil {
+			sent := time.UnixMilli(ms).UTC()
+			if stats.OldestSentAt == nil || sent.Before(*stats.OldestSentAt) {
+				stats.OldestSentAt = &sent
+			}
+		}
+	}
+	s.release(context.WithoutCancel(ctx), handles)
+	return stats, nil
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ReceiveMode controls what listing messages does to them.
+type ReceiveMode string
+
+const (
+	// ModeObserve is non-destructive: messages are released (visibility 0) as soon as the listing completes.
+	ModeObserve ReceiveMode = "observe"
+	// ModeConsume keeps messages in flight for ConsumeVisibility and expects the caller to delete them.
+	ModeConsume ReceiveMode = "consume"
+	// ModePeek receives with visibility 0, so listed messages are never hidden from other
+	// consumers. It is forced while a queue has an observe-only lock; batches may repeat messages.
+	ModePeek ReceiveMode = "peek"
+)
+
+// ConsumeVisibility is how long consumed messages stay hidden before SQS redelivers them.
+const ConsumeVisibility = 30 * time.Second
+
+// ParseReceiveMode validates a mode name; empty means "use the default".
+func ParseReceiveMode(v string) (ReceiveMode, error) {
+	switch m := ReceiveMode(strings.ToLower(strings.TrimSpace(v))); m {
+	case "", ModeObserve, ModeConsume:
+		return m, nil
+	default:
+		return "", fmt.Errorf("invalid receive mode %q (expected observe or consume)", v)
+	}
+}
+
+// DefaultMode returns the service's receive mode, falling back to observe.
+func (s *SQSService) DefaultMode() ReceiveMode {
+	if s.Mode == "" {
+		return ModeObserve
+	}
+	return s.Mode
+}
+
+// VisibilityFor is how long messages listed in mode remain hidden on this queue.
+func (s *SQSService) VisibilityFor(mode ReceiveMode) time.Duration {
+	if mode == ModeConsume && s.ConsumeTimeout > 0 {
+		return s.ConsumeTimeout
+	}
+	return mode.Visibility()
+}
+
+func (s *SQSService) waitSeconds() int32 {
+	if s.WaitSeconds > 0 {
+		return s.WaitSeconds
+	}
+	return receiveWaitSecs
+}
+
+// Visibility is how long messages listed in this mode remain hidden by default.
+func (m ReceiveMode) Visibility() time.Duration {
+	if m == ModeConsume {
+		return ConsumeVisibility
+	}
+	return 0
+}
@@ -0,0 +1,175 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+
+	"github.com/pachecoc/sqs-ui/internal/tracing"
+	"github.com/pachecoc/sqs-ui/internal/validate"
+)
+
+// PipeMessage is one NDJSON line of the CLI pipe mode: read from stdin by `sqs-ui send`
+// and written to stdout by `sqs-ui receive`.
+type PipeMessage struct {
+	MessageID       string                      `json:"message_id,omitempty"`
+	Body            string                      `json:"body"`
+	Attributes      map[string]MessageAttribute `json:"attributes,omitempty"`
+	MessageGroupID  string                      `json:"message_group_id,omitempty"`
+	DeduplicationID string                      `json:"deduplication_id,omitempty"`
+	DelaySeconds    int32                       `json:"delay_seconds,omitempty"`
+	ReceiveCount    int                         `json:"receive_count,omitempty"`
+	SentTimestamp   string                      `json:"sent_timestamp,omitempty"` // epoch milliseconds, as SQS reports it
+
+	// ReceiptHandle is kept for deleting a received message; it is not written out.
+	ReceiptHandle string `json:"-"`
+}
+
+func (m PipeMessage) options() SendOptions {
+	return SendOptions{
+		Attributes:      m.Attributes,
+		DelaySeconds:    m.DelaySeconds,
+		MessageGroupID:  m.MessageGroupID,
+		DeduplicationID: m.DeduplicationID,
+	}
+}
+
+// BatchFailure is a message SendBatch could not send; Index is its position in the batch.
+type BatchFailure struct {
+	Index int    `json:"index"`
+	Error string `json:"error"`
+}
+
+// BatchSize returns the payload size SendMessageBatch counts for m (body and attributes).
+func BatchSize(m PipeMessage) int {
+	n := len(m.Body)
+	for name, a := range m.Attributes {
+		n += len(name) + len(a.Type) + len(a.Value)
+	}
+	return n
+}
+
+// SendBatch sends up to validate.MaxBatchSize messages, whose total BatchSize may not exceed
+// validate.MaxMessageBytes, in one SendMessageBatch call. Messages that fail validation or
+// that SQS rejects are returned as failures; the others are sent.
+func (s *SQSService) SendBatch(ctx context.Context, msgs []PipeMessage) (_ []SendResult, _ []BatchFailure, err error) {
+	ctx, span := s.startSpan(ctx, "SendBatch", semconv.MessagingBatchMessageCount(len(msgs)))
+	defer func() { tracing.End(span, err) }()
+	if s.Client == nil {
+		return nil, nil, ErrAWSNotConfigured
+	}
+	if s.QueueURL == "" {
+		return nil, nil, fmt.Errorf("no active queue configured, try to fetch queue info first")
+	}
+	if s.ReadOnly {
+		return nil, nil, ErrReadOnly
+	}
+	if len(msgs) == 0 || len(msgs) > validate.MaxBatchSize {
+		return nil, nil, fmt.Errorf("a batch holds 1-%d messages, got %d", validate.MaxBatchSize, len(msgs))
+	}
+
+	var failed []BatchFailure
+	entries := make([]types.SendMessageBatchRequestEntry, 0, len(msgs))
+	for i, m := range msgs {
+		opts := m.options()
+		err := ValidateAttributes(opts.Attributes)
+		if err == nil {
+			err = s.validateOptions(opts)
+		}
+		if err == nil && strings.TrimSpace(m.Body) == "" {
+			err = fmt.Errorf("message body cannot be empty")
+		}
+		var group, dedup *string
+		if err == nil {
+			group, dedup, err = s.fifoIDs(ctx, opts)
+		}
+		if err != nil {
+			failed = append(failed, BatchFailure{Index: i, Error: err.Error()})
+			continue
+		}
+		entries = append(entries, types.SendMessageBatchRequestEntry{
+			Id:                     aws.String(strconv.Itoa(i)),
+			MessageBody:            aws.String(m.Body),
+			MessageAttributes:      attributeValues(opts.Attributes),
+			DelaySeconds:           opts.DelaySeconds,
+			MessageGroupId:         group,
+			MessageDeduplicationId: dedup,
+		})
+	}
+	if len(entries) == 0 {
+		return nil, failed, nil
+	}
+
+	ctx, cancel := budget(ctx, receiveTimeout)
+	defer cancel()
+	out, err := s.Client.SendMessageBatch(ctx, &sqs.SendMessageBatchInput{QueueUrl: aws.String(s.QueueURL), Entries: entries})
+	if err != nil {
+		return nil, failed, fmt.Errorf("failed to send messages: %w", err)
+	}
+	sent := make([]SendResult, 0, len(out.Successful))
+	for _, e := range out.Successful {
+		sent = append(sent, SendResult{
+			MessageID:      aws.ToString(e.MessageId),
+			MD5OfBody:      aws.ToString(e.MD5OfMessageBody),
+			SequenceNumber: aws.ToString(e.SequenceNumber),
+		})
+	}
+	for _, e := range out.Failed {
+		i, _ := strconv.Atoi(aws.ToString(e.Id))
+		failed = append(failed, BatchFailure{Index: i, Error: aws.ToString(e.Code) + ": " + aws.ToString(e.Message)})
+	}
+	s.invalidateAttributes()
+	s.Log.InfoContext(ctx, "message batch sent", "queue_name", s.QueueName, "sent", len(sent), "failed", len(failed))
+	return sent, failed, nil
+}
+
+// ReceiveBatch long-polls once for up to validate.MaxBatchSize messages, keeping them hidden
+// for visibility seconds; delete them with Delete once handled. An empty batch means none
+// arrived within the wait.
+func (s *SQSService) ReceiveBatch(ctx context.Context, visibility int32) ([]PipeMessage, error) {
+	if s.QueueURL == "" {
+		return nil, fmt.Errorf("no active queue configured, try to fetch queue info first")
+	}
+	resp, err := s.receiveMessage(ctx, &sqs.ReceiveMessageInput{
+		QueueUrl:                    aws.String(s.QueueURL),
+		MaxNumberOfMessages:         validate.MaxBatchSize,
+		VisibilityTimeout:           visibility,
+		WaitTimeSeconds:             validate.MaxWaitSeconds,
+		MessageAttributeNames:       []string{"All"},
+		MessageSystemAttributeNames: []types.MessageSystemAttributeName{types.MessageSystemAttributeNameAll},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to receive messages: %w", err)
+	}
+	msgs := make([]PipeMessage, 0, len(resp.Messages))
+	for _, m := range resp.Messages {
+		pm := PipeMessage{
+			MessageID:       aws.ToString(m.MessageId),
+			Body:            aws.ToString(m.Body),
+			MessageGroupID:  m.Attributes[string(types.MessageSystemAttributeNameMessageGroupId)],
+			DeduplicationID: m.Attributes[string(types.MessageSystemAttributeNameMessageDeduplicationId)],
+			SentTimestamp:   m.Attributes[string(types.MessageSystemAttributeNameSentTimestamp)],
+			ReceiptHandle:   aws.ToString(m.ReceiptHandle),
+		}
+		pm.ReceiveCount, _ = strconv.Atoi(m.Attributes[string(types.MessageSystemAttributeNameApproximateReceiveCount)])
+		for name, a := range m.MessageAttributes {
+			if pm.Attributes == nil {
+				pm.Attributes = make(map[string]MessageAttribute, len(m.MessageAttributes))
+			}
+			value := aws.ToString(a.StringValue)
+			if a.BinaryValue != nil {
+				value = base64.StdEncoding.EncodeToString(a.BinaryValue)
+			}
+			pm.Attributes[name] = MessageAttribute{Type: aws.ToString(a.DataType), Value: value}
+		}
+		msgs = append(msgs, pm)
+	}
+	return msgs, nil
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+// planSampleSize caps how many matched messages a dry-run plan includes.
+const planSampleSize = 5
+
+// Plan is what a destructive operation would do, returned instead of running it on dry runs.
+type Plan struct {
+	DryRun      bool             `json:"dry_run"`
+	Action      string           `json:"action"`
+	QueueName   string           `json:"queue_name"`
+	QueueURL    string           `json:"queue_url"`
+	TargetQueue string           `json:"target_queue,omitempty"`
+	Count       int64            `json:"count"` // messages affected; approximate for queue-wide actions
+	Sample      []map[string]any `json:"sample,omitempty"`
+}
+
+// PlanQueue describes a queue-wide action (purge, drain, move, ...) capped at limit messages
+// (0 = all). includeInFlight counts in-flight messages too, as PurgeQueue deletes them. The
+// sample comes from one observe-mode receive, so nothing is consumed.
+func (s *SQSService) PlanQueue(ctx context.Context, action string, limit int, includeInFlight bool) (Plan, error) {
+	plan := Plan{DryRun: true, Action: action, QueueName: s.QueueName, QueueURL: s.QueueURL}
+	if s.Client == nil {
+		return plan, ErrAWSNotConfigured
+	}
+	if s.QueueURL == "" {
+		return plan, fmt.Errorf("no active queue configured, try to fetch queue info first")
+	}
+
+	attrCtx, cancel := budget(ctx, queueAttrTimeout)
+	defer cancel()
+	out, err := s.Client.GetQueueAttributes(attrCtx, &sqs.GetQueueAttributesInput{
+		QueueUrl: &s.QueueURL,
+		AttributeNames: []types.QueueAttributeName{
+			types.QueueAttributeNameApproximateNumberOfMessages,
+			types.QueueAttributeNameApproximateNumberOfMessagesNotVisible,
+		},
+	})
+	if err != nil {
+		return plan, fmt.Errorf("failed to get queue attributes: %w", err)
+	}
+	plan.Count, _ = strconv.ParseInt(out.Attributes[string(types.QueueAttributeNameApproximateNumberOfMessages)], 10, 64)
+	if includeInFlight {
+		inFlight, _ := strconv.ParseInt(out.Attributes[string(types.QueueAttributeNameApproximateNumberOfMessagesNotVisible)], 10, 64)
+		plan.Count += inFlight
+	}
+	if limit > 0 && plan.Count > int64(limit) {
+		plan.Count = int64(limit)
+	}
+
+	if plan.Sample, err = s.sample(ctx, planSampleSize); err != nil {
+		return plan, err
+	}
+	s.Log.InfoContext(ctx, "dry run", "action", action, "queue_name", s.QueueName, "count", plan.Count)
+	return plan, nil
+}
+
+// sample receives up to n messages in one call and releases them straight away.
+func (s *SQSService) sample(ctx context.Context, n int) ([]map[string]any, error) {
+	ctx, cancel := budget(ctx, receiveTimeout)
+	defer cancel()
+
+	resp, err := s.receiveMessage(ctx, &sqs.ReceiveMessageInput{
+		QueueUrl:            &s.QueueURL,
+		MaxNumberOfMessages: int32(min(n, 10)),
+		VisibilityTimeout:   receiveVisibility,
+		WaitTimeSeconds:     1,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to sample messages: %w", err)
+	}
+
+	msgs := make([]map[string]any, 0, len(resp.Messages))
+	handles := make([]string, 0, len(resp.Messages))
+	for _, m := range resp.Messages {
+		handles = append(handles, aws.ToString(m.ReceiptHandle))
+		msgs = append(msgs, map[string]any{"message_id": aws.ToString(m.MessageId), "body": aws.ToString(m.Body)})
+	}
+	s.release(context.WithoutCancel(ctx), handles)
+	return msgs, nil
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+// Poll intervals suggested to clients by PollHint.
+const (
+	pollIntervalBusy      = 5 * time.Second
+	pollIntervalIdle      = 30 * time.Second
+	pollIntervalThrottled = 60 * time.Second
+
+	// pollActivityWindow is how far back receives and counts are considered recent.
+	pollActivityWindow = 5 * time.Minute
+	// pollThrottleWindow is how long clients are slowed down after SQS throttled the queue.
+	pollThrottleWindow = time.Minute
+)
+
+// throttles remembers when SQS last throttled a call for each queue (by name).
+var throttles = struct {
+	sync.Mutex
+	at map[string]time.Time
+}{at: make(map[string]time.Time)}
+
+// noteThrottle records err when it is SQS throttling the queue's calls.
+func (s *SQSService) noteThrottle(err error) {
+	var awsErr *AWSError
+	if err == nil || !errors.As(TranslateAWSError(err), &awsErr) || awsErr.Kind != KindThrottled {
+		return
+	}
+	throttles.Lock()
+	throttles.at[s.QueueName] = time.Now()
+	throttles.Unlock()
+}
+
+// PollHint suggests how long a client should wait before polling the queue again: rarely
+// while SQS throttles it, often while messages are flowing, rarely when it looks idle. It
+// uses only what this process already saw (receives, cached attributes), no AWS calls.
+func (s *SQSService) PollHint() time.Duration {
+	throttles.Lock()
+	throttled := time.Since(throttles.at[s.QueueName]) < pollThrottleWindow
+	throttles.Unlock()
+	switch {
+	case throttled:
+		return pollIntervalThrottled
+	case s.recentlyActive():
+		return pollIntervalBusy
+	default:
+		return pollIntervalIdle
+	}
+}
+
+// recentlyActive reports whether a recent receive got messages or recently cached
+// attributes show messages in the queue.
+func (s *SQSService) recentlyActive() bool {
+	since := time.Now().Add(-pollActivityWindow)
+
+	polls.Lock()
+	list := polls.byQueue[s.QueueName]
+	for i := len(list) - 1; i >= 0 && list[i].at.After(since); i-- {
+		if list[i].received > 0 {
+			polls.Unlock()
+			return true
+		}
+	}
+	polls.Unlock()
+
+	attrCache.Lock()
+	c, ok := attrCache.at[s.QueueURL]
+	attrCache.Unlock()
+	if !ok || c.fetched.Before(since) {
+		return false
+	}
+	for _, name := range []types.QueueAttributeName{
+		types.QueueAttributeNameApproximateNumberOfMessages,
+		types.QueueAttributeNameApproximateNumberOfMessagesNotVisible,
+		types.QueueAttributeNameApproximateNumberOfMessagesDelayed,
+	} {
+		if n, _ := strconv.ParseInt(c.attrs[string(name)], 10, 64); n > 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+
+	"github.com/pachecoc/sqs-ui/internal/tracing"
+)
+
+// pollHistory is how many recent receive calls are kept per queue.
+const pollHistory = 500
+
+// PollStats summarizes recent ReceiveMessage calls made against one queue.
+type PollStats struct {
+	Calls             int     `json:"calls"`
+	Empty             int     `json:"empty"`
+	Errors            int     `json:"errors"`
+	Messages          int     `json:"messages"`
+	ShortPolls        int     `json:"short_polls"` // calls with WaitTimeSeconds 0
+	AvgWaitSeconds    float64 `json:"avg_wait_seconds"`
+	AvgRequested      float64 `json:"avg_requested"`  // MaxNumberOfMessages asked for
+	AvgBatchFill      float64 `json:"avg_batch_fill"` // received / requested, non-empty calls only
+	AvgEmptyLatencyMs float64 `json:"avg_empty_latency_ms"`
+	AvgFullLatencyMs  float64 `json:"avg_nonempty_latency_ms"`
+}
+
+type poll struct {
+	at        time.Time
+	wait      int32
+	requested int32
+	received  int
+	latency   time.Duration
+	failed    bool
+}
+
+var polls = struct {
+	sync.Mutex
+	byQueue map[string][]poll
+}{byQueue: make(map[string][]poll)}
+
+// receiveMessage calls ReceiveMessage and records the call for the poll advisor.
+func (s *SQSService) receiveMessage(ctx context.Context, input *sqs.ReceiveMessageInput) (*sqs.ReceiveMessageOutput, error) {
+	if s.Client == nil {
+		return nil, ErrAWSNotConfigured
+	}
+	ctx, span := s.startSpan(ctx, "poll",
+		attribute.Int("sqs_ui.wait_seconds", int(input.WaitTimeSeconds)),
+		attribute.Int("sqs_ui.max_messages", int(input.MaxNumberOfMessages)),
+	)
+	start := time.Now()
+	resp, err := s.Client.ReceiveMessage(ctx, input)
+	if resp != nil {
+		span.SetAttributes(semconv.MessagingBatchMessageCount(len(resp.Messages)))
+	}
+	tracing.End(span, err)
+
+	s.noteThrottle(err)
+	p := poll{at: start, wait: input.WaitTimeSeconds, requested: max(input.MaxNumberOfMessages, 1), latency: time.Since(start), failed: err != nil}
+	if resp != nil {
+		p.received = len(resp.Messages)
+	}
+	polls.Lock()
+	list := append(polls.byQueue[s.QueueName], p)
+	if len(list) > pollHistory {
+		list = list[len(list)-pollHistory:]
+	}
+	polls.byQueue[s.QueueName] = list
+	polls.Unlock()
+
+	return resp, err
+}
+
+// PollStats returns statistics over the recent receive calls this process made to the queue.
+func (s *SQSService) PollStats() PollStats {
+	polls.Lock()
+	list := append([]poll(nil), polls.byQueue[s.QueueName]...)
+	polls.Unlock()
+
+	var st PollStats
+	var wait, requested, fill float64
+	var emptyLatency, fullLatency time.Duration
+	for _, p := range list {
+		st.Calls++
+		wait += float64(p.wait)
+		requested += float64(p.requested)
+		switch {
+		case p.failed:
+			st.Errors++
+			continue
+		case p.received == 0:
+			st.Empty++
+			emptyLatency += p.latency
+		default:
+			st.Messages += p.received
+			fill += float64(p.received) / float64(p.requested)
+			fullLatency += p.latency
+		}
+		if p.wait == 0 {
+			st.ShortPolls++
+		}
+	}
+	if st.Calls == 0 {
+		return st
+	}
+	st.AvgWaitSeconds = wait / float64(st.Calls)
+	st.AvgRequested = requested / float64(st.Calls)
+	if st.Empty > 0 {
+		st.AvgEmptyLatencyMs = float64(emptyLatency.Milliseconds()) / float64(st.Empty)
+	}
+	if full := st.Calls - st.Empty - st.Errors; full > 0 {
+		st.AvgBatchFill = fill / float64(full)
+		st.AvgFullLatencyMs = float64(fullLatency.Milliseconds()) / float64(full)
+	}
+	return st
+}
@@ -0,0 +1,51 @@
+package service
+
+import (
+	"maps"
+
+	"github.com/pachecoc/sqs-ui/internal/validate"
+)
+
+// Provenance message attributes Send adds to messages sent through sqs-ui.
+const (
+	AttrSentBy      = "sent_by"
+	AttrSentVia     = "sent_via"
+	AttrUIRequestID = "ui_request_id"
+
+	// SentVia is the value of the sent_via attribute.
+	SentVia = "sqs-ui"
+)
+
+// Provenance identifies who sent a message through sqs-ui, so incident reviews can tell
+// synthetic messages from real traffic.
+type Provenance struct {
+	SentBy    string // empty when the request carried no user
+	RequestID string
+}
+
+// withProvenance returns attrs with the provenance attributes added, unless the queue opts
+// out (NoProvenance). Attributes set by the caller win, and provenance is dropped rather
+// than exceeding the per-message attribute limit.
+func (s *SQSService) withProvenance(attrs map[string]MessageAttribute, p *Provenance) map[string]MessageAttribute {
+	if p == nil || s.NoProvenance {
+		return attrs
+	}
+	out := maps.Clone(attrs)
+	if out == nil {
+		out = make(map[string]MessageAttribute, 3)
+	}
+	add := func(name, value string) {
+		if _, ok := out[name]; ok || value == "" {
+			return
+		}
+		if len(out) >= validate.MaxMessageAttributes {
+			s.Log.Debug("provenance attribute dropped, attribute limit reached", "attribute", name)
+			return
+		}
+		out[name] = MessageAttribute{Type: "String", Value: value}
+	}
+	add(AttrSentVia, SentVia)
+	add(AttrSentBy, p.SentBy)
+	add(AttrUIRequestID, p.RequestID)
+	return out
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+// QueueRef is one queue returned by ListQueues.
+type QueueRef struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+// QueueList is one page of ListQueues. NextToken is empty on the last page.
+type QueueList struct {
+	Queues    []QueueRef `json:"queues"`
+	NextToken string     `json:"next_token,omitempty"`
+}
+
+// ListQueues returns up to max queues whose names start with prefix, continuing after
+// token when set. It needs only a client, not a configured queue.
+func (s *SQSService) ListQueues(ctx context.Context, prefix string, max int32, token string) (QueueList, error) {
+	list := QueueList{Queues: []QueueRef{}}
+	if s.Client == nil {
+		return list, ErrAWSNotConfigured
+	}
+
+	ctx, cancel := budget(ctx, queueAttrTimeout)
+	defer cancel()
+
+	input := &sqs.ListQueuesInput{MaxResults: aws.Int32(max)}
+	if prefix != "" {
+		input.QueueNamePrefix = aws.String(prefix)
+	}
+	if token != "" {
+		input.NextToken = aws.String(token)
+	}
+	out, err := s.Client.ListQueues(ctx, input)
+	if err != nil {
+		return list, fmt.Errorf("failed to list queues: %w", err)
+	}
+	for _, u := range out.QueueUrls {
+		list.Queues = append(list.Queues, QueueRef{Name: u[strings.LastIndex(u, "/")+1:], URL: u})
+	}
+	list.NextToken = aws.ToString(out.NextToken)
+	return list, nil
+}
+
+// ForQueue returns a service for another queue that shares this service's AWS client,
+// region, receive mode and Configure hook, with its URL resolved. An empty or matching name returns s.
+func (s *SQSService) ForQueue(ctx context.Context, queueName string) (*SQSService, error) {
+	target := s
+	if queueName != "" && queueName != s.QueueName {
+		target = NewSQSService(ctx, s.Client, queueName, "", s.Region, s.Log)
+		target.Mode = s.Mode
+		target.Configure = s.Configure
+		if s.Configure != nil {
+			s.Configure(target)
+		}
+	}
+	if err := target.EnsureQueueConfigured(); err != nil {
+		return nil, err
+	}
+	if target.QueueURL == "" {
+		if _, err := target.FetchQueueURL(ctx); err != nil {
+			return nil, err
+		}
+	}
+	return target, nil
+}
+
+// OldestMessageAge samples one receive batch and returns the age of the oldest message seen
+// (0 when the queue looks empty). Sampled messages are released straight away.
+func (s *SQSService) OldestMessageAge(ctx context.Context) (time.Duration, error) {
+	if s.Client == nil {
+		return 0, ErrAWSNotConfigured
+	}
+	if s.QueueURL == "" {
+		return 0, fmt.Errorf("no active queue configured, try to fetch queue info first")
+	}
+
+	ctx, cancel := budget(ctx, receiveTimeout)
+	defer cancel()
+
+	resp, err := s.receiveMessage(ctx, &sqs.ReceiveMessageInput{
+		QueueUrl:                    &s.QueueURL,
+		MaxNumberOfMessages:         10,
+		VisibilityTimeout:           receiveVisibility,
+		WaitTimeSeconds:             1,
+		MessageSystemAttributeNames: []types.MessageSystemAttributeName{types.MessageSystemAttributeNameSentTimestamp},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to sample messages: %w", err)
+	}
+
+	var oldest time.Duration
+	handles := make([]string, 0, len(resp.Messages))
+	for _, m := range resp.Messages {
+		handles = append(handles, aws.ToString(m.ReceiptHandle))
+		ms, err := strconv.ParseInt(m.Attributes[string(types.MessageSystemAttributeNameSentTimestamp)], 10, 64)
+		if err != nil {
+			continue
+		}
+		oldest = max(oldest, time.Since(time.UnixMilli(ms)))
+	}
+	s.release(context.WithoutCancel(ctx), handles)
+	return oldest, nil
+}
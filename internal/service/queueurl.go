@@ -0,0 +1,56 @@
+package service
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// QueueURLTTL is how long a URL resolved from a queue name is reused before GetQueueUrl
+// is called again.
+const QueueURLTTL = 10 * time.Minute
+
+// resolvedURLs caches GetQueueUrl results by region and queue name, shared by every
+// service of this process (the default queue, ?queue= selections, Slack lookups).
+var resolvedURLs = struct {
+	sync.Mutex
+	at map[string]resolvedURL
+}{at: make(map[string]resolvedURL)}
+
+type resolvedURL struct {
+	url     string
+	expires time.Time
+}
+
+func (s *SQSService) urlCacheKey() string { return s.Region + "/" + s.QueueName }
+
+// cachedQueueURL returns the unexpired cached URL of the queue, if any.
+func (s *SQSService) cachedQueueURL() (string, bool) {
+	resolvedURLs.Lock()
+	defer resolvedURLs.Unlock()
+	r, ok := resolvedURLs.at[s.urlCacheKey()]
+	if !ok || time.Now().After(r.expires) {
+		return "", false
+	}
+	return r.url, true
+}
+
+func (s *SQSService) storeQueueURL(url string) {
+	resolvedURLs.Lock()
+	resolvedURLs.at[s.urlCacheKey()] = resolvedURL{url: url, expires: time.Now().Add(QueueURLTTL)}
+	resolvedURLs.Unlock()
+}
+
+// forgetQueueURL drops a resolved URL SQS no longer knows (the queue was deleted, or
+// recreated in another account or region), so the next call resolves the name again.
+// URLs that were configured explicitly are left alone.
+func (s *SQSService) forgetQueueURL(err error) {
+	var awsErr *AWSError
+	if !s.urlFromName || !errors.As(TranslateAWSError(err), &awsErr) || awsErr.Kind != KindQueueNotFound {
+		return
+	}
+	resolvedURLs.Lock()
+	delete(resolvedURLs.at, s.urlCacheKey())
+	resolvedURLs.Unlock()
+	s.Log.Info("forgot cached queue URL", "queue_name", s.QueueName, "queue_url", s.QueueURL)
+}
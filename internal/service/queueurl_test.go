@@ -0,0 +1,52 @@
+package service_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+
+	"github.com/pachecoc/sqs-ui/internal/memsqs"
+	"github.com/pachecoc/sqs-ui/internal/service"
+)
+
+// TestInfoResolvesWithoutWriting runs Info concurrently on a service configured by name, as
+// requests do on the default service; run with -race.
+func TestInfoResolvesWithoutWriting(t *testing.T) {
+	ctx := context.Background()
+	mem := memsqs.New()
+	out, err := mem.CreateQueue(ctx, &sqs.CreateQueueInput{QueueName: aws.String("info-by-name")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := aws.ToString(out.QueueUrl)
+	svc := service.NewSQSService(ctx, mem, "info-by-name", "", mem.Region, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			info := svc.Info(ctx)
+			if info["status"] != "ok" || info["queue_url"] != want {
+				t.Errorf("info: status %v, queue_url %v, want ok and %s", info["status"], info["queue_url"], want)
+			}
+		}()
+	}
+	wg.Wait()
+	if svc.QueueURL != "" {
+		t.Errorf("Info set QueueURL to %s on the shared service", svc.QueueURL)
+	}
+
+	resolved, err := svc.Resolve(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resolved == svc || resolved.QueueURL != want || resolved.QueueName != svc.QueueName {
+		t.Errorf("Resolve returned %s (%p, service %p), want a copy on %s", resolved.QueueURL, resolved, svc, want)
+	}
+}
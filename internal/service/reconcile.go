@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+// reconcileVisibility hides peeked messages long enough for a full pass; they are released at the end.
+const reconcileVisibility = int32(120)
+
+// QueueCounts are the approximate message counts SQS reports for a queue.
+type QueueCounts struct {
+	Visible    int64 `json:"visible"`
+	NotVisible int64 `json:"not_visible"`
+	Delayed    int64 `json:"delayed"`
+}
+
+// Reconciliation compares reported counts against what a full peek could actually receive.
+type Reconciliation struct {
+	QueueName  string      `json:"queue_name"`
+	Before     QueueCounts `json:"before"`
+	After      QueueCounts `json:"after"`
+	Receivable int         `json:"receivable"` // unique messages received during the peek
+	Duplicates int         `json:"duplicates"` // redeliveries seen during the peek
+	Truncated  bool        `json:"truncated"`  // limit or deadline reached before the queue ran dry
+	Findings   []string    `json:"findings"`
+	StartedAt  time.Time   `json:"started_at"`
+	FinishedAt time.Time   `json:"finished_at"`
+}
+
+// Counts reads the queue's approximate counts.
+func (s *SQSService) Counts(ctx context.Context) (QueueCounts, error) {
+	if s.Client == nil {
+		return QueueCounts{}, ErrAWSNotConfigured
+	}
+	if s.QueueURL == "" {
+		return QueueCounts{}, fmt.Errorf("no active queue configured, try to fetch queue info first")
+	}
+
+	ctx, cancel := budget(ctx, queueAttrTimeout)
+	defer cancel()
+	out, err := s.Client.GetQueueAttributes(ctx, &sqs.GetQueueAttributesInput{
+		QueueUrl: &s.QueueURL,
+		AttributeNames: []types.QueueAttributeName{
+			types.QueueAttributeNameApproximateNumberOfMessages,
+			types.QueueAttributeNameApproximateNumberOfMessagesNotVisible,
+			types.QueueAttributeNameApproximateNumberOfMessagesDelayed,
+		},
+	})
+	if err != nil {
+		return QueueCounts{}, fmt.Errorf("failed to get queue attributes: %w", err)
+	}
+
+	parse := func(name types.QueueAttributeName) int64 {
+		n, _ := strconv.ParseInt(out.Attributes[string(name)], 10, 64)
+		return n
+	}
+	return QueueCounts{
+		Visible:    parse(types.QueueAttributeNameApproximateNumberOfMessages),
+		NotVisible: parse(types.QueueAttributeNameApproximateNumberOfMessagesNotVisible),
+		Delayed:    parse(types.QueueAttributeNameApproximateNumberOfMessagesDelayed),
+	}, nil
+}
+
+// Reconcile peeks at every receivable message (up to limit, 0 = no limit) without deleting
+// anything and compares the result with the counts reported before and after. Peeked messages
+// are hidden during the pass so each is counted once, then released.
+func (s *SQSService) Reconcile(ctx context.Context, limit int) (Reconciliation, error) {
+	rec := Reconciliation{QueueName: s.QueueName, StartedAt: time.Now().UTC()}
+	before, err := s.Counts(ctx)
+	if err != nil {
+		return rec, err
+	}
+	rec.Before = before
+
+	var handles []string
+	defer func() { s.release(context.WithoutCancel(ctx), handles) }()
+
+	seen := make(map[string]bool)
+	empty := 0
+	for empty < 2 {
+		if limit > 0 && len(seen) >= limit {
+			rec.Truncated = true
+			break
+		}
+		if ctx.Err() != nil {
+			rec.Truncated = true
+			break
+		}
+		resp, err := s.receiveMessage(ctx, &sqs.ReceiveMessageInput{
+			QueueUrl:            &s.QueueURL,
+			MaxNumberOfMessages: 10,
+			VisibilityTimeout:   reconcileVisibility,
+			WaitTimeSeconds:     1,
+		})
+		if err != nil {
+			if ctx.Err() != nil {
+				rec.Truncated = true
+				break
+			}
+			return rec, fmt.Errorf("failed to receive messages: %w", err)
+		}
+		// SQS samples servers, so one empty short poll doesn't prove the queue is empty
+		if len(resp.Messages) == 0 {
+			empty++
+			continue
+		}
+		empty = 0
+		for _, m := range resp.Messages {
+			handles = append(handles, aws.ToString(m.ReceiptHandle))
+			if id := aws.ToString(m.MessageId); seen[id] {
+				rec.Duplicates++
+			} else {
+				seen[id] = true
+			}
+		}
+	}
+	rec.Receivable = len(seen)
+
+	if rec.After, err = s.Counts(context.WithoutCancel(ctx)); err != nil {
+		return rec, err
+	}
+	rec.FinishedAt = time.Now().UTC()
+	rec.Findings = rec.findings()
+	s.Log.InfoContext(ctx, "queue reconciled", "queue_name", s.QueueName, "visible", before.Visible,
+		"receivable", rec.Receivable, "not_visible", before.NotVisible, "truncated", rec.Truncated)
+	return rec, nil
+}
+
+// findings explains the gap between reported and receivable counts in plain words.
+func (r Reconciliation) findings() []string {
+	var out []string
+	missing := r.Before.Visible - int64(r.Receivable)
+	switch {
+	case r.Truncated:
+		out = append(out, fmt.Sprintf("peek stopped after %d messages; counts below are a lower bound", r.Receivable))
+	case missing > 0:
+		out = append(out, fmt.Sprintf("%d messages reported visible could not be received; counts are approximate and may lag by a minute", missing))
+	case missing < 0:
+		out = append(out, fmt.Sprintf("%d more messages were received than reported visible (new sends or delayed messages becoming visible)", -missing))
+	default:
+		out = append(out, "reported visible count matches the receivable messages")
+	}
+	if r.Before.NotVisible > 0 {
+		out = append(out, fmt.Sprintf("%d messages are in flight: received by consumers (or other tools) and not yet deleted; they return after their visibility timeout", r.Before.NotVisible))
+	}
+	if r.Before.Delayed > 0 {
+		out = append(out, fmt.Sprintf("%d messages are delayed and not receivable yet", r.Before.Delayed))
+	}
+	if r.Duplicates > 0 {
+		out = append(out, fmt.Sprintf("%d redeliveries seen during the peek; the queue may be larger than one visibility window can cover", r.Duplicates))
+	}
+	return out
+}
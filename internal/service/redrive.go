@@ -0,0 +1,173 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+
+	"github.com/pachecoc/sqs-ui/internal/validate"
+)
+
+// MaxReceiveCountLimit is the highest maxReceiveCount SQS accepts in a redrive policy.
+const MaxReceiveCountLimit = 1000
+
+// RedrivePolicy is a queue's dead-letter configuration.
+type RedrivePolicy struct {
+	DeadLetterTargetARN string `json:"dead_letter_target_arn"`
+	DeadLetterQueue     string `json:"dead_letter_queue"` // name, from the ARN
+	MaxReceiveCount     int    `json:"max_receive_count"`
+}
+
+// RedriveChange is the policy before and after SetRedrivePolicy or RemoveRedrivePolicy
+// (nil when the queue has none).
+type RedriveChange struct {
+	From *RedrivePolicy `json:"from"`
+	To   *RedrivePolicy `json:"to"`
+}
+
+// RedrivePolicyJSON renders a RedrivePolicy attribute value.
+func RedrivePolicyJSON(targetARN string, maxReceiveCount int) string {
+	b, _ := json.Marshal(map[string]string{
+		"deadLetterTargetArn": targetARN,
+		"maxReceiveCount":     strconv.Itoa(maxReceiveCount),
+	})
+	return string(b)
+}
+
+// parseRedrivePolicy parses a RedrivePolicy attribute; maxReceiveCount may be a number or
+// a string, as SQS accepts both.
+func parseRedrivePolicy(raw string) (*RedrivePolicy, error) {
+	var p struct {
+		DeadLetterTargetArn string          `json:"deadLetterTargetArn"`
+		MaxReceiveCount     json.RawMessage `json:"maxReceiveCount"`
+	}
+	if err := json.Unmarshal([]byte(raw), &p); err != nil {
+		return nil, fmt.Errorf("invalid redrive policy: %w", err)
+	}
+	n, _ := strconv.Atoi(strings.Trim(string(p.MaxReceiveCount), `"`))
+	return &RedrivePolicy{
+		DeadLetterTargetARN: p.DeadLetterTargetArn,
+		DeadLetterQueue:     p.DeadLetterTargetArn[strings.LastIndex(p.DeadLetterTargetArn, ":")+1:],
+		MaxReceiveCount:     n,
+	}, nil
+}
+
+// queueARNParts splits arn:aws:sqs:<region>:<account>:<name>.
+func queueARNParts(arn string) (region, account, name string, err error) {
+	parts := strings.Split(arn, ":")
+	if len(parts) != 6 || parts[0] != "arn" || parts[2] != "sqs" {
+		return "", "", "", fmt.Errorf("invalid dead-letter target ARN %q", arn)
+	}
+	return parts[3], parts[4], parts[5], nil
+}
+
+// RedrivePolicy returns the queue's redrive policy, or nil when it has none.
+func (s *SQSService) RedrivePolicy(ctx context.Context) (*RedrivePolicy, error) {
+	if s.Client == nil {
+		return nil, ErrAWSNotConfigured
+	}
+	if s.QueueURL == "" {
+		return nil, fmt.Errorf("no active queue configured, try to fetch queue info first")
+	}
+	attrs, _, err := s.queueAttributes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read redrive policy: %w", err)
+	}
+	raw := attrs[string(types.QueueAttributeNameRedrivePolicy)]
+	if raw == "" {
+		return nil, nil
+	}
+	return parseRedrivePolicy(raw)
+}
+
+// SetRedrivePolicy points the queue's failures at deadLetterQueue (a queue name in this
+// account, or a queue ARN) after maxReceiveCount receives. The DLQ must exist, differ from
+// the queue and be of the same type (FIFO or standard). With dryRun only the change is reported.
+func (s *SQSService) SetRedrivePolicy(ctx context.Context, deadLetterQueue string, maxReceiveCount int, dryRun bool) (RedriveChange, error) {
+	if err := s.checkTaggable(); err != nil {
+		return RedriveChange{}, err
+	}
+	var v validate.Validator
+	v.Required("dead_letter_queue", deadLetterQueue)
+	v.Range("max_receive_count", maxReceiveCount, 1, MaxReceiveCountLimit)
+	if err := v.Err(); err != nil {
+		return RedriveChange{}, err
+	}
+
+	input := &sqs.GetQueueUrlInput{QueueName: aws.String(deadLetterQueue)}
+	if strings.HasPrefix(deadLetterQueue, "arn:") {
+		_, account, name, err := queueARNParts(deadLetterQueue)
+		if err != nil {
+			return RedriveChange{}, validate.Errors{{Field: "dead_letter_queue", Message: err.Error()}}
+		}
+		input = &sqs.GetQueueUrlInput{QueueName: aws.String(name), QueueOwnerAWSAccountId: aws.String(account)}
+	}
+	name := aws.ToString(input.QueueName)
+
+	v.Check(name != s.QueueName, "dead_letter_queue", "must be a different queue")
+	v.Check(isFIFO(name) == isFIFO(s.QueueName), "dead_letter_queue",
+		"must be a FIFO queue for a FIFO queue and a standard queue for a standard queue")
+	if err := v.Err(); err != nil {
+		return RedriveChange{}, err
+	}
+
+	lookupCtx, cancel := budget(ctx, queueAttrTimeout)
+	out, err := s.Client.GetQueueUrl(lookupCtx, input)
+	cancel()
+	if err != nil {
+		var awsErr *AWSError
+		if errors.As(TranslateAWSError(err), &awsErr) && awsErr.Kind == KindQueueNotFound {
+			return RedriveChange{}, validate.Errors{{Field: "dead_letter_queue", Message: fmt.Sprintf("queue %s does not exist", name)}}
+		}
+		return RedriveChange{}, fmt.Errorf("failed to resolve dead-letter queue %s: %w", name, err)
+	}
+	arn, err := s.QueueARN(ctx, aws.ToString(out.QueueUrl))
+	if err != nil {
+		return RedriveChange{}, err
+	}
+
+	to := &RedrivePolicy{DeadLetterTargetARN: arn, DeadLetterQueue: name, MaxReceiveCount: maxReceiveCount}
+	return s.applyRedrivePolicy(ctx, to, dryRun)
+}
+
+// RemoveRedrivePolicy detaches the queue from its dead-letter queue.
+func (s *SQSService) RemoveRedrivePolicy(ctx context.Context, dryRun bool) (RedriveChange, error) {
+	if err := s.checkTaggable(); err != nil {
+		return RedriveChange{}, err
+	}
+	return s.applyRedrivePolicy(ctx, nil, dryRun)
+}
+
+// applyRedrivePolicy sets (or with to == nil clears) the RedrivePolicy attribute.
+func (s *SQSService) applyRedrivePolicy(ctx context.Context, to *RedrivePolicy, dryRun bool) (RedriveChange, error) {
+	from, err := s.RedrivePolicy(WithRefresh(ctx))
+	if err != nil {
+		return RedriveChange{}, err
+	}
+	change := RedriveChange{From: from, To: to}
+	if dryRun {
+		return change, nil
+	}
+
+	value := ""
+	if to != nil {
+		value = RedrivePolicyJSON(to.DeadLetterTargetARN, to.MaxReceiveCount)
+	}
+	ctx, cancel := budget(ctx, queueAttrTimeout)
+	defer cancel()
+	if _, err := s.Client.SetQueueAttributes(ctx, &sqs.SetQueueAttributesInput{
+		QueueUrl:   aws.String(s.QueueURL),
+		Attributes: map[string]string{string(types.QueueAttributeNameRedrivePolicy): value},
+	}); err != nil {
+		return RedriveChange{}, fmt.Errorf("failed to set redrive policy: %w", err)
+	}
+	s.invalidateAttributes()
+	return change, nil
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"strings"
+	"testing"
+)
+
+func FuzzParseRedrivePolicy(f *testing.F) {
+	f.Add(`{"deadLetterTargetArn":"arn:aws:sqs:us-east-1:123456789012:orders-dlq","maxReceiveCount":5}`)
+	f.Add(`{"deadLetterTargetArn":"arn:aws:sqs:us-east-1:123456789012:orders-dlq","maxReceiveCount":"5"}`)
+	f.Add(`{"deadLetterTargetArn":"orders-dlq"}`)
+	f.Add(`{"maxReceiveCount":{}}`)
+	f.Add(`{}`)
+	f.Add(`null`)
+	f.Add(`[]`)
+	f.Add(``)
+	f.Fuzz(func(t *testing.T, raw string) {
+		p, err := parseRedrivePolicy(raw)
+		if err != nil {
+			return
+		}
+		if p == nil {
+			t.Fatalf("%q: no policy and no error", raw)
+		}
+		if strings.Contains(p.DeadLetterQueue, ":") || !strings.HasSuffix(p.DeadLetterTargetARN, p.DeadLetterQueue) {
+			t.Fatalf("%q: queue %q isn't the last part of ARN %q", raw, p.DeadLetterQueue, p.DeadLetterTargetARN)
+		}
+	})
+}
+
+func FuzzRedrivePolicyRoundTrip(f *testing.F) {
+	f.Add("arn:aws:sqs:us-east-1:123456789012:orders-dlq", 5)
+	f.Add("arn:aws:sqs:eu-west-1:123456789012:orders-dlq.fifo", 1000)
+	f.Add(`"quoted"`, -1)
+	f.Fuzz(func(t *testing.T, arn string, maxReceiveCount int) {
+		p, err := parseRedrivePolicy(RedrivePolicyJSON(arn, maxReceiveCount))
+		if err != nil {
+			t.Fatalf("%q, %d: policy we rendered doesn't parse: %v", arn, maxReceiveCount, err)
+		}
+		// Invalid UTF-8 is replaced on the way through JSON; everything else must survive
+		if strings.ToValidUTF8(arn, "�") == arn && p.DeadLetterTargetARN != arn {
+			t.Fatalf("ARN %q came back as %q", arn, p.DeadLetterTargetARN)
+		}
+		if p.MaxReceiveCount != maxReceiveCount {
+			t.Fatalf("maxReceiveCount %d came back as %d", maxReceiveCount, p.MaxReceiveCount)
+		}
+	})
+}
+
+func FuzzQueueARNParts(f *testing.F) {
+	f.Add("arn:aws:sqs:us-east-1:123456789012:orders")
+	f.Add("arn:aws-cn:sqs:cn-north-1:123456789012:orders.fifo")
+	f.Add("arn:aws:sns:us-east-1:123456789012:topic")
+	f.Add("arn:aws:sqs:us-east-1:123456789012:orders:extra")
+	f.Add("https://sqs.us-east-1.amazonaws.com/123456789012/orders")
+	f.Add(":::::")
+	f.Add("")
+	f.Fuzz(func(t *testing.T, arn string) {
+		region, account, name, err := queueARNParts(arn)
+		if err != nil {
+			if region != "" || account != "" || name != "" {
+				t.Fatalf("%q: parts %q %q %q returned with error %v", arn, region, account, name, err)
+			}
+			return
+		}
+		if !strings.HasPrefix(arn, "arn:") || !strings.HasSuffix(arn, ":"+region+":"+account+":"+name) {
+			t.Fatalf("%q: parts %q %q %q don't rebuild the ARN", arn, region, account, name)
+		}
+		for _, part := range []string{region, account, name} {
+			if strings.Contains(part, ":") {
+				t.Fatalf("%q: part %q contains a separator", arn, part)
+			}
+		}
+	})
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+
+	"github.com/pachecoc/sqs-ui/internal/validate"
+)
+
+// attributeRange is the allowed range of a mutable numeric queue attribute.
+type attributeRange struct{ lo, hi int }
+
+// MutableAttributes lists the queue attributes SetAttributes changes, with their SQS ranges.
+var MutableAttributes = map[string]attributeRange{
+	"VisibilityTimeout":             {0, validate.MaxVisibilitySeconds},
+	"DelaySeconds":                  {0, validate.MaxDelaySeconds},
+	"ReceiveMessageWaitTimeSeconds": {0, validate.MaxWaitSeconds},
+	"MessageRetentionPeriod":        {validate.MinRetentionSeconds, validate.MaxRetentionSeconds},
+	"MaximumMessageSize":            {validate.MinMessageBytes, validate.MaxMessageBytes},
+}
+
+// AttributeChange is one attribute's value before and after SetAttributes.
+type AttributeChange struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// ValidateMutableAttributes checks that attrs only names MutableAttributes, with integer
+// values in range, reporting each problem under "attributes.<name>".
+func ValidateMutableAttributes(attrs map[string]string) error {
+	var v validate.Validator
+	v.NotEmpty("attributes", len(attrs))
+	for _, name := range slices.Sorted(maps.Keys(attrs)) {
+		field := "attributes." + name
+		r, ok := MutableAttributes[name]
+		if !ok {
+			v.Add(field, "can't be changed here (allowed: %s)", strings.Join(slices.Sorted(maps.Keys(MutableAttributes)), ", "))
+			continue
+		}
+		n, err := strconv.Atoi(attrs[name])
+		if v.Check(err == nil, field, "must be an integer") {
+			v.Range(field, n, r.lo, r.hi)
+		}
+	}
+	return v.Err()
+}
+
+// SetAttributes changes mutable queue attributes and returns each one's previous and new
+// value. With dryRun it only reports the changes. Attributes already at the requested value
+// are left out.
+func (s *SQSService) SetAttributes(ctx context.Context, attrs map[string]string, dryRun bool) (map[string]AttributeChange, error) {
+	if s.Client == nil {
+		return nil, ErrAWSNotConfigured
+	}
+	if s.QueueURL == "" {
+		return nil, fmt.Errorf("no active queue configured, try to fetch queue info first")
+	}
+	if s.ReadOnly {
+		return nil, ErrReadOnly
+	}
+	if err := ValidateMutableAttributes(attrs); err != nil {
+		return nil, err
+	}
+
+	current, _, err := s.queueAttributes(WithRefresh(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read queue attributes: %w", err)
+	}
+	changes := make(map[string]AttributeChange)
+	for name, value := range attrs {
+		n, _ := strconv.Atoi(value)
+		if to := strconv.Itoa(n); current[name] != to {
+			changes[name] = AttributeChange{From: current[name], To: to}
+		}
+	}
+	if dryRun || len(changes) == 0 {
+		return changes, nil
+	}
+
+	set := make(map[string]string, len(changes))
+	for name, c := range changes {
+		set[name] = c.To
+	}
+	ctx, cancel := budget(ctx, queueAttrTimeout)
+	defer cancel()
+	if _, err := s.Client.SetQueueAttributes(ctx, &sqs.SetQueueAttributesInput{
+		QueueUrl:   aws.String(s.QueueURL),
+		Attributes: set,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to set queue attributes: %w", err)
+	}
+	s.invalidateAttributes()
+	return changes, nil
+}
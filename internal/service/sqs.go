@@ -1,6 +1,7 @@
 package service
 
 import (
+	"cmp"
 	"context"
 	"errors"
 	"fmt"
@@ -9,8 +10,13 @@ import (
 	"strings"
 	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/sqs"
 	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+
+	"github.com/pachecoc/sqs-ui/internal/tracing"
 )
 
 // Message represents a simplified SQS message form (kept for potential future use).
@@ -21,23 +27,55 @@ type Message struct {
 
 // SQSService wraps SQS operations with configuration and logging.
 type SQSService struct {
-	Client    *sqs.Client
+	Client    SQSAPI
 	QueueName string
 	QueueURL  string
 	Region    string
 	Log       *slog.Logger
+
+	// Mode is the default receive mode for listings (observe when empty).
+	Mode ReceiveMode
+
+	// Per-queue overrides, usually applied from a queue profile. Zero values keep the defaults.
+	ConsumeTimeout time.Duration // how long consumed messages stay in flight
+	WaitSeconds    int32         // long-poll wait per receive call
+	MessageGroupID string        // group for sends to FIFO queues
+	ReadOnly       bool          // refuse sends, deletes, purges, drains and transfers
+	NoProvenance   bool          // don't tag sent messages with sent_by/sent_via/ui_request_id
+
+	// Configure, when set, is applied to services ForQueue creates for other queues.
+	Configure func(*SQSService)
+
+	urlFromName bool // QueueURL was resolved from QueueName and is refreshed after QueueURLTTL
+}
+
+// ErrReadOnly is returned by operations that would change a queue marked read-only.
+var ErrReadOnly = errors.New("queue is read-only")
+
+// ErrAWSNotConfigured is returned when the service has no SQS client, e.g. in idle mode
+// started without AWS configuration.
+var ErrAWSNotConfigured error = &codedError{code: "aws_not_configured", msg: "no AWS client configured"}
+
+// codedError carries a stable, machine-readable code the API reports alongside the message.
+type codedError struct {
+	code string
+	msg  string
 }
 
+func (e *codedError) Error() string     { return e.msg }
+func (e *codedError) ErrorCode() string { return e.code }
+
 const (
 	receiveTimeout    = 10 * time.Second
 	receiveWaitSecs   = int32(5)
 	receiveVisibility = int32(10)
 	maxReceiveIters   = 25
 	queueAttrTimeout  = 3 * time.Second
+	drainVisibility   = int32(30)
 )
 
 // NewSQSService creates the SQS service wrapper (no remote calls).
-func NewSQSService(ctx context.Context, client *sqs.Client, queueName, queueURL, region string, log *slog.Logger) *SQSService {
+func NewSQSService(ctx context.Context, client SQSAPI, queueName, queueURL, region string, log *slog.Logger) *SQSService {
 	log.Debug("creating SQS service", "queue_name", queueName, "queue_url", queueURL)
 
 	s := &SQSService{
@@ -66,129 +104,278 @@ func (s *SQSService) EnsureQueueConfigured() error {
 	return nil
 }
 
-// FetchQueueURL attempts to resolve the queue URL from AWS using the queue name.
+// FetchQueueURL resolves the queue URL from the queue name and sets QueueURL to it. It
+// writes the service, so call it before the service is shared; Resolve and Info leave it
+// untouched. Results are cached for QueueURLTTL.
 func (s *SQSService) FetchQueueURL(ctx context.Context) (string, error) {
-	s.Log.Debug("fetching queue URL", "queue_name", s.QueueName)
+	url, err := s.resolveQueueURL(ctx)
+	if err != nil {
+		return "", err
+	}
+	s.QueueURL, s.urlFromName = url, true
+	return url, nil
+}
+
+// Resolve returns a copy of the service with QueueURL resolved from the queue name, for a
+// service other goroutines may be using.
+func (s *SQSService) Resolve(ctx context.Context) (*SQSService, error) {
+	url, err := s.resolveQueueURL(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.withResolvedURL(url), nil
+}
+
+func (s *SQSService) withResolvedURL(url string) *SQSService {
+	c := *s
+	c.QueueURL, c.urlFromName = url, true
+	return &c
+}
+
+// resolveQueueURL looks the queue name up with GetQueueUrl, or in the cache of earlier results.
+func (s *SQSService) resolveQueueURL(ctx context.Context) (string, error) {
+	s.Log.DebugContext(ctx, "fetching queue URL", "queue_name", s.QueueName)
 
 	if s.Client == nil {
-		return "", fmt.Errorf("no AWS client configured")
+		return "", ErrAWSNotConfigured
 	}
 	if s.QueueName == "" {
 		return "", fmt.Errorf("queue name is empty")
 	}
+	if url, ok := s.cachedQueueURL(); ok {
+		return url, nil
+	}
 
-	resolveCtx, cancel := context.WithTimeout(ctx, queueAttrTimeout)
+	resolveCtx, cancel := budget(ctx, queueAttrTimeout)
 	defer cancel()
 
 	resp, err := s.Client.GetQueueUrl(resolveCtx, &sqs.GetQueueUrlInput{
 		QueueName: &s.QueueName,
 	})
 	if err != nil {
-		s.Log.Warn("failed to resolve queue URL", "queue_name", s.QueueName, "error", err)
+		s.Log.WarnContext(ctx, "failed to resolve queue URL", "queue_name", s.QueueName, "error", err)
 		return "", err
 	}
 
-	s.QueueURL = *resp.QueueUrl
-	s.Log.Info("resolved queue URL", "queue_name", s.QueueName, "queue_url", s.QueueURL)
+	url := aws.ToString(resp.QueueUrl)
+	s.storeQueueURL(url)
+	s.Log.InfoContext(ctx, "resolved queue URL", "queue_name", s.QueueName, "queue_url", url)
+
+	return url, nil
+}
+
+// SendOptions are the optional parts of a send; the zero value sends a plain message.
+type SendOptions struct {
+	Attributes     map[string]MessageAttribute
+	DelaySeconds   int32
+	MessageGroupID string // overrides the queue's group; also accepted by standard (fair) queues
+	// DeduplicationID is for FIFO queues. Without it one is generated, unless the queue
+	// has ContentBasedDeduplication enabled and SQS derives it from the body.
+	DeduplicationID string
+	// Provenance, when set, is added as sent_by/sent_via/ui_request_id attributes.
+	Provenance *Provenance
+}
+
+// DefaultMessageGroupID is the group for FIFO sends when neither the request nor the
+// queue profile names one.
+var DefaultMessageGroupID = "default-group"
 
-	return s.QueueURL, nil
+// SendResult identifies a sent message. SequenceNumber is only set for FIFO queues.
+type SendResult struct {
+	MessageID      string `json:"message_id"`
+	MD5OfBody      string `json:"md5_of_body"`
+	SequenceNumber string `json:"sequence_number,omitempty"`
 }
 
 // Send publishes a message to the queue (adds group id if FIFO).
-func (s *SQSService) Send(ctx context.Context, msg string) error {
-	s.Log.Debug("sending message", "msg_len", len(msg))
+func (s *SQSService) Send(ctx context.Context, msg string, opts SendOptions) (_ SendResult, err error) {
+	s.Log.DebugContext(ctx, "sending message", "msg_len", len(msg))
+	ctx, span := s.startSpan(ctx, "Send")
+	defer func() { tracing.End(span, err) }()
 
+	if s.Client == nil {
+		return SendResult{}, ErrAWSNotConfigured
+	}
 	if s.QueueURL == "" {
-		s.Log.Warn("send skipped — no active queue configured")
-		return fmt.Errorf("no active queue configured, try to fetch queue info first")
+		s.Log.WarnContext(ctx, "send skipped — no active queue configured")
+		return SendResult{}, fmt.Errorf("no active queue configured, try to fetch queue info first")
 	}
-	if s.Client == nil {
-		return fmt.Errorf("no AWS client configured")
+	if s.ReadOnly {
+		return SendResult{}, ErrReadOnly
 	}
 	if strings.TrimSpace(msg) == "" {
-		return fmt.Errorf("message body cannot be empty")
+		return SendResult{}, fmt.Errorf("message body cannot be empty")
+	}
+	if err := ValidateAttributes(opts.Attributes); err != nil {
+		return SendResult{}, err
+	}
+	if err := s.validateOptions(opts); err != nil {
+		return SendResult{}, err
 	}
 
-	ctx, cancel := context.WithTimeout(ctx, receiveTimeout)
+	ctx, cancel := budget(ctx, receiveTimeout)
 	defer cancel()
 
 	input := &sqs.SendMessageInput{
-		QueueUrl:    &s.QueueURL,
-		MessageBody: &msg,
+		QueueUrl:          &s.QueueURL,
+		MessageBody:       &msg,
+		MessageAttributes: attributeValues(s.withProvenance(opts.Attributes, opts.Provenance)),
+		DelaySeconds:      opts.DelaySeconds,
 	}
 
-	// If FIFO queue, set MessageGroupId and ensure a MessageDeduplicationId.
-	if isFIFO(s.QueueURL) {
-		groupID := "default-group"
-		input.MessageGroupId = &groupID
-		dedupID := fmt.Sprintf("%d-%s", time.Now().UnixNano(), s.QueueName)
-		input.MessageDeduplicationId = &dedupID
+	if input.MessageGroupId, input.MessageDeduplicationId, err = s.fifoIDs(ctx, opts); err != nil {
+		return SendResult{}, err
 	}
 
-	if _, err := s.Client.SendMessage(ctx, input); err != nil {
-		return fmt.Errorf("failed to send message: %w", err)
+	out, err := s.Client.SendMessage(ctx, input)
+	if err != nil {
+		return SendResult{}, fmt.Errorf("failed to send message: %w", err)
+	}
+	res := SendResult{
+		MessageID:      aws.ToString(out.MessageId),
+		MD5OfBody:      aws.ToString(out.MD5OfMessageBody),
+		SequenceNumber: aws.ToString(out.SequenceNumber),
 	}
+	s.invalidateAttributes()
 
-	s.Log.Info("message sent", "queue_name", s.QueueName, "queue_url", s.QueueURL)
-	return nil
+	s.Log.InfoContext(ctx, "message sent", "message_id", res.MessageID, "queue_name", s.QueueName, "queue_url", s.QueueURL, "attributes", len(input.MessageAttributes), "delay_seconds", opts.DelaySeconds, "message_group_id", aws.ToString(input.MessageGroupId))
+	return res, nil
 }
 
-// Fetch retrieves messages in batches until empty batch, iteration cap, or timeout.
-// max is currently unused (placeholder for future limit); retained for API stability.
+// fifoIDs returns the MessageGroupId and MessageDeduplicationId of a send (nil when not
+// needed). FIFO queues need both; standard queues only get a group when opts names one.
+func (s *SQSService) fifoIDs(ctx context.Context, opts SendOptions) (group, dedup *string, err error) {
+	if isFIFO(s.QueueURL) || opts.MessageGroupID != "" {
+		group = aws.String(cmp.Or(opts.MessageGroupID, s.MessageGroupID, DefaultMessageGroupID))
+	}
+	if !isFIFO(s.QueueURL) {
+		return group, nil, nil
+	}
+	if opts.DeduplicationID != "" {
+		return group, aws.String(opts.DeduplicationID), nil
+	}
+	contentBased, err := s.contentBasedDedup(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !contentBased {
+		dedup = aws.String(fmt.Sprintf("%d-%s", time.Now().UnixNano(), s.QueueName))
+	}
+	return group, dedup, nil
+}
+
+// contentBasedDedup reports whether the queue derives deduplication IDs from message bodies.
+func (s *SQSService) contentBasedDedup(ctx context.Context) (bool, error) {
+	attrs, _, err := s.queueAttributes(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to read ContentBasedDeduplication: %w", err)
+	}
+	return attrs[string(types.QueueAttributeNameContentBasedDeduplication)] == "true", nil
+}
+
+// Fetch retrieves messages in batches until empty batch, iteration cap, or timeout, using the
+// service's default receive mode. max is currently unused; retained for API stability.
 func (s *SQSService) Fetch(ctx context.Context, max int32) ([]map[string]interface{}, error) {
-	s.Log.Debug("fetching messages", "max", max)
+	return s.Receive(ctx, s.DefaultMode())
+}
+
+// Receive lists messages in the given mode. In observe mode every received message is
+// released (visibility 0) once the listing completes; in consume mode messages stay in
+// flight for ConsumeVisibility and carry a receipt_handle for Delete. When ctx's deadline
+// expires after some messages arrived, they are returned with an ErrPartial error.
+func (s *SQSService) Receive(ctx context.Context, mode ReceiveMode) (msgs []map[string]interface{}, err error) {
+	s.Log.DebugContext(ctx, "fetching messages", "mode", mode)
+	ctx, span := s.startSpan(ctx, "Receive", attribute.String("sqs_ui.receive_mode", string(mode)))
+	defer func() {
+		span.SetAttributes(semconv.MessagingBatchMessageCount(len(msgs)))
+		tracing.End(span, err)
+	}()
 
+	if s.Client == nil {
+		return nil, ErrAWSNotConfigured
+	}
 	if s.QueueURL == "" {
-		s.Log.Info("fetch skipped — no active queue configured")
+		s.Log.InfoContext(ctx, "fetch skipped — no active queue configured")
 		return nil, fmt.Errorf("no active queue configured, try to fetch queue info first")
 	}
-	if s.Client == nil {
-		return nil, fmt.Errorf("no AWS client configured")
+	if s.ReadOnly && mode == ModeConsume {
+		return nil, ErrReadOnly
 	}
 
-	ctx, cancel := context.WithTimeout(ctx, receiveTimeout)
+	parent := ctx
+	ctx, cancel := budget(ctx, receiveTimeout)
 	defer cancel()
 
 	start := time.Now()
 	var allMsgs []map[string]interface{}
+	var handles []string
+	seen := make(map[string]bool)
+
+	// Messages are hidden while the listing runs so batches don't repeat them (except when peeking)
+	visibility := receiveVisibility
+	if mode == ModeConsume || mode == ModePeek {
+		visibility = int32(s.VisibilityFor(mode) / time.Second)
+	}
 
 	doReceive := func(rc context.Context) (int, error) {
 		input := &sqs.ReceiveMessageInput{
-			QueueUrl:          &s.QueueURL,
-			VisibilityTimeout: receiveVisibility,
-			WaitTimeSeconds:   receiveWaitSecs,
+			QueueUrl:            &s.QueueURL,
+			MaxNumberOfMessages: 10,
+			VisibilityTimeout:   visibility,
+			WaitTimeSeconds:     s.waitSeconds(),
 		}
 
-		resp, err := s.Client.ReceiveMessage(rc, input)
+		resp, err := s.receiveMessage(rc, input)
 		if err != nil {
 			return 0, err
 		}
 
 		for _, m := range resp.Messages {
-			allMsgs = append(allMsgs, map[string]interface{}{
-				"MessageId": *m.MessageId,
-				"Body":      *m.Body,
-			})
+			handles = append(handles, aws.ToString(m.ReceiptHandle))
+			if seen[*m.MessageId] {
+				continue
+			}
+			seen[*m.MessageId] = true
+
+			msg := map[string]interface{}{
+				"message_id": *m.MessageId,
+				"body":       *m.Body,
+			}
+			if mode == ModeConsume {
+				msg["receipt_handle"] = aws.ToString(m.ReceiptHandle)
+			}
+			allMsgs = append(allMsgs, msg)
 		}
 
 		return len(resp.Messages), nil
 	}
 
+	if mode == ModeObserve {
+		defer func() { s.release(context.WithoutCancel(ctx), handles) }()
+	}
+
 	for iteration := 1; iteration <= maxReceiveIters; iteration++ {
 		select {
 		case <-ctx.Done():
 			if len(allMsgs) > 0 {
-				s.Log.Warn("fetch cancelled after partial retrieval", "count", len(allMsgs))
+				s.Log.WarnContext(ctx, "fetch cancelled after partial retrieval", "count", len(allMsgs))
+				if parent.Err() != nil {
+					return allMsgs, fmt.Errorf("%w: %w", ErrPartial, parent.Err())
+				}
 				goto END
 			}
 			return nil, fmt.Errorf("fetch operation timed out: %w", ctx.Err())
 		default:
 		}
 
+		before := len(allMsgs)
 		n, err := doReceive(ctx)
 		if err != nil {
 			if (errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled)) && len(allMsgs) > 0 {
-				s.Log.Warn("fetch timeout after partial retrieval", "count", len(allMsgs))
+				s.Log.WarnContext(ctx, "fetch timeout after partial retrieval", "count", len(allMsgs))
+				if parent.Err() != nil {
+					return allMsgs, fmt.Errorf("%w: %w", ErrPartial, parent.Err())
+				}
 				break
 			}
 			if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
@@ -197,99 +384,246 @@ func (s *SQSService) Fetch(ctx context.Context, max int32) ([]map[string]interfa
 			return nil, fmt.Errorf("failed to fetch messages: %w", err)
 		}
 
-		if n == 0 {
+		// Peeked messages stay visible, so a batch of repeats means the queue was seen
+		if n == 0 || (mode == ModePeek && len(allMsgs) == before) {
 			break
 		}
 
 		if s.Log.Enabled(ctx, slog.LevelDebug) {
-			s.Log.Debug("fetch batch", "batch_count", n, "total", len(allMsgs), "iteration", iteration)
+			s.Log.DebugContext(ctx, "fetch batch", "batch_count", n, "total", len(allMsgs), "iteration", iteration)
 		}
 
 		if iteration == maxReceiveIters {
-			s.Log.Warn("fetch iteration cap reached", "cap", maxReceiveIters, "count", len(allMsgs))
+			s.Log.WarnContext(ctx, "fetch iteration cap reached", "cap", maxReceiveIters, "count", len(allMsgs))
 		}
 	}
 
 	END:
 		elapsed := time.Since(start)
-		s.Log.Info("messages fetched", "count", len(allMsgs), "elapsed_ms", elapsed.Milliseconds())
+		s.Log.InfoContext(ctx, "messages fetched", "count", len(allMsgs), "mode", mode, "elapsed_ms", elapsed.Milliseconds())
 		return allMsgs, nil
 }
 
+// release makes received messages visible again immediately (observe mode).
+func (s *SQSService) release(ctx context.Context, handles []string) {
+	if s.Client == nil {
+		return
+	}
+	for i := 0; i < len(handles); i += 10 {
+		chunk := handles[i:min(i+10, len(handles))]
+		entries := make([]types.ChangeMessageVisibilityBatchRequestEntry, 0, len(chunk))
+		for j, h := range chunk {
+			entries = append(entries, types.ChangeMessageVisibilityBatchRequestEntry{
+				Id:                aws.String(strconv.Itoa(j)),
+				ReceiptHandle:     aws.String(h),
+				VisibilityTimeout: 0,
+			})
+		}
+		// Each batch gets its own timeout so long peeks (thousands of handles) release fully
+		batchCtx, cancel := budget(ctx, queueAttrTimeout)
+		out, err := s.Client.ChangeMessageVisibilityBatch(batchCtx, &sqs.ChangeMessageVisibilityBatchInput{
+			QueueUrl: &s.QueueURL,
+			Entries:  entries,
+		})
+		cancel()
+		if err != nil {
+			s.Log.WarnContext(ctx, "failed to release observed messages", "count", len(chunk), "error", err)
+			return
+		}
+		if len(out.Failed) > 0 {
+			s.Log.WarnContext(ctx, "some observed messages could not be released", "failed", len(out.Failed))
+		}
+	}
+}
+
+// Delete acknowledges consumed messages by receipt handle and returns how many were deleted.
+func (s *SQSService) Delete(ctx context.Context, receiptHandles []string) (_ int, err error) {
+	ctx, span := s.startSpan(ctx, "Delete", semconv.MessagingBatchMessageCount(len(receiptHandles)))
+	defer func() { tracing.End(span, err) }()
+	if s.Client == nil {
+		return 0, ErrAWSNotConfigured
+	}
+	if s.QueueURL == "" {
+		return 0, fmt.Errorf("no active queue configured, try to fetch queue info first")
+	}
+	if s.ReadOnly {
+		return 0, ErrReadOnly
+	}
+
+	ctx, cancel := budget(ctx, receiveTimeout)
+	defer cancel()
+
+	deleted := 0
+	for i := 0; i < len(receiptHandles); i += 10 {
+		chunk := receiptHandles[i:min(i+10, len(receiptHandles))]
+		entries := make([]types.DeleteMessageBatchRequestEntry, 0, len(chunk))
+		for j, h := range chunk {
+			entries = append(entries, types.DeleteMessageBatchRequestEntry{
+				Id:            aws.String(strconv.Itoa(j)),
+				ReceiptHandle: aws.String(h),
+			})
+		}
+		out, err := s.Client.DeleteMessageBatch(ctx, &sqs.DeleteMessageBatchInput{
+			QueueUrl: &s.QueueURL,
+			Entries:  entries,
+		})
+		if err != nil {
+			return deleted, fmt.Errorf("failed to delete messages: %w", err)
+		}
+		deleted += len(out.Successful)
+		if len(out.Failed) > 0 {
+			s.Log.WarnContext(ctx, "some deletes failed", "failed", len(out.Failed))
+		}
+	}
+
+	s.invalidateAttributes()
+	s.Log.InfoContext(ctx, "messages deleted", "queue_name", s.QueueName, "deleted", deleted)
+	return deleted, nil
+}
+
 // Purge deletes all messages currently in the queue.
-func (s *SQSService) Purge(ctx context.Context) error {
-	s.Log.Debug("purging queue", "queue_name", s.QueueName)
+func (s *SQSService) Purge(ctx context.Context) (err error) {
+	s.Log.DebugContext(ctx, "purging queue", "queue_name", s.QueueName)
+	ctx, span := s.startSpan(ctx, "Purge")
+	defer func() { tracing.End(span, err) }()
 
+	if s.Client == nil {
+		return ErrAWSNotConfigured
+	}
 	if s.QueueURL == "" {
-		s.Log.Info("purge skipped — no active queue configured")
+		s.Log.InfoContext(ctx, "purge skipped — no active queue configured")
 		return fmt.Errorf("no active queue configured, try to fetch queue info first")
 	}
-	if s.Client == nil {
-		return fmt.Errorf("no AWS client configured")
+	if s.ReadOnly {
+		return ErrReadOnly
+	}
+	if err := s.checkPurgeCooldown(); err != nil {
+		return err
 	}
 
-	ctx, cancel := context.WithTimeout(ctx, receiveTimeout)
+	ctx, cancel := budget(ctx, receiveTimeout)
 	defer cancel()
 
-	if _, err := s.Client.PurgeQueue(ctx, &sqs.PurgeQueueInput{QueueUrl: &s.QueueURL}); err != nil {
-		return fmt.Errorf("failed to purge queue: %w", err)
+	_, err = s.Client.PurgeQueue(ctx, &sqs.PurgeQueueInput{QueueUrl: &s.QueueURL})
+	if err = s.recordPurge(err); err != nil {
+		return err
 	}
 
-	s.Log.Info("queue purged", "queue_name", s.QueueName)
+	s.invalidateAttributes()
+	s.Log.InfoContext(ctx, "queue purged", "queue_name", s.QueueName)
 	return nil
 }
 
+// Drain receives and deletes messages until the queue is empty, limit is reached (0 = no limit)
+// or ctx ends. It returns how many messages were deleted.
+func (s *SQSService) Drain(ctx context.Context, limit int) (_ int, err error) {
+	s.Log.DebugContext(ctx, "draining queue", "queue_name", s.QueueName, "limit", limit)
+	ctx, span := s.startSpan(ctx, "Drain")
+	defer func() { tracing.End(span, err) }()
+
+	if s.Client == nil {
+		return 0, ErrAWSNotConfigured
+	}
+	if s.QueueURL == "" {
+		return 0, fmt.Errorf("no active queue configured, try to fetch queue info first")
+	}
+	if s.ReadOnly {
+		return 0, ErrReadOnly
+	}
+
+	deleted := 0
+	for limit == 0 || deleted < limit {
+		batch := int32(10)
+		if limit > 0 && limit-deleted < 10 {
+			batch = int32(limit - deleted)
+		}
+
+		resp, err := s.receiveMessage(ctx, &sqs.ReceiveMessageInput{
+			QueueUrl:            &s.QueueURL,
+			MaxNumberOfMessages: batch,
+			VisibilityTimeout:   drainVisibility,
+			WaitTimeSeconds:     1,
+		})
+		if err != nil {
+			return deleted, fmt.Errorf("failed to receive messages: %w", err)
+		}
+		if len(resp.Messages) == 0 {
+			break
+		}
+
+		entries := make([]types.DeleteMessageBatchRequestEntry, 0, len(resp.Messages))
+		for i, m := range resp.Messages {
+			entries = append(entries, types.DeleteMessageBatchRequestEntry{
+				Id:            aws.String(strconv.Itoa(i)),
+				ReceiptHandle: m.ReceiptHandle,
+			})
+		}
+		out, err := s.Client.DeleteMessageBatch(ctx, &sqs.DeleteMessageBatchInput{
+			QueueUrl: &s.QueueURL,
+			Entries:  entries,
+		})
+		if err != nil {
+			return deleted, fmt.Errorf("failed to delete messages: %w", err)
+		}
+		deleted += len(out.Successful)
+		if len(out.Failed) > 0 {
+			s.Log.WarnContext(ctx, "some deletes failed during drain", "failed", len(out.Failed))
+		}
+	}
+
+	s.Log.InfoContext(ctx, "queue drained", "queue_name", s.QueueName, "deleted", deleted)
+	return deleted, nil
+}
+
 // Info returns summary attributes for the queue (approximate counts).
 func (s *SQSService) Info(ctx context.Context) map[string]interface{} {
-	s.Log.Debug("fetching queue info", "queue_name", s.QueueName, "queue_url", s.QueueURL)
+	s.Log.DebugContext(ctx, "fetching queue info", "queue_name", s.QueueName, "queue_url", s.QueueURL)
 
 	// Base info map
 	info := map[string]interface{}{
-		"current_region":     s.Region,
-		"queue_name":         s.QueueName,
-		"queue_url":          s.QueueURL,
-		"number_of_messages": nil,
-		"status":             "not_connected",
+		"current_region":         s.Region,
+		"queue_name":             s.QueueName,
+		"queue_url":              s.QueueURL,
+		"number_of_messages":     nil,
+		"receive_mode":           s.DefaultMode(),
+		"read_only":              s.ReadOnly,
+		"status":                 "not_connected",
+		"purge_cooldown_seconds": retrySeconds(s.PurgeCooldownRemaining()),
 	}
 
-	// Ensure the queue is configured before fetching info
-	if err := s.EnsureQueueConfigured(); err != nil {
-		s.Log.Info("queue is not configured", "error", err)
-		info["error"] = err.Error()
+	if s.Client == nil {
+		setInfoError(info, ErrAWSNotConfigured)
 		return info
 	}
 
-	if s.Client == nil {
-		info["error"] = "no AWS client configured"
+	// Ensure the queue is configured before fetching info
+	if err := s.EnsureQueueConfigured(); err != nil {
+		s.Log.InfoContext(ctx, "queue is not configured", "error", err)
+		info["error"] = err.Error()
 		return info
 	}
 
-	// If no URL, we should fetch it with the name
-	if s.QueueURL == "" && s.QueueName != "" {
-		queueURL, err := s.FetchQueueURL(ctx)
+	// Without a configured URL, resolve the name (a cache hit until QueueURLTTL expires).
+	// The service may be shared, so the rest reads through a copy holding the result.
+	if (s.QueueURL == "" || s.urlFromName) && s.QueueName != "" {
+		queueURL, err := s.resolveQueueURL(ctx)
 		if err != nil {
-			s.Log.Info("queue could not be loaded — running in idle mode", "queue_name", s.QueueName)
-			info["error"] = err.Error()
+			s.Log.InfoContext(ctx, "queue could not be loaded — running in idle mode", "queue_name", s.QueueName)
+			setInfoError(info, err)
 			return info
 		}
+		if queueURL != s.QueueURL {
+			s = s.withResolvedURL(queueURL)
+		}
 		info["queue_url"] = queueURL
 	}
 
-	// Once we have a URL, we can fetch attributes with a timeout
-	ctx, cancel := context.WithTimeout(ctx, queueAttrTimeout)
-	defer cancel()
-
-	out, err := s.Client.GetQueueAttributes(ctx, &sqs.GetQueueAttributesInput{
-		QueueUrl: &s.QueueURL,
-		AttributeNames: []types.QueueAttributeName{
-			types.QueueAttributeNameApproximateNumberOfMessages,
-			types.QueueAttributeNameApproximateNumberOfMessagesNotVisible,
-			types.QueueAttributeNameApproximateNumberOfMessagesDelayed,
-		},
-	})
+	// Once we have a URL, read the attributes (cached for AttributeCacheTTL unless refreshed)
+	attrs, fetched, err := s.queueAttributes(ctx)
 	if err != nil {
-		s.Log.Warn("failed to get queue attributes", "error", err)
-		info["error"] = err.Error()
+		s.Log.WarnContext(ctx, "failed to get queue attributes", "error", err)
+		s.forgetQueueURL(err)
+		setInfoError(info, err)
 		return info
 	}
 
@@ -299,17 +633,26 @@ func (s *SQSService) Info(ctx context.Context) map[string]interface{} {
 		return n
 	}
 
-	visible := parseInt(out.Attributes[string(types.QueueAttributeNameApproximateNumberOfMessages)])
-	notVisible := parseInt(out.Attributes[string(types.QueueAttributeNameApproximateNumberOfMessagesNotVisible)])
-	delayed := parseInt(out.Attributes[string(types.QueueAttributeNameApproximateNumberOfMessagesDelayed)])
+	visible := parseInt(attrs[string(types.QueueAttributeNameApproximateNumberOfMessages)])
+	notVisible := parseInt(attrs[string(types.QueueAttributeNameApproximateNumberOfMessagesNotVisible)])
+	delayed := parseInt(attrs[string(types.QueueAttributeNameApproximateNumberOfMessagesDelayed)])
 
 	info["approximate_number_of_messages"] = visible
 	info["approximate_number_of_messages_not_visible"] = notVisible
 	info["approximate_number_of_messages_delayed"] = delayed
 	info["number_of_messages"] = strconv.FormatInt(visible+notVisible+delayed, 10)
+	info["attributes_age_seconds"] = int(time.Since(fetched).Seconds())
 	info["status"] = "ok"
 
-	s.Log.Info("queue info fetched", "queue_name", s.QueueName, "queue_url", s.QueueURL)
+	// Whether other queues send their failures here; leave it out when it can't be listed
+	if sources, err := s.deadLetterSourceNames(ctx); err != nil {
+		s.Log.DebugContext(ctx, "failed to list dead-letter source queues", "error", err)
+	} else {
+		info["is_dead_letter_queue"] = len(sources) > 0
+		info["dead_letter_sources"] = sources
+	}
+
+	s.Log.InfoContext(ctx, "queue info fetched", "queue_name", s.QueueName, "queue_url", s.QueueURL)
 	return info
 }
 
@@ -0,0 +1,177 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sort"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+
+	"github.com/pachecoc/sqs-ui/internal/memsqs"
+	"github.com/pachecoc/sqs-ui/internal/service"
+)
+
+// bodies returns the sorted bodies of msgs.
+func bodies(msgs []map[string]any) []string {
+	out := make([]string, 0, len(msgs))
+	for _, m := range msgs {
+		body, _ := m["body"].(string)
+		out = append(out, body)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func wantCounts(t *testing.T, svc *service.SQSService, want service.QueueCounts) {
+	t.Helper()
+	got, err := svc.Counts(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("counts %+v, want %+v", got, want)
+	}
+}
+
+func TestSendReceiveDelete(t *testing.T) {
+	ctx := context.Background()
+	mem := memsqs.New()
+	svc := newQueue(t, mem, mem, "orders", 0)
+
+	for _, body := range []string{"one", "two", "three"} {
+		res, err := svc.Send(ctx, body, service.SendOptions{})
+		if err != nil {
+			t.Fatalf("send %s: %v", body, err)
+		}
+		if res.MessageID == "" || res.MD5OfBody == "" || res.SequenceNumber != "" {
+			t.Errorf("send %s: %+v", body, res)
+		}
+	}
+	if _, err := svc.Send(ctx, "  ", service.SendOptions{}); err == nil {
+		t.Error("a blank body was sent")
+	}
+
+	observed, err := svc.Receive(ctx, service.ModeObserve)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := bodies(observed); len(got) != 3 || got[0] != "one" || got[1] != "three" || got[2] != "two" {
+		t.Errorf("observe listing %q", got)
+	}
+	for _, m := range observed {
+		if _, ok := m["receipt_handle"]; ok || m["message_id"] == "" {
+			t.Errorf("observed message %v, want an id and no receipt handle", m)
+		}
+	}
+	wantCounts(t, svc, service.QueueCounts{Visible: 3})
+
+	consumed, err := svc.Receive(ctx, service.ModeConsume)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(consumed) != 3 {
+		t.Fatalf("consume listing of %d messages, want 3", len(consumed))
+	}
+	wantCounts(t, svc, service.QueueCounts{NotVisible: 3})
+
+	handles := make([]string, 0, len(consumed))
+	for _, m := range consumed {
+		handle, _ := m["receipt_handle"].(string)
+		handles = append(handles, handle)
+	}
+	if n, err := svc.Delete(ctx, handles); err != nil || n != 3 {
+		t.Fatalf("delete: %d, %v", n, err)
+	}
+	wantCounts(t, svc, service.QueueCounts{})
+}
+
+func TestSendFIFO(t *testing.T) {
+	ctx := context.Background()
+	mem := memsqs.New()
+	out, err := mem.CreateQueue(ctx, &sqs.CreateQueueInput{
+		QueueName:  aws.String("orders.fifo"),
+		Attributes: map[string]string{"FifoQueue": "true"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc := service.NewSQSService(ctx, mem, "orders.fifo", aws.ToString(out.QueueUrl), mem.Region, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	first, err := svc.Send(ctx, "first", service.SendOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := svc.Send(ctx, "second", service.SendOptions{MessageGroupID: "vip"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.SequenceNumber == "" || second.SequenceNumber == "" {
+		t.Errorf("FIFO sends without sequence numbers: %+v, %+v", first, second)
+	}
+
+	recv, err := mem.ReceiveMessage(ctx, &sqs.ReceiveMessageInput{
+		QueueUrl:                    out.QueueUrl,
+		MaxNumberOfMessages:         10,
+		MessageSystemAttributeNames: []types.MessageSystemAttributeName{types.MessageSystemAttributeNameMessageGroupId},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	groups := map[string]string{}
+	for _, m := range recv.Messages {
+		groups[aws.ToString(m.Body)] = m.Attributes["MessageGroupId"]
+	}
+	if groups["first"] != service.DefaultMessageGroupID || groups["second"] != "vip" {
+		t.Errorf("message groups %v", groups)
+	}
+}
+
+func TestPurge(t *testing.T) {
+	ctx := context.Background()
+	mem := memsqs.New()
+	svc := newQueue(t, mem, mem, "orders", 0)
+	for range 3 {
+		if _, err := svc.Send(ctx, "doomed", service.SendOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := svc.Purge(ctx); err != nil {
+		t.Fatal(err)
+	}
+	wantCounts(t, svc, service.QueueCounts{})
+
+	var cooldown *service.PurgeCooldownError
+	if err := svc.Purge(ctx); !errors.As(err, &cooldown) || cooldown.Remaining <= 0 {
+		t.Errorf("second purge: %v, want a PurgeCooldownError", err)
+	}
+}
+
+func TestReadOnly(t *testing.T) {
+	ctx := context.Background()
+	mem := memsqs.New()
+	svc := newQueue(t, mem, mem, "orders", 0)
+	if _, err := svc.Send(ctx, "kept", service.SendOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	svc.ReadOnly = true
+
+	if _, err := svc.Send(ctx, "blocked", service.SendOptions{}); !errors.Is(err, service.ErrReadOnly) {
+		t.Errorf("send: %v, want ErrReadOnly", err)
+	}
+	if _, err := svc.Delete(ctx, []string{"handle"}); !errors.Is(err, service.ErrReadOnly) {
+		t.Errorf("delete: %v, want ErrReadOnly", err)
+	}
+	if err := svc.Purge(ctx); !errors.Is(err, service.ErrReadOnly) {
+		t.Errorf("purge: %v, want ErrReadOnly", err)
+	}
+	if _, err := svc.Drain(ctx, 0); !errors.Is(err, service.ErrReadOnly) {
+		t.Errorf("drain: %v, want ErrReadOnly", err)
+	}
+	wantCounts(t, svc, service.QueueCounts{Visible: 1})
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+
+	"github.com/pachecoc/sqs-ui/internal/validate"
+)
+
+// SQS tag limits.
+const (
+	maxQueueTags      = 50
+	maxTagKeyLen      = 128
+	maxTagValueLen    = 256
+	reservedTagPrefix = "aws:"
+)
+
+// Tags returns the queue's tags (cost allocation, ownership, ...).
+func (s *SQSService) Tags(ctx context.Context) (map[string]string, error) {
+	if s.QueueURL == "" {
+		return nil, fmt.Errorf("no active queue configured, try to fetch queue info first")
+	}
+	return s.QueueTags(ctx, s.QueueURL)
+}
+
+// ValidateTags checks tags against the SQS limits, reporting each problem under "tags.<key>".
+func ValidateTags(tags map[string]string) error {
+	var v validate.Validator
+	v.NotEmpty("tags", len(tags))
+	v.Check(len(tags) <= maxQueueTags, "tags", fmt.Sprintf("at most %d tags are allowed", maxQueueTags))
+	for _, k := range slices.Sorted(maps.Keys(tags)) {
+		field := "tags." + k
+		v.Check(k != "" && utf8.RuneCountInString(k) <= maxTagKeyLen, field, fmt.Sprintf("key must be 1-%d characters", maxTagKeyLen))
+		v.Check(!strings.HasPrefix(k, reservedTagPrefix), field, "keys starting with aws: are reserved")
+		v.Check(utf8.RuneCountInString(tags[k]) <= maxTagValueLen, field, fmt.Sprintf("value must be at most %d characters", maxTagValueLen))
+	}
+	return v.Err()
+}
+
+// TagQueue adds or overwrites tags on the queue.
+func (s *SQSService) TagQueue(ctx context.Context, tags map[string]string) error {
+	if err := s.checkTaggable(); err != nil {
+		return err
+	}
+	if err := ValidateTags(tags); err != nil {
+		return err
+	}
+	ctx, cancel := budget(ctx, queueAttrTimeout)
+	defer cancel()
+
+	if _, err := s.Client.TagQueue(ctx, &sqs.TagQueueInput{QueueUrl: aws.String(s.QueueURL), Tags: tags}); err != nil {
+		return fmt.Errorf("failed to tag queue: %w", err)
+	}
+	return nil
+}
+
+// UntagQueue removes the tags named keys from the queue.
+func (s *SQSService) UntagQueue(ctx context.Context, keys []string) error {
+	if err := s.checkTaggable(); err != nil {
+		return err
+	}
+	var v validate.Validator
+	v.NotEmpty("keys", len(keys))
+	for i, k := range keys {
+		v.Check(k != "", fmt.Sprintf("keys[%d]", i), "must not be empty")
+	}
+	if err := v.Err(); err != nil {
+		return err
+	}
+	ctx, cancel := budget(ctx, queueAttrTimeout)
+	defer cancel()
+
+	if _, err := s.Client.UntagQueue(ctx, &sqs.UntagQueueInput{QueueUrl: aws.String(s.QueueURL), TagKeys: keys}); err != nil {
+		return fmt.Errorf("failed to untag queue: %w", err)
+	}
+	return nil
+}
+
+func (s *SQSService) checkTaggable() error {
+	switch {
+	case s.Client == nil:
+		return ErrAWSNotConfigured
+	case s.QueueURL == "":
+		return fmt.Errorf("no active queue configured, try to fetch queue info first")
+	case s.ReadOnly:
+		return ErrReadOnly
+	}
+	return nil
+}
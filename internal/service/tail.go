@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+const (
+	// tailWindow is how far back a tail keeps remembering emitted messages. Peeked messages
+	// keep coming back until deleted, and a delayed message (up to 15 minutes) shows up long
+	// after its SentTimestamp, so anything sent before it is only considered within the window.
+	tailWindow = 15 * time.Minute
+	// tailRepeatPause is the first pause after a poll that only returned messages already
+	// emitted; peeks return at once while the queue holds messages, so the long poll doesn't
+	// pace them. It doubles with each such poll, up to tailMaxRepeatPause.
+	tailRepeatPause    = time.Second
+	tailMaxRepeatPause = 30 * time.Second
+	// tailMaxBackoff caps the wait after failed receives.
+	tailMaxBackoff = time.Minute
+)
+
+// TailMinReceiveCount is the lowest redrive maxReceiveCount CheckTail accepts. Every tail
+// poll is a receive, so on a queue that dead-letters after fewer receives a tail left open
+// can move messages to the DLQ before their consumers get to them.
+const TailMinReceiveCount = 10
+
+// TailRedriveError is returned by CheckTail for a queue whose redrive policy allows fewer
+// than TailMinReceiveCount receives.
+type TailRedriveError struct {
+	QueueName string
+	Policy    *RedrivePolicy
+}
+
+func (e *TailRedriveError) Error() string {
+	return fmt.Sprintf("%s moves messages to %s after %d receives, and every tail poll counts as one",
+		e.QueueName, e.Policy.DeadLetterQueue, e.Policy.MaxReceiveCount)
+}
+func (e *TailRedriveError) ErrorCode() string { return "tail_redrive_risk" }
+func (e *TailRedriveError) Remediation() string {
+	return fmt.Sprintf("raise maxReceiveCount to at least %d, or tail anyway and watch the dead-letter queue", TailMinReceiveCount)
+}
+
+// CheckTail refuses to tail a queue whose redrive policy dead-letters messages after fewer
+// than TailMinReceiveCount receives.
+func (s *SQSService) CheckTail(ctx context.Context) error {
+	policy, err := s.RedrivePolicy(ctx)
+	if err != nil {
+		return err
+	}
+	if policy != nil && policy.MaxReceiveCount < TailMinReceiveCount {
+		return &TailRedriveError{QueueName: s.QueueName, Policy: policy}
+	}
+	return nil
+}
+
+// Tail long-polls the queue until ctx ends and calls emit with the messages sent at or after
+// since that it hasn't emitted before. Failed receives are reported to onError and retried
+// with backoff; Tail returns only when ctx is done.
+//
+// Messages are peeked: each batch is made visible again as soon as it arrives, so consumers
+// are barely delayed. But each poll that returns a message raises its ApproximateReceiveCount,
+// which counts towards the queue's redrive maxReceiveCount (see CheckTail). While polls only
+// return messages already emitted, Tail waits longer between them, so a message nobody
+// consumes is soon received twice a minute rather than once a second.
+func (s *SQSService) Tail(ctx context.Context, since time.Time, emit func([]PipeMessage), onError func(error)) error {
+	seen := make(map[string]time.Time)
+	backoff := tailRepeatPause
+	pause := tailRepeatPause
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		batch, err := s.ReceiveBatch(ctx, 0)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			onError(err)
+			if !sleepCtx(ctx, backoff) {
+				return ctx.Err()
+			}
+			backoff = min(backoff*2, tailMaxBackoff)
+			continue
+		}
+		backoff = tailRepeatPause
+		// The SDK leaves a zero VisibilityTimeout out of the request, so SQS hides the batch
+		// for the queue's default; hand it straight back so consumers aren't held up
+		if len(batch) > 0 {
+			handles := make([]string, len(batch))
+			for i, m := range batch {
+				handles[i] = m.ReceiptHandle
+			}
+			s.release(context.WithoutCancel(ctx), handles)
+		}
+
+		cutoff := since
+		if c := time.Now().Add(-tailWindow); c.After(cutoff) {
+			cutoff = c
+		}
+		for id, sent := range seen {
+			if sent.Before(cutoff) {
+				delete(seen, id)
+			}
+		}
+
+		var fresh []PipeMessage
+		for _, m := range batch {
+			sent := sentTime(m.SentTimestamp)
+			if _, dup := seen[m.MessageID]; dup || sent.Before(cutoff) {
+				continue
+			}
+			seen[m.MessageID] = sent
+			fresh = append(fresh, m)
+		}
+		if len(fresh) > 0 || len(batch) == 0 {
+			pause = tailRepeatPause
+			if len(fresh) > 0 {
+				emit(fresh)
+			}
+			continue
+		}
+		if !sleepCtx(ctx, pause) {
+			return ctx.Err()
+		}
+		pause = min(pause*2, tailMaxRepeatPause)
+	}
+}
+
+// sentTime parses an SQS SentTimestamp (epoch milliseconds); a missing one counts as now.
+func sentTime(ms string) time.Time {
+	n, err := strconv.ParseInt(ms, 10, 64)
+	if err != nil {
+		return time.Now()
+	}
+	return time.UnixMilli(n)
+}
+
+// sleepCtx waits for d and reports whether ctx was still live afterwards.
+func sleepCtx(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
@@ -0,0 +1,107 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+
+	"github.com/pachecoc/sqs-ui/internal/memsqs"
+	"github.com/pachecoc/sqs-ui/internal/service"
+)
+
+// countingClient counts ReceiveMessage calls.
+type countingClient struct {
+	service.SQSAPI
+	receives atomic.Int32
+}
+
+func (c *countingClient) ReceiveMessage(ctx context.Context, in *sqs.ReceiveMessageInput, optFns ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error) {
+	c.receives.Add(1)
+	return c.SQSAPI.ReceiveMessage(ctx, in, optFns...)
+}
+
+// newQueue creates name on mem, with a redrive policy to name-dlq after maxReceiveCount
+// receives unless that is 0, and returns a service on it through client.
+func newQueue(t *testing.T, mem *memsqs.Client, client service.SQSAPI, name string, maxReceiveCount int) *service.SQSService {
+	t.Helper()
+	ctx := context.Background()
+	in := &sqs.CreateQueueInput{QueueName: aws.String(name)}
+	if maxReceiveCount > 0 {
+		dlq := name + "-dlq"
+		if _, err := mem.CreateQueue(ctx, &sqs.CreateQueueInput{QueueName: aws.String(dlq)}); err != nil {
+			t.Fatal(err)
+		}
+		arn := "arn:aws:sqs:" + mem.Region + ":" + mem.Account + ":" + dlq
+		in.Attributes = map[string]string{"RedrivePolicy": service.RedrivePolicyJSON(arn, maxReceiveCount)}
+	}
+	out, err := mem.CreateQueue(ctx, in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc := service.NewSQSService(ctx, client, "", aws.ToString(out.QueueUrl), mem.Region, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	svc.WaitSeconds = 1
+	return svc
+}
+
+func TestCheckTail(t *testing.T) {
+	ctx := context.Background()
+	mem := memsqs.New()
+
+	var risk *service.TailRedriveError
+	if err := newQueue(t, mem, mem, "orders", 3).CheckTail(ctx); !errors.As(err, &risk) {
+		t.Fatalf("maxReceiveCount 3: got %v, want a TailRedriveError", err)
+	}
+	if risk.Policy.MaxReceiveCount != 3 || risk.Policy.DeadLetterQueue != "orders-dlq" {
+		t.Errorf("refusal names %+v", risk.Policy)
+	}
+	if err := newQueue(t, mem, mem, "payments", service.TailMinReceiveCount).CheckTail(ctx); err != nil {
+		t.Errorf("maxReceiveCount %d: %v", service.TailMinReceiveCount, err)
+	}
+	if err := newQueue(t, mem, mem, "events", 0).CheckTail(ctx); err != nil {
+		t.Errorf("no redrive policy: %v", err)
+	}
+}
+
+func TestTailBacksOffOnRepeats(t *testing.T) {
+	mem := memsqs.New()
+	client := &countingClient{SQSAPI: mem}
+	svc := newQueue(t, mem, client, "orders", 0)
+	if _, err := svc.Send(context.Background(), "stuck", service.SendOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Polls at 0s (emits), 0s, 1s and 3s; a fixed one-second pause would add a fifth at 2s
+	ctx, cancel := context.WithTimeout(context.Background(), 3500*time.Millisecond)
+	defer cancel()
+	var emitted int
+	err := svc.Tail(ctx, time.Now().Add(-time.Minute), func(msgs []service.PipeMessage) {
+		emitted += len(msgs)
+	}, func(err error) {
+		t.Errorf("receive failed: %v", err)
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Tail returned %v", err)
+	}
+	if emitted != 1 {
+		t.Errorf("emitted %d messages, want the one sent", emitted)
+	}
+	if n := client.receives.Load(); n > 4 {
+		t.Errorf("%d polls in 3.5s of a queue holding one emitted message, want at most 4", n)
+	}
+
+	// The message was handed back after each poll, so a consumer gets it at once
+	counts, err := svc.Counts(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if counts.Visible != 1 {
+		t.Errorf("counts after the tail: %+v, want the message visible", counts)
+	}
+}
@@ -0,0 +1,18 @@
+package service
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/pachecoc/sqs-ui/internal/tracing"
+)
+
+// startSpan starts the span of a queue operation; the SDK calls it makes nest under it.
+// End it with tracing.End.
+func (s *SQSService) startSpan(ctx context.Context, op string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	attrs = append(attrs, semconv.MessagingSystemAWSSqs, semconv.MessagingDestinationName(s.QueueName))
+	return tracing.Start(ctx, "SQSService."+op, attrs...)
+}
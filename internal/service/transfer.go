@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+// TransformFunc rewrites a message body before it is sent to the target queue.
+type TransformFunc func(ctx context.Context, messageID, body string) (string, error)
+
+// TransferResult counts what a Transfer did.
+type TransferResult struct {
+	Sent    int            `json:"sent"`
+	Deleted int            `json:"deleted"`
+	Failed  int            `json:"failed"`
+	Errors  map[string]int `json:"errors,omitempty"` // error text -> occurrences
+}
+
+// Transfer receives messages from s and sends them (optionally transformed) to dst until the
+// queue is empty, limit is reached (0 = no limit) or ctx ends. When keep is false, sent
+// messages are deleted from s (move); otherwise they are released back (forward). Messages
+// that fail to transform or send stay on s and become visible again.
+func (s *SQSService) Transfer(ctx context.Context, dst *SQSService, limit int, transform TransformFunc, keep bool) (TransferResult, error) {
+	s.Log.DebugContext(ctx, "transferring messages", "queue_name", s.QueueName, "target_queue", dst.QueueName, "limit", limit, "keep", keep)
+
+	var res TransferResult
+	if s.QueueURL == "" || dst.QueueURL == "" {
+		return res, fmt.Errorf("no active queue configured, try to fetch queue info first")
+	}
+	if s.Client == nil || dst.Client == nil {
+		return res, ErrAWSNotConfigured
+	}
+	if dst.ReadOnly || (s.ReadOnly && !keep) {
+		return res, ErrReadOnly
+	}
+	if s.QueueURL == dst.QueueURL {
+		return res, fmt.Errorf("source and target queue are the same")
+	}
+
+	// Forwarded messages are held until the end so the loop doesn't see them again
+	var held []string
+	if keep {
+		defer func() { s.release(context.WithoutCancel(ctx), held) }()
+	}
+
+	seen := make(map[string]bool)
+	for limit == 0 || res.Sent < limit {
+		batch := int32(10)
+		if limit > 0 && limit-res.Sent < 10 {
+			batch = int32(limit - res.Sent)
+		}
+
+		resp, err := s.receiveMessage(ctx, &sqs.ReceiveMessageInput{
+			QueueUrl:            &s.QueueURL,
+			MaxNumberOfMessages: batch,
+			VisibilityTimeout:   drainVisibility,
+			WaitTimeSeconds:     1,
+		})
+		if err != nil {
+			return res, fmt.Errorf("failed to receive messages: %w", err)
+		}
+		if len(resp.Messages) == 0 {
+			break
+		}
+
+		var done []types.DeleteMessageBatchRequestEntry
+		fresh := 0
+		for _, m := range resp.Messages {
+			id := aws.ToString(m.MessageId)
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+			fresh++
+
+			body := aws.ToString(m.Body)
+			if transform != nil {
+				if body, err = transform(ctx, id, body); err != nil {
+					res.fail(err)
+					continue
+				}
+			}
+			if _, err := dst.Send(ctx, body, SendOptions{}); err != nil {
+				res.fail(err)
+				continue
+			}
+			res.Sent++
+
+			if keep {
+				held = append(held, aws.ToString(m.ReceiptHandle))
+				continue
+			}
+			done = append(done, types.DeleteMessageBatchRequestEntry{
+				Id:            aws.String(strconv.Itoa(len(done))),
+				ReceiptHandle: m.ReceiptHandle,
+			})
+		}
+
+		if len(done) > 0 {
+			out, err := s.Client.DeleteMessageBatch(ctx, &sqs.DeleteMessageBatchInput{
+				QueueUrl: &s.QueueURL,
+				Entries:  done,
+			})
+			if err != nil {
+				return res, fmt.Errorf("failed to delete messages: %w", err)
+			}
+			res.Deleted += len(out.Successful)
+			if len(out.Failed) > 0 {
+				s.Log.WarnContext(ctx, "some deletes failed during transfer", "failed", len(out.Failed))
+			}
+		}
+		if fresh == 0 {
+			// Only failed messages are coming back around
+			break
+		}
+	}
+
+	s.Log.InfoContext(ctx, "messages transferred", "queue_name", s.QueueName, "target_queue", dst.QueueName,
+		"sent", res.Sent, "deleted", res.Deleted, "failed", res.Failed)
+	return res, nil
+}
+
+func (r *TransferResult) fail(err error) {
+	r.Failed++
+	if r.Errors == nil {
+		r.Errors = make(map[string]int)
+	}
+	r.Errors[err.Error()]++
+}
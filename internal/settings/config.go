@@ -1,10 +1,15 @@
 package settings
 
 import (
+	"fmt"
+	"io"
 	"log/slog"
 	"os"
+	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // AppConfig holds application runtime parameters (populated from environment).
@@ -12,57 +17,371 @@ type AppConfig struct {
 	QueueName              string
 	QueueURL               string
 	LogLevel               string
+	AccessLog              bool
 	Port                   string
+	InfoStreamInterval     time.Duration
+	WatchInterval          time.Duration
+	AlertDepthThreshold    int64
+	JobWorkers             int
+	JobQueueConcurrency    int
+	JobResultTTL           time.Duration
+	ReceiveMode            string
+	StoreBackend           string
+	DataDir                string
+	RedisURL               string
+	RedisPrefix            string
+	DynamoDBTable          string
+	CoordinationEnabled    bool
+	LeaseTTL               time.Duration
+	SlackSigningSecret     string
+	DigestWebhookURL       string
+	DigestQueues           []string
+	DigestInterval         time.Duration
+	HeartbeatURL           string
+	HeartbeatFailURL       string
+	HeartbeatInterval      time.Duration
+	DigestStaleAfter       time.Duration
+	SMTPHost               string
+	SMTPPort               int
+	SMTPUsername           string
+	SMTPPassword           string
+	SMTPFrom               string
+	EmailRules             string
+	ExecDecoderCommand     string
+	ExecDecoderQueues      []string
+	ExecDecoderTimeout     time.Duration
+	ExecDecoderRate        int
+	ScriptTimeout          time.Duration
+	ApprovalQueues         []string
+	Approvers              []string
+	ApprovalTTL            time.Duration
+	UserHeader             string
+	OIDCIssuer             string
+	OIDCClientID           string
+	OIDCClientSecret       string
+	OIDCRedirectURL        string
+	OIDCScopes             []string
+	OIDCUserClaim          string
+	SessionSecret          string
+	SessionTTL             time.Duration
+	APITokens              string
+	MaintenanceWindows     string
+	MaintenanceTimezone    string
+	MaintenanceOverride    bool
+	ProfilesFile           string
+	InFlightInterval       time.Duration
+	InFlightWindow         time.Duration
+	DepthHistoryInterval   time.Duration
+	DepthHistoryWindow     time.Duration
+	CloudWatchMetrics      bool
+	RequestTimeout         time.Duration
+	ShutdownTimeout        time.Duration
+	ReconnectHint          time.Duration
+	TLSCertFile            string
+	TLSKeyFile             string
+	ListenerFile           string
+	WebDir                 string
+	BasePath               string
+	DefaultMessageGroupID  string
+	AttributeCacheTTL      time.Duration
+	QueueNameRules         string
+	SQSEndpoint            string
+	DemoMode               bool
+	DemoDataset            string
+	DemoDatasetSize        int
+	FaultInjection         string
+	FaultInjectionSeed     int
+	QueueRefRefresh        time.Duration
+	SecretsRefresh         time.Duration
+	StoreEncryptionKeys    string
+	StoreKMSKeyID          string
+	StoreKeyRotation       time.Duration
+	StoreRetention         string
+	PurgeConfirmTTL        time.Duration
+	Admins                 []string
+	CORSAllowedOrigins     []string
+	CORSAllowedMethods     []string
+	CORSAllowedHeaders     []string
+	CORSAllowCredentials   bool
+	CORSMaxAge             time.Duration
+	ShareLinks             bool
+	ShareLinkSecret        string
+	ShareLinkMaxTTL        time.Duration
+	RBACDefaultRole        string
+	RBACViewers            []string
+	RBACOperators          []string
+	RBACRoleHeader         string
+	StoreJanitorInterval   time.Duration
+	TelemetryEnabled       bool
+	TelemetryEndpoint      string
+	TelemetryInterval      time.Duration
+	UpdateCheck            bool
+	UpdateCheckURL         string
+	UpdateCheckTTL         time.Duration
+	ScratchPrefix          string
+	ScratchTTL             time.Duration
+	ScratchMaxTTL          time.Duration
+	ScratchReapInterval    time.Duration
 }
 
 // Load reads environment variables, applying defaults and validation.
 func Load(log *slog.Logger) AppConfig {
 
 	// Read environment variables
-	queueName := strings.TrimSpace(os.Getenv("QUEUE_NAME"))
-	queueURL := strings.TrimSpace(os.Getenv("QUEUE_URL"))
-	port := strings.TrimSpace(os.Getenv("PORT"))
-	logLevel := strings.ToLower(strings.TrimSpace(os.Getenv("LOG_LEVEL")))
-
-	// Default port
-	if port == "" {
-		port = "8080"
-	}
+	queueName := stringEnv("QUEUE_NAME", "")
+	queueURL := stringEnv("QUEUE_URL", "")
+	port := stringEnv("PORT", "8080")
+	logLevel := strings.ToLower(stringEnv("LOG_LEVEL", "info"))
+
+	// Local store (job results, artifacts)
+	storeBackend := strings.ToLower(stringEnv("STORE_BACKEND", "file"))
+	dataDir := stringEnv("DATA_DIR", filepath.Join(os.TempDir(), "sqs-ui"))
+	redisPrefix := stringEnv("REDIS_PREFIX", "sqs-ui")
+	userHeader := stringEnv("USER_HEADER", "X-Forwarded-User")
 
-	// Default log level
-	if logLevel == "" {
+	// Validate log level
+	switch logLevel {
+	case "debug", "info", "warn", "error":
+	default:
+		log.Warn("unsupported log level, falling back to default", "provided", logLevel, "default", "info")
 		logLevel = "info"
-	} else {
-		switch logLevel {
-		case "debug", "info", "warn", "error":
-		default:
-			log.Warn("unsupported log level, falling back to default", "provided", logLevel, "default", "info")
-			logLevel = "info"
-		}
+		record("LOG_LEVEL", "info", logLevel)
 	}
 
 	return AppConfig{
 		QueueName:              queueName,
 		QueueURL:               queueURL,
 		LogLevel:               logLevel,
+		AccessLog:              parseBoolEnv("ACCESS_LOG_ENABLED", true),
 		Port:                   port,
+		InfoStreamInterval:     time.Duration(parseIntEnv("INFO_STREAM_INTERVAL_SECONDS", 5)) * time.Second,
+		WatchInterval:          time.Duration(parseIntEnv("WATCH_INTERVAL_SECONDS", 30)) * time.Second,
+		AlertDepthThreshold:    int64(parseIntEnv("ALERT_DEPTH_THRESHOLD", 0)),
+		JobWorkers:             parseIntEnv("JOB_WORKERS", 4),
+		JobQueueConcurrency:    parseIntEnv("JOB_QUEUE_CONCURRENCY", 1),
+		JobResultTTL:           time.Duration(parseIntEnv("JOB_RESULT_TTL_HOURS", 24)) * time.Hour,
+		ReceiveMode:            strings.ToLower(stringEnv("RECEIVE_MODE", "")),
+		StoreBackend:           storeBackend,
+		DataDir:                dataDir,
+		RedisURL:               stringEnv("REDIS_URL", ""),
+		RedisPrefix:            redisPrefix,
+		DynamoDBTable:          stringEnv("DYNAMODB_TABLE", "sqs-ui"),
+		CoordinationEnabled:    parseBoolEnv("COORDINATION_ENABLED", false),
+		LeaseTTL:               time.Duration(parseIntEnv("LEASE_TTL_SECONDS", 15)) * time.Second,
+		SlackSigningSecret:     rawEnv("SLACK_SIGNING_SECRET"),
+		DigestWebhookURL:       stringEnv("DIGEST_WEBHOOK_URL", ""),
+		DigestQueues:           parseListEnv("DIGEST_QUEUES"),
+		DigestInterval:         time.Duration(parseIntEnv("DIGEST_INTERVAL_HOURS", 24)) * time.Hour,
+		HeartbeatURL:           stringEnv("HEARTBEAT_URL", ""),
+		HeartbeatFailURL:       stringEnv("HEARTBEAT_FAIL_URL", ""),
+		HeartbeatInterval:      time.Duration(parseIntEnv("HEARTBEAT_INTERVAL_SECONDS", 60)) * time.Second,
+		DigestStaleAfter:       time.Duration(parseIntEnv("DIGEST_STALE_MINUTES", 60)) * time.Minute,
+		SMTPHost:               stringEnv("SMTP_HOST", ""),
+		SMTPPort:               parseIntEnv("SMTP_PORT", 587),
+		SMTPUsername:           rawEnv("SMTP_USERNAME"),
+		SMTPPassword:           rawEnv("SMTP_PASSWORD"),
+		SMTPFrom:               stringEnv("SMTP_FROM", ""),
+		EmailRules:             rawEnv("EMAIL_RULES"),
+		ExecDecoderCommand:     stringEnv("EXEC_DECODER_COMMAND", ""),
+		ExecDecoderQueues:      parseListEnv("EXEC_DECODER_QUEUES"),
+		ExecDecoderTimeout:     time.Duration(parseIntEnv("EXEC_DECODER_TIMEOUT_MS", 2000)) * time.Millisecond,
+		ExecDecoderRate:        parseIntEnv("EXEC_DECODER_RATE_PER_SECOND", 5),
+		ScriptTimeout:          time.Duration(parseIntEnv("SCRIPT_TIMEOUT_MS", 200)) * time.Millisecond,
+		ApprovalQueues:         parseListEnv("APPROVAL_QUEUES"),
+		Approvers:              parseListEnv("APPROVERS"),
+		ApprovalTTL:            time.Duration(parseIntEnv("APPROVAL_TTL_MINUTES", 30)) * time.Minute,
+		UserHeader:             userHeader,
+		OIDCIssuer:             stringEnv("OIDC_ISSUER", ""),
+		OIDCClientID:           stringEnv("OIDC_CLIENT_ID", ""),
+		OIDCClientSecret:       rawEnv("OIDC_CLIENT_SECRET"),
+		OIDCRedirectURL:        stringEnv("OIDC_REDIRECT_URL", ""),
+		OIDCScopes:             parseListEnv("OIDC_SCOPES"),
+		OIDCUserClaim:          stringEnv("OIDC_USER_CLAIM", "email"),
+		SessionSecret:          rawEnv("SESSION_SECRET"),
+		SessionTTL:             time.Duration(parseIntEnv("SESSION_TTL_HOURS", 12)) * time.Hour,
+		APITokens:              rawEnv("API_TOKENS"),
+		MaintenanceWindows:     rawEnv("MAINTENANCE_WINDOWS"),
+		MaintenanceTimezone:    stringEnv("MAINTENANCE_TIMEZONE", ""),
+		MaintenanceOverride:    parseBoolEnv("MAINTENANCE_ALLOW_OVERRIDE", false),
+		ProfilesFile:           stringEnv("PROFILES_FILE", ""),
+		InFlightInterval:       time.Duration(parseIntEnv("INFLIGHT_SAMPLE_SECONDS", 60)) * time.Second,
+		InFlightWindow:         time.Duration(parseIntEnv("INFLIGHT_WINDOW_MINUTES", 60)) * time.Minute,
+		DepthHistoryInterval:   time.Duration(parseNonNegIntEnv("DEPTH_HISTORY_INTERVAL_SECONDS", 30)) * time.Second,
+		DepthHistoryWindow:     time.Duration(parseIntEnv("DEPTH_HISTORY_MINUTES", 60)) * time.Minute,
+		CloudWatchMetrics:      parseBoolEnv("CLOUDWATCH_METRICS_ENABLED", false),
+		RequestTimeout:         time.Duration(parseIntEnv("REQUEST_TIMEOUT_SECONDS", 8)) * time.Second,
+		ShutdownTimeout:        time.Duration(parseIntEnv("SHUTDOWN_TIMEOUT_SECONDS", 10)) * time.Second,
+		ReconnectHint:          time.Duration(parseIntEnv("SHUTDOWN_RECONNECT_SECONDS", 5)) * time.Second,
+		TLSCertFile:            stringEnv("TLS_CERT_FILE", ""),
+		TLSKeyFile:             stringEnv("TLS_KEY_FILE", ""),
+		ListenerFile:           stringEnv("LISTENER_FILE", ""),
+		WebDir:                 stringEnv("WEB_DIR", ""),
+		BasePath:               stringEnv("BASE_PATH", ""),
+		DefaultMessageGroupID:  stringEnv("DEFAULT_MESSAGE_GROUP_ID", "default-group"),
+		AttributeCacheTTL:      time.Duration(parseNonNegIntEnv("ATTRIBUTE_CACHE_SECONDS", 15)) * time.Second,
+		QueueNameRules:         rawEnv("QUEUE_NAME_RULES"),
+		SQSEndpoint:            stringEnv("SQS_ENDPOINT", ""),
+		DemoMode:               parseBoolEnv("DEMO_MODE", false),
+		DemoDataset:            stringEnv("DEMO_DATASET", "full"),
+		DemoDatasetSize:        parseIntEnv("DEMO_DATASET_SIZE", 60),
+		FaultInjection:         rawEnv("FAULT_INJECTION"),
+		FaultInjectionSeed:     parseIntEnv("FAULT_INJECTION_SEED", 1),
+		QueueRefRefresh:        time.Duration(parseNonNegIntEnv("QUEUE_REF_REFRESH_SECONDS", 300)) * time.Second,
+		SecretsRefresh:         time.Duration(parseNonNegIntEnv("SECRETS_REFRESH_SECONDS", 300)) * time.Second,
+		StoreEncryptionKeys:    rawEnv("STORE_ENCRYPTION_KEYS"),
+		StoreKMSKeyID:          stringEnv("STORE_KMS_KEY_ID", ""),
+		StoreKeyRotation:       time.Duration(parseNonNegIntEnv("STORE_KEY_ROTATION_DAYS", 90)) * 24 * time.Hour,
+		StoreRetention:         rawEnv("STORE_RETENTION"),
+		PurgeConfirmTTL:        time.Duration(parseIntEnv("PURGE_CONFIRM_TTL_SECONDS", 120)) * time.Second,
+		Admins:                 parseListEnv("ADMINS"),
+		CORSAllowedOrigins:     parseListEnv("CORS_ALLOWED_ORIGINS"),
+		CORSAllowedMethods:     listEnvOr("CORS_ALLOWED_METHODS", "GET", "POST", "PUT", "DELETE"),
+		CORSAllowedHeaders:     listEnvOr("CORS_ALLOWED_HEADERS", "Content-Type", "Authorization", "X-Request-ID"),
+		CORSAllowCredentials:   parseBoolEnv("CORS_ALLOW_CREDENTIALS", false),
+		CORSMaxAge:             time.Duration(parseIntEnv("CORS_MAX_AGE_SECONDS", 600)) * time.Second,
+		ShareLinks:             parseBoolEnv("SHARE_LINKS", false),
+		ShareLinkSecret:        rawEnv("SHARE_LINK_SECRET"),
+		ShareLinkMaxTTL:        time.Duration(parseIntEnv("SHARE_LINK_MAX_TTL_MINUTES", 1440)) * time.Minute,
+		RBACDefaultRole:        stringEnv("RBAC_DEFAULT_ROLE", ""),
+		RBACViewers:            parseListEnv("RBAC_VIEWERS"),
+		RBACOperators:          parseListEnv("RBAC_OPERATORS"),
+		RBACRoleHeader:         stringEnv("RBAC_ROLE_HEADER", ""),
+		StoreJanitorInterval:   time.Duration(parseNonNegIntEnv("STORE_JANITOR_INTERVAL_SECONDS", 600)) * time.Second,
+		TelemetryEnabled:       parseBoolEnv("TELEMETRY_ENABLED", false),
+		TelemetryEndpoint:      stringEnv("TELEMETRY_ENDPOINT", ""),
+		TelemetryInterval:      time.Duration(parseIntEnv("TELEMETRY_INTERVAL_HOURS", 24)) * time.Hour,
+		UpdateCheck:            parseBoolEnv("UPDATE_CHECK_ENABLED", true),
+		UpdateCheckURL:         stringEnv("UPDATE_CHECK_URL", "https://api.github.com/repos/pachecoc/sqs-ui/releases/latest"),
+		UpdateCheckTTL:         time.Duration(parseIntEnv("UPDATE_CHECK_CACHE_HOURS", 6)) * time.Hour,
+		ScratchPrefix:          stringEnv("SCRATCH_QUEUE_PREFIX", "sqs-ui-scratch-"),
+		ScratchTTL:             time.Duration(parseIntEnv("SCRATCH_QUEUE_TTL_MINUTES", 60)) * time.Minute,
+		ScratchMaxTTL:          time.Duration(parseIntEnv("SCRATCH_QUEUE_MAX_TTL_HOURS", 24)) * time.Hour,
+		ScratchReapInterval:    time.Duration(parseIntEnv("SCRATCH_REAP_INTERVAL_SECONDS", 300)) * time.Second,
+	}
+}
+
+// vars records each variable Load reads with its default and effective value, in reading order.
+var vars []envVar
+
+type envVar struct{ key, def, value string }
+
+func record(k, def, value string) {
+	for i, v := range vars {
+		if v.key == k {
+			vars[i].value = value
+			return
+		}
 	}
+	vars = append(vars, envVar{k, def, value})
 }
 
-func parseIntEnv(k string, def int) int {
+// secretPattern matches variables whose values are never logged. URLs are included
+// because webhook and Redis URLs carry credentials.
+var secretPattern = regexp.MustCompile(`SECRET|PASSWORD|TOKEN|_URL$|_KEYS$`)
+
+// Effective returns the settings read by the last Load as KEY: value, for the startup
+// record. Secrets that are set show as "[redacted]"; QUEUE_URL is kept for support.
+func Effective() map[string]string {
+	out := make(map[string]string, len(vars))
+	for _, v := range vars {
+		if v.value != "" && v.key != "QUEUE_URL" && secretPattern.MatchString(v.key) {
+			out[v.key] = "[redacted]"
+			continue
+		}
+		out[v.key] = v.value
+	}
+	return out
+}
+
+// WriteDefaults writes every supported variable with its default as an env file.
+// Variables without a default are commented out, so the output can be passed to
+// `docker run --env-file` as-is.
+func WriteDefaults(w io.Writer) error {
+	Load(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	for _, v := range vars {
+		line := v.key + "=" + v.def
+		if v.def == "" {
+			line = "# " + line
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// stringEnv returns the trimmed variable, or def when it is empty.
+func stringEnv(k, def string) string {
+	v := strings.TrimSpace(os.Getenv(k))
+	if v == "" {
+		v = def
+	}
+	record(k, def, v)
+	return v
+}
+
+// rawEnv returns the variable untrimmed (secrets, rule lists).
+func rawEnv(k string) string {
+	v := os.Getenv(k)
+	record(k, "", v)
+	return v
+}
+
+func parseIntEnv(k string, def int) (n int) {
+	defer func() { record(k, strconv.Itoa(def), strconv.Itoa(n)) }()
 	// Safe integer parser with fallback
 	v := os.Getenv(k)
 	if v == "" {
 		return def
 	}
-	n, err := strconv.Atoi(v)
-	if err != nil || n <= 0 {
+	parsed, err := strconv.Atoi(v)
+	if err != nil || parsed <= 0 {
+		return def
+	}
+	return parsed
+}
+
+// parseNonNegIntEnv is parseIntEnv for settings where 0 means off, so 0 is kept.
+func parseNonNegIntEnv(k string, def int) (n int) {
+	defer func() { record(k, strconv.Itoa(def), strconv.Itoa(n)) }()
+	v := os.Getenv(k)
+	if v == "" {
+		return def
+	}
+	parsed, err := strconv.Atoi(v)
+	if err != nil || parsed < 0 {
+		return def
+	}
+	return parsed
+}
+
+// parseListEnv splits a comma-separated variable, dropping empty entries.
+func parseListEnv(k string) (out []string) {
+	defer func() { record(k, "", strings.Join(out, ",")) }()
+	for _, v := range strings.Split(os.Getenv(k), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// listEnvOr is parseListEnv with a default for when the variable is empty.
+func listEnvOr(k string, def ...string) (out []string) {
+	defer func() { record(k, strings.Join(def, ","), strings.Join(out, ",")) }()
+	for _, v := range strings.Split(os.Getenv(k), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	if len(out) == 0 {
 		return def
 	}
-	return n
+	return out
 }
 
-func parseBoolEnv(k string, def bool) bool {
+func parseBoolEnv(k string, def bool) (b bool) {
+	defer func() { record(k, strconv.FormatBool(def), strconv.FormatBool(b)) }()
 	v := strings.ToLower(os.Getenv(k))
 	if v == "" {
 		return def
@@ -0,0 +1,36 @@
+package settings
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+// TestZeroDisables checks that settings documented as "0 disables" keep a 0, and that a
+// negative or malformed value still falls back to the default.
+func TestZeroDisables(t *testing.T) {
+	tests := []struct {
+		env string
+		get func(AppConfig) time.Duration
+		def time.Duration
+	}{
+		{"DEPTH_HISTORY_INTERVAL_SECONDS", func(c AppConfig) time.Duration { return c.DepthHistoryInterval }, 30 * time.Second},
+		{"STORE_JANITOR_INTERVAL_SECONDS", func(c AppConfig) time.Duration { return c.StoreJanitorInterval }, 10 * time.Minute},
+		{"SECRETS_REFRESH_SECONDS", func(c AppConfig) time.Duration { return c.SecretsRefresh }, 5 * time.Minute},
+		{"QUEUE_REF_REFRESH_SECONDS", func(c AppConfig) time.Duration { return c.QueueRefRefresh }, 5 * time.Minute},
+		{"ATTRIBUTE_CACHE_SECONDS", func(c AppConfig) time.Duration { return c.AttributeCacheTTL }, 15 * time.Second},
+		{"STORE_KEY_ROTATION_DAYS", func(c AppConfig) time.Duration { return c.StoreKeyRotation }, 90 * 24 * time.Hour},
+	}
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	for _, tt := range tests {
+		t.Run(tt.env, func(t *testing.T) {
+			for value, want := range map[string]time.Duration{"0": 0, "-1": tt.def, "soon": tt.def, "": tt.def} {
+				t.Setenv(tt.env, value)
+				if got := tt.get(Load(log)); got != want {
+					t.Errorf("%s=%q: got %v, want %v", tt.env, value, got, want)
+				}
+			}
+		})
+	}
+}
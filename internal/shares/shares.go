@@ -0,0 +1,164 @@
+// Package shares issues short-lived signed links that let someone without an account read one
+// queue (a peek) or one pinned receive snapshot. A link's token carries what it grants and when
+// it expires, signed with HMAC-SHA256; its record in the store lists it and lets it be revoked
+// before then.
+package shares
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pachecoc/sqs-ui/internal/secrets"
+	"github.com/pachecoc/sqs-ui/internal/store"
+)
+
+// category is the store category holding share records.
+const category = "shares"
+
+// DefaultTTL is how long a link lasts when none is asked for.
+const DefaultTTL = time.Hour
+
+var (
+	// ErrInvalid is returned for a token that wasn't issued by this server.
+	ErrInvalid = errors.New("the share link is not valid")
+	// ErrExpired is returned for a link past its expiry.
+	ErrExpired = errors.New("the share link expired")
+	// ErrRevoked is returned for a link revoked before its expiry.
+	ErrRevoked = errors.New("the share link was revoked")
+)
+
+// Share is what a link grants: a peek at QueueName, or the receive snapshot SnapshotID of it.
+type Share struct {
+	ID         string    `json:"id"`
+	QueueName  string    `json:"queue_name"`
+	SnapshotID string    `json:"snapshot_id,omitempty"`
+	Note       string    `json:"note,omitempty"`
+	CreatedBy  string    `json:"created_by,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// claims is the signed part of a token.
+type claims struct {
+	ID         string `json:"id"`
+	QueueName  string `json:"q"`
+	SnapshotID string `json:"s,omitempty"`
+	ExpiresAt  int64  `json:"exp"`
+}
+
+// Manager issues and opens share links.
+type Manager struct {
+	Store store.Store
+	// Secret signs tokens; share it between replicas. When unset, a random key is used and
+	// links stop working when the process restarts.
+	Secret *secrets.Value
+
+	keyOnce sync.Once
+	key     []byte
+}
+
+func (m *Manager) signingKey() []byte {
+	if m.Secret.IsSet() {
+		sum := sha256.Sum256([]byte(m.Secret.Get()))
+		return sum[:]
+	}
+	m.keyOnce.Do(func() {
+		m.key = make([]byte, 32)
+		_, _ = rand.Read(m.key)
+	})
+	return m.key
+}
+
+func (m *Manager) sign(payload string) string {
+	mac := hmac.New(sha256.New, m.signingKey())
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Create records s for ttl (filling in its ID, CreatedAt and ExpiresAt) and returns it with
+// the token that opens it.
+func (m *Manager) Create(ctx context.Context, s Share, ttl time.Duration) (Share, string, error) {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	id := make([]byte, 12)
+	if _, err := rand.Read(id); err != nil {
+		return Share{}, "", err
+	}
+	now := time.Now().UTC().Truncate(time.Second)
+	s.ID = hex.EncodeToString(id)
+	s.CreatedAt = now
+	s.ExpiresAt = now.Add(ttl)
+	if err := store.PutJSON(ctx, m.Store, category, s.ID, s, ttl); err != nil {
+		return Share{}, "", err
+	}
+	b, err := json.Marshal(claims{ID: s.ID, QueueName: s.QueueName, SnapshotID: s.SnapshotID, ExpiresAt: s.ExpiresAt.Unix()})
+	if err != nil {
+		return Share{}, "", err
+	}
+	payload := base64.RawURLEncoding.EncodeToString(b)
+	return s, payload + "." + m.sign(payload), nil
+}
+
+// Open checks token's signature and expiry and that it wasn't revoked, and returns its share.
+func (m *Manager) Open(ctx context.Context, token string) (Share, error) {
+	payload, sig, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(sig), []byte(m.sign(payload))) {
+		return Share{}, ErrInvalid
+	}
+	b, err := base64.RawURLEncoding.DecodeString(payload)
+	var c claims
+	if err != nil || json.Unmarshal(b, &c) != nil {
+		return Share{}, ErrInvalid
+	}
+	if time.Now().Unix() >= c.ExpiresAt {
+		return Share{}, ErrExpired
+	}
+	var s Share
+	if err := store.GetJSON(ctx, m.Store, category, c.ID, &s); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return Share{}, ErrRevoked
+		}
+		return Share{}, err
+	}
+	if s.QueueName != c.QueueName || s.SnapshotID != c.SnapshotID {
+		return Share{}, ErrInvalid
+	}
+	return s, nil
+}
+
+// List returns the live links, newest first.
+func (m *Manager) List(ctx context.Context) ([]Share, error) {
+	keys, err := m.Store.List(ctx, category)
+	if err != nil {
+		return nil, err
+	}
+	out := []Share{}
+	for _, k := range keys {
+		var s Share
+		if err := store.GetJSON(ctx, m.Store, category, k, &s); err == nil {
+			out = append(out, s)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	return out, nil
+}
+
+// Revoke ends a link before its expiry (store.ErrNotFound when there's no such live link).
+func (m *Manager) Revoke(ctx context.Context, id string) error {
+	// Store deletes succeed for missing keys, so look the link up first
+	if _, err := m.Store.Get(ctx, category, id); err != nil {
+		return err
+	}
+	return m.Store.Delete(ctx, category, id)
+}
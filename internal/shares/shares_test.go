@@ -0,0 +1,99 @@
+package shares
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pachecoc/sqs-ui/internal/secrets"
+	"github.com/pachecoc/sqs-ui/internal/store"
+)
+
+// token signs c with m's key, as Create would.
+func token(m *Manager, c claims) string {
+	b, _ := json.Marshal(c)
+	payload := base64.RawURLEncoding.EncodeToString(b)
+	return payload + "." + m.sign(payload)
+}
+
+func TestOpen(t *testing.T) {
+	ctx := context.Background()
+	m := &Manager{Store: store.NewMemory(), Secret: secrets.Static("share-secret")}
+	s, tok, err := m.Create(ctx, Share{QueueName: "orders", CreatedBy: "ada"}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, sig, _ := strings.Cut(tok, ".")
+	other := &Manager{Store: m.Store, Secret: secrets.Static("another-secret")}
+	_, otherTok, err := other.Create(ctx, Share{QueueName: "orders"}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		want  error
+	}{
+		{"issued", tok, nil},
+		{"no signature", payload, ErrInvalid},
+		{"forged signature", payload + "." + strings.Repeat("A", len(sig)), ErrInvalid},
+		{"signed by another key", otherTok, ErrInvalid},
+		{"queue changed", swapPayload(tok, claims{ID: s.ID, QueueName: "payments", ExpiresAt: s.ExpiresAt.Unix()}), ErrInvalid},
+		{"expiry extended", swapPayload(tok, claims{ID: s.ID, QueueName: "orders", ExpiresAt: s.ExpiresAt.Add(time.Hour).Unix()}), ErrInvalid},
+		{"signed claims for another queue", token(m, claims{ID: s.ID, QueueName: "payments", ExpiresAt: s.ExpiresAt.Unix()}), ErrInvalid},
+		{"expired", token(m, claims{ID: s.ID, QueueName: "orders", ExpiresAt: time.Now().Add(-time.Second).Unix()}), ErrExpired},
+		{"unknown id", token(m, claims{ID: "0123", QueueName: "orders", ExpiresAt: s.ExpiresAt.Unix()}), ErrRevoked},
+		{"not base64", "%%%." + m.sign("%%%"), ErrInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := m.Open(ctx, tt.token)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("got %v, want %v", err, tt.want)
+			}
+			if err == nil && (got.ID != s.ID || got.QueueName != "orders" || got.CreatedBy != "ada") {
+				t.Errorf("opened %+v, want %+v", got, s)
+			}
+		})
+	}
+}
+
+// swapPayload replaces tok's claims but keeps its signature.
+func swapPayload(tok string, c claims) string {
+	_, sig, _ := strings.Cut(tok, ".")
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b) + "." + sig
+}
+
+func TestRevoke(t *testing.T) {
+	ctx := context.Background()
+	m := &Manager{Store: store.NewMemory()}
+	s, tok, err := m.Create(ctx, Share{QueueName: "orders"}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := s.ExpiresAt.Sub(s.CreatedAt); got != DefaultTTL {
+		t.Errorf("lifetime %s, want the default %s", got, DefaultTTL)
+	}
+	if list, err := m.List(ctx); err != nil || len(list) != 1 {
+		t.Fatalf("List: %v, %v", list, err)
+	}
+
+	if err := m.Revoke(ctx, s.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Open(ctx, tok); !errors.Is(err, ErrRevoked) {
+		t.Errorf("revoked link: got %v, want ErrRevoked", err)
+	}
+	if list, err := m.List(ctx); err != nil || len(list) != 0 {
+		t.Errorf("List after revoking: %v, %v", list, err)
+	}
+	if err := m.Revoke(ctx, s.ID); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("second revoke: got %v, want store.ErrNotFound", err)
+	}
+}
@@ -0,0 +1,240 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	ddbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// DynamoDBAPI is the part of the DynamoDB client the store uses.
+type DynamoDBAPI interface {
+	PutItem(ctx context.Context, in *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error)
+	GetItem(ctx context.Context, in *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error)
+	DeleteItem(ctx context.Context, in *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error)
+	Query(ctx context.Context, in *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error)
+	Scan(ctx context.Context, in *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error)
+}
+
+// Item attributes. The table's partition key is category and its sort key is key, both
+// strings; expires_at (epoch seconds) is meant as the table's TTL attribute. Expressions
+// refer to them through placeholders, since key, value, size and owner are reserved words.
+const (
+	ddbCategory  = "category"
+	ddbKey       = "key"
+	ddbValue     = "value"
+	ddbSize      = "size"
+	ddbModified  = "modified_ms"
+	ddbExpiresMS = "expires_ms"
+	ddbExpiresAt = "expires_at"
+	ddbOwner     = "owner"
+)
+
+// DynamoDB is a Store shared by all replicas using the same table. Each record is one
+// item; DynamoDB's TTL deletes expired items within a few days, so reads skip items past
+// expires_ms themselves.
+type DynamoDB struct {
+	client DynamoDBAPI
+	table  string
+}
+
+// NewDynamoDB returns a store on an existing table (see DynamoDB for its key schema).
+func NewDynamoDB(client DynamoDBAPI, table string) (*DynamoDB, error) {
+	if table == "" {
+		return nil, errors.New("a table name is required for the dynamodb store")
+	}
+	return &DynamoDB{client: client, table: table}, nil
+}
+
+func (d *DynamoDB) Put(ctx context.Context, category, key string, value []byte, ttl time.Duration) error {
+	item := d.itemKey(category, key)
+	item[ddbValue] = &ddbtypes.AttributeValueMemberB{Value: value}
+	item[ddbSize] = ddbNumber(int64(len(value)))
+	item[ddbModified] = ddbNumber(time.Now().UnixMilli())
+	if at := expiry(ttl); !at.IsZero() {
+		item[ddbExpiresMS] = ddbNumber(at.UnixMilli())
+		item[ddbExpiresAt] = ddbNumber(at.Unix())
+	}
+	_, err := d.client.PutItem(ctx, &dynamodb.PutItemInput{TableName: aws.String(d.table), Item: item})
+	return err
+}
+
+func (d *DynamoDB) Get(ctx context.Context, category, key string) ([]byte, error) {
+	out, err := d.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(d.table),
+		Key:            d.itemKey(category, key),
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if out.Item == nil || ddbExpired(out.Item) {
+		return nil, ErrNotFound
+	}
+	v, _ := out.Item[ddbValue].(*ddbtypes.AttributeValueMemberB)
+	if v == nil {
+		return nil, fmt.Errorf("dynamodb item %s/%s has no binary value", category, key)
+	}
+	return v.Value, nil
+}
+
+func (d *DynamoDB) Delete(ctx context.Context, category, key string) error {
+	_, err := d.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{TableName: aws.String(d.table), Key: d.itemKey(category, key)})
+	return err
+}
+
+func (d *DynamoDB) List(ctx context.Context, category string) ([]string, error) {
+	infos, err := d.Inspect(ctx, category)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, len(infos))
+	for i, info := range infos {
+		keys[i] = info.Key
+	}
+	return keys, nil
+}
+
+func (d *DynamoDB) Close() error { return nil }
+
+// Categories implements Inspector. It scans the whole table, reading only the category and
+// expiry of each item.
+func (d *DynamoDB) Categories(ctx context.Context) ([]string, error) {
+	seen := make(map[string]bool)
+	p := dynamodb.NewScanPaginator(d.client, &dynamodb.ScanInput{
+		TableName:                aws.String(d.table),
+		ProjectionExpression:     aws.String("#c, #exp"),
+		ExpressionAttributeNames: map[string]string{"#c": ddbCategory, "#exp": ddbExpiresMS},
+	})
+	for p.HasMorePages() {
+		out, err := p.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range out.Items {
+			if c := ddbString(item, ddbCategory); c != "" && !ddbExpired(item) {
+				seen[c] = true
+			}
+		}
+	}
+	cats := make([]string, 0, len(seen))
+	for c := range seen {
+		cats = append(cats, c)
+	}
+	sort.Strings(cats)
+	return cats, nil
+}
+
+// Inspect implements Inspector. Items come back sorted by key, without their values.
+func (d *DynamoDB) Inspect(ctx context.Context, category string) ([]RecordInfo, error) {
+	p := dynamodb.NewQueryPaginator(d.client, &dynamodb.QueryInput{
+		TableName:                 aws.String(d.table),
+		KeyConditionExpression:    aws.String("#c = :c"),
+		ProjectionExpression:      aws.String("#k, #sz, #m, #exp"),
+		ExpressionAttributeNames:  map[string]string{"#c": ddbCategory, "#k": ddbKey, "#sz": ddbSize, "#m": ddbModified, "#exp": ddbExpiresMS},
+		ExpressionAttributeValues: map[string]ddbtypes.AttributeValue{":c": &ddbtypes.AttributeValueMemberS{Value: category}},
+		ConsistentRead:            aws.Bool(true),
+	})
+	var infos []RecordInfo
+	for p.HasMorePages() {
+		out, err := p.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range out.Items {
+			if ddbExpired(item) {
+				continue
+			}
+			info := RecordInfo{Key: ddbString(item, ddbKey), Size: ddbInt(item, ddbSize)}
+			if ms := ddbInt(item, ddbModified); ms > 0 {
+				info.Modified = time.UnixMilli(ms)
+			}
+			if ms := ddbInt(item, ddbExpiresMS); ms > 0 {
+				info.ExpiresAt = time.UnixMilli(ms)
+			}
+			infos = append(infos, info)
+		}
+	}
+	return infos, nil
+}
+
+// AcquireLease implements Leaser with a conditional write: it succeeds when no lease item
+// exists, owner holds it, or it has expired.
+func (d *DynamoDB) AcquireLease(ctx context.Context, name, owner string, ttl time.Duration) (bool, error) {
+	now := time.Now()
+	at := now.Add(ttl)
+	item := d.itemKey(categoryLeases, name)
+	item[ddbOwner] = &ddbtypes.AttributeValueMemberS{Value: owner}
+	item[ddbExpiresMS] = ddbNumber(at.UnixMilli())
+	item[ddbExpiresAt] = ddbNumber(at.Unix())
+	_, err := d.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:                aws.String(d.table),
+		Item:                     item,
+		ConditionExpression:      aws.String("attribute_not_exists(#k) OR #o = :o OR #exp < :now"),
+		ExpressionAttributeNames: map[string]string{"#k": ddbKey, "#o": ddbOwner, "#exp": ddbExpiresMS},
+		ExpressionAttributeValues: map[string]ddbtypes.AttributeValue{
+			":o":   &ddbtypes.AttributeValueMemberS{Value: owner},
+			":now": ddbNumber(now.UnixMilli()),
+		},
+	})
+	var held *ddbtypes.ConditionalCheckFailedException
+	if errors.As(err, &held) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// ReleaseLease implements Leaser.
+func (d *DynamoDB) ReleaseLease(ctx context.Context, name, owner string) error {
+	_, err := d.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName:                 aws.String(d.table),
+		Key:                       d.itemKey(categoryLeases, name),
+		ConditionExpression:       aws.String("#o = :o"),
+		ExpressionAttributeNames:  map[string]string{"#o": ddbOwner},
+		ExpressionAttributeValues: map[string]ddbtypes.AttributeValue{":o": &ddbtypes.AttributeValueMemberS{Value: owner}},
+	})
+	var notHeld *ddbtypes.ConditionalCheckFailedException
+	if errors.As(err, &notHeld) {
+		return nil
+	}
+	return err
+}
+
+func (d *DynamoDB) itemKey(category, key string) map[string]ddbtypes.AttributeValue {
+	return map[string]ddbtypes.AttributeValue{
+		ddbCategory: &ddbtypes.AttributeValueMemberS{Value: category},
+		ddbKey:      &ddbtypes.AttributeValueMemberS{Value: key},
+	}
+}
+
+func ddbNumber(n int64) *ddbtypes.AttributeValueMemberN {
+	return &ddbtypes.AttributeValueMemberN{Value: strconv.FormatInt(n, 10)}
+}
+
+func ddbString(item map[string]ddbtypes.AttributeValue, name string) string {
+	v, _ := item[name].(*ddbtypes.AttributeValueMemberS)
+	if v == nil {
+		return ""
+	}
+	return v.Value
+}
+
+func ddbInt(item map[string]ddbtypes.AttributeValue, name string) int64 {
+	v, _ := item[name].(*ddbtypes.AttributeValueMemberN)
+	if v == nil {
+		return 0
+	}
+	n, _ := strconv.ParseInt(v.Value, 10, 64)
+	return n
+}
+
+func ddbExpired(item map[string]ddbtypes.AttributeValue) bool {
+	ms := ddbInt(item, ddbExpiresMS)
+	return ms > 0 && time.Now().UnixMilli() >= ms
+}
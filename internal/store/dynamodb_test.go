@@ -0,0 +1,199 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	ddbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// fakeDynamoDB is a table keyed by category and key that evaluates the two condition
+// expressions the store writes, and returns query and scan results two items per page.
+type fakeDynamoDB struct {
+	mu    sync.Mutex
+	items map[[2]string]map[string]ddbtypes.AttributeValue
+}
+
+const fakeDynamoDBPage = 2
+
+func newFakeDynamoDB() *fakeDynamoDB {
+	return &fakeDynamoDB{items: make(map[[2]string]map[string]ddbtypes.AttributeValue)}
+}
+
+func fakeItemKey(item map[string]ddbtypes.AttributeValue) [2]string {
+	return [2]string{ddbString(item, ddbCategory), ddbString(item, ddbKey)}
+}
+
+func (f *fakeDynamoDB) PutItem(_ context.Context, in *dynamodb.PutItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	k := fakeItemKey(in.Item)
+	switch cond := aws.ToString(in.ConditionExpression); cond {
+	case "":
+	case "attribute_not_exists(#k) OR #o = :o OR #exp < :now":
+		cur, ok := f.items[k]
+		owner := in.ExpressionAttributeValues[":o"].(*ddbtypes.AttributeValueMemberS).Value
+		now := ddbInt(in.ExpressionAttributeValues, ":now")
+		if ok && ddbString(cur, ddbOwner) != owner && ddbInt(cur, ddbExpiresMS) >= now {
+			return nil, &ddbtypes.ConditionalCheckFailedException{Message: aws.String("held")}
+		}
+	default:
+		return nil, errors.New("fake: unsupported condition " + cond)
+	}
+	f.items[k] = in.Item
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+func (f *fakeDynamoDB) GetItem(_ context.Context, in *dynamodb.GetItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return &dynamodb.GetItemOutput{Item: f.items[fakeItemKey(in.Key)]}, nil
+}
+
+func (f *fakeDynamoDB) DeleteItem(_ context.Context, in *dynamodb.DeleteItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	k := fakeItemKey(in.Key)
+	switch cond := aws.ToString(in.ConditionExpression); cond {
+	case "":
+	case "#o = :o":
+		owner := in.ExpressionAttributeValues[":o"].(*ddbtypes.AttributeValueMemberS).Value
+		if cur, ok := f.items[k]; !ok || ddbString(cur, ddbOwner) != owner {
+			return nil, &ddbtypes.ConditionalCheckFailedException{Message: aws.String("not held")}
+		}
+	default:
+		return nil, errors.New("fake: unsupported condition " + cond)
+	}
+	delete(f.items, k)
+	return &dynamodb.DeleteItemOutput{}, nil
+}
+
+func (f *fakeDynamoDB) Query(_ context.Context, in *dynamodb.QueryInput, _ ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	category := in.ExpressionAttributeValues[":c"].(*ddbtypes.AttributeValueMemberS).Value
+	items, last := f.page(func(k [2]string) bool { return k[0] == category }, in.ExclusiveStartKey)
+	return &dynamodb.QueryOutput{Items: items, LastEvaluatedKey: last}, nil
+}
+
+func (f *fakeDynamoDB) Scan(_ context.Context, in *dynamodb.ScanInput, _ ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+	items, last := f.page(func([2]string) bool { return true }, in.ExclusiveStartKey)
+	return &dynamodb.ScanOutput{Items: items, LastEvaluatedKey: last}, nil
+}
+
+// page returns the next page of matching items after start, in key order.
+func (f *fakeDynamoDB) page(match func([2]string) bool, start map[string]ddbtypes.AttributeValue) ([]map[string]ddbtypes.AttributeValue, map[string]ddbtypes.AttributeValue) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var keys [][2]string
+	for k := range f.items {
+		if match(k) && (start == nil || less(fakeItemKey(start), k)) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return less(keys[i], keys[j]) })
+	var items []map[string]ddbtypes.AttributeValue
+	for _, k := range keys {
+		if len(items) == fakeDynamoDBPage {
+			return items, items[len(items)-1]
+		}
+		items = append(items, f.items[k])
+	}
+	return items, nil
+}
+
+func less(a, b [2]string) bool {
+	return a[0] < b[0] || (a[0] == b[0] && a[1] < b[1])
+}
+
+func TestDynamoDBRecords(t *testing.T) {
+	ctx := context.Background()
+	d, err := NewDynamoDB(newFakeDynamoDB(), "sqs-ui")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	puts := []struct {
+		category, key string
+		ttl           time.Duration
+	}{
+		{"jobs", "c", 0}, {"jobs", "a", time.Minute}, {"jobs", "b", 0},
+		{"jobs", "gone", time.Millisecond}, {"artifacts", "x", 0}, {"old", "y", time.Millisecond},
+	}
+	for _, p := range puts {
+		if err := d.Put(ctx, p.category, p.key, []byte("value "+p.key), p.ttl); err != nil {
+			t.Fatalf("put %s/%s: %v", p.category, p.key, err)
+		}
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	if got, err := d.Get(ctx, "jobs", "a"); err != nil || string(got) != "value a" {
+		t.Errorf("get a: %q, %v", got, err)
+	}
+	if _, err := d.Get(ctx, "jobs", "gone"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("get expired: %v, want ErrNotFound", err)
+	}
+	if _, err := d.Get(ctx, "jobs", "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("get missing: %v, want ErrNotFound", err)
+	}
+	// Three pages of the fake, with the expired record skipped
+	if keys, err := d.List(ctx, "jobs"); err != nil || !reflect.DeepEqual(keys, []string{"a", "b", "c"}) {
+		t.Errorf("list: %q, %v", keys, err)
+	}
+	infos, err := d.Inspect(ctx, "jobs")
+	if err != nil || len(infos) != 3 {
+		t.Fatalf("inspect: %+v, %v", infos, err)
+	}
+	if a := infos[0]; a.Size != int64(len("value a")) || a.Modified.IsZero() || a.ExpiresAt.IsZero() || !infos[1].ExpiresAt.IsZero() {
+		t.Errorf("inspect: %+v", infos)
+	}
+	if cats, err := d.Categories(ctx); err != nil || !reflect.DeepEqual(cats, []string{"artifacts", "jobs"}) {
+		t.Errorf("categories: %q, %v", cats, err)
+	}
+
+	if err := d.Delete(ctx, "jobs", "a"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.Get(ctx, "jobs", "a"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("get after delete: %v", err)
+	}
+}
+
+func TestDynamoDBLeases(t *testing.T) {
+	ctx := context.Background()
+	d, err := NewDynamoDB(newFakeDynamoDB(), "sqs-ui")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ok, err := d.AcquireLease(ctx, "leader", "one", time.Minute); err != nil || !ok {
+		t.Fatalf("first acquire: %v, %v", ok, err)
+	}
+	if ok, err := d.AcquireLease(ctx, "leader", "two", time.Minute); err != nil || ok {
+		t.Errorf("a second owner took a held lease: %v, %v", ok, err)
+	}
+	if ok, err := d.AcquireLease(ctx, "leader", "one", time.Minute); err != nil || !ok {
+		t.Errorf("owner couldn't renew: %v, %v", ok, err)
+	}
+	if err := d.ReleaseLease(ctx, "leader", "two"); err != nil {
+		t.Errorf("releasing a lease held by another owner: %v", err)
+	}
+	if ok, _ := d.AcquireLease(ctx, "leader", "two", time.Minute); ok {
+		t.Error("another owner's release dropped the lease")
+	}
+	if err := d.ReleaseLease(ctx, "leader", "one"); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := d.AcquireLease(ctx, "leader", "two", time.Millisecond); err != nil || !ok {
+		t.Fatalf("acquire after release: %v, %v", ok, err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	if ok, err := d.AcquireLease(ctx, "leader", "one", time.Minute); err != nil || !ok {
+		t.Errorf("an expired lease wasn't taken over: %v, %v", ok, err)
+	}
+}
@@ -0,0 +1,196 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// sealedMagic starts every encrypted value; values without it predate encryption and are
+// returned as stored.
+var sealedMagic = []byte("sqe1")
+
+const keyIDLen = 8
+
+// Keyring holds the AES-256 keys values are sealed with. New values use the current key;
+// older keys stay available for reading what they sealed, which is how keys are rotated.
+type Keyring struct {
+	mu      sync.RWMutex
+	current []byte
+	keys    map[string]cipher.AEAD // by key ID
+
+	// Reload, when set, is called once when a value was sealed with an unknown key, e.g.
+	// a data key another replica created after this one started.
+	Reload func(ctx context.Context) error
+}
+
+// NewKeyring returns an empty keyring.
+func NewKeyring() *Keyring {
+	return &Keyring{keys: make(map[string]cipher.AEAD)}
+}
+
+// ParseKeys reads a comma-separated list of base64-encoded 32-byte keys. The first one is
+// current; list the previous keys after it while values sealed with them are still kept.
+func ParseKeys(s string) (*Keyring, error) {
+	k := NewKeyring()
+	for i, part := range strings.Split(s, ",") {
+		key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(part))
+		if err != nil {
+			return nil, fmt.Errorf("key %d is not valid base64: %w", i+1, err)
+		}
+		if err := k.Add(key, i == 0); err != nil {
+			return nil, fmt.Errorf("key %d: %w", i+1, err)
+		}
+	}
+	return k, nil
+}
+
+// Add adds a 32-byte key, making it the current one when current is set.
+func (k *Keyring) Add(key []byte, current bool) error {
+	if len(key) != 32 {
+		return fmt.Errorf("key must be 32 bytes, got %d", len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return err
+	}
+	id := keyID(key)
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.keys[string(id)] = aead
+	if current {
+		k.current = id
+	}
+	return nil
+}
+
+// CurrentID returns the hex ID of the current key (for logs).
+func (k *Keyring) CurrentID() string {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return hex.EncodeToString(k.current)
+}
+
+// keyID identifies a key without revealing it.
+func keyID(key []byte) []byte {
+	sum := sha256.Sum256(key)
+	return sum[:keyIDLen]
+}
+
+func (k *Keyring) lookup(id []byte) (cipher.AEAD, bool) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	aead, ok := k.keys[string(id)]
+	return aead, ok
+}
+
+// seal encrypts value; aad binds it to its category and key.
+func (k *Keyring) seal(value, aad []byte) ([]byte, error) {
+	k.mu.RLock()
+	id := k.current
+	aead := k.keys[string(id)]
+	k.mu.RUnlock()
+	if aead == nil {
+		return nil, errors.New("store encryption has no current key")
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	out := make([]byte, 0, len(sealedMagic)+keyIDLen+len(nonce)+len(value)+aead.Overhead())
+	out = append(append(append(out, sealedMagic...), id...), nonce...)
+	return aead.Seal(out, nonce, value, aad), nil
+}
+
+// open decrypts a sealed value, reloading the keyring once for an unknown key.
+func (k *Keyring) open(ctx context.Context, data, aad []byte) ([]byte, error) {
+	rest := data[len(sealedMagic):]
+	if len(rest) < keyIDLen {
+		return nil, errors.New("sealed store record is truncated")
+	}
+	id, rest := rest[:keyIDLen], rest[keyIDLen:]
+	aead, ok := k.lookup(id)
+	if !ok && k.Reload != nil {
+		if err := k.Reload(ctx); err != nil {
+			return nil, fmt.Errorf("failed to reload store keys: %w", err)
+		}
+		aead, ok = k.lookup(id)
+	}
+	if !ok {
+		return nil, fmt.Errorf("store record was sealed with unknown key %x; keep retired keys configured", id)
+	}
+	if len(rest) < aead.NonceSize() {
+		return nil, errors.New("sealed store record is truncated")
+	}
+	plain, err := aead.Open(nil, rest[:aead.NonceSize()], rest[aead.NonceSize():], aad)
+	if err != nil {
+		return nil, fmt.Errorf("store record failed authentication: %w", err)
+	}
+	return plain, nil
+}
+
+// Encrypted seals every value of the wrapped store with AES-256-GCM, so snapshots, job
+// results and notes holding message payloads are unreadable at rest. Keys and categories
+// stay in the clear; each value is bound to them, so records can't be swapped.
+type Encrypted struct {
+	Store
+	Keys *Keyring
+}
+
+// NewEncrypted wraps s. The result is a Leaser when s is; lease records are not encrypted.
+func NewEncrypted(s Store, keys *Keyring) Store {
+	e := &Encrypted{Store: s, Keys: keys}
+	if l, ok := s.(Leaser); ok {
+		return &encryptedLeaser{Encrypted: e, Leaser: l}
+	}
+	return e
+}
+
+type encryptedLeaser struct {
+	*Encrypted
+	Leaser
+}
+
+func (e *Encrypted) Put(ctx context.Context, category, key string, value []byte, ttl time.Duration) error {
+	sealed, err := e.Keys.seal(value, recordAAD(category, key))
+	if err != nil {
+		return err
+	}
+	return e.Store.Put(ctx, category, key, sealed, ttl)
+}
+
+func (e *Encrypted) Get(ctx context.Context, category, key string) ([]byte, error) {
+	data, err := e.Store.Get(ctx, category, key)
+	if err != nil || !bytes.HasPrefix(data, sealedMagic) {
+		return data, err
+	}
+	return e.Keys.open(ctx, data, recordAAD(category, key))
+}
+
+func recordAAD(category, key string) []byte {
+	return []byte(category + "\x00" + key)
+}
+
+// Categories implements Inspector for the wrapped store.
+func (e *Encrypted) Categories(ctx context.Context) ([]string, error) {
+	return Categories(ctx, e.Store)
+}
+
+// Inspect implements Inspector; sizes are those of the sealed values.
+func (e *Encrypted) Inspect(ctx context.Context, category string) ([]RecordInfo, error) {
+	return Inspect(ctx, e.Store, category)
+}
@@ -0,0 +1,279 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// fileRecord is the on-disk layout of one entry.
+type fileRecord struct {
+	ExpiresAt time.Time `json:"expires_at,omitempty"`
+	Value     []byte    `json:"value"`
+}
+
+// File stores each record as a JSON file under <dir>/<category>/<key>.json.
+type File struct {
+	dir string
+}
+
+// NewFile creates (if needed) and opens a directory-backed store.
+func NewFile(dir string) (*File, error) {
+	if dir == "" {
+		return nil, errors.New("data directory is required for the file store")
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create data directory: %w", err)
+	}
+	return &File{dir: dir}, nil
+}
+
+func (f *File) Put(_ context.Context, category, key string, value []byte, ttl time.Duration) error {
+	data, err := json.Marshal(fileRecord{ExpiresAt: expiry(ttl), Value: value})
+	if err != nil {
+		return err
+	}
+	dir := filepath.Join(f.dir, escapeName(category))
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+
+	// Write-then-rename so readers never see a partial record
+	tmp, err := os.CreateTemp(dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), f.path(category, key))
+}
+
+func (f *File) Get(_ context.Context, category, key string) ([]byte, error) {
+	rec, err := f.read(f.path(category, key))
+	if err != nil {
+		return nil, err
+	}
+	if expired(rec.ExpiresAt) {
+		_ = os.Remove(f.path(category, key))
+		return nil, ErrNotFound
+	}
+	return rec.Value, nil
+}
+
+func (f *File) Delete(_ context.Context, category, key string) error {
+	err := os.Remove(f.path(category, key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func (f *File) List(_ context.Context, category string) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(f.dir, escapeName(category)))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []string
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || strings.HasPrefix(name, ".") || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		key, err := url.PathUnescape(strings.TrimSuffix(name, ".json"))
+		if err != nil {
+			continue
+		}
+		rec, err := f.read(filepath.Join(f.dir, escapeName(category), name))
+		if err != nil {
+			continue
+		}
+		if expired(rec.ExpiresAt) {
+			_ = os.Remove(filepath.Join(f.dir, escapeName(category), name))
+			continue
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+func (f *File) Close() error { return nil }
+
+// Categories implements Inspector.
+func (f *File) Categories(_ context.Context) ([]string, error) {
+	entries, err := os.ReadDir(f.dir)
+	if err != nil {
+		return nil, err
+	}
+	var cats []string
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		if c, err := url.PathUnescape(e.Name()); err == nil {
+			cats = append(cats, c)
+		}
+	}
+	sort.Strings(cats)
+	return cats, nil
+}
+
+// Inspect implements Inspector. Sizes are on-disk file sizes; expired records are removed.
+func (f *File) Inspect(_ context.Context, category string) ([]RecordInfo, error) {
+	dir := filepath.Join(f.dir, escapeName(category))
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var infos []RecordInfo
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || strings.HasPrefix(name, ".") || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		key, err := url.PathUnescape(strings.TrimSuffix(name, ".json"))
+		if err != nil {
+			continue
+		}
+		st, err := e.Info()
+		if err != nil {
+			continue
+		}
+		rec, err := f.read(filepath.Join(dir, name))
+		if err != nil {
+			continue
+		}
+		if expired(rec.ExpiresAt) {
+			_ = os.Remove(filepath.Join(dir, name))
+			continue
+		}
+		infos = append(infos, RecordInfo{Key: key, Size: st.Size(), Modified: st.ModTime(), ExpiresAt: rec.ExpiresAt})
+	}
+	return sortInfos(infos), nil
+}
+
+// staleLockAge is how old a lease lock file may get before it is considered abandoned.
+const staleLockAge = 10 * time.Second
+
+// AcquireLease implements Leaser. Replicas sharing the data directory (e.g. a shared
+// volume) serialize lease updates through an O_EXCL lock file.
+func (f *File) AcquireLease(ctx context.Context, name, owner string, ttl time.Duration) (bool, error) {
+	acquired := false
+	err := f.withLock(ctx, name, func() error {
+		var cur lease
+		if data, err := f.Get(ctx, categoryLeases, name); err == nil {
+			_ = json.Unmarshal(data, &cur)
+		} else if !errors.Is(err, ErrNotFound) {
+			return err
+		}
+		if cur.Owner != "" && cur.Owner != owner && time.Now().Before(cur.ExpiresAt) {
+			return nil
+		}
+		data, err := json.Marshal(lease{Owner: owner, ExpiresAt: time.Now().Add(ttl)})
+		if err != nil {
+			return err
+		}
+		acquired = true
+		return f.Put(ctx, categoryLeases, name, data, 0)
+	})
+	return acquired && err == nil, err
+}
+
+// ReleaseLease implements Leaser.
+func (f *File) ReleaseLease(ctx context.Context, name, owner string) error {
+	return f.withLock(ctx, name, func() error {
+		var cur lease
+		data, err := f.Get(ctx, categoryLeases, name)
+		if errors.Is(err, ErrNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if json.Unmarshal(data, &cur) == nil && cur.Owner == owner {
+			return f.Delete(ctx, categoryLeases, name)
+		}
+		return nil
+	})
+}
+
+// withLock runs fn while holding the lock file for a lease.
+func (f *File) withLock(ctx context.Context, name string, fn func() error) error {
+	dir := filepath.Join(f.dir, categoryLeases)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	lockPath := filepath.Join(dir, "."+escapeName(name)+".lock")
+
+	for {
+		lf, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+		if err == nil {
+			lf.Close()
+			defer os.Remove(lockPath)
+			return fn()
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return err
+		}
+		if st, err := os.Stat(lockPath); err == nil && time.Since(st.ModTime()) > staleLockAge {
+			_ = os.Remove(lockPath)
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(20 * time.Millisecond):
+		}
+	}
+}
+
+func (f *File) path(category, key string) string {
+	return filepath.Join(f.dir, escapeName(category), escapeName(key)+".json")
+}
+
+// escapeName makes a category or key safe as a single path element (no separators, no dot names).
+func escapeName(s string) string {
+	name := url.PathEscape(s)
+	if strings.HasPrefix(name, ".") {
+		name = "%2E" + name[1:]
+	}
+	return name
+}
+
+func (f *File) read(path string) (fileRecord, error) {
+	var rec fileRecord
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return rec, ErrNotFound
+	}
+	if err != nil {
+		return rec, err
+	}
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return rec, fmt.Errorf("corrupt store record %s: %w", filepath.Base(path), err)
+	}
+	return rec, nil
+}
@@ -0,0 +1,61 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+)
+
+// RecordInfo describes one stored record without its value.
+type RecordInfo struct {
+	Key  string `json:"key"`
+	Size int64  `json:"size_bytes"`
+	// Modified is when the record was last written (redis: last accessed); zero when unknown.
+	Modified  time.Time `json:"modified,omitempty"`
+	ExpiresAt time.Time `json:"expires_at,omitempty"`
+}
+
+// Inspector is implemented by stores that can enumerate their categories and report
+// record sizes and ages cheaply, for usage reporting and retention.
+type Inspector interface {
+	Categories(ctx context.Context) ([]string, error)
+	Inspect(ctx context.Context, category string) ([]RecordInfo, error)
+}
+
+// Categories lists the categories holding records, or nil when s can't enumerate them.
+func Categories(ctx context.Context, s Store) ([]string, error) {
+	if in, ok := s.(Inspector); ok {
+		return in.Categories(ctx)
+	}
+	return nil, nil
+}
+
+// Inspect describes the live records of a category, sorted by key. Stores that aren't an
+// Inspector are read record by record, and their records have no Modified time.
+func Inspect(ctx context.Context, s Store, category string) ([]RecordInfo, error) {
+	if in, ok := s.(Inspector); ok {
+		return in.Inspect(ctx, category)
+	}
+	keys, err := s.List(ctx, category)
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]RecordInfo, 0, len(keys))
+	for _, key := range keys {
+		value, err := s.Get(ctx, category, key)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, RecordInfo{Key: key, Size: int64(len(value))})
+	}
+	return infos, nil
+}
+
+func sortInfos(infos []RecordInfo) []RecordInfo {
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Key < infos[j].Key })
+	return infos
+}
@@ -0,0 +1,101 @@
+package store
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	kmstypes "github.com/aws/aws-sdk-go-v2/service/kms/types"
+)
+
+// categoryDataKeys holds the KMS-encrypted data keys of an encrypted store. They are
+// stored as KMS returns them, so reading them needs kms:Decrypt on the KMS key.
+const categoryDataKeys = "store-keys"
+
+// KMSAPI is the part of the KMS client the store uses.
+type KMSAPI interface {
+	GenerateDataKey(ctx context.Context, in *kms.GenerateDataKeyInput, optFns ...func(*kms.Options)) (*kms.GenerateDataKeyOutput, error)
+	Decrypt(ctx context.Context, in *kms.DecryptInput, optFns ...func(*kms.Options)) (*kms.DecryptOutput, error)
+}
+
+type dataKeyRecord struct {
+	CreatedAt      time.Time `json:"created_at"`
+	CiphertextBlob []byte    `json:"ciphertext_blob"`
+}
+
+// KMSKeyring returns a keyring of data keys kept in s and wrapped by the KMS key kmsKeyID
+// (envelope encryption). The newest data key is current; a new one is generated when
+// there is none or the newest is older than rotateAfter (0 never rotates). Older keys
+// keep decrypting what they sealed. The keyring reloads itself on meeting a data key
+// another replica created.
+func KMSKeyring(ctx context.Context, s Store, client KMSAPI, kmsKeyID string, rotateAfter time.Duration, log *slog.Logger) (*Keyring, error) {
+	k := NewKeyring()
+	var (
+		mu     sync.Mutex
+		loaded = make(map[string]bool)
+		newest time.Time
+	)
+	load := func(ctx context.Context) error {
+		mu.Lock()
+		defer mu.Unlock()
+		names, err := s.List(ctx, categoryDataKeys)
+		if err != nil {
+			return err
+		}
+		for _, name := range names {
+			if loaded[name] {
+				continue
+			}
+			var rec dataKeyRecord
+			if err := GetJSON(ctx, s, categoryDataKeys, name, &rec); err != nil {
+				return fmt.Errorf("data key %s: %w", name, err)
+			}
+			out, err := client.Decrypt(ctx, &kms.DecryptInput{CiphertextBlob: rec.CiphertextBlob, KeyId: aws.String(kmsKeyID)})
+			if err != nil {
+				return fmt.Errorf("failed to decrypt data key %s: %w", name, err)
+			}
+			isNewest := rec.CreatedAt.After(newest)
+			if err := k.Add(out.Plaintext, isNewest); err != nil {
+				return fmt.Errorf("data key %s: %w", name, err)
+			}
+			if isNewest {
+				newest = rec.CreatedAt
+			}
+			loaded[name] = true
+		}
+		return nil
+	}
+	if err := load(ctx); err != nil {
+		return nil, err
+	}
+
+	if newest.IsZero() || (rotateAfter > 0 && time.Since(newest) > rotateAfter) {
+		out, err := client.GenerateDataKey(ctx, &kms.GenerateDataKeyInput{KeyId: aws.String(kmsKeyID), KeySpec: kmstypes.DataKeySpecAes256})
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate a data key: %w", err)
+		}
+		now := time.Now().UTC()
+		suffix := make([]byte, 4)
+		_, _ = rand.Read(suffix)
+		name := now.Format("20060102T150405Z") + "-" + hex.EncodeToString(suffix)
+		if err := PutJSON(ctx, s, categoryDataKeys, name, dataKeyRecord{CreatedAt: now, CiphertextBlob: out.CiphertextBlob}, 0); err != nil {
+			return nil, fmt.Errorf("failed to save the data key: %w", err)
+		}
+		if err := k.Add(out.Plaintext, true); err != nil {
+			return nil, err
+		}
+		mu.Lock()
+		loaded[name], newest = true, now
+		mu.Unlock()
+		log.Info("store data key generated", "key_id", k.CurrentID(), "previous_keys", len(loaded)-1)
+	}
+
+	k.Reload = load
+	return k, nil
+}
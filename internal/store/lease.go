@@ -0,0 +1,25 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+// Leaser is implemented by stores that can arbitrate short-lived ownership of a
+// named lease between replicas sharing the store.
+type Leaser interface {
+	// AcquireLease takes the lease for owner, or renews it if owner already holds it.
+	// It reports false when another owner holds an unexpired lease.
+	AcquireLease(ctx context.Context, name, owner string, ttl time.Duration) (bool, error)
+	// ReleaseLease gives the lease up if owner holds it.
+	ReleaseLease(ctx context.Context, name, owner string) error
+}
+
+// lease is the persisted lease state.
+type lease struct {
+	Owner     string    `json:"owner"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// categoryLeases holds lease records in stores that keep them alongside data.
+const categoryLeases = "leases"
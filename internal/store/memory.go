@@ -0,0 +1,121 @@
+package store
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+type memRecord struct {
+	value     []byte
+	modified  time.Time
+	expiresAt time.Time
+}
+
+// Memory is a process-local Store; contents are lost on restart.
+type Memory struct {
+	mu     sync.RWMutex
+	data   map[string]map[string]memRecord
+	leases map[string]lease
+}
+
+// NewMemory creates an empty in-memory store.
+func NewMemory() *Memory {
+	return &Memory{data: map[string]map[string]memRecord{}, leases: map[string]lease{}}
+}
+
+func (m *Memory) Put(_ context.Context, category, key string, value []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.data[category] == nil {
+		m.data[category] = map[string]memRecord{}
+	}
+	m.data[category][key] = memRecord{value: append([]byte(nil), value...), modified: time.Now(), expiresAt: expiry(ttl)}
+	return nil
+}
+
+func (m *Memory) Get(_ context.Context, category, key string) ([]byte, error) {
+	m.mu.RLock()
+	rec, ok := m.data[category][key]
+	m.mu.RUnlock()
+	if !ok || expired(rec.expiresAt) {
+		return nil, ErrNotFound
+	}
+	return append([]byte(nil), rec.value...), nil
+}
+
+func (m *Memory) Delete(_ context.Context, category, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.data[category], key)
+	return nil
+}
+
+func (m *Memory) List(_ context.Context, category string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	keys := make([]string, 0, len(m.data[category]))
+	for k, rec := range m.data[category] {
+		if expired(rec.expiresAt) {
+			delete(m.data[category], k)
+			continue
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+func (m *Memory) Close() error { return nil }
+
+// Categories implements Inspector.
+func (m *Memory) Categories(_ context.Context) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	cats := make([]string, 0, len(m.data))
+	for c, recs := range m.data {
+		if len(recs) > 0 {
+			cats = append(cats, c)
+		}
+	}
+	sort.Strings(cats)
+	return cats, nil
+}
+
+// Inspect implements Inspector.
+func (m *Memory) Inspect(_ context.Context, category string) ([]RecordInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	infos := make([]RecordInfo, 0, len(m.data[category]))
+	for k, rec := range m.data[category] {
+		if expired(rec.expiresAt) {
+			delete(m.data[category], k)
+			continue
+		}
+		infos = append(infos, RecordInfo{Key: k, Size: int64(len(rec.value)), Modified: rec.modified, ExpiresAt: rec.expiresAt})
+	}
+	return sortInfos(infos), nil
+}
+
+// AcquireLease implements Leaser; leases only coordinate goroutines of this process.
+func (m *Memory) AcquireLease(_ context.Context, name, owner string, ttl time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	cur, ok := m.leases[name]
+	if ok && cur.Owner != owner && time.Now().Before(cur.ExpiresAt) {
+		return false, nil
+	}
+	m.leases[name] = lease{Owner: owner, ExpiresAt: time.Now().Add(ttl)}
+	return true, nil
+}
+
+// ReleaseLease implements Leaser.
+func (m *Memory) ReleaseLease(_ context.Context, name, owner string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.leases[name].Owner == owner {
+		delete(m.leases, name)
+	}
+	return nil
+}
@@ -0,0 +1,419 @@
+package store
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	redisPoolSize    = 4
+	redisDialTimeout = 5 * time.Second
+)
+
+// Lua scripts keep record and lease updates atomic on the server.
+const (
+	// A record and its index entry are written together, so List never misses a record
+	// (or lists one that was never written) when a command fails halfway.
+	redisPutScript = `if tonumber(ARGV[2]) > 0 then
+  redis.call('SET', KEYS[1], ARGV[1], 'PX', ARGV[2])
+else
+  redis.call('SET', KEYS[1], ARGV[1])
+end
+redis.call('SADD', KEYS[2], ARGV[3])
+return 1`
+	redisDeleteScript = `redis.call('DEL', KEYS[1])
+redis.call('SREM', KEYS[2], ARGV[1])
+return 1`
+	// Listing checks every indexed key and drops the ones expired by TTL from the index in
+	// one round trip, however many keys the category holds. ARGV[1] is the key prefix.
+	redisListScript = `local keys = {}
+for _, k in ipairs(redis.call('SMEMBERS', KEYS[1])) do
+  if redis.call('EXISTS', ARGV[1] .. k) == 1 then
+    keys[#keys + 1] = k
+  else
+    redis.call('SREM', KEYS[1], k)
+  end
+end
+return keys`
+	redisAcquireScript = `local v = redis.call('GET', KEYS[1])
+if v == false or v == ARGV[1] then
+  redis.call('SET', KEYS[1], ARGV[1], 'PX', ARGV[2])
+  return 1
+end
+return 0`
+	redisReleaseScript = `if redis.call('GET', KEYS[1]) == ARGV[1] then
+  return redis.call('DEL', KEYS[1])
+end
+return 0`
+)
+
+// Redis is a Store shared by all replicas pointing at the same server. Each record is
+// a string key with a native TTL; a per-category set indexes keys for List.
+type Redis struct {
+	addr     string
+	password string
+	username string
+	db       int
+	useTLS   bool
+	prefix   string
+	pool     chan *redisConn
+}
+
+// NewRedis connects to a redis:// or rediss:// URL (user, password and /db are optional).
+func NewRedis(rawURL, prefix string) (*Redis, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid redis URL: %w", err)
+	}
+	if u.Scheme != "redis" && u.Scheme != "rediss" {
+		return nil, fmt.Errorf("redis URL must use redis:// or rediss://")
+	}
+
+	r := &Redis{
+		addr:   u.Host,
+		useTLS: u.Scheme == "rediss",
+		prefix: prefix,
+		pool:   make(chan *redisConn, redisPoolSize),
+	}
+	if !strings.Contains(r.addr, ":") {
+		r.addr += ":6379"
+	}
+	if u.User != nil {
+		r.username = u.User.Username()
+		r.password, _ = u.User.Password()
+	}
+	if db := strings.Trim(u.Path, "/"); db != "" {
+		if r.db, err = strconv.Atoi(db); err != nil {
+			return nil, fmt.Errorf("invalid redis database %q", db)
+		}
+	}
+
+	// Fail fast on bad address or credentials
+	ctx, cancel := context.WithTimeout(context.Background(), redisDialTimeout)
+	defer cancel()
+	if _, err := r.do(ctx, "PING"); err != nil {
+		return nil, fmt.Errorf("redis unreachable: %w", err)
+	}
+	return r, nil
+}
+
+func (r *Redis) Put(ctx context.Context, category, key string, value []byte, ttl time.Duration) error {
+	var ms int64
+	if ttl > 0 {
+		ms = max(1, ttl.Milliseconds())
+	}
+	_, err := r.do(ctx, "EVAL", redisPutScript, "2", r.key(category, key), r.index(category), string(value), strconv.FormatInt(ms, 10), key)
+	return err
+}
+
+func (r *Redis) Get(ctx context.Context, category, key string) ([]byte, error) {
+	v, err := r.do(ctx, "GET", r.key(category, key))
+	if err != nil {
+		return nil, err
+	}
+	if v == nil {
+		return nil, ErrNotFound
+	}
+	s, _ := v.(string)
+	return []byte(s), nil
+}
+
+func (r *Redis) Delete(ctx context.Context, category, key string) error {
+	_, err := r.do(ctx, "EVAL", redisDeleteScript, "2", r.key(category, key), r.index(category), key)
+	return err
+}
+
+func (r *Redis) List(ctx context.Context, category string) ([]string, error) {
+	v, err := r.do(ctx, "EVAL", redisListScript, "1", r.index(category), r.key(category, ""))
+	if err != nil {
+		return nil, err
+	}
+	members, _ := v.([]any)
+
+	keys := make([]string, 0, len(members))
+	for _, m := range members {
+		if key, ok := m.(string); ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// AcquireLease implements Leaser.
+func (r *Redis) AcquireLease(ctx context.Context, name, owner string, ttl time.Duration) (bool, error) {
+	v, err := r.do(ctx, "EVAL", redisAcquireScript, "1", r.key(categoryLeases, name), owner, strconv.FormatInt(ttl.Milliseconds(), 10))
+	if err != nil {
+		return false, err
+	}
+	n, _ := v.(int64)
+	return n == 1, nil
+}
+
+// ReleaseLease implements Leaser.
+func (r *Redis) ReleaseLease(ctx context.Context, name, owner string) error {
+	_, err := r.do(ctx, "EVAL", redisReleaseScript, "1", r.key(categoryLeases, name), owner)
+	return err
+}
+
+func (r *Redis) Close() error {
+	for {
+		select {
+		case c := <-r.pool:
+			c.Close()
+		default:
+			return nil
+		}
+	}
+}
+
+// Categories implements Inspector by scanning for category indexes.
+func (r *Redis) Categories(ctx context.Context) ([]string, error) {
+	var cats []string
+	cursor := "0"
+	for {
+		v, err := r.do(ctx, "SCAN", cursor, "MATCH", r.index("*"), "COUNT", "100")
+		if err != nil {
+			return nil, err
+		}
+		reply, _ := v.([]any)
+		if len(reply) != 2 {
+			return nil, errors.New("unexpected SCAN reply")
+		}
+		cursor, _ = reply[0].(string)
+		keys, _ := reply[1].([]any)
+		for _, k := range keys {
+			name, _ := k.(string)
+			cats = append(cats, strings.TrimSuffix(strings.TrimPrefix(name, r.prefix+":"), ":_keys"))
+		}
+		if cursor == "0" || cursor == "" {
+			break
+		}
+	}
+	sort.Strings(cats)
+	return cats, nil
+}
+
+// Inspect implements Inspector. Redis doesn't keep write times, so Modified is derived from
+// the key's idle time (last access); it stays zero under an LFU eviction policy.
+func (r *Redis) Inspect(ctx context.Context, category string) ([]RecordInfo, error) {
+	keys, err := r.List(ctx, category)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	infos := make([]RecordInfo, 0, len(keys))
+	for _, key := range keys {
+		size, err := r.do(ctx, "STRLEN", r.key(category, key))
+		if err != nil {
+			return nil, err
+		}
+		info := RecordInfo{Key: key}
+		info.Size, _ = size.(int64)
+		if ttl, err := r.do(ctx, "PTTL", r.key(category, key)); err == nil {
+			if ms, _ := ttl.(int64); ms > 0 {
+				info.ExpiresAt = now.Add(time.Duration(ms) * time.Millisecond)
+			}
+		}
+		if idle, err := r.do(ctx, "OBJECT", "IDLETIME", r.key(category, key)); err == nil {
+			if secs, ok := idle.(int64); ok {
+				info.Modified = now.Add(-time.Duration(secs) * time.Second)
+			}
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+func (r *Redis) key(category, key string) string {
+	return r.prefix + ":" + category + ":" + key
+}
+
+func (r *Redis) index(category string) string {
+	return r.prefix + ":" + category + ":_keys"
+}
+
+// do runs one command on a pooled connection. Connections left in an unknown state by an
+// I/O or protocol error are closed rather than pooled.
+func (r *Redis) do(ctx context.Context, args ...string) (any, error) {
+	var c *redisConn
+	select {
+	case c = <-r.pool:
+	default:
+		var err error
+		if c, err = r.dial(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	v, err := c.do(ctx, args...)
+	if c.broken {
+		c.Close()
+		return nil, err
+	}
+
+	select {
+	case r.pool <- c:
+	default:
+		c.Close()
+	}
+	return v, err
+}
+
+func (r *Redis) dial(ctx context.Context) (*redisConn, error) {
+	d := &net.Dialer{Timeout: redisDialTimeout}
+	var (
+		conn net.Conn
+		err  error
+	)
+	if r.useTLS {
+		host, _, _ := net.SplitHostPort(r.addr)
+		conn, err = (&tls.Dialer{NetDialer: d, Config: &tls.Config{ServerName: host, MinVersion: tls.VersionTLS12}}).DialContext(ctx, "tcp", r.addr)
+	} else {
+		conn, err = d.DialContext(ctx, "tcp", r.addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	c := &redisConn{conn: conn, rd: bufio.NewReader(conn)}
+	if r.password != "" {
+		args := []string{"AUTH", r.password}
+		if r.username != "" {
+			args = []string{"AUTH", r.username, r.password}
+		}
+		if _, err := c.do(ctx, args...); err != nil {
+			c.Close()
+			return nil, fmt.Errorf("redis auth failed: %w", err)
+		}
+	}
+	if r.db != 0 {
+		if _, err := c.do(ctx, "SELECT", strconv.Itoa(r.db)); err != nil {
+			c.Close()
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// redisError is an error reply from the server (the connection stays usable).
+type redisError string
+
+func (e redisError) Error() string { return "redis: " + string(e) }
+
+// redisConn speaks RESP2 over one connection.
+type redisConn struct {
+	conn net.Conn
+	rd   *bufio.Reader
+	// broken is set when a command failed other than with a complete error reply: what
+	// is left unread on the connection is unknown, so it can't run another command.
+	broken bool
+}
+
+func (c *redisConn) Close() error { return c.conn.Close() }
+
+func (c *redisConn) do(ctx context.Context, args ...string) (v any, err error) {
+	defer func() {
+		var reply redisError
+		if err != nil && !errors.As(err, &reply) {
+			c.broken = true
+		}
+	}()
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(10 * time.Second)
+	}
+	if err := c.conn.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, a := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(a), a)
+	}
+	if _, err := io.WriteString(c.conn, b.String()); err != nil {
+		return nil, err
+	}
+	return c.read()
+}
+
+// read parses one RESP reply: strings, integers, nil, arrays or errors. An array is read to
+// its end even when an element is an error reply, which is then returned.
+func (c *redisConn) read() (any, error) {
+	line, err := c.rd.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, errors.New("redis: empty reply")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, redisError(line[1:])
+	case ':':
+		n, err := strconv.ParseInt(line[1:], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("redis: bad integer reply %q", line)
+		}
+		return n, nil
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("redis: bad bulk length %q", line)
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(c.rd, buf); err != nil {
+			return nil, err
+		}
+		if string(buf[n:]) != "\r\n" {
+			return nil, errors.New("redis: bulk string not terminated by CRLF")
+		}
+		return string(buf[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("redis: bad array length %q", line)
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		items := make([]any, n)
+		var replyErr error
+		for i := range items {
+			item, err := c.read()
+			var reply redisError
+			switch {
+			case errors.As(err, &reply):
+				replyErr = cmp.Or(replyErr, err)
+			case err != nil:
+				return nil, err
+			}
+			items[i] = item
+		}
+		if replyErr != nil {
+			return nil, replyErr
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("redis: unexpected reply %q", line)
+	}
+}
@@ -0,0 +1,207 @@
+package store
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func TestRedisRecords(t *testing.T) {
+	ctx := context.Background()
+	srv := miniredis.RunT(t)
+	r, err := NewRedis("redis://"+srv.Addr(), "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	for key, ttl := range map[string]time.Duration{"b": 0, "a": time.Minute, "short": time.Second} {
+		if err := r.Put(ctx, "jobs", key, []byte("value "+key), ttl); err != nil {
+			t.Fatalf("put %s: %v", key, err)
+		}
+	}
+	if got, err := r.Get(ctx, "jobs", "a"); err != nil || string(got) != "value a" {
+		t.Errorf("get a: %q, %v", got, err)
+	}
+	if ttl := srv.TTL("test:jobs:a"); ttl != time.Minute {
+		t.Errorf("a expires in %v, want 1m", ttl)
+	}
+	if ttl := srv.TTL("test:jobs:b"); ttl != 0 {
+		t.Errorf("b expires in %v, want never", ttl)
+	}
+
+	srv.FastForward(2 * time.Second)
+	if keys, err := r.List(ctx, "jobs"); err != nil || !reflect.DeepEqual(keys, []string{"a", "b"}) {
+		t.Errorf("list after short expired: %q, %v", keys, err)
+	}
+	if members, _ := srv.Members("test:jobs:_keys"); !reflect.DeepEqual(members, []string{"a", "b"}) {
+		t.Errorf("index after listing: %q, want the expired key dropped", members)
+	}
+	if _, err := r.Get(ctx, "jobs", "short"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("get expired: %v, want ErrNotFound", err)
+	}
+
+	if err := r.Delete(ctx, "jobs", "a"); err != nil {
+		t.Fatal(err)
+	}
+	if members, _ := srv.Members("test:jobs:_keys"); !reflect.DeepEqual(members, []string{"b"}) {
+		t.Errorf("index after delete: %q", members)
+	}
+	if cats, err := r.Categories(ctx); err != nil || !reflect.DeepEqual(cats, []string{"jobs"}) {
+		t.Errorf("categories: %q, %v", cats, err)
+	}
+}
+
+func TestRedisLeases(t *testing.T) {
+	ctx := context.Background()
+	srv := miniredis.RunT(t)
+	r, err := NewRedis("redis://"+srv.Addr(), "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	if ok, err := r.AcquireLease(ctx, "leader", "one", time.Minute); err != nil || !ok {
+		t.Fatalf("first acquire: %v, %v", ok, err)
+	}
+	if ok, _ := r.AcquireLease(ctx, "leader", "two", time.Minute); ok {
+		t.Error("a second owner took a held lease")
+	}
+	if err := r.ReleaseLease(ctx, "leader", "two"); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := r.AcquireLease(ctx, "leader", "one", time.Minute); !ok {
+		t.Error("another owner's release dropped the lease")
+	}
+	srv.FastForward(2 * time.Minute)
+	if ok, _ := r.AcquireLease(ctx, "leader", "two", time.Minute); !ok {
+		t.Error("an expired lease wasn't taken over")
+	}
+}
+
+// scriptedRedis answers each command on a connection with the next of replies, and counts
+// the connections it accepted.
+func scriptedRedis(t *testing.T, replies ...string) (addr string, conns *atomic.Int32) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	conns = new(atomic.Int32)
+	var next atomic.Int32
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conns.Add(1)
+			go func() {
+				defer conn.Close()
+				rd := bufio.NewReader(conn)
+				for {
+					if err := skipCommand(rd); err != nil {
+						return
+					}
+					i := int(next.Add(1)) - 1
+					if i >= len(replies) {
+						return
+					}
+					if _, err := conn.Write([]byte(replies[i])); err != nil {
+						return
+					}
+				}
+			}()
+		}
+	}()
+	return ln.Addr().String(), conns
+}
+
+// skipCommand reads one RESP command array.
+func skipCommand(rd *bufio.Reader) error {
+	line, err := rd.ReadString('\n')
+	if err != nil {
+		return err
+	}
+	n, err := strconv.Atoi(strings.TrimPrefix(strings.TrimSpace(line), "*"))
+	if err != nil {
+		return err
+	}
+	for range 2 * n {
+		if _, err := rd.ReadString('\n'); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestRedisDrainsArrayWithErrorElement(t *testing.T) {
+	ctx := context.Background()
+	addr, conns := scriptedRedis(t,
+		"+PONG\r\n",
+		"*3\r\n-ERR first\r\n:1\r\n$5\r\nthird\r\n",
+		"+OK\r\n",
+	)
+	r, err := NewRedis("redis://"+addr, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.do(ctx, "EXEC"); err == nil || !strings.Contains(err.Error(), "first") {
+		t.Fatalf("array with an error element: %v, want the element's error", err)
+	}
+	// The rest of the array was read, so the pooled connection answers the next command
+	if v, err := r.do(ctx, "PING"); err != nil || v != "OK" {
+		t.Errorf("next command: %v, %v, want OK", v, err)
+	}
+	if n := conns.Load(); n != 1 {
+		t.Errorf("%d connections, want the one pooled", n)
+	}
+}
+
+func TestRedisDiscardsConnectionAfterProtocolError(t *testing.T) {
+	ctx := context.Background()
+	addr, conns := scriptedRedis(t,
+		"+PONG\r\n",
+		"$5\r\nhello!!\r\n",
+		"+OK\r\n",
+	)
+	r, err := NewRedis("redis://"+addr, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.do(ctx, "GET", "k"); err == nil {
+		t.Fatal("a bulk string longer than announced was accepted")
+	}
+	if v, err := r.do(ctx, "PING"); err != nil || v != "OK" {
+		t.Errorf("next command: %v, %v, want OK on a new connection", v, err)
+	}
+	if n := conns.Load(); n != 2 {
+		t.Errorf("%d connections, want the broken one replaced", n)
+	}
+}
+
+func TestRedisListIsOneCommand(t *testing.T) {
+	ctx := context.Background()
+	// The connection closes after these replies, so a second command per key would fail
+	addr, _ := scriptedRedis(t,
+		"+PONG\r\n",
+		"*2\r\n$1\r\nb\r\n$1\r\na\r\n",
+	)
+	r, err := NewRedis("redis://"+addr, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keys, err := r.List(ctx, "jobs"); err != nil || !reflect.DeepEqual(keys, []string{"a", "b"}) {
+		t.Errorf("list: %q, %v", keys, err)
+	}
+}
@@ -0,0 +1,98 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// ErrNotFound is returned when a key does not exist or has expired.
+var ErrNotFound = errors.New("not found")
+
+// Store is a small key/value persistence layer. Records are grouped by category
+// (e.g. "jobs", "artifacts") and may expire after a TTL (0 = never).
+type Store interface {
+	Put(ctx context.Context, category, key string, value []byte, ttl time.Duration) error
+	Get(ctx context.Context, category, key string) ([]byte, error)
+	Delete(ctx context.Context, category, key string) error
+	List(ctx context.Context, category string) ([]string, error)
+	Close() error
+}
+
+// Supported backends.
+const (
+	BackendMemory   = "memory"
+	BackendFile     = "file"
+	BackendRedis    = "redis"
+	BackendDynamoDB = "dynamodb"
+)
+
+// Config selects and configures a backend.
+type Config struct {
+	Backend     string
+	DataDir     string
+	RedisURL    string
+	RedisPrefix string
+	// DynamoDB is the client for the dynamodb backend, which keeps records in DynamoDBTable.
+	DynamoDB      DynamoDBAPI
+	DynamoDBTable string
+}
+
+// Open creates the configured backend.
+func Open(cfg Config, log *slog.Logger) (Store, error) {
+	switch cfg.Backend {
+	case BackendMemory:
+		log.Info("using in-memory store")
+		return NewMemory(), nil
+	case BackendFile, "":
+		log.Info("using file store", "data_dir", cfg.DataDir)
+		return NewFile(cfg.DataDir)
+	case BackendRedis:
+		if cfg.RedisURL == "" {
+			return nil, fmt.Errorf("REDIS_URL is required for the redis store")
+		}
+		log.Info("using redis store", "prefix", cfg.RedisPrefix)
+		return NewRedis(cfg.RedisURL, cfg.RedisPrefix)
+	case BackendDynamoDB:
+		if cfg.DynamoDB == nil {
+			return nil, fmt.Errorf("the dynamodb store needs AWS configuration")
+		}
+		log.Info("using dynamodb store", "table", cfg.DynamoDBTable)
+		return NewDynamoDB(cfg.DynamoDB, cfg.DynamoDBTable)
+	default:
+		return nil, fmt.Errorf("unsupported store backend %q", cfg.Backend)
+	}
+}
+
+// PutJSON stores v encoded as JSON.
+func PutJSON(ctx context.Context, s Store, category, key string, v any, ttl time.Duration) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return s.Put(ctx, category, key, data, ttl)
+}
+
+// GetJSON loads a JSON record into v.
+func GetJSON(ctx context.Context, s Store, category, key string, v any) error {
+	data, err := s.Get(ctx, category, key)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// expiry converts a TTL into an absolute deadline (zero time = never).
+func expiry(ttl time.Duration) time.Time {
+	if ttl <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(ttl)
+}
+
+func expired(at time.Time) bool {
+	return !at.IsZero() && time.Now().After(at)
+}
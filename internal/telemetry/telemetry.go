@@ -0,0 +1,176 @@
+// Package telemetry reports anonymous usage counts to a configured endpoint. It is off
+// unless explicitly enabled, and only ever sends what Report holds: which routes were
+// called and how often, which error codes were returned, the build and the enabled
+// features. Message bodies, queue names, users, addresses and AWS identities are never
+// collected.
+package telemetry
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"maps"
+	"net/http"
+	"runtime"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/pachecoc/sqs-ui/internal/lifecycle"
+	"github.com/pachecoc/sqs-ui/internal/notify"
+	"github.com/pachecoc/sqs-ui/internal/version"
+)
+
+// SchemaVersion is bumped whenever Report changes shape.
+const SchemaVersion = 1
+
+// flushTimeout bounds the final report sent on shutdown.
+const flushTimeout = 5 * time.Second
+
+// Report is the complete payload posted to the endpoint.
+type Report struct {
+	Schema int `json:"schema"`
+	// InstanceID is random per process start, so reports can't be linked across restarts.
+	InstanceID    string           `json:"instance_id"`
+	Version       string           `json:"version"`
+	GoVersion     string           `json:"go_version"`
+	OS            string           `json:"os"`
+	Arch          string           `json:"arch"`
+	UptimeSeconds int64            `json:"uptime_seconds"`
+	PeriodStart   time.Time        `json:"period_start"`
+	PeriodEnd     time.Time        `json:"period_end"`
+	Features      []string         `json:"features"`
+	Requests      map[string]int64 `json:"requests"` // by "METHOD /route/{pattern}"
+	Errors        map[string]int64 `json:"errors"`   // by error code
+}
+
+// Reporter counts requests and errors and posts them to Endpoint every Interval. A nil
+// Reporter counts nothing, so callers don't need to check whether telemetry is enabled.
+type Reporter struct {
+	Endpoint string
+	Interval time.Duration
+	Features []string
+	Log      *slog.Logger
+
+	once       sync.Once
+	instanceID string
+	started    time.Time
+
+	mu          sync.Mutex
+	periodStart time.Time
+	requests    map[string]int64
+	errors      map[string]int64
+}
+
+// methods are counted by name; anything else counts as OTHER to keep keys bounded.
+var methods = []string{http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodOptions}
+
+func (r *Reporter) init() {
+	r.once.Do(func() {
+		b := make([]byte, 8)
+		_, _ = rand.Read(b)
+		r.instanceID = hex.EncodeToString(b)
+		r.started = time.Now().UTC()
+		r.periodStart = r.started
+		r.requests = map[string]int64{}
+		r.errors = map[string]int64{}
+	})
+}
+
+// CountRequest records a call to the route registered as pattern.
+func (r *Reporter) CountRequest(method, pattern string) {
+	if r == nil {
+		return
+	}
+	if !slices.Contains(methods, method) {
+		method = "OTHER"
+	}
+	r.init()
+	r.mu.Lock()
+	r.requests[method+" "+pattern]++
+	r.mu.Unlock()
+}
+
+// CountError records a response carrying error code.
+func (r *Reporter) CountError(code string) {
+	if r == nil || code == "" {
+		return
+	}
+	r.init()
+	r.mu.Lock()
+	r.errors[code]++
+	r.mu.Unlock()
+}
+
+// Preview returns the report that would be sent now, without resetting the counts.
+func (r *Reporter) Preview() Report {
+	r.init()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.report(maps.Clone(r.requests), maps.Clone(r.errors))
+}
+
+func (r *Reporter) report(requests, errors map[string]int64) Report {
+	now := time.Now().UTC()
+	return Report{
+		Schema:        SchemaVersion,
+		InstanceID:    r.instanceID,
+		Version:       version.Version,
+		GoVersion:     runtime.Version(),
+		OS:            runtime.GOOS,
+		Arch:          runtime.GOARCH,
+		UptimeSeconds: int64(now.Sub(r.started).Seconds()),
+		PeriodStart:   r.periodStart,
+		PeriodEnd:     now,
+		Features:      r.Features,
+		Requests:      requests,
+		Errors:        errors,
+	}
+}
+
+// Run posts a report every Interval until ctx is done, then sends what was counted since
+// the last one. Failed reports are logged and their counts carried into the next.
+func (r *Reporter) Run(ctx context.Context) {
+	r.init()
+	r.Log.Info("usage telemetry enabled", "endpoint", r.Endpoint, "interval_hours", r.Interval.Hours(), "features", r.Features)
+	ticker := time.NewTicker(r.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			flushCtx, cancel := context.WithTimeout(context.Background(), flushTimeout)
+			r.send(flushCtx)
+			cancel()
+			return
+		case <-ticker.C:
+			r.send(ctx)
+		}
+	}
+}
+
+// send posts the counts since the previous report and starts a new period.
+func (r *Reporter) send(ctx context.Context) {
+	r.mu.Lock()
+	rep := r.report(r.requests, r.errors)
+	r.requests, r.errors = map[string]int64{}, map[string]int64{}
+	r.periodStart = rep.PeriodEnd
+	r.mu.Unlock()
+
+	if err := notify.PostJSON(ctx, r.Endpoint, rep); err != nil {
+		r.Log.Warn("usage telemetry report failed", "endpoint", r.Endpoint, "error", err)
+		r.mu.Lock()
+		for k, n := range rep.Requests {
+			r.requests[k] += n
+		}
+		for k, n := range rep.Errors {
+			r.errors[k] += n
+		}
+		r.periodStart = rep.PeriodStart
+		r.mu.Unlock()
+		lifecycle.Report(ctx, err)
+		return
+	}
+	lifecycle.Report(ctx, nil)
+	r.Log.Debug("usage telemetry reported", "requests", len(rep.Requests), "errors", len(rep.Errors))
+}
@@ -0,0 +1,229 @@
+//go:build e2e
+
+// End-to-end tests against LocalStack: go test -tags e2e ./internal/testenv (make e2e).
+// They need a Docker daemon; LOCALSTACK_IMAGE overrides the image.
+package testenv_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/pachecoc/sqs-ui/internal/service"
+	"github.com/pachecoc/sqs-ui/internal/testenv"
+)
+
+var env *testenv.Env
+
+func TestMain(m *testing.M) {
+	ctx := context.Background()
+	queues := append([]testenv.Queue{
+		{Name: "sends"},
+		{Name: "purges"},
+		{Name: "moves"},
+		{Name: "moves-target"},
+	}, testenv.DefaultQueues...)
+	var err error
+	env, err = testenv.Start(ctx, testenv.Options{Image: os.Getenv("LOCALSTACK_IMAGE"), Queues: queues})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "could not start the test environment:", err)
+		os.Exit(1)
+	}
+	code := m.Run()
+	if err := env.Stop(ctx); err != nil {
+		fmt.Fprintln(os.Stderr, "could not remove the LocalStack container:", err)
+	}
+	os.Exit(code)
+}
+
+// queue returns a service on one of env's queues.
+func queue(t *testing.T, name string) *service.SQSService {
+	t.Helper()
+	url := env.QueueURLs[name]
+	if url == "" {
+		t.Fatalf("queue %s is not provisioned", name)
+	}
+	svc := service.NewSQSService(context.Background(), env.Client, "", url, testenv.Region, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	// Listings poll until the queue answers empty; keep each empty poll short
+	svc.WaitSeconds = 1
+	return svc
+}
+
+// eventually retries check until it returns nil or 20s pass, since LocalStack applies some
+// changes (purges, redrives) asynchronously.
+func eventually(t *testing.T, what string, check func() error) {
+	t.Helper()
+	deadline := time.Now().Add(20 * time.Second)
+	for {
+		err := check()
+		if err == nil {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%s: %v", what, err)
+		}
+		time.Sleep(250 * time.Millisecond)
+	}
+}
+
+// wantVisible waits until svc has n visible messages.
+func wantVisible(t *testing.T, svc *service.SQSService, n int64) {
+	t.Helper()
+	eventually(t, fmt.Sprintf("%s holds %d messages", svc.QueueName, n), func() error {
+		counts, err := svc.Counts(context.Background())
+		if err != nil {
+			return err
+		}
+		if counts.Visible != n {
+			return fmt.Errorf("%d visible", counts.Visible)
+		}
+		return nil
+	})
+}
+
+// bodies returns the body of each message in a listing.
+func bodies(msgs []map[string]any) []string {
+	out := make([]string, 0, len(msgs))
+	for _, m := range msgs {
+		out = append(out, fmt.Sprint(m["body"]))
+	}
+	return out
+}
+
+func TestSendAndReceive(t *testing.T) {
+	ctx := context.Background()
+	svc := queue(t, "sends")
+
+	sent, err := svc.Send(ctx, `{"order_id":1}`, service.SendOptions{
+		Attributes: map[string]service.MessageAttribute{"kind": {Type: "String", Value: "order"}},
+	})
+	if err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	if sent.MessageID == "" {
+		t.Fatal("send returned no message ID")
+	}
+
+	// Observing leaves the message on the queue
+	observed, err := svc.Receive(ctx, service.ModeObserve)
+	if err != nil {
+		t.Fatalf("observe: %v", err)
+	}
+	if got := bodies(observed); len(got) != 1 || got[0] != `{"order_id":1}` {
+		t.Fatalf("observe listed %q", got)
+	}
+	wantVisible(t, svc, 1)
+
+	consumed, err := svc.Receive(ctx, service.ModeConsume)
+	if err != nil {
+		t.Fatalf("consume: %v", err)
+	}
+	if len(consumed) != 1 {
+		t.Fatalf("consume listed %d messages, want 1", len(consumed))
+	}
+	handle, _ := consumed[0]["receipt_handle"].(string)
+	if n, err := svc.Delete(ctx, []string{handle}); err != nil || n != 1 {
+		t.Fatalf("delete: %d, %v", n, err)
+	}
+	eventually(t, "queue is empty after the delete", func() error {
+		counts, err := svc.Counts(ctx)
+		if err != nil {
+			return err
+		}
+		if counts.Visible+counts.NotVisible != 0 {
+			return fmt.Errorf("%+v left", counts)
+		}
+		return nil
+	})
+}
+
+func TestPurge(t *testing.T) {
+	ctx := context.Background()
+	svc := queue(t, "purges")
+	for i := range 3 {
+		if _, err := svc.Send(ctx, fmt.Sprintf("message %d", i), service.SendOptions{}); err != nil {
+			t.Fatalf("send: %v", err)
+		}
+	}
+	wantVisible(t, svc, 3)
+
+	if err := svc.Purge(ctx); err != nil {
+		t.Fatalf("purge: %v", err)
+	}
+	wantVisible(t, svc, 0)
+	// SQS refuses a second purge within 60 seconds; the service refuses it first
+	if err := svc.Purge(ctx); err == nil {
+		t.Error("second purge within the cooldown succeeded")
+	}
+}
+
+func TestMove(t *testing.T) {
+	ctx := context.Background()
+	src, dst := queue(t, "moves"), queue(t, "moves-target")
+	for i := range 12 {
+		if _, err := src.Send(ctx, fmt.Sprintf("message %d", i), service.SendOptions{}); err != nil {
+			t.Fatalf("send: %v", err)
+		}
+	}
+	wantVisible(t, src, 12)
+
+	res, err := src.Transfer(ctx, dst, 0, func(_ context.Context, _, body string) (string, error) {
+		return "moved " + body, nil
+	}, false)
+	if err != nil {
+		t.Fatalf("move: %v", err)
+	}
+	if res.Sent != 12 || res.Deleted != 12 || res.Failed != 0 {
+		t.Fatalf("move: %+v", res)
+	}
+	wantVisible(t, src, 0)
+	wantVisible(t, dst, 12)
+
+	moved, err := dst.Receive(ctx, service.ModeObserve)
+	if err != nil {
+		t.Fatalf("observe target: %v", err)
+	}
+	for _, body := range bodies(moved) {
+		if len(body) < 6 || body[:6] != "moved " {
+			t.Errorf("target holds %q, which wasn't transformed", body)
+		}
+	}
+}
+
+// TestRedrive lets SQS move a message to the DLQ after maxReceiveCount receives, then
+// replays it to the source queue.
+func TestRedrive(t *testing.T) {
+	ctx := context.Background()
+	svc := queue(t, "orders")
+	policy, err := svc.RedrivePolicy(ctx)
+	if err != nil || policy == nil || policy.DeadLetterQueue != "orders-dlq" {
+		t.Fatalf("orders redrive policy: %+v, %v", policy, err)
+	}
+	if _, err := svc.Send(ctx, "poison", service.SendOptions{}); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+
+	dlq, err := svc.DeadLetterQueue(ctx)
+	if err != nil {
+		t.Fatalf("dead-letter queue: %v", err)
+	}
+	// Every observe is a receive, so it counts towards maxReceiveCount
+	for range policy.MaxReceiveCount + 1 {
+		if _, err := svc.Receive(ctx, service.ModeObserve); err != nil {
+			t.Fatalf("observe: %v", err)
+		}
+	}
+	wantVisible(t, svc, 0)
+	wantVisible(t, dlq, 1)
+
+	res, err := dlq.Transfer(ctx, svc, 0, nil, false)
+	if err != nil || res.Sent != 1 || res.Deleted != 1 {
+		t.Fatalf("replay: %+v, %v", res, err)
+	}
+	wantVisible(t, dlq, 0)
+	wantVisible(t, svc, 1)
+}
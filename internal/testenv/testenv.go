@@ -0,0 +1,213 @@
+// Package testenv starts a disposable LocalStack container and provisions queues in it, so
+// sqs-ui can be exercised against a real SQS API (redrives, FIFO rules, purge cooldowns)
+// without an AWS account. Containers are run with testcontainers-go, so a Docker-compatible
+// daemon must be reachable (DOCKER_HOST and the other testcontainers settings apply).
+package testenv
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+
+	"github.com/pachecoc/sqs-ui/internal/service"
+)
+
+// Defaults for Options.
+const (
+	DefaultImage        = "localstack/localstack:3"
+	DefaultStartTimeout = 90 * time.Second
+)
+
+// edgePort is LocalStack's single port for every service.
+const edgePort = "4566/tcp"
+
+// Region and credentials LocalStack is addressed with; it accepts any.
+const (
+	Region          = service.DefaultLocalRegion
+	AccessKeyID     = "test"
+	SecretAccessKey = "test"
+)
+
+// Queue is a queue to provision. DLQ names another queue of the same environment that
+// receives its messages after MaxReceiveCount receives (3 when zero).
+type Queue struct {
+	Name            string
+	DLQ             string
+	MaxReceiveCount int
+	Attributes      map[string]string
+}
+
+// DefaultQueues mirror the demo queues: a standard queue redriving to a DLQ and a FIFO
+// queue with content-based deduplication.
+var DefaultQueues = []Queue{
+	{Name: "orders-dlq"},
+	{Name: "orders", DLQ: "orders-dlq"},
+	{Name: "events.fifo", Attributes: map[string]string{"FifoQueue": "true", "ContentBasedDeduplication": "true"}},
+}
+
+// Options configure Start. Zero values use the defaults.
+type Options struct {
+	Image        string
+	Queues       []Queue
+	StartTimeout time.Duration
+	Log          *slog.Logger
+}
+
+// Env is a running LocalStack with its provisioned queues.
+type Env struct {
+	Endpoint  string
+	Client    *sqs.Client
+	QueueURLs map[string]string // by queue name
+
+	container testcontainers.Container
+}
+
+// Start runs LocalStack with only SQS enabled on a random local port, waits until it is
+// ready and creates opts.Queues. On failure the container is removed.
+func Start(ctx context.Context, opts Options) (*Env, error) {
+	if opts.Image == "" {
+		opts.Image = DefaultImage
+	}
+	if opts.Queues == nil {
+		opts.Queues = DefaultQueues
+	}
+	if opts.StartTimeout <= 0 {
+		opts.StartTimeout = DefaultStartTimeout
+	}
+	if opts.Log == nil {
+		opts.Log = slog.New(slog.NewTextHandler(io.Discard, nil))
+	}
+
+	container, err := startContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: testcontainers.ContainerRequest{
+			Image:        opts.Image,
+			ExposedPorts: []string{edgePort},
+			Env:          map[string]string{"SERVICES": "sqs"},
+			WaitingFor: wait.ForHTTP("/_localstack/health").
+				WithPort(edgePort).
+				WithResponseMatcher(sqsReady).
+				WithStartupTimeout(opts.StartTimeout),
+		},
+		Started: true,
+	})
+	env := &Env{container: container, QueueURLs: map[string]string{}}
+	ok := false
+	defer func() {
+		if !ok {
+			_ = env.Stop(context.WithoutCancel(ctx))
+		}
+	}()
+	if err != nil {
+		return nil, fmt.Errorf("could not start LocalStack: %w", err)
+	}
+
+	if env.Endpoint, err = container.PortEndpoint(ctx, edgePort, "http"); err != nil {
+		return nil, fmt.Errorf("could not read the LocalStack port: %w", err)
+	}
+	id := container.GetContainerID()
+	opts.Log.Info("localstack started", "container", id[:min(12, len(id))], "endpoint", env.Endpoint)
+
+	env.Client = service.NewClient(aws.Config{
+		Region:      Region,
+		Credentials: credentials.NewStaticCredentialsProvider(AccessKeyID, SecretAccessKey, ""),
+	}, env.Endpoint)
+	if err := env.provision(ctx, opts.Queues, opts.Log); err != nil {
+		return nil, err
+	}
+	ok = true
+	return env, nil
+}
+
+// startContainer runs req, reporting a missing Docker host (which testcontainers-go panics
+// on) as an error.
+func startContainer(ctx context.Context, req testcontainers.GenericContainerRequest) (c testcontainers.Container, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			c, err = nil, fmt.Errorf("%v", p)
+		}
+	}()
+	return testcontainers.GenericContainer(ctx, req)
+}
+
+// provision creates queues, DLQs before the queues redriving to them.
+func (e *Env) provision(ctx context.Context, queues []Queue, log *slog.Logger) error {
+	svc := &service.SQSService{Client: e.Client, Region: Region, Log: log}
+	pending := append([]Queue(nil), queues...)
+	for len(pending) > 0 {
+		var next []Queue
+		for _, q := range pending {
+			if q.DLQ != "" && e.QueueURLs[q.DLQ] == "" {
+				next = append(next, q)
+				continue
+			}
+			attrs := map[string]string{}
+			for k, v := range q.Attributes {
+				attrs[k] = v
+			}
+			if q.DLQ != "" {
+				arn, err := svc.QueueARN(ctx, e.QueueURLs[q.DLQ])
+				if err != nil {
+					return err
+				}
+				attrs["RedrivePolicy"] = service.RedrivePolicyJSON(arn, cmpOr(q.MaxReceiveCount, 3))
+			}
+			url, err := svc.CreateQueue(ctx, q.Name, attrs, nil)
+			if err != nil {
+				return err
+			}
+			e.QueueURLs[q.Name] = url
+		}
+		if len(next) == len(pending) {
+			return fmt.Errorf("queue %s redrives to %s, which is not provisioned", next[0].Name, next[0].DLQ)
+		}
+		pending = next
+	}
+	return nil
+}
+
+func cmpOr(n, def int) int {
+	if n > 0 {
+		return n
+	}
+	return def
+}
+
+// Environ returns the variables that point sqs-ui at this environment, opening queue.
+func (e *Env) Environ(queue string) []string {
+	return []string{
+		"SQS_ENDPOINT=" + e.Endpoint,
+		"AWS_REGION=" + Region,
+		"AWS_ACCESS_KEY_ID=" + AccessKeyID,
+		"AWS_SECRET_ACCESS_KEY=" + SecretAccessKey,
+		"QUEUE_URL=" + e.QueueURLs[queue],
+	}
+}
+
+// Stop removes the container, discarding every queue.
+func (e *Env) Stop(ctx context.Context) error {
+	// A container that failed to be created can be a typed nil; TerminateContainer skips it
+	err := testcontainers.TerminateContainer(e.container, testcontainers.StopContext(ctx))
+	e.container = nil
+	return err
+}
+
+// sqsReady reports whether LocalStack's health document lists SQS as ready.
+func sqsReady(body io.Reader) bool {
+	var health struct {
+		Services map[string]string `json:"services"`
+	}
+	if json.NewDecoder(body).Decode(&health) != nil {
+		return false
+	}
+	status := health.Services["sqs"]
+	return status == "available" || status == "running"
+}
@@ -0,0 +1,75 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/smithy-go"
+	"github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Span attributes beyond the semantic conventions, for AWS failures and throttling.
+const (
+	attrAttempts          = attribute.Key("aws.attempts")
+	attrThrottledAttempts = attribute.Key("aws.throttled_attempts")
+	attrErrorCode         = attribute.Key("aws.error_code")
+)
+
+var throttles = retry.IsErrorThrottles(retry.DefaultThrottles)
+
+// WithAWS adds a client span around every AWS SDK operation (retries included) to
+// clients built from the loaded config. Spans record the request id, the attempts made
+// and how many of them were throttled.
+func WithAWS() config.LoadOptionsFunc {
+	return config.WithAPIOptions([]func(*middleware.Stack) error{addAWSSpan})
+}
+
+func addAWSSpan(stack *middleware.Stack) error {
+	return stack.Initialize.Add(middleware.InitializeMiddlewareFunc("OTelSpan", awsSpan), middleware.After)
+}
+
+func awsSpan(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+	serviceID, operation := awsmiddleware.GetServiceID(ctx), awsmiddleware.GetOperationName(ctx)
+	ctx, span := otel.Tracer("github.com/pachecoc/sqs-ui").Start(ctx, serviceID+"."+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.RPCSystemKey.String("aws-api"),
+			semconv.RPCService(serviceID),
+			semconv.RPCMethod(operation),
+			semconv.CloudRegion(awsmiddleware.GetRegion(ctx)),
+		),
+	)
+	out, metadata, err := next.HandleInitialize(ctx, in)
+
+	if id, ok := awsmiddleware.GetRequestIDMetadata(metadata); ok {
+		span.SetAttributes(semconv.AWSRequestID(id))
+	}
+	if results, ok := retry.GetAttemptResults(metadata); ok {
+		throttled := 0
+		for _, r := range results.Results {
+			if r.Err != nil && throttles.IsErrorThrottle(r.Err) == aws.TrueTernary {
+				throttled++
+			}
+		}
+		span.SetAttributes(attrAttempts.Int(len(results.Results)), attrThrottledAttempts.Int(throttled))
+	}
+	var respErr *smithyhttp.ResponseError
+	if errors.As(err, &respErr) {
+		span.SetAttributes(semconv.HTTPResponseStatusCode(respErr.HTTPStatusCode()))
+	}
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		span.SetAttributes(attrErrorCode.String(apiErr.ErrorCode()))
+	}
+	End(span, err)
+	return out, metadata, err
+}
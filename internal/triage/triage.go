@@ -0,0 +1,218 @@
+// Package triage tracks on-call work through a DLQ backlog: who owns a message and what was done with it.
+package triage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pachecoc/sqs-ui/internal/store"
+	"github.com/pachecoc/sqs-ui/internal/validate"
+)
+
+// category is the store category holding triage items.
+const category = "triage"
+
+// maxHistory bounds the change log kept per item.
+const maxHistory = 20
+
+// Status is where a message is in the triage workflow.
+type Status string
+
+const (
+	StatusOpen     Status = "open"
+	StatusResolved Status = "resolved"
+	StatusIgnored  Status = "ignored"
+	StatusReplayed Status = "replayed"
+)
+
+// ErrNotFound is returned when a message has not been triaged.
+var ErrNotFound = errors.New("triage item not found")
+
+// Item is the triage state of one message.
+type Item struct {
+	MessageID string    `json:"message_id"`
+	QueueName string    `json:"queue_name,omitempty"`
+	Assignee  string    `json:"assignee,omitempty"`
+	Status    Status    `json:"status"`
+	UpdatedBy string    `json:"updated_by,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+	History   []Change  `json:"history,omitempty"`
+}
+
+// Change is one entry of an item's history.
+type Change struct {
+	By       string    `json:"by,omitempty"`
+	Assignee string    `json:"assignee,omitempty"`
+	Status   Status    `json:"status"`
+	Note     string    `json:"note,omitempty"`
+	At       time.Time `json:"at"`
+}
+
+// Update describes a change to an item. Nil fields are left as they are.
+type Update struct {
+	QueueName string  `json:"queue_name"`
+	Assignee  *string `json:"assignee"`
+	Status    *Status `json:"status"`
+	By        string  `json:"by"`
+	Note      string  `json:"note"`
+}
+
+// Filter selects items in List and Summary. Empty fields match everything.
+type Filter struct {
+	QueueName string
+	Status    Status
+	Assignee  string
+}
+
+// Summary counts items for an overview of the backlog.
+type Summary struct {
+	Total          int            `json:"total"`
+	ByStatus       map[Status]int `json:"by_status"`
+	ByAssignee     map[string]int `json:"open_by_assignee"`
+	UnassignedOpen int            `json:"unassigned_open"`
+}
+
+// Manager reads and writes triage items in the shared store.
+type Manager struct {
+	Store store.Store
+}
+
+// ParseStatus validates a status name.
+func ParseStatus(v string) (Status, error) {
+	switch s := Status(strings.ToLower(strings.TrimSpace(v))); s {
+	case StatusOpen, StatusResolved, StatusIgnored, StatusReplayed:
+		return s, nil
+	default:
+		return "", fmt.Errorf("invalid triage status %q (expected open, resolved, ignored or replayed)", v)
+	}
+}
+
+// Get returns the triage item of a message.
+func (m *Manager) Get(ctx context.Context, messageID string) (Item, error) {
+	var it Item
+	if err := store.GetJSON(ctx, m.Store, category, messageID, &it); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return Item{}, ErrNotFound
+		}
+		return Item{}, err
+	}
+	return it, nil
+}
+
+// Apply updates (or starts) the triage of a message and records the change in its history.
+func (m *Manager) Apply(ctx context.Context, messageID string, u Update) (Item, error) {
+	messageID = strings.TrimSpace(messageID)
+	if messageID == "" {
+		return Item{}, fmt.Errorf("message id is required")
+	}
+	if u.Status != nil {
+		s, err := ParseStatus(string(*u.Status))
+		if err != nil {
+			return Item{}, validate.Errors{{Field: "status", Message: "must be open, resolved, ignored or replayed"}}
+		}
+		u.Status = &s
+	}
+
+	it, err := m.Get(ctx, messageID)
+	if errors.Is(err, ErrNotFound) {
+		it = Item{MessageID: messageID, Status: StatusOpen}
+	} else if err != nil {
+		return Item{}, err
+	}
+
+	if u.QueueName != "" {
+		it.QueueName = u.QueueName
+	}
+	if u.Assignee != nil {
+		it.Assignee = strings.TrimSpace(*u.Assignee)
+	}
+	if u.Status != nil {
+		it.Status = *u.Status
+	}
+	it.UpdatedBy = strings.TrimSpace(u.By)
+	it.UpdatedAt = time.Now().UTC()
+	it.History = append(it.History, Change{
+		By:       it.UpdatedBy,
+		Assignee: it.Assignee,
+		Status:   it.Status,
+		Note:     strings.TrimSpace(u.Note),
+		At:       it.UpdatedAt,
+	})
+	if len(it.History) > maxHistory {
+		it.History = it.History[len(it.History)-maxHistory:]
+	}
+
+	if err := store.PutJSON(ctx, m.Store, category, messageID, it, 0); err != nil {
+		return Item{}, err
+	}
+	return it, nil
+}
+
+// List returns items matching f, most recently updated first.
+func (m *Manager) List(ctx context.Context, f Filter) ([]Item, error) {
+	keys, err := m.Store.List(ctx, category)
+	if err != nil {
+		return nil, err
+	}
+
+	out := []Item{}
+	for _, k := range keys {
+		it, err := m.Get(ctx, k)
+		if err != nil {
+			continue
+		}
+		if f.QueueName != "" && it.QueueName != f.QueueName {
+			continue
+		}
+		if f.Status != "" && it.Status != f.Status {
+			continue
+		}
+		if f.Assignee != "" && !strings.EqualFold(it.Assignee, f.Assignee) {
+			continue
+		}
+		out = append(out, it)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].UpdatedAt.After(out[j].UpdatedAt) })
+	return out, nil
+}
+
+// Summarize counts items matching f by status and open items by assignee.
+func (m *Manager) Summarize(ctx context.Context, f Filter) (Summary, error) {
+	items, err := m.List(ctx, f)
+	if err != nil {
+		return Summary{}, err
+	}
+
+	sum := Summary{ByStatus: map[Status]int{}, ByAssignee: map[string]int{}}
+	for _, it := range items {
+		sum.Total++
+		sum.ByStatus[it.Status]++
+		if it.Status != StatusOpen {
+			continue
+		}
+		if it.Assignee == "" {
+			sum.UnassignedOpen++
+		} else {
+			sum.ByAssignee[it.Assignee]++
+		}
+	}
+	return sum, nil
+}
+
+// Attach adds a "triage" field to each message that has been triaged.
+func (m *Manager) Attach(ctx context.Context, msgs []map[string]any) {
+	for _, msg := range msgs {
+		id, _ := msg["message_id"].(string)
+		if id == "" {
+			continue
+		}
+		if it, err := m.Get(ctx, id); err == nil {
+			it.History = nil
+			msg["triage"] = it
+		}
+	}
+}
@@ -0,0 +1,162 @@
+// Package updates compares the running build with the latest published release, so
+// long-lived deployments can tell when they have fallen behind.
+package updates
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// errorTTL is how long a failed check is remembered, so an unreachable endpoint isn't
+// asked again on every request (GitHub allows 60 unauthenticated calls an hour).
+const errorTTL = 5 * time.Minute
+
+var httpClient = &http.Client{Timeout: 10 * time.Second}
+
+// Result is the outcome of a check.
+type Result struct {
+	Current         string    `json:"current"`
+	Latest          string    `json:"latest"`
+	UpdateAvailable bool      `json:"update_available"`
+	ReleaseURL      string    `json:"release_url,omitempty"`
+	PublishedAt     time.Time `json:"published_at"`
+	CheckedAt       time.Time `json:"checked_at"`
+	// Note explains why versions couldn't be compared (development builds).
+	Note string `json:"note,omitempty"`
+}
+
+// Checker fetches the latest release from URL (a GitHub "latest release" API address)
+// at most once per TTL.
+type Checker struct {
+	URL     string
+	Current string
+	TTL     time.Duration
+
+	mu     sync.Mutex
+	cached *Result
+	err    error
+	until  time.Time
+}
+
+// release is the part of GitHub's release object used here.
+type release struct {
+	TagName     string    `json:"tag_name"`
+	HTMLURL     string    `json:"html_url"`
+	PublishedAt time.Time `json:"published_at"`
+}
+
+// Check returns the cached result while it is fresh, or asks URL again. Concurrent
+// callers share one request.
+func (c *Checker) Check(ctx context.Context) (Result, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if time.Now().Before(c.until) {
+		if c.err != nil {
+			return Result{}, c.err
+		}
+		return *c.cached, nil
+	}
+
+	rel, err := c.fetch(ctx)
+	if err != nil {
+		c.cached, c.err, c.until = nil, err, time.Now().Add(min(errorTTL, c.TTL))
+		return Result{}, err
+	}
+	res := Result{
+		Current:     c.Current,
+		Latest:      rel.TagName,
+		ReleaseURL:  rel.HTMLURL,
+		PublishedAt: rel.PublishedAt,
+		CheckedAt:   time.Now().UTC(),
+	}
+	current, okCurrent := parseVersion(c.Current)
+	latest, okLatest := parseVersion(rel.TagName)
+	switch {
+	case !okCurrent:
+		res.Note = "running a development build; its version can't be compared"
+	case !okLatest:
+		res.Note = "the latest release tag is not a semantic version"
+	default:
+		res.UpdateAvailable = compare(current, latest) < 0
+	}
+	c.cached, c.err, c.until = &res, nil, time.Now().Add(c.TTL)
+	return res, nil
+}
+
+func (c *Checker) fetch(ctx context.Context) (release, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.URL, nil)
+	if err != nil {
+		return release{}, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return release{}, fmt.Errorf("release check failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return release{}, fmt.Errorf("release check failed: %s returned %s", c.URL, resp.Status)
+	}
+	var rel release
+	if err := json.NewDecoder(resp.Body).Decode(&rel); err != nil {
+		return release{}, fmt.Errorf("release check failed: %w", err)
+	}
+	if rel.TagName == "" {
+		return release{}, fmt.Errorf("release check failed: %s returned no tag_name", c.URL)
+	}
+	return rel, nil
+}
+
+// version is a parsed semantic version; pre is the pre-release suffix, if any.
+type version struct {
+	nums [3]int
+	pre  string
+}
+
+// parseVersion reads "v1.2.3", "1.2" or "1.2.3-rc.1" (build metadata after "+" ignored).
+func parseVersion(s string) (version, bool) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "v")
+	s, _, _ = strings.Cut(s, "+")
+	core, pre, _ := strings.Cut(s, "-")
+	parts := strings.Split(core, ".")
+	if len(parts) == 0 || len(parts) > 3 {
+		return version{}, false
+	}
+	var v version
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return version{}, false
+		}
+		v.nums[i] = n
+	}
+	v.pre = pre
+	return v, true
+}
+
+// compare orders versions; a pre-release sorts before its release.
+func compare(a, b version) int {
+	for i := range a.nums {
+		if a.nums[i] != b.nums[i] {
+			if a.nums[i] < b.nums[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	switch {
+	case a.pre == b.pre:
+		return 0
+	case a.pre == "":
+		return 1
+	case b.pre == "":
+		return -1
+	}
+	return strings.Compare(a.pre, b.pre)
+}
@@ -0,0 +1,120 @@
+// Package validate collects field-level errors for API requests so a client sees every
+// problem at once instead of fixing them one round trip at a time.
+package validate
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// SQS service limits.
+const (
+	MaxDelaySeconds      = 900
+	MaxWaitSeconds       = 20
+	MaxVisibilitySeconds = 43200
+	MaxBatchSize         = 10
+	MaxMessageBytes      = 256 * 1024
+	MaxMessageAttributes = 10
+	MinRetentionSeconds  = 60
+	MaxRetentionSeconds  = 1209600 // 14 days
+	MinMessageBytes      = 1024
+)
+
+// FieldError is one invalid field. Field uses the JSON (or query parameter) name.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Errors is the list of field errors of one request.
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	parts := make([]string, len(e))
+	for i, fe := range e {
+		parts[i] = fe.Field + ": " + fe.Message
+	}
+	return strings.Join(parts, "; ")
+}
+
+// ErrorCode is the API error code for a failed validation.
+func (e Errors) ErrorCode() string { return "validation_failed" }
+
+// Validator accumulates errors; the zero value is ready to use. Each check returns
+// whether it passed so callers can skip dependent checks.
+type Validator struct {
+	errs Errors
+}
+
+// Add records an error for field.
+func (v *Validator) Add(field, format string, args ...any) {
+	v.errs = append(v.errs, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// Check records msg for field unless ok.
+func (v *Validator) Check(ok bool, field, msg string) bool {
+	if !ok {
+		v.Add(field, "%s", msg)
+	}
+	return ok
+}
+
+// Required fails on empty or whitespace-only values.
+func (v *Validator) Required(field, value string) bool {
+	return v.Check(strings.TrimSpace(value) != "", field, "is required")
+}
+
+// NotEmpty fails on an empty list.
+func (v *Validator) NotEmpty(field string, n int) bool {
+	return v.Check(n > 0, field, "cannot be empty")
+}
+
+// Range fails when value is outside [lo, hi].
+func (v *Validator) Range(field string, value, lo, hi int) bool {
+	if value < lo || value > hi {
+		v.Add(field, "must be between %d and %d", lo, hi)
+		return false
+	}
+	return true
+}
+
+// MaxBytes fails when value is longer than max bytes.
+func (v *Validator) MaxBytes(field, value string, max int) bool {
+	if len(value) > max {
+		v.Add(field, "must be at most %d bytes", max)
+		return false
+	}
+	return true
+}
+
+// OneOf fails when value is not one of allowed. Empty values pass; pair with Required.
+func (v *Validator) OneOf(field, value string, allowed ...string) bool {
+	if value == "" || slices.Contains(allowed, value) {
+		return true
+	}
+	v.Add(field, "must be one of %s", strings.Join(allowed, ", "))
+	return false
+}
+
+// Merge adds the field errors of err, as returned by another package's validation.
+// Any other non-nil error is recorded under field.
+func (v *Validator) Merge(field string, err error) {
+	var errs Errors
+	switch {
+	case err == nil:
+	case errors.As(err, &errs):
+		v.errs = append(v.errs, errs...)
+	default:
+		v.Add(field, "%v", err)
+	}
+}
+
+// Err returns the collected Errors, or nil when every check passed.
+func (v *Validator) Err() error {
+	if len(v.errs) == 0 {
+		return nil
+	}
+	return v.errs
+}
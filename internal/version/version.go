@@ -7,4 +7,8 @@ var (
 	Version   = "dev"
 	Commit    = "none"
 	BuildTime = "unknown"
+
+	// AssetHash identifies the served web assets. When not injected at build time
+	// it is computed from the asset contents at startup.
+	AssetHash = ""
 )
@@ -0,0 +1,135 @@
+package watch
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/pachecoc/sqs-ui/internal/lifecycle"
+	"github.com/pachecoc/sqs-ui/internal/service"
+)
+
+// DepthPoller samples the active queue's depth every Interval and keeps the last Window of
+// samples per queue in a ring buffer, so clients can draw depth over time without each
+// page load calling GetQueueAttributes.
+type DepthPoller struct {
+	Service  func() *service.SQSService
+	Log      *slog.Logger
+	Interval time.Duration
+	Window   time.Duration
+
+	mu      sync.Mutex
+	history map[string]*depthRing // by queue name
+}
+
+// DepthSample is one depth reading.
+type DepthSample struct {
+	At time.Time `json:"at"`
+	service.QueueCounts
+}
+
+// DepthHistory is the recorded depth of one queue, oldest sample first.
+type DepthHistory struct {
+	QueueName       string        `json:"queue_name"`
+	IntervalSeconds int           `json:"interval_seconds"`
+	WindowSeconds   int           `json:"window_seconds"`
+	Samples         []DepthSample `json:"samples"`
+}
+
+// depthRing is a fixed-size ring buffer of samples.
+type depthRing struct {
+	samples []DepthSample
+	next    int
+	full    bool
+}
+
+func (r *depthRing) add(s DepthSample) {
+	r.samples[r.next] = s
+	r.next = (r.next + 1) % len(r.samples)
+	r.full = r.full || r.next == 0
+}
+
+// ordered returns the samples oldest first.
+func (r *depthRing) ordered() []DepthSample {
+	if !r.full {
+		return append([]DepthSample(nil), r.samples[:r.next]...)
+	}
+	return append(append([]DepthSample(nil), r.samples[r.next:]...), r.samples[:r.next]...)
+}
+
+func (r *depthRing) last() DepthSample {
+	return r.samples[(r.next-1+len(r.samples))%len(r.samples)]
+}
+
+// Run samples the active queue every Interval until ctx is canceled.
+func (p *DepthPoller) Run(ctx context.Context) {
+	p.Log.Info("depth poller started", "interval_seconds", p.Interval.Seconds(), "window_minutes", p.Window.Minutes())
+	ticker := time.NewTicker(p.Interval)
+	defer ticker.Stop()
+	for {
+		if svc := p.Service(); svc != nil && svc.QueueURL != "" && svc.Client != nil {
+			counts, err := svc.Counts(ctx)
+			if err != nil {
+				p.Log.Debug("depth sample failed", "queue_name", svc.QueueName, "error", err)
+			} else {
+				p.observe(svc.QueueName, DepthSample{At: time.Now().UTC(), QueueCounts: counts})
+			}
+			lifecycle.Report(ctx, err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *DepthPoller) observe(queue string, s DepthSample) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.history == nil {
+		p.history = make(map[string]*depthRing)
+	}
+	ring, ok := p.history[queue]
+	if !ok {
+		ring = &depthRing{samples: make([]DepthSample, p.capacity())}
+		p.history[queue] = ring
+	}
+	ring.add(s)
+
+	// Queues that stopped being sampled age out once their newest sample leaves the window
+	cutoff := s.At.Add(-p.Window)
+	for name, r := range p.history {
+		if r.last().At.Before(cutoff) {
+			delete(p.history, name)
+		}
+	}
+}
+
+// capacity is how many samples cover the window.
+func (p *DepthPoller) capacity() int {
+	return max(1, int(p.Window/p.Interval))
+}
+
+// History returns the recorded depth of queue within the window; Samples is empty when
+// the queue hasn't been sampled.
+func (p *DepthPoller) History(queue string) DepthHistory {
+	h := DepthHistory{
+		QueueName:       queue,
+		IntervalSeconds: int(p.Interval.Seconds()),
+		WindowSeconds:   int(p.Window.Seconds()),
+		Samples:         []DepthSample{},
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if ring, ok := p.history[queue]; ok {
+		cutoff := time.Now().Add(-p.Window)
+		for _, s := range ring.ordered() {
+			if !s.At.Before(cutoff) {
+				h.Samples = append(h.Samples, s)
+			}
+		}
+	}
+	return h
+}
@@ -0,0 +1,142 @@
+package watch
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/pachecoc/sqs-ui/internal/lifecycle"
+	"github.com/pachecoc/sqs-ui/internal/service"
+)
+
+// minStuckHistory is how much in-flight history a stuck estimate needs to mean anything.
+const minStuckHistory = 10 * time.Minute
+
+// InFlightTracker records the active queue's in-flight (NotVisible) count over time. A floor that
+// never drops to zero across the window suggests messages held by crashed or hung consumers.
+type InFlightTracker struct {
+	Service  func() *service.SQSService
+	Log      *slog.Logger
+	Interval time.Duration
+	Window   time.Duration
+
+	mu      sync.Mutex
+	samples map[string][]inFlightSample // by queue name
+}
+
+type inFlightSample struct {
+	At         time.Time
+	NotVisible int64
+}
+
+// InFlightReport estimates how many messages are stuck in flight on a queue and since when.
+type InFlightReport struct {
+	QueueName                string               `json:"queue_name"`
+	Counts                   service.QueueCounts  `json:"counts"`
+	VisibilityTimeoutSeconds int                  `json:"visibility_timeout_seconds"`
+	HistorySeconds           int                  `json:"history_seconds"`
+	StuckEstimate            *int64               `json:"stuck_estimate"` // lowest in-flight count over the window
+	StuckSince               *time.Time           `json:"stuck_since,omitempty"`
+	Sample                   service.ReceiveStats `json:"sample"`
+	Findings                 []string             `json:"findings"`
+}
+
+// Run samples the active queue every Interval until ctx is canceled.
+func (t *InFlightTracker) Run(ctx context.Context) {
+	ticker := time.NewTicker(t.Interval)
+	defer ticker.Stop()
+	for {
+		if svc := t.Service(); svc != nil && svc.QueueURL != "" && svc.Client != nil {
+			counts, err := svc.Counts(ctx)
+			if err != nil {
+				t.Log.Debug("in-flight sample failed", "error", err)
+			} else {
+				t.observe(svc.QueueName, counts.NotVisible, time.Now())
+			}
+			lifecycle.Report(ctx, err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (t *InFlightTracker) observe(queue string, notVisible int64, at time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.samples == nil {
+		t.samples = make(map[string][]inFlightSample)
+	}
+
+	list := append(t.samples[queue], inFlightSample{At: at, NotVisible: notVisible})
+	cutoff := at.Add(-t.Window)
+	for len(list) > 0 && list[0].At.Before(cutoff) {
+		list = list[1:]
+	}
+	t.samples[queue] = list
+}
+
+// Report combines the recorded history with fresh counts and a receive sample for svc's queue.
+func (t *InFlightTracker) Report(ctx context.Context, svc *service.SQSService) (InFlightReport, error) {
+	rep := InFlightReport{QueueName: svc.QueueName}
+	counts, err := svc.Counts(ctx)
+	if err != nil {
+		return rep, err
+	}
+	rep.Counts = counts
+	now := time.Now()
+	t.observe(svc.QueueName, counts.NotVisible, now)
+
+	if vt, err := svc.VisibilityTimeout(ctx); err == nil {
+		rep.VisibilityTimeoutSeconds = int(vt.Seconds())
+	}
+	if rep.Sample, err = svc.SampleReceiveStats(ctx); err != nil {
+		return rep, err
+	}
+
+	t.mu.Lock()
+	history := append([]inFlightSample(nil), t.samples[svc.QueueName]...)
+	t.mu.Unlock()
+
+	span := now.Sub(history[0].At)
+	rep.HistorySeconds = int(span.Seconds())
+	floor := history[0].NotVisible
+	for _, s := range history {
+		floor = min(floor, s.NotVisible)
+	}
+	// The current unbroken run of non-zero in-flight counts
+	for i := len(history) - 1; i >= 0 && history[i].NotVisible > 0; i-- {
+		at := history[i].At.UTC()
+		rep.StuckSince = &at
+	}
+	if span >= minStuckHistory {
+		rep.StuckEstimate = &floor
+	}
+
+	rep.Findings = rep.findings(span)
+	return rep, nil
+}
+
+func (r InFlightReport) findings(span time.Duration) []string {
+	var out []string
+	switch {
+	case r.StuckEstimate == nil:
+		out = append(out, fmt.Sprintf("only %s of in-flight history; an estimate needs %s", span.Round(time.Second), minStuckHistory))
+	case *r.StuckEstimate == 0:
+		out = append(out, "in-flight count dropped to zero within the window; nothing looks stuck")
+	default:
+		out = append(out, fmt.Sprintf("at least %d messages stayed in flight for the whole %s window", *r.StuckEstimate, span.Round(time.Minute)))
+		if r.VisibilityTimeoutSeconds > 0 && r.StuckSince != nil && time.Since(*r.StuckSince) > time.Duration(r.VisibilityTimeoutSeconds)*time.Second {
+			out = append(out, "in flight longer than the visibility timeout: consumers are extending visibility or new receives keep replacing expired ones")
+		}
+	}
+	if r.Sample.Redelivered > 0 {
+		out = append(out, fmt.Sprintf("%d of %d sampled messages were received before without being deleted (max %d times); consumers may be crashing mid-processing",
+			r.Sample.Redelivered, r.Sample.Sampled, r.Sample.MaxReceiveCount))
+	}
+	return out
+}
@@ -0,0 +1,130 @@
+package watch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+
+	"github.com/pachecoc/sqs-ui/internal/events"
+	"github.com/pachecoc/sqs-ui/internal/lifecycle"
+	"github.com/pachecoc/sqs-ui/internal/service"
+)
+
+// credentialWarnWindow is how far ahead of expiry a credentials warning is raised.
+const credentialWarnWindow = 10 * time.Minute
+
+// Watcher periodically inspects the active queue and AWS credentials and publishes
+// notifications (depth threshold crossings, credential expiry) to the events hub.
+type Watcher struct {
+	Service        func() *service.SQSService
+	Events         *events.Hub
+	Log            *slog.Logger
+	Interval       time.Duration
+	DepthThreshold int64 // 0 disables depth alerts
+
+	// Leader, when set, limits checks to the elected replica so alerts aren't duplicated.
+	Leader func() bool
+
+	aboveThreshold bool
+	warnedExpiry   time.Time
+}
+
+// Run blocks until ctx is canceled.
+func (w *Watcher) Run(ctx context.Context) {
+	w.Log.Info("watcher started", "interval_seconds", w.Interval.Seconds(), "depth_threshold", w.DepthThreshold)
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+
+	for {
+		w.check(ctx)
+		select {
+		case <-ctx.Done():
+			w.Log.Info("watcher stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (w *Watcher) check(ctx context.Context) {
+	if w.Leader != nil && !w.Leader() {
+		return
+	}
+	svc := w.Service()
+	if svc == nil || svc.Client == nil {
+		return
+	}
+	err := w.checkCredentials(ctx, svc)
+	if w.DepthThreshold > 0 && svc.EnsureQueueConfigured() == nil {
+		err = errors.Join(err, w.checkDepth(ctx, svc))
+	}
+	lifecycle.Report(ctx, err)
+}
+
+// checkCredentials warns once per credential set when expiry is near.
+func (w *Watcher) checkCredentials(ctx context.Context, svc *service.SQSService) error {
+	client, ok := svc.Client.(interface{ Options() sqs.Options })
+	if !ok {
+		return nil // no AWS credentials behind clients without SDK options (demo mode)
+	}
+	provider := client.Options().Credentials
+	if provider == nil {
+		return nil
+	}
+	creds, err := provider.Retrieve(ctx)
+	if err != nil {
+		w.Log.Debug("credential check failed", "error", err)
+		return fmt.Errorf("credential check failed: %w", err)
+	}
+	if !creds.CanExpire || creds.Expires.Equal(w.warnedExpiry) {
+		return nil
+	}
+	if remaining := time.Until(creds.Expires); remaining < credentialWarnWindow {
+		w.warnedExpiry = creds.Expires
+		w.Events.Publish(events.Event{
+			Type:    events.TypeCredentialsExpiring,
+			Level:   events.LevelWarn,
+			Message: fmt.Sprintf("AWS credentials expire in %s", remaining.Round(time.Second)),
+			Data:    map[string]any{"expires_at": creds.Expires.UTC()},
+		})
+	}
+	return nil
+}
+
+// checkDepth publishes when total depth crosses the threshold in either direction.
+func (w *Watcher) checkDepth(ctx context.Context, svc *service.SQSService) error {
+	info := svc.Info(ctx)
+	if info["status"] != "ok" {
+		return fmt.Errorf("depth check failed: %v", info["error"])
+	}
+	total, _ := strconv.ParseInt(fmt.Sprint(info["number_of_messages"]), 10, 64)
+
+	above := total >= w.DepthThreshold
+	if above == w.aboveThreshold {
+		return nil
+	}
+	w.aboveThreshold = above
+
+	e := events.Event{
+		Type: events.TypeAlertThreshold,
+		Data: map[string]any{
+			"queue_name": svc.QueueName,
+			"depth":      total,
+			"threshold":  w.DepthThreshold,
+		},
+	}
+	if above {
+		e.Level = events.LevelWarn
+		e.Message = fmt.Sprintf("queue %s depth %d reached threshold %d", svc.QueueName, total, w.DepthThreshold)
+	} else {
+		e.Level = events.LevelInfo
+		e.Message = fmt.Sprintf("queue %s depth %d back below threshold %d", svc.QueueName, total, w.DepthThreshold)
+	}
+	w.Events.Publish(e)
+	return nil
+}
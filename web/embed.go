@@ -0,0 +1,10 @@
+// Package web holds the browser UI's static assets. They're embedded so the binary serves
+// them wherever it runs, including FROM scratch images without the folder.
+package web
+
+import "embed"
+
+// Assets is the web UI: index.html, css/, js/ and assets/.
+//
+//go:embed index.html css js assets
+var Assets embed.FS
@@ -1,17 +1,36 @@
 'use strict';
 
-// HTTP helper (JSON if possible)
+// A frontend served from another origin (e.g. a CDN) sets <meta name="sqs-ui-api-base"> to
+// the API's origin; the server must list that origin in CORS_ALLOWED_ORIGINS and allow
+// credentials. Empty means the API is on this page's origin.
+window.apiBase = (document.querySelector('meta[name="sqs-ui-api-base"]')?.content || '').replace(/\/+$/, '');
+// Behind a reverse proxy with BASE_PATH the server sets <base href> to it; an apiBase on
+// another origin includes it itself.
+window.basePath = new URL(document.baseURI).pathname.replace(/\/+$/, '');
+window.apiURL = (path) => (window.apiBase || window.basePath) + path;
+// The page's path without the base path, as the server routes it
+window.appPath = () => location.pathname.slice(window.basePath.length) || '/';
+
+// HTTP helper (JSON if possible). JSON responses arrive in a { data, error, meta }
+// envelope; callers get data back and errors are thrown with error.message (and the
+// request ID as error.requestId).
+// options.onResponse, when set, sees the raw Response (e.g. to read headers). A 304 reply
+// to a conditional request returns api.NOT_MODIFIED.
 window.api = async function api(path, options = {}) {
-    const method = (options.method || 'GET').toUpperCase();
+    const { onResponse, ...init } = options;
+    const method = (init.method || 'GET').toUpperCase();
     const headers = {
         'Accept': 'application/json',
-        ...(options.headers || {})
+        ...(init.headers || {})
     };
-    const res = await fetch(path, {
-        ...options,
+    const res = await fetch(window.apiURL(path), {
+        credentials: window.apiBase ? 'include' : 'same-origin',
+        ...init,
         method,
         headers
     });
+    if (onResponse) onResponse(res);
+    if (res.status === 304) return window.api.NOT_MODIFIED;
 
     const raw = await res.text();
     let data;
@@ -22,11 +41,28 @@ window.api = async function api(path, options = {}) {
         return raw;
     }
 
+    // With OIDC sign-in, an expired session sends the page back through the provider
+    if (res.status === 401 && !path.startsWith('/auth/')) {
+        window.location.href = window.basePath + '/auth/login?return_to=' + encodeURIComponent(window.appPath() + location.search);
+    }
     if (!res.ok) {
-        const msg = (data && (data.detail || data.error)) || raw || `HTTP ${res.status}`;
-        const err = new Error(msg);
+        const e = data && data.error;
+        const msg = (e && e.message) || raw || `HTTP ${res.status}`;
+        // Server failures name the request, to find it in the server logs
+        const requestId = (data && data.meta && data.meta.request_id) || res.headers.get('X-Request-ID');
+        let text = e && e.hint ? `${msg}. ${e.hint}` : msg;
+        if (res.status >= 500 && requestId) text += ` (request ${requestId})`;
+        const err = new Error(text);
         err.status = res.status;
+        err.requestId = requestId;
+        err.code = e && e.code;
+        err.hint = e && e.hint;
         throw err;
     }
-    return data;
+    // Queue info and listings suggest when to poll next (busy queues often, idle ones rarely)
+    const hint = data && data.meta && data.meta.poll_interval_seconds;
+    if (hint > 0) window.pollIntervalSeconds = hint;
+    return data && typeof data === 'object' && 'data' in data ? data.data : data;
 };
+
+window.api.NOT_MODIFIED = Symbol('not modified');
@@ -4,8 +4,8 @@
 let lastQueueInfo = null;
 let pendingFetchInfo = false;
 
-// Fetch queue info
-window.fetchInfo = async function fetchInfo() {
+// Fetch queue info; refresh bypasses the server's attribute cache
+window.fetchInfo = async function fetchInfo(refresh = false) {
     if (pendingFetchInfo) return;
     const infoOut = document.getElementById('infoOut');
     const msgOut = document.getElementById('msgOut');
@@ -13,7 +13,7 @@ window.fetchInfo = async function fetchInfo() {
     if (infoOut) infoOut.innerHTML = '<p>Fetching queue info...</p>';
     pendingFetchInfo = true;
     try {
-        const info = await api('/info');
+        const info = await api(linkedPath(refresh === true ? '/info?refresh=true' : '/info'));
         lastQueueInfo = info || null;
 
         if (info) {
@@ -38,11 +38,42 @@ window.fetchInfo = async function fetchInfo() {
     }
 };
 
+// Depth history is sampled by the server; re-read it at the sampling interval
+let depthTimer = null;
+
+window.loadDepthHistory = async function loadDepthHistory() {
+    clearTimeout(depthTimer);
+    let history;
+    try {
+        history = await api(linkedPath('/api/metrics/history'));
+    } catch (err) {
+        // Disabled on the server (503) or no queue yet: leave the panel empty
+        const out = document.getElementById('depthOut');
+        if (out) out.innerHTML = '';
+        if (err.status === 503) return;
+        depthTimer = setTimeout(loadDepthHistory, 60000);
+        return;
+    }
+    window.renderDepthSparkline(history);
+    depthTimer = setTimeout(loadDepthHistory, Math.max(5, history.interval_seconds || 60) * 1000);
+};
+
 // Refresh info and messages
 window.refreshInfoAndMessages = async function refreshInfoAndMessages() {
     await Promise.allSettled([fetchInfo(), fetchMessages()]);
 };
 
+// Start or stop the live tail, keeping the button label in sync
+window.toggleMessageTail = function toggleMessageTail() {
+    const btn = document.getElementById('tailMessagesBtn');
+    if (isTailing()) {
+        stopMessageTail();
+    } else {
+        startMessageTail();
+    }
+    if (btn) btn.textContent = isTailing() ? 'Stop Tail' : 'Live Tail';
+};
+
 // Build app skeleton
 function renderAppSkeleton() {
     const root = document.getElementById('app-root');
@@ -55,6 +86,9 @@ function renderAppSkeleton() {
       <button id="fetchInfoBtn" type="button" class="bg-gray-500 hover:bg-gray-600 text-white px-4 py-2 rounded shadow">
         Fetch Queue Info
       </button>
+      <button id="copyLinkBtn" type="button" class="bg-gray-500 hover:bg-gray-600 text-white px-4 py-2 rounded shadow">
+        Copy Link
+      </button>
     </div>
 
     <div class="bg-gray-50 border border-gray-200 rounded-lg p-4 text-left text-sm font-mono mb-6">
@@ -62,12 +96,22 @@ function renderAppSkeleton() {
       <div id="infoOut" class="whitespace-pre-wrap break-words text-sm text-gray-700">
         <p class="text-gray-400 italic">Click “Fetch Queue Info” to view queue details...</p>
       </div>
+      <div id="depthOut" class="mt-2"></div>
     </div>
 
     <div class="flex flex-wrap justify-center gap-3 mb-6">
       <button id="fetchMessagesBtn" type="button" class="bg-green-500 hover:bg-green-600 text-white px-4 py-2 rounded shadow">
         Fetch Messages
       </button>
+      <button id="tailMessagesBtn" type="button" class="bg-teal-500 hover:bg-teal-600 text-white px-4 py-2 rounded shadow">
+        Live Tail
+      </button>
+      <button id="shareMessagesBtn" type="button" class="bg-indigo-500 hover:bg-indigo-600 text-white px-4 py-2 rounded shadow">
+        Share
+      </button>
+      <button id="queueReportBtn" type="button" class="bg-slate-500 hover:bg-slate-600 text-white px-4 py-2 rounded shadow">
+        Report
+      </button>
       <button id="purgeQueueBtn" type="button" class="bg-red-500 hover:bg-red-600 text-white px-4 py-2 rounded shadow">
         Purge Queue
       </button>
@@ -95,6 +139,16 @@ function renderAppSkeleton() {
       <h2 class="text-lg font-semibold mb-2">Send a Message</h2>
       <textarea id="msgInput" rows="4" class="w-full border border-gray-300 rounded-md p-2 focus:ring-blue-500 focus:border-blue-500 mb-2 resize-y font-mono text-sm bg-white text-gray-700"
         placeholder="Type your message here..."></textarea>
+      <input id="attrInput" type="text" class="w-full border border-gray-300 rounded-md p-2 focus:ring-blue-500 focus:border-blue-500 mb-2 font-mono text-sm bg-white text-gray-700"
+        placeholder='Attributes (optional JSON): {"tenant": {"type": "String", "value": "acme"}}'>
+      <div class="flex gap-2">
+        <input id="delayInput" type="number" min="0" max="900" class="w-48 border border-gray-300 rounded-md p-2 focus:ring-blue-500 focus:border-blue-500 mb-2 text-sm bg-white text-gray-700"
+          placeholder="Delay seconds (0-900)">
+        <input id="groupInput" type="text" maxlength="128" class="w-64 border border-gray-300 rounded-md p-2 focus:ring-blue-500 focus:border-blue-500 mb-2 font-mono text-sm bg-white text-gray-700"
+          placeholder="Message group ID (optional)">
+        <input id="dedupInput" type="text" maxlength="128" class="w-64 border border-gray-300 rounded-md p-2 focus:ring-blue-500 focus:border-blue-500 mb-2 font-mono text-sm bg-white text-gray-700"
+          placeholder="Deduplication ID (FIFO, optional)">
+      </div>
       <div id="sendStatus" class="text-sm mb-2 min-h-[1.5rem] text-gray-700">:)</div>
     </div>
 
@@ -108,8 +162,9 @@ function renderAppSkeleton() {
       <div class="bg-white rounded-lg shadow-lg p-6 w-[40rem] max-w-full text-left">
         <h2 class="text-xl font-semibold mb-4">Change Queue</h2>
         <label class="block text-sm font-medium text-gray-700 mb-1">Queue Name (ignored if URL is set)</label>
-        <input id="queueNameInput" type="text" placeholder="example-queue"
+        <input id="queueNameInput" type="text" placeholder="example-queue" list="queueOptions" autocomplete="off"
           class="w-full border border-gray-300 rounded-md p-2 mb-3 focus:ring-blue-500 focus:border-blue-500" />
+        <datalist id="queueOptions"></datalist>
         <label class="block text-sm font-medium text-gray-700 mb-1">Queue URL (required only for cross-account/region queue)</label>
         <input id="queueUrlInput" type="text" placeholder="https://sqs.us-east-1.amazonaws.com/123456789012/example-queue"
           class="w-full border border-gray-300 rounded-md p-2 mb-4 font-mono text-sm focus:ring-blue-500 focus:border-blue-500" />
@@ -137,8 +192,13 @@ function wireEvents() {
     const byId = (id) => document.getElementById(id);
 
     byId('changeQueueBtn')?.addEventListener('click', openQueueDialog);
-    byId('fetchInfoBtn')?.addEventListener('click', fetchInfo);
+    byId('fetchInfoBtn')?.addEventListener('click', () => fetchInfo(true));
+    byId('copyLinkBtn')?.addEventListener('click', () => copyQueueLink(lastQueueInfo));
     byId('fetchMessagesBtn')?.addEventListener('click', fetchMessages);
+    byId('tailMessagesBtn')?.addEventListener('click', toggleMessageTail);
+    byId('shareMessagesBtn')?.addEventListener('click', shareMessages);
+    // The incident report is a standalone printable page
+    byId('queueReportBtn')?.addEventListener('click', () => window.open(window.apiURL(linkedPath('/api/queue/report?format=html')), '_blank', 'noopener'));
     byId('purgeQueueBtn')?.addEventListener('click', purgeQueue);
     byId('sendMessageBtn')?.addEventListener('click', sendMessage);
     byId('queueCancelBtn')?.addEventListener('click', closeQueueDialog);
@@ -149,5 +209,11 @@ function wireEvents() {
 window.addEventListener('DOMContentLoaded', async () => {
     renderAppSkeleton();
     wireEvents();
+    await openLink();
     await fetchInfo();
+    startInfoStream();
+    loadDepthHistory();
+    // A shared listing opens filled in, unless fetching it would consume messages
+    if (window.openedLink && window.openedLink.mode !== 'consume') fetchMessages();
+    startEventsStream();
 });
@@ -0,0 +1,51 @@
+'use strict';
+
+// Backend-driven notifications rendered as toasts
+let eventsStream = null;
+
+const toastStyles = {
+    info: 'bg-blue-50 border-blue-200 text-blue-800',
+    warn: 'bg-amber-50 border-amber-200 text-amber-800',
+    error: 'bg-red-50 border-red-200 text-red-700',
+};
+
+// Show a toast that disappears after a few seconds
+window.showToast = function showToast(message, level = 'info') {
+    let container = document.getElementById('toastContainer');
+    if (!container) {
+        container = document.createElement('div');
+        container.id = 'toastContainer';
+        container.className = 'fixed top-4 right-4 z-50 flex flex-col gap-2 w-80';
+        document.body.appendChild(container);
+    }
+
+    const toast = document.createElement('div');
+    toast.className = `border rounded shadow px-3 py-2 text-sm text-left ${toastStyles[level] || toastStyles.info}`;
+    toast.textContent = message;
+    container.appendChild(toast);
+
+    setTimeout(() => {
+        if (toast.isConnected) toast.remove();
+    }, 8000);
+};
+
+window.startEventsStream = function startEventsStream() {
+    if (!window.EventSource || eventsStream) return;
+
+    eventsStream = new EventSource(window.apiURL('/api/events'), { withCredentials: !!window.apiBase });
+    eventsStream.addEventListener('notification', (ev) => {
+        let note;
+        try {
+            note = JSON.parse(ev.data);
+        } catch {
+            return;
+        }
+        if (note && note.message) {
+            window.showToast(note.message, note.level);
+        }
+    });
+    // The server is restarting; EventSource reconnects after the retry hint it sent
+    eventsStream.addEventListener('shutdown', () => {
+        window.showToast('Server is restarting, reconnecting…', 'warn');
+    });
+};
@@ -0,0 +1,90 @@
+'use strict';
+
+// Share links (/q/{region}/{account}/{name}?filter=...) open the UI on one queue with the
+// listing settings they carry. The page then sends ?queue= with its requests instead of
+// changing the server's default queue for everyone else.
+window.openedLink = null;
+
+const linkListingParams = ['filter', 'transform', 'label', 'mode'];
+
+// Add the opened link's queue to an API path, and its listing settings to listings
+window.linkedPath = function linkedPath(path) {
+    const link = window.openedLink;
+    if (!link) return path;
+    const params = new URLSearchParams({ queue: link.queue_name });
+    const route = path.split('?')[0];
+    if (route === '/api/messages' || route === '/api/messages/stream') {
+        for (const key of linkListingParams) {
+            if (link[key]) params.set(key, link[key]);
+        }
+    }
+    return path + (path.includes('?') ? '&' : '?') + params.toString();
+};
+
+// Resolve the share link in the address bar, if there is one
+window.openLink = async function openLink() {
+    if (!window.appPath().startsWith('/q/')) return;
+    try {
+        window.openedLink = await api('/api' + window.appPath() + location.search);
+        history.replaceState(null, '', window.openedLink.link);
+    } catch (err) {
+        window.openedLink = null;
+        history.replaceState(null, '', window.basePath + '/');
+        const msgOut = document.getElementById('msgOut');
+        if (msgOut) window.renderError(msgOut, 'Could not open the shared link', err.message, 'Ask for a new link, or pick the queue with “Change Queue”.');
+    }
+};
+
+// Go back to the server's default queue (after “Change Queue”)
+window.leaveLink = function leaveLink() {
+    if (!window.openedLink) return;
+    window.openedLink = null;
+    history.replaceState(null, '', window.basePath + '/');
+};
+
+// Copy a share link to the queue on screen
+window.copyQueueLink = async function copyQueueLink(info) {
+    let link = window.openedLink && window.openedLink.link;
+    if (!link && info && info.queue_url && info.current_region) {
+        const account = new URL(info.queue_url).pathname.split('/')[1];
+        link = `${window.basePath}/q/${info.current_region}/${account}/${info.queue_name}`;
+    }
+    if (!link) {
+        window.showToast('Fetch the queue info first.', 'warn');
+        return;
+    }
+    const url = new URL(link, location.origin).toString();
+    try {
+        await navigator.clipboard.writeText(url);
+        window.showToast('Link copied.');
+    } catch {
+        window.prompt('Copy this link:', url);
+    }
+};
+
+// Create a signed read-only link to the last listing (or to the queue before one is fetched)
+// for someone without an account, and copy it
+window.shareMessages = async function shareMessages() {
+    const snapshotId = lastMessages.data && lastMessages.data.snapshot_id;
+    const body = snapshotId ? { snapshot_id: snapshotId } : {};
+    if (!snapshotId && window.openedLink) body.queue = window.openedLink.queue_name;
+    let created;
+    try {
+        created = await api('/api/shares', {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify(body)
+        });
+    } catch (err) {
+        window.showToast(`Could not create a share link: ${err.message}`, 'error');
+        return;
+    }
+    const url = new URL(created.url, window.apiBase || location.origin).toString();
+    const until = new Date(created.share.expires_at).toLocaleString();
+    try {
+        await navigator.clipboard.writeText(url);
+        window.showToast(`Read-only link copied; it works until ${until}.`);
+    } catch {
+        window.prompt(`Read-only link (works until ${until}):`, url);
+    }
+};
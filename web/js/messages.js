@@ -7,15 +7,29 @@ let sendTimer = null;
 let pendingFetchMessages = false;
 let pendingSendMessage = false;
 
+// Last listing and its ETag, for conditional re-fetches
+const lastMessages = { etag: null, data: null };
+
 // Fetch messages
 window.fetchMessages = async function fetchMessages() {
   if (pendingFetchMessages) return;
   const msgOut = document.getElementById('msgOut');
   if (!msgOut) return;
+  // A listing replaces the live tail in the messages panel
+  if (window.isTailing()) window.toggleMessageTail();
   pendingFetchMessages = true;
   msgOut.textContent = 'Fetching messages...';
   try {
-    const data = await api('/api/messages');
+    // Skip the receive when the queue depth hasn't changed since the last listing
+    const headers = lastMessages.etag ? { 'If-None-Match': lastMessages.etag } : {};
+    let etag = null;
+    const data = await api(linkedPath('/api/messages'), { headers, onResponse: (res) => { etag = res.headers.get('ETag'); } });
+    if (data === api.NOT_MODIFIED) {
+      renderMessages(lastMessages.data);
+      return;
+    }
+    lastMessages.etag = etag;
+    lastMessages.data = data;
     renderMessages(data);
   } catch (err) {
     renderError(msgOut, 'Failed to fetch messages:', err.message, 'Check queue settings and credentials provided.');
@@ -38,6 +52,24 @@ window.sendMessage = async function sendMessage() {
     return;
   }
 
+  const attrBox = document.getElementById('attrInput');
+  let attributes;
+  if (attrBox && attrBox.value.trim()) {
+    try {
+      attributes = JSON.parse(attrBox.value);
+    } catch {
+      sendStatus.innerHTML = '<p class="text-red-600">Attributes must be a JSON object.</p>';
+      return;
+    }
+  }
+
+  const delayBox = document.getElementById('delayInput');
+  const delaySeconds = delayBox && delayBox.value ? Number(delayBox.value) : 0;
+  const groupBox = document.getElementById('groupInput');
+  const messageGroupId = groupBox ? groupBox.value.trim() : '';
+  const dedupBox = document.getElementById('dedupInput');
+  const dedupId = dedupBox ? dedupBox.value.trim() : '';
+
   pendingSendMessage = true;
   sendStatus.textContent = '';
   const statusP = document.createElement('p');
@@ -46,14 +78,20 @@ window.sendMessage = async function sendMessage() {
   sendStatus.appendChild(statusP);
 
   try {
-    await api('/api/send', {
+    const sent = await api(linkedPath('/api/send'), {
       method: 'POST',
       headers: { 'Content-Type': 'application/json' },
-      body: JSON.stringify({ message: msg })
+      body: JSON.stringify({ message: msg, attributes, delay_seconds: delaySeconds, message_group_id: messageGroupId, dedup_id: dedupId })
     });
 
     msgBox.value = '';
     sendStatus.innerHTML = '<p class="text-green-600 font-semibold mb-1">Message sent successfully.</p>';
+    if (sent && sent.message_id) {
+      const idEl = document.createElement('p');
+      idEl.className = 'text-gray-600 text-xs font-mono';
+      idEl.textContent = `MessageId ${sent.message_id}` + (sent.sequence_number ? ` · SequenceNumber ${sent.sequence_number}` : '');
+      sendStatus.appendChild(idEl);
+    }
 
     if (sendTimer) {
       clearInterval(sendTimer);
@@ -149,12 +187,19 @@ window.purgeQueue = async function purgeQueue() {
   const msgOut = document.getElementById('msgOut');
   if (!msgOut) return;
 
-  const confirmed = await window.confirmDialog('This will delete all messages from the queue. Continue?');
-  if (!confirmed) return;
-
   try {
+    // The first call only plans the purge; its confirm token runs it
+    const plan = await api(linkedPath('/api/purge'), { method: 'POST' });
+    const confirmed = await window.confirmDialog(
+      `This will delete about ${plan.count} message(s) from ${plan.queue_name}, in flight included. Continue?`);
+    if (!confirmed) return;
+
     msgOut.textContent = 'Purging queue...';
-    await api('/api/purge', { method: 'POST' });
+    await api(linkedPath('/api/purge'), {
+      method: 'POST',
+      headers: { 'Content-Type': 'application/json' },
+      body: JSON.stringify({ confirm: plan.confirm })
+    });
 
     msgOut.innerHTML = `
       <p class="text-green-600 font-semibold mb-1">Queue purged.</p>
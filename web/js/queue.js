@@ -7,9 +7,33 @@ window.openQueueDialog = function openQueueDialog() {
     dlg.classList.remove('hidden');
     dlg.classList.add('flex');
     const first = document.getElementById('queueNameInput');
-    if (first) first.focus();
+    if (first) {
+        first.focus();
+        first.oninput = () => loadQueueOptions(first.value.trim());
+    }
+    loadQueueOptions('');
 };
 
+// Fill the queue name suggestions from /api/queues (best effort; the name can still be typed)
+let queueOptionsTimer = null;
+function loadQueueOptions(prefix) {
+    clearTimeout(queueOptionsTimer);
+    queueOptionsTimer = setTimeout(async () => {
+        const list = document.getElementById('queueOptions');
+        if (!list || !/^[A-Za-z0-9_-]*$/.test(prefix)) return;
+        try {
+            const page = await api(`/api/queues?limit=100&prefix=${encodeURIComponent(prefix)}`);
+            list.replaceChildren(...page.queues.map(q => {
+                const opt = document.createElement('option');
+                opt.value = q.name;
+                return opt;
+            }));
+        } catch (err) {
+            console.warn('could not list queues', err);
+        }
+    }, 250);
+}
+
 // Close queue config dialog
 window.closeQueueDialog = function closeQueueDialog() {
     const dialog = document.getElementById('queueDialog');
@@ -63,7 +87,18 @@ window.updateQueueConfig = async function updateQueueConfig() {
         });
 
         closeQueueDialog();
+        // The info stream followed the shared link's queue; follow the new default instead
+        if (window.openedLink) {
+            window.leaveLink();
+            window.stopInfoStream();
+            window.startInfoStream();
+        }
+        // A tail follows the queue it was opened on; reopen it on the new one
+        const tailing = window.isTailing();
+        if (tailing) window.stopMessageTail();
         window.clearMessageUI({ clearAll: true });
+        if (tailing) window.startMessageTail();
+        window.loadDepthHistory();
         await fetchInfo();
     } catch (err) {
         statusEl.textContent = `Failed to update queue: ${err.message}`;
@@ -10,8 +10,17 @@ window.renderQueueInfo = function renderQueueInfo(info) {
     { label: 'Queue Name', value: info.queue_name || '-' },
     { label: 'Queue URL', value: info.queue_url || '-' },
     { label: 'Total Messages', value: info.number_of_messages ?? '-' },
+    { label: 'Attributes Age', value: info.attributes_age_seconds != null ? info.attributes_age_seconds + 's' : '-' },
     { label: 'Status', value: info.status || '-' },
   ];
+  if (info.observe_only_lock) {
+    const lock = info.observe_only_lock;
+    lines.push({ label: 'Observe-only', value: `locked by ${lock.by}: ${lock.reason}` });
+  }
+  // Purging or redriving a DLQ affects the queues feeding it
+  if (info.is_dead_letter_queue) {
+    lines.splice(3, 0, { label: 'DLQ For', value: info.dead_letter_sources.join(', ') });
+  }
 
   const formatted = lines
     .map(({ label, value }) => label.padEnd(16, ' ') + ': ' + value)
@@ -21,6 +30,32 @@ window.renderQueueInfo = function renderQueueInfo(info) {
   infoOut.innerHTML = `<pre class="bg-gray-800 text-gray-200 rounded p-2 text-left font-mono overflow-auto whitespace-pre leading-snug break-all">${escapeHTML(formatted)}</pre>`;
 };
 
+// Render queue depth history as an inline SVG sparkline (visible + in flight)
+window.renderDepthSparkline = function renderDepthSparkline(history) {
+  const out = document.getElementById('depthOut');
+  if (!out) return;
+  const samples = (history && history.samples) || [];
+  if (samples.length < 2) {
+    out.innerHTML = '<p class="text-gray-400 italic text-xs">Collecting depth history…</p>';
+    return;
+  }
+
+  const totals = samples.map((s) => (s.visible || 0) + (s.not_visible || 0));
+  const peak = Math.max(1, ...totals);
+  const width = 300;
+  const height = 40;
+  const step = width / (totals.length - 1);
+  const points = totals
+    .map((v, i) => `${(i * step).toFixed(1)},${(height - (v / peak) * (height - 2) - 1).toFixed(1)}`)
+    .join(' ');
+  const minutes = Math.round(history.window_seconds / 60);
+
+  out.innerHTML =
+    `<svg viewBox="0 0 ${width} ${height}" class="w-full h-10" preserveAspectRatio="none" role="img" aria-label="Queue depth">` +
+    `<polyline fill="none" stroke="#3b82f6" stroke-width="1.5" points="${points}" /></svg>` +
+    `<p class="text-gray-500 text-xs">Depth over the last ${minutes} min · now ${totals[totals.length - 1]} · peak ${peak}</p>`;
+};
+
 // Render messages list
 window.renderMessages = function renderMessages(data) {
   const msgOut = document.getElementById('msgOut');
@@ -0,0 +1,134 @@
+'use strict';
+
+// Live queue info over server-sent events (replaces manual re-fetching)
+let infoStream = null;
+
+let infoPollTimer = null;
+
+// Without EventSource, poll /info at the interval the server suggests
+function pollInfo() {
+    infoPollTimer = setTimeout(async () => {
+        await window.fetchInfo();
+        pollInfo();
+    }, (window.pollIntervalSeconds || 30) * 1000);
+}
+
+window.startInfoStream = function startInfoStream() {
+    if (infoStream || infoPollTimer) return;
+    if (!window.EventSource) {
+        pollInfo();
+        return;
+    }
+
+    infoStream = new EventSource(window.apiURL(linkedPath('/api/info/stream')), { withCredentials: !!window.apiBase });
+    infoStream.addEventListener('info', (ev) => {
+        let info;
+        try {
+            info = JSON.parse(ev.data);
+        } catch {
+            return;
+        }
+        // Only refresh the counters panel; errors are surfaced by explicit fetches
+        if (info && info.status === 'ok') {
+            window.renderQueueInfo(info);
+        }
+    });
+    // EventSource reconnects on its own; nothing to do on error
+};
+
+window.stopInfoStream = function stopInfoStream() {
+    clearTimeout(infoPollTimer);
+    infoPollTimer = null;
+    if (!infoStream) return;
+    infoStream.close();
+    infoStream = null;
+};
+
+// Live tail: messages arriving on the queue are pushed over /api/messages/stream
+let messageTail = null;
+const tailedMessages = [];
+const maxTailedMessages = 200;
+
+function renderTail(status) {
+    const msgOut = document.getElementById('msgOut');
+    if (!msgOut) return;
+    const header = `<p class="text-gray-600 mb-2 text-left">${escapeHTML(status)}</p>`;
+    if (tailedMessages.length === 0) {
+        msgOut.innerHTML = header + '<p class="text-gray-500 italic">Waiting for new messages…</p>';
+        return;
+    }
+    msgOut.innerHTML = header +
+        `<pre class="bg-gray-800 text-gray-200 rounded p-3 text-left overflow-auto whitespace-pre-wrap break-words text-sm leading-snug">${escapeHTML(JSON.stringify(tailedMessages, null, 2))}</pre>`;
+}
+
+window.isTailing = () => messageTail !== null;
+
+function syncTailButton() {
+    const btn = document.getElementById('tailMessagesBtn');
+    if (btn) btn.textContent = window.isTailing() ? 'Stop Tail' : 'Live Tail';
+}
+
+// EventSource doesn't expose why a stream was refused, so ask again with fetch. A queue that
+// dead-letters after a few receives is refused until the user accepts that each poll counts.
+async function explainTailRefusal(path) {
+    const ctrl = new AbortController();
+    try {
+        // Accepted this time: drop the response rather than hold a second stream open
+        await window.api(path, { signal: ctrl.signal, onResponse: (res) => res.ok && ctrl.abort() });
+    } catch (err) {
+        if (err.name === 'AbortError') {
+            window.showToast('Live tail: the connection was refused; try again', 'error');
+        } else if (err.code === 'tail_redrive_risk') {
+            if (await window.confirmDialog(`${err.message}\n\nTail anyway?`)) window.startMessageTail({ allowRedrive: true });
+        } else {
+            window.showToast(`Live tail: ${err.message}`, 'error');
+        }
+    }
+    syncTailButton();
+}
+
+window.startMessageTail = function startMessageTail({ allowRedrive = false } = {}) {
+    if (messageTail || !window.EventSource) return;
+    tailedMessages.length = 0;
+    const path = linkedPath('/api/messages/stream' + (allowRedrive ? '?allow_redrive=true' : ''));
+    messageTail = new EventSource(window.apiURL(path), { withCredentials: !!window.apiBase });
+    renderTail('Live tail: connecting…');
+
+    messageTail.addEventListener('open', () => renderTail(`Live tail: ${tailedMessages.length} new message(s)`));
+    messageTail.addEventListener('message', (ev) => {
+        let msg;
+        try {
+            msg = JSON.parse(ev.data);
+        } catch {
+            return;
+        }
+        // Newest first, bounded so a busy queue doesn't grow the page forever
+        tailedMessages.unshift(msg);
+        tailedMessages.length = Math.min(tailedMessages.length, maxTailedMessages);
+        renderTail(`Live tail: ${tailedMessages.length} new message(s)`);
+    });
+    // Receive failures arrive as "error" events with a body; connection drops have none, and
+    // a refused stream is closed for good
+    messageTail.addEventListener('error', (ev) => {
+        if (!ev.data) {
+            if (messageTail && messageTail.readyState === EventSource.CLOSED) {
+                window.stopMessageTail();
+                explainTailRefusal(path);
+            }
+            return;
+        }
+        try {
+            const err = JSON.parse(ev.data);
+            window.showToast(`Live tail: ${err.message}`, 'error');
+        } catch {
+            // ignore malformed error events
+        }
+    });
+};
+
+window.stopMessageTail = function stopMessageTail() {
+    if (!messageTail) return;
+    messageTail.close();
+    messageTail = null;
+    renderTail(`Live tail stopped: ${tailedMessages.length} message(s)`);
+};